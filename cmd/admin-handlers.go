@@ -214,6 +214,8 @@ type ServerInfoData struct {
 	ConnStats   ServerConnStats  `json:"network"`
 	HTTPStats   ServerHTTPStats  `json:"http"`
 	Properties  ServerProperties `json:"server"`
+	// Disks of the server being healed in the background.
+	Healing []madmin.HealingDisk `json:"healing,omitempty"`
 }
 
 // ServerInfo holds server information result of one node
@@ -401,6 +403,183 @@ func (a adminAPIHandlers) DownloadProfilingHandler(w http.ResponseWriter, r *htt
 	}
 }
 
+// TraceHandler - GET /minio/admin/v1/trace?errors={bool}&api={api}&bucket={bucket}&body={bool}&internal={bool}
+// ----------
+// Streams the HTTP trace records of all nodes as JSON objects, until the
+// client disconnects. Records can be restricted to failed requests, to
+// an API or to a bucket, request and response bodies are only sent on
+// demand.
+func (a adminAPIHandlers) TraceHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	opts, err := parseTraceOptions(r.URL.Query())
+	if err != nil {
+		writeErrorResponseJSON(w, ErrAdminInvalidArgument, r.URL)
+		return
+	}
+
+	traceCh := make(chan interface{}, traceChannelSize)
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	subscribeTrace(traceCh, doneCh, opts)
+	if globalNotificationSys != nil {
+		globalNotificationSys.Trace(traceCh, doneCh, opts)
+	}
+
+	writeTraceStream(w, r, traceCh, opts)
+}
+
+// ConsoleLogHandler - GET /minio/admin/v1/log?level={level}&node={node}&limit={limit}
+// ----------
+// Streams the log entries of all nodes as JSON objects, until the client
+// disconnects. The last entries of each node are sent first. Entries can
+// be restricted to a level and to a node.
+func (a adminAPIHandlers) ConsoleLogHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	opts, err := parseConsoleLogOptions(r.URL.Query())
+	if err != nil {
+		writeErrorResponseJSON(w, ErrAdminInvalidArgument, r.URL)
+		return
+	}
+
+	logCh := make(chan interface{}, consoleLogChannelSize)
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	subscribeConsoleLog(logCh, doneCh, opts)
+	if globalNotificationSys != nil {
+		globalNotificationSys.ConsoleLog(logCh, doneCh, opts)
+	}
+
+	writeConsoleLogStream(w, r, logCh)
+}
+
+// extractLocksParams - validates the namespace locks selection of the
+// ListLocks and ClearLocks APIs.
+func extractLocksParams(r *http.Request) (bucket, prefix string, olderThan time.Duration, err APIErrorCode) {
+	queryVal := r.URL.Query()
+	bucket, prefix = queryVal.Get("bucket"), queryVal.Get("prefix")
+	if bucket != "" && bucket != minioMetaBucket && !IsValidBucketName(bucket) {
+		return "", "", 0, ErrInvalidBucketName
+	}
+	if bucket == "" && prefix != "" {
+		return "", "", 0, ErrAdminInvalidArgument
+	}
+	if value := queryVal.Get("older-than"); value != "" {
+		var perr error
+		if olderThan, perr = time.ParseDuration(value); perr != nil || olderThan < 0 {
+			return "", "", 0, ErrAdminInvalidArgument
+		}
+	}
+	return bucket, prefix, olderThan, ErrNone
+}
+
+// getPeersLocks - lists, or clears when clear is set, the namespace locks
+// of all the peers. Unreachable peers are logged and skipped.
+func getPeersLocks(peers adminPeers, clear bool, bucket, prefix string, olderThan time.Duration) []madmin.VolumeLockInfo {
+	peerLocks := make([][]madmin.VolumeLockInfo, len(peers))
+
+	var wg sync.WaitGroup
+	for i, p := range peers {
+		wg.Add(1)
+		go func(idx int, peer adminPeer) {
+			defer wg.Done()
+
+			var volLocks []madmin.VolumeLockInfo
+			var err error
+			if clear {
+				volLocks, err = peer.cmdRunner.ClearLocks(bucket, prefix, olderThan)
+			} else {
+				volLocks, err = peer.cmdRunner.ListLocks(bucket, prefix, olderThan)
+			}
+			if err != nil {
+				reqInfo := (&logger.ReqInfo{}).AppendTags("peerAddress", peer.addr)
+				ctx := logger.SetReqInfo(context.Background(), reqInfo)
+				logger.LogIf(ctx, err)
+				return
+			}
+			for j := range volLocks {
+				volLocks[j].Node = peer.addr
+			}
+			peerLocks[idx] = volLocks
+		}(i, p)
+	}
+	wg.Wait()
+
+	volLocks := []madmin.VolumeLockInfo{}
+	for _, locks := range peerLocks {
+		volLocks = append(volLocks, locks...)
+	}
+	return volLocks
+}
+
+// writeLocksResponse - writes the namespace locks as JSON.
+func writeLocksResponse(w http.ResponseWriter, r *http.Request, volLocks []madmin.VolumeLockInfo) {
+	jsonBytes, err := json.Marshal(volLocks)
+	if err != nil {
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		logger.LogIf(context.Background(), err)
+		return
+	}
+
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// ListLocksHandler - GET /minio/admin/v1/locks?bucket={bucket}&prefix={prefix}&older-than={duration}
+// ----------
+// Lists the namespace locks held or waited for on the objects under
+// bucket/prefix for longer than the given duration, on all the nodes.
+// All the locks are listed when no bucket is given.
+func (a adminAPIHandlers) ListLocksHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	bucket, prefix, olderThan, errCode := extractLocksParams(r)
+	if errCode != ErrNone {
+		writeErrorResponseJSON(w, errCode, r.URL)
+		return
+	}
+
+	writeLocksResponse(w, r, getPeersLocks(globalAdminPeers, false, bucket, prefix, olderThan))
+}
+
+// ClearLocksHandler - DELETE /minio/admin/v1/locks?bucket={bucket}&prefix={prefix}&older-than={duration}
+// ----------
+// Forcefully unlocks the objects under bucket/prefix having namespace
+// locks held or waited for longer than the given duration, on all the
+// nodes, and replies with the cleared locks. A bucket is mandatory.
+func (a adminAPIHandlers) ClearLocksHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	bucket, prefix, olderThan, errCode := extractLocksParams(r)
+	if errCode == ErrNone && bucket == "" {
+		errCode = ErrInvalidBucketName
+	}
+	if errCode != ErrNone {
+		writeErrorResponseJSON(w, errCode, r.URL)
+		return
+	}
+
+	writeLocksResponse(w, r, getPeersLocks(globalAdminPeers, true, bucket, prefix, olderThan))
+}
+
 // extractHealInitParams - Validates params for heal init API.
 func extractHealInitParams(r *http.Request) (bucket, objPrefix string,
 	hs madmin.HealOpts, clientToken string, forceStart bool, forceStop bool,
@@ -787,6 +966,46 @@ func (a adminAPIHandlers) RemoveUser(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// CachePendingUploadsHandler - GET /minio/admin/v1/cache/pending
+// ----------
+// Lists the objects committed to the disk cache in writeback mode and not
+// uploaded to the backend yet.
+func (a adminAPIHandlers) CachePendingUploadsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "CachePendingUploads")
+
+	// Get current object layer instance.
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	pending := []madmin.CachePendingUpload{}
+	if cacheAPI := newCacheObjectsFn(); cacheAPI != nil {
+		var err error
+		if pending, err = cacheAPI.PendingUploads(ctx); err != nil {
+			writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+			return
+		}
+	}
+
+	data, err := json.Marshal(pending)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		return
+	}
+
+	writeSuccessResponseJSON(w, data)
+}
+
 // ListUsers - GET /minio/admin/v1/list-users
 func (a adminAPIHandlers) ListUsers(w http.ResponseWriter, r *http.Request) {
 	ctx := newContext(r, w, "ListUsers")
@@ -931,6 +1150,74 @@ func (a adminAPIHandlers) AddUser(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// RotateUserSecret - PUT /minio/admin/v1/rotate-user-secret?accessKey=<access_key>&gracePeriod=<duration>
+// The response holds the encrypted credentials with the new secret key, the
+// previous secret key is still accepted during the grace period.
+func (a adminAPIHandlers) RotateUserSecret(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "RotateUserSecret")
+
+	// Get current object layer instance.
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Deny if WORM is enabled
+	if globalWORMEnabled {
+		writeErrorResponseJSON(w, ErrMethodNotAllowed, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	accessKey := vars["accessKey"]
+
+	gracePeriod, err := time.ParseDuration(vars["gracePeriod"])
+	if err != nil {
+		writeErrorResponseJSON(w, ErrAdminInvalidArgument, r.URL)
+		return
+	}
+
+	cred, err := globalIAMSys.RotateUserSecret(accessKey, gracePeriod)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	rotation := madmin.UserSecretRotation{
+		AccessKey: cred.AccessKey,
+		SecretKey: cred.SecretKey,
+	}
+	if cred.PrevSecretKey != nil {
+		rotation.PrevSecretKeyExpiration = cred.PrevSecretKey.Expiration
+	}
+
+	data, err := json.Marshal(rotation)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		return
+	}
+
+	password := globalServerConfig.GetCredential().SecretKey
+	econfigData, err := madmin.EncryptData(password, data)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		return
+	}
+
+	writeSuccessResponseJSON(w, econfigData)
+}
+
 // ListCannedPolicies - GET /minio/admin/v1/list-canned-policies
 func (a adminAPIHandlers) ListCannedPolicies(w http.ResponseWriter, r *http.Request) {
 	ctx := newContext(r, w, "ListCannedPolicies")
@@ -1097,6 +1384,537 @@ func (a adminAPIHandlers) SetUserPolicy(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// GetUserPolicies - GET /minio/admin/v1/user-policies?accessKey=<access_key>
+func (a adminAPIHandlers) GetUserPolicies(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "GetUserPolicies")
+
+	// Get current object layer instance.
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	accessKey := vars["accessKey"]
+
+	policies, err := globalIAMSys.GetUserPolicies(accessKey)
+	if err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	body, err := json.Marshal(policies)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		return
+	}
+
+	writeSuccessResponseJSON(w, body)
+}
+
+// AttachUserPolicy - PUT /minio/admin/v1/attach-user-policy?accessKey=<access_key>&name=<policy_name>
+func (a adminAPIHandlers) AttachUserPolicy(w http.ResponseWriter, r *http.Request) {
+	a.updateUserPolicies(w, r, "AttachUserPolicy", globalIAMSys.AttachUserPolicy)
+}
+
+// DetachUserPolicy - PUT /minio/admin/v1/detach-user-policy?accessKey=<access_key>&name=<policy_name>
+func (a adminAPIHandlers) DetachUserPolicy(w http.ResponseWriter, r *http.Request) {
+	a.updateUserPolicies(w, r, "DetachUserPolicy", globalIAMSys.DetachUserPolicy)
+}
+
+// updateUserPolicies - attaches or detaches the canned policy of the
+// request to or from the user of the request with update.
+func (a adminAPIHandlers) updateUserPolicies(w http.ResponseWriter, r *http.Request, api string,
+	update func(accessKey, policyName string) error) {
+	ctx := newContext(r, w, api)
+
+	// Get current object layer instance.
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	accessKey := vars["accessKey"]
+	policyName := vars["name"]
+
+	// Validate request signature.
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Deny if WORM is enabled
+	if globalWORMEnabled {
+		writeErrorResponseJSON(w, ErrMethodNotAllowed, r.URL)
+		return
+	}
+
+	// Custom IAM policies not allowed for admin user.
+	if accessKey == globalServerConfig.GetCredential().AccessKey {
+		writeErrorResponseJSON(w, ErrInvalidRequest, r.URL)
+		return
+	}
+
+	if err := update(accessKey, policyName); err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+	}
+}
+
+// SetUserInlinePolicy - PUT /minio/admin/v1/set-user-inline-policy?accessKey=<access_key>
+func (a adminAPIHandlers) SetUserInlinePolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SetUserInlinePolicy")
+
+	// Get current object layer instance.
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	accessKey := vars["accessKey"]
+
+	// Validate request signature.
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Deny if WORM is enabled
+	if globalWORMEnabled {
+		writeErrorResponseJSON(w, ErrMethodNotAllowed, r.URL)
+		return
+	}
+
+	// Custom IAM policies not allowed for admin user.
+	if accessKey == globalServerConfig.GetCredential().AccessKey {
+		writeErrorResponseJSON(w, ErrInvalidRequest, r.URL)
+		return
+	}
+
+	// Error out if Content-Length is missing.
+	if r.ContentLength <= 0 {
+		writeErrorResponseJSON(w, ErrMissingContentLength, r.URL)
+		return
+	}
+
+	// Error out if Content-Length is beyond allowed size.
+	if r.ContentLength > maxBucketPolicySize {
+		writeErrorResponseJSON(w, ErrEntityTooLarge, r.URL)
+		return
+	}
+
+	iamPolicy, err := iampolicy.ParseConfig(io.LimitReader(r.Body, r.ContentLength))
+	if err != nil {
+		writeErrorResponseJSON(w, ErrMalformedPolicy, r.URL)
+		return
+	}
+
+	// Version in policy must not be empty
+	if iamPolicy.Version == "" {
+		writeErrorResponseJSON(w, ErrMalformedPolicy, r.URL)
+		return
+	}
+
+	if err = globalIAMSys.SetUserInlinePolicy(accessKey, iamPolicy); err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+	}
+}
+
+// RemoveUserInlinePolicy - DELETE /minio/admin/v1/remove-user-inline-policy?accessKey=<access_key>
+func (a adminAPIHandlers) RemoveUserInlinePolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "RemoveUserInlinePolicy")
+
+	// Get current object layer instance.
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Deny if WORM is enabled
+	if globalWORMEnabled {
+		writeErrorResponseJSON(w, ErrMethodNotAllowed, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	accessKey := vars["accessKey"]
+
+	if err := globalIAMSys.SetUserInlinePolicy(accessKey, nil); err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+	}
+}
+
+// UpdateGroupMembers - PUT /minio/admin/v1/update-group-members
+// ----------
+// Adds or removes the members of a group. The group is created when members
+// are added to it, and removed by a removal request listing no members,
+// provided that the group is empty.
+func (a adminAPIHandlers) UpdateGroupMembers(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "UpdateGroupMembers")
+
+	// Get current object layer instance.
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Deny if WORM is enabled
+	if globalWORMEnabled {
+		writeErrorResponseJSON(w, ErrMethodNotAllowed, r.URL)
+		return
+	}
+
+	if r.ContentLength > maxEConfigJSONSize || r.ContentLength == -1 {
+		// More than maxConfigSize bytes were available
+		writeErrorResponseJSON(w, ErrAdminConfigTooLarge, r.URL)
+		return
+	}
+
+	var updReq madmin.GroupAddRemove
+	if err := json.NewDecoder(io.LimitReader(r.Body, r.ContentLength)).Decode(&updReq); err != nil {
+		writeErrorResponseJSON(w, ErrAdminConfigBadJSON, r.URL)
+		return
+	}
+
+	var err error
+	if updReq.IsRemove {
+		err = globalIAMSys.RemoveUsersFromGroup(updReq.Group, updReq.Members)
+	} else {
+		err = globalIAMSys.AddUsersToGroup(updReq.Group, updReq.Members)
+	}
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+}
+
+// GetGroup - GET /minio/admin/v1/group?group=<group>
+func (a adminAPIHandlers) GetGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "GetGroup")
+
+	// Get current object layer instance.
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	group := vars["group"]
+
+	gdesc, err := globalIAMSys.GetGroupDescription(group)
+	if err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	body, err := json.Marshal(gdesc)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		return
+	}
+
+	writeSuccessResponseJSON(w, body)
+}
+
+// ListGroups - GET /minio/admin/v1/groups
+func (a adminAPIHandlers) ListGroups(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ListGroups")
+
+	// Get current object layer instance.
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	groups, err := globalIAMSys.ListGroups()
+	if err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	body, err := json.Marshal(groups)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		return
+	}
+
+	writeSuccessResponseJSON(w, body)
+}
+
+// SetGroupPolicy - PUT /minio/admin/v1/set-group-policy?group=<group>&name=<policy_name>
+func (a adminAPIHandlers) SetGroupPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "SetGroupPolicy")
+
+	// Get current object layer instance.
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Deny if WORM is enabled
+	if globalWORMEnabled {
+		writeErrorResponseJSON(w, ErrMethodNotAllowed, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	group := vars["group"]
+	policyName := vars["name"]
+
+	if err := globalIAMSys.SetGroupPolicy(group, policyName); err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+	}
+}
+
+// AddServiceAccount - PUT /minio/admin/v1/add-service-account?user=<parent_user>
+// The optional body is the policy narrowing the permissions of the
+// service account, the response holds its encrypted credentials.
+func (a adminAPIHandlers) AddServiceAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "AddServiceAccount")
+
+	// Get current object layer instance.
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Deny if WORM is enabled
+	if globalWORMEnabled {
+		writeErrorResponseJSON(w, ErrMethodNotAllowed, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	parentUser := vars["user"]
+
+	// Error out if Content-Length is beyond allowed size.
+	if r.ContentLength > maxBucketPolicySize {
+		writeErrorResponseJSON(w, ErrEntityTooLarge, r.URL)
+		return
+	}
+
+	var iamPolicy *iampolicy.Policy
+	if r.ContentLength > 0 {
+		var err error
+		iamPolicy, err = iampolicy.ParseConfig(io.LimitReader(r.Body, r.ContentLength))
+		// Version in policy must not be empty
+		if err != nil || iamPolicy.Version == "" {
+			writeErrorResponseJSON(w, ErrMalformedPolicy, r.URL)
+			return
+		}
+	}
+
+	cred, err := globalIAMSys.NewServiceAccount(parentUser, iamPolicy)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	writeServiceAccountCreds(ctx, w, r, cred)
+}
+
+// ListServiceAccounts - GET /minio/admin/v1/list-service-accounts?user=<parent_user>
+func (a adminAPIHandlers) ListServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "ListServiceAccounts")
+
+	// Get current object layer instance.
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	parentUser := vars["user"]
+
+	serviceAccounts, err := globalIAMSys.ListServiceAccounts(parentUser)
+	if err != nil {
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	body, err := json.Marshal(serviceAccounts)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		return
+	}
+
+	writeSuccessResponseJSON(w, body)
+}
+
+// RotateServiceAccount - PUT /minio/admin/v1/rotate-service-account?accessKey=<access_key>
+// The response holds the encrypted credentials with the new secret key.
+func (a adminAPIHandlers) RotateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "RotateServiceAccount")
+
+	// Get current object layer instance.
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Deny if WORM is enabled
+	if globalWORMEnabled {
+		writeErrorResponseJSON(w, ErrMethodNotAllowed, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	accessKey := vars["accessKey"]
+
+	cred, err := globalIAMSys.RotateServiceAccount(accessKey)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+		return
+	}
+
+	writeServiceAccountCreds(ctx, w, r, cred)
+}
+
+// RemoveServiceAccount - DELETE /minio/admin/v1/remove-service-account?accessKey=<access_key>
+func (a adminAPIHandlers) RemoveServiceAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "RemoveServiceAccount")
+
+	// Get current object layer instance.
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeErrorResponseJSON(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkAdminRequestAuthType(r, "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponseJSON(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Deny if WORM is enabled
+	if globalWORMEnabled {
+		writeErrorResponseJSON(w, ErrMethodNotAllowed, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	accessKey := vars["accessKey"]
+
+	if err := globalIAMSys.DeleteServiceAccount(accessKey); err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, toAdminAPIErrCode(err), r.URL)
+	}
+}
+
+// writeServiceAccountCreds - writes the credentials of a service account
+// encrypted with the admin secret key.
+func writeServiceAccountCreds(ctx context.Context, w http.ResponseWriter, r *http.Request, cred auth.Credentials) {
+	data, err := json.Marshal(madmin.ServiceAccountCreds{
+		AccessKey: cred.AccessKey,
+		SecretKey: cred.SecretKey,
+	})
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		return
+	}
+
+	password := globalServerConfig.GetCredential().SecretKey
+	econfigData, err := madmin.EncryptData(password, data)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeErrorResponseJSON(w, ErrInternalError, r.URL)
+		return
+	}
+
+	writeSuccessResponseJSON(w, econfigData)
+}
+
 // SetConfigHandler - PUT /minio/admin/v1/config
 func (a adminAPIHandlers) SetConfigHandler(w http.ResponseWriter, r *http.Request) {
 	ctx := newContext(r, w, "SetConfigHandler")
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -32,6 +33,7 @@ import (
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/pydio/minio-srv/cmd/logger"
 	"github.com/pydio/minio-srv/pkg/auth"
 	"github.com/pydio/minio-srv/pkg/madmin"
 )
@@ -848,6 +850,179 @@ func TestAdminServerInfo(t *testing.T) {
 	}
 }
 
+// TestAdminTraceHandler - tests streaming trace records with the admin client.
+func TestAdminTraceHandler(t *testing.T) {
+	adminTestBed, err := prepareAdminXLTestBed()
+	if err != nil {
+		t.Fatal("Failed to initialize a single node XL backend for admin handler tests.")
+	}
+	defer adminTestBed.TearDown()
+
+	server := httptest.NewServer(adminTestBed.router)
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred := globalServerConfig.GetCredential()
+	adminClient, err := madmin.New(u.Host, cred.AccessKey, cred.SecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	traceCh := adminClient.Trace(madmin.TraceOptions{API: "Version"}, doneCh)
+
+	// The trace listener is subscribed once the stream is started,
+	// keep sending requests until a trace record is received.
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case traceInfo, ok := <-traceCh:
+			if !ok {
+				t.Fatal("Trace stream ended unexpectedly")
+			}
+			if traceInfo.Err != nil {
+				t.Fatal(traceInfo.Err)
+			}
+			if traceInfo.Trace.FuncName != "Version" || traceInfo.Trace.ReqInfo.Path != "/minio/admin/version" ||
+				traceInfo.Trace.RespInfo.StatusCode != http.StatusOK {
+				t.Fatalf("Unexpected trace record %v", traceInfo.Trace)
+			}
+			return
+		case <-ticker.C:
+			if _, err = adminClient.VersionInfo(); err != nil {
+				t.Fatal(err)
+			}
+		case <-timeout:
+			t.Fatal("Timed out waiting for a trace record")
+		}
+	}
+}
+
+// Test for streaming log entries through the admin API.
+func TestAdminConsoleLogHandler(t *testing.T) {
+	adminTestBed, err := prepareAdminXLTestBed()
+	if err != nil {
+		t.Fatal("Failed to initialize a single node XL backend for admin handler tests.")
+	}
+	defer adminTestBed.TearDown()
+
+	server := httptest.NewServer(adminTestBed.router)
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred := globalServerConfig.GetCredential()
+	adminClient, err := madmin.New(u.Host, cred.AccessKey, cred.SecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Log an error to the recent log entries.
+	prevTargets := logger.Targets
+	logger.Targets = []logger.LoggingTarget{globalConsoleLog}
+	logger.Disable = false
+	logger.LogIf(context.Background(), errors.New("console log test error"))
+	logger.Disable = true
+	logger.Targets = prevTargets
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	logCh := adminClient.GetLogs(madmin.LogOptions{Level: "ERROR", Limit: 1}, doneCh)
+
+	select {
+	case logInfo, ok := <-logCh:
+		if !ok {
+			t.Fatal("Log stream ended unexpectedly")
+		}
+		if logInfo.Err != nil {
+			t.Fatal(logInfo.Err)
+		}
+		entry := logInfo.Entry
+		if entry.Level != "ERROR" || entry.Trace == nil || entry.Trace.Message != "console log test error" ||
+			entry.NodeName != GetLocalPeer(globalEndpoints) {
+			t.Fatalf("Unexpected log entry %v", entry)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for a log entry")
+	}
+
+	// Invalid options are rejected.
+	for logInfo := range adminClient.GetLogs(madmin.LogOptions{Level: "DEBUG"}, doneCh) {
+		if logInfo.Err == nil {
+			t.Fatal("Expected an error for an invalid log level")
+		}
+	}
+}
+
+// Test for listing and clearing namespace locks through the admin API.
+func TestAdminLocksHandler(t *testing.T) {
+	adminTestBed, err := prepareAdminXLTestBed()
+	if err != nil {
+		t.Fatal("Failed to initialize a single node XL backend for admin handler tests.")
+	}
+	defer adminTestBed.TearDown()
+
+	// Initialize admin peers to make admin RPC calls.
+	initGlobalAdminPeers(mustGetNewEndpointList("http://127.0.0.1:9000/d1"))
+
+	server := httptest.NewServer(adminTestBed.router)
+	defer server.Close()
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred := globalServerConfig.GetCredential()
+	adminClient, err := madmin.New(u.Host, cred.AccessKey, cred.SecretKey, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock := globalNSMutex.NewNSLock("mybucket", "myprefix/object")
+	if err = lock.GetRLock(newDynamicTimeout(time.Second, time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	volLocks, err := adminClient.ListLocks("mybucket", "myprefix", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(volLocks) != 1 || volLocks[0].Node != globalAdminPeers[0].addr ||
+		volLocks[0].Object != "myprefix/object" || volLocks[0].LockDetails[0].LockType != madmin.ReadLock {
+		t.Fatalf("Unexpected locks %v", volLocks)
+	}
+
+	if volLocks, err = adminClient.ClearLocks("mybucket", "myprefix", time.Hour); err != nil || len(volLocks) != 0 {
+		t.Fatalf("Expected no cleared locks, got %v, %v", volLocks, err)
+	}
+	if volLocks, err = adminClient.ClearLocks("mybucket", "myprefix", 0); err != nil || len(volLocks) != 1 {
+		t.Fatalf("Expected a cleared lock, got %v, %v", volLocks, err)
+	}
+	if volLocks, err = adminClient.ListLocks("", "", 0); err != nil || len(volLocks) != 0 {
+		t.Fatalf("Expected no locks, got %v, %v", volLocks, err)
+	}
+
+	// Clearing the locks of all the buckets is not allowed.
+	if _, err = adminClient.ClearLocks("", "", 0); err == nil {
+		t.Fatal("Expected clearing locks without a bucket to fail")
+	}
+	// Object prefixes require a bucket.
+	if _, err = adminClient.ListLocks("", "myprefix", 0); err == nil {
+		t.Fatal("Expected listing locks with a prefix and without a bucket to fail")
+	}
+	if _, err = adminClient.ListLocks("my_bucket", "", 0); err == nil {
+		t.Fatal("Expected listing locks of an invalid bucket to fail")
+	}
+}
+
 // TestToAdminAPIErr - test for toAdminAPIErr helper function.
 func TestToAdminAPIErr(t *testing.T) {
 	testCases := []struct {
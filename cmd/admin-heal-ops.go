@@ -577,16 +577,9 @@ func (h *healSequence) healConfig() error {
 	marker := ""
 	isTruncated := true
 	for isTruncated {
-		if globalHTTPServer != nil {
-			// Wait at max 1 minute for an inprogress request
-			// before proceeding to heal
-			waitCount := 60
-			// Any requests in progress, delay the heal.
-			for globalHTTPServer.GetRequestCount() > 2 && waitCount > 0 {
-				waitCount--
-				time.Sleep(1 * time.Second)
-			}
-		}
+		// Wait at max 1 minute for an inprogress request
+		// before proceeding to heal
+		waitForLowHTTPReq(time.Minute)
 
 		// Lists all objects under `config` prefix.
 		objectInfos, err := objectAPI.ListObjectsHeal(h.ctx, minioMetaBucket, minioConfigPrefix,
@@ -723,16 +716,9 @@ func (h *healSequence) healBucket(bucket string) error {
 	marker := ""
 	isTruncated := true
 	for isTruncated {
-		if globalHTTPServer != nil {
-			// Wait at max 1 minute for an inprogress request
-			// before proceeding to heal
-			waitCount := 60
-			// Any requests in progress, delay the heal.
-			for globalHTTPServer.GetRequestCount() > 2 && waitCount > 0 {
-				waitCount--
-				time.Sleep(1 * time.Second)
-			}
-		}
+		// Wait at max 1 minute for an inprogress request
+		// before proceeding to heal
+		waitForLowHTTPReq(time.Minute)
 
 		// Heal numCPU * nodes objects at a time.
 		objectInfos, err := objectAPI.ListObjectsHeal(h.ctx, bucket,
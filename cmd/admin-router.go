@@ -57,9 +57,9 @@ func registerAdminRouter(router *mux.Router) {
 		/// Heal operations
 
 		// Heal processing endpoint.
-		adminV1Router.Methods(http.MethodPost).Path("/heal/").HandlerFunc(httpTraceAll(adminAPI.HealHandler))
-		adminV1Router.Methods(http.MethodPost).Path("/heal/{bucket}").HandlerFunc(httpTraceAll(adminAPI.HealHandler))
-		adminV1Router.Methods(http.MethodPost).Path("/heal/{bucket}/{prefix:.*}").HandlerFunc(httpTraceAll(adminAPI.HealHandler))
+		adminV1Router.Methods(http.MethodPost).Path("/heal/").HandlerFunc(httpTraceHdrs(adminAPI.HealHandler))
+		adminV1Router.Methods(http.MethodPost).Path("/heal/{bucket}").HandlerFunc(httpTraceHdrs(adminAPI.HealHandler))
+		adminV1Router.Methods(http.MethodPost).Path("/heal/{bucket}/{prefix:.*}").HandlerFunc(httpTraceHdrs(adminAPI.HealHandler))
 	}
 
 	// Profiling operations
@@ -67,6 +67,16 @@ func registerAdminRouter(router *mux.Router) {
 		Queries("profilerType", "{profilerType:.*}")
 	adminV1Router.Methods(http.MethodGet).Path("/profiling/download").HandlerFunc(httpTraceAll(adminAPI.DownloadProfilingHandler))
 
+	// HTTP trace, trace streams are not traced themselves.
+	adminV1Router.Methods(http.MethodGet).Path("/trace").HandlerFunc(adminAPI.TraceHandler)
+
+	// Console log, log streams are not traced either.
+	adminV1Router.Methods(http.MethodGet).Path("/log").HandlerFunc(adminAPI.ConsoleLogHandler)
+
+	// Namespace locks
+	adminV1Router.Methods(http.MethodGet).Path("/locks").HandlerFunc(httpTraceAll(adminAPI.ListLocksHandler))
+	adminV1Router.Methods(http.MethodDelete).Path("/locks").HandlerFunc(httpTraceAll(adminAPI.ClearLocksHandler))
+
 	/// Config operations
 
 	// Update credentials
@@ -81,6 +91,11 @@ func registerAdminRouter(router *mux.Router) {
 	// Set config keys/values
 	adminV1Router.Methods(http.MethodPut).Path("/config-keys").HandlerFunc(httpTraceHdrs(adminAPI.SetConfigKeysHandler))
 
+	// -- Cache APIs --
+
+	// List the uploads pending in the disk cache
+	adminV1Router.Methods(http.MethodGet).Path("/cache/pending").HandlerFunc(httpTraceAll(adminAPI.CachePendingUploadsHandler))
+
 	// -- IAM APIs --
 
 	// Add policy IAM
@@ -92,16 +107,47 @@ func registerAdminRouter(router *mux.Router) {
 		Queries("accessKey", "{accessKey:.*}").Queries("name", "{name:.*}")
 	adminV1Router.Methods(http.MethodPut).Path("/set-user-status").HandlerFunc(httpTraceHdrs(adminAPI.SetUserStatus)).
 		Queries("accessKey", "{accessKey:.*}").Queries("status", "{status:.*}")
+	adminV1Router.Methods(http.MethodPut).Path("/attach-user-policy").HandlerFunc(httpTraceHdrs(adminAPI.AttachUserPolicy)).
+		Queries("accessKey", "{accessKey:.*}").Queries("name", "{name:.*}")
+	adminV1Router.Methods(http.MethodPut).Path("/detach-user-policy").HandlerFunc(httpTraceHdrs(adminAPI.DetachUserPolicy)).
+		Queries("accessKey", "{accessKey:.*}").Queries("name", "{name:.*}")
+	adminV1Router.Methods(http.MethodPut).Path("/set-user-inline-policy").HandlerFunc(httpTraceHdrs(adminAPI.SetUserInlinePolicy)).
+		Queries("accessKey", "{accessKey:.*}")
+	adminV1Router.Methods(http.MethodGet).Path("/user-policies").HandlerFunc(httpTraceHdrs(adminAPI.GetUserPolicies)).
+		Queries("accessKey", "{accessKey:.*}")
+	adminV1Router.Methods(http.MethodPut).Path("/rotate-user-secret").HandlerFunc(httpTraceHdrs(adminAPI.RotateUserSecret)).
+		Queries("accessKey", "{accessKey:.*}").Queries("gracePeriod", "{gracePeriod:.*}")
+
+	// Group IAM
+	adminV1Router.Methods(http.MethodPut).Path("/update-group-members").HandlerFunc(httpTraceHdrs(adminAPI.UpdateGroupMembers))
+	adminV1Router.Methods(http.MethodPut).Path("/set-group-policy").HandlerFunc(httpTraceHdrs(adminAPI.SetGroupPolicy)).
+		Queries("group", "{group:.*}").Queries("name", "{name:.*}")
+	adminV1Router.Methods(http.MethodGet).Path("/group").HandlerFunc(httpTraceHdrs(adminAPI.GetGroup)).Queries("group", "{group:.*}")
+
+	// Service accounts IAM
+	adminV1Router.Methods(http.MethodPut).Path("/add-service-account").HandlerFunc(httpTraceHdrs(adminAPI.AddServiceAccount)).
+		Queries("user", "{user:.*}")
+	adminV1Router.Methods(http.MethodGet).Path("/list-service-accounts").HandlerFunc(httpTraceHdrs(adminAPI.ListServiceAccounts)).
+		Queries("user", "{user:.*}")
+	adminV1Router.Methods(http.MethodPut).Path("/rotate-service-account").HandlerFunc(httpTraceHdrs(adminAPI.RotateServiceAccount)).
+		Queries("accessKey", "{accessKey:.*}")
+	adminV1Router.Methods(http.MethodDelete).Path("/remove-service-account").HandlerFunc(httpTraceHdrs(adminAPI.RemoveServiceAccount)).
+		Queries("accessKey", "{accessKey:.*}")
 
 	// Remove policy IAM
 	adminV1Router.Methods(http.MethodDelete).Path("/remove-canned-policy").HandlerFunc(httpTraceHdrs(adminAPI.RemoveCannedPolicy)).Queries("name", "{name:.*}")
 
 	// Remove user IAM
 	adminV1Router.Methods(http.MethodDelete).Path("/remove-user").HandlerFunc(httpTraceHdrs(adminAPI.RemoveUser)).Queries("accessKey", "{accessKey:.*}")
+	adminV1Router.Methods(http.MethodDelete).Path("/remove-user-inline-policy").HandlerFunc(httpTraceHdrs(adminAPI.RemoveUserInlinePolicy)).
+		Queries("accessKey", "{accessKey:.*}")
 
 	// List users
 	adminV1Router.Methods(http.MethodGet).Path("/list-users").HandlerFunc(httpTraceHdrs(adminAPI.ListUsers))
 
+	// List groups
+	adminV1Router.Methods(http.MethodGet).Path("/groups").HandlerFunc(httpTraceHdrs(adminAPI.ListGroups))
+
 	// List policies
 	adminV1Router.Methods(http.MethodGet).Path("/list-canned-policies").HandlerFunc(httpTraceHdrs(adminAPI.ListCannedPolicies))
 
@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/pydio/minio-srv/cmd/logger"
+	"github.com/pydio/minio-srv/pkg/madmin"
 	xnet "github.com/pydio/minio-srv/pkg/net"
 )
 
@@ -84,6 +85,24 @@ func (rpcClient *AdminRPCClient) DownloadProfilingData() ([]byte, error) {
 	return reply, err
 }
 
+// ListLocks - lists the namespace locks of the remote server.
+func (rpcClient *AdminRPCClient) ListLocks(bucket, prefix string, olderThan time.Duration) ([]madmin.VolumeLockInfo, error) {
+	args := LocksArgs{Bucket: bucket, Prefix: prefix, OlderThan: olderThan}
+	var reply []madmin.VolumeLockInfo
+
+	err := rpcClient.Call(adminServiceName+".ListLocks", &args, &reply)
+	return reply, err
+}
+
+// ClearLocks - forcefully unlocks the namespace locks of the remote server.
+func (rpcClient *AdminRPCClient) ClearLocks(bucket, prefix string, olderThan time.Duration) ([]madmin.VolumeLockInfo, error) {
+	args := LocksArgs{Bucket: bucket, Prefix: prefix, OlderThan: olderThan}
+	var reply []madmin.VolumeLockInfo
+
+	err := rpcClient.Call(adminServiceName+".ClearLocks", &args, &reply)
+	return reply, err
+}
+
 // NewAdminRPCClient - returns new admin RPC client.
 func NewAdminRPCClient(host *xnet.Host) (*AdminRPCClient, error) {
 	scheme := "http"
@@ -130,6 +149,8 @@ type adminCmdRunner interface {
 	GetConfig() ([]byte, error)
 	StartProfiling(string) error
 	DownloadProfilingData() ([]byte, error)
+	ListLocks(bucket, prefix string, olderThan time.Duration) ([]madmin.VolumeLockInfo, error)
+	ClearLocks(bucket, prefix string, olderThan time.Duration) ([]madmin.VolumeLockInfo, error)
 }
 
 // adminPeer - represents an entity that implements admin API RPCs.
@@ -18,10 +18,12 @@ package cmd
 
 import (
 	"path"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/pydio/minio-srv/cmd/logger"
 	xrpc "github.com/pydio/minio-srv/cmd/rpc"
+	"github.com/pydio/minio-srv/pkg/madmin"
 )
 
 const adminServiceName = "Admin"
@@ -85,6 +87,27 @@ func (receiver *adminRPCReceiver) ReInitFormat(args *ReInitFormatArgs, reply *Vo
 	return receiver.local.ReInitFormat(args.DryRun)
 }
 
+// LocksArgs - provides the namespace locks selection to ListLocks and
+// ClearLocks RPCs.
+type LocksArgs struct {
+	AuthArgs
+	Bucket    string
+	Prefix    string
+	OlderThan time.Duration
+}
+
+// ListLocks - lists the namespace locks of this server.
+func (receiver *adminRPCReceiver) ListLocks(args *LocksArgs, reply *[]madmin.VolumeLockInfo) (err error) {
+	*reply, err = receiver.local.ListLocks(args.Bucket, args.Prefix, args.OlderThan)
+	return err
+}
+
+// ClearLocks - forcefully unlocks the namespace locks of this server.
+func (receiver *adminRPCReceiver) ClearLocks(args *LocksArgs, reply *[]madmin.VolumeLockInfo) (err error) {
+	*reply, err = receiver.local.ClearLocks(args.Bucket, args.Prefix, args.OlderThan)
+	return err
+}
+
 // NewAdminRPCServer - returns new admin RPC server.
 func NewAdminRPCServer() (*xrpc.Server, error) {
 	rpcServer := xrpc.NewServer()
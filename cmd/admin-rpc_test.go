@@ -22,6 +22,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/pydio/minio-srv/pkg/madmin"
 	xnet "github.com/pydio/minio-srv/pkg/net"
 )
 
@@ -167,6 +168,56 @@ func testAdminCmdRunnerGetConfig(t *testing.T, client adminCmdRunner) {
 	}
 }
 
+func testAdminCmdRunnerLocks(t *testing.T, client adminCmdRunner) {
+	prevNSMutex := globalNSMutex
+	initNSLock(false)
+	defer func() {
+		globalNSMutex = prevNSMutex
+	}()
+
+	lock := globalNSMutex.NewNSLock("bucket", "object")
+	if err := lock.GetLock(newDynamicTimeout(time.Second, time.Second)); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	testCases := []struct {
+		clear         bool
+		bucket        string
+		prefix        string
+		olderThan     time.Duration
+		expectedLocks int
+	}{
+		{false, "bucket", "", 0, 1},
+		{false, "bucket", "obj", 0, 1},
+		{false, "bucket", "other", 0, 0},
+		{false, "bucket", "", time.Hour, 0},
+		{true, "bucket", "obj", 0, 1},
+		{false, "bucket", "", 0, 0},
+	}
+
+	for i, testCase := range testCases {
+		var volLocks []madmin.VolumeLockInfo
+		var err error
+		if testCase.clear {
+			volLocks, err = client.ClearLocks(testCase.bucket, testCase.prefix, testCase.olderThan)
+		} else {
+			volLocks, err = client.ListLocks(testCase.bucket, testCase.prefix, testCase.olderThan)
+		}
+		if err != nil {
+			t.Fatalf("case %v: unexpected error %v", i+1, err)
+		}
+		if len(volLocks) != testCase.expectedLocks {
+			t.Fatalf("case %v: expected: %v locks, got: %v", i+1, testCase.expectedLocks, len(volLocks))
+		}
+		for _, volLock := range volLocks {
+			if volLock.Bucket != "bucket" || volLock.Object != "object" || len(volLock.LockDetails) != 1 ||
+				volLock.LockDetails[0].LockType != madmin.WriteLock || volLock.LockDetails[0].Status != madmin.LockHeld {
+				t.Fatalf("case %v: unexpected lock %v", i+1, volLock)
+			}
+		}
+	}
+}
+
 func newAdminRPCHTTPServerClient(t *testing.T) (*httptest.Server, *AdminRPCClient, *serverConfig) {
 	rpcServer, err := NewAdminRPCServer()
 	if err != nil {
@@ -237,3 +288,13 @@ func TestAdminRPCClientGetConfig(t *testing.T) {
 
 	testAdminCmdRunnerGetConfig(t, rpcClient)
 }
+
+func TestAdminRPCClientLocks(t *testing.T) {
+	httpServer, rpcClient, prevGlobalServerConfig := newAdminRPCHTTPServerClient(t)
+	defer httpServer.Close()
+	defer func() {
+		globalServerConfig = prevGlobalServerConfig
+	}()
+
+	testAdminCmdRunnerLocks(t, rpcClient)
+}
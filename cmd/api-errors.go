@@ -28,6 +28,7 @@ import (
 	"github.com/pydio/minio-srv/pkg/event"
 	"github.com/pydio/minio-srv/pkg/hash"
 	"github.com/pydio/minio-srv/pkg/s3select"
+	"github.com/pydio/minio-srv/pkg/s3select/format"
 )
 
 // APIError structure
@@ -167,6 +168,9 @@ const (
 
 	// S3 extended errors.
 	ErrContentSHA256Mismatch
+	ErrChecksumMismatch
+	ErrInvalidChecksum
+	ErrUnsupportedTrailer
 
 	// Add new extended error codes here.
 
@@ -193,6 +197,9 @@ const (
 	ErrMalformedJSON
 	ErrAdminNoSuchUser
 	ErrAdminNoSuchPolicy
+	ErrAdminNoSuchGroup
+	ErrAdminGroupNotEmpty
+	ErrAdminNoSuchServiceAccount
 	ErrAdminInvalidArgument
 	ErrAdminInvalidAccessKey
 	ErrAdminInvalidSecretKey
@@ -222,6 +229,7 @@ const (
 	ErrIllegalSQLFunctionArgument
 	ErrInvalidKeyPath
 	ErrInvalidCompressionFormat
+	ErrInvalidScanRange
 	ErrInvalidFileHeaderInfo
 	ErrInvalidJSONType
 	ErrInvalidQuoteFields
@@ -296,6 +304,9 @@ const (
 	ErrEvaluatorInvalidTimestampFormatPatternSymbol
 	ErrEvaluatorBindingDoesNotExist
 	ErrMissingHeaders
+	ErrParquetParsingError
+	ErrParquetUnsupportedCompressionCodec
+	ErrUnsupportedParquetType
 	ErrAdminConfigNotificationTargetsFailed
 	ErrAdminProfilerNotEnabled
 	ErrInvalidDecompressedSize
@@ -827,6 +838,21 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "The provided 'x-amz-content-sha256' header does not match what was computed.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrChecksumMismatch: {
+		Code:           "BadDigest",
+		Description:    "The x-amz-checksum you specified did not match what we received.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidChecksum: {
+		Code:           "InvalidRequest",
+		Description:    "Expecting a single valid x-amz-checksum header, or a valid trailing checksum.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrUnsupportedTrailer: {
+		Code:           "InvalidRequest",
+		Description:    "Only x-amz-checksum headers are supported as trailing headers.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 
 	/// Minio extensions.
 	ErrStorageFull: {
@@ -889,6 +915,21 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "The canned policy does not exist.",
 		HTTPStatusCode: http.StatusNotFound,
 	},
+	ErrAdminNoSuchGroup: {
+		Code:           "XMinioAdminNoSuchGroup",
+		Description:    "The specified group does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminGroupNotEmpty: {
+		Code:           "XMinioAdminGroupNotEmpty",
+		Description:    "The specified group is not empty - cannot remove it.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminNoSuchServiceAccount: {
+		Code:           "XMinioAdminNoSuchServiceAccount",
+		Description:    "The specified service account does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
 	ErrAdminInvalidArgument: {
 		Code:           "XMinioAdminInvalidArgument",
 		Description:    "Invalid arguments specified.",
@@ -1027,7 +1068,7 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 	},
 	ErrInvalidDataSource: {
 		Code:           "InvalidDataSource",
-		Description:    "Invalid data source type. Only CSV and JSON are supported at this time.",
+		Description:    "Invalid data source type. Only CSV, JSON and Parquet are supported at this time.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
 	ErrInvalidExpressionType: {
@@ -1062,7 +1103,12 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 	},
 	ErrInvalidCompressionFormat: {
 		Code:           "InvalidCompressionFormat",
-		Description:    "The file is not in a supported compression format. Only GZIP is supported at this time.",
+		Description:    "The file is not in a supported compression format. Only GZIP, BZIP2, ZSTD and SNAPPY are supported at this time.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidScanRange: {
+		Code:           "InvalidScanRange",
+		Description:    "The scan range is invalid. It is only supported for uncompressed CSV and JSON LINES objects.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
 	ErrInvalidFileHeaderInfo: {
@@ -1430,6 +1476,21 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Some headers in the query are missing from the file. Check the file and try again.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrParquetParsingError: {
+		Code:           "ParquetParsingError",
+		Description:    "Encountered an error parsing the Parquet file. Check the file and try again.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrParquetUnsupportedCompressionCodec: {
+		Code:           "ParquetUnsupportedCompressionCodec",
+		Description:    "The specified Parquet compression codec is not supported.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrUnsupportedParquetType: {
+		Code:           "UnsupportedParquetType",
+		Description:    "The specified Parquet type is not supported.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrInvalidDecompressedSize: {
 		Code:           "XMinioInvalidDecompressedSize",
 		Description:    "The data provided is unfit for decompression",
@@ -1459,6 +1520,12 @@ func toAPIErrorCode(err error) (apiErr APIErrorCode) {
 		apiErr = ErrAdminNoSuchUser
 	case errNoSuchPolicy:
 		apiErr = ErrAdminNoSuchPolicy
+	case errNoSuchGroup:
+		apiErr = ErrAdminNoSuchGroup
+	case errGroupNotEmpty:
+		apiErr = ErrAdminGroupNotEmpty
+	case errNoSuchServiceAccount:
+		apiErr = ErrAdminNoSuchServiceAccount
 	case errSignatureMismatch:
 		apiErr = ErrSignatureDoesNotMatch
 	case errInvalidRange:
@@ -1516,6 +1583,8 @@ func toAPIErrorCode(err error) (apiErr APIErrorCode) {
 		apiErr = ErrInvalidKeyPath
 	case s3select.ErrInvalidCompressionFormat:
 		apiErr = ErrInvalidCompressionFormat
+	case s3select.ErrInvalidScanRange:
+		apiErr = ErrInvalidScanRange
 	case s3select.ErrInvalidFileHeaderInfo:
 		apiErr = ErrInvalidFileHeaderInfo
 	case s3select.ErrInvalidJSONType:
@@ -1660,6 +1729,12 @@ func toAPIErrorCode(err error) (apiErr APIErrorCode) {
 		apiErr = ErrEvaluatorBindingDoesNotExist
 	case s3select.ErrMissingHeaders:
 		apiErr = ErrMissingHeaders
+	case format.ErrParquetParsingError:
+		apiErr = ErrParquetParsingError
+	case format.ErrParquetUnsupportedCompressionCodec:
+		apiErr = ErrParquetUnsupportedCompressionCodec
+	case format.ErrParquetUnsupportedType:
+		apiErr = ErrUnsupportedParquetType
 
 	}
 
@@ -1735,6 +1810,10 @@ func toAPIErrorCode(err error) (apiErr APIErrorCode) {
 		apiErr = ErrSignatureDoesNotMatch
 	case hash.SHA256Mismatch:
 		apiErr = ErrContentSHA256Mismatch
+	case hash.ChecksumMismatch:
+		apiErr = ErrChecksumMismatch
+	case hash.InvalidChecksum:
+		apiErr = ErrInvalidChecksum
 	case ObjectTooLarge:
 		apiErr = ErrEntityTooLarge
 	case ObjectTooSmall:
@@ -19,6 +19,7 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
@@ -70,10 +71,22 @@ func isRequestPostPolicySignatureV4(r *http.Request) bool {
 		r.Method == http.MethodPost
 }
 
-// Verify if the request has AWS Streaming Signature Version '4'. This is only valid for 'PUT' operation.
+// Verify if the request has AWS Streaming Signature Version '4', with or
+// without trailing headers. This is only valid for 'PUT' operation.
 func isRequestSignStreamingV4(r *http.Request) bool {
-	return r.Header.Get("x-amz-content-sha256") == streamingContentSHA256 &&
-		r.Method == http.MethodPut
+	switch r.Header.Get("x-amz-content-sha256") {
+	case streamingContentSHA256, streamingContentSHA256Trailer:
+		return r.Method == http.MethodPut
+	}
+	return false
+}
+
+// Verify if the request is an AWS Signature Version '4' streaming upload
+// with unsigned chunks and trailing headers. This is only valid for 'PUT'
+// operation.
+func isRequestStreamingUnsignedTrailerV4(r *http.Request) bool {
+	return r.Header.Get("x-amz-content-sha256") == streamingUnsignedTrailer &&
+		r.Method == http.MethodPut && isRequestSignatureV4(r)
 }
 
 // Authorization type.
@@ -87,6 +100,7 @@ const (
 	authTypePresignedV2
 	authTypePostPolicy
 	authTypeStreamingSigned
+	authTypeStreamingUnsignedTrailer
 	authTypeSigned
 	authTypeSignedV2
 	authTypeJWT
@@ -101,6 +115,8 @@ func getRequestAuthType(r *http.Request) authType {
 		return authTypePresignedV2
 	} else if isRequestSignStreamingV4(r) {
 		return authTypeStreamingSigned
+	} else if isRequestStreamingUnsignedTrailerV4(r) {
+		return authTypeStreamingUnsignedTrailer
 	} else if isRequestSignatureV4(r) {
 		return authTypeSigned
 	} else if isRequestPresignedSignatureV4(r) {
@@ -136,7 +152,7 @@ func checkAdminRequestAuthType(r *http.Request, region string) APIErrorCode {
 		}
 
 		// we only support V4 (no presign) with auth body
-		s3Err = isReqAuthenticated(r, region)
+		s3Err = isReqAuthenticated(r, region, serviceS3)
 	}
 	if s3Err != ErrNone {
 		reqInfo := (&logger.ReqInfo{}).AppendTags("requestHeaders", dumpRequest(r))
@@ -202,6 +218,15 @@ func getClaimsFromToken(r *http.Request, cred auth.Credentials) (map[string]inte
 	return claims, ErrNone
 }
 
+// getCredUsername - returns the user name of given credentials, service
+// accounts act on behalf of their parent user.
+func getCredUsername(cred auth.Credentials) string {
+	if cred.IsServiceAccount() {
+		return cred.ParentUser
+	}
+	return cred.AccessKey
+}
+
 // Check request auth type verifies the incoming http request
 // - validates the request signature
 // - validates the policy action if anonymous tests bucket policies if any,
@@ -211,7 +236,7 @@ func checkRequestAuthType(ctx context.Context, r *http.Request, action policy.Ac
 	var cred auth.Credentials
 	var owner bool
 	switch getRequestAuthType(r) {
-	case authTypeUnknown, authTypeStreamingSigned:
+	case authTypeUnknown, authTypeStreamingSigned, authTypeStreamingUnsignedTrailer:
 		return ErrAccessDenied
 	case authTypePresignedV2, authTypeSignedV2:
 		if s3Err = isReqAuthenticatedV2(r); s3Err != ErrNone {
@@ -224,7 +249,7 @@ func checkRequestAuthType(ctx context.Context, r *http.Request, action policy.Ac
 		case policy.GetBucketLocationAction, policy.ListAllMyBucketsAction:
 			region = ""
 		}
-		if s3Err = isReqAuthenticated(r, region); s3Err != ErrNone {
+		if s3Err = isReqAuthenticated(r, region, serviceS3); s3Err != ErrNone {
 			return s3Err
 		}
 		cred, owner, s3Err = getReqAccessKeyV4(r, region)
@@ -262,11 +287,25 @@ func checkRequestAuthType(ctx context.Context, r *http.Request, action policy.Ac
 	}
 
 	if cred.AccessKey == "" {
+		if allowed, ok := isAllowedByPydioClaims(r, iampolicy.Args{
+			Action:          iampolicy.Action(action),
+			BucketName:      bucketName,
+			ConditionValues: getConditionValues(r, locationConstraint, ""),
+			ObjectName:      objectName,
+			Headers:         r.Header,
+			ObjectTags:      getRequestObjectTags(r),
+		}); ok {
+			if allowed {
+				return ErrNone
+			}
+			return ErrAccessDenied
+		}
+
 		if globalPolicySys.IsAllowed(policy.Args{
 			AccountName:     cred.AccessKey,
 			Action:          action,
 			BucketName:      bucketName,
-			ConditionValues: getConditionValues(r, locationConstraint),
+			ConditionValues: getConditionValues(r, locationConstraint, cred.AccessKey),
 			IsOwner:         false,
 			ObjectName:      objectName,
 		}) {
@@ -279,10 +318,12 @@ func checkRequestAuthType(ctx context.Context, r *http.Request, action policy.Ac
 		AccountName:     cred.AccessKey,
 		Action:          iampolicy.Action(action),
 		BucketName:      bucketName,
-		ConditionValues: getConditionValues(r, ""),
+		ConditionValues: getConditionValues(r, "", getCredUsername(cred)),
 		ObjectName:      objectName,
 		IsOwner:         owner,
 		Claims:          claims,
+		Headers:         r.Header,
+		ObjectTags:      getRequestObjectTags(r),
 	}) {
 		return ErrNone
 	}
@@ -297,11 +338,22 @@ func isReqAuthenticatedV2(r *http.Request) (s3Error APIErrorCode) {
 	return doesPresignV2SignatureMatch(r)
 }
 
-func reqSignatureV4Verify(r *http.Request, region string) (s3Error APIErrorCode) {
+func reqSignatureV4Verify(r *http.Request, region string, stype serviceType) (s3Error APIErrorCode) {
 	sha256sum := getContentSha256Cksum(r)
+	if _, ok := r.Header["X-Amz-Content-Sha256"]; !ok && stype == serviceSTS {
+		// STS clients do not send 'X-Amz-Content-Sha256', the
+		// payload hash is computed from the form sent in the body.
+		payload, err := ioutil.ReadAll(io.LimitReader(r.Body, maxSTSRequestSize))
+		if err != nil {
+			return ErrInternalError
+		}
+		sum := sha256.Sum256(payload)
+		sha256sum = hex.EncodeToString(sum[:])
+		r.Body = ioutil.NopCloser(bytes.NewReader(payload))
+	}
 	switch {
 	case isRequestSignatureV4(r):
-		return doesSignatureMatch(sha256sum, r, region)
+		return doesSignatureMatch(sha256sum, r, region, stype)
 	case isRequestPresignedSignatureV4(r):
 		return doesPresignedSignatureMatch(sha256sum, r, region)
 	default:
@@ -309,9 +361,9 @@ func reqSignatureV4Verify(r *http.Request, region string) (s3Error APIErrorCode)
 	}
 }
 
-// Verify if request has valid AWS Signature Version '4'.
-func isReqAuthenticated(r *http.Request, region string) (s3Error APIErrorCode) {
-	if errCode := reqSignatureV4Verify(r, region); errCode != ErrNone {
+// Verify if request has valid AWS Signature Version '4' for the stype service.
+func isReqAuthenticated(r *http.Request, region string, stype serviceType) (s3Error APIErrorCode) {
+	if errCode := reqSignatureV4Verify(r, region, stype); errCode != ErrNone {
 		return errCode
 	}
 
@@ -365,13 +417,14 @@ func setAuthHandler(h http.Handler) http.Handler {
 
 // List of all support S3 auth types.
 var supportedS3AuthTypes = map[authType]struct{}{
-	authTypeAnonymous:       {},
-	authTypePresigned:       {},
-	authTypePresignedV2:     {},
-	authTypeSigned:          {},
-	authTypeSignedV2:        {},
-	authTypePostPolicy:      {},
-	authTypeStreamingSigned: {},
+	authTypeAnonymous:                {},
+	authTypePresigned:                {},
+	authTypePresignedV2:              {},
+	authTypeSigned:                   {},
+	authTypeSignedV2:                 {},
+	authTypePostPolicy:               {},
+	authTypeStreamingSigned:          {},
+	authTypeStreamingUnsignedTrailer: {},
 }
 
 // Validate if the authType is valid and supported.
@@ -410,7 +463,7 @@ func isPutAllowed(atype authType, bucketName, objectName string, r *http.Request
 		return ErrAccessDenied
 	case authTypeSignedV2, authTypePresignedV2:
 		cred, owner, s3Err = getReqAccessKeyV2(r)
-	case authTypeStreamingSigned, authTypePresigned, authTypeSigned:
+	case authTypeStreamingSigned, authTypeStreamingUnsignedTrailer, authTypePresigned, authTypeSigned:
 		region := globalServerConfig.GetRegion()
 		cred, owner, s3Err = getReqAccessKeyV4(r, region)
 	}
@@ -424,11 +477,25 @@ func isPutAllowed(atype authType, bucketName, objectName string, r *http.Request
 	}
 
 	if cred.AccessKey == "" {
+		if allowed, ok := isAllowedByPydioClaims(r, iampolicy.Args{
+			Action:          policy.PutObjectAction,
+			BucketName:      bucketName,
+			ConditionValues: getConditionValues(r, "", ""),
+			ObjectName:      objectName,
+			Headers:         r.Header,
+			ObjectTags:      getRequestObjectTags(r),
+		}); ok {
+			if allowed {
+				return ErrNone
+			}
+			return ErrAccessDenied
+		}
+
 		if globalPolicySys.IsAllowed(policy.Args{
 			AccountName:     cred.AccessKey,
 			Action:          policy.PutObjectAction,
 			BucketName:      bucketName,
-			ConditionValues: getConditionValues(r, ""),
+			ConditionValues: getConditionValues(r, "", cred.AccessKey),
 			IsOwner:         false,
 			ObjectName:      objectName,
 		}) {
@@ -441,10 +508,12 @@ func isPutAllowed(atype authType, bucketName, objectName string, r *http.Request
 		AccountName:     cred.AccessKey,
 		Action:          policy.PutObjectAction,
 		BucketName:      bucketName,
-		ConditionValues: getConditionValues(r, ""),
+		ConditionValues: getConditionValues(r, "", getCredUsername(cred)),
 		ObjectName:      objectName,
 		IsOwner:         owner,
 		Claims:          claims,
+		Headers:         r.Header,
+		ObjectTags:      getRequestObjectTags(r),
 	}) {
 		return ErrNone
 	}
@@ -51,6 +51,9 @@ func checkUpdate(mode string) {
 
 // Load logger targets based on user's configuration
 func loadLoggers() {
+	// Keep the recent log entries for the admin API.
+	logger.AddTarget(globalConsoleLog)
+
 	auditEndpoint, ok := os.LookupEnv("MINIO_AUDIT_LOGGER_HTTP_ENDPOINT")
 	if ok {
 		// Enable audit HTTP logging through ENV.
@@ -156,6 +159,10 @@ func handleCommonEnvVars() {
 		globalIsBrowserEnabled = bool(browserFlag)
 	}
 
+	ldapConfig, err := newLDAPConfigFromEnv()
+	logger.FatalIf(err, "Unable to parse LDAP identity configuration")
+	globalLDAPConfig = ldapConfig
+
 	traceFile := os.Getenv("MINIO_HTTP_TRACE")
 	if traceFile != "" {
 		var err error
@@ -268,6 +275,42 @@ func handleCommonEnvVars() {
 			globalCacheMaxUse = maxUse
 		}
 	}
+
+	if lowStr := os.Getenv("MINIO_CACHE_WATERMARK_LOW"); lowStr != "" {
+		low, err := strconv.Atoi(lowStr)
+		if err != nil {
+			logger.Fatal(uiErrInvalidCacheWatermark(err), "Unable to parse MINIO_CACHE_WATERMARK_LOW value (`%s`)", lowStr)
+		}
+		globalCacheWatermarkLow = low
+	}
+
+	if highStr := os.Getenv("MINIO_CACHE_WATERMARK_HIGH"); highStr != "" {
+		high, err := strconv.Atoi(highStr)
+		if err != nil {
+			logger.Fatal(uiErrInvalidCacheWatermark(err), "Unable to parse MINIO_CACHE_WATERMARK_HIGH value (`%s`)", highStr)
+		}
+		globalCacheWatermarkHigh = high
+	}
+
+	if err := checkCacheWatermarks(globalCacheWatermarkLow, globalCacheWatermarkHigh); err != nil {
+		logger.Fatal(err, "Invalid cache watermarks (`%d`, `%d`)", globalCacheWatermarkLow, globalCacheWatermarkHigh)
+	}
+
+	if evictionStr := os.Getenv("MINIO_CACHE_EVICTION"); evictionStr != "" {
+		eviction, err := parseCacheEviction(evictionStr)
+		if err != nil {
+			logger.Fatal(err, "Unable to parse MINIO_CACHE_EVICTION value (`%s`)", evictionStr)
+		}
+		globalCacheEviction = eviction
+	}
+
+	if commitStr := os.Getenv("MINIO_CACHE_COMMIT"); commitStr != "" {
+		commit, err := parseCacheCommit(commitStr)
+		if err != nil {
+			logger.Fatal(err, "Unable to parse MINIO_CACHE_COMMIT value (`%s`)", commitStr)
+		}
+		globalCacheCommit = commit
+	}
 	// In place update is true by default if the MINIO_UPDATE is not set
 	// or is not set to 'off', if MINIO_UPDATE is set to 'off' then
 	// in-place update is off.
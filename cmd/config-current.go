@@ -75,6 +75,15 @@ func (s *serverConfig) GetRegion() string {
 	return s.Region
 }
 
+// GetOpenIDClaimName returns the claim of web identity tokens holding the
+// policy of temporary credentials.
+func (s *serverConfig) GetOpenIDClaimName() string {
+	if s == nil || s.OpenID.ClaimName == "" {
+		return defaultOpenIDClaimName
+	}
+	return s.OpenID.ClaimName
+}
+
 // SetCredential sets new credential and returns the previous credential.
 func (s *serverConfig) SetCredential(creds auth.Credentials) (prevCred auth.Credentials) {
 	if creds.IsValid() && globalActiveCred.IsValid() {
@@ -134,21 +143,29 @@ func (s *serverConfig) GetWorm() bool {
 }
 
 // SetCacheConfig sets the current cache config
-func (s *serverConfig) SetCacheConfig(drives, exclude []string, expiry int, maxuse int) {
+func (s *serverConfig) SetCacheConfig(drives, exclude []string, expiry int, maxuse int, watermarkLow, watermarkHigh int, eviction, commit string) {
 	s.Cache.Drives = drives
 	s.Cache.Exclude = exclude
 	s.Cache.Expiry = expiry
 	s.Cache.MaxUse = maxuse
+	s.Cache.WatermarkLow = watermarkLow
+	s.Cache.WatermarkHigh = watermarkHigh
+	s.Cache.Eviction = eviction
+	s.Cache.Commit = commit
 }
 
 // GetCacheConfig gets the current cache config
 func (s *serverConfig) GetCacheConfig() CacheConfig {
 	if globalIsDiskCacheEnabled {
 		return CacheConfig{
-			Drives:  globalCacheDrives,
-			Exclude: globalCacheExcludes,
-			Expiry:  globalCacheExpiry,
-			MaxUse:  globalCacheMaxUse,
+			Drives:        globalCacheDrives,
+			Exclude:       globalCacheExcludes,
+			Expiry:        globalCacheExpiry,
+			MaxUse:        globalCacheMaxUse,
+			WatermarkLow:  globalCacheWatermarkLow,
+			WatermarkHigh: globalCacheWatermarkHigh,
+			Eviction:      globalCacheEviction,
+			Commit:        globalCacheCommit,
 		}
 	}
 	if s == nil {
@@ -174,6 +191,10 @@ func (s *serverConfig) Validate() error {
 
 	// Region: nothing to validate
 	// Worm, Cache and StorageClass values are already validated during json unmarshal
+	if err := s.Policy.OPA.Validate(); err != nil {
+		return fmt.Errorf("opa: %s", err)
+	}
+
 	for _, v := range s.Notify.AMQP {
 		if err := v.Validate(); err != nil {
 			return fmt.Errorf("amqp: %s", err)
@@ -268,7 +289,8 @@ func (s *serverConfig) loadFromEnvs() {
 	}
 
 	if globalIsDiskCacheEnabled {
-		s.SetCacheConfig(globalCacheDrives, globalCacheExcludes, globalCacheExpiry, globalCacheMaxUse)
+		s.SetCacheConfig(globalCacheDrives, globalCacheExcludes, globalCacheExpiry, globalCacheMaxUse,
+			globalCacheWatermarkLow, globalCacheWatermarkHigh, globalCacheEviction, globalCacheCommit)
 	}
 
 	if globalKMS != nil {
@@ -286,10 +308,16 @@ func (s *serverConfig) loadFromEnvs() {
 		}
 	}
 
+	if claimName, ok := os.LookupEnv("MINIO_IAM_JWKS_CLAIM_NAME"); ok {
+		s.OpenID.ClaimName = claimName
+	}
+
 	if opaURL, ok := os.LookupEnv("MINIO_IAM_OPA_URL"); ok {
 		if u, err := xnet.ParseURL(opaURL); err == nil {
 			s.Policy.OPA.URL = u
 			s.Policy.OPA.AuthToken = os.Getenv("MINIO_IAM_OPA_AUTHTOKEN")
+			s.Policy.OPA.FailOpen = os.Getenv("MINIO_IAM_OPA_FAIL_OPEN") == "on"
+			s.Policy.OPA.CacheTTL = os.Getenv("MINIO_IAM_OPA_CACHE_TTL")
 		}
 	}
 }
@@ -464,10 +492,14 @@ func newServerConfig() *serverConfig {
 			RRS:      storageClass{},
 		},
 		Cache: CacheConfig{
-			Drives:  []string{},
-			Exclude: []string{},
-			Expiry:  globalCacheExpiry,
-			MaxUse:  globalCacheMaxUse,
+			Drives:        []string{},
+			Exclude:       []string{},
+			Expiry:        globalCacheExpiry,
+			MaxUse:        globalCacheMaxUse,
+			WatermarkLow:  globalCacheWatermarkLow,
+			WatermarkHigh: globalCacheWatermarkHigh,
+			Eviction:      globalCacheEviction,
+			Commit:        globalCacheCommit,
 		},
 		KMS:    crypto.KMSConfig{},
 		Notify: notifier{},
@@ -504,6 +536,10 @@ func newServerConfig() *serverConfig {
 	srvCfg.Cache.Exclude = make([]string, 0)
 	srvCfg.Cache.Expiry = globalCacheExpiry
 	srvCfg.Cache.MaxUse = globalCacheMaxUse
+	srvCfg.Cache.WatermarkLow = globalCacheWatermarkLow
+	srvCfg.Cache.WatermarkHigh = globalCacheWatermarkHigh
+	srvCfg.Cache.Eviction = globalCacheEviction
+	srvCfg.Cache.Commit = globalCacheCommit
 
 	// Console logging is on by default
 	srvCfg.Logger.Console.Enabled = true
@@ -533,6 +569,10 @@ func (s *serverConfig) loadToCachedConfigs() {
 		globalCacheExcludes = cacheConf.Exclude
 		globalCacheExpiry = cacheConf.Expiry
 		globalCacheMaxUse = cacheConf.MaxUse
+		globalCacheWatermarkLow = cacheConf.WatermarkLow
+		globalCacheWatermarkHigh = cacheConf.WatermarkHigh
+		globalCacheEviction = cacheConf.Eviction
+		globalCacheCommit = cacheConf.Commit
 	}
 	if globalKMS == nil {
 		globalKMSConfig = s.KMS
@@ -555,9 +595,9 @@ func (s *serverConfig) loadToCachedConfigs() {
 
 	if globalPolicyOPA == nil {
 		if s.Policy.OPA.URL != nil && s.Policy.OPA.URL.String() != "" {
-			globalPolicyOPA = iampolicy.NewOpa(iampolicy.OpaArgs{
-				URL:       s.Policy.OPA.URL,
-				AuthToken: s.Policy.OPA.AuthToken,
+			globalPolicyOPA = iampolicy.NewOpa(s.Policy.OPA)
+			globalPolicyOPA.SetDecisionLogger(func(decision iampolicy.OpaDecision) {
+				logger.AuditDecision(decision)
 			})
 		}
 	}
@@ -882,6 +882,10 @@ type serverConfigV32 struct {
 	OpenID struct {
 		// JWKS validator config.
 		JWKS validator.JWKSArgs `json:"jwks"`
+
+		// Claim of the web identity tokens holding the policy
+		// of the temporary credentials, "policy" if empty.
+		ClaimName string `json:"claimName,omitempty"`
 	} `json:"openid"`
 
 	// External policy enforcements.
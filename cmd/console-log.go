@@ -0,0 +1,123 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pydio/minio-srv/cmd/logger"
+	"github.com/pydio/minio-srv/cmd/logger/message/log"
+)
+
+// Number of recent log entries kept in memory by each node.
+const consoleLogBufferSize = 1000
+
+// Default number of recent log entries of each node sent first to the
+// log listeners.
+const consoleLogDefaultLimit = 10
+
+// Size of the buffer of log entries of a log listener, entries are
+// dropped for listeners which are too slow.
+const consoleLogChannelSize = 4000
+
+// consoleLogOptions - selects the log entries streamed to a log listener.
+type consoleLogOptions struct {
+	// Only stream entries of this level.
+	level string
+	// Only stream entries of this node.
+	node string
+	// Number of recent entries of each node sent first.
+	limit int
+}
+
+// parseConsoleLogOptions - parses the log options of a log request.
+func parseConsoleLogOptions(values url.Values) (opts consoleLogOptions, err error) {
+	opts.level = strings.ToUpper(values.Get("level"))
+	switch opts.level {
+	case "", logger.InformationLvl.String(), logger.ErrorLvl.String(), logger.FatalLvl.String():
+	default:
+		return opts, errors.New("invalid log level")
+	}
+
+	opts.node = values.Get("node")
+
+	opts.limit = consoleLogDefaultLimit
+	if value := values.Get("limit"); value != "" {
+		if opts.limit, err = strconv.Atoi(value); err != nil {
+			return opts, err
+		}
+		if opts.limit < 0 || opts.limit > consoleLogBufferSize {
+			return opts, errors.New("invalid log limit")
+		}
+	}
+
+	return opts, nil
+}
+
+// values - returns the query values of the log options, to forward them
+// to the peers.
+func (opts consoleLogOptions) values() url.Values {
+	values := make(url.Values)
+	if opts.level != "" {
+		values.Set("level", opts.level)
+	}
+	if opts.node != "" {
+		values.Set("node", opts.node)
+	}
+	values.Set("limit", strconv.Itoa(opts.limit))
+	return values
+}
+
+// matches - returns whether the log entry should be streamed.
+func (opts consoleLogOptions) matches(entry log.Entry) bool {
+	return opts.level == "" || entry.Level == opts.level
+}
+
+// matchesNode - returns whether the log entries of node should be streamed.
+func (opts consoleLogOptions) matchesNode(node string) bool {
+	return opts.node == "" || opts.node == node
+}
+
+// subscribeConsoleLog - subscribes logCh to the log entries of this node
+// matching opts, until doneCh is closed.
+func subscribeConsoleLog(logCh chan interface{}, doneCh <-chan struct{}, opts consoleLogOptions) {
+	if !opts.matchesNode(GetLocalPeer(globalEndpoints)) {
+		return
+	}
+	globalConsoleLog.Subscribe(logCh, doneCh, opts.limit, opts.matches)
+}
+
+// writeConsoleLogStream - writes the log entries received on logCh to w as
+// JSON objects, until the client disconnects. Entries of this node are
+// stamped with its name.
+func writeConsoleLogStream(w http.ResponseWriter, r *http.Request, logCh <-chan interface{}) {
+	nodeName := GetLocalPeer(globalEndpoints)
+	writeJSONStream(w, r, logCh, func(v interface{}) (interface{}, bool) {
+		entry, ok := v.(log.Entry)
+		if !ok {
+			return nil, false
+		}
+		if entry.NodeName == "" {
+			entry.NodeName = nodeName
+		}
+		return entry, true
+	})
+}
@@ -0,0 +1,147 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pydio/minio-srv/cmd/logger"
+	"github.com/pydio/minio-srv/cmd/logger/message/log"
+	xnet "github.com/pydio/minio-srv/pkg/net"
+)
+
+// Tests parsing log options and selecting log entries.
+func TestConsoleLogOptions(t *testing.T) {
+	testCases := []struct {
+		values        url.Values
+		entry         log.Entry
+		expectedErr   bool
+		expectedLimit int
+		matches       bool
+	}{
+		// Test - 1 all entries.
+		{url.Values{}, log.Entry{Level: "INFO"}, false, consoleLogDefaultLimit, true},
+		// Test - 2 matching level.
+		{url.Values{"level": []string{"error"}}, log.Entry{Level: "ERROR"}, false, consoleLogDefaultLimit, true},
+		// Test - 3 other level.
+		{url.Values{"level": []string{"ERROR"}}, log.Entry{Level: "INFO"}, false, consoleLogDefaultLimit, false},
+		// Test - 4 invalid level.
+		{url.Values{"level": []string{"DEBUG"}}, log.Entry{}, true, 0, false},
+		// Test - 5 limit.
+		{url.Values{"limit": []string{"0"}}, log.Entry{Level: "FATAL"}, false, 0, true},
+		// Test - 6 invalid limit.
+		{url.Values{"limit": []string{"ten"}}, log.Entry{}, true, 0, false},
+		// Test - 7 negative limit.
+		{url.Values{"limit": []string{"-1"}}, log.Entry{}, true, 0, false},
+		// Test - 8 limit larger than the buffer.
+		{url.Values{"limit": []string{"1001"}}, log.Entry{}, true, 0, false},
+		// Test - 9 node.
+		{url.Values{"node": []string{"host:9000"}, "limit": []string{"1000"}}, log.Entry{Level: "ERROR"}, false, 1000, true},
+	}
+	for i, testCase := range testCases {
+		opts, err := parseConsoleLogOptions(testCase.values)
+		if (err != nil) != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if opts.limit != testCase.expectedLimit {
+			t.Errorf("Test %d: Expected limit %d, got %d", i+1, testCase.expectedLimit, opts.limit)
+		}
+		if matches := opts.matches(testCase.entry); matches != testCase.matches {
+			t.Errorf("Test %d: Expected matches %v, got %v", i+1, testCase.matches, matches)
+		}
+		if !opts.matchesNode("host:9000") || opts.matchesNode("other:9000") != (opts.node == "") {
+			t.Errorf("Test %d: Unexpected node selection of %v", i+1, opts)
+		}
+		// Options sent to peers must select the same entries.
+		peerOpts, err := parseConsoleLogOptions(opts.values())
+		if err != nil || peerOpts != opts {
+			t.Errorf("Test %d: Expected peer options %v, got %v, %v", i+1, opts, peerOpts, err)
+		}
+	}
+}
+
+// Tests streaming the log entries of a peer.
+func TestPeerRPCClientConsoleLog(t *testing.T) {
+	prevGlobalServerConfig := globalServerConfig
+	globalServerConfig = newServerConfig()
+	defer func() {
+		globalServerConfig = prevGlobalServerConfig
+	}()
+
+	router := mux.NewRouter()
+	registerPeerRPCRouter(router)
+	httpServer := httptest.NewServer(router)
+	defer httpServer.Close()
+
+	u, err := xnet.ParseURL(httpServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, err := xnet.ParseHost(u.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rpcClient, err := NewPeerRPCClient(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Log an error to the recent log entries.
+	prevTargets := logger.Targets
+	logger.Targets = []logger.LoggingTarget{globalConsoleLog}
+	logger.Disable = false
+	logger.LogIf(context.Background(), errors.New("peer console log test error"))
+	logger.Disable = true
+	logger.Targets = prevTargets
+
+	logCh := make(chan interface{}, 10)
+	doneCh := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- rpcClient.ConsoleLog(logCh, doneCh, consoleLogOptions{level: "ERROR", limit: 1})
+	}()
+
+	select {
+	case v := <-logCh:
+		entry := v.(log.Entry)
+		if entry.Trace == nil || entry.Trace.Message != "peer console log test error" || entry.NodeName == "" {
+			t.Fatalf("Unexpected log entry %v", entry)
+		}
+	case err = <-errCh:
+		t.Fatalf("Unexpected end of the log stream: %v", err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for a log entry")
+	}
+
+	// Closing doneCh ends the stream.
+	close(doneCh)
+	select {
+	case <-errCh:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Timed out waiting for the end of the log stream")
+	}
+}
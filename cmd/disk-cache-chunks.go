@@ -0,0 +1,469 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/pydio/minio-srv/cmd/logger"
+)
+
+const (
+	// Objects read by ranges are cached in chunks of this size.
+	cacheChunkSize = 1 << 20
+
+	// Directory of the meta bucket holding the objects cached in chunks.
+	cacheChunksDir = "chunks"
+
+	// Chunks of an object are stored at their offset in a sparse data
+	// file, next to the metadata listing the chunks present.
+	cacheChunksMetaFile = "chunks.json"
+	cacheChunksDataFile = "part.1"
+
+	// Chunks filled from the backend are recorded in the metadata every
+	// so many chunks, so that long reads are shared while in progress.
+	cacheChunksSaveInterval = 16
+
+	cacheChunksMetaVersion = "1.0.0"
+)
+
+// cacheChunksMeta - metadata of an object cached in chunks.
+type cacheChunksMeta struct {
+	Version string `json:"version"`
+	// ID identifies the data file, chunks filled in a data file which
+	// was replaced or evicted since are not recorded.
+	ID        string    `json:"id"`
+	Bucket    string    `json:"bucket"`
+	Object    string    `json:"object"`
+	ETag      string    `json:"etag"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"modTime"`
+	ChunkSize int64     `json:"chunkSize"`
+	// Chunks is a bitmap of the chunks present in the data file.
+	Chunks []byte `json:"chunks"`
+	// Meta holds the user defined metadata and the access stats.
+	Meta map[string]string `json:"meta"`
+}
+
+func newCacheChunksMeta(objInfo ObjectInfo) *cacheChunksMeta {
+	m := &cacheChunksMeta{
+		Version:   cacheChunksMetaVersion,
+		ID:        mustGetUUID(),
+		Bucket:    objInfo.Bucket,
+		Object:    objInfo.Name,
+		ETag:      objInfo.ETag,
+		Size:      objInfo.Size,
+		ModTime:   objInfo.ModTime,
+		ChunkSize: cacheChunkSize,
+		Meta:      make(map[string]string),
+	}
+	m.Chunks = make([]byte, (m.numChunks()+7)/8)
+	for k, v := range objInfo.UserDefined {
+		m.Meta[k] = v
+	}
+	m.Meta[cacheAccessTimeKey] = UTCNow().Format(time.RFC3339Nano)
+	m.Meta[cacheHitsKey] = "0"
+	return m
+}
+
+// Returns the number of chunks of the object.
+func (m *cacheChunksMeta) numChunks() int {
+	return int((m.Size + m.ChunkSize - 1) / m.ChunkSize)
+}
+
+// Returns true if chunk i is present.
+func (m *cacheChunksMeta) has(i int) bool {
+	return m.Chunks[i/8]&(1<<uint(i%8)) != 0
+}
+
+// Marks chunk i as present.
+func (m *cacheChunksMeta) set(i int) {
+	m.Chunks[i/8] |= 1 << uint(i%8)
+}
+
+// Returns the number of chunks present.
+func (m *cacheChunksMeta) present() (n int) {
+	for i := 0; i < m.numChunks(); i++ {
+		if m.has(i) {
+			n++
+		}
+	}
+	return n
+}
+
+// Returns true if the object cached in chunks matches the backend one.
+func (m *cacheChunksMeta) matches(objInfo ObjectInfo) bool {
+	return m.ETag == objInfo.ETag && m.Size == objInfo.Size && m.ChunkSize == cacheChunkSize &&
+		len(m.Chunks) == (m.numChunks()+7)/8
+}
+
+// Returns the directory holding the chunks of an object.
+func (cfs *cacheFSObjects) chunksDir(bucket, object string) string {
+	return pathJoin(cfs.fsPath, minioMetaBucket, cacheChunksDir, getSHA256Hash([]byte(pathJoin(bucket, object))))
+}
+
+// Reads the metadata of the object cached in chunks in dir.
+func readCacheChunksMeta(dir string) (*cacheChunksMeta, error) {
+	b, err := ioutil.ReadFile(pathJoin(dir, cacheChunksMetaFile))
+	if err != nil {
+		return nil, err
+	}
+	m := &cacheChunksMeta{}
+	if err = json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	if m.Version != cacheChunksMetaVersion || m.ChunkSize <= 0 {
+		return nil, errCorruptedFormat
+	}
+	return m, nil
+}
+
+// Writes the metadata of an object cached in chunks, the previous metadata
+// is replaced atomically.
+func writeCacheChunksMeta(dir string, m *cacheChunksMeta) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	tmpFile := pathJoin(dir, cacheChunksMetaFile+"."+mustGetUUID())
+	if err = ioutil.WriteFile(tmpFile, b, 0644); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpFile, pathJoin(dir, cacheChunksMetaFile)); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	return nil
+}
+
+// Returns true if the object is cached in chunks.
+func (cfs *cacheFSObjects) chunksExist(bucket, object string) bool {
+	_, err := os.Stat(pathJoin(cfs.chunksDir(bucket, object), cacheChunksMetaFile))
+	return err == nil
+}
+
+// Deletes the chunks of an object.
+func (cfs *cacheFSObjects) deleteChunks(bucket, object string) error {
+	cfs.chunksMutex.Lock()
+	defer cfs.chunksMutex.Unlock()
+	return os.RemoveAll(cfs.chunksDir(bucket, object))
+}
+
+// Opens the chunks of an object for reading and filling, the chunks are
+// reset if the object changed on the backend.
+func (cfs *cacheFSObjects) openChunks(objInfo ObjectInfo) (*cacheChunksMeta, *os.File, error) {
+	cfs.chunksMutex.Lock()
+	defer cfs.chunksMutex.Unlock()
+
+	dir := cfs.chunksDir(objInfo.Bucket, objInfo.Name)
+	m, err := readCacheChunksMeta(dir)
+	if err == nil && m.matches(objInfo) {
+		data, err := os.OpenFile(pathJoin(dir, cacheChunksDataFile), os.O_RDWR, 0644)
+		if err == nil {
+			return m, data, nil
+		}
+	}
+
+	// Start over with no chunk present.
+	if err = os.RemoveAll(dir); err != nil {
+		return nil, nil, err
+	}
+	if err = mkdirAll(dir, 0777); err != nil {
+		return nil, nil, err
+	}
+	m = newCacheChunksMeta(objInfo)
+	data, err := os.OpenFile(pathJoin(dir, cacheChunksDataFile), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The data file is sparse, only the chunks present use disk space.
+	if err = data.Truncate(m.Size); err != nil {
+		data.Close()
+		return nil, nil, err
+	}
+	if err = writeCacheChunksMeta(dir, m); err != nil {
+		data.Close()
+		return nil, nil, err
+	}
+	return m, data, nil
+}
+
+// Records the chunks filled in the data file identified by m.ID.
+func (cfs *cacheFSObjects) saveChunks(m *cacheChunksMeta, filled []int) error {
+	cfs.chunksMutex.Lock()
+	defer cfs.chunksMutex.Unlock()
+
+	dir := cfs.chunksDir(m.Bucket, m.Object)
+	cur, err := readCacheChunksMeta(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Evicted in the meantime.
+			return nil
+		}
+		return err
+	}
+	if cur.ID != m.ID {
+		// Replaced in the meantime.
+		return nil
+	}
+	for _, i := range filled {
+		cur.set(i)
+	}
+	return writeCacheChunksMeta(dir, cur)
+}
+
+// Adds access to the access stats in the metadata of an object cached in
+// chunks.
+func (cfs *cacheFSObjects) saveChunksAccess(bucket, object string, access cacheAccess) error {
+	cfs.chunksMutex.Lock()
+	defer cfs.chunksMutex.Unlock()
+
+	dir := cfs.chunksDir(bucket, object)
+	m, err := readCacheChunksMeta(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Evicted in the meantime.
+			return nil
+		}
+		return err
+	}
+	if m.Meta == nil {
+		m.Meta = make(map[string]string)
+	}
+	addCacheAccess(m.Meta, access)
+	return writeCacheChunksMeta(dir, m)
+}
+
+// Lists the objects cached in chunks with their access stats.
+func (cfs *cacheFSObjects) listChunksEntries(ctx context.Context) ([]cacheEntry, error) {
+	entries, err := readDir(pathJoin(cfs.fsPath, minioMetaBucket, cacheChunksDir))
+	if err != nil {
+		if err == errFileNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var chunksEntries []cacheEntry
+	for _, entry := range entries {
+		m, err := readCacheChunksMeta(pathJoin(cfs.fsPath, minioMetaBucket, cacheChunksDir, entry))
+		if err != nil {
+			continue
+		}
+		objInfo := ObjectInfo{
+			Bucket:      m.Bucket,
+			Name:        m.Object,
+			ModTime:     m.ModTime,
+			UserDefined: m.Meta,
+		}
+		atime, hits := getCacheAccessStats(objInfo)
+		chunksEntries = append(chunksEntries, cacheEntry{
+			bucket:  m.Bucket,
+			object:  m.Object,
+			size:    int64(m.present()) * m.ChunkSize,
+			atime:   atime,
+			hits:    hits,
+			stale:   filterFromCache(m.Meta) || isStaleCache(objInfo),
+			chunked: true,
+		})
+	}
+	return chunksEntries, nil
+}
+
+// cacheChunksReader reads a range of an object from its cached chunks,
+// missing chunks are read from the backend and cached on the way.
+type cacheChunksReader struct {
+	cfs  *cacheFSObjects
+	meta *cacheChunksMeta
+	data *os.File
+	// offset is the next offset of the range to read, end the offset
+	// after the range.
+	offset, end int64
+	// buf holds the remaining bytes of the current chunk within the range.
+	buf []byte
+	// fill returns a reader of length bytes of the backend object at
+	// offset, backend is the current one and backendOffset its position.
+	fill          func(offset, length int64) (io.ReadCloser, error)
+	backend       io.ReadCloser
+	backendOffset int64
+	// cache is false when chunks read from the backend are not cached.
+	cache bool
+	// filled are the chunks filled and not recorded yet.
+	filled []int
+	// hit is true when all the chunks of the range were cached.
+	hit    bool
+	closed bool
+}
+
+// Returns a reader of length bytes of an object at offset, reading from the
+// cached chunks and filling missing ones from the backend with fill.
+func (cfs *cacheFSObjects) newChunksReader(objInfo ObjectInfo, offset, length int64, fill func(offset, length int64) (io.ReadCloser, error)) (*cacheChunksReader, error) {
+	m, data, err := cfs.openChunks(objInfo)
+	if err != nil {
+		return nil, err
+	}
+	r := &cacheChunksReader{
+		cfs:    cfs,
+		meta:   m,
+		data:   data,
+		offset: offset,
+		end:    offset + length,
+		fill:   fill,
+		hit:    true,
+	}
+	var missing int64
+	for i := r.chunk(offset); length > 0 && i <= r.chunk(r.end-1); i++ {
+		if !m.has(i) {
+			missing++
+		}
+	}
+	if missing > 0 {
+		r.hit = false
+		r.cache = cfs.diskAvailable(missing * m.ChunkSize)
+		if cfs.diskUsageHigh() || !r.cache {
+			select {
+			case cfs.purgeChan <- struct{}{}:
+			default:
+			}
+		}
+	}
+	return r, nil
+}
+
+// Returns the chunk holding offset.
+func (r *cacheChunksReader) chunk(offset int64) int {
+	return int(offset / r.meta.ChunkSize)
+}
+
+// Returns the offsets of chunk i.
+func (r *cacheChunksReader) chunkBounds(i int) (start, end int64) {
+	start = int64(i) * r.meta.ChunkSize
+	end = start + r.meta.ChunkSize
+	if end > r.meta.Size {
+		end = r.meta.Size
+	}
+	return start, end
+}
+
+// Reads the chunk holding the next offset of the range into buf.
+func (r *cacheChunksReader) next() error {
+	i := r.chunk(r.offset)
+	start, end := r.chunkBounds(i)
+	chunk := make([]byte, end-start)
+	if r.meta.has(i) {
+		if _, err := r.data.ReadAt(chunk, start); err != nil {
+			return err
+		}
+	} else {
+		if r.backend == nil || r.backendOffset != start {
+			if err := r.openBackend(i); err != nil {
+				return err
+			}
+		}
+		if _, err := io.ReadFull(r.backend, chunk); err != nil {
+			return err
+		}
+		r.backendOffset = end
+		if r.cache {
+			if _, err := r.data.WriteAt(chunk, start); err != nil {
+				// Keep serving the range without caching.
+				logger.LogIf(context.Background(), err)
+				r.cache = false
+			} else {
+				r.filled = append(r.filled, i)
+				if len(r.filled) >= cacheChunksSaveInterval {
+					r.save()
+				}
+			}
+		}
+	}
+	rangeEnd := end
+	if r.end < rangeEnd {
+		rangeEnd = r.end
+	}
+	r.buf = chunk[r.offset-start : rangeEnd-start]
+	return nil
+}
+
+// Opens the backend at chunk i, up to the end of the missing chunks of
+// the range.
+func (r *cacheChunksReader) openBackend(i int) error {
+	if r.backend != nil {
+		r.backend.Close()
+		r.backend = nil
+	}
+	last := i
+	for last+1 <= r.chunk(r.end-1) && !r.meta.has(last+1) {
+		last++
+	}
+	start, _ := r.chunkBounds(i)
+	_, end := r.chunkBounds(last)
+	backend, err := r.fill(start, end-start)
+	if err != nil {
+		return err
+	}
+	r.backend = backend
+	r.backendOffset = start
+	return nil
+}
+
+// Records the filled chunks.
+func (r *cacheChunksReader) save() {
+	if len(r.filled) == 0 {
+		return
+	}
+	if err := r.cfs.saveChunks(r.meta, r.filled); err != nil {
+		logger.LogIf(context.Background(), err)
+	}
+	for _, i := range r.filled {
+		r.meta.set(i)
+	}
+	r.filled = nil
+}
+
+func (r *cacheChunksReader) Read(p []byte) (n int, err error) {
+	for len(r.buf) == 0 {
+		if r.offset >= r.end {
+			return 0, io.EOF
+		}
+		if err = r.next(); err != nil {
+			return 0, err
+		}
+	}
+	n = copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.offset += int64(n)
+	return n, nil
+}
+
+// Close records the filled chunks and the access to the object.
+func (r *cacheChunksReader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if r.backend != nil {
+		r.backend.Close()
+	}
+	r.save()
+	r.cfs.recordAccess(r.meta.Bucket, r.meta.Object, true)
+	return r.data.Close()
+}
@@ -0,0 +1,174 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"testing"
+)
+
+// Test reading ranges of objects cached in chunks.
+func TestCacheChunksReader(t *testing.T) {
+	fsDirs, err := getRandomDisks(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := initDiskCaches(fsDirs, 100, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := d.cfs[0]
+	content := make([]byte, 2*cacheChunkSize+cacheChunkSize/2)
+	rand.New(rand.NewSource(1)).Read(content)
+	objInfo := ObjectInfo{Bucket: "testbucket", Name: "video.mp4", ETag: "etag1", Size: int64(len(content))}
+
+	var fills [][2]int64
+	fill := func(offset, length int64) (io.ReadCloser, error) {
+		fills = append(fills, [2]int64{offset, length})
+		return ioutil.NopCloser(bytes.NewReader(content[offset : offset+length])), nil
+	}
+	testCases := []struct {
+		etag          string
+		offset        int64
+		length        int64
+		expectedHit   bool
+		expectedFills [][2]int64
+	}{
+		// Chunks 0 and 1 are filled.
+		{"etag1", cacheChunkSize / 2, cacheChunkSize, false, [][2]int64{{0, 2 * cacheChunkSize}}},
+		// Served from the cache.
+		{"etag1", 10, cacheChunkSize, true, nil},
+		{"etag1", cacheChunkSize, cacheChunkSize, true, nil},
+		// Only the last chunk is filled.
+		{"etag1", 0, int64(len(content)), false, [][2]int64{{2 * cacheChunkSize, cacheChunkSize / 2}}},
+		{"etag1", 0, int64(len(content)), true, nil},
+		{"etag1", int64(len(content)) - 1, 1, true, nil},
+		{"etag1", 0, 0, true, nil},
+		// Chunks are reset when the object changes.
+		{"etag2", cacheChunkSize + 5, 10, false, [][2]int64{{cacheChunkSize, cacheChunkSize}}},
+		{"etag2", 0, 10, false, [][2]int64{{0, cacheChunkSize}}},
+	}
+	for i, testCase := range testCases {
+		fills = nil
+		objInfo.ETag = testCase.etag
+		r, err := cache.newChunksReader(objInfo, testCase.offset, testCase.length, fill)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if err = r.Close(); err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if !bytes.Equal(data, content[testCase.offset:testCase.offset+testCase.length]) {
+			t.Errorf("Test %d: Unexpected range content", i+1)
+		}
+		if r.hit != testCase.expectedHit {
+			t.Errorf("Test %d: Expected hit %v, got %v", i+1, testCase.expectedHit, r.hit)
+		}
+		if len(fills) != len(testCase.expectedFills) {
+			t.Fatalf("Test %d: Expected fills %v, got %v", i+1, testCase.expectedFills, fills)
+		}
+		for j := range fills {
+			if fills[j] != testCase.expectedFills[j] {
+				t.Errorf("Test %d: Expected fills %v, got %v", i+1, testCase.expectedFills, fills)
+			}
+		}
+	}
+
+	entries, err := cache.listCacheEntries(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !entries[0].chunked || entries[0].object != objInfo.Name || entries[0].size != 2*cacheChunkSize {
+		t.Errorf("Unexpected cache entries %v", entries)
+	}
+	if err = cache.Delete(context.Background(), objInfo.Bucket, objInfo.Name); err == nil {
+		t.Errorf("Expected no object cached whole")
+	}
+	if cache.chunksExist(objInfo.Bucket, objInfo.Name) {
+		t.Errorf("Expected chunks to be deleted")
+	}
+}
+
+// Test range requests served by the cache layer.
+func TestCacheRangeRequests(t *testing.T) {
+	fsDirs, err := getRandomDisks(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := initDiskCaches(fsDirs, 100, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	content := make([]byte, 3*cacheChunkSize)
+	rand.New(rand.NewSource(2)).Read(content)
+	objInfo := ObjectInfo{Bucket: "testbucket", Name: "video.mp4", ETag: "etag1", Size: int64(len(content))}
+	backendReads := 0
+	c := cacheObjects{
+		cache: d,
+		GetObjectInfoFn: func(ctx context.Context, bucket, object string, opts ObjectOptions) (ObjectInfo, error) {
+			return objInfo, nil
+		},
+		GetObjectNInfoFn: func(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, lockType LockType, opts ObjectOptions) (*GetObjectReader, error) {
+			backendReads++
+			offset, length, err := rs.GetOffsetLength(objInfo.Size)
+			if err != nil {
+				return nil, err
+			}
+			return NewGetObjectReaderFromReader(bytes.NewReader(content[offset:offset+length]), objInfo), nil
+		},
+	}
+	testCases := []struct {
+		rs                   *HTTPRangeSpec
+		expectedBackendReads int
+	}{
+		{&HTTPRangeSpec{Start: 100, End: 199}, 1},
+		{&HTTPRangeSpec{Start: 0, End: 99}, 0},
+		{&HTTPRangeSpec{IsSuffixLength: true, Start: -100}, 1},
+		// Objects cached in chunks are also read whole from the chunks.
+		{nil, 1},
+		{nil, 0},
+	}
+	for i, testCase := range testCases {
+		backendReads = 0
+		gr, err := c.GetObjectNInfo(ctx, objInfo.Bucket, objInfo.Name, testCase.rs, nil, readLock, ObjectOptions{})
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		data, err := ioutil.ReadAll(gr)
+		gr.Close()
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		offset, length, _ := testCase.rs.GetOffsetLength(objInfo.Size)
+		if !bytes.Equal(data, content[offset:offset+length]) {
+			t.Errorf("Test %d: Unexpected range content", i+1)
+		}
+		if backendReads != testCase.expectedBackendReads {
+			t.Errorf("Test %d: Expected %d backend reads, got %d", i+1, testCase.expectedBackendReads, backendReads)
+		}
+	}
+}
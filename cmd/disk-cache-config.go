@@ -25,12 +25,40 @@ import (
 	"github.com/pydio/minio-srv/pkg/ellipses"
 )
 
+// Cache eviction policies, the entries accessed least recently or least
+// frequently are evicted first.
+const (
+	cacheEvictionLRU = "lru"
+	cacheEvictionLFU = "lfu"
+)
+
+// Cache commit modes, objects uploaded in writethrough mode are written
+// to the backend before the upload returns, in writeback mode they are
+// written to the cache drive and uploaded to the backend in the background.
+const (
+	cacheCommitWriteThrough = "writethrough"
+	cacheCommitWriteBack    = "writeback"
+)
+
+// Default cache usage watermarks, in percentage of the cache quota.
+const (
+	cacheDefaultWatermarkLow  = 70
+	cacheDefaultWatermarkHigh = 80
+)
+
 // CacheConfig represents cache config settings
 type CacheConfig struct {
 	Drives  []string `json:"drives"`
 	Expiry  int      `json:"expiry"`
 	MaxUse  int      `json:"maxuse"`
 	Exclude []string `json:"exclude"`
+	// Garbage collection starts when the usage of a cache drive is above
+	// WatermarkHigh percent of its quota, and evicts entries until the usage
+	// is below WatermarkLow percent.
+	WatermarkLow  int    `json:"watermark_low"`
+	WatermarkHigh int    `json:"watermark_high"`
+	Eviction      string `json:"eviction"`
+	Commit        string `json:"commit"`
 }
 
 // UnmarshalJSON - implements JSON unmarshal interface for unmarshalling
@@ -54,6 +82,18 @@ func (cfg *CacheConfig) UnmarshalJSON(data []byte) (err error) {
 		return errors.New("config max use value should not be null or negative")
 	}
 
+	if err = checkCacheWatermarks(_cfg.WatermarkLow, _cfg.WatermarkHigh); err != nil {
+		return err
+	}
+
+	if _, err = parseCacheEviction(_cfg.Eviction); err != nil {
+		return err
+	}
+
+	if _, err = parseCacheCommit(_cfg.Commit); err != nil {
+		return err
+	}
+
 	if _, err = parseCacheDrives(_cfg.Drives); err != nil {
 		return err
 	}
@@ -115,3 +155,46 @@ func parseCacheExcludes(excludes []string) ([]string, error) {
 	}
 	return excludes, nil
 }
+
+// Validates the cache usage watermarks, unset watermarks take their
+// default value.
+func checkCacheWatermarks(low, high int) error {
+	if low < 0 || low > 100 {
+		return uiErrInvalidCacheWatermark(nil).Msg("cache low watermark (%d) should be between 0-100", low)
+	}
+	if high < 0 || high > 100 {
+		return uiErrInvalidCacheWatermark(nil).Msg("cache high watermark (%d) should be between 0-100", high)
+	}
+	if low == 0 {
+		low = cacheDefaultWatermarkLow
+	}
+	if high == 0 {
+		high = cacheDefaultWatermarkHigh
+	}
+	if low > high {
+		return uiErrInvalidCacheWatermark(nil).Msg("cache low watermark (%d) should not be above the high watermark (%d)", low, high)
+	}
+	return nil
+}
+
+// Parses given cache eviction policy, LRU is used by default.
+func parseCacheEviction(policy string) (string, error) {
+	switch strings.ToLower(policy) {
+	case "", cacheEvictionLRU:
+		return cacheEvictionLRU, nil
+	case cacheEvictionLFU:
+		return cacheEvictionLFU, nil
+	}
+	return "", uiErrInvalidCacheEviction(nil).Msg("cache eviction policy (%s) should be either lru or lfu", policy)
+}
+
+// Parses given cache commit mode, writethrough is used by default.
+func parseCacheCommit(commit string) (string, error) {
+	switch strings.ToLower(commit) {
+	case "", cacheCommitWriteThrough:
+		return cacheCommitWriteThrough, nil
+	case cacheCommitWriteBack:
+		return cacheCommitWriteBack, nil
+	}
+	return "", uiErrInvalidCacheCommit(nil).Msg("cache commit mode (%s) should be either writethrough or writeback", commit)
+}
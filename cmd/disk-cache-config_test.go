@@ -17,6 +17,7 @@
 package cmd
 
 import (
+	"encoding/json"
 	"reflect"
 	"runtime"
 	"strings"
@@ -112,3 +113,114 @@ func TestParseCacheExclude(t *testing.T) {
 		}
 	}
 }
+
+// Tests cache watermarks validation.
+func TestCheckCacheWatermarks(t *testing.T) {
+	testCases := []struct {
+		low, high int
+		success   bool
+	}{
+		{0, 0, true},
+		{50, 90, true},
+		{80, 80, true},
+		{75, 0, true},
+		{90, 0, false},
+		{0, 60, false},
+		{90, 50, false},
+		{-1, 80, false},
+		{70, 101, false},
+	}
+
+	for i, testCase := range testCases {
+		err := checkCacheWatermarks(testCase.low, testCase.high)
+		if err != nil && testCase.success {
+			t.Errorf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && !testCase.success {
+			t.Errorf("Test %d: Expected failure but passed instead", i+1)
+		}
+	}
+}
+
+// Tests cache eviction policy parsing.
+func TestParseCacheEviction(t *testing.T) {
+	testCases := []struct {
+		policy   string
+		expected string
+		success  bool
+	}{
+		{"", cacheEvictionLRU, true},
+		{"lru", cacheEvictionLRU, true},
+		{"LFU", cacheEvictionLFU, true},
+		{"fifo", "", false},
+	}
+
+	for i, testCase := range testCases {
+		policy, err := parseCacheEviction(testCase.policy)
+		if err != nil && testCase.success {
+			t.Errorf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && !testCase.success {
+			t.Errorf("Test %d: Expected failure but passed instead", i+1)
+		}
+		if policy != testCase.expected {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.expected, policy)
+		}
+	}
+}
+
+// Tests cache commit mode parsing.
+func TestParseCacheCommit(t *testing.T) {
+	testCases := []struct {
+		commit   string
+		expected string
+		success  bool
+	}{
+		{"", cacheCommitWriteThrough, true},
+		{"writethrough", cacheCommitWriteThrough, true},
+		{"WriteBack", cacheCommitWriteBack, true},
+		{"writearound", "", false},
+	}
+
+	for i, testCase := range testCases {
+		commit, err := parseCacheCommit(testCase.commit)
+		if err != nil && testCase.success {
+			t.Errorf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && !testCase.success {
+			t.Errorf("Test %d: Expected failure but passed instead", i+1)
+		}
+		if commit != testCase.expected {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.expected, commit)
+		}
+	}
+}
+
+// Tests cache config unmarshalling.
+func TestCacheConfigUnmarshal(t *testing.T) {
+	testCases := []struct {
+		config  string
+		success bool
+	}{
+		{`{"drives": ["/mnt/cache"], "expiry": 90, "maxuse": 80, "exclude": []}`, true},
+		{`{"drives": ["/mnt/cache"], "maxuse": 80, "watermark_low": 60, "watermark_high": 90, "eviction": "lfu"}`, true},
+		{`{"drives": ["/mnt/cache"], "maxuse": 80, "watermark_low": 95, "watermark_high": 90}`, false},
+		{`{"drives": ["/mnt/cache"], "maxuse": 80, "eviction": "mru"}`, false},
+		{`{"drives": ["/mnt/cache"], "maxuse": 80, "commit": "writeback"}`, true},
+		{`{"drives": ["/mnt/cache"], "maxuse": 80, "commit": "writearound"}`, false},
+	}
+	if runtime.GOOS == globalWindowsOSName {
+		return
+	}
+
+	for i, testCase := range testCases {
+		var config CacheConfig
+		err := json.Unmarshal([]byte(testCase.config), &config)
+		if err != nil && testCase.success {
+			t.Errorf("Test %d: Expected success but failed instead %s", i+1, err)
+		}
+		if err == nil && !testCase.success {
+			t.Errorf("Test %d: Expected failure but passed instead", i+1)
+		}
+	}
+}
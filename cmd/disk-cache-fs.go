@@ -25,6 +25,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -41,6 +43,31 @@ const (
 	cacheEnvDelimiter = ";"
 )
 
+// Keys of the cache metadata holding the access stats of cached objects,
+// used to select the entries to evict.
+const (
+	cacheAccessTimeKey = ReservedMetadataPrefix + "cache-atime"
+	cacheHitsKey       = ReservedMetadataPrefix + "cache-hits"
+)
+
+// Interval between the saves of the access stats of cached objects in
+// their metadata, accesses are only counted in memory in the meantime.
+const cacheAccessSaveInterval = 5 * time.Minute
+
+// cacheAccessKey identifies a cached object, chunked is true for objects
+// cached in chunks.
+type cacheAccessKey struct {
+	bucket, object string
+	chunked        bool
+}
+
+// cacheAccess holds the accesses to a cached object not saved in its
+// metadata yet.
+type cacheAccess struct {
+	atime time.Time
+	hits  int64
+}
+
 // cacheFSObjects implements the cache backend operations.
 type cacheFSObjects struct {
 	*FSObjects
@@ -50,17 +77,32 @@ type cacheFSObjects struct {
 	expiry int
 	// max disk usage pct
 	maxDiskUsagePct int
+	// usage watermarks in pct of the max disk usage
+	watermarkLow  int
+	watermarkHigh int
+	// eviction policy, lru or lfu
+	eviction string
 	// purge() listens on this channel to start the cache-purge process
 	purgeChan chan struct{}
 	// mark false if drive is offline
 	online bool
 	// mutex to protect updates to online variable
 	onlineMutex *sync.RWMutex
+	// mutex to protect updates to the metadata of objects cached in chunks
+	chunksMutex *sync.Mutex
+	// mutex to protect updates to the journal of pending uploads
+	writebackMutex *sync.Mutex
+	// uploader() listens on this channel to upload pending objects
+	writebackChan chan struct{}
+	// mutex to protect updates to the access stats not saved yet
+	accessMutex *sync.Mutex
+	// accesses to the cached objects since the last save of their stats
+	accessStats map[cacheAccessKey]cacheAccess
 }
 
 // Inits the cache directory if it is not init'ed already.
 // Initializing implies creation of new FS Object layer.
-func newCacheFSObjects(dir string, expiry int, maxDiskUsagePct int) (*cacheFSObjects, error) {
+func newCacheFSObjects(dir string, config CacheConfig) (*cacheFSObjects, error) {
 	// Assign a new UUID for FS minio mode. Each server instance
 	// gets its own UUID for temporary file transaction.
 	fsUUID := mustGetUUID()
@@ -75,9 +117,24 @@ func newCacheFSObjects(dir string, expiry int, maxDiskUsagePct int) (*cacheFSObj
 		return nil, err
 	}
 
+	expiry := config.Expiry
 	if expiry == 0 {
 		expiry = globalCacheExpiry
 	}
+	if err := checkCacheWatermarks(config.WatermarkLow, config.WatermarkHigh); err != nil {
+		return nil, err
+	}
+	watermarkLow, watermarkHigh := config.WatermarkLow, config.WatermarkHigh
+	if watermarkLow == 0 {
+		watermarkLow = cacheDefaultWatermarkLow
+	}
+	if watermarkHigh == 0 {
+		watermarkHigh = cacheDefaultWatermarkHigh
+	}
+	eviction, err := parseCacheEviction(config.Eviction)
+	if err != nil {
+		return nil, err
+	}
 
 	// Initialize fs objects.
 	fsObjects := &FSObjects{
@@ -98,44 +155,47 @@ func newCacheFSObjects(dir string, expiry int, maxDiskUsagePct int) (*cacheFSObj
 		FSObjects:       fsObjects,
 		dir:             dir,
 		expiry:          expiry,
-		maxDiskUsagePct: maxDiskUsagePct,
+		maxDiskUsagePct: config.MaxUse,
+		watermarkLow:    watermarkLow,
+		watermarkHigh:   watermarkHigh,
+		eviction:        eviction,
 		purgeChan:       make(chan struct{}),
 		online:          true,
 		onlineMutex:     &sync.RWMutex{},
+		chunksMutex:     &sync.Mutex{},
+		writebackMutex:  &sync.Mutex{},
+		writebackChan:   make(chan struct{}, 1),
+		accessMutex:     &sync.Mutex{},
+		accessStats:     make(map[cacheAccessKey]cacheAccess),
 	}
 	return &cacheFS, nil
 }
 
-// Returns if the disk usage is low.
-// Disk usage is low if usage is < 80% of cacheMaxDiskUsagePct
-// Ex. for a 100GB disk, if maxUsage is configured as 70% then cacheMaxDiskUsagePct is 70G
-// hence disk usage is low if the disk usage is less than 56G (because 80% of 70G is 56G)
-func (cfs *cacheFSObjects) diskUsageLow() bool {
-
-	minUsage := cfs.maxDiskUsagePct * 80 / 100
+// Returns the bytes used on the cache drive, and the usage above which
+// garbage collection starts and down to which it evicts entries.
+// Ex. for a 100GB disk, if maxUsage is configured as 70% the cache quota is 70G,
+// with watermarks of 70% and 80% garbage collection starts when more than 56G
+// are used and evicts entries until less than 49G are used.
+func (cfs *cacheFSObjects) diskUsage() (used, low, high uint64, err error) {
 	di, err := disk.GetInfo(cfs.dir)
 	if err != nil {
 		reqInfo := (&logger.ReqInfo{}).AppendTags("cachePath", cfs.dir)
 		ctx := logger.SetReqInfo(context.Background(), reqInfo)
 		logger.LogIf(ctx, err)
-		return false
+		return 0, 0, 0, err
 	}
-	usedPercent := (di.Total - di.Free) * 100 / di.Total
-	return int(usedPercent) < minUsage
+	quota := di.Total * uint64(cfs.maxDiskUsagePct) / 100
+	return di.Total - di.Free, quota * uint64(cfs.watermarkLow) / 100, quota * uint64(cfs.watermarkHigh) / 100, nil
 }
 
 // Return if the disk usage is high.
-// Disk usage is high if disk used is > cacheMaxDiskUsagePct
+// Disk usage is high if disk used is above the high watermark
 func (cfs *cacheFSObjects) diskUsageHigh() bool {
-	di, err := disk.GetInfo(cfs.dir)
+	used, _, high, err := cfs.diskUsage()
 	if err != nil {
-		reqInfo := (&logger.ReqInfo{}).AppendTags("cachePath", cfs.dir)
-		ctx := logger.SetReqInfo(context.Background(), reqInfo)
-		logger.LogIf(ctx, err)
 		return true
 	}
-	usedPercent := (di.Total - di.Free) * 100 / di.Total
-	return int(usedPercent) > cfs.maxDiskUsagePct
+	return used > high
 }
 
 // Returns if size space can be allocated without exceeding
@@ -182,64 +242,239 @@ func (cfs *cacheFSObjects) purgeTrash() {
 	}
 }
 
-// Purge cache entries that were not accessed.
+// Runs the garbage collection of the cache whenever it is signalled on
+// purgeChan, and periodically to catch up on usage growing outside of
+// cache writes.
 func (cfs *cacheFSObjects) purge() {
-	delimiter := slashSeparator
-	maxKeys := 1000
-	ctx := context.Background()
+	ticker := time.NewTicker(time.Minute * cacheCleanupInterval)
+	defer ticker.Stop()
+
 	for {
-		olderThan := cfs.expiry
-		for !cfs.diskUsageLow() {
-			// delete unaccessed objects older than expiry duration
-			expiry := UTCNow().AddDate(0, 0, -1*olderThan)
-			olderThan /= 2
-			if olderThan < 1 {
-				break
-			}
-			deletedCount := 0
-			buckets, err := cfs.ListBuckets(ctx)
+		select {
+		case <-globalServiceDoneCh:
+			return
+		case <-cfs.purgeChan:
+		case <-ticker.C:
+		}
+		// Reset cache online status if drive was offline earlier.
+		if !cfs.IsOnline() {
+			cfs.setOnline(true)
+		}
+		reqInfo := (&logger.ReqInfo{}).AppendTags("cachePath", cfs.dir)
+		ctx := logger.SetReqInfo(context.Background(), reqInfo)
+		cfs.gc(ctx)
+	}
+}
+
+// Evicts cache entries when the disk usage is above the high watermark,
+// until it is below the low watermark.
+func (cfs *cacheFSObjects) gc(ctx context.Context) {
+	used, low, high, err := cfs.diskUsage()
+	if err != nil || used <= high {
+		return
+	}
+	entries, err := cfs.listCacheEntries(ctx)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		return
+	}
+	sortCacheEntries(entries, cfs.eviction, UTCNow().AddDate(0, 0, -1*cfs.expiry))
+	var freed uint64
+	for _, entry := range entries {
+		if used-freed < low {
+			break
+		}
+		evicted := true
+		if entry.chunked {
+			err = cfs.deleteChunks(entry.bucket, entry.object)
+		} else {
+			evicted, err = cfs.evict(ctx, entry.bucket, entry.object)
+		}
+		if err != nil {
+			logger.LogIf(ctx, err)
+			continue
+		}
+		if !evicted {
+			// Not uploaded to the backend yet.
+			continue
+		}
+		freed += uint64(entry.size)
+		globalCacheStats.incEvictions()
+	}
+}
+
+// cacheEntry is a cached object considered for eviction.
+type cacheEntry struct {
+	bucket, object string
+	size           int64
+	atime          time.Time
+	hits           int64
+	// stale is true for entries expired by their cache-control directives.
+	stale bool
+	// chunked is true for objects cached in chunks.
+	chunked bool
+}
+
+// Lists all the objects of the cache with their access stats.
+func (cfs *cacheFSObjects) listCacheEntries(ctx context.Context) ([]cacheEntry, error) {
+	buckets, err := cfs.ListBuckets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := cfs.listChunksEntries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, bucket := range buckets {
+		var marker string
+		for {
+			objects, err := cfs.ListObjects(ctx, bucket.Name, "", marker, "", 1000)
 			if err != nil {
 				logger.LogIf(ctx, err)
+				break
 			}
-			// Reset cache online status if drive was offline earlier.
-			if !cfs.IsOnline() {
-				cfs.setOnline(true)
-			}
-			for _, bucket := range buckets {
-				var continuationToken string
-				var marker string
-				for {
-					objects, err := cfs.ListObjects(ctx, bucket.Name, marker, continuationToken, delimiter, maxKeys)
-					if err != nil {
-						break
-					}
-
-					if !objects.IsTruncated {
-						break
-					}
-					marker = objects.NextMarker
-					for _, object := range objects.Objects {
-						// purge objects that qualify because of cache-control directives or
-						// past cache expiry duration.
-						if !filterFromCache(object.UserDefined) ||
-							!isStaleCache(object) ||
-							object.AccTime.After(expiry) {
-							continue
-						}
-						if err = cfs.DeleteObject(ctx, bucket.Name, object.Name); err != nil {
-							logger.LogIf(ctx, err)
-							continue
-						}
-						deletedCount++
-					}
-				}
+			for _, object := range objects.Objects {
+				atime, hits := getCacheAccessStats(object)
+				entries = append(entries, cacheEntry{
+					bucket: bucket.Name,
+					object: object.Name,
+					size:   object.Size,
+					atime:  atime,
+					hits:   hits,
+					stale:  filterFromCache(object.UserDefined) || isStaleCache(object),
+				})
 			}
-			if deletedCount == 0 {
-				// to avoid a busy loop
-				time.Sleep(time.Minute * 30)
+			if !objects.IsTruncated {
+				break
 			}
+			marker = objects.NextMarker
 		}
-		<-cfs.purgeChan
+	}
+
+	// Add the accesses not saved yet.
+	cfs.accessMutex.Lock()
+	defer cfs.accessMutex.Unlock()
+	for i, entry := range entries {
+		access, ok := cfs.accessStats[cacheAccessKey{entry.bucket, entry.object, entry.chunked}]
+		if !ok {
+			continue
+		}
+		entries[i].hits += access.hits
+		if access.atime.After(entry.atime) {
+			entries[i].atime = access.atime
+		}
+	}
+	return entries, nil
+}
+
+// Sorts cache entries in eviction order, stale entries and entries not
+// accessed since the expiry date come first, then the least recently
+// accessed entries for lru and the least frequently accessed ones for lfu.
+func sortCacheEntries(entries []cacheEntry, eviction string, expiry time.Time) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		ei, ej := entries[i], entries[j]
+		expiredI := ei.stale || ei.atime.Before(expiry)
+		expiredJ := ej.stale || ej.atime.Before(expiry)
+		if expiredI != expiredJ {
+			return expiredI
+		}
+		if eviction == cacheEvictionLFU && ei.hits != ej.hits {
+			return ei.hits < ej.hits
+		}
+		return ei.atime.Before(ej.atime)
+	})
+}
+
+// Returns the last access time and the number of hits of a cached object,
+// entries cached before access stats were recorded were last accessed
+// when they were cached.
+func getCacheAccessStats(objInfo ObjectInfo) (atime time.Time, hits int64) {
+	atime = objInfo.ModTime
+	if t, err := time.Parse(time.RFC3339Nano, objInfo.UserDefined[cacheAccessTimeKey]); err == nil {
+		atime = t
+	}
+	hits, _ = strconv.ParseInt(objInfo.UserDefined[cacheHitsKey], 10, 64)
+	return atime, hits
+}
+
+// Adds access to the access stats saved in metadata.
+func addCacheAccess(metadata map[string]string, access cacheAccess) {
+	hits, _ := strconv.ParseInt(metadata[cacheHitsKey], 10, 64)
+	metadata[cacheHitsKey] = strconv.FormatInt(hits+access.hits, 10)
+	metadata[cacheAccessTimeKey] = access.atime.Format(time.RFC3339Nano)
+}
+
+// Records a cache hit of an object, it is saved in the object metadata
+// by the next saveAccessStats. Hits are counted in memory so that reads
+// do not become writes of the metadata.
+func (cfs *cacheFSObjects) recordAccess(bucket, object string, chunked bool) {
+	cfs.accessMutex.Lock()
+	defer cfs.accessMutex.Unlock()
+
+	key := cacheAccessKey{bucket, object, chunked}
+	access := cfs.accessStats[key]
+	access.atime = UTCNow()
+	access.hits++
+	cfs.accessStats[key] = access
+}
+
+// Saves the access stats every interval, until doneCh is closed.
+func (cfs *cacheFSObjects) saveAccessStatsLoop(interval time.Duration, doneCh <-chan struct{}) {
+	reqInfo := (&logger.ReqInfo{}).AppendTags("cachePath", cfs.dir)
+	ctx := logger.SetReqInfo(context.Background(), reqInfo)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-doneCh:
+			cfs.saveAccessStats(ctx)
+			return
+		case <-ticker.C:
+			cfs.saveAccessStats(ctx)
+		}
+	}
+}
+
+// Saves the accesses recorded since the last save in the metadata of the
+// cached objects, each metadata file is written once per save.
+func (cfs *cacheFSObjects) saveAccessStats(ctx context.Context) {
+	cfs.accessMutex.Lock()
+	accessStats := cfs.accessStats
+	cfs.accessStats = make(map[cacheAccessKey]cacheAccess)
+	cfs.accessMutex.Unlock()
+
+	for key, access := range accessStats {
+		if key.chunked {
+			logger.LogIf(ctx, cfs.saveChunksAccess(key.bucket, key.object, access))
+		} else {
+			cfs.saveObjectAccess(ctx, key.bucket, key.object, access)
+		}
+	}
+}
+
+// Adds access to the access stats in the metadata of a cached object.
+func (cfs *cacheFSObjects) saveObjectAccess(ctx context.Context, bucket, object string, access cacheAccess) {
+	fs := cfs.FSObjects
+	fsMetaPath := pathJoin(fs.fsPath, minioMetaBucket, bucketMetaPrefix, bucket, object, fs.metaJSONFile)
+	wlk, err := fs.rwPool.Write(fsMetaPath)
+	if err != nil {
+		// The entry was evicted or replaced in the meantime.
+		return
+	}
+	defer wlk.Close()
+
+	fsMeta := fsMetaV1{}
+	if _, err = fsMeta.ReadFrom(ctx, wlk); err != nil {
+		return
+	}
+	if fsMeta.Meta == nil {
+		fsMeta.Meta = make(map[string]string)
+	}
+	addCacheAccess(fsMeta.Meta, access)
+	if _, err = fsMeta.WriteTo(wlk); err != nil {
+		logger.LogIf(ctx, err)
 	}
 }
 
@@ -264,7 +499,6 @@ func (cfs *cacheFSObjects) Put(ctx context.Context, bucket, object string, data
 		case cfs.purgeChan <- struct{}{}:
 		default:
 		}
-		return errDiskFull
 	}
 	if !cfs.diskAvailable(data.Size()) {
 		return errDiskFull
@@ -288,8 +522,11 @@ func (cfs *cacheFSObjects) Get(ctx context.Context, bucket, object string, start
 	return cfs.GetObject(ctx, bucket, object, startOffset, length, writer, etag, opts)
 }
 
-// Deletes the cached object
+// Deletes the cached object, whether cached whole or in chunks
 func (cfs *cacheFSObjects) Delete(ctx context.Context, bucket, object string) (err error) {
+	if err = cfs.deleteChunks(bucket, object); err != nil {
+		logger.LogIf(ctx, err)
+	}
 	return cfs.DeleteObject(ctx, bucket, object)
 }
 
@@ -315,6 +552,9 @@ func (cfs *cacheFSObjects) PutObject(ctx context.Context, bucket string, object
 	for k, v := range metadata {
 		meta[k] = v
 	}
+	// Caching an object counts as its first access.
+	meta[cacheAccessTimeKey] = UTCNow().Format(time.RFC3339Nano)
+	meta[cacheHitsKey] = "0"
 
 	var err error
 
@@ -401,6 +641,7 @@ func (cfs *cacheFSObjects) PutObject(ctx context.Context, bucket string, object
 	if fsMeta.Meta["etag"] == "" {
 		fsMeta.Meta["etag"] = hex.EncodeToString(data.MD5Current())
 	}
+	saveChecksumMetadata(fsMeta.Meta, data)
 	// Should return IncompleteBody{} error when reader has fewer
 	// bytes than specified in request header.
 	if bytesWritten < data.Size() {
@@ -444,6 +685,8 @@ func (cfs *cacheFSObjects) NewMultipartUpload(ctx context.Context, bucket, objec
 		case cfs.purgeChan <- struct{}{}:
 		default:
 		}
+	}
+	if !cfs.diskAvailable(0) {
 		return "", errDiskFull
 	}
 
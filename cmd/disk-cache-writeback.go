@@ -0,0 +1,491 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/pydio/minio-srv/cmd/logger"
+	"github.com/pydio/minio-srv/pkg/hash"
+	"github.com/pydio/minio-srv/pkg/madmin"
+)
+
+const (
+	// Directory of the meta bucket holding the journal of the objects
+	// cached in writeback mode and not uploaded to the backend yet.
+	cacheWritebackDir = "writeback"
+
+	cacheWritebackMetaVersion = "1.0.0"
+
+	// Failed uploads are retried with an exponential backoff.
+	cacheWritebackRetryMin = time.Second
+	cacheWritebackRetryMax = 30 * time.Minute
+
+	// Interval at which the uploader looks for uploads to retry.
+	cacheWritebackInterval = 10 * time.Second
+)
+
+// cachePendingUpload - journal entry of an object cached in writeback mode.
+type cachePendingUpload struct {
+	Version string `json:"version"`
+	// ID identifies the cached copy of the object, the upload of a copy
+	// which was written again since is not completed.
+	ID        string    `json:"id"`
+	Bucket    string    `json:"bucket"`
+	Object    string    `json:"object"`
+	Size      int64     `json:"size"`
+	Created   time.Time `json:"created"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"lastError,omitempty"`
+	NextRetry time.Time `json:"nextRetry"`
+}
+
+// Returns the delay before retrying an upload which failed attempts times.
+func cacheWritebackBackoff(attempts int) time.Duration {
+	if attempts < 1 {
+		return 0
+	}
+	if attempts > 32 {
+		return cacheWritebackRetryMax
+	}
+	backoff := cacheWritebackRetryMin << uint(attempts-1)
+	if backoff <= 0 || backoff > cacheWritebackRetryMax {
+		return cacheWritebackRetryMax
+	}
+	return backoff
+}
+
+// Returns the path of the journal entry of an object.
+func (cfs *cacheFSObjects) pendingPath(bucket, object string) string {
+	return pathJoin(cfs.fsPath, minioMetaBucket, cacheWritebackDir, getSHA256Hash([]byte(pathJoin(bucket, object)))+".json")
+}
+
+// Reads a journal entry.
+func readCachePendingUpload(file string) (*cachePendingUpload, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	p := &cachePendingUpload{}
+	if err = json.Unmarshal(b, p); err != nil {
+		return nil, err
+	}
+	if p.Version != cacheWritebackMetaVersion {
+		return nil, errCorruptedFormat
+	}
+	return p, nil
+}
+
+// Writes a journal entry, the previous entry is replaced atomically.
+func writeCachePendingUpload(file string, p *cachePendingUpload) error {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	if err = mkdirAll(path.Dir(file), 0777); err != nil {
+		return err
+	}
+	tmpFile := file + "." + mustGetUUID()
+	if err = ioutil.WriteFile(tmpFile, b, 0644); err != nil {
+		return err
+	}
+	if err = os.Rename(tmpFile, file); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+	return nil
+}
+
+// Returns the lock serializing the commit of an object written back with
+// the deletion of its cached copy.
+func (cfs *cacheFSObjects) pendingLock(bucket, object string) RWLocker {
+	return cfs.nsMutex.NewNSLock(minioMetaBucket, pathJoin(cacheWritebackDir, bucket, object))
+}
+
+// Caches an object to be written back to the backend.
+func (cfs *cacheFSObjects) putPending(ctx context.Context, bucket, object string, data *hash.Reader, metadata map[string]string, opts ObjectOptions) (ObjectInfo, error) {
+	lock := cfs.pendingLock(bucket, object)
+	if err := lock.GetLock(globalObjectTimeout); err != nil {
+		return ObjectInfo{}, err
+	}
+	defer lock.Unlock()
+
+	if err := cfs.Put(ctx, bucket, object, data, metadata, opts); err != nil {
+		return ObjectInfo{}, err
+	}
+	if err := cfs.addPending(bucket, object, data.Size()); err != nil {
+		// The object would never be uploaded.
+		cfs.Delete(ctx, bucket, object)
+		return ObjectInfo{}, err
+	}
+	return cfs.GetObjectInfo(ctx, bucket, object, opts)
+}
+
+// Deletes a cached object found stale on the backend, unless it is
+// written back to the backend.
+func (cfs *cacheFSObjects) deleteStale(ctx context.Context, bucket, object string) {
+	lock := cfs.pendingLock(bucket, object)
+	if err := lock.GetLock(globalObjectTimeout); err != nil {
+		return
+	}
+	defer lock.Unlock()
+
+	if cfs.isPending(bucket, object) {
+		return
+	}
+	cfs.Delete(ctx, bucket, object)
+}
+
+// Evicts the cached copy of an object, unless it is not uploaded to the
+// backend yet. Returns false if the cached copy is kept.
+func (cfs *cacheFSObjects) evict(ctx context.Context, bucket, object string) (bool, error) {
+	lock := cfs.pendingLock(bucket, object)
+	if err := lock.GetLock(globalObjectTimeout); err != nil {
+		return false, err
+	}
+	defer lock.Unlock()
+
+	if cfs.isPending(bucket, object) {
+		return false, nil
+	}
+	return true, cfs.DeleteObject(ctx, bucket, object)
+}
+
+// Records the cached copy of an object as pending upload to the backend
+// and wakes up the uploader.
+func (cfs *cacheFSObjects) addPending(bucket, object string, size int64) error {
+	cfs.writebackMutex.Lock()
+	defer cfs.writebackMutex.Unlock()
+
+	now := UTCNow()
+	err := writeCachePendingUpload(cfs.pendingPath(bucket, object), &cachePendingUpload{
+		Version:   cacheWritebackMetaVersion,
+		ID:        mustGetUUID(),
+		Bucket:    bucket,
+		Object:    object,
+		Size:      size,
+		Created:   now,
+		NextRetry: now,
+	})
+	if err != nil {
+		return err
+	}
+	select {
+	case cfs.writebackChan <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Returns true if the cached copy of an object is not uploaded to the
+// backend yet.
+func (cfs *cacheFSObjects) isPending(bucket, object string) bool {
+	_, err := os.Stat(cfs.pendingPath(bucket, object))
+	return err == nil
+}
+
+// Removes the journal entry of an object.
+func (cfs *cacheFSObjects) removePending(bucket, object string) error {
+	cfs.writebackMutex.Lock()
+	defer cfs.writebackMutex.Unlock()
+
+	if err := os.Remove(cfs.pendingPath(bucket, object)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Drops the pending upload of an object along with its cached copy. An
+// upload in progress holds a read lock on the cached copy, so it
+// completes before the copy is deleted.
+func (cfs *cacheFSObjects) cancelPending(ctx context.Context, bucket, object string) error {
+	lock := cfs.pendingLock(bucket, object)
+	if err := lock.GetLock(globalObjectTimeout); err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if !cfs.isPending(bucket, object) {
+		return nil
+	}
+	if err := cfs.Delete(ctx, bucket, object); err != nil && !isErrObjectNotFound(err) {
+		return err
+	}
+	return cfs.removePending(bucket, object)
+}
+
+// Lists the journal entries, oldest first.
+func (cfs *cacheFSObjects) listPending() ([]*cachePendingUpload, error) {
+	dir := pathJoin(cfs.fsPath, minioMetaBucket, cacheWritebackDir)
+	entries, err := readDir(dir)
+	if err != nil {
+		if err == errFileNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var pending []*cachePendingUpload
+	for _, entry := range entries {
+		if !hasSuffix(entry, ".json") {
+			// Skip temporary files.
+			continue
+		}
+		p, err := readCachePendingUpload(pathJoin(dir, entry))
+		if err != nil {
+			// Removed in the meantime.
+			continue
+		}
+		pending = append(pending, p)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Created.Before(pending[j].Created)
+	})
+	return pending, nil
+}
+
+// Completes the upload of the cached copy identified by p.ID, returns
+// false if the object was written again or deleted in the meantime.
+func (cfs *cacheFSObjects) completePending(p *cachePendingUpload) (bool, error) {
+	cfs.writebackMutex.Lock()
+	defer cfs.writebackMutex.Unlock()
+
+	file := cfs.pendingPath(p.Bucket, p.Object)
+	cur, err := readCachePendingUpload(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if cur.ID != p.ID {
+		return false, nil
+	}
+	return true, os.Remove(file)
+}
+
+// Drops the journal entry p of an object whose cached copy is missing,
+// unless the object is being cached again.
+func (cfs *cacheFSObjects) dropPending(ctx context.Context, p *cachePendingUpload) error {
+	lock := cfs.pendingLock(p.Bucket, p.Object)
+	if err := lock.GetLock(globalObjectTimeout); err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if cfs.Exists(ctx, p.Bucket, p.Object) {
+		// Uploaded at the next attempt.
+		return nil
+	}
+	_, err := cfs.completePending(p)
+	return err
+}
+
+// Drops the pending upload of the cached copy identified by p.ID along
+// with the cached copy, for uploads which can never succeed.
+func (cfs *cacheFSObjects) abortPending(ctx context.Context, p *cachePendingUpload) error {
+	lock := cfs.pendingLock(p.Bucket, p.Object)
+	if err := lock.GetLock(globalObjectTimeout); err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	completed, err := cfs.completePending(p)
+	if err != nil || !completed {
+		return err
+	}
+	if err = cfs.Delete(ctx, p.Bucket, p.Object); err != nil && !isErrObjectNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// Records a failed upload of the cached copy identified by p.ID and
+// schedules its next attempt. The upload is dropped if the bucket was
+// deleted from the backend.
+func (cfs *cacheFSObjects) retryPending(ctx context.Context, p *cachePendingUpload, uploadErr error) error {
+	if isErrBucketNotFound(uploadErr) {
+		return cfs.abortPending(ctx, p)
+	}
+
+	cfs.writebackMutex.Lock()
+	defer cfs.writebackMutex.Unlock()
+
+	file := cfs.pendingPath(p.Bucket, p.Object)
+	cur, err := readCachePendingUpload(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if cur.ID != p.ID {
+		return nil
+	}
+	cur.Attempts++
+	cur.LastError = uploadErr.Error()
+	cur.NextRetry = UTCNow().Add(cacheWritebackBackoff(cur.Attempts))
+	return writeCachePendingUpload(file, cur)
+}
+
+// Sets the ETag of a cached object to the one of its backend copy, so
+// that the cached object is validated against the backend once uploaded.
+func (cfs *cacheFSObjects) setCachedETag(ctx context.Context, bucket, object, etag string) {
+	fs := cfs.FSObjects
+	fsMetaPath := pathJoin(fs.fsPath, minioMetaBucket, bucketMetaPrefix, bucket, object, fs.metaJSONFile)
+	wlk, err := fs.rwPool.Write(fsMetaPath)
+	if err != nil {
+		// The entry was evicted or replaced in the meantime.
+		return
+	}
+	defer wlk.Close()
+
+	fsMeta := fsMetaV1{}
+	if _, err = fsMeta.ReadFrom(ctx, wlk); err != nil {
+		return
+	}
+	if fsMeta.Meta == nil {
+		fsMeta.Meta = make(map[string]string)
+	}
+	fsMeta.Meta["etag"] = etag
+	if _, err = fsMeta.WriteTo(wlk); err != nil {
+		logger.LogIf(ctx, err)
+	}
+}
+
+// Uploads the objects cached in writeback mode on dcache to the backend,
+// whenever they are written and periodically to retry failed uploads.
+func (c cacheObjects) uploader(dcache *cacheFSObjects) {
+	ticker := time.NewTicker(cacheWritebackInterval)
+	defer ticker.Stop()
+
+	reqInfo := (&logger.ReqInfo{}).AppendTags("cachePath", dcache.dir)
+	ctx := logger.SetReqInfo(context.Background(), reqInfo)
+	for {
+		// Uploads pending since the last run are resumed at startup.
+		c.uploadPending(ctx, dcache)
+		select {
+		case <-globalServiceDoneCh:
+			return
+		case <-dcache.writebackChan:
+		case <-ticker.C:
+		}
+	}
+}
+
+// Uploads the objects of dcache due for an attempt.
+func (c cacheObjects) uploadPending(ctx context.Context, dcache *cacheFSObjects) {
+	pending, err := dcache.listPending()
+	if err != nil {
+		logger.LogIf(ctx, err)
+		return
+	}
+	now := UTCNow()
+	for _, p := range pending {
+		if p.NextRetry.After(now) {
+			continue
+		}
+		if err = c.writeBack(ctx, dcache, p); err != nil {
+			logger.GetReqInfo(ctx).AppendTags("object", pathJoin(p.Bucket, p.Object))
+			logger.LogIf(ctx, err)
+		}
+	}
+}
+
+// Uploads the cached copy of an object to the backend.
+func (c cacheObjects) writeBack(ctx context.Context, dcache *cacheFSObjects, p *cachePendingUpload) error {
+	// The read lock keeps the cached copy from being replaced or deleted
+	// until the upload is completed.
+	gr, err := dcache.GetObjectNInfo(ctx, p.Bucket, p.Object, nil, nil, readLock, ObjectOptions{})
+	if err != nil {
+		if isErrObjectNotFound(err) {
+			return dcache.dropPending(ctx, p)
+		}
+		if rerr := dcache.retryPending(ctx, p, err); rerr != nil {
+			return rerr
+		}
+		return err
+	}
+	objInfo, err := c.putCachedObject(ctx, gr)
+	if err != nil {
+		gr.Close()
+		if rerr := dcache.retryPending(ctx, p, err); rerr != nil {
+			return rerr
+		}
+		return err
+	}
+	completed, err := dcache.completePending(p)
+	gr.Close()
+	if err != nil {
+		return err
+	}
+	if completed {
+		dcache.setCachedETag(ctx, p.Bucket, p.Object, objInfo.ETag)
+	}
+	return nil
+}
+
+// Puts the object read by gr to the backend with its user metadata.
+func (c cacheObjects) putCachedObject(ctx context.Context, gr *GetObjectReader) (ObjectInfo, error) {
+	size := gr.ObjInfo.Size
+	hashReader, err := hash.NewReader(io.LimitReader(gr, size), size, "", "", size)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	metadata := cleanMetadataKeys(gr.ObjInfo.UserDefined, "etag", cacheAccessTimeKey, cacheHitsKey)
+	if gr.ObjInfo.ContentType != "" {
+		metadata["content-type"] = gr.ObjInfo.ContentType
+	}
+	if gr.ObjInfo.ContentEncoding != "" {
+		metadata["content-encoding"] = gr.ObjInfo.ContentEncoding
+	}
+	return c.PutObjectFn(ctx, gr.ObjInfo.Bucket, gr.ObjInfo.Name, hashReader, metadata, ObjectOptions{})
+}
+
+// PendingUploads - lists the objects cached in writeback mode and not
+// uploaded to the backend yet.
+func (c cacheObjects) PendingUploads(ctx context.Context) ([]madmin.CachePendingUpload, error) {
+	pending := []madmin.CachePendingUpload{}
+	for _, dcache := range c.cache.cfs {
+		if dcache == nil {
+			continue
+		}
+		entries, err := dcache.listPending()
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range entries {
+			pending = append(pending, madmin.CachePendingUpload{
+				Drive:     dcache.dir,
+				Bucket:    p.Bucket,
+				Object:    p.Object,
+				Size:      p.Size,
+				Created:   p.Created,
+				Attempts:  p.Attempts,
+				LastError: p.LastError,
+				NextRetry: p.NextRetry,
+			})
+		}
+	}
+	return pending, nil
+}
@@ -0,0 +1,212 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pydio/minio-srv/pkg/hash"
+)
+
+func TestCacheWritebackBackoff(t *testing.T) {
+	testCases := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{0, 0},
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{5, 16 * time.Second},
+		{11, 1024 * time.Second},
+		{12, cacheWritebackRetryMax},
+		{100, cacheWritebackRetryMax},
+	}
+	for i, testCase := range testCases {
+		if backoff := cacheWritebackBackoff(testCase.attempts); backoff != testCase.expected {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.expected, backoff)
+		}
+	}
+}
+
+// Test objects committed to the cache and uploaded in the background.
+func TestWritebackCache(t *testing.T) {
+	fsDirs, err := getRandomDisks(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := initDiskCaches(fsDirs, 100, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	bucketName := "testbucket"
+	objectName := "testobject"
+	content := "written back"
+
+	var backendErr error
+	var bucketMissing bool
+	var uploaded []byte
+	var uploadedMeta map[string]string
+	c := cacheObjects{
+		cache:     d,
+		writeback: true,
+		GetBucketInfoFn: func(ctx context.Context, bucket string) (BucketInfo, error) {
+			if bucketMissing {
+				return BucketInfo{}, BucketNotFound{Bucket: bucket}
+			}
+			return BucketInfo{Name: bucket}, nil
+		},
+		GetObjectInfoFn: func(ctx context.Context, bucket, object string, opts ObjectOptions) (ObjectInfo, error) {
+			return ObjectInfo{}, ObjectNotFound{Bucket: bucket, Object: object}
+		},
+		GetObjectNInfoFn: func(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, lockType LockType, opts ObjectOptions) (*GetObjectReader, error) {
+			return nil, ObjectNotFound{Bucket: bucket, Object: object}
+		},
+		PutObjectFn: func(ctx context.Context, bucket, object string, data *hash.Reader, metadata map[string]string, opts ObjectOptions) (ObjectInfo, error) {
+			if backendErr != nil {
+				return ObjectInfo{}, backendErr
+			}
+			b, err := ioutil.ReadAll(data)
+			if err != nil {
+				return ObjectInfo{}, err
+			}
+			uploaded, uploadedMeta = b, metadata
+			return ObjectInfo{Bucket: bucket, Name: object, ETag: "backend-etag", Size: int64(len(b))}, nil
+		},
+		DeleteObjectFn: func(ctx context.Context, bucket, object string) error {
+			return ObjectNotFound{Bucket: bucket, Object: object}
+		},
+	}
+	putObject := func() error {
+		hashReader, err := hash.NewReader(bytes.NewReader([]byte(content)), int64(len(content)), "", "", int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		meta := map[string]string{"content-type": "text/plain", "x-amz-meta-color": "blue"}
+		_, err = c.PutObject(ctx, bucketName, objectName, hashReader, meta, ObjectOptions{})
+		return err
+	}
+
+	// Objects of missing buckets are not accepted.
+	bucketMissing = true
+	if err = putObject(); !isErrBucketNotFound(err) {
+		t.Fatalf("Expected BucketNotFound, got %v", err)
+	}
+	if d.cfs[0].isPending(bucketName, objectName) {
+		t.Fatal("Expected no pending upload for a missing bucket")
+	}
+	bucketMissing = false
+
+	if err = putObject(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pending objects are served from the cache.
+	objInfo, err := c.GetObjectInfo(ctx, bucketName, objectName, ObjectOptions{})
+	if err != nil || objInfo.Size != int64(len(content)) {
+		t.Fatalf("Expected cached object info, got %v (%v)", objInfo, err)
+	}
+	gr, err := c.GetObjectNInfo(ctx, bucketName, objectName, nil, nil, readLock, ObjectOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(gr)
+	gr.Close()
+	if err != nil || string(data) != content {
+		t.Fatalf("Expected cached content, got %q (%v)", data, err)
+	}
+
+	// Failed uploads are retried later.
+	backendErr = errors.New("backend unreachable")
+	c.uploadPending(ctx, d.cfs[0])
+	pending, err := c.PendingUploads(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 {
+		t.Fatalf("Expected 1 pending upload, got %d", len(pending))
+	}
+	if p := pending[0]; p.Bucket != bucketName || p.Object != objectName || p.Attempts != 1 ||
+		p.LastError != backendErr.Error() || !p.NextRetry.After(p.Created) {
+		t.Fatalf("Unexpected pending upload %+v", p)
+	}
+
+	// Pending uploads are resumed after a restart.
+	cfs, err := initCacheFSObjects(fsDirs[0], 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := cfs.listPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 pending upload after restart, got %d", len(entries))
+	}
+	backendErr = nil
+	if err = c.writeBack(ctx, cfs, entries[0]); err != nil {
+		t.Fatal(err)
+	}
+	if string(uploaded) != content {
+		t.Fatalf("Expected %q to be uploaded, got %q", content, uploaded)
+	}
+	if uploadedMeta["x-amz-meta-color"] != "blue" || uploadedMeta["content-type"] != "text/plain" {
+		t.Fatalf("Expected user metadata to be uploaded, got %v", uploadedMeta)
+	}
+	if _, ok := uploadedMeta[cacheHitsKey]; ok {
+		t.Fatal("Expected cache metadata not to be uploaded")
+	}
+	if cfs.isPending(bucketName, objectName) {
+		t.Fatal("Expected upload to be completed")
+	}
+	cachedInfo, err := cfs.GetObjectInfo(ctx, bucketName, objectName, ObjectOptions{})
+	if err != nil || cachedInfo.ETag != "backend-etag" {
+		t.Fatalf("Expected cached object to have the backend ETag, got %v (%v)", cachedInfo.ETag, err)
+	}
+
+	// Deleting a pending object cancels its upload.
+	if err = putObject(); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.DeleteObject(ctx, bucketName, objectName); err != nil {
+		t.Fatal(err)
+	}
+	if d.cfs[0].isPending(bucketName, objectName) || d.cfs[0].Exists(ctx, bucketName, objectName) {
+		t.Fatal("Expected pending upload to be cancelled")
+	}
+
+	// Pending objects are not evicted.
+	if err = putObject(); err != nil {
+		t.Fatal(err)
+	}
+	if evicted, err := d.cfs[0].evict(ctx, bucketName, objectName); err != nil || evicted {
+		t.Fatalf("Expected pending object not to be evicted, got %v (%v)", evicted, err)
+	}
+
+	// Uploads to a bucket deleted from the backend are dropped.
+	backendErr = BucketNotFound{Bucket: bucketName}
+	c.uploadPending(ctx, d.cfs[0])
+	if d.cfs[0].isPending(bucketName, objectName) || d.cfs[0].Exists(ctx, bucketName, objectName) {
+		t.Fatal("Expected pending upload to a deleted bucket to be dropped")
+	}
+}
@@ -18,23 +18,20 @@ package cmd
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/djherbis/atime"
-
 	"github.com/pydio/minio-srv/cmd/crypto"
 	"github.com/pydio/minio-srv/cmd/logger"
 	"github.com/pydio/minio-srv/pkg/hash"
+	"github.com/pydio/minio-srv/pkg/madmin"
 	"github.com/pydio/minio-srv/pkg/wildcard"
+	"go.uber.org/atomic"
 )
 
 const (
@@ -43,6 +40,45 @@ const (
 	cacheCleanupInterval = 10 // in minutes
 )
 
+// cacheStats - disk cache statistics, counts the requests served
+// from the cache, the requests for cacheable objects served from the
+// backend and the entries evicted during the server's life.
+type cacheStats struct {
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+// Increase cache hits
+func (s *cacheStats) incHits() {
+	s.hits.Inc()
+}
+
+// Increase cache misses
+func (s *cacheStats) incMisses() {
+	s.misses.Inc()
+}
+
+// Increase cache evictions
+func (s *cacheStats) incEvictions() {
+	s.evictions.Inc()
+}
+
+// Return cache hits
+func (s *cacheStats) getHits() uint64 {
+	return s.hits.Load()
+}
+
+// Return cache misses
+func (s *cacheStats) getMisses() uint64 {
+	return s.misses.Load()
+}
+
+// Return cache evictions
+func (s *cacheStats) getEvictions() uint64 {
+	return s.evictions.Load()
+}
+
 // abstract slice of cache drives backed by FS.
 type diskCache struct {
 	cfs []*cacheFSObjects
@@ -56,6 +92,13 @@ type cacheObjects struct {
 	listPool *treeWalkPool
 	// file path patterns to exclude from cache
 	exclude []string
+	// strict is set for backends checking access on every request: cached
+	// objects are only served once validated against the backend, and
+	// writes invalidate cached objects instead of caching their content.
+	strict bool
+	// writeback is set to commit uploaded objects to the cache drives and
+	// upload them to the backend in the background.
+	writeback bool
 	// Object functions pointing to the corresponding functions of backend implementation.
 	GetObjectNInfoFn          func(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, lockType LockType, opts ObjectOptions) (gr *GetObjectReader, err error)
 	GetObjectFn               func(ctx context.Context, bucket, object string, startOffset int64, length int64, writer io.Writer, etag string, opts ObjectOptions) (err error)
@@ -103,6 +146,9 @@ type CacheObjectLayer interface {
 
 	// Storage operations.
 	StorageInfo(ctx context.Context) CacheStorageInfo
+
+	// Writeback operations.
+	PendingUploads(ctx context.Context) ([]madmin.CachePendingUpload, error)
 }
 
 // IsCacheable returns if the object should be saved in the cache.
@@ -116,6 +162,19 @@ func backendDownError(err error) bool {
 	return backendDown || IsErr(err, baseErrs...)
 }
 
+// backendDown returns true if err is due to backend failure and cached
+// objects may be served instead.
+func (c cacheObjects) backendDown(err error) bool {
+	return !c.strict && backendDownError(err)
+}
+
+// invalidate deletes the cached copy of an object written through the backend.
+func (c cacheObjects) invalidate(ctx context.Context, bucket, object string) {
+	if dcache, err := c.cache.getCachedFSLoc(ctx, bucket, object); err == nil {
+		dcache.Delete(ctx, bucket, object)
+	}
+}
+
 // get cache disk where object is currently cached for a GET operation. If object does not exist at that location,
 // treat the list of cache drives as a circular buffer and walk through them starting at hash index
 // until an online drive is found.If object is not found, fall back to the first online cache drive
@@ -183,7 +242,7 @@ func (c cacheObjects) getMetadata(objInfo ObjectInfo) map[string]string {
 }
 
 func (c cacheObjects) GetObjectNInfo(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, lockType LockType, opts ObjectOptions) (gr *GetObjectReader, err error) {
-	if c.isCacheExclude(bucket, object) {
+	if c.isCacheExclude(bucket, object) || opts.VersionID != "" {
 		return c.GetObjectNInfoFn(ctx, bucket, object, rs, h, writeLock, opts)
 	}
 
@@ -194,39 +253,53 @@ func (c cacheObjects) GetObjectNInfo(ctx context.Context, bucket, object string,
 	}
 
 	cacheReader, cacheErr := dcache.GetObjectNInfo(ctx, bucket, object, rs, h, lockType, opts)
+	if cacheErr == nil && dcache.isPending(bucket, object) {
+		// The object is not uploaded to the backend yet.
+		c.cacheHit(dcache, bucket, object)
+		return cacheReader, nil
+	}
 
 	objInfo, err := c.GetObjectInfoFn(ctx, bucket, object, opts)
-	if backendDownError(err) && cacheErr == nil {
+	if c.backendDown(err) && cacheErr == nil {
+		c.cacheHit(dcache, bucket, object)
 		return cacheReader, nil
 	} else if err != nil {
+		if cacheErr == nil {
+			cacheReader.Close()
+		}
 		if _, ok := err.(ObjectNotFound); ok {
 			// Delete cached entry if backend object was deleted.
-			dcache.Delete(ctx, bucket, object)
+			dcache.deleteStale(ctx, bucket, object)
 		}
 		return nil, err
 	}
 
 	if !objInfo.IsCacheable() || filterFromCache(objInfo.UserDefined) {
+		if cacheErr == nil {
+			cacheReader.Close()
+		}
 		return c.GetObjectNInfoFn(ctx, bucket, object, rs, h, writeLock, opts)
 	}
 
 	if cacheErr == nil {
 		if cacheReader.ObjInfo.ETag == objInfo.ETag && !isStaleCache(objInfo) {
 			// Object is not stale, so serve from cache
+			c.cacheHit(dcache, bucket, object)
 			return cacheReader, nil
 		}
 		cacheReader.Close()
 		// Object is stale, so delete from cache
-		dcache.Delete(ctx, bucket, object)
+		dcache.deleteStale(ctx, bucket, object)
+	}
+
+	if rs != nil || dcache.chunksExist(bucket, object) {
+		// Ranges are served from the chunks of the object.
+		return c.getChunksNInfo(ctx, dcache, bucket, object, objInfo, rs, h, opts)
 	}
+	globalCacheStats.incMisses()
 
 	// Since we got here, we are serving the request from backend,
 	// and also adding the object to the cache.
-
-	if rs != nil {
-		// We don't cache partial objects.
-		return c.GetObjectNInfoFn(ctx, bucket, object, rs, h, writeLock, opts)
-	}
 	if !dcache.diskAvailable(objInfo.Size) {
 		return c.GetObjectNInfoFn(ctx, bucket, object, rs, h, writeLock, opts)
 	}
@@ -259,13 +332,53 @@ func (c cacheObjects) GetObjectNInfo(ctx context.Context, bucket, object string,
 	return gr, nil
 }
 
+// Serves a range of an object from its chunks cached on dcache, missing
+// chunks are read from the backend and cached on the way.
+func (c cacheObjects) getChunksNInfo(ctx context.Context, dcache *cacheFSObjects, bucket, object string, objInfo ObjectInfo, rs *HTTPRangeSpec, h http.Header, opts ObjectOptions) (gr *GetObjectReader, err error) {
+	offset, length, err := rs.GetOffsetLength(objInfo.Size)
+	if err != nil {
+		return c.GetObjectNInfoFn(ctx, bucket, object, rs, h, writeLock, opts)
+	}
+	if isStaleCache(objInfo) {
+		logger.LogIf(ctx, dcache.deleteChunks(bucket, object))
+	}
+	fill := func(offset, length int64) (io.ReadCloser, error) {
+		bkReader, bkErr := c.GetObjectNInfoFn(ctx, bucket, object, &HTTPRangeSpec{Start: offset, End: offset + length - 1}, h, readLock, opts)
+		if bkErr != nil {
+			return nil, bkErr
+		}
+		return bkReader, nil
+	}
+	cacheInfo := objInfo
+	cacheInfo.Bucket, cacheInfo.Name = bucket, object
+	chunksReader, err := dcache.newChunksReader(cacheInfo, offset, length, fill)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		globalCacheStats.incMisses()
+		return c.GetObjectNInfoFn(ctx, bucket, object, rs, h, writeLock, opts)
+	}
+	if chunksReader.hit {
+		globalCacheStats.incHits()
+	} else {
+		globalCacheStats.incMisses()
+	}
+	return NewGetObjectReaderFromReader(chunksReader, objInfo, func() { chunksReader.Close() }), nil
+}
+
+// Counts a request served from the cache and records the access to the
+// cached object.
+func (c cacheObjects) cacheHit(dcache *cacheFSObjects, bucket, object string) {
+	globalCacheStats.incHits()
+	dcache.recordAccess(bucket, object, false)
+}
+
 // Uses cached-object to serve the request. If object is not cached it serves the request from the backend and also
 // stores it in the cache for serving subsequent requests.
 func (c cacheObjects) GetObject(ctx context.Context, bucket, object string, startOffset int64, length int64, writer io.Writer, etag string, opts ObjectOptions) (err error) {
 	GetObjectFn := c.GetObjectFn
 	GetObjectInfoFn := c.GetObjectInfoFn
 
-	if c.isCacheExclude(bucket, object) {
+	if c.isCacheExclude(bucket, object) || opts.VersionID != "" {
 		return GetObjectFn(ctx, bucket, object, startOffset, length, writer, etag, opts)
 	}
 	// fetch cacheFSObjects if object is currently cached or nearest available cache drive
@@ -273,13 +386,18 @@ func (c cacheObjects) GetObject(ctx context.Context, bucket, object string, star
 	if err != nil {
 		return GetObjectFn(ctx, bucket, object, startOffset, length, writer, etag, opts)
 	}
+	if dcache.isPending(bucket, object) {
+		// The object is not uploaded to the backend yet.
+		c.cacheHit(dcache, bucket, object)
+		return dcache.Get(ctx, bucket, object, startOffset, length, writer, etag, opts)
+	}
 	// stat object on backend
 	objInfo, err := GetObjectInfoFn(ctx, bucket, object, opts)
-	backendDown := backendDownError(err)
+	backendDown := c.backendDown(err)
 	if err != nil && !backendDown {
 		if _, ok := err.(ObjectNotFound); ok {
 			// Delete the cached entry if backend object was deleted.
-			dcache.Delete(ctx, bucket, object)
+			dcache.deleteStale(ctx, bucket, object)
 		}
 		return err
 	}
@@ -296,13 +414,16 @@ func (c cacheObjects) GetObject(ctx context.Context, bucket, object string, star
 	if err == nil {
 		if backendDown {
 			// If the backend is down, serve the request from cache.
+			c.cacheHit(dcache, bucket, object)
 			return dcache.Get(ctx, bucket, object, startOffset, length, writer, etag, opts)
 		}
 		if cachedObjInfo.ETag == objInfo.ETag && !isStaleCache(objInfo) {
+			c.cacheHit(dcache, bucket, object)
 			return dcache.Get(ctx, bucket, object, startOffset, length, writer, etag, opts)
 		}
-		dcache.Delete(ctx, bucket, object)
+		dcache.deleteStale(ctx, bucket, object)
 	}
+	globalCacheStats.incMisses()
 	if startOffset != 0 || length != objInfo.Size {
 		// We don't cache partial objects.
 		return GetObjectFn(ctx, bucket, object, startOffset, length, writer, etag, opts)
@@ -331,7 +452,7 @@ func (c cacheObjects) GetObject(ctx context.Context, bucket, object string, star
 // Returns ObjectInfo from cache if available.
 func (c cacheObjects) GetObjectInfo(ctx context.Context, bucket, object string, opts ObjectOptions) (ObjectInfo, error) {
 	getObjectInfoFn := c.GetObjectInfoFn
-	if c.isCacheExclude(bucket, object) {
+	if c.isCacheExclude(bucket, object) || opts.VersionID != "" {
 		return getObjectInfoFn(ctx, bucket, object, opts)
 	}
 	// fetch cacheFSObjects if object is currently cached or nearest available cache drive
@@ -339,14 +460,20 @@ func (c cacheObjects) GetObjectInfo(ctx context.Context, bucket, object string,
 	if err != nil {
 		return getObjectInfoFn(ctx, bucket, object, opts)
 	}
+	if dcache.isPending(bucket, object) {
+		// The object is not uploaded to the backend yet.
+		if cachedObjInfo, cerr := dcache.GetObjectInfo(ctx, bucket, object, opts); cerr == nil {
+			return cachedObjInfo, nil
+		}
+	}
 	objInfo, err := getObjectInfoFn(ctx, bucket, object, opts)
 	if err != nil {
 		if _, ok := err.(ObjectNotFound); ok {
 			// Delete the cached entry if backend object was deleted.
-			dcache.Delete(ctx, bucket, object)
+			dcache.deleteStale(ctx, bucket, object)
 			return ObjectInfo{}, err
 		}
-		if !backendDownError(err) {
+		if !c.backendDown(err) {
 			return ObjectInfo{}, err
 		}
 		// when backend is down, serve from cache.
@@ -363,7 +490,7 @@ func (c cacheObjects) GetObjectInfo(ctx context.Context, bucket, object string,
 	}
 	if cachedObjInfo.ETag != objInfo.ETag {
 		// Delete the cached entry if the backend object was replaced.
-		dcache.Delete(ctx, bucket, object)
+		dcache.deleteStale(ctx, bucket, object)
 	}
 	return objInfo, nil
 }
@@ -537,7 +664,7 @@ func (c cacheObjects) ListObjects(ctx context.Context, bucket, prefix, marker, d
 
 	result, err = listObjectsFn(ctx, bucket, prefix, marker, delimiter, maxKeys)
 	if err != nil {
-		if backendDownError(err) {
+		if c.backendDown(err) {
 			return c.listCacheObjects(ctx, bucket, prefix, marker, delimiter, maxKeys)
 		}
 		return
@@ -551,7 +678,7 @@ func (c cacheObjects) ListObjectsV2(ctx context.Context, bucket, prefix, continu
 
 	result, err = listObjectsV2Fn(ctx, bucket, prefix, continuationToken, delimiter, maxKeys, fetchOwner, startAfter)
 	if err != nil {
-		if backendDownError(err) {
+		if c.backendDown(err) {
 			return c.listCacheV2Objects(ctx, bucket, prefix, continuationToken, delimiter, maxKeys, fetchOwner, startAfter)
 		}
 		return
@@ -591,7 +718,7 @@ func (c cacheObjects) ListBuckets(ctx context.Context) (buckets []BucketInfo, er
 	listBucketsFn := c.ListBucketsFn
 	buckets, err = listBucketsFn(ctx)
 	if err != nil {
-		if backendDownError(err) {
+		if c.backendDown(err) {
 			return c.listBuckets(ctx)
 		}
 		return []BucketInfo{}, err
@@ -603,7 +730,7 @@ func (c cacheObjects) ListBuckets(ctx context.Context) (buckets []BucketInfo, er
 func (c cacheObjects) GetBucketInfo(ctx context.Context, bucket string) (bucketInfo BucketInfo, err error) {
 	getBucketInfoFn := c.GetBucketInfoFn
 	bucketInfo, err = getBucketInfoFn(ctx, bucket)
-	if backendDownError(err) {
+	if c.backendDown(err) {
 		for _, cache := range c.cache.cfs {
 			// ignore disk-caches that might be missing/offline
 			if cache == nil {
@@ -619,15 +746,26 @@ func (c cacheObjects) GetBucketInfo(ctx context.Context, bucket string) (bucketI
 
 // Delete Object deletes from cache as well if backend operation succeeds
 func (c cacheObjects) DeleteObject(ctx context.Context, bucket, object string) (err error) {
+	dcache, cerr := c.cache.getCachedFSLoc(ctx, bucket, object)
+	pending := cerr == nil && dcache.isPending(bucket, object)
+	if pending {
+		// Stop the upload of the object before deleting it from the backend.
+		if err = dcache.cancelPending(ctx, bucket, object); err != nil {
+			return err
+		}
+	}
 	if err = c.DeleteObjectFn(ctx, bucket, object); err != nil {
+		if pending && isErrObjectNotFound(err) {
+			// The object was never uploaded.
+			return nil
+		}
 		return
 	}
 	if c.isCacheExclude(bucket, object) {
 		return
 	}
-	dcache, cerr := c.cache.getCachedFSLoc(ctx, bucket, object)
 	if cerr == nil {
-		_ = dcache.DeleteObject(ctx, bucket, object)
+		_ = dcache.Delete(ctx, bucket, object)
 	}
 	return
 }
@@ -646,6 +784,11 @@ func (c cacheObjects) isCacheExclude(bucket, object string) bool {
 // PutObject - caches the uploaded object for single Put operations
 func (c cacheObjects) PutObject(ctx context.Context, bucket, object string, r *hash.Reader, metadata map[string]string, opts ObjectOptions) (objInfo ObjectInfo, err error) {
 	putObjectFn := c.PutObjectFn
+	if c.strict {
+		objInfo, err = putObjectFn(ctx, bucket, object, r, metadata, opts)
+		c.invalidate(ctx, bucket, object)
+		return objInfo, err
+	}
 	dcache, err := c.cache.getCacheFS(ctx, bucket, object)
 	if err != nil {
 		// disk cache could not be located,execute backend call.
@@ -655,6 +798,9 @@ func (c cacheObjects) PutObject(ctx context.Context, bucket, object string, r *h
 
 	// fetch from backend if there is no space on cache drive
 	if !dcache.diskAvailable(size) {
+		if err = dcache.cancelPending(ctx, bucket, object); err != nil {
+			return ObjectInfo{}, err
+		}
 		return putObjectFn(ctx, bucket, object, r, metadata, opts)
 	}
 	// fetch from backend if cache exclude pattern or cache-control
@@ -663,6 +809,18 @@ func (c cacheObjects) PutObject(ctx context.Context, bucket, object string, r *h
 		dcache.Delete(ctx, bucket, object)
 		return putObjectFn(ctx, bucket, object, r, metadata, opts)
 	}
+	if c.writeback && !crypto.IsEncrypted(metadata) {
+		// The object could never be written back to a missing bucket.
+		if _, err = c.GetBucketInfoFn(ctx, bucket); err != nil {
+			return ObjectInfo{}, err
+		}
+		// Commit to the cache drive, the uploader writes the object to the backend.
+		return dcache.putPending(ctx, bucket, object, r, metadata, opts)
+	}
+	// A pending upload would overwrite the object written through.
+	if err = dcache.cancelPending(ctx, bucket, object); err != nil {
+		return ObjectInfo{}, err
+	}
 	objInfo = ObjectInfo{}
 	// Initialize pipe to stream data to backend
 	pipeReader, pipeWriter := io.Pipe()
@@ -676,6 +834,9 @@ func (c cacheObjects) PutObject(ctx context.Context, bucket, object string, r *h
 	if err != nil {
 		return ObjectInfo{}, err
 	}
+	// Both copies report the additional checksum verified by r.
+	hashReader.InheritChecksum(r)
+	cHashReader.InheritChecksum(r)
 	oinfoCh := make(chan ObjectInfo)
 	errCh := make(chan error)
 	go func() {
@@ -714,7 +875,7 @@ func (c cacheObjects) PutObject(ctx context.Context, bucket, object string, r *h
 func (c cacheObjects) NewMultipartUpload(ctx context.Context, bucket, object string, metadata map[string]string, opts ObjectOptions) (uploadID string, err error) {
 	newMultipartUploadFn := c.NewMultipartUploadFn
 
-	if c.isCacheExclude(bucket, object) || filterFromCache(metadata) {
+	if c.strict || c.isCacheExclude(bucket, object) || filterFromCache(metadata) {
 		return newMultipartUploadFn(ctx, bucket, object, metadata, opts)
 	}
 
@@ -736,6 +897,9 @@ func (c cacheObjects) NewMultipartUpload(ctx context.Context, bucket, object str
 // PutObjectPart - uploads part to backend and cache simultaneously.
 func (c cacheObjects) PutObjectPart(ctx context.Context, bucket, object, uploadID string, partID int, data *hash.Reader, opts ObjectOptions) (info PartInfo, err error) {
 	putObjectPartFn := c.PutObjectPartFn
+	if c.strict {
+		return putObjectPartFn(ctx, bucket, object, uploadID, partID, data, opts)
+	}
 	dcache, err := c.cache.getCacheFS(ctx, bucket, object)
 	if err != nil {
 		// disk cache could not be located,execute backend call.
@@ -806,7 +970,7 @@ func (c cacheObjects) PutObjectPart(ctx context.Context, bucket, object, uploadI
 func (c cacheObjects) AbortMultipartUpload(ctx context.Context, bucket, object, uploadID string) error {
 	abortMultipartUploadFn := c.AbortMultipartUploadFn
 
-	if c.isCacheExclude(bucket, object) {
+	if c.strict || c.isCacheExclude(bucket, object) {
 		return abortMultipartUploadFn(ctx, bucket, object, uploadID)
 	}
 
@@ -832,12 +996,21 @@ func (c cacheObjects) CompleteMultipartUpload(ctx context.Context, bucket, objec
 	if c.isCacheExclude(bucket, object) {
 		return completeMultipartUploadFn(ctx, bucket, object, uploadID, uploadedParts)
 	}
+	if c.strict {
+		objInfo, err = completeMultipartUploadFn(ctx, bucket, object, uploadID, uploadedParts)
+		c.invalidate(ctx, bucket, object)
+		return objInfo, err
+	}
 
 	dcache, err := c.cache.getCacheFS(ctx, bucket, object)
 	if err != nil {
 		// disk cache could not be located,execute backend call.
 		return completeMultipartUploadFn(ctx, bucket, object, uploadID, uploadedParts)
 	}
+	// A pending upload would overwrite the object completed on the backend.
+	if err = dcache.cancelPending(ctx, bucket, object); err != nil {
+		return
+	}
 	// perform backend operation
 	objInfo, err = completeMultipartUploadFn(ctx, bucket, object, uploadID, uploadedParts)
 	if err != nil {
@@ -907,47 +1080,24 @@ func newCache(config CacheConfig) (*diskCache, error) {
 			cfsObjects = append(cfsObjects, nil)
 			continue
 		}
-		if err := checkAtimeSupport(dir); err != nil {
-			return nil, errors.New("Atime support required for disk caching")
-		}
-		cache, err := newCacheFSObjects(dir, config.Expiry, config.MaxUse)
+		cache, err := newCacheFSObjects(dir, config)
 		if err != nil {
 			return nil, err
 		}
-		// Start the purging go-routine for entries that have expired
+		// Start the garbage collection go-routine evicting entries when usage is high
 		go cache.purge()
 
 		// Start trash purge routine for deleted buckets.
 		go cache.purgeTrash()
 
+		// Start the routine saving the access stats of cached objects.
+		go cache.saveAccessStatsLoop(cacheAccessSaveInterval, globalServiceDoneCh)
+
 		cfsObjects = append(cfsObjects, cache)
 	}
 	return &diskCache{cfs: cfsObjects}, nil
 }
 
-// Return error if Atime is disabled on the O/S
-func checkAtimeSupport(dir string) (err error) {
-	file, err := ioutil.TempFile(dir, "prefix")
-	if err != nil {
-		return
-	}
-	defer os.Remove(file.Name())
-	finfo1, err := os.Stat(file.Name())
-	if err != nil {
-		return
-	}
-	if _, err = io.Copy(ioutil.Discard, file); err != io.EOF {
-		return
-	}
-
-	finfo2, err := os.Stat(file.Name())
-
-	if atime.Get(finfo2).Equal(atime.Get(finfo1)) {
-		return errors.New("Atime not supported")
-	}
-	return
-}
-
 // Returns cacheObjects for use by Server.
 func newServerCacheObjects(config CacheConfig) (CacheObjectLayer, error) {
 	// list of disk caches for cache "drives" specified in config.json or MINIO_CACHE_DRIVES env var.
@@ -955,11 +1105,17 @@ func newServerCacheObjects(config CacheConfig) (CacheObjectLayer, error) {
 	if err != nil {
 		return nil, err
 	}
+	commit, err := parseCacheCommit(config.Commit)
+	if err != nil {
+		return nil, err
+	}
 
-	return &cacheObjects{
-		cache:    dcache,
-		exclude:  config.Exclude,
-		listPool: newTreeWalkPool(globalLookupTimeout),
+	c := &cacheObjects{
+		cache:     dcache,
+		exclude:   config.Exclude,
+		strict:    globalCacheStrict,
+		writeback: commit == cacheCommitWriteBack,
+		listPool:  newTreeWalkPool(globalLookupTimeout),
 		GetObjectFn: func(ctx context.Context, bucket, object string, startOffset int64, length int64, writer io.Writer, etag string, opts ObjectOptions) error {
 			return newObjectLayerFn().GetObject(ctx, bucket, object, startOffset, length, writer, etag, opts)
 		},
@@ -1002,7 +1158,15 @@ func newServerCacheObjects(config CacheConfig) (CacheObjectLayer, error) {
 		DeleteBucketFn: func(ctx context.Context, bucket string) error {
 			return newObjectLayerFn().DeleteBucket(ctx, bucket)
 		},
-	}, nil
+	}
+	// Start the uploaders of the objects written back, which also resume
+	// the uploads pending before a restart or a switch to writethrough.
+	for _, cfs := range dcache.cfs {
+		if cfs != nil {
+			go c.uploader(cfs)
+		}
+	}
+	return c, nil
 }
 
 type cacheControl struct {
@@ -19,6 +19,9 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"reflect"
 	"testing"
 	"time"
@@ -28,7 +31,7 @@ import (
 
 // Initialize cache FS objects.
 func initCacheFSObjects(disk string, cacheMaxUse int) (*cacheFSObjects, error) {
-	return newCacheFSObjects(disk, globalCacheExpiry, cacheMaxUse)
+	return newCacheFSObjects(disk, CacheConfig{Expiry: globalCacheExpiry, MaxUse: cacheMaxUse})
 }
 
 // inits diskCache struct for nDisks
@@ -390,3 +393,222 @@ func TestFilterFromCache(t *testing.T) {
 		}
 	}
 }
+
+// Test eviction order of cache entries.
+func TestSortCacheEntries(t *testing.T) {
+	now := UTCNow()
+	expiry := now.AddDate(0, 0, -30)
+	entries := []cacheEntry{
+		{object: "recent-popular", atime: now.Add(-time.Minute), hits: 10},
+		{object: "old-popular", atime: now.Add(-time.Hour), hits: 20},
+		{object: "recent-unpopular", atime: now.Add(-2 * time.Minute), hits: 1},
+		{object: "stale", atime: now, hits: 100, stale: true},
+		{object: "expired", atime: now.AddDate(0, 0, -31), hits: 50},
+	}
+	testCases := []struct {
+		eviction string
+		expected []string
+	}{
+		{cacheEvictionLRU, []string{"expired", "stale", "old-popular", "recent-unpopular", "recent-popular"}},
+		{cacheEvictionLFU, []string{"expired", "stale", "recent-unpopular", "recent-popular", "old-popular"}},
+	}
+	for i, testCase := range testCases {
+		sorted := append([]cacheEntry{}, entries...)
+		sortCacheEntries(sorted, testCase.eviction, expiry)
+		var objects []string
+		for _, entry := range sorted {
+			objects = append(objects, entry.object)
+		}
+		if !reflect.DeepEqual(objects, testCase.expected) {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expected, objects)
+		}
+	}
+}
+
+// Test access stats recorded in cache metadata.
+func TestCacheAccessStats(t *testing.T) {
+	fsDirs, err := getRandomDisks(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := initDiskCaches(fsDirs, 100, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := d.cfs[0]
+	ctx := context.Background()
+	bucketName := "testbucket"
+	objectName := "testobject"
+	content := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	opts := ObjectOptions{}
+
+	hashReader, err := hash.NewReader(bytes.NewReader([]byte(content)), int64(len(content)), "", "", int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = cache.Put(ctx, bucketName, objectName, hashReader, map[string]string{"etag": "061208c10af71a30c6dcd6cf5d89f0fe"}, opts); err != nil {
+		t.Fatal(err)
+	}
+	objInfo, err := cache.GetObjectInfo(ctx, bucketName, objectName, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	atime, hits := getCacheAccessStats(objInfo)
+	if hits != 0 {
+		t.Errorf("Expected no hits, got %d", hits)
+	}
+
+	cache.recordAccess(bucketName, objectName, false)
+	cache.recordAccess(bucketName, objectName, false)
+
+	// Accesses are counted in memory until they are saved.
+	if objInfo, err = cache.GetObjectInfo(ctx, bucketName, objectName, opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, hits = getCacheAccessStats(objInfo); hits != 0 {
+		t.Errorf("Expected no saved hits, got %d", hits)
+	}
+	entries, err := cache.listCacheEntries(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].object != objectName || entries[0].hits != 2 {
+		t.Errorf("Unexpected cache entries %v", entries)
+	}
+
+	cache.saveAccessStats(ctx)
+	if objInfo, err = cache.GetObjectInfo(ctx, bucketName, objectName, opts); err != nil {
+		t.Fatal(err)
+	}
+	lastAccess, hits := getCacheAccessStats(objInfo)
+	if hits != 2 {
+		t.Errorf("Expected 2 hits, got %d", hits)
+	}
+	if lastAccess.Before(atime) {
+		t.Errorf("Expected access time after %s, got %s", atime, lastAccess)
+	}
+	if objInfo.ETag != "061208c10af71a30c6dcd6cf5d89f0fe" {
+		t.Errorf("Expected etag to be kept, got %s", objInfo.ETag)
+	}
+
+	// Saved accesses are not counted twice.
+	cache.recordAccess(bucketName, objectName, false)
+	if entries, err = cache.listCacheEntries(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].object != objectName || entries[0].hits != 3 {
+		t.Errorf("Unexpected cache entries %v", entries)
+	}
+	cache.saveAccessStats(ctx)
+	if objInfo, err = cache.GetObjectInfo(ctx, bucketName, objectName, opts); err != nil {
+		t.Fatal(err)
+	}
+	if _, hits = getCacheAccessStats(objInfo); hits != 3 {
+		t.Errorf("Expected 3 hits, got %d", hits)
+	}
+
+	// Accesses to evicted entries are dropped.
+	if err = cache.Delete(ctx, bucketName, objectName); err != nil {
+		t.Fatal(err)
+	}
+	cache.recordAccess(bucketName, objectName, false)
+	cache.saveAccessStats(ctx)
+	if _, err = cache.GetObjectInfo(ctx, bucketName, objectName, opts); err == nil {
+		t.Fatal("Expected evicted entry not to be recreated")
+	}
+}
+
+// Test cache validated against the backend on every request.
+func TestStrictCache(t *testing.T) {
+	fsDirs, err := getRandomDisks(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := initDiskCaches(fsDirs, 100, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	bucketName := "testbucket"
+	objectName := "testobject"
+	content := "cached content"
+	etag := "f45e2a4e4bba2a7a0b80e2be52ee3c0c"
+
+	var backendInfo ObjectInfo
+	var backendErr error
+	c := cacheObjects{
+		cache:  d,
+		strict: true,
+		GetObjectInfoFn: func(ctx context.Context, bucket, object string, opts ObjectOptions) (ObjectInfo, error) {
+			return backendInfo, backendErr
+		},
+		GetObjectNInfoFn: func(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, lockType LockType, opts ObjectOptions) (*GetObjectReader, error) {
+			if backendErr != nil {
+				return nil, backendErr
+			}
+			return NewGetObjectReaderFromReader(bytes.NewReader([]byte("backend content")), backendInfo), nil
+		},
+		PutObjectFn: func(ctx context.Context, bucket, object string, data *hash.Reader, metadata map[string]string, opts ObjectOptions) (ObjectInfo, error) {
+			_, err := io.Copy(ioutil.Discard, data)
+			return ObjectInfo{Bucket: bucket, Name: object}, err
+		},
+	}
+	putCache := func() {
+		hashReader, err := hash.NewReader(bytes.NewReader([]byte(content)), int64(len(content)), "", "", int64(len(content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = d.cfs[0].Put(ctx, bucketName, objectName, hashReader, map[string]string{"etag": etag}, ObjectOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	readObject := func() (string, error) {
+		gr, err := c.GetObjectNInfo(ctx, bucketName, objectName, nil, nil, readLock, ObjectOptions{})
+		if err != nil {
+			return "", err
+		}
+		defer gr.Close()
+		b, err := ioutil.ReadAll(gr)
+		return string(b), err
+	}
+	putCache()
+
+	// Cached objects are not served when the backend is down.
+	backendErr = BackendDown{}
+	if _, err = c.GetObjectInfo(ctx, bucketName, objectName, ObjectOptions{}); err == nil {
+		t.Fatal("Expected GetObjectInfo to fail when the backend is down")
+	}
+	if _, err = readObject(); err == nil {
+		t.Fatal("Expected GetObjectNInfo to fail when the backend is down")
+	}
+
+	// Cached objects are served once validated.
+	backendErr = nil
+	backendInfo = ObjectInfo{Bucket: bucketName, Name: objectName, ETag: etag, Size: int64(len(content))}
+	if data, err := readObject(); err != nil || data != content {
+		t.Fatalf("Expected cached content, got %q (%v)", data, err)
+	}
+
+	// Versions are served by the backend.
+	gr, err := c.GetObjectNInfo(ctx, bucketName, objectName, nil, nil, readLock, ObjectOptions{VersionID: "v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(gr)
+	gr.Close()
+	if err != nil || string(data) != "backend content" {
+		t.Fatalf("Expected backend content, got %q (%v)", data, err)
+	}
+
+	// Writes invalidate cached objects.
+	hashReader, err := hash.NewReader(bytes.NewReader([]byte("new content")), 11, "", "", 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.PutObject(ctx, bucketName, objectName, hashReader, map[string]string{}, ObjectOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if d.cfs[0].Exists(ctx, bucketName, objectName) {
+		t.Fatal("Expected cached object to be invalidated")
+	}
+}
@@ -958,6 +958,7 @@ func (fs *FSObjects) putObject(ctx context.Context, bucket string, object string
 	}
 
 	fsMeta.Meta["etag"] = hex.EncodeToString(data.MD5Current())
+	saveChecksumMetadata(fsMeta.Meta, data)
 
 	// Should return IncompleteBody{} error when reader has fewer
 	// bytes than specified in request header.
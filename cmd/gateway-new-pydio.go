@@ -10,7 +10,11 @@ import (
 	"github.com/pydio/minio-srv/pkg/auth"
 )
 
-func StartPydioGateway(ctx context.Context, gw Gateway, gatewayAddr string, accessKey string, secretKey string, log logger.PydioLogger, certFile string, certKey string) {
+// StartPydioGateway starts the gateway embedded in Cells. Objects are cached
+// on the drives of cacheConfig, if any, and validated against the backend on
+// every request. Bucket policies and the IAM configuration are kept in the
+// Cells configuration, shared by the gateway instances.
+func StartPydioGateway(ctx context.Context, gw Gateway, gatewayAddr string, accessKey string, secretKey string, log logger.PydioLogger, certFile string, certKey string, cacheConfig CacheConfig) {
 
 	target := logger.NewPydioTarget(log)
 	logger.AddTarget(target)
@@ -24,6 +28,10 @@ func StartPydioGateway(ctx context.Context, gw Gateway, gatewayAddr string, acce
 	globalIsEnvCreds = true
 	globalActiveCred = cred
 
+	if len(cacheConfig.Drives) > 0 {
+		logger.FatalIf(setPydioCacheConfig(cacheConfig), "Unable to initialize disk caching")
+	}
+
 	globalHandlers = append(globalHandlers,
 		getPydioAuthHandlerFunc(true),
 		servicecontext.HttpSpanHandlerWrapper,
@@ -31,8 +39,17 @@ func StartPydioGateway(ctx context.Context, gw Gateway, gatewayAddr string, acce
 
 	StartGateway(cliContext, gw, true)
 
-	globalIAMSys = NewJwtIAMSys()
-	globalPolicySys = NewMemoryPolicySys()
+	configStore := newPydioCellsConfigStore()
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = &pydioPolicyObjects{ObjectLayer: globalObjectAPI, policies: pydioPolicyStore{store: configStore}}
+	globalObjLayerMutex.Unlock()
+
+	iamSys := NewJwtIAMSys(configStore)
+	logger.LogIf(ctx, iamSys.Init(globalObjectAPI))
+	globalIAMSys = iamSys
+	policySys := NewMemoryPolicySys(configStore)
+	logger.LogIf(ctx, policySys.Init(globalObjectAPI))
+	globalPolicySys = policySys
 
 	stopProcess := func() bool {
 		var err error
@@ -61,3 +78,58 @@ func StartPydioGateway(ctx context.Context, gw Gateway, gatewayAddr string, acce
 	}
 
 }
+
+// setPydioCacheConfig enables the disk cache of the gateway. As access is
+// checked by the backend on each request, the cache is strict: cached objects
+// are only served once their ETag matches the backend one, and objects written
+// through the gateway are invalidated rather than cached.
+func setPydioCacheConfig(config CacheConfig) error {
+	drives, err := parseCacheDrives(config.Drives)
+	if err != nil {
+		return err
+	}
+	excludes, err := parseCacheExcludes(config.Exclude)
+	if err != nil {
+		return err
+	}
+	if config.Expiry < 0 {
+		return uiErrInvalidCacheExpiryValue(nil).Msg("cache expiry (%d) should not be negative", config.Expiry)
+	}
+	if config.MaxUse < 0 || config.MaxUse > 100 {
+		return uiErrInvalidCacheMaxUse(nil).Msg("cache max use (%d) should be between 0-100", config.MaxUse)
+	}
+	if err = checkCacheWatermarks(config.WatermarkLow, config.WatermarkHigh); err != nil {
+		return err
+	}
+	eviction, err := parseCacheEviction(config.Eviction)
+	if err != nil {
+		return err
+	}
+	commit, err := parseCacheCommit(config.Commit)
+	if err != nil {
+		return err
+	}
+	if commit == cacheCommitWriteBack {
+		// Uploads must be checked by the backend before they succeed.
+		return uiErrInvalidCacheCommit(nil).Msg("cache commit mode (%s) is not supported by the strict cache", config.Commit)
+	}
+
+	globalCacheDrives = drives
+	globalCacheExcludes = excludes
+	if config.Expiry > 0 {
+		globalCacheExpiry = config.Expiry
+	}
+	if config.MaxUse > 0 {
+		globalCacheMaxUse = config.MaxUse
+	}
+	if config.WatermarkLow > 0 {
+		globalCacheWatermarkLow = config.WatermarkLow
+	}
+	if config.WatermarkHigh > 0 {
+		globalCacheWatermarkHigh = config.WatermarkHigh
+	}
+	globalCacheEviction = eviction
+	globalCacheStrict = true
+	globalIsDiskCacheEnabled = true
+	return nil
+}
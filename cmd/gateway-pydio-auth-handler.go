@@ -13,7 +13,6 @@ import (
 	context2 "github.com/pydio/cells/common/utils/context"
 	"github.com/pydio/cells/common/utils/permissions"
 	"github.com/pydio/minio-srv/cmd/logger"
-	auth2 "github.com/pydio/minio-srv/pkg/auth"
 )
 
 // authHandler - handles all the incoming authorization headers and validates them if possible.
@@ -86,8 +85,10 @@ func (a pydioAuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		userName = claims.Name
-		if storeJwtInGlobalIAM {
-			globalIAMSys.SetTempUser(rawIDToken, auth2.Credentials{}, "")
+		// Claims are checked against the IAM policies by checkRequestAuthType.
+		ctx = context.WithValue(ctx, claim.ContextKey, claims)
+		if iamSys, ok := globalIAMSys.(*JwtIAMSys); ok && storeJwtInGlobalIAM {
+			iamSys.setClaims(rawIDToken, claims)
 		}
 
 	} else if agent, aOk := r.Header["User-Agent"]; aOk && strings.Contains(strings.Join(agent, ""), "pydio.sync.client.s3") {
@@ -109,7 +110,7 @@ func (a pydioAuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				anonClaim := claim.Claims{
 					Name:      common.PydioS3AnonUsername,
 					Roles:     strings.Join(s, ","),
-					Profile:   "anon",
+					Profile:   pydioProfileAnon,
 					GroupPath: "/",
 				}
 				ctx = context.WithValue(ctx, claim.ContextKey, anonClaim)
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pydio/cells/common"
+	"github.com/pydio/cells/common/config"
+	"github.com/pydio/minio-srv/cmd/logger"
+)
+
+// ErrPydioConfigNotFound - returned by config stores for missing files.
+var ErrPydioConfigNotFound = errConfigNotFound
+
+// PydioConfigStore - storage shared by the Pydio gateway instances, holding
+// the bucket policies and the IAM configuration set through the gateway.
+type PydioConfigStore interface {
+	// ReadConfig returns ErrPydioConfigNotFound if file does not exist.
+	ReadConfig(ctx context.Context, file string) ([]byte, error)
+	SaveConfig(ctx context.Context, file string, data []byte) error
+	// DeleteConfig returns ErrPydioConfigNotFound if file does not exist.
+	DeleteConfig(ctx context.Context, file string) error
+	// ListConfigs returns the files of directory prefix.
+	ListConfigs(ctx context.Context, prefix string) ([]string, error)
+	// Watch returns a channel receiving a value whenever files are changed,
+	// by this or another gateway instance, until doneCh is closed. Stores unable to
+	// notify changes return nil, their files are reloaded periodically.
+	Watch(doneCh <-chan struct{}) <-chan struct{}
+}
+
+// Path of the gateway files in the Cells configuration.
+var pydioConfigPath = []string{"services", common.ServiceGatewayData, "minio"}
+
+// pydioCellsConfigStore - stores the files in the Cells configuration, which
+// is shared by the gateway instances and cannot be reached by S3 clients.
+type pydioCellsConfigStore struct{}
+
+// newPydioCellsConfigStore - creates a config store keeping files in the
+// Cells configuration.
+func newPydioCellsConfigStore() PydioConfigStore {
+	return pydioCellsConfigStore{}
+}
+
+// Returns the Cells configuration path of file.
+func (s pydioCellsConfigStore) path(file string) []string {
+	path := append([]string{}, pydioConfigPath...)
+	if file = strings.Trim(file, "/"); file != "" {
+		path = append(path, strings.Split(file, "/")...)
+	}
+	return path
+}
+
+// ReadConfig - reads file.
+func (s pydioCellsConfigStore) ReadConfig(ctx context.Context, file string) ([]byte, error) {
+	data := config.Get(s.path(file)...).String()
+	if data == "" {
+		return nil, errConfigNotFound
+	}
+	return []byte(data), nil
+}
+
+// SaveConfig - saves data in file.
+func (s pydioCellsConfigStore) SaveConfig(ctx context.Context, file string, data []byte) error {
+	if err := config.Set(string(data), s.path(file)...); err != nil {
+		return err
+	}
+	return config.Save(common.PydioSystemUsername, "Update gateway "+file)
+}
+
+// DeleteConfig - removes file.
+func (s pydioCellsConfigStore) DeleteConfig(ctx context.Context, file string) error {
+	if config.Get(s.path(file)...).String() == "" {
+		return errConfigNotFound
+	}
+	if err := config.Del(s.path(file)...); err != nil {
+		return err
+	}
+	return config.Save(common.PydioSystemUsername, "Delete gateway "+file)
+}
+
+// ListConfigs - lists the files of directory prefix.
+func (s pydioCellsConfigStore) ListConfigs(ctx context.Context, prefix string) ([]string, error) {
+	var files []string
+	for name := range config.Get(s.path(prefix)...).StringMap() {
+		files = append(files, strings.TrimSuffix(prefix, "/")+"/"+name)
+	}
+	return files, nil
+}
+
+// Watch - notifies the changes made to the files of the gateway, until
+// doneCh is closed.
+func (s pydioCellsConfigStore) Watch(doneCh <-chan struct{}) <-chan struct{} {
+	watcher, err := config.Watch(pydioConfigPath...)
+	if err != nil {
+		logger.LogIf(context.Background(), err)
+		return nil
+	}
+
+	go func() {
+		<-doneCh
+		watcher.Stop()
+	}()
+
+	changeCh := make(chan struct{}, 1)
+	go func() {
+		for {
+			if _, err := watcher.Next(); err != nil {
+				return
+			}
+			select {
+			case changeCh <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return changeCh
+}
+
+// watchPydioConfig - calls reload in background every interval, and
+// whenever store notifies a change, until the server stops.
+func watchPydioConfig(store PydioConfigStore, interval time.Duration, reload func()) {
+	changeCh := store.Watch(globalServiceDoneCh)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-globalServiceDoneCh:
+				return
+			case <-ticker.C:
+				reload()
+			case <-changeCh:
+				reload()
+			}
+		}
+	}()
+}
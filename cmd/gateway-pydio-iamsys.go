@@ -2,57 +2,217 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/patrickmn/go-cache"
 	auth2 "github.com/pydio/cells/common/auth"
+	"github.com/pydio/cells/common/auth/claim"
+	"github.com/pydio/minio-srv/cmd/logger"
 	"github.com/pydio/minio-srv/pkg/auth"
 	"github.com/pydio/minio-srv/pkg/iam/policy"
 	"github.com/pydio/minio-srv/pkg/madmin"
+	"github.com/pydio/minio-srv/pkg/policy"
+	"github.com/pydio/minio-srv/pkg/policy/condition"
 )
 
-func NewJwtIAMSys() *JwtIAMSys {
+// Profiles of Cells users, as found in their JWT claims.
+const (
+	pydioProfileAdmin    = "admin"
+	pydioProfileStandard = "standard"
+	pydioProfileShared   = "shared"
+	pydioProfileAnon     = "anon"
+)
+
+const (
+	// IAM configuration file in the config store.
+	pydioIAMConfigFile = "iam/config.json"
+
+	// Current version of the IAM configuration file.
+	pydioIAMConfigVersion = 1
+
+	// Prefix of the names given to SetUserPolicy to set the policy of a
+	// profile rather than of a role.
+	pydioProfilePolicyPrefix = "profile:"
+
+	// Prefixes of the gateway buckets the users of shared links and the
+	// anonymous users are restricted to by default.
+	pydioSharedPrefix = "shared/"
+	pydioAnonPrefix   = "public/"
+)
+
+// Buckets served by the Cells router.
+var pydioBuckets = []string{"io", "data"}
+
+// pydioIAMConfig - canned policies and policies of the roles, profiles and
+// group paths, as saved in the IAM configuration file.
+type pydioIAMConfig struct {
+	Version         int                         `json:"version"`
+	CannedPolicies  map[string]iampolicy.Policy `json:"cannedPolicies"`
+	RolePolicies    map[string]string           `json:"rolePolicies"`
+	ProfilePolicies map[string]string           `json:"profilePolicies"`
+	GroupPolicies   map[string]string           `json:"groupPolicies"`
+}
+
+// newPydioIAMConfig - returns the configuration used until one is saved.
+func newPydioIAMConfig() pydioIAMConfig {
+	config := pydioIAMConfig{
+		Version:         pydioIAMConfigVersion,
+		CannedPolicies:  make(map[string]iampolicy.Policy),
+		RolePolicies:    make(map[string]string),
+		ProfilePolicies: make(map[string]string),
+		GroupPolicies:   make(map[string]string),
+	}
+	setPydioDefaultCannedPolicies(config.CannedPolicies)
+	for profile, policyName := range pydioDefaultProfilePolicies {
+		config.ProfilePolicies[profile] = policyName
+	}
+	return config
+}
+
+// loadPydioIAMConfig - reads the IAM configuration of store.
+func loadPydioIAMConfig(ctx context.Context, store PydioConfigStore) (pydioIAMConfig, error) {
+	config := newPydioIAMConfig()
+	data, err := store.ReadConfig(ctx, pydioIAMConfigFile)
+	if err != nil {
+		if err == errConfigNotFound {
+			return config, nil
+		}
+		return config, err
+	}
+	config = pydioIAMConfig{}
+	if err = json.Unmarshal(data, &config); err != nil {
+		return config, err
+	}
+	if config.CannedPolicies == nil {
+		config.CannedPolicies = make(map[string]iampolicy.Policy)
+	}
+	setPydioDefaultCannedPolicies(config.CannedPolicies)
+	for _, m := range []*map[string]string{&config.RolePolicies, &config.ProfilePolicies, &config.GroupPolicies} {
+		if *m == nil {
+			*m = make(map[string]string)
+		}
+	}
+	return config, nil
+}
+
+// Canned policies applied by default to the users of each profile. Users
+// of shared links and anonymous users are restricted to their prefixes.
+var pydioDefaultProfilePolicies = map[string]string{
+	pydioProfileAdmin:    "readwrite",
+	pydioProfileStandard: "readwrite",
+	pydioProfileShared:   "pydio-shared",
+	pydioProfileAnon:     "pydio-anon",
+}
+
+// newPydioPrefixPolicy - returns a policy allowing actions on the objects
+// under prefix of the gateway buckets, and listing them if list is set.
+func newPydioPrefixPolicy(prefix string, list bool, actions ...iampolicy.Action) iampolicy.Policy {
+	var buckets, objects []iampolicy.Resource
+	for _, bucket := range pydioBuckets {
+		buckets = append(buckets, iampolicy.NewResource(bucket, ""))
+		objects = append(objects, iampolicy.NewResource(bucket, prefix+"*"))
+	}
+
+	statements := []iampolicy.Statement{
+		iampolicy.NewStatement(
+			policy.Allow,
+			iampolicy.NewActionSet(iampolicy.GetBucketLocationAction),
+			iampolicy.NewResourceSet(buckets...),
+			condition.NewFunctions(),
+		),
+		iampolicy.NewStatement(
+			policy.Allow,
+			iampolicy.NewActionSet(actions...),
+			iampolicy.NewResourceSet(objects...),
+			condition.NewFunctions(),
+		),
+	}
+	if list {
+		prefixFunc, err := condition.NewStringLikeFunc(condition.S3Prefix, prefix+"*")
+		logger.CriticalIf(context.Background(), err)
+		statements = append(statements, iampolicy.NewStatement(
+			policy.Allow,
+			iampolicy.NewActionSet(iampolicy.ListBucketAction),
+			iampolicy.NewResourceSet(buckets...),
+			condition.NewFunctions(prefixFunc),
+		))
+	}
+	return iampolicy.Policy{Version: iampolicy.DefaultVersion, Statements: statements}
+}
+
+// setPydioDefaultCannedPolicies - adds the default canned policies, and the
+// ones of the users of shared links and of the anonymous users, unless they
+// were replaced.
+func setPydioDefaultCannedPolicies(policies map[string]iampolicy.Policy) {
+	setDefaultCannedPolicies(policies)
+	if _, ok := policies["pydio-shared"]; !ok {
+		policies["pydio-shared"] = newPydioPrefixPolicy(pydioSharedPrefix, true,
+			iampolicy.GetObjectAction, iampolicy.PutObjectAction)
+	}
+	if _, ok := policies["pydio-anon"]; !ok {
+		policies["pydio-anon"] = newPydioPrefixPolicy(pydioAnonPrefix, false,
+			iampolicy.GetObjectAction)
+	}
+}
+
+// NewJwtIAMSys - creates the IAM system of the Pydio gateway, keeping its
+// configuration in store. The default configuration cannot be changed if
+// store is nil.
+func NewJwtIAMSys(store PydioConfigStore) *JwtIAMSys {
 	j := &JwtIAMSys{}
 	j.jwtVerifier = auth2.DefaultJWTVerifier()
 	j.quickCache = cache.New(time.Second*1, time.Second*30)
+	j.store = store
+	j.setConfig(newPydioIAMConfig())
+	j.verify = func(token string) (claim.Claims, error) {
+		_, claims, err := j.jwtVerifier.Verify(context.Background(), token)
+		return claims, err
+	}
 	return j
 }
 
+// JwtIAMSys - IAM system authenticating Cells users by their JWT. Access
+// is granted by the canned policies set for the roles, the profile and
+// the group of a user: as each Cells user has its own role, user
+// policies are set on roles. The policies are kept in a config store
+// shared by the gateway instances.
 type JwtIAMSys struct {
 	IAMSys
+	// Claims of the verified JWTs, the cache is safe for concurrent use
+	// and is not guarded by the IAMSys lock.
 	quickCache  *cache.Cache
 	jwtVerifier *auth2.JWTVerifier
+	// verify returns the claims of a valid JWT.
+	verify func(token string) (claim.Claims, error)
+	// Canned policies of roles, profiles and group paths.
+	rolePolicyMap    map[string]string
+	profilePolicyMap map[string]string
+	groupPolicyMap   map[string]string
+	store            PydioConfigStore
 }
 
-// GetUser - get user credentials
+// GetUser - get user credentials, valid JWTs are used as access keys.
 func (sys *JwtIAMSys) GetUser(accessKey string) (cred auth.Credentials, ok bool) {
-	sys.RLock()
-	defer sys.RUnlock()
-
-	// Check if it's already cached
-	if cred, ok := sys.quickCache.Get(accessKey); ok {
-		return cred.(auth.Credentials), true
-	}
-
-	// Check if it's a valid JWT
-	if _, _, e := sys.jwtVerifier.Verify(context.Background(), accessKey); e == nil {
-		cred, _ = auth.CreateCredentials(accessKey, "gatewaysecret")
-		ok = true
-		sys.quickCache.Set(accessKey, cred, cache.DefaultExpiration)
+	if _, ok = sys.getClaims(accessKey); !ok {
+		return cred, false
 	}
-
-	return cred, ok
+	cred, _ = auth.CreateCredentials(accessKey, "gatewaysecret")
+	return cred, true
 }
 
-// SetTempUser - set temporary user credentials, these credentials have an expiry.
+// SetTempUser - not supported, Cells users authenticate with their JWT.
 func (sys *JwtIAMSys) SetTempUser(accessKey string, cred auth.Credentials, policyName string) error {
-	sys.RLock()
-	defer sys.RUnlock()
-
-	cred, _ = auth.CreateCredentials(accessKey, "gatewaysecret")
-	sys.quickCache.Set(accessKey, cred, cache.DefaultExpiration)
+	return NotImplemented{}
+}
 
-	return nil
+// setClaims - caches the claims of a JWT verified by the Pydio auth handler,
+// to use it as access key without verifying it again.
+func (sys *JwtIAMSys) setClaims(token string, claims claim.Claims) {
+	sys.quickCache.Set(token, claims, cache.DefaultExpiration)
 }
 
 // Load - load iam.json
@@ -60,54 +220,413 @@ func (sys *JwtIAMSys) Load(objAPI ObjectLayer) error {
 	return sys.Init(objAPI)
 }
 
-// Init - initializes config system from iam.json
+// Init - loads the configuration of the store and reloads it in background.
 func (sys *JwtIAMSys) Init(objAPI ObjectLayer) error {
+	if sys.store == nil {
+		return nil
+	}
+	if err := sys.refresh(); err != nil {
+		return err
+	}
+
+	watchPydioConfig(sys.store, globalRefreshIAMInterval, func() {
+		sys.refresh()
+	})
+	return nil
+}
+
+// refresh - replaces the configuration in memory with the one of the store.
+func (sys *JwtIAMSys) refresh() error {
+	config, err := loadPydioIAMConfig(context.Background(), sys.store)
+	if err != nil {
+		logger.LogIf(context.Background(), err)
+		return err
+	}
+
+	sys.Lock()
+	defer sys.Unlock()
+
+	sys.setConfig(config)
+	return nil
+}
+
+// setConfig - replaces the configuration in memory, the caller must hold
+// the lock.
+func (sys *JwtIAMSys) setConfig(config pydioIAMConfig) {
+	sys.iamCannedPolicyMap = config.CannedPolicies
+	sys.rolePolicyMap = config.RolePolicies
+	sys.profilePolicyMap = config.ProfilePolicies
+	sys.groupPolicyMap = config.GroupPolicies
+}
+
+// updateConfig - applies f to the configuration of the store, then saves it
+// and replaces the configuration in memory. The configuration is read from
+// the store first to keep the changes made through other gateway instances.
+func (sys *JwtIAMSys) updateConfig(f func(config *pydioIAMConfig) error) error {
+	if sys.store == nil {
+		return NotImplemented{}
+	}
+
+	sys.Lock()
+	defer sys.Unlock()
+
+	ctx := context.Background()
+	config, err := loadPydioIAMConfig(ctx, sys.store)
+	if err != nil {
+		return err
+	}
+	if err = f(&config); err != nil {
+		return err
+	}
+	config.Version = pydioIAMConfigVersion
+	data, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if err = sys.store.SaveConfig(ctx, pydioIAMConfigFile, data); err != nil {
+		return err
+	}
+	sys.setConfig(config)
 	return nil
 }
 
 // DeleteCannedPolicy - deletes a canned policy.
 func (sys *JwtIAMSys) DeleteCannedPolicy(policyName string) error {
-	return nil
+	if policyName == "" {
+		return errInvalidArgument
+	}
+
+	return sys.updateConfig(func(config *pydioIAMConfig) error {
+		delete(config.CannedPolicies, policyName)
+		return nil
+	})
 }
 
 // ListCannedPolicies - lists all canned policies.
 func (sys *JwtIAMSys) ListCannedPolicies() (map[string][]byte, error) {
 	var cannedPolicyMap = make(map[string][]byte)
+
+	sys.RLock()
+	defer sys.RUnlock()
+
+	for k, v := range sys.iamCannedPolicyMap {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		cannedPolicyMap[k] = data
+	}
+
 	return cannedPolicyMap, nil
 }
 
 // SetCannedPolicy - sets a new canned policy.
 func (sys *JwtIAMSys) SetCannedPolicy(policyName string, p iampolicy.Policy) error {
-	return nil
+	if p.IsEmpty() || policyName == "" {
+		return errInvalidArgument
+	}
+
+	return sys.updateConfig(func(config *pydioIAMConfig) error {
+		config.CannedPolicies[policyName] = p
+		return nil
+	})
 }
 
-// SetUserPolicy - sets policy to given user name.
-func (sys *JwtIAMSys) SetUserPolicy(accessKey, policyName string) error {
-	return nil
+// SetUserPolicy - sets policy to given role, users are given the
+// policies of their roles. Names prefixed with "profile:" set the policy
+// of a profile instead.
+func (sys *JwtIAMSys) SetUserPolicy(role, policyName string) error {
+	if strings.HasPrefix(role, pydioProfilePolicyPrefix) {
+		return sys.SetProfilePolicy(strings.TrimPrefix(role, pydioProfilePolicyPrefix), policyName)
+	}
+	return sys.setPolicy(func(config *pydioIAMConfig) map[string]string {
+		return config.RolePolicies
+	}, role, policyName)
+}
+
+// AttachUserPolicy - not supported, roles have a single policy set with
+// SetUserPolicy.
+func (sys *JwtIAMSys) AttachUserPolicy(role, policyName string) error {
+	return NotImplemented{}
 }
 
-// DeleteUser - set user credentials.
+// DetachUserPolicy - not supported, roles have a single policy set with
+// SetUserPolicy.
+func (sys *JwtIAMSys) DetachUserPolicy(role, policyName string) error {
+	return NotImplemented{}
+}
+
+// SetUserInlinePolicy - not supported, roles have a single policy set
+// with SetUserPolicy.
+func (sys *JwtIAMSys) SetUserInlinePolicy(role string, p *iampolicy.Policy) error {
+	return NotImplemented{}
+}
+
+// GetUserPolicies - returns the policy of given role, or of given profile
+// for names prefixed with "profile:".
+func (sys *JwtIAMSys) GetUserPolicies(role string) (madmin.UserPolicies, error) {
+	sys.RLock()
+	defer sys.RUnlock()
+
+	policyMap := sys.rolePolicyMap
+	if strings.HasPrefix(role, pydioProfilePolicyPrefix) {
+		policyMap = sys.profilePolicyMap
+		role = strings.TrimPrefix(role, pydioProfilePolicyPrefix)
+	}
+
+	var policies madmin.UserPolicies
+	if policyName, ok := policyMap[role]; ok {
+		policies.Policies = []string{policyName}
+	}
+	return policies, nil
+}
+
+// SetProfilePolicy - sets policy to the users of given profile, an empty
+// policy name removes the policy of the profile.
+func (sys *JwtIAMSys) SetProfilePolicy(profile, policyName string) error {
+	return sys.setPolicy(func(config *pydioIAMConfig) map[string]string {
+		return config.ProfilePolicies
+	}, profile, policyName)
+}
+
+// SetGroupPolicy - sets policy to the users of given group path and of
+// its sub groups, an empty policy name removes the policy of the group.
+func (sys *JwtIAMSys) SetGroupPolicy(groupPath, policyName string) error {
+	return sys.setPolicy(func(config *pydioIAMConfig) map[string]string {
+		return config.GroupPolicies
+	}, strings.TrimSuffix(groupPath, "/")+"/", policyName)
+}
+
+// AddUsersToGroup - not supported, group memberships of Cells users are
+// read from their claims.
+func (sys *JwtIAMSys) AddUsersToGroup(group string, members []string) error {
+	return NotImplemented{}
+}
+
+// RemoveUsersFromGroup - not supported, group memberships of Cells users
+// are read from their claims.
+func (sys *JwtIAMSys) RemoveUsersFromGroup(group string, members []string) error {
+	return NotImplemented{}
+}
+
+// GetGroupDescription - returns the policy of given group path.
+func (sys *JwtIAMSys) GetGroupDescription(groupPath string) (madmin.GroupDesc, error) {
+	sys.RLock()
+	defer sys.RUnlock()
+
+	groupPath = strings.TrimSuffix(groupPath, "/") + "/"
+	policyName, ok := sys.groupPolicyMap[groupPath]
+	if !ok {
+		return madmin.GroupDesc{}, errNoSuchGroup
+	}
+	return madmin.GroupDesc{Name: groupPath, Policy: policyName}, nil
+}
+
+// ListGroups - lists the group paths having a policy.
+func (sys *JwtIAMSys) ListGroups() ([]string, error) {
+	sys.RLock()
+	defer sys.RUnlock()
+
+	groups := make([]string, 0, len(sys.groupPolicyMap))
+	for groupPath := range sys.groupPolicyMap {
+		groups = append(groups, groupPath)
+	}
+	sort.Strings(groups)
+	return groups, nil
+}
+
+// setPolicy - sets policy to key in the policy map of the configuration
+// returned by policyMap, an empty policy name removes the policy of key.
+func (sys *JwtIAMSys) setPolicy(policyMap func(config *pydioIAMConfig) map[string]string, key, policyName string) error {
+	if key == "" {
+		return errInvalidArgument
+	}
+
+	return sys.updateConfig(func(config *pydioIAMConfig) error {
+		if policyName == "" {
+			delete(policyMap(config), key)
+			return nil
+		}
+		if _, ok := config.CannedPolicies[policyName]; !ok {
+			return errNoSuchPolicy
+		}
+		policyMap(config)[key] = policyName
+		return nil
+	})
+}
+
+// DeleteUser - not supported, Cells users are managed by Cells.
 func (sys *JwtIAMSys) DeleteUser(accessKey string) error {
-	return nil
+	return NotImplemented{}
 }
 
-// ListUsers - list all users.
+// ListUsers - not supported, Cells users are managed by Cells.
 func (sys *JwtIAMSys) ListUsers() (map[string]madmin.UserInfo, error) {
-	var users = make(map[string]madmin.UserInfo)
-	return users, nil
+	return nil, NotImplemented{}
 }
 
-// SetUserStatus - sets current user status, supports disabled or enabled.
+// SetUserStatus - not supported, Cells users are managed by Cells.
 func (sys *JwtIAMSys) SetUserStatus(accessKey string, status madmin.AccountStatus) error {
-	return nil
+	return NotImplemented{}
 }
 
-// SetUser - set user credentials.
+// SetUser - not supported, Cells users are managed by Cells.
 func (sys *JwtIAMSys) SetUser(accessKey string, uinfo madmin.UserInfo) error {
-	return nil
+	return NotImplemented{}
+}
+
+// NewServiceAccount - not supported, Cells users authenticate with their
+// JWT.
+func (sys *JwtIAMSys) NewServiceAccount(parentUser string, p *iampolicy.Policy) (auth.Credentials, error) {
+	return auth.Credentials{}, NotImplemented{}
+}
+
+// ListServiceAccounts - not supported, Cells users authenticate with their
+// JWT.
+func (sys *JwtIAMSys) ListServiceAccounts(parentUser string) ([]madmin.ServiceAccountInfo, error) {
+	return nil, NotImplemented{}
+}
+
+// RotateServiceAccount - not supported, Cells users authenticate with
+// their JWT.
+func (sys *JwtIAMSys) RotateServiceAccount(accessKey string) (auth.Credentials, error) {
+	return auth.Credentials{}, NotImplemented{}
+}
+
+// DeleteServiceAccount - not supported, Cells users authenticate with
+// their JWT.
+func (sys *JwtIAMSys) DeleteServiceAccount(accessKey string) error {
+	return NotImplemented{}
+}
+
+// RotateUserSecret - not supported, secrets of Cells users are not
+// managed here.
+func (sys *JwtIAMSys) RotateUserSecret(accessKey string, gracePeriod time.Duration) (auth.Credentials, error) {
+	return auth.Credentials{}, NotImplemented{}
+}
+
+// UpdateLastUsed - does nothing, Cells tracks the activity of its users.
+func (sys *JwtIAMSys) UpdateLastUsed(accessKey string) {
 }
 
 // IsAllowed - checks given policy args is allowed to continue the Rest API.
+// This is called for requests signed with a JWT as access key, see
+// isAllowedByPydioClaims for the other Pydio authentication methods.
 func (sys *JwtIAMSys) IsAllowed(args iampolicy.Args) bool {
-	return true
+	// Requests signed with the gateway credentials come from Cells services.
+	if args.IsOwner {
+		return true
+	}
+
+	claims, ok := sys.getClaims(args.AccountName)
+	if !ok {
+		return false
+	}
+	return sys.IsAllowedByClaims(claims, args)
+}
+
+// IsAllowedByClaims - checks given policy args is allowed for the Cells
+// user of given claims.
+func (sys *JwtIAMSys) IsAllowedByClaims(claims claim.Claims, args iampolicy.Args) bool {
+	sys.RLock()
+	defer sys.RUnlock()
+
+	return sys.isAllowedByClaims(claims, args)
+}
+
+// isAllowedByPydioClaims - checks args against the claims set in the request
+// context by the Pydio auth handler, for requests authenticated by the
+// X-Pydio-Bearer header, the pydio_jwt query parameter or as anonymous user.
+// ok is false if the request has no claims or the IAM system of the Pydio
+// gateway is not in use.
+func isAllowedByPydioClaims(r *http.Request, args iampolicy.Args) (allowed bool, ok bool) {
+	sys, ok := globalIAMSys.(*JwtIAMSys)
+	if !ok {
+		return false, false
+	}
+	claims, ok := r.Context().Value(claim.ContextKey).(claim.Claims)
+	if !ok {
+		return false, false
+	}
+	return sys.IsAllowedByClaims(claims, args), true
+}
+
+// Returns the claims of a JWT access key. Tokens are verified without
+// holding the lock, as verification may call the Cells auth service.
+func (sys *JwtIAMSys) getClaims(accessKey string) (claim.Claims, bool) {
+	if claims, ok := sys.quickCache.Get(accessKey); ok {
+		return claims.(claim.Claims), true
+	}
+	claims, err := sys.verify(accessKey)
+	if err != nil {
+		return claim.Claims{}, false
+	}
+	sys.setClaims(accessKey, claims)
+	return claims, true
+}
+
+// Checks the action against the policies of the roles, the profile and
+// the groups of a user, a statement denying the action in any of them
+// takes precedence over the statements allowing it.
+func (sys *JwtIAMSys) isAllowedByClaims(claims claim.Claims, args iampolicy.Args) bool {
+	var names []string
+	for _, role := range strings.Split(claims.Roles, ",") {
+		if name, ok := sys.rolePolicyMap[strings.TrimSpace(role)]; ok {
+			names = append(names, name)
+		}
+	}
+	if name, ok := sys.profilePolicyMap[claims.Profile]; ok {
+		names = append(names, name)
+	}
+	groupPath := strings.TrimSuffix(claims.GroupPath, "/") + "/"
+	for group, name := range sys.groupPolicyMap {
+		if strings.HasPrefix(groupPath, group) {
+			names = append(names, name)
+		}
+	}
+
+	combined := iampolicy.Policy{Version: iampolicy.DefaultVersion}
+	for _, name := range names {
+		if p, ok := sys.iamCannedPolicyMap[name]; ok {
+			combined.Statements = append(combined.Statements, p.Statements...)
+		}
+	}
+	return combined.IsAllowed(claimsPolicyArgs(claims, args))
+}
+
+// Returns the args with the policy variables resolved from the claims, the
+// access key being the token itself.
+func claimsPolicyArgs(claims claim.Claims, args iampolicy.Args) iampolicy.Args {
+	conditionValues := make(map[string][]string, len(args.ConditionValues)+2)
+	for key, values := range args.ConditionValues {
+		conditionValues[key] = values
+	}
+	delete(conditionValues, "username")
+	delete(conditionValues, "userid")
+	userID := claims.Subject
+	if userID == "" {
+		userID = claims.Name
+	}
+	if claims.Name != "" {
+		conditionValues["username"] = []string{claims.Name}
+	}
+	if userID != "" {
+		conditionValues["userid"] = []string{userID}
+	}
+	args.ConditionValues = conditionValues
+
+	args.Claims = make(map[string]interface{})
+	for key, value := range map[string]string{
+		"sub":       claims.Subject,
+		"name":      claims.Name,
+		"email":     claims.Email,
+		"profile":   claims.Profile,
+		"roles":     claims.Roles,
+		"groupPath": claims.GroupPath,
+	} {
+		if value != "" {
+			args.Claims[key] = value
+		}
+	}
+	return args
 }
@@ -0,0 +1,408 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pydio/cells/common"
+	"github.com/pydio/cells/common/auth/claim"
+	"github.com/pydio/minio-srv/pkg/auth"
+	"github.com/pydio/minio-srv/pkg/iam/policy"
+	"github.com/pydio/minio-srv/pkg/policy"
+)
+
+func TestJwtIAMSysIsAllowed(t *testing.T) {
+	sys := NewJwtIAMSys(newTestPydioConfigStore())
+	tokens := map[string]claim.Claims{
+		"admin":    {Name: "admin", Profile: "admin", Roles: "ROOT_GROUP,admin", GroupPath: "/"},
+		"user":     {Name: "user", Profile: "standard", Roles: "ROOT_GROUP,user", GroupPath: "/sales"},
+		"reader":   {Name: "reader", Profile: "standard", Roles: "ROOT_GROUP,readers,reader", GroupPath: "/sales/emea"},
+		"external": {Name: "external", Profile: "standard", Roles: "ROOT_GROUP,external", GroupPath: "/partners"},
+		"shared":   {Name: "shared", Profile: "shared", Roles: "shared", GroupPath: "/"},
+		"anon":     {Name: "anon", Profile: "anon", Roles: "anon", GroupPath: "/"},
+	}
+	sys.verify = func(token string) (claim.Claims, error) {
+		if claims, ok := tokens[token]; ok {
+			return claims, nil
+		}
+		return claim.Claims{}, errors.New("invalid token")
+	}
+
+	denyWrites := iampolicy.Policy{
+		Version: iampolicy.DefaultVersion,
+		Statements: []iampolicy.Statement{
+			iampolicy.NewStatement(policy.Deny, iampolicy.NewActionSet(iampolicy.PutObjectAction, iampolicy.DeleteObjectAction),
+				iampolicy.NewResourceSet(iampolicy.NewResource("*", "")), nil),
+		},
+	}
+	partners := iampolicy.Policy{
+		Version: iampolicy.DefaultVersion,
+		Statements: []iampolicy.Statement{
+			iampolicy.NewStatement(policy.Deny, iampolicy.NewActionSet(iampolicy.GetObjectAction),
+				iampolicy.NewResourceSet(iampolicy.NewResource("io", "internal/*")), nil),
+		},
+	}
+	if err := sys.SetCannedPolicy("denywrites", denyWrites); err != nil {
+		t.Fatal(err)
+	}
+	if err := sys.SetCannedPolicy("partners", partners); err != nil {
+		t.Fatal(err)
+	}
+	if err := sys.SetUserPolicy("readers", "denywrites"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sys.SetGroupPolicy("/partners", "partners"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sys.SetUserPolicy("writers", "missing"); err != errNoSuchPolicy {
+		t.Fatalf("Expected %v, got %v", errNoSuchPolicy, err)
+	}
+
+	testCases := []struct {
+		token    string
+		owner    bool
+		action   iampolicy.Action
+		object   string
+		expected bool
+	}{
+		{"admin", false, iampolicy.PutObjectAction, "personal/file", true},
+		{"user", false, iampolicy.PutObjectAction, "personal/file", true},
+		{"user", false, iampolicy.DeleteObjectAction, "personal/file", true},
+		// Read only roles cannot write.
+		{"reader", false, iampolicy.GetObjectAction, "personal/file", true},
+		{"reader", false, iampolicy.PutObjectAction, "personal/file", false},
+		{"reader", false, iampolicy.DeleteObjectAction, "personal/file", false},
+		// Group policies apply to sub groups only.
+		{"external", false, iampolicy.GetObjectAction, "internal/file", false},
+		{"external", false, iampolicy.GetObjectAction, "common/file", true},
+		{"user", false, iampolicy.GetObjectAction, "internal/file", true},
+		// Shared links and anonymous users are restricted to their prefixes.
+		{"shared", false, iampolicy.GetObjectAction, "shared/file", true},
+		{"shared", false, iampolicy.PutObjectAction, "shared/file", true},
+		{"shared", false, iampolicy.DeleteObjectAction, "shared/file", false},
+		{"shared", false, iampolicy.GetObjectAction, "common/file", false},
+		{"shared", false, iampolicy.PutObjectAction, "common/file", false},
+		{"shared", false, iampolicy.GetObjectAction, "common/shared/file", false},
+		{"anon", false, iampolicy.GetObjectAction, "public/file", true},
+		{"anon", false, iampolicy.PutObjectAction, "public/file", false},
+		{"anon", false, iampolicy.GetObjectAction, "shared/file", false},
+		{"anon", false, iampolicy.GetObjectAction, "common/file", false},
+		// Invalid tokens are denied, gateway credentials are allowed.
+		{"invalid", false, iampolicy.GetObjectAction, "common/file", false},
+		{"gateway", true, iampolicy.PutObjectAction, "common/file", true},
+	}
+	for i, testCase := range testCases {
+		allowed := sys.IsAllowed(iampolicy.Args{
+			AccountName: testCase.token,
+			Action:      testCase.action,
+			BucketName:  "io",
+			ObjectName:  testCase.object,
+			IsOwner:     testCase.owner,
+		})
+		if allowed != testCase.expected {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expected, allowed)
+		}
+	}
+}
+
+func TestJwtIAMSysPolicyVariables(t *testing.T) {
+	sys := NewJwtIAMSys(newTestPydioConfigStore())
+	tokens := map[string]claim.Claims{
+		"alice": {Name: "alice", Subject: "alice-uuid", Profile: "custom", Roles: "homes", GroupPath: "/"},
+		"bob":   {Name: "bob", Subject: "bob-uuid", Profile: "custom", Roles: "homes", GroupPath: "/"},
+	}
+	sys.verify = func(token string) (claim.Claims, error) {
+		if claims, ok := tokens[token]; ok {
+			return claims, nil
+		}
+		return claim.Claims{}, errors.New("invalid token")
+	}
+
+	homes := iampolicy.Policy{
+		Version: iampolicy.DefaultVersion,
+		Statements: []iampolicy.Statement{
+			iampolicy.NewStatement(policy.Allow, iampolicy.NewActionSet(iampolicy.GetObjectAction),
+				iampolicy.NewResourceSet(iampolicy.NewResource("home", "${aws:username}/*")), nil),
+			iampolicy.NewStatement(policy.Allow, iampolicy.NewActionSet(iampolicy.GetObjectAction),
+				iampolicy.NewResourceSet(iampolicy.NewResource("data", "${jwt:sub}/*")), nil),
+		},
+	}
+	if err := sys.SetCannedPolicy("homes", homes); err != nil {
+		t.Fatal(err)
+	}
+	if err := sys.SetUserPolicy("homes", "homes"); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		token    string
+		bucket   string
+		object   string
+		expected bool
+	}{
+		{"alice", "home", "alice/file", true},
+		{"alice", "home", "bob/file", false},
+		{"bob", "home", "bob/file", true},
+		{"alice", "data", "alice-uuid/file", true},
+		{"alice", "data", "bob-uuid/file", false},
+	}
+	for i, testCase := range testCases {
+		allowed := sys.IsAllowed(iampolicy.Args{
+			AccountName: testCase.token,
+			Action:      iampolicy.GetObjectAction,
+			BucketName:  testCase.bucket,
+			ObjectName:  testCase.object,
+			// The username is the token for requests signed with it.
+			ConditionValues: map[string][]string{"username": {testCase.token}},
+		})
+		if allowed != testCase.expected {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expected, allowed)
+		}
+	}
+}
+
+// Test that the configuration is shared through the config store, and
+// cannot be changed without one.
+func TestJwtIAMSysConfigStore(t *testing.T) {
+	store := newTestPydioConfigStore()
+	sys := NewJwtIAMSys(store)
+	if err := sys.Init(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	denyWrites := iampolicy.Policy{
+		Version: iampolicy.DefaultVersion,
+		Statements: []iampolicy.Statement{
+			iampolicy.NewStatement(policy.Deny, iampolicy.NewActionSet(iampolicy.PutObjectAction),
+				iampolicy.NewResourceSet(iampolicy.NewResource("*", "")), nil),
+		},
+	}
+	if err := sys.SetCannedPolicy("denywrites", denyWrites); err != nil {
+		t.Fatal(err)
+	}
+	if err := sys.SetUserPolicy("readers", "denywrites"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sys.SetGroupPolicy("/partners", "denywrites"); err != nil {
+		t.Fatal(err)
+	}
+	// Profile policies are set through SetUserPolicy with a prefix.
+	if err := sys.SetUserPolicy(pydioProfilePolicyPrefix+"anon", "readonly"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The configuration is loaded by other gateway instances.
+	other := NewJwtIAMSys(store)
+	if err := other.Init(nil); err != nil {
+		t.Fatal(err)
+	}
+	getPolicy := func(sys *JwtIAMSys, role string) string {
+		policies, err := sys.GetUserPolicies(role)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(policies.Policies) == 0 {
+			return ""
+		}
+		return policies.Policies[0]
+	}
+	testCases := []struct {
+		role     string
+		expected string
+	}{
+		{"readers", "denywrites"},
+		{pydioProfilePolicyPrefix + "anon", "readonly"},
+		{pydioProfilePolicyPrefix + "shared", "pydio-shared"},
+		{"writers", ""},
+	}
+	for i, testCase := range testCases {
+		if policyName := getPolicy(other, testCase.role); policyName != testCase.expected {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.expected, policyName)
+		}
+	}
+	if group, err := other.GetGroupDescription("/partners"); err != nil || group.Policy != "denywrites" {
+		t.Fatalf("Expected group policy denywrites, got %v, %v", group, err)
+	}
+
+	// Changes are reloaded once the store notifies them, and changes made
+	// through an instance keep the ones made through the others.
+	if err := other.SetUserPolicy("writers", "readwrite"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sys.SetUserPolicy("readers", ""); err != nil {
+		t.Fatal(err)
+	}
+	if policyName := getPolicy(sys, "writers"); policyName != "readwrite" {
+		t.Fatalf("Expected readwrite, got %q", policyName)
+	}
+	store.notify()
+	deadline := time.Now().Add(5 * time.Second)
+	for getPolicy(other, "readers") != "" {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the policy of readers to be removed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	config, err := loadPydioIAMConfig(context.Background(), store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := config.CannedPolicies["denywrites"]; !ok || config.RolePolicies["writers"] != "readwrite" {
+		t.Fatalf("Unexpected saved configuration %v", config)
+	}
+
+	// Without a config store, only the default configuration is used.
+	sys = NewJwtIAMSys(nil)
+	if err = sys.Init(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.SetCannedPolicy("denywrites", denyWrites); err != (NotImplemented{}) {
+		t.Fatalf("Expected %v, got %v", NotImplemented{}, err)
+	}
+	if err = sys.SetUserPolicy("readers", "readonly"); err != (NotImplemented{}) {
+		t.Fatalf("Expected %v, got %v", NotImplemented{}, err)
+	}
+	if policyName := getPolicy(sys, pydioProfilePolicyPrefix+"standard"); policyName != "readwrite" {
+		t.Fatalf("Expected readwrite, got %q", policyName)
+	}
+
+	// Users are managed by Cells.
+	if err = sys.DeleteUser("user"); err != (NotImplemented{}) {
+		t.Fatalf("Expected %v, got %v", NotImplemented{}, err)
+	}
+	if _, err = sys.ListUsers(); err != (NotImplemented{}) {
+		t.Fatalf("Expected %v, got %v", NotImplemented{}, err)
+	}
+}
+
+// Test requests authenticated by the Pydio auth handler, with the claims of
+// their JWT in the request context rather than a signature.
+func TestCheckRequestAuthTypePydioClaims(t *testing.T) {
+	iamSys, policySys := globalIAMSys, globalPolicySys
+	defer func() {
+		globalIAMSys, globalPolicySys = iamSys, policySys
+	}()
+
+	sys := NewJwtIAMSys(newTestPydioConfigStore())
+	readOnly := iampolicy.Policy{
+		Version: iampolicy.DefaultVersion,
+		Statements: []iampolicy.Statement{
+			iampolicy.NewStatement(policy.Deny, iampolicy.NewActionSet(iampolicy.PutObjectAction),
+				iampolicy.NewResourceSet(iampolicy.NewResource("*", "")), nil),
+		},
+	}
+	if err := sys.SetCannedPolicy("denywrites", readOnly); err != nil {
+		t.Fatal(err)
+	}
+	if err := sys.SetUserPolicy("readers", "denywrites"); err != nil {
+		t.Fatal(err)
+	}
+	globalIAMSys = sys
+	globalPolicySys = NewMemoryPolicySys(nil)
+
+	user := claim.Claims{Name: "user", Profile: "standard", Roles: "ROOT_GROUP,user", GroupPath: "/"}
+	reader := claim.Claims{Name: "reader", Profile: "standard", Roles: "ROOT_GROUP,readers", GroupPath: "/"}
+	shared := claim.Claims{Name: "shared", Profile: "shared", Roles: "shared", GroupPath: "/"}
+	anon := claim.Claims{Name: common.PydioS3AnonUsername, Profile: "anon", GroupPath: "/"}
+
+	testCases := []struct {
+		claims   *claim.Claims
+		method   string
+		url      string
+		action   policy.Action
+		object   string
+		expected APIErrorCode
+	}{
+		{&user, "GET", "/io/personal/file", policy.GetObjectAction, "personal/file", ErrNone},
+		{&user, "PUT", "/io/personal/file", policy.PutObjectAction, "personal/file", ErrNone},
+		{&reader, "GET", "/io/personal/file", policy.GetObjectAction, "personal/file", ErrNone},
+		{&reader, "PUT", "/io/personal/file", policy.PutObjectAction, "personal/file", ErrAccessDenied},
+		{&shared, "GET", "/io/shared/file", policy.GetObjectAction, "shared/file", ErrNone},
+		{&shared, "PUT", "/io/shared/file", policy.PutObjectAction, "shared/file", ErrNone},
+		{&shared, "GET", "/io/personal/file", policy.GetObjectAction, "personal/file", ErrAccessDenied},
+		{&shared, "GET", "/io?prefix=shared/", policy.ListBucketAction, "", ErrNone},
+		{&shared, "GET", "/io?prefix=personal/", policy.ListBucketAction, "", ErrAccessDenied},
+		{&anon, "GET", "/io/public/file", policy.GetObjectAction, "public/file", ErrNone},
+		{&anon, "PUT", "/io/public/file", policy.PutObjectAction, "public/file", ErrAccessDenied},
+		{&anon, "GET", "/io/personal/file", policy.GetObjectAction, "personal/file", ErrAccessDenied},
+		// Requests without claims come from Cells services, they are
+		// checked against the bucket policies only.
+		{nil, "PUT", "/io/personal/file", policy.PutObjectAction, "personal/file", ErrNone},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest(testCase.method, "http://localhost"+testCase.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if testCase.claims != nil {
+			req = req.WithContext(context.WithValue(req.Context(), claim.ContextKey, *testCase.claims))
+		}
+		if s3Err := checkRequestAuthType(context.Background(), req, testCase.action, "io", testCase.object); s3Err != testCase.expected {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expected, s3Err)
+		}
+		if testCase.action == policy.PutObjectAction {
+			if s3Err := isPutAllowed(authTypeAnonymous, "io", testCase.object, req); s3Err != testCase.expected {
+				t.Errorf("Test %d: Expected %v for isPutAllowed, got %v", i+1, testCase.expected, s3Err)
+			}
+		}
+	}
+}
+
+// Test that tokens are verified once, without holding the IAM lock.
+func TestJwtIAMSysGetUser(t *testing.T) {
+	sys := NewJwtIAMSys(nil)
+	var mu sync.Mutex
+	verified := make(map[string]int)
+	sys.verify = func(token string) (claim.Claims, error) {
+		// Writers must not be blocked by verifications.
+		sys.Lock()
+		sys.Unlock()
+		mu.Lock()
+		defer mu.Unlock()
+		verified[token]++
+		if token == "invalid" {
+			return claim.Claims{}, errors.New("invalid token")
+		}
+		return claim.Claims{Name: token, Profile: "standard"}, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if cred, ok := sys.GetUser("user"); !ok || cred.AccessKey != "user" {
+				t.Errorf("Expected credentials of user, got %v, %v", cred, ok)
+			}
+		}()
+	}
+	wg.Wait()
+	if _, ok := sys.GetUser("invalid"); ok {
+		t.Fatal("Expected invalid token to be rejected")
+	}
+
+	// Tokens verified by the auth handler are not verified again.
+	sys.setClaims("handler", claim.Claims{Name: "handler", Profile: "standard"})
+	if _, ok := sys.GetUser("handler"); !ok {
+		t.Fatal("Expected token verified by the auth handler to be accepted")
+	}
+	if !sys.IsAllowed(iampolicy.Args{AccountName: "handler", Action: iampolicy.GetObjectAction, BucketName: "io", ObjectName: "file"}) {
+		t.Fatal("Expected standard user to be allowed")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if verified["user"] == 0 || verified["user"] > 10 {
+		t.Fatalf("Unexpected verifications of user: %d", verified["user"])
+	}
+	if verified["handler"] != 0 {
+		t.Fatalf("Expected no verification of handler token, got %d", verified["handler"])
+	}
+	if err := sys.SetTempUser("user", auth.Credentials{}, ""); err != (NotImplemented{}) {
+		t.Fatalf("Expected %v, got %v", NotImplemented{}, err)
+	}
+}
@@ -1,66 +1,219 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/pydio/minio-srv/cmd/logger"
 	"github.com/pydio/minio-srv/pkg/policy"
 )
 
-// PolicySys - policy subsystem.
+// Prefix of the bucket policy files in the config store.
+const pydioPolicyConfigPrefix = "policies/"
+
+// Extension of the bucket policy files.
+const pydioPolicyFileExt = ".json"
+
+// pydioPolicyStore - keeps one <bucket>.json file per bucket policy in the
+// config store shared by the gateway instances. Without a config store,
+// setting bucket policies is not supported rather than kept by a single
+// gateway instance.
+type pydioPolicyStore struct {
+	store PydioConfigStore
+}
+
+func pydioPolicyFile(bucket string) string {
+	return pydioPolicyConfigPrefix + bucket + pydioPolicyFileExt
+}
+
+// GetBucketPolicy - reads the policy of bucket, returns BucketPolicyNotFound
+// if bucket has no policy.
+func (s pydioPolicyStore) GetBucketPolicy(ctx context.Context, bucket string) (*policy.Policy, error) {
+	if s.store == nil {
+		return nil, BucketPolicyNotFound{Bucket: bucket}
+	}
+	data, err := s.store.ReadConfig(ctx, pydioPolicyFile(bucket))
+	if err != nil {
+		if err == errConfigNotFound {
+			return nil, BucketPolicyNotFound{Bucket: bucket}
+		}
+		return nil, err
+	}
+	return policy.ParseConfig(bytes.NewReader(data), bucket)
+}
+
+// SetBucketPolicy - saves the policy of bucket.
+func (s pydioPolicyStore) SetBucketPolicy(ctx context.Context, bucket string, p *policy.Policy) error {
+	if s.store == nil {
+		return NotImplemented{}
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return s.store.SaveConfig(ctx, pydioPolicyFile(bucket), data)
+}
+
+// DeleteBucketPolicy - removes the policy of bucket, returns
+// BucketPolicyNotFound if bucket has no policy.
+func (s pydioPolicyStore) DeleteBucketPolicy(ctx context.Context, bucket string) error {
+	if s.store == nil {
+		return BucketPolicyNotFound{Bucket: bucket}
+	}
+	if err := s.store.DeleteConfig(ctx, pydioPolicyFile(bucket)); err != nil {
+		if err == errConfigNotFound {
+			return BucketPolicyNotFound{Bucket: bucket}
+		}
+		return err
+	}
+	return nil
+}
+
+// ListBucketPolicies - reads all the bucket policies. Invalid policy files
+// are logged and skipped.
+func (s pydioPolicyStore) ListBucketPolicies(ctx context.Context) (map[string]policy.Policy, error) {
+	policies := make(map[string]policy.Policy)
+	if s.store == nil {
+		return policies, nil
+	}
+	files, err := s.store.ListConfigs(ctx, pydioPolicyConfigPrefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		bucket := strings.TrimPrefix(file, pydioPolicyConfigPrefix)
+		if strings.Contains(bucket, "/") || !strings.HasSuffix(bucket, pydioPolicyFileExt) {
+			continue
+		}
+		bucket = strings.TrimSuffix(bucket, pydioPolicyFileExt)
+		p, err := s.GetBucketPolicy(ctx, bucket)
+		if err != nil {
+			if _, ok := err.(BucketPolicyNotFound); !ok {
+				logger.LogIf(ctx, err)
+			}
+			continue
+		}
+		policies[bucket] = *p
+	}
+	return policies, nil
+}
+
+// pydioPolicyObjects - object layer of the Pydio gateway storing bucket
+// policies in the config store, as the Cells backend has no room for them.
+type pydioPolicyObjects struct {
+	ObjectLayer
+	policies pydioPolicyStore
+}
+
+// SetBucketPolicy - saves policy of bucket in the config store.
+func (l *pydioPolicyObjects) SetBucketPolicy(ctx context.Context, bucket string, p *policy.Policy) error {
+	if err := l.policies.SetBucketPolicy(ctx, bucket, p); err != nil {
+		logger.LogIf(ctx, err)
+		return err
+	}
+	return nil
+}
+
+// GetBucketPolicy - reads policy of bucket from the config store.
+func (l *pydioPolicyObjects) GetBucketPolicy(ctx context.Context, bucket string) (*policy.Policy, error) {
+	return l.policies.GetBucketPolicy(ctx, bucket)
+}
+
+// DeleteBucketPolicy - removes policy of bucket from the config store.
+func (l *pydioPolicyObjects) DeleteBucketPolicy(ctx context.Context, bucket string) error {
+	return l.policies.DeleteBucketPolicy(ctx, bucket)
+}
+
+// MemoryPolicySys - policy subsystem of the Pydio gateway. Policies are
+// evaluated from memory and reloaded from the config store periodically and
+// when it notifies changes, so that policies set through other gateway
+// instances are picked up.
 type MemoryPolicySys struct {
-	//sync.RWMutex
+	sync.RWMutex
 	bucketPolicyMap map[string]policy.Policy
+	policies        pydioPolicyStore
 }
 
 // Set - sets policy to given bucket name.  If policy is empty, existing policy is removed.
 func (sys *MemoryPolicySys) Set(bucketName string, policy policy.Policy) {
-	/*
-		sys.Lock()
-		defer sys.Unlock()
-
-		if policy.IsEmpty() {
-			delete(sys.bucketPolicyMap, bucketName)
-		} else {
-			sys.bucketPolicyMap[bucketName] = policy
-		}
-	*/
+	sys.Lock()
+	defer sys.Unlock()
+
+	if policy.IsEmpty() {
+		delete(sys.bucketPolicyMap, bucketName)
+	} else {
+		sys.bucketPolicyMap[bucketName] = policy
+	}
 }
 
 // Remove - removes policy for given bucket name.
 func (sys *MemoryPolicySys) Remove(bucketName string) {
-	/*
-		sys.Lock()
-		defer sys.Unlock()
+	sys.Lock()
+	defer sys.Unlock()
 
-		delete(sys.bucketPolicyMap, bucketName)
-	*/
+	delete(sys.bucketPolicyMap, bucketName)
 }
 
 // IsAllowed - checks given policy args is allowed to continue the Rest API.
 func (sys *MemoryPolicySys) IsAllowed(args policy.Args) bool {
-	return true
+	sys.RLock()
+	defer sys.RUnlock()
 
-	/*
-		sys.RLock()
-		defer sys.RUnlock()
+	// If policy is available for given bucket, check the policy.
+	if p, found := sys.bucketPolicyMap[args.BucketName]; found {
+		return p.IsAllowed(args)
+	}
+
+	// As policy is not available for given bucket name, the operation is
+	// allowed: requests of Cells users are authenticated by the Pydio auth
+	// handler and checked against their claims by the IAM system, then by
+	// the Cells router.
+	return true
+}
 
-		// If policy is available for given bucket, check the policy.
-		if p, found := sys.bucketPolicyMap[args.BucketName]; found {
-			return p.IsAllowed(args)
+// refresh - replaces the policies in memory with the ones of the store.
+func (sys *MemoryPolicySys) refresh() error {
+	policies, err := sys.policies.ListBucketPolicies(context.Background())
+	if err != nil {
+		logger.LogIf(context.Background(), err)
+		return err
+	}
+	for bucket, p := range policies {
+		if p.IsEmpty() {
+			delete(policies, bucket)
 		}
+	}
 
-		// As policy is not available for given bucket name, returns IsOwner i.e.
-		// operation is allowed only for owner.
-		return args.IsOwner
-	*/
+	sys.Lock()
+	defer sys.Unlock()
+
+	sys.bucketPolicyMap = policies
+	return nil
 }
 
-// Init - initializes policy system from policy.json of all buckets.
+// Init - loads the policies of the store and reloads them in background.
 func (sys *MemoryPolicySys) Init(objAPI ObjectLayer) error {
+	if sys.policies.store == nil {
+		return nil
+	}
+	if err := sys.refresh(); err != nil {
+		return err
+	}
+
+	watchPydioConfig(sys.policies.store, globalRefreshBucketPolicyInterval, func() {
+		sys.refresh()
+	})
 	return nil
 }
 
-// NewPolicySys - creates new policy system.
-func NewMemoryPolicySys() *MemoryPolicySys {
+// NewMemoryPolicySys - creates new policy system backed by store, setting
+// bucket policies is not supported if store is nil.
+func NewMemoryPolicySys(store PydioConfigStore) *MemoryPolicySys {
 	return &MemoryPolicySys{
 		bucketPolicyMap: make(map[string]policy.Policy),
+		policies:        pydioPolicyStore{store: store},
 	}
 }
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pydio/cells/common"
+	"github.com/pydio/minio-srv/pkg/policy"
+	"github.com/pydio/minio-srv/pkg/policy/condition"
+)
+
+// testPydioConfigStore - config store kept in memory, notifying the changes
+// made with notify to all its watchers.
+type testPydioConfigStore struct {
+	mu       sync.Mutex
+	files    map[string][]byte
+	watchers []chan struct{}
+}
+
+func newTestPydioConfigStore() *testPydioConfigStore {
+	return &testPydioConfigStore{files: make(map[string][]byte)}
+}
+
+func (s *testPydioConfigStore) ReadConfig(ctx context.Context, file string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.files[file]
+	if !ok {
+		return nil, ErrPydioConfigNotFound
+	}
+	return data, nil
+}
+
+func (s *testPydioConfigStore) SaveConfig(ctx context.Context, file string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[file] = data
+	return nil
+}
+
+func (s *testPydioConfigStore) DeleteConfig(ctx context.Context, file string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.files[file]; !ok {
+		return ErrPydioConfigNotFound
+	}
+	delete(s.files, file)
+	return nil
+}
+
+func (s *testPydioConfigStore) ListConfigs(ctx context.Context, prefix string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var files []string
+	for file := range s.files {
+		if strings.HasPrefix(file, prefix) {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+func (s *testPydioConfigStore) Watch(doneCh <-chan struct{}) <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changeCh := make(chan struct{}, 1)
+	s.watchers = append(s.watchers, changeCh)
+	return changeCh
+}
+
+// notify - notifies a change made by another gateway instance.
+func (s *testPydioConfigStore) notify() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, changeCh := range s.watchers {
+		select {
+		case changeCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func TestMemoryPolicySys(t *testing.T) {
+	store := newTestPydioConfigStore()
+	objAPI := &pydioPolicyObjects{policies: pydioPolicyStore{store: store}}
+	ctx := context.Background()
+
+	readOnly := &policy.Policy{
+		Version: policy.DefaultVersion,
+		Statements: []policy.Statement{
+			policy.NewStatement(
+				policy.Allow,
+				policy.NewPrincipal("*"),
+				policy.NewActionSet(policy.GetObjectAction),
+				policy.NewResourceSet(policy.NewResource("mybucket", "/*")),
+				condition.NewFunctions(),
+			),
+		},
+	}
+
+	if _, err := objAPI.GetBucketPolicy(ctx, "mybucket"); err == nil {
+		t.Fatal("Expected BucketPolicyNotFound")
+	} else if _, ok := err.(BucketPolicyNotFound); !ok {
+		t.Fatalf("Expected BucketPolicyNotFound, got %v", err)
+	}
+	if err := objAPI.SetBucketPolicy(ctx, "mybucket", readOnly); err != nil {
+		t.Fatal(err)
+	}
+
+	// Policies saved by another instance are loaded at Init.
+	sys := NewMemoryPolicySys(store)
+	if err := sys.Init(objAPI); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		args     policy.Args
+		expected bool
+	}{
+		{policy.Args{Action: policy.GetObjectAction, BucketName: "mybucket", ObjectName: "obj"}, true},
+		{policy.Args{Action: policy.PutObjectAction, BucketName: "mybucket", ObjectName: "obj"}, false},
+		// Buckets without a policy are left to the IAM system and the router.
+		{policy.Args{Action: policy.GetObjectAction, BucketName: "otherbucket", ObjectName: "obj"}, true},
+		{policy.Args{Action: policy.PutObjectAction, BucketName: "otherbucket", ObjectName: "obj"}, true},
+		{policy.Args{Action: policy.PutObjectAction, BucketName: "otherbucket", ObjectName: "obj", IsOwner: true}, true},
+	}
+	for i, testCase := range testCases {
+		if allowed := sys.IsAllowed(testCase.args); allowed != testCase.expected {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expected, allowed)
+		}
+	}
+
+	// Deleted policies are dropped once the store notifies the change, the
+	// bucket is then left to the IAM system.
+	if err := objAPI.DeleteBucketPolicy(ctx, "mybucket"); err != nil {
+		t.Fatal(err)
+	}
+	if err := objAPI.DeleteBucketPolicy(ctx, "mybucket"); err == nil {
+		t.Fatal("Expected BucketPolicyNotFound")
+	}
+	store.notify()
+	deadline := time.Now().Add(5 * time.Second)
+	for !sys.IsAllowed(testCases[1].args) {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected deleted policy not to be enforced")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Test that bucket policies cannot be set without a config store.
+func TestMemoryPolicySysNoStore(t *testing.T) {
+	objAPI := &pydioPolicyObjects{}
+	ctx := context.Background()
+
+	if err := objAPI.SetBucketPolicy(ctx, "mybucket", &policy.Policy{Version: policy.DefaultVersion}); err != (NotImplemented{}) {
+		t.Fatalf("Expected %v, got %v", NotImplemented{}, err)
+	}
+	if _, err := objAPI.GetBucketPolicy(ctx, "mybucket"); err != (BucketPolicyNotFound{Bucket: "mybucket"}) {
+		t.Fatalf("Expected BucketPolicyNotFound, got %v", err)
+	}
+	sys := NewMemoryPolicySys(nil)
+	if err := sys.Init(objAPI); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Test the paths of the files in the Cells configuration.
+func TestPydioCellsConfigStorePath(t *testing.T) {
+	store := pydioCellsConfigStore{}
+	testCases := []struct {
+		file     string
+		expected string
+	}{
+		{"iam/config.json", "services/" + common.ServiceGatewayData + "/minio/iam/config.json"},
+		{"policies/mybucket.json", "services/" + common.ServiceGatewayData + "/minio/policies/mybucket.json"},
+		{"policies/", "services/" + common.ServiceGatewayData + "/minio/policies"},
+		{"", "services/" + common.ServiceGatewayData + "/minio"},
+	}
+	for i, testCase := range testCases {
+		if path := strings.Join(store.path(testCase.file), "/"); path != testCase.expected {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.expected, path)
+		}
+	}
+	// Paths of different files must not share their backing array.
+	a, b := store.path("policies/a.json"), store.path("policies/b.json")
+	if a[len(a)-1] != "a.json" || b[len(b)-1] != "b.json" {
+		t.Fatalf("Unexpected paths %v, %v", a, b)
+	}
+}
@@ -74,6 +74,10 @@ ENVIRONMENT VARIABLES:
      MINIO_CACHE_EXCLUDE: List of cache exclusion patterns delimited by ";".
      MINIO_CACHE_EXPIRY: Cache expiry duration in days.
      MINIO_CACHE_MAXUSE: Maximum permitted usage of the cache in percentage (0-100).
+     MINIO_CACHE_WATERMARK_LOW: Cache usage in percentage of MINIO_CACHE_MAXUSE down to which garbage collection evicts entries.
+     MINIO_CACHE_WATERMARK_HIGH: Cache usage in percentage of MINIO_CACHE_MAXUSE above which garbage collection starts.
+     MINIO_CACHE_EVICTION: Cache eviction policy, either "lru" or "lfu".
+     MINIO_CACHE_COMMIT: Cache commit mode of uploads, either "writethrough" or "writeback".
 
 EXAMPLES:
   1. Start minio gateway server for B2 backend.
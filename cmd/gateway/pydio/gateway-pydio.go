@@ -100,6 +100,11 @@ func fromPydioNodeObjectInfo(bucket string, node *tree.Node) minio.ObjectInfo {
 	userDefined := map[string]string{
 		"Content-Type": cType,
 	}
+	if node.Etag == common.NodeFlagEtagTemporary {
+		// Contents are not identified until their etag is computed, keep
+		// them out of the disk cache.
+		userDefined["cache-control"] = "no-store"
+	}
 	vId := node.GetStringMeta("versionId")
 
 	nodePath := node.Path
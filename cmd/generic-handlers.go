@@ -356,7 +356,8 @@ func parseAmzDateHeader(req *http.Request) (time.Time, APIErrorCode) {
 
 func (h timeValidityHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	aType := getRequestAuthType(r)
-	if aType == authTypeSigned || aType == authTypeSignedV2 || aType == authTypeStreamingSigned {
+	if aType == authTypeSigned || aType == authTypeSignedV2 || aType == authTypeStreamingSigned ||
+		aType == authTypeStreamingUnsignedTrailer {
 		// Verify if date headers are set, if not reject the request
 		amzDate, apiErr := parseAmzDateHeader(r)
 		if apiErr != ErrNone {
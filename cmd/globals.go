@@ -30,11 +30,13 @@ import (
 	"github.com/fatih/color"
 	"github.com/pydio/minio-srv/cmd/crypto"
 	xhttp "github.com/pydio/minio-srv/cmd/http"
+	"github.com/pydio/minio-srv/cmd/logger"
 	"github.com/pydio/minio-srv/pkg/auth"
 	"github.com/pydio/minio-srv/pkg/certs"
 	"github.com/pydio/minio-srv/pkg/dns"
 	"github.com/pydio/minio-srv/pkg/iam/policy"
 	"github.com/pydio/minio-srv/pkg/iam/validator"
+	"github.com/pydio/minio-srv/pkg/pubsub"
 	etcd "go.etcd.io/etcd/clientv3"
 )
 
@@ -153,6 +155,17 @@ var (
 	// File to log HTTP request/response headers and body.
 	globalHTTPTraceFile *os.File
 
+	// Publishes the HTTP trace records to the admin trace listeners.
+	globalHTTPTrace = pubsub.New()
+
+	// Number of the admin trace listeners asking for the bodies, only
+	// updated atomically.
+	globalHTTPTraceBodyListeners int32
+
+	// Keeps the recent log entries and publishes them to the admin log
+	// listeners.
+	globalConsoleLog = logger.NewRing(consoleLogBufferSize)
+
 	// List of admin peers.
 	globalAdminPeers = adminPeers{}
 
@@ -164,6 +177,9 @@ var (
 	// Global server's network statistics
 	globalConnStats = newConnStats()
 
+	// Disk cache hits, misses and evictions
+	globalCacheStats = &cacheStats{}
+
 	// Global HTTP request statisitics
 	globalHTTPStats = newHTTPStats()
 
@@ -207,6 +223,16 @@ var (
 	globalCacheExpiry = 90
 	// Max allowed disk cache percentage
 	globalCacheMaxUse = 80
+	// Disk cache usage watermarks, in percentage of the max allowed usage
+	globalCacheWatermarkLow  = cacheDefaultWatermarkLow
+	globalCacheWatermarkHigh = cacheDefaultWatermarkHigh
+	// Disk cache eviction policy
+	globalCacheEviction = cacheEvictionLRU
+	// Disk cache commit mode of uploaded objects
+	globalCacheCommit = cacheCommitWriteThrough
+	// Validate every cached object against the backend and never serve
+	// the cache when the backend is down, set for the Pydio gateway
+	globalCacheStrict bool
 
 	// RPC V1 - Initial version
 	// RPC V2 - format.json XL version changed to 2
@@ -255,6 +281,9 @@ var (
 	// OPA policy system.
 	globalPolicyOPA *iampolicy.Opa
 
+	// LDAP server of AssumeRoleWithLDAPIdentity, nil if not configured.
+	globalLDAPConfig *ldapConfig
+
 	// Add new variable global values here.
 )
 
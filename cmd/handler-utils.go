@@ -26,6 +26,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync/atomic"
 
 	"github.com/pydio/cells/common"
 	context2 "github.com/pydio/cells/common/utils/context"
@@ -336,18 +337,30 @@ func extractPostPolicyFormValues(ctx context.Context, form *multipart.Form) (fil
 
 // Log headers and body.
 func httpTraceAll(f http.HandlerFunc) http.HandlerFunc {
-	if globalHTTPTraceFile == nil {
-		return f
-	}
-	return httptracer.TraceReqHandlerFunc(f, globalHTTPTraceFile, true)
+	return httpTrace(f, true)
 }
 
 // Log only the headers.
 func httpTraceHdrs(f http.HandlerFunc) http.HandlerFunc {
-	if globalHTTPTraceFile == nil {
-		return f
+	return httpTrace(f, false)
+}
+
+// httpTrace - logs requests served by f to the trace file if any, and
+// publishes their trace records while admin clients listen to them.
+// Bodies are only published while an admin client asked for them.
+func httpTrace(f http.HandlerFunc, logBody bool) http.HandlerFunc {
+	funcName := getTraceFuncName(f)
+	if globalHTTPTraceFile != nil {
+		f = httptracer.TraceReqHandlerFunc(f, globalHTTPTraceFile, logBody)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !globalHTTPTrace.HasSubscribers() {
+			f(w, r)
+			return
+		}
+		traceBody := logBody && atomic.LoadInt32(&globalHTTPTraceBodyListeners) > 0
+		globalHTTPTrace.Publish(traceRequest(f, funcName, traceBody, w, r))
 	}
-	return httptracer.TraceReqHandlerFunc(f, globalHTTPTraceFile, false)
 }
 
 // Returns "/bucketName/objectName" for path-style or virtual-host-style requests.
@@ -0,0 +1,379 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/pydio/minio-srv/pkg/trace"
+)
+
+// Interval of the whitespace sent to keep admin streams alive
+// while no records are sent.
+const streamKeepAliveInterval = 500 * time.Millisecond
+
+// Size of the buffer of trace records of a trace listener, records
+// are dropped for listeners which are too slow.
+const traceChannelSize = 4000
+
+// Maximum size of the request and response bodies recorded in a trace
+// record, the rest of the bodies is not recorded.
+const traceBodyMaxSize = 4 * humanize.KiByte
+
+// Value replacing the credentials in trace records.
+const traceRedactedValue = "*REDACTED*"
+
+// Request headers holding credentials, they are redacted from the trace
+// records.
+var traceRedactedHeaders = []string{
+	"Authorization",
+	"X-Amz-Security-Token",
+	"X-Pydio-Bearer",
+}
+
+// Query parameters holding credentials, they are redacted from the trace
+// records.
+var traceRedactedParams = []string{
+	"X-Amz-Credential",
+	"X-Amz-Security-Token",
+	"X-Amz-Signature",
+	"Signature",
+	"pydio_jwt",
+}
+
+// redactHeaders - returns a copy of h with the credentials redacted.
+func redactHeaders(h http.Header) http.Header {
+	h = cloneHeader(h)
+	for _, name := range traceRedactedHeaders {
+		if _, ok := h[name]; ok {
+			h.Set(name, traceRedactedValue)
+		}
+	}
+	return h
+}
+
+// redactQuery - returns rawQuery with the credentials redacted.
+func redactQuery(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		// Do not record what could not be checked.
+		return traceRedactedValue
+	}
+	redacted := false
+	for _, name := range traceRedactedParams {
+		if _, ok := values[name]; ok {
+			values.Set(name, traceRedactedValue)
+			redacted = true
+		}
+	}
+	if !redacted {
+		return rawQuery
+	}
+	return values.Encode()
+}
+
+// recordBody - appends p to buf, up to traceBodyMaxSize bytes.
+func recordBody(buf *bytes.Buffer, p []byte) {
+	if n := traceBodyMaxSize - buf.Len(); n < len(p) {
+		p = p[:n]
+	}
+	buf.Write(p)
+}
+
+// recordRequest - counts the bytes read from the request body and
+// records them when the body should be traced.
+type recordRequest struct {
+	// Data source to record
+	io.Reader
+	// Request body should be traced
+	logBody bool
+	// Internal recording buffer
+	buf bytes.Buffer
+	// Number of bytes read
+	bytesRead int64
+}
+
+func (r *recordRequest) Read(p []byte) (n int, err error) {
+	n, err = r.Reader.Read(p)
+	r.bytesRead += int64(n)
+	if r.logBody {
+		recordBody(&r.buf, p[:n])
+	}
+	return n, err
+}
+
+// recordResponseWriter - records the status code and headers of the
+// response, and its body when it should be traced.
+type recordResponseWriter struct {
+	http.ResponseWriter
+	// Response body should be traced
+	logBody bool
+	// Headers at the time they were written
+	headers http.Header
+	// Internal recording buffer
+	body bytes.Buffer
+	// The status code of the current HTTP request
+	statusCode int
+	// Number of bytes written
+	bytesWritten int64
+}
+
+// Record the headers.
+func (r *recordResponseWriter) WriteHeader(i int) {
+	if r.headers == nil {
+		r.statusCode = i
+		r.headers = cloneHeader(r.ResponseWriter.Header())
+	}
+	r.ResponseWriter.WriteHeader(i)
+}
+
+func (r *recordResponseWriter) Write(p []byte) (n int, err error) {
+	if r.headers == nil {
+		// We assume the response code to be '200 OK' when WriteHeader() is not called,
+		// that way following Golang HTTP response behavior.
+		r.statusCode = http.StatusOK
+		r.headers = cloneHeader(r.ResponseWriter.Header())
+	}
+	n, err = r.ResponseWriter.Write(p)
+	r.bytesWritten += int64(n)
+	if r.logBody {
+		recordBody(&r.body, p[:n])
+	}
+	return n, err
+}
+
+// Calls the underlying Flush, if any.
+func (r *recordResponseWriter) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// getTraceFuncName - returns the API name of a traced handler, for
+// example "PutObject" for objectAPIHandlers.PutObjectHandler.
+func getTraceFuncName(f http.HandlerFunc) string {
+	name := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSuffix(name, "-fm")
+	return strings.TrimSuffix(name, "Handler")
+}
+
+// traceRequest - serves the request with f and returns its trace record,
+// with the beginning of the request and response bodies when logBody is
+// set.
+func traceRequest(f http.HandlerFunc, funcName string, logBody bool, w http.ResponseWriter, r *http.Request) trace.Info {
+	reqBodyRecorder := &recordRequest{Reader: r.Body, logBody: logBody}
+	r.Body = ioutil.NopCloser(reqBodyRecorder)
+	respBodyRecorder := &recordResponseWriter{ResponseWriter: w, logBody: logBody}
+
+	reqInfo := trace.RequestInfo{
+		Time:     UTCNow(),
+		Method:   r.Method,
+		Host:     r.Host,
+		Path:     r.URL.Path,
+		RawQuery: redactQuery(r.URL.RawQuery),
+		Headers:  redactHeaders(r.Header),
+	}
+
+	f(respBodyRecorder, r)
+
+	respInfo := trace.ResponseInfo{
+		Time:       UTCNow(),
+		Headers:    respBodyRecorder.headers,
+		StatusCode: respBodyRecorder.statusCode,
+	}
+	if respInfo.Headers == nil {
+		// Nothing was written, the response is an empty '200 OK'.
+		respInfo.Headers = cloneHeader(w.Header())
+		respInfo.StatusCode = http.StatusOK
+	}
+	if logBody {
+		reqInfo.Body = reqBodyRecorder.buf.Bytes()
+		respInfo.Body = respBodyRecorder.body.Bytes()
+	}
+
+	return trace.Info{
+		NodeName: GetLocalPeer(globalEndpoints),
+		FuncName: funcName,
+		ReqInfo:  reqInfo,
+		RespInfo: respInfo,
+		CallStats: trace.CallStats{
+			Latency:     respInfo.Time.Sub(reqInfo.Time),
+			InputBytes:  reqBodyRecorder.bytesRead,
+			OutputBytes: respBodyRecorder.bytesWritten,
+		},
+	}
+}
+
+// traceOptions - selects the trace records sent to a trace listener.
+type traceOptions struct {
+	errorsOnly bool   // Only failed requests.
+	api        string // Only requests to this API, for example "PutObject".
+	bucket     string // Only requests to this bucket.
+	body       bool   // Send the recorded request and response bodies.
+	internal   bool   // Also send the internal requests between nodes.
+}
+
+// parseTraceOptions - parses the trace options from the query of a trace
+// request.
+func parseTraceOptions(values url.Values) (opts traceOptions, err error) {
+	parseBool := func(key string) (bool, error) {
+		if value := values.Get(key); value != "" {
+			return strconv.ParseBool(value)
+		}
+		return false, nil
+	}
+	if opts.errorsOnly, err = parseBool("errors"); err != nil {
+		return opts, err
+	}
+	if opts.body, err = parseBool("body"); err != nil {
+		return opts, err
+	}
+	if opts.internal, err = parseBool("internal"); err != nil {
+		return opts, err
+	}
+	opts.api = values.Get("api")
+	opts.bucket = values.Get("bucket")
+	return opts, nil
+}
+
+// subscribeTrace - sends the trace records of this node matching opts on
+// traceCh until doneCh is closed. Bodies are only recorded while some
+// listener asked for them.
+func subscribeTrace(traceCh chan interface{}, doneCh <-chan struct{}, opts traceOptions) {
+	if opts.body {
+		atomic.AddInt32(&globalHTTPTraceBodyListeners, 1)
+		go func() {
+			<-doneCh
+			atomic.AddInt32(&globalHTTPTraceBodyListeners, -1)
+		}()
+	}
+	globalHTTPTrace.Subscribe(traceCh, doneCh, opts.matches)
+}
+
+// values - returns the query of the trace request to a peer.
+func (opts traceOptions) values() url.Values {
+	values := url.Values{}
+	values.Set("errors", strconv.FormatBool(opts.errorsOnly))
+	values.Set("body", strconv.FormatBool(opts.body))
+	values.Set("internal", strconv.FormatBool(opts.internal))
+	if opts.api != "" {
+		values.Set("api", opts.api)
+	}
+	if opts.bucket != "" {
+		values.Set("bucket", opts.bucket)
+	}
+	return values
+}
+
+// matches - returns whether the trace record entry should be sent.
+func (opts traceOptions) matches(entry interface{}) bool {
+	info, ok := entry.(trace.Info)
+	if !ok {
+		return false
+	}
+	if !opts.internal && strings.HasPrefix(info.ReqInfo.Path, minioReservedBucketPath+slashSeparator) &&
+		!strings.HasPrefix(info.ReqInfo.Path, adminAPIPathPrefix+slashSeparator) {
+		return false
+	}
+	if opts.errorsOnly && info.RespInfo.StatusCode < http.StatusBadRequest {
+		return false
+	}
+	if opts.api != "" && !strings.EqualFold(opts.api, info.FuncName) {
+		return false
+	}
+	if opts.bucket != "" {
+		resource, err := getResource(info.ReqInfo.Path, info.ReqInfo.Host, globalDomainName)
+		if err != nil {
+			return false
+		}
+		if bucket, _ := urlPath2BucketObjectName(resource); bucket != opts.bucket {
+			return false
+		}
+	}
+	return true
+}
+
+// prepare - returns the trace record as it should be sent.
+func (opts traceOptions) prepare(info trace.Info) trace.Info {
+	if !opts.body {
+		info.ReqInfo.Body = nil
+		info.RespInfo.Body = nil
+	}
+	return info
+}
+
+// writeTraceStream - writes the trace records received on traceCh to w as
+// JSON objects, until the client disconnects.
+func writeTraceStream(w http.ResponseWriter, r *http.Request, traceCh <-chan interface{}, opts traceOptions) {
+	writeJSONStream(w, r, traceCh, func(entry interface{}) (interface{}, bool) {
+		info, ok := entry.(trace.Info)
+		if !ok {
+			return nil, false
+		}
+		return opts.prepare(info), true
+	})
+}
+
+// writeJSONStream - writes the entries received on ch, as returned by
+// prepare, to w as JSON objects until the client disconnects. Entries
+// prepare does not accept are skipped, whitespace is sent to keep the
+// stream alive while there are no entries.
+func writeJSONStream(w http.ResponseWriter, r *http.Request, ch <-chan interface{}, prepare func(interface{}) (interface{}, bool)) {
+	setCommonHeaders(w)
+	w.Header().Set("Content-Type", string(mimeJSON))
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+
+	keepAliveTicker := time.NewTicker(streamKeepAliveInterval)
+	defer keepAliveTicker.Stop()
+
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case entry := <-ch:
+			v, ok := prepare(entry)
+			if !ok {
+				continue
+			}
+			if err := enc.Encode(v); err != nil {
+				return
+			}
+		case <-keepAliveTicker.C:
+			if _, err := w.Write([]byte(" ")); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		w.(http.Flusher).Flush()
+	}
+}
@@ -0,0 +1,223 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pydio/minio-srv/pkg/trace"
+)
+
+// Tests parsing trace options and selecting trace records.
+func TestTraceOptions(t *testing.T) {
+	testCases := []struct {
+		values      url.Values
+		info        trace.Info
+		expectedErr bool
+		matches     bool
+	}{
+		// Test - 1 all requests.
+		{url.Values{}, trace.Info{FuncName: "PutObject", ReqInfo: trace.RequestInfo{Path: "/bucket/object"}, RespInfo: trace.ResponseInfo{StatusCode: http.StatusOK}}, false, true},
+		// Test - 2 invalid bool.
+		{url.Values{"errors": []string{"maybe"}}, trace.Info{}, true, false},
+		// Test - 3 only failed requests.
+		{url.Values{"errors": []string{"true"}}, trace.Info{ReqInfo: trace.RequestInfo{Path: "/bucket"}, RespInfo: trace.ResponseInfo{StatusCode: http.StatusOK}}, false, false},
+		// Test - 4 only failed requests.
+		{url.Values{"errors": []string{"true"}}, trace.Info{ReqInfo: trace.RequestInfo{Path: "/bucket"}, RespInfo: trace.ResponseInfo{StatusCode: http.StatusNotFound}}, false, true},
+		// Test - 5 matching API.
+		{url.Values{"api": []string{"putobject"}}, trace.Info{FuncName: "PutObject", ReqInfo: trace.RequestInfo{Path: "/bucket/object"}}, false, true},
+		// Test - 6 other API.
+		{url.Values{"api": []string{"GetObject"}}, trace.Info{FuncName: "PutObject", ReqInfo: trace.RequestInfo{Path: "/bucket/object"}}, false, false},
+		// Test - 7 matching bucket.
+		{url.Values{"bucket": []string{"bucket"}}, trace.Info{ReqInfo: trace.RequestInfo{Path: "/bucket/object"}}, false, true},
+		// Test - 8 other bucket.
+		{url.Values{"bucket": []string{"other"}}, trace.Info{ReqInfo: trace.RequestInfo{Path: "/bucket/object"}}, false, false},
+		// Test - 9 internal request.
+		{url.Values{}, trace.Info{ReqInfo: trace.RequestInfo{Path: "/minio/storage/v1"}}, false, false},
+		// Test - 10 internal request on demand.
+		{url.Values{"internal": []string{"true"}}, trace.Info{ReqInfo: trace.RequestInfo{Path: "/minio/storage/v1"}}, false, true},
+		// Test - 11 admin request.
+		{url.Values{}, trace.Info{ReqInfo: trace.RequestInfo{Path: "/minio/admin/v1/info"}}, false, true},
+	}
+	for i, testCase := range testCases {
+		opts, err := parseTraceOptions(testCase.values)
+		if (err != nil) != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if matches := opts.matches(testCase.info); matches != testCase.matches {
+			t.Errorf("Test %d: Expected matches %v, got %v", i+1, testCase.matches, matches)
+		}
+		// Options sent to peers must select the same records.
+		peerOpts, err := parseTraceOptions(opts.values())
+		if err != nil || peerOpts != opts {
+			t.Errorf("Test %d: Expected peer options %v, got %v, %v", i+1, opts, peerOpts, err)
+		}
+	}
+}
+
+func traceTestHandler(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	w.Header().Set("X-Test", "test")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(append(body, body...))
+}
+
+// Tests publishing trace records of traced handlers.
+func TestHTTPTrace(t *testing.T) {
+	largeBody := bytes.Repeat([]byte("a"), traceBodyMaxSize)
+	testCases := []struct {
+		handler      http.HandlerFunc
+		opts         traceOptions
+		body         []byte
+		expectedBody bool
+	}{
+		{httpTraceAll(traceTestHandler), traceOptions{body: true}, []byte("data"), true},
+		{httpTraceHdrs(traceTestHandler), traceOptions{body: true}, []byte("data"), false},
+		// No listener asked for the bodies.
+		{httpTraceAll(traceTestHandler), traceOptions{}, []byte("data"), false},
+		// Recorded bodies are truncated.
+		{httpTraceAll(traceTestHandler), traceOptions{body: true}, largeBody, true},
+	}
+	for i, testCase := range testCases {
+		// Wait for the listeners of the previous test to be removed.
+		for deadline := time.Now().Add(time.Second); atomic.LoadInt32(&globalHTTPTraceBodyListeners) != 0; {
+			if time.Now().After(deadline) {
+				t.Fatalf("Test %d: Expected no body listeners", i+1)
+			}
+			time.Sleep(time.Millisecond)
+		}
+		traceCh := make(chan interface{}, 1)
+		doneCh := make(chan struct{})
+		subscribeTrace(traceCh, doneCh, testCase.opts)
+
+		req := httptest.NewRequest("PUT", "/bucket/object?versioning", bytes.NewReader(testCase.body))
+		rec := httptest.NewRecorder()
+		testCase.handler(rec, req)
+		close(doneCh)
+
+		var info trace.Info
+		select {
+		case entry := <-traceCh:
+			info = entry.(trace.Info)
+		case <-time.After(time.Second):
+			t.Fatalf("Test %d: Expected a trace record", i+1)
+		}
+
+		respBody := append(testCase.body, testCase.body...)
+		if rec.Code != http.StatusCreated || !bytes.Equal(rec.Body.Bytes(), respBody) {
+			t.Errorf("Test %d: Unexpected response %d %q", i+1, rec.Code, rec.Body.String())
+		}
+		if info.FuncName != "traceTest" {
+			t.Errorf("Test %d: Expected function traceTest, got %s", i+1, info.FuncName)
+		}
+		if info.ReqInfo.Method != "PUT" || info.ReqInfo.Path != "/bucket/object" || info.ReqInfo.RawQuery != "versioning" {
+			t.Errorf("Test %d: Unexpected request %v", i+1, info.ReqInfo)
+		}
+		if info.RespInfo.StatusCode != http.StatusCreated || info.RespInfo.Headers.Get("X-Test") != "test" {
+			t.Errorf("Test %d: Unexpected response %v", i+1, info.RespInfo)
+		}
+		if info.CallStats.InputBytes != int64(len(testCase.body)) || info.CallStats.OutputBytes != int64(len(respBody)) {
+			t.Errorf("Test %d: Unexpected stats %v", i+1, info.CallStats)
+		}
+		recordedRespBody := respBody
+		if len(recordedRespBody) > traceBodyMaxSize {
+			recordedRespBody = recordedRespBody[:traceBodyMaxSize]
+		}
+		hasBody := bytes.Equal(info.ReqInfo.Body, testCase.body) && bytes.Equal(info.RespInfo.Body, recordedRespBody)
+		if testCase.expectedBody && !hasBody {
+			t.Errorf("Test %d: Expected bodies, got %q and %q", i+1, info.ReqInfo.Body, info.RespInfo.Body)
+		}
+		if !testCase.expectedBody && (info.ReqInfo.Body != nil || info.RespInfo.Body != nil) {
+			t.Errorf("Test %d: Expected no bodies, got %q and %q", i+1, info.ReqInfo.Body, info.RespInfo.Body)
+		}
+	}
+}
+
+// Tests redacting the credentials of the traced requests.
+func TestTraceRedaction(t *testing.T) {
+	queryTestCases := []struct {
+		rawQuery string
+		expected string
+	}{
+		{"", ""},
+		{"versioning", "versioning"},
+		{"prefix=a&max-keys=10", "prefix=a&max-keys=10"},
+		{"X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Credential=key%2F20180101&X-Amz-Signature=abcd",
+			"X-Amz-Algorithm=AWS4-HMAC-SHA256&X-Amz-Credential=%2AREDACTED%2A&X-Amz-Signature=%2AREDACTED%2A"},
+		{"X-Amz-Security-Token=token", "X-Amz-Security-Token=%2AREDACTED%2A"},
+		{"AWSAccessKeyId=key&Signature=abcd", "AWSAccessKeyId=key&Signature=%2AREDACTED%2A"},
+		{"prefix=a&pydio_jwt=token", "prefix=a&pydio_jwt=%2AREDACTED%2A"},
+		{"pydio_jwt=%zz", traceRedactedValue},
+	}
+	for i, testCase := range queryTestCases {
+		if rawQuery := redactQuery(testCase.rawQuery); rawQuery != testCase.expected {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.expected, rawQuery)
+		}
+	}
+
+	h := http.Header{}
+	h.Set("Authorization", "AWS4-HMAC-SHA256 Credential=key/20180101, Signature=abcd")
+	h.Set("X-Amz-Security-Token", "token")
+	h.Set("X-Pydio-Bearer", "jwt")
+	h.Set("X-Amz-Date", "20180101T000000Z")
+	redacted := redactHeaders(h)
+	for _, name := range []string{"Authorization", "X-Amz-Security-Token", "X-Pydio-Bearer"} {
+		if value := redacted.Get(name); value != traceRedactedValue {
+			t.Errorf("Expected %s to be redacted, got %q", name, value)
+		}
+	}
+	if redacted.Get("X-Amz-Date") != "20180101T000000Z" {
+		t.Errorf("Expected X-Amz-Date to be kept, got %q", redacted.Get("X-Amz-Date"))
+	}
+	if h.Get("Authorization") == traceRedactedValue {
+		t.Error("Expected request headers to be left unchanged")
+	}
+}
+
+// traceNoFlushWriter - response writer which cannot be flushed.
+type traceNoFlushWriter struct {
+	http.ResponseWriter
+}
+
+// Tests flushing traced responses written to writers which cannot be
+// flushed.
+func TestRecordResponseWriterFlush(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &recordResponseWriter{ResponseWriter: traceNoFlushWriter{rec}}
+	w.Write([]byte("data"))
+	w.Flush()
+	if rec.Flushed {
+		t.Fatal("Expected no flush")
+	}
+
+	w = &recordResponseWriter{ResponseWriter: rec}
+	w.Flush()
+	if !rec.Flushed {
+		t.Fatal("Expected response to be flushed")
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"context"
 	"encoding/json"
 	"path"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -45,13 +46,84 @@ const (
 	// IAM sts directory.
 	iamConfigSTSPrefix = iamConfigPrefix + "/sts/"
 
+	// IAM groups directory.
+	iamConfigGroupsPrefix = iamConfigPrefix + "/groups/"
+
+	// IAM service accounts directory.
+	iamConfigServiceAccountsPrefix = iamConfigPrefix + "/service-accounts/"
+
 	// IAM identity file which captures identity credentials.
 	iamIdentityFile = "identity.json"
 
 	// IAM policy file which provides policies for each users.
 	iamPolicyFile = "policy.json"
+
+	// IAM group members file.
+	iamGroupMembersFile = "members.json"
+
+	// IAM last used file, saved for users and service accounts.
+	iamLastUsedFile = "lastused.json"
+
+	// Current version of the group members file.
+	iamGroupInfoVersion = 1
+
+	// Current version of the user policy file.
+	iamUserPolicyInfoVersion = 1
+
+	// Current version of the last used file.
+	iamLastUsedInfoVersion = 1
+
+	// Shortest time between two saves of the last use of an access
+	// key, uses in between are only tracked in memory.
+	iamLastUsedSaveInterval = 10 * time.Minute
+
+	// Longest time the previous secret key of a user is accepted
+	// after a rotation.
+	maxSecretKeyGracePeriod = 7 * 24 * time.Hour
 )
 
+// UserPolicyInfo - canned policies attached to a user and its inline
+// policy, as saved in the user policy file. The file used to hold the
+// name of a single canned policy, such files are migrated when loaded.
+type UserPolicyInfo struct {
+	Version      int               `json:"version"`
+	Policies     []string          `json:"policies,omitempty"`
+	InlinePolicy *iampolicy.Policy `json:"inlinePolicy,omitempty"`
+}
+
+// IsEmpty - returns whether no policy is attached.
+func (info UserPolicyInfo) IsEmpty() bool {
+	return len(info.Policies) == 0 && info.InlinePolicy == nil
+}
+
+// parseUserPolicyInfo - decodes a user policy file, migrated is true when
+// the file has the format holding a single canned policy name.
+func parseUserPolicyInfo(data []byte) (info UserPolicyInfo, migrated bool, err error) {
+	var policyName string
+	if err = json.Unmarshal(data, &policyName); err == nil {
+		info = UserPolicyInfo{Version: iamUserPolicyInfoVersion}
+		if policyName != "" {
+			info.Policies = []string{policyName}
+		}
+		return info, true, nil
+	}
+	err = json.Unmarshal(data, &info)
+	return info, false, err
+}
+
+// LastUsedInfo - last successful authentication with an access key, as
+// saved in the last used file.
+type LastUsedInfo struct {
+	Version  int       `json:"version"`
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+// GroupInfo - members of a group, as saved in the group members file.
+type GroupInfo struct {
+	Version int      `json:"version"`
+	Members []string `json:"members"`
+}
+
 type IAMSysProvider interface {
 	Load(objAPI ObjectLayer) error
 	Init(objAPI ObjectLayer) error
@@ -59,12 +131,27 @@ type IAMSysProvider interface {
 	ListCannedPolicies() (map[string][]byte, error)
 	SetCannedPolicy(policyName string, p iampolicy.Policy) error
 	SetUserPolicy(accessKey, policyName string) error
+	AttachUserPolicy(accessKey, policyName string) error
+	DetachUserPolicy(accessKey, policyName string) error
+	SetUserInlinePolicy(accessKey string, p *iampolicy.Policy) error
+	GetUserPolicies(accessKey string) (madmin.UserPolicies, error)
 	DeleteUser(accessKey string) error
 	SetTempUser(accessKey string, cred auth.Credentials, policyName string) error
 	ListUsers() (map[string]madmin.UserInfo, error)
 	SetUserStatus(accessKey string, status madmin.AccountStatus) error
 	SetUser(accessKey string, uinfo madmin.UserInfo) error
 	GetUser(accessKey string) (cred auth.Credentials, ok bool)
+	AddUsersToGroup(group string, members []string) error
+	RemoveUsersFromGroup(group string, members []string) error
+	SetGroupPolicy(group, policyName string) error
+	GetGroupDescription(group string) (madmin.GroupDesc, error)
+	ListGroups() ([]string, error)
+	NewServiceAccount(parentUser string, p *iampolicy.Policy) (auth.Credentials, error)
+	ListServiceAccounts(parentUser string) ([]madmin.ServiceAccountInfo, error)
+	RotateServiceAccount(accessKey string) (auth.Credentials, error)
+	DeleteServiceAccount(accessKey string) error
+	RotateUserSecret(accessKey string, gracePeriod time.Duration) (auth.Credentials, error)
+	UpdateLastUsed(accessKey string)
 	IsAllowed(args iampolicy.Args) bool
 }
 
@@ -72,8 +159,18 @@ type IAMSysProvider interface {
 type IAMSys struct {
 	sync.RWMutex
 	iamUsersMap        map[string]auth.Credentials
-	iamPolicyMap       map[string]string
+	iamPolicyMap       map[string]UserPolicyInfo
 	iamCannedPolicyMap map[string]iampolicy.Policy
+	iamGroupsMap       map[string]GroupInfo
+	iamGroupPolicyMap  map[string]string
+	// Groups of each user, derived from iamGroupsMap.
+	iamUserGroupMemberships map[string]set.StringSet
+
+	// Last successful authentication of the users and service
+	// accounts, and the last time it was saved for each access key.
+	lastUsedMu      sync.Mutex
+	lastUsedMap     map[string]time.Time
+	lastUsedSaveMap map[string]time.Time
 }
 
 // Load - load iam.json
@@ -212,8 +309,82 @@ func (sys *IAMSys) SetCannedPolicy(policyName string, p iampolicy.Policy) error
 	return nil
 }
 
-// SetUserPolicy - sets policy to given user name.
+// SetUserPolicy - sets policy to given user name, replacing the canned
+// policies attached to the user. The inline policy of the user is kept.
 func (sys *IAMSys) SetUserPolicy(accessKey, policyName string) error {
+	return sys.updateUserPolicies(accessKey, func(info *UserPolicyInfo) error {
+		if _, ok := sys.iamCannedPolicyMap[policyName]; !ok {
+			return errNoSuchPolicy
+		}
+		info.Policies = []string{policyName}
+		return nil
+	})
+}
+
+// AttachUserPolicy - attaches a canned policy to given user name, in
+// addition to the policies already attached.
+func (sys *IAMSys) AttachUserPolicy(accessKey, policyName string) error {
+	return sys.updateUserPolicies(accessKey, func(info *UserPolicyInfo) error {
+		if _, ok := sys.iamCannedPolicyMap[policyName]; !ok {
+			return errNoSuchPolicy
+		}
+		for _, name := range info.Policies {
+			if name == policyName {
+				return nil
+			}
+		}
+		info.Policies = append(info.Policies, policyName)
+		return nil
+	})
+}
+
+// DetachUserPolicy - detaches a canned policy from given user name.
+func (sys *IAMSys) DetachUserPolicy(accessKey, policyName string) error {
+	return sys.updateUserPolicies(accessKey, func(info *UserPolicyInfo) error {
+		for i, name := range info.Policies {
+			if name == policyName {
+				info.Policies = append(info.Policies[:i:i], info.Policies[i+1:]...)
+				return nil
+			}
+		}
+		return errNoSuchPolicy
+	})
+}
+
+// SetUserInlinePolicy - sets the inline policy of given user name, a nil
+// policy removes the inline policy.
+func (sys *IAMSys) SetUserInlinePolicy(accessKey string, p *iampolicy.Policy) error {
+	return sys.updateUserPolicies(accessKey, func(info *UserPolicyInfo) error {
+		info.InlinePolicy = p
+		return nil
+	})
+}
+
+// GetUserPolicies - returns the canned policies attached to given user
+// name and its inline policy.
+func (sys *IAMSys) GetUserPolicies(accessKey string) (madmin.UserPolicies, error) {
+	sys.RLock()
+	defer sys.RUnlock()
+
+	if _, ok := sys.iamUsersMap[accessKey]; !ok {
+		return madmin.UserPolicies{}, errNoSuchUser
+	}
+
+	info := sys.iamPolicyMap[accessKey]
+	policies := madmin.UserPolicies{Policies: info.Policies}
+	if info.InlinePolicy != nil {
+		data, err := json.Marshal(info.InlinePolicy)
+		if err != nil {
+			return madmin.UserPolicies{}, err
+		}
+		policies.InlinePolicy = data
+	}
+	return policies, nil
+}
+
+// updateUserPolicies - applies update to the policies of given user name
+// and saves them, the user policy file is removed when no policy is left.
+func (sys *IAMSys) updateUserPolicies(accessKey string, update func(info *UserPolicyInfo) error) error {
 	objectAPI := newObjectLayerFn()
 	if objectAPI == nil {
 		return errServerNotInitialized
@@ -222,30 +393,38 @@ func (sys *IAMSys) SetUserPolicy(accessKey, policyName string) error {
 	sys.Lock()
 	defer sys.Unlock()
 
-	if _, ok := sys.iamUsersMap[accessKey]; !ok {
+	// The policy of service accounts is set on creation.
+	if cred, ok := sys.iamUsersMap[accessKey]; !ok || cred.IsServiceAccount() {
 		return errNoSuchUser
 	}
 
-	if _, ok := sys.iamCannedPolicyMap[policyName]; !ok {
-		return errNoSuchPolicy
-	}
-
-	data, err := json.Marshal(policyName)
-	if err != nil {
+	info := sys.iamPolicyMap[accessKey]
+	info.Version = iamUserPolicyInfoVersion
+	info.Policies = append([]string(nil), info.Policies...)
+	if err := update(&info); err != nil {
 		return err
 	}
 
 	configFile := pathJoin(iamConfigUsersPrefix, accessKey, iamPolicyFile)
-	if globalEtcdClient != nil {
-		err = saveConfigEtcd(context.Background(), globalEtcdClient, configFile, data)
-	} else {
-		err = saveConfig(context.Background(), objectAPI, configFile, data)
+	if info.IsEmpty() {
+		var err error
+		if globalEtcdClient != nil {
+			err = deleteConfigEtcd(context.Background(), globalEtcdClient, configFile)
+		} else {
+			err = deleteConfig(context.Background(), objectAPI, configFile)
+		}
+		if _, ok := err.(ObjectNotFound); err != nil && !ok {
+			return err
+		}
+		delete(sys.iamPolicyMap, accessKey)
+		return nil
 	}
-	if err != nil {
+
+	if err := saveIAMConfig(objectAPI, configFile, info); err != nil {
 		return err
 	}
 
-	sys.iamPolicyMap[accessKey] = policyName
+	sys.iamPolicyMap[accessKey] = info
 	return nil
 }
 
@@ -256,16 +435,27 @@ func (sys *IAMSys) DeleteUser(accessKey string) error {
 		return errServerNotInitialized
 	}
 
+	// Service accounts are removed with DeleteServiceAccount().
+	sys.RLock()
+	cred, found := sys.iamUsersMap[accessKey]
+	sys.RUnlock()
+	if found && cred.IsServiceAccount() {
+		return errNoSuchUser
+	}
+
 	var err error
 	pFile := pathJoin(iamConfigUsersPrefix, accessKey, iamPolicyFile)
 	iFile := pathJoin(iamConfigUsersPrefix, accessKey, iamIdentityFile)
+	lFile := pathJoin(iamConfigUsersPrefix, accessKey, iamLastUsedFile)
 	if globalEtcdClient != nil {
-		// It is okay to ingnore errors when deleting policy.json for the user.
+		// It is okay to ingnore errors when deleting policy.json and lastused.json for the user.
 		_ = deleteConfigEtcd(context.Background(), globalEtcdClient, pFile)
+		_ = deleteConfigEtcd(context.Background(), globalEtcdClient, lFile)
 		err = deleteConfigEtcd(context.Background(), globalEtcdClient, iFile)
 	} else {
-		// It is okay to ingnore errors when deleting policy.json for the user.
+		// It is okay to ingnore errors when deleting policy.json and lastused.json for the user.
 		_ = deleteConfig(context.Background(), objectAPI, pFile)
+		_ = deleteConfig(context.Background(), objectAPI, lFile)
 		err = deleteConfig(context.Background(), objectAPI, iFile)
 	}
 
@@ -281,6 +471,27 @@ func (sys *IAMSys) DeleteUser(accessKey string) error {
 	delete(sys.iamUsersMap, accessKey)
 	delete(sys.iamPolicyMap, accessKey)
 
+	sys.deleteLastUsed(accessKey)
+
+	// Remove the user from its groups.
+	for _, group := range sys.iamUserGroupMemberships[accessKey].ToSlice() {
+		gi := sys.iamGroupsMap[group]
+		gi.Members = set.CreateStringSet(gi.Members...).Difference(set.CreateStringSet(accessKey)).ToSlice()
+		if gerr := saveIAMConfig(objectAPI, pathJoin(iamConfigGroupsPrefix, group, iamGroupMembersFile), gi); gerr != nil {
+			logger.LogIf(context.Background(), gerr)
+			continue
+		}
+		sys.iamGroupsMap[group] = gi
+	}
+	delete(sys.iamUserGroupMemberships, accessKey)
+
+	// Remove the service accounts of the user.
+	for saKey, saCred := range sys.iamUsersMap {
+		if saCred.IsServiceAccount() && saCred.ParentUser == accessKey {
+			logger.LogIf(context.Background(), sys.deleteServiceAccount(objectAPI, saKey))
+		}
+	}
+
 	return err
 }
 
@@ -307,22 +518,16 @@ func (sys *IAMSys) SetTempUser(accessKey string, cred auth.Credentials, policyNa
 			return nil
 		}
 
-		data, err := json.Marshal(policyName)
-		if err != nil {
-			return err
+		info := UserPolicyInfo{
+			Version:  iamUserPolicyInfoVersion,
+			Policies: []string{policyName},
 		}
-
 		configFile := pathJoin(iamConfigSTSPrefix, accessKey, iamPolicyFile)
-		if globalEtcdClient != nil {
-			err = saveConfigEtcd(context.Background(), globalEtcdClient, configFile, data)
-		} else {
-			err = saveConfig(context.Background(), objectAPI, configFile, data)
-		}
-		if err != nil {
+		if err := saveIAMConfig(objectAPI, configFile, info); err != nil {
 			return err
 		}
 
-		sys.iamPolicyMap[accessKey] = policyName
+		sys.iamPolicyMap[accessKey] = info
 	}
 
 	configFile := pathJoin(iamConfigSTSPrefix, accessKey, iamIdentityFile)
@@ -358,10 +563,22 @@ func (sys *IAMSys) ListUsers() (map[string]madmin.UserInfo, error) {
 	defer sys.RUnlock()
 
 	for k, v := range sys.iamUsersMap {
-		users[k] = madmin.UserInfo{
-			PolicyName: sys.iamPolicyMap[k],
+		// Service accounts are listed with ListServiceAccounts().
+		if v.IsServiceAccount() {
+			continue
+		}
+		uinfo := madmin.UserInfo{
+			PolicyName: strings.Join(sys.iamPolicyMap[k].Policies, ","),
 			Status:     madmin.AccountStatus(v.Status),
 		}
+		if !v.Expiration.IsZero() {
+			expiration := v.Expiration
+			uinfo.Expiration = &expiration
+		}
+		if lastUsed, ok := sys.lastUsed(k); ok {
+			uinfo.LastUsed = &lastUsed
+		}
+		users[k] = uinfo
 	}
 
 	return users, nil
@@ -382,13 +599,40 @@ func (sys *IAMSys) SetUserStatus(accessKey string, status madmin.AccountStatus)
 	defer sys.Unlock()
 
 	cred, ok := sys.iamUsersMap[accessKey]
-	if !ok {
+	if !ok || cred.IsServiceAccount() {
 		return errNoSuchUser
 	}
 
-	uinfo := madmin.UserInfo{
-		SecretKey: cred.SecretKey,
-		Status:    status,
+	// The expiration and the rotated secret key of the user are kept.
+	cred.Status = string(status)
+
+	configFile := pathJoin(iamConfigUsersPrefix, accessKey, iamIdentityFile)
+	if err := saveIAMConfig(objectAPI, configFile, cred); err != nil {
+		return err
+	}
+
+	sys.iamUsersMap[accessKey] = cred
+	return nil
+}
+
+// SetUser - set user credentials.
+func (sys *IAMSys) SetUser(accessKey string, uinfo madmin.UserInfo) error {
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		return errServerNotInitialized
+	}
+
+	// Users cannot take the access key of a service account.
+	sys.RLock()
+	cred, found := sys.iamUsersMap[accessKey]
+	sys.RUnlock()
+	if found && cred.IsServiceAccount() {
+		return errInvalidArgument
+	}
+
+	// An access key can't be created already expired.
+	if uinfo.Expiration != nil && !uinfo.Expiration.After(UTCNow()) {
+		return errInvalidArgument
 	}
 
 	configFile := pathJoin(iamConfigUsersPrefix, accessKey, iamIdentityFile)
@@ -407,57 +651,519 @@ func (sys *IAMSys) SetUserStatus(accessKey string, status madmin.AccountStatus)
 		return err
 	}
 
-	sys.iamUsersMap[accessKey] = auth.Credentials{
+	cred = auth.Credentials{
 		AccessKey: accessKey,
 		SecretKey: uinfo.SecretKey,
 		Status:    string(uinfo.Status),
 	}
+	if uinfo.Expiration != nil {
+		cred.Expiration = uinfo.Expiration.UTC()
+	}
 
+	sys.Lock()
+	defer sys.Unlock()
+
+	sys.iamUsersMap[accessKey] = cred
 	return nil
 }
 
-// SetUser - set user credentials.
-func (sys *IAMSys) SetUser(accessKey string, uinfo madmin.UserInfo) error {
+// RotateUserSecret - replaces the secret key of a user with a new one,
+// the previous secret key is still accepted during gracePeriod.
+func (sys *IAMSys) RotateUserSecret(accessKey string, gracePeriod time.Duration) (auth.Credentials, error) {
 	objectAPI := newObjectLayerFn()
 	if objectAPI == nil {
-		return errServerNotInitialized
+		return auth.Credentials{}, errServerNotInitialized
 	}
 
-	configFile := pathJoin(iamConfigUsersPrefix, accessKey, iamIdentityFile)
-	data, err := json.Marshal(uinfo)
+	if gracePeriod < 0 || gracePeriod > maxSecretKeyGracePeriod {
+		return auth.Credentials{}, errInvalidArgument
+	}
+
+	sys.Lock()
+	defer sys.Unlock()
+
+	cred, ok := sys.iamUsersMap[accessKey]
+	if !ok || cred.IsTemp() || cred.IsServiceAccount() {
+		return auth.Credentials{}, errNoSuchUser
+	}
+
+	newCred, err := auth.GetNewCredentials()
 	if err != nil {
+		return auth.Credentials{}, err
+	}
+
+	// Rotating again during a grace period ends it, only the secret key
+	// being replaced is kept.
+	cred.PrevSecretKey = nil
+	if gracePeriod > 0 {
+		cred.PrevSecretKey = &auth.PrevSecretKey{
+			SecretKey:  cred.SecretKey,
+			Expiration: UTCNow().Add(gracePeriod),
+		}
+	}
+	cred.SecretKey = newCred.SecretKey
+
+	configFile := pathJoin(iamConfigUsersPrefix, accessKey, iamIdentityFile)
+	if err = saveIAMConfig(objectAPI, configFile, cred); err != nil {
+		return auth.Credentials{}, err
+	}
+
+	sys.iamUsersMap[accessKey] = cred
+	return cred, nil
+}
+
+// isLastUsedTracked - returns whether the last use of cred is tracked,
+// only the uses of long-term users and service accounts are tracked.
+func isLastUsedTracked(cred auth.Credentials) bool {
+	return !cred.IsTemp() && !cred.IsExpired()
+}
+
+// lastUsedFile - returns the last used file of cred.
+func lastUsedFile(cred auth.Credentials) string {
+	if cred.IsServiceAccount() {
+		return pathJoin(iamConfigServiceAccountsPrefix, cred.AccessKey, iamLastUsedFile)
+	}
+	return pathJoin(iamConfigUsersPrefix, cred.AccessKey, iamLastUsedFile)
+}
+
+// UpdateLastUsed - records a successful authentication with accessKey,
+// and saves it in the background unless it was saved recently.
+func (sys *IAMSys) UpdateLastUsed(accessKey string) {
+	sys.RLock()
+	cred, ok := sys.iamUsersMap[accessKey]
+	sys.RUnlock()
+	if !ok || !isLastUsedTracked(cred) {
+		return
+	}
+
+	now := UTCNow()
+	sys.lastUsedMu.Lock()
+	sys.lastUsedMap[accessKey] = now
+	save := now.Sub(sys.lastUsedSaveMap[accessKey]) >= iamLastUsedSaveInterval
+	if save {
+		sys.lastUsedSaveMap[accessKey] = now
+	}
+	sys.lastUsedMu.Unlock()
+
+	if save {
+		go sys.saveLastUsed(cred, now)
+	}
+}
+
+// saveLastUsed - saves lastUsed in the last used file of cred, unless
+// cred was removed in the meantime.
+func (sys *IAMSys) saveLastUsed(cred auth.Credentials, lastUsed time.Time) {
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		return
+	}
+
+	sys.RLock()
+	defer sys.RUnlock()
+
+	if _, ok := sys.iamUsersMap[cred.AccessKey]; !ok {
+		return
+	}
+	info := LastUsedInfo{Version: iamLastUsedInfoVersion, LastUsed: lastUsed}
+	logger.LogIf(context.Background(), saveIAMConfig(objectAPI, lastUsedFile(cred), info))
+}
+
+// deleteLastUsed - forgets the last use of accessKey.
+func (sys *IAMSys) deleteLastUsed(accessKey string) {
+	sys.lastUsedMu.Lock()
+	defer sys.lastUsedMu.Unlock()
+
+	delete(sys.lastUsedMap, accessKey)
+	delete(sys.lastUsedSaveMap, accessKey)
+}
+
+// lastUsed - returns the last successful authentication with accessKey.
+func (sys *IAMSys) lastUsed(accessKey string) (time.Time, bool) {
+	sys.lastUsedMu.Lock()
+	defer sys.lastUsedMu.Unlock()
+
+	lastUsed, ok := sys.lastUsedMap[accessKey]
+	return lastUsed, ok
+}
+
+// GetUser - get user credentials
+func (sys *IAMSys) GetUser(accessKey string) (cred auth.Credentials, ok bool) {
+	sys.RLock()
+	defer sys.RUnlock()
+
+	cred, ok = sys.iamUsersMap[accessKey]
+	return cred, ok && cred.IsValid()
+}
+
+// isValidGroupName - group names are used as a single path component of
+// the IAM config, they can't be empty, contain '/' or be a dot entry.
+func isValidGroupName(group string) bool {
+	return group != "" && group != "." && group != ".." && !strings.Contains(group, slashSeparator)
+}
+
+// AddUsersToGroup - adds users to a group, creating the group if it
+// doesn't exist.
+func (sys *IAMSys) AddUsersToGroup(group string, members []string) error {
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		return errServerNotInitialized
+	}
+
+	if !isValidGroupName(group) {
+		return errInvalidArgument
+	}
+
+	sys.Lock()
+	defer sys.Unlock()
+
+	// Temporary users and service accounts cannot be group members.
+	for _, member := range members {
+		cred, ok := sys.iamUsersMap[member]
+		if !ok || cred.SessionToken != "" || cred.IsServiceAccount() {
+			return errNoSuchUser
+		}
+	}
+
+	gi, ok := sys.iamGroupsMap[group]
+	if !ok {
+		gi = GroupInfo{Version: iamGroupInfoVersion}
+	}
+	gi.Members = set.CreateStringSet(gi.Members...).Union(set.CreateStringSet(members...)).ToSlice()
+
+	configFile := pathJoin(iamConfigGroupsPrefix, group, iamGroupMembersFile)
+	if err := saveIAMConfig(objectAPI, configFile, gi); err != nil {
 		return err
 	}
 
-	if globalEtcdClient != nil {
-		err = saveConfigEtcd(context.Background(), globalEtcdClient, configFile, data)
-	} else {
-		err = saveConfig(context.Background(), objectAPI, configFile, data)
+	sys.iamGroupsMap[group] = gi
+	for _, member := range members {
+		addGroupMembership(sys.iamUserGroupMemberships, member, group)
 	}
+	return nil
+}
 
-	if err != nil {
+// RemoveUsersFromGroup - removes users from a group. When no users are
+// given, the group itself is removed, provided that it has no members.
+func (sys *IAMSys) RemoveUsersFromGroup(group string, members []string) error {
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		return errServerNotInitialized
+	}
+
+	if !isValidGroupName(group) {
+		return errInvalidArgument
+	}
+
+	sys.Lock()
+	defer sys.Unlock()
+
+	gi, ok := sys.iamGroupsMap[group]
+	if !ok {
+		return errNoSuchGroup
+	}
+
+	if len(members) == 0 {
+		if len(gi.Members) != 0 {
+			return errGroupNotEmpty
+		}
+
+		mFile := pathJoin(iamConfigGroupsPrefix, group, iamGroupMembersFile)
+		pFile := pathJoin(iamConfigGroupsPrefix, group, iamPolicyFile)
+		var err error
+		if globalEtcdClient != nil {
+			// It is okay to ignore errors when deleting policy.json for the group.
+			_ = deleteConfigEtcd(context.Background(), globalEtcdClient, pFile)
+			err = deleteConfigEtcd(context.Background(), globalEtcdClient, mFile)
+		} else {
+			// It is okay to ignore errors when deleting policy.json for the group.
+			_ = deleteConfig(context.Background(), objectAPI, pFile)
+			err = deleteConfig(context.Background(), objectAPI, mFile)
+		}
+		if err != nil {
+			return err
+		}
+
+		delete(sys.iamGroupsMap, group)
+		delete(sys.iamGroupPolicyMap, group)
+		return nil
+	}
+
+	gi.Members = set.CreateStringSet(gi.Members...).Difference(set.CreateStringSet(members...)).ToSlice()
+
+	configFile := pathJoin(iamConfigGroupsPrefix, group, iamGroupMembersFile)
+	if err := saveIAMConfig(objectAPI, configFile, gi); err != nil {
 		return err
 	}
 
+	sys.iamGroupsMap[group] = gi
+	for _, member := range members {
+		if groups, ok := sys.iamUserGroupMemberships[member]; ok {
+			groups.Remove(group)
+		}
+	}
+	return nil
+}
+
+// SetGroupPolicy - sets policy to given group, all the members of the
+// group are given the policy. An empty policy name removes the policy
+// of the group.
+func (sys *IAMSys) SetGroupPolicy(group, policyName string) error {
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		return errServerNotInitialized
+	}
+
+	if !isValidGroupName(group) {
+		return errInvalidArgument
+	}
+
 	sys.Lock()
 	defer sys.Unlock()
 
-	sys.iamUsersMap[accessKey] = auth.Credentials{
-		AccessKey: accessKey,
-		SecretKey: uinfo.SecretKey,
-		Status:    string(uinfo.Status),
+	if _, ok := sys.iamGroupsMap[group]; !ok {
+		return errNoSuchGroup
+	}
+
+	configFile := pathJoin(iamConfigGroupsPrefix, group, iamPolicyFile)
+	if policyName == "" {
+		var err error
+		if globalEtcdClient != nil {
+			err = deleteConfigEtcd(context.Background(), globalEtcdClient, configFile)
+		} else {
+			err = deleteConfig(context.Background(), objectAPI, configFile)
+		}
+		if _, ok := err.(ObjectNotFound); err != nil && !ok {
+			return err
+		}
+		delete(sys.iamGroupPolicyMap, group)
+		return nil
 	}
 
+	if _, ok := sys.iamCannedPolicyMap[policyName]; !ok {
+		return errNoSuchPolicy
+	}
+
+	if err := saveIAMConfig(objectAPI, configFile, policyName); err != nil {
+		return err
+	}
+
+	sys.iamGroupPolicyMap[group] = policyName
 	return nil
 }
 
-// GetUser - get user credentials
-func (sys *IAMSys) GetUser(accessKey string) (cred auth.Credentials, ok bool) {
+// GetGroupDescription - returns the members and the policy of a group.
+func (sys *IAMSys) GetGroupDescription(group string) (madmin.GroupDesc, error) {
 	sys.RLock()
 	defer sys.RUnlock()
 
-	cred, ok = sys.iamUsersMap[accessKey]
-	return cred, ok && cred.IsValid()
+	gi, ok := sys.iamGroupsMap[group]
+	if !ok {
+		return madmin.GroupDesc{}, errNoSuchGroup
+	}
+
+	return madmin.GroupDesc{
+		Name:    group,
+		Members: gi.Members,
+		Policy:  sys.iamGroupPolicyMap[group],
+	}, nil
+}
+
+// ListGroups - lists all groups.
+func (sys *IAMSys) ListGroups() ([]string, error) {
+	sys.RLock()
+	defer sys.RUnlock()
+
+	groups := make([]string, 0, len(sys.iamGroupsMap))
+	for group := range sys.iamGroupsMap {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	return groups, nil
+}
+
+// NewServiceAccount - creates a service account owned by given parent
+// user, the service account gets the permissions of its parent narrowed
+// by the policy p, if any.
+func (sys *IAMSys) NewServiceAccount(parentUser string, p *iampolicy.Policy) (auth.Credentials, error) {
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		return auth.Credentials{}, errServerNotInitialized
+	}
+
+	sys.Lock()
+	defer sys.Unlock()
+
+	// Temporary users and service accounts cannot own service accounts.
+	parent, ok := sys.iamUsersMap[parentUser]
+	if !ok || parent.SessionToken != "" || parent.IsServiceAccount() {
+		return auth.Credentials{}, errNoSuchUser
+	}
+
+	cred, err := auth.GetNewCredentials()
+	if err != nil {
+		return auth.Credentials{}, err
+	}
+	cred.ParentUser = parentUser
+
+	// The policy is saved before the identity, so that the service
+	// account never exists without it.
+	if p != nil {
+		info := UserPolicyInfo{
+			Version:      iamUserPolicyInfoVersion,
+			InlinePolicy: p,
+		}
+		configFile := pathJoin(iamConfigServiceAccountsPrefix, cred.AccessKey, iamPolicyFile)
+		if err = saveIAMConfig(objectAPI, configFile, info); err != nil {
+			return auth.Credentials{}, err
+		}
+		sys.iamPolicyMap[cred.AccessKey] = info
+	}
+
+	configFile := pathJoin(iamConfigServiceAccountsPrefix, cred.AccessKey, iamIdentityFile)
+	if err = saveIAMConfig(objectAPI, configFile, cred); err != nil {
+		return auth.Credentials{}, err
+	}
+
+	sys.iamUsersMap[cred.AccessKey] = cred
+	return cred, nil
+}
+
+// ListServiceAccounts - lists the service accounts owned by given parent
+// user, sorted by access key.
+func (sys *IAMSys) ListServiceAccounts(parentUser string) ([]madmin.ServiceAccountInfo, error) {
+	sys.RLock()
+	defer sys.RUnlock()
+
+	if _, ok := sys.iamUsersMap[parentUser]; !ok {
+		return nil, errNoSuchUser
+	}
+
+	serviceAccounts := []madmin.ServiceAccountInfo{}
+	for accessKey, cred := range sys.iamUsersMap {
+		if !cred.IsServiceAccount() || cred.ParentUser != parentUser {
+			continue
+		}
+		info := madmin.ServiceAccountInfo{
+			AccessKey:  accessKey,
+			ParentUser: parentUser,
+			Status:     madmin.AccountStatus(cred.Status),
+		}
+		if p := sys.iamPolicyMap[accessKey].InlinePolicy; p != nil {
+			data, err := json.Marshal(p)
+			if err != nil {
+				return nil, err
+			}
+			info.Policy = data
+		}
+		serviceAccounts = append(serviceAccounts, info)
+	}
+
+	sort.Slice(serviceAccounts, func(i, j int) bool {
+		return serviceAccounts[i].AccessKey < serviceAccounts[j].AccessKey
+	})
+	return serviceAccounts, nil
+}
+
+// RotateServiceAccount - replaces the secret key of a service account.
+func (sys *IAMSys) RotateServiceAccount(accessKey string) (auth.Credentials, error) {
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		return auth.Credentials{}, errServerNotInitialized
+	}
+
+	sys.Lock()
+	defer sys.Unlock()
+
+	cred, ok := sys.iamUsersMap[accessKey]
+	if !ok || !cred.IsServiceAccount() {
+		return auth.Credentials{}, errNoSuchServiceAccount
+	}
+
+	newCred, err := auth.GetNewCredentials()
+	if err != nil {
+		return auth.Credentials{}, err
+	}
+	cred.SecretKey = newCred.SecretKey
+
+	configFile := pathJoin(iamConfigServiceAccountsPrefix, accessKey, iamIdentityFile)
+	if err = saveIAMConfig(objectAPI, configFile, cred); err != nil {
+		return auth.Credentials{}, err
+	}
+
+	sys.iamUsersMap[accessKey] = cred
+	return cred, nil
+}
+
+// DeleteServiceAccount - revokes a service account.
+func (sys *IAMSys) DeleteServiceAccount(accessKey string) error {
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		return errServerNotInitialized
+	}
+
+	sys.Lock()
+	defer sys.Unlock()
+
+	if cred, ok := sys.iamUsersMap[accessKey]; !ok || !cred.IsServiceAccount() {
+		return errNoSuchServiceAccount
+	}
+
+	return sys.deleteServiceAccount(objectAPI, accessKey)
+}
+
+// deleteServiceAccount - removes the identity and the policy files of a
+// service account, the caller must hold the lock.
+func (sys *IAMSys) deleteServiceAccount(objectAPI ObjectLayer, accessKey string) error {
+	pFile := pathJoin(iamConfigServiceAccountsPrefix, accessKey, iamPolicyFile)
+	iFile := pathJoin(iamConfigServiceAccountsPrefix, accessKey, iamIdentityFile)
+
+	// The identity is removed first, so that the service account never
+	// exists without its policy.
+	var err error
+	if globalEtcdClient != nil {
+		err = deleteConfigEtcd(context.Background(), globalEtcdClient, iFile)
+	} else {
+		err = deleteConfig(context.Background(), objectAPI, iFile)
+	}
+	if _, ok := err.(ObjectNotFound); err != nil && !ok {
+		return err
+	}
+
+	// It is okay to ignore errors when deleting policy.json and lastused.json.
+	lFile := pathJoin(iamConfigServiceAccountsPrefix, accessKey, iamLastUsedFile)
+	if globalEtcdClient != nil {
+		_ = deleteConfigEtcd(context.Background(), globalEtcdClient, pFile)
+		_ = deleteConfigEtcd(context.Background(), globalEtcdClient, lFile)
+	} else {
+		_ = deleteConfig(context.Background(), objectAPI, pFile)
+		_ = deleteConfig(context.Background(), objectAPI, lFile)
+	}
+
+	delete(sys.iamUsersMap, accessKey)
+	delete(sys.iamPolicyMap, accessKey)
+	sys.deleteLastUsed(accessKey)
+	return nil
+}
+
+// saveIAMConfig - saves v as JSON in configFile of the IAM configuration.
+func saveIAMConfig(objectAPI ObjectLayer, configFile string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if globalEtcdClient != nil {
+		return saveConfigEtcd(context.Background(), globalEtcdClient, configFile, data)
+	}
+	return saveConfig(context.Background(), objectAPI, configFile, data)
+}
+
+// addGroupMembership - records that user is a member of group.
+func addGroupMembership(memberships map[string]set.StringSet, user, group string) {
+	if groups, ok := memberships[user]; ok {
+		groups.Add(group)
+		return
+	}
+	memberships[user] = set.CreateStringSet(group)
 }
 
 // IsAllowed - checks given policy args is allowed to continue the Rest API.
@@ -465,25 +1171,105 @@ func (sys *IAMSys) IsAllowed(args iampolicy.Args) bool {
 	sys.RLock()
 	defer sys.RUnlock()
 
+	// Session policies of temporary credentials can only narrow
+	// the permissions, they are checked first.
+	if !isAllowedBySessionPolicy(args) {
+		return false
+	}
+
 	// If opa is configured, use OPA always.
 	if globalPolicyOPA != nil {
 		return globalPolicyOPA.IsAllowed(args)
 	}
 
-	// If policy is available for given user, check the policy.
-	if name, found := sys.iamPolicyMap[args.AccountName]; found {
-		p, ok := sys.iamCannedPolicyMap[name]
-		return ok && p.IsAllowed(args)
+	// Temporary credentials of LDAP users get the canned policies
+	// mapped from their groups.
+	if claim, ok := args.Claims[stsPoliciesClaim].([]interface{}); ok {
+		var names []string
+		for _, name := range claim {
+			if policyName, ok := name.(string); ok {
+				names = append(names, policyName)
+			}
+		}
+		return sys.isAllowedByPolicies(names, nil, args)
+	}
+
+	// Temporary credentials obtained through AssumeRole get the
+	// policy of their parent user, as long as it is valid.
+	accountName := args.AccountName
+	if parent, ok := args.Claims[stsParentClaim].(string); ok {
+		if cred, found := sys.iamUsersMap[parent]; !found || !cred.IsValid() {
+			return false
+		}
+		accountName = parent
+	}
+
+	// Service accounts get the policies of their parent user, as long
+	// as it is valid, narrowed by their own policy.
+	if cred, found := sys.iamUsersMap[args.AccountName]; found && cred.IsServiceAccount() {
+		if parent, found := sys.iamUsersMap[cred.ParentUser]; !found || !parent.IsValid() {
+			return false
+		}
+		if p := sys.iamPolicyMap[args.AccountName].InlinePolicy; p != nil && !p.IsAllowed(args) {
+			return false
+		}
+		accountName = cred.ParentUser
+	}
+
+	// Users get the union of their own policies, of their inline
+	// policy and of the policies of their groups.
+	info := sys.iamPolicyMap[accountName]
+	names := append([]string(nil), info.Policies...)
+	for _, group := range sys.iamUserGroupMemberships[accountName].ToSlice() {
+		if name, found := sys.iamGroupPolicyMap[group]; found {
+			names = append(names, name)
+		}
+	}
+
+	// If policies are available for given user, check the policies.
+	if len(names) > 0 || info.InlinePolicy != nil {
+		return sys.isAllowedByPolicies(names, info.InlinePolicy, args)
 	}
 
 	// As policy is not available and OPA is not configured, return the owner value.
 	return args.IsOwner
 }
 
+// isAllowedByPolicies - checks args against the statements of the canned
+// policies names and of the inline policy if any, an explicit deny in any
+// of them wins.
+func (sys *IAMSys) isAllowedByPolicies(names []string, inline *iampolicy.Policy, args iampolicy.Args) bool {
+	var combined iampolicy.Policy
+	if inline != nil {
+		combined.Statements = append(combined.Statements, inline.Statements...)
+	}
+	for _, policyName := range names {
+		if p, ok := sys.iamCannedPolicyMap[policyName]; ok {
+			combined.Statements = append(combined.Statements, p.Statements...)
+		}
+	}
+	return combined.IsAllowed(args)
+}
+
+// isAllowedBySessionPolicy - checks args against the session policy set in
+// the claims of temporary credentials, if any.
+func isAllowedBySessionPolicy(args iampolicy.Args) bool {
+	policyStr, ok := args.Claims[stsSessionPolicyClaim].(string)
+	if !ok {
+		return true
+	}
+	p, err := iampolicy.ParseConfig(strings.NewReader(policyStr))
+	if err != nil {
+		logger.LogIf(context.Background(), err)
+		return false
+	}
+	return p.IsAllowed(args)
+}
+
 var defaultContextTimeout = 5 * time.Minute
 
 // Similar to reloadUsers but updates users, policies maps from etcd server,
-func reloadEtcdUsers(prefix string, usersMap map[string]auth.Credentials, policyMap map[string]string) error {
+func reloadEtcdUsers(prefix string, usersMap map[string]auth.Credentials, policyMap map[string]UserPolicyInfo) error {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultContextTimeout)
 	r, err := globalEtcdClient.Get(ctx, prefix, etcd.WithPrefix(), etcd.WithKeysOnly())
 	defer cancel()
@@ -532,7 +1318,9 @@ func reloadEtcdUsers(prefix string, usersMap map[string]auth.Credentials, policy
 				return err
 			}
 			cred.AccessKey = user
-			if cred.IsExpired() {
+			// Expired users are kept, they are denied until an
+			// administrator removes them or sets a new expiration.
+			if cred.IsTemp() && cred.IsExpired() {
 				deleteConfigEtcd(ctx, globalEtcdClient, idFile)
 				deleteConfigEtcd(ctx, globalEtcdClient, pFile)
 				continue
@@ -540,11 +1328,16 @@ func reloadEtcdUsers(prefix string, usersMap map[string]auth.Credentials, policy
 			usersMap[cred.AccessKey] = cred
 		}
 		if perr == nil {
-			var policyName string
-			if err = json.Unmarshal(pdata, &policyName); err != nil {
+			info, migrated, err := parseUserPolicyInfo(pdata)
+			if err != nil {
 				return err
 			}
-			policyMap[user] = policyName
+			if migrated {
+				logger.LogIf(ctx, saveIAMConfig(nil, pFile, info))
+			}
+			if !info.IsEmpty() {
+				policyMap[user] = info
+			}
 		}
 	}
 	return nil
@@ -625,7 +1418,7 @@ func reloadPolicies(objectAPI ObjectLayer, prefix string, cannedPolicyMap map[st
 }
 
 // reloadUsers reads an updates users, policies from object layer into user and policy maps.
-func reloadUsers(objectAPI ObjectLayer, prefix string, usersMap map[string]auth.Credentials, policyMap map[string]string) error {
+func reloadUsers(objectAPI ObjectLayer, prefix string, usersMap map[string]auth.Credentials, policyMap map[string]UserPolicyInfo) error {
 	marker := ""
 	for {
 		var lo ListObjectsInfo
@@ -655,7 +1448,9 @@ func reloadUsers(objectAPI ObjectLayer, prefix string, usersMap map[string]auth.
 					return err
 				}
 				cred.AccessKey = path.Base(prefix)
-				if cred.IsExpired() {
+				// Expired users are kept, they are denied until an
+				// administrator removes them or sets a new expiration.
+				if cred.IsTemp() && cred.IsExpired() {
 					// Delete expired identity.
 					objectAPI.DeleteObject(context.Background(), minioMetaBucket, idFile)
 					// Delete expired identity policy.
@@ -665,11 +1460,16 @@ func reloadUsers(objectAPI ObjectLayer, prefix string, usersMap map[string]auth.
 				usersMap[cred.AccessKey] = cred
 			}
 			if perr == nil {
-				var policyName string
-				if err = json.Unmarshal(pdata, &policyName); err != nil {
+				info, migrated, err := parseUserPolicyInfo(pdata)
+				if err != nil {
 					return err
 				}
-				policyMap[path.Base(prefix)] = policyName
+				if migrated {
+					logger.LogIf(context.Background(), saveIAMConfig(objectAPI, pFile, info))
+				}
+				if !info.IsEmpty() {
+					policyMap[path.Base(prefix)] = info
+				}
 			}
 		}
 		if !lo.IsTruncated {
@@ -679,6 +1479,95 @@ func reloadUsers(objectAPI ObjectLayer, prefix string, usersMap map[string]auth.
 	return nil
 }
 
+// Similar to reloadGroups but updates groups, group policies maps from etcd server.
+func reloadEtcdGroups(prefix string, groupsMap map[string]GroupInfo, groupPolicyMap map[string]string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultContextTimeout)
+	r, err := globalEtcdClient.Get(ctx, prefix, etcd.WithPrefix(), etcd.WithKeysOnly())
+	defer cancel()
+	if err != nil {
+		return err
+	}
+	// No groups are created yet.
+	if r.Count == 0 {
+		return nil
+	}
+
+	groups := set.NewStringSet()
+	for _, kv := range r.Kvs {
+		// Extract group the same way as users, e.g.
+		//
+		//  key := "config/iam/groups/newgroup/members.json"
+		//  prefix := "config/iam/groups/"
+		//  v := trim(trim(key, prefix), base(key)) == "newgroup"
+		//
+		group := path.Clean(strings.TrimSuffix(strings.TrimPrefix(string(kv.Key), prefix), path.Base(string(kv.Key))))
+		groups.Add(group)
+	}
+
+	// Reload members and policies for all groups.
+	for _, group := range groups.ToSlice() {
+		mdata, merr := readConfigEtcd(ctx, globalEtcdClient, pathJoin(prefix, group, iamGroupMembersFile))
+		pdata, perr := readConfigEtcd(ctx, globalEtcdClient, pathJoin(prefix, group, iamPolicyFile))
+		if err = loadGroup(group, mdata, merr, pdata, perr, groupsMap, groupPolicyMap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reloadGroups reads groups and their policies from object layer into group and policy maps.
+func reloadGroups(objectAPI ObjectLayer, prefix string, groupsMap map[string]GroupInfo, groupPolicyMap map[string]string) error {
+	marker := ""
+	for {
+		lo, err := objectAPI.ListObjects(context.Background(), minioMetaBucket, prefix, marker, "/", 1000)
+		if err != nil {
+			return err
+		}
+		marker = lo.NextMarker
+		for _, prefix := range lo.Prefixes {
+			mdata, merr := readConfig(context.Background(), objectAPI, pathJoin(prefix, iamGroupMembersFile))
+			pdata, perr := readConfig(context.Background(), objectAPI, pathJoin(prefix, iamPolicyFile))
+			if err = loadGroup(path.Base(prefix), mdata, merr, pdata, perr, groupsMap, groupPolicyMap); err != nil {
+				return err
+			}
+		}
+		if !lo.IsTruncated {
+			break
+		}
+	}
+	return nil
+}
+
+// loadGroup - decodes the members and the policy files of a group into the
+// group and policy maps. Groups without members file are skipped.
+func loadGroup(group string, mdata []byte, merr error, pdata []byte, perr error,
+	groupsMap map[string]GroupInfo, groupPolicyMap map[string]string) error {
+	if merr == errConfigNotFound {
+		return nil
+	}
+	if merr != nil {
+		return merr
+	}
+	if perr != nil && perr != errConfigNotFound {
+		return perr
+	}
+
+	var gi GroupInfo
+	if err := json.Unmarshal(mdata, &gi); err != nil {
+		return err
+	}
+	groupsMap[group] = gi
+
+	if perr == nil {
+		var policyName string
+		if err := json.Unmarshal(pdata, &policyName); err != nil {
+			return err
+		}
+		groupPolicyMap[group] = policyName
+	}
+	return nil
+}
+
 // Set default canned policies only if not already overridden by users.
 func setDefaultCannedPolicies(policies map[string]iampolicy.Policy) {
 	_, ok := policies["writeonly"]
@@ -698,8 +1587,10 @@ func setDefaultCannedPolicies(policies map[string]iampolicy.Policy) {
 // Refresh IAMSys.
 func (sys *IAMSys) refresh(objAPI ObjectLayer) error {
 	iamUsersMap := make(map[string]auth.Credentials)
-	iamPolicyMap := make(map[string]string)
+	iamPolicyMap := make(map[string]UserPolicyInfo)
 	iamCannedPolicyMap := make(map[string]iampolicy.Policy)
+	iamGroupsMap := make(map[string]GroupInfo)
+	iamGroupPolicyMap := make(map[string]string)
 
 	if globalEtcdClient != nil {
 		if err := reloadEtcdPolicies(iamConfigPoliciesPrefix, iamCannedPolicyMap); err != nil {
@@ -711,6 +1602,12 @@ func (sys *IAMSys) refresh(objAPI ObjectLayer) error {
 		if err := reloadEtcdUsers(iamConfigSTSPrefix, iamUsersMap, iamPolicyMap); err != nil {
 			return err
 		}
+		if err := reloadEtcdUsers(iamConfigServiceAccountsPrefix, iamUsersMap, iamPolicyMap); err != nil {
+			return err
+		}
+		if err := reloadEtcdGroups(iamConfigGroupsPrefix, iamGroupsMap, iamGroupPolicyMap); err != nil {
+			return err
+		}
 	} else {
 		if err := reloadPolicies(objAPI, iamConfigPoliciesPrefix, iamCannedPolicyMap); err != nil {
 			return err
@@ -721,26 +1618,111 @@ func (sys *IAMSys) refresh(objAPI ObjectLayer) error {
 		if err := reloadUsers(objAPI, iamConfigSTSPrefix, iamUsersMap, iamPolicyMap); err != nil {
 			return err
 		}
+		if err := reloadUsers(objAPI, iamConfigServiceAccountsPrefix, iamUsersMap, iamPolicyMap); err != nil {
+			return err
+		}
+		if err := reloadGroups(objAPI, iamConfigGroupsPrefix, iamGroupsMap, iamGroupPolicyMap); err != nil {
+			return err
+		}
 	}
 
 	// Sets default canned policies, if none set.
 	setDefaultCannedPolicies(iamCannedPolicyMap)
 
+	lastUsedMap := reloadLastUsed(objAPI, iamUsersMap)
+
+	iamUserGroupMemberships := make(map[string]set.StringSet)
+	for group, gi := range iamGroupsMap {
+		for _, member := range gi.Members {
+			addGroupMembership(iamUserGroupMemberships, member, group)
+		}
+	}
+
 	sys.Lock()
 	defer sys.Unlock()
 
 	sys.iamUsersMap = iamUsersMap
 	sys.iamPolicyMap = iamPolicyMap
 	sys.iamCannedPolicyMap = iamCannedPolicyMap
+	sys.iamGroupsMap = iamGroupsMap
+	sys.iamGroupPolicyMap = iamGroupPolicyMap
+	sys.iamUserGroupMemberships = iamUserGroupMemberships
+
+	sys.mergeLastUsed(lastUsedMap)
 
 	return nil
 }
 
+// reloadLastUsed - reads the saved last uses of the tracked users and
+// service accounts of usersMap.
+func reloadLastUsed(objectAPI ObjectLayer, usersMap map[string]auth.Credentials) map[string]time.Time {
+	lastUsedMap := make(map[string]time.Time)
+	for accessKey, cred := range usersMap {
+		if !isLastUsedTracked(cred) {
+			continue
+		}
+		var data []byte
+		var err error
+		if globalEtcdClient != nil {
+			data, err = readConfigEtcd(context.Background(), globalEtcdClient, lastUsedFile(cred))
+		} else {
+			data, err = readConfig(context.Background(), objectAPI, lastUsedFile(cred))
+		}
+		if err != nil {
+			if err != errConfigNotFound {
+				logger.LogIf(context.Background(), err)
+			}
+			continue
+		}
+		var info LastUsedInfo
+		if err = json.Unmarshal(data, &info); err != nil {
+			logger.LogIf(context.Background(), err)
+			continue
+		}
+		lastUsedMap[accessKey] = info.LastUsed
+	}
+	return lastUsedMap
+}
+
+// mergeLastUsed - replaces the last uses with the saved ones, keeping
+// the more recent uses tracked in memory. The uses of the access keys
+// removed or expired since are forgotten, the caller must hold the lock.
+func (sys *IAMSys) mergeLastUsed(savedMap map[string]time.Time) {
+	sys.lastUsedMu.Lock()
+	defer sys.lastUsedMu.Unlock()
+
+	lastUsedMap := make(map[string]time.Time)
+	lastUsedSaveMap := make(map[string]time.Time)
+	for accessKey, cred := range sys.iamUsersMap {
+		if !isLastUsedTracked(cred) {
+			continue
+		}
+		saved, ok := savedMap[accessKey]
+		if ok {
+			lastUsedMap[accessKey] = saved
+			lastUsedSaveMap[accessKey] = saved
+		}
+		if lastUsed, ok := sys.lastUsedMap[accessKey]; ok && lastUsed.After(saved) {
+			lastUsedMap[accessKey] = lastUsed
+		}
+		if lastSave, ok := sys.lastUsedSaveMap[accessKey]; ok && lastSave.After(saved) {
+			lastUsedSaveMap[accessKey] = lastSave
+		}
+	}
+	sys.lastUsedMap = lastUsedMap
+	sys.lastUsedSaveMap = lastUsedSaveMap
+}
+
 // NewIAMSys - creates new config system object.
 func NewIAMSys() *IAMSys {
 	return &IAMSys{
-		iamUsersMap:        make(map[string]auth.Credentials),
-		iamPolicyMap:       make(map[string]string),
-		iamCannedPolicyMap: make(map[string]iampolicy.Policy),
+		iamUsersMap:             make(map[string]auth.Credentials),
+		iamPolicyMap:            make(map[string]UserPolicyInfo),
+		iamCannedPolicyMap:      make(map[string]iampolicy.Policy),
+		iamGroupsMap:            make(map[string]GroupInfo),
+		iamGroupPolicyMap:       make(map[string]string),
+		iamUserGroupMemberships: make(map[string]set.StringSet),
+		lastUsedMap:             make(map[string]time.Time),
+		lastUsedSaveMap:         make(map[string]time.Time),
 	}
 }
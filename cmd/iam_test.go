@@ -0,0 +1,733 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pydio/minio-srv/pkg/auth"
+	"github.com/pydio/minio-srv/pkg/iam/policy"
+	"github.com/pydio/minio-srv/pkg/madmin"
+)
+
+// Test policies of temporary credentials obtained through AssumeRole.
+func TestIAMSysIsAllowedAssumeRole(t *testing.T) {
+	sys := NewIAMSys()
+	setDefaultCannedPolicies(sys.iamCannedPolicyMap)
+	sys.iamUsersMap["user"] = auth.Credentials{AccessKey: "user", SecretKey: "secretkey", Status: "enabled"}
+	sys.iamPolicyMap["user"] = UserPolicyInfo{Policies: []string{"readonly"}}
+	sys.iamUsersMap["disabled"] = auth.Credentials{AccessKey: "disabled", SecretKey: "secretkey", Status: "disabled"}
+	sys.iamPolicyMap["disabled"] = UserPolicyInfo{Policies: []string{"readwrite"}}
+
+	sessionPolicy := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":["s3:GetObject","s3:PutObject"],"Resource":["arn:aws:s3:::photos/*"]}]}`
+	testCases := []struct {
+		claims   map[string]interface{}
+		action   iampolicy.Action
+		bucket   string
+		expected bool
+	}{
+		// Temporary credentials get the policy of their parent.
+		{map[string]interface{}{stsParentClaim: "user"}, iampolicy.GetObjectAction, "docs", true},
+		{map[string]interface{}{stsParentClaim: "user"}, iampolicy.PutObjectAction, "docs", false},
+		// Session policies narrow the policy of the parent.
+		{map[string]interface{}{stsParentClaim: "user", stsSessionPolicyClaim: sessionPolicy}, iampolicy.GetObjectAction, "photos", true},
+		{map[string]interface{}{stsParentClaim: "user", stsSessionPolicyClaim: sessionPolicy}, iampolicy.GetObjectAction, "docs", false},
+		{map[string]interface{}{stsParentClaim: "user", stsSessionPolicyClaim: sessionPolicy}, iampolicy.PutObjectAction, "photos", false},
+		{map[string]interface{}{stsParentClaim: "user", stsSessionPolicyClaim: "invalid"}, iampolicy.GetObjectAction, "photos", false},
+		// Disabled or deleted parents have no access.
+		{map[string]interface{}{stsParentClaim: "disabled"}, iampolicy.GetObjectAction, "docs", false},
+		{map[string]interface{}{stsParentClaim: "deleted"}, iampolicy.GetObjectAction, "docs", false},
+	}
+	for i, testCase := range testCases {
+		args := iampolicy.Args{
+			AccountName: "tempuser",
+			Action:      testCase.action,
+			BucketName:  testCase.bucket,
+			ObjectName:  "object",
+			Claims:      testCase.claims,
+		}
+		if allowed := sys.IsAllowed(args); allowed != testCase.expected {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expected, allowed)
+		}
+	}
+}
+
+// Test groups, their persistence and the policies of their members.
+func TestIAMSysGroups(t *testing.T) {
+	initNSLock(false)
+	objLayer, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+	if err = newTestConfig(globalMinioDefaultRegion, objLayer); err != nil {
+		t.Fatal(err)
+	}
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = objLayer
+	globalObjLayerMutex.Unlock()
+	defer resetGlobalObjectAPI()
+
+	sys := NewIAMSys()
+	if err = sys.Init(objLayer); err != nil {
+		t.Fatal(err)
+	}
+	for _, user := range []string{"alice", "bob"} {
+		if err = sys.SetUser(user, madmin.UserInfo{SecretKey: "secretkey", Status: madmin.AccountEnabled}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = sys.SetUserPolicy("alice", "readonly"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = sys.AddUsersToGroup("writers", []string{"alice", "nobody"}); err != errNoSuchUser {
+		t.Fatalf("Expected %v, got %v", errNoSuchUser, err)
+	}
+	if err = sys.AddUsersToGroup("writers", []string{"alice", "bob"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.SetGroupPolicy("writers", "writeonly"); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.SetGroupPolicy("readers", "readonly"); err != errNoSuchGroup {
+		t.Fatalf("Expected %v, got %v", errNoSuchGroup, err)
+	}
+
+	// Group names are a single path component of the IAM config.
+	for i, group := range []string{"", ".", "..", "../users/alice", "writers/sub", "/writers"} {
+		if err = sys.AddUsersToGroup(group, []string{"bob"}); err != errInvalidArgument {
+			t.Fatalf("Test %d: expected %v, got %v", i+1, errInvalidArgument, err)
+		}
+		if err = sys.SetGroupPolicy(group, "writeonly"); err != errInvalidArgument {
+			t.Fatalf("Test %d: expected %v, got %v", i+1, errInvalidArgument, err)
+		}
+		if err = sys.RemoveUsersFromGroup(group, nil); err != errInvalidArgument {
+			t.Fatalf("Test %d: expected %v, got %v", i+1, errInvalidArgument, err)
+		}
+	}
+
+	// Groups are reloaded from the object layer.
+	if err = sys.refresh(objLayer); err != nil {
+		t.Fatal(err)
+	}
+	gdesc, err := sys.GetGroupDescription("writers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gdesc.Members) != 2 || gdesc.Policy != "writeonly" {
+		t.Fatalf("Unexpected group description %#v", gdesc)
+	}
+
+	type testCase struct {
+		accountName string
+		action      iampolicy.Action
+		expected    bool
+	}
+	testCases := []testCase{
+		// Members get the union of their policy and of their groups' policies.
+		{"alice", iampolicy.GetObjectAction, true},
+		{"alice", iampolicy.PutObjectAction, true},
+		{"bob", iampolicy.GetObjectAction, false},
+		{"bob", iampolicy.PutObjectAction, true},
+	}
+	check := func(testCases []testCase) {
+		t.Helper()
+		for i, testCase := range testCases {
+			args := iampolicy.Args{
+				AccountName: testCase.accountName,
+				Action:      testCase.action,
+				BucketName:  "bucket",
+				ObjectName:  "object",
+			}
+			if allowed := sys.IsAllowed(args); allowed != testCase.expected {
+				t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expected, allowed)
+			}
+		}
+	}
+	check(testCases)
+
+	// Removed members lose the policies of the group.
+	if err = sys.RemoveUsersFromGroup("writers", []string{"alice"}); err != nil {
+		t.Fatal(err)
+	}
+	testCases[1].expected = false
+	check(testCases)
+
+	// Only empty groups can be removed.
+	if err = sys.RemoveUsersFromGroup("writers", nil); err != errGroupNotEmpty {
+		t.Fatalf("Expected %v, got %v", errGroupNotEmpty, err)
+	}
+	if err = sys.DeleteUser("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.RemoveUsersFromGroup("writers", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.refresh(objLayer); err != nil {
+		t.Fatal(err)
+	}
+	if groups, _ := sys.ListGroups(); len(groups) != 0 {
+		t.Fatalf("Expected no groups, got %v", groups)
+	}
+}
+
+// Test canned policies attached to users, their inline policies and the
+// migration of user policy files holding a single policy name.
+func TestIAMSysUserPolicies(t *testing.T) {
+	initNSLock(false)
+	objLayer, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+	if err = newTestConfig(globalMinioDefaultRegion, objLayer); err != nil {
+		t.Fatal(err)
+	}
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = objLayer
+	globalObjLayerMutex.Unlock()
+	defer resetGlobalObjectAPI()
+
+	sys := NewIAMSys()
+	if err = sys.Init(objLayer); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.SetUser("alice", madmin.UserInfo{SecretKey: "secretkey", Status: madmin.AccountEnabled}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Policy files of earlier versions are migrated at refresh.
+	pFile := pathJoin(iamConfigUsersPrefix, "alice", iamPolicyFile)
+	if err = saveConfig(context.Background(), objLayer, pFile, []byte(`"readonly"`)); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.refresh(objLayer); err != nil {
+		t.Fatal(err)
+	}
+	data, err := readConfig(context.Background(), objLayer, pFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, migrated, perr := parseUserPolicyInfo(data); perr != nil || migrated ||
+		!reflect.DeepEqual(info.Policies, []string{"readonly"}) {
+		t.Fatalf("Unexpected policy file %s", data)
+	}
+
+	if err = sys.AttachUserPolicy("alice", "writeonly"); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.AttachUserPolicy("alice", "missing"); err != errNoSuchPolicy {
+		t.Fatalf("Expected %v, got %v", errNoSuchPolicy, err)
+	}
+	denyDelete, err := iampolicy.ParseConfig(strings.NewReader(`{"Version":"2012-10-17","Statement":[{"Effect":"Deny","Action":["s3:DeleteObject"],"Resource":["arn:aws:s3:::*"]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.SetUserInlinePolicy("alice", denyDelete); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.refresh(objLayer); err != nil {
+		t.Fatal(err)
+	}
+	policies, err := sys.GetUserPolicies("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(policies.Policies, []string{"readonly", "writeonly"}) || len(policies.InlinePolicy) == 0 {
+		t.Fatalf("Unexpected user policies %#v", policies)
+	}
+
+	isAllowed := func(action iampolicy.Action) bool {
+		return sys.IsAllowed(iampolicy.Args{
+			AccountName: "alice",
+			Action:      action,
+			BucketName:  "bucket",
+			ObjectName:  "object",
+		})
+	}
+	// The attached policies are combined, the explicit deny of the
+	// inline policy overrides the allow of writeonly.
+	if !isAllowed(iampolicy.GetObjectAction) || !isAllowed(iampolicy.PutObjectAction) {
+		t.Fatal("Expected get and put to be allowed")
+	}
+	if isAllowed(iampolicy.DeleteObjectAction) {
+		t.Fatal("Expected delete to be denied by the inline policy")
+	}
+
+	if err = sys.DetachUserPolicy("alice", "readonly"); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.DetachUserPolicy("alice", "readonly"); err != errNoSuchPolicy {
+		t.Fatalf("Expected %v, got %v", errNoSuchPolicy, err)
+	}
+	if isAllowed(iampolicy.GetObjectAction) {
+		t.Fatal("Expected get to be denied once readonly is detached")
+	}
+
+	// The policy file is removed along with the last policy.
+	if err = sys.DetachUserPolicy("alice", "writeonly"); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.SetUserInlinePolicy("alice", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = readConfig(context.Background(), objLayer, pFile); err != errConfigNotFound {
+		t.Fatalf("Expected %v, got %v", errConfigNotFound, err)
+	}
+}
+
+func TestIAMSysServiceAccounts(t *testing.T) {
+	initNSLock(false)
+	objLayer, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+	if err = newTestConfig(globalMinioDefaultRegion, objLayer); err != nil {
+		t.Fatal(err)
+	}
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = objLayer
+	globalObjLayerMutex.Unlock()
+	defer resetGlobalObjectAPI()
+
+	sys := NewIAMSys()
+	if err = sys.Init(objLayer); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.SetUser("cibot", madmin.UserInfo{SecretKey: "secretkey", Status: madmin.AccountEnabled}); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.SetUserPolicy("cibot", "readwrite"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = sys.NewServiceAccount("nobody", nil); err != errNoSuchUser {
+		t.Fatalf("Expected %v, got %v", errNoSuchUser, err)
+	}
+	unrestricted, err := sys.NewServiceAccount("cibot", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readOnly := iampolicy.ReadOnly
+	restricted, err := sys.NewServiceAccount("cibot", &readOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = sys.NewServiceAccount(restricted.AccessKey, nil); err != errNoSuchUser {
+		t.Fatalf("Expected %v, got %v", errNoSuchUser, err)
+	}
+
+	// Service accounts cannot be managed as users.
+	if err = sys.SetUserPolicy(restricted.AccessKey, "readwrite"); err != errNoSuchUser {
+		t.Fatalf("Expected %v, got %v", errNoSuchUser, err)
+	}
+	if err = sys.AddUsersToGroup("builders", []string{restricted.AccessKey}); err != errNoSuchUser {
+		t.Fatalf("Expected %v, got %v", errNoSuchUser, err)
+	}
+	if err = sys.SetUser(restricted.AccessKey, madmin.UserInfo{SecretKey: "secretkey"}); err != errInvalidArgument {
+		t.Fatalf("Expected %v, got %v", errInvalidArgument, err)
+	}
+	users, err := sys.ListUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 {
+		t.Fatalf("Expected only the parent user, got %v", users)
+	}
+
+	// Service accounts are reloaded from the object layer.
+	if err = sys.refresh(objLayer); err != nil {
+		t.Fatal(err)
+	}
+	serviceAccounts, err := sys.ListServiceAccounts("cibot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(serviceAccounts) != 2 {
+		t.Fatalf("Expected 2 service accounts, got %v", serviceAccounts)
+	}
+	for _, sa := range serviceAccounts {
+		if sa.ParentUser != "cibot" || (sa.AccessKey == restricted.AccessKey) != (sa.Policy != nil) {
+			t.Fatalf("Unexpected service account %#v", sa)
+		}
+	}
+	if cred, ok := sys.GetUser(restricted.AccessKey); !ok || !cred.Equal(restricted) {
+		t.Fatalf("Unexpected credentials %#v for %v", cred, restricted.AccessKey)
+	}
+
+	isAllowed := func(accessKey string, action iampolicy.Action) bool {
+		return sys.IsAllowed(iampolicy.Args{
+			AccountName: accessKey,
+			Action:      action,
+			BucketName:  "artifacts",
+			ObjectName:  "build.tar.gz",
+		})
+	}
+	testCases := []struct {
+		accessKey string
+		action    iampolicy.Action
+		expected  bool
+	}{
+		{unrestricted.AccessKey, iampolicy.GetObjectAction, true},
+		{unrestricted.AccessKey, iampolicy.PutObjectAction, true},
+		// The policy of the service account narrows the parent's permissions.
+		{restricted.AccessKey, iampolicy.GetObjectAction, true},
+		{restricted.AccessKey, iampolicy.PutObjectAction, false},
+	}
+	for i, testCase := range testCases {
+		if allowed := isAllowed(testCase.accessKey, testCase.action); allowed != testCase.expected {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expected, allowed)
+		}
+	}
+
+	// The policy of the service account cannot widen the parent's permissions.
+	if err = sys.SetUserPolicy("cibot", "writeonly"); err != nil {
+		t.Fatal(err)
+	}
+	if isAllowed(restricted.AccessKey, iampolicy.GetObjectAction) {
+		t.Fatalf("Expected service account to be denied beyond its parent's permissions")
+	}
+
+	// Service accounts of disabled users are denied.
+	if err = sys.SetUserStatus("cibot", madmin.AccountDisabled); err != nil {
+		t.Fatal(err)
+	}
+	if isAllowed(unrestricted.AccessKey, iampolicy.PutObjectAction) {
+		t.Fatalf("Expected service account of disabled user to be denied")
+	}
+	if err = sys.SetUserStatus("cibot", madmin.AccountEnabled); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rotation replaces the secret key only.
+	rotated, err := sys.RotateServiceAccount(unrestricted.AccessKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.AccessKey != unrestricted.AccessKey || rotated.SecretKey == unrestricted.SecretKey || rotated.ParentUser != "cibot" {
+		t.Fatalf("Unexpected rotated credentials %#v", rotated)
+	}
+	if _, err = sys.RotateServiceAccount("cibot"); err != errNoSuchServiceAccount {
+		t.Fatalf("Expected %v, got %v", errNoSuchServiceAccount, err)
+	}
+
+	// Revoked service accounts are removed, deleting the parent user
+	// removes its remaining service accounts.
+	if err = sys.DeleteServiceAccount(restricted.AccessKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sys.GetUser(restricted.AccessKey); ok {
+		t.Fatalf("Expected service account %v to be revoked", restricted.AccessKey)
+	}
+	if err = sys.DeleteUser(unrestricted.AccessKey); err != errNoSuchUser {
+		t.Fatalf("Expected %v, got %v", errNoSuchUser, err)
+	}
+	if err = sys.DeleteUser("cibot"); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.refresh(objLayer); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sys.GetUser(unrestricted.AccessKey); ok {
+		t.Fatalf("Expected service account %v to be removed with its parent", unrestricted.AccessKey)
+	}
+}
+
+// Test expiration of user access keys and rotation of their secret keys.
+func TestIAMSysUserExpirationAndRotation(t *testing.T) {
+	initNSLock(false)
+	objLayer, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+	if err = newTestConfig(globalMinioDefaultRegion, objLayer); err != nil {
+		t.Fatal(err)
+	}
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = objLayer
+	globalObjLayerMutex.Unlock()
+	defer resetGlobalObjectAPI()
+
+	sys := NewIAMSys()
+	if err = sys.Init(objLayer); err != nil {
+		t.Fatal(err)
+	}
+
+	past, future := UTCNow().Add(-time.Hour), UTCNow().Add(time.Hour)
+	if err = sys.SetUser("contractor", madmin.UserInfo{SecretKey: "secretkey", Status: madmin.AccountEnabled, Expiration: &past}); err != errInvalidArgument {
+		t.Fatalf("Expected %v, got %v", errInvalidArgument, err)
+	}
+	if err = sys.SetUser("contractor", madmin.UserInfo{SecretKey: "secretkey", Status: madmin.AccountEnabled, Expiration: &future}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sys.GetUser("contractor"); !ok {
+		t.Fatal("Expected user to be valid before its expiration")
+	}
+
+	// The expiration survives status changes and reloads.
+	if err = sys.SetUserStatus("contractor", madmin.AccountDisabled); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.SetUserStatus("contractor", madmin.AccountEnabled); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.refresh(objLayer); err != nil {
+		t.Fatal(err)
+	}
+	users, err := sys.ListUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expiration := users["contractor"].Expiration; expiration == nil || !expiration.Equal(future) {
+		t.Fatalf("Expected expiration %v, got %v", future, expiration)
+	}
+	if users["contractor"].LastUsed != nil {
+		t.Fatalf("Expected no last use, got %v", users["contractor"].LastUsed)
+	}
+
+	// Expired users are denied but kept.
+	cred, _ := sys.GetUser("contractor")
+	cred.Expiration = past
+	if err = saveIAMConfig(objLayer, pathJoin(iamConfigUsersPrefix, "contractor", iamIdentityFile), cred); err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.refresh(objLayer); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sys.GetUser("contractor"); ok {
+		t.Fatal("Expected expired user to be denied")
+	}
+	if users, err = sys.ListUsers(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := users["contractor"]; !ok {
+		t.Fatal("Expected expired user to be listed")
+	}
+
+	if err = sys.SetUser("rotated", madmin.UserInfo{SecretKey: "secretkey", Status: madmin.AccountEnabled}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = sys.RotateUserSecret("rotated", maxSecretKeyGracePeriod+time.Hour); err != errInvalidArgument {
+		t.Fatalf("Expected %v, got %v", errInvalidArgument, err)
+	}
+	if _, err = sys.RotateUserSecret("nobody", time.Hour); err != errNoSuchUser {
+		t.Fatalf("Expected %v, got %v", errNoSuchUser, err)
+	}
+	serviceAccount, err := sys.NewServiceAccount("rotated", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = sys.RotateUserSecret(serviceAccount.AccessKey, time.Hour); err != errNoSuchUser {
+		t.Fatalf("Expected %v, got %v", errNoSuchUser, err)
+	}
+
+	rotated, err := sys.RotateUserSecret("rotated", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sys.refresh(objLayer); err != nil {
+		t.Fatal(err)
+	}
+	cred, ok := sys.GetUser("rotated")
+	if !ok {
+		t.Fatal("Expected rotated user to be valid")
+	}
+	if expected := []string{rotated.SecretKey, "secretkey"}; !reflect.DeepEqual(cred.SecretKeys(), expected) {
+		t.Fatalf("Expected secret keys %v, got %v", expected, cred.SecretKeys())
+	}
+
+	// Rotating again ends the grace period of the first secret key.
+	if _, err = sys.RotateUserSecret("rotated", 0); err != nil {
+		t.Fatal(err)
+	}
+	if cred, _ = sys.GetUser("rotated"); len(cred.SecretKeys()) != 1 || cred.SecretKey == rotated.SecretKey {
+		t.Fatalf("Expected a single new secret key, got %v", cred.SecretKeys())
+	}
+}
+
+// Test that signatures made with the previous secret key of a user are
+// accepted during the grace period, and that uses are tracked.
+func TestMatchCredSecretKey(t *testing.T) {
+	sys := NewIAMSys()
+	savedIAMSys := globalIAMSys
+	globalIAMSys = sys
+	defer func() { globalIAMSys = savedIAMSys }()
+
+	cred := auth.Credentials{
+		AccessKey: "rotated",
+		SecretKey: "newsecretkey",
+		PrevSecretKey: &auth.PrevSecretKey{
+			SecretKey:  "oldsecretkey",
+			Expiration: UTCNow().Add(time.Hour),
+		},
+	}
+	expired := cred
+	expired.PrevSecretKey = &auth.PrevSecretKey{
+		SecretKey:  "oldsecretkey",
+		Expiration: UTCNow().Add(-time.Hour),
+	}
+
+	testCases := []struct {
+		cred          auth.Credentials
+		secretKey     string
+		expectedMatch bool
+	}{
+		{cred, "newsecretkey", true},
+		{cred, "oldsecretkey", true},
+		{cred, "badsecretkey", false},
+		{expired, "newsecretkey", true},
+		{expired, "oldsecretkey", false},
+	}
+
+	for i, testCase := range testCases {
+		sys.iamUsersMap[testCase.cred.AccessKey] = testCase.cred
+		sys.lastUsedMap = make(map[string]time.Time)
+		matched, ok := matchCredSecretKey(testCase.cred, func(cred auth.Credentials) bool {
+			return cred.SecretKey == testCase.secretKey
+		})
+		if ok != testCase.expectedMatch {
+			t.Fatalf("Test %d: Expected %v, got %v", i+1, testCase.expectedMatch, ok)
+		}
+		if ok && matched.SecretKey != testCase.secretKey {
+			t.Fatalf("Test %d: Expected secret key %s, got %s", i+1, testCase.secretKey, matched.SecretKey)
+		}
+		if _, used := sys.lastUsed(testCase.cred.AccessKey); used != testCase.expectedMatch {
+			t.Fatalf("Test %d: Expected last use recorded %v, got %v", i+1, testCase.expectedMatch, used)
+		}
+	}
+}
+
+// Test that the last uses of users and service accounts are saved, and
+// that the last uses of other credentials are not tracked.
+func TestIAMSysLastUsed(t *testing.T) {
+	initNSLock(false)
+	objLayer, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+	if err = newTestConfig(globalMinioDefaultRegion, objLayer); err != nil {
+		t.Fatal(err)
+	}
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = objLayer
+	globalObjLayerMutex.Unlock()
+	defer resetGlobalObjectAPI()
+
+	sys := NewIAMSys()
+	if err = sys.Init(objLayer); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = sys.SetUser("tracked", madmin.UserInfo{SecretKey: "secretkey", Status: madmin.AccountEnabled}); err != nil {
+		t.Fatal(err)
+	}
+	serviceAccount, err := sys.NewServiceAccount("tracked", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sys.Lock()
+	sys.iamUsersMap["temp"] = auth.Credentials{AccessKey: "temp", SecretKey: "secretkey", SessionToken: "token"}
+	sys.Unlock()
+
+	testCases := []struct {
+		accessKey string
+		tracked   bool
+	}{
+		{"tracked", true},
+		{serviceAccount.AccessKey, true},
+		{"temp", false},
+		{"unknown", false},
+	}
+	for i, testCase := range testCases {
+		sys.UpdateLastUsed(testCase.accessKey)
+		if _, tracked := sys.lastUsed(testCase.accessKey); tracked != testCase.tracked {
+			t.Fatalf("Test %d: Expected last use tracked %v, got %v", i+1, testCase.tracked, tracked)
+		}
+	}
+
+	// The last uses are saved in the background.
+	files := []string{
+		pathJoin(iamConfigUsersPrefix, "tracked", iamLastUsedFile),
+		pathJoin(iamConfigServiceAccountsPrefix, serviceAccount.AccessKey, iamLastUsedFile),
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for _, file := range files {
+		for {
+			if _, err = readConfig(context.Background(), objLayer, file); err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected %s to be saved, got %v", file, err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// Uses right after a save are only tracked in memory.
+	lastUsed, _ := sys.lastUsed("tracked")
+	sys.UpdateLastUsed("tracked")
+	if newLastUsed, _ := sys.lastUsed("tracked"); newLastUsed.Before(lastUsed) {
+		t.Fatalf("Expected last use after %v, got %v", lastUsed, newLastUsed)
+	}
+	sys.lastUsedMu.Lock()
+	lastSave := sys.lastUsedSaveMap["tracked"]
+	sys.lastUsedMu.Unlock()
+	if !lastSave.Equal(lastUsed) {
+		t.Fatalf("Expected last save %v, got %v", lastUsed, lastSave)
+	}
+
+	// Saved last uses are loaded by other nodes.
+	other := NewIAMSys()
+	if err = other.refresh(objLayer); err != nil {
+		t.Fatal(err)
+	}
+	if saved, ok := other.lastUsed("tracked"); !ok || !saved.Equal(lastUsed) {
+		t.Fatalf("Expected saved last use %v, got %v", lastUsed, saved)
+	}
+	users, err := other.ListUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved := users["tracked"].LastUsed; saved == nil || !saved.Equal(lastUsed) {
+		t.Fatalf("Expected listed last use %v, got %v", lastUsed, saved)
+	}
+
+	// Deleting the user forgets the last uses of the user and of its
+	// service accounts.
+	if err = sys.DeleteUser("tracked"); err != nil {
+		t.Fatal(err)
+	}
+	for i, testCase := range testCases {
+		if _, tracked := sys.lastUsed(testCase.accessKey); tracked {
+			t.Fatalf("Test %d: Expected last use of %s to be forgotten", i+1, testCase.accessKey)
+		}
+	}
+	for _, file := range files {
+		if _, err = readConfig(context.Background(), objLayer, file); err != errConfigNotFound {
+			t.Fatalf("Expected %s to be removed, got %v", file, err)
+		}
+	}
+}
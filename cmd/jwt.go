@@ -64,7 +64,9 @@ func authenticateJWTUsers(accessKey, secretKey string, expiry time.Duration) (st
 		}
 	}
 
-	if !serverCred.Equal(passedCredential) {
+	// Tokens are always signed with the current secret key, even when
+	// the user logs in with its previous one.
+	if _, ok := matchCredSecretKey(serverCred, passedCredential.Equal); !ok {
 		return "", errAuthentication
 	}
 
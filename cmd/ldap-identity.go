@@ -0,0 +1,189 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	ldap "gopkg.in/ldap.v3"
+)
+
+const (
+	// Default duration of the temporary credentials of LDAP users.
+	defaultLDAPExpiry = time.Hour
+
+	// Default attribute of the LDAP groups holding their name.
+	defaultLDAPGroupNameAttribute = "cn"
+)
+
+// ldapConfig - LDAP/Active Directory server users are authenticated against
+// by AssumeRoleWithLDAPIdentity.
+type ldapConfig struct {
+	// host:port of the LDAP server.
+	ServerAddr string
+
+	// Connect without TLS, for testing purposes only.
+	ServerInsecure bool
+
+	// Do not verify the TLS certificate of the LDAP server.
+	TLSSkipVerify bool
+
+	// Format of the DN users bind with, "%s" is replaced by
+	// the username, e.g. "uid=%s,cn=accounts,dc=example,dc=com".
+	UsernameFormat string
+
+	// Search of the groups of users, "%s" is replaced by the
+	// username and "%d" by the DN of the user in the filter,
+	// e.g. "(&(objectclass=groupOfNames)(member=%d))".
+	GroupSearchBaseDN  string
+	GroupSearchFilter  string
+	GroupNameAttribute string
+
+	// Default duration of the temporary credentials.
+	STSExpiry time.Duration
+}
+
+// newLDAPConfigFromEnv - reads the LDAP configuration from the environment,
+// nil is returned if MINIO_IDENTITY_LDAP_SERVER_ADDR is not set.
+func newLDAPConfigFromEnv() (*ldapConfig, error) {
+	serverAddr := os.Getenv("MINIO_IDENTITY_LDAP_SERVER_ADDR")
+	if serverAddr == "" {
+		return nil, nil
+	}
+
+	config := &ldapConfig{
+		ServerAddr:         serverAddr,
+		UsernameFormat:     os.Getenv("MINIO_IDENTITY_LDAP_USERNAME_FORMAT"),
+		GroupSearchBaseDN:  os.Getenv("MINIO_IDENTITY_LDAP_GROUP_SEARCH_BASE_DN"),
+		GroupSearchFilter:  os.Getenv("MINIO_IDENTITY_LDAP_GROUP_SEARCH_FILTER"),
+		GroupNameAttribute: os.Getenv("MINIO_IDENTITY_LDAP_GROUP_NAME_ATTRIBUTE"),
+		STSExpiry:          defaultLDAPExpiry,
+	}
+	if config.UsernameFormat == "" {
+		return nil, errors.New("MINIO_IDENTITY_LDAP_USERNAME_FORMAT is required with MINIO_IDENTITY_LDAP_SERVER_ADDR")
+	}
+	if config.GroupNameAttribute == "" {
+		config.GroupNameAttribute = defaultLDAPGroupNameAttribute
+	}
+
+	for env, flag := range map[string]*bool{
+		"MINIO_IDENTITY_LDAP_SERVER_INSECURE": &config.ServerInsecure,
+		"MINIO_IDENTITY_LDAP_TLS_SKIP_VERIFY": &config.TLSSkipVerify,
+	} {
+		if v := os.Getenv(env); v != "" {
+			b, err := ParseBoolFlag(v)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid %s value `%s`: %v", env, v, err)
+			}
+			*flag = bool(b)
+		}
+	}
+
+	if v := os.Getenv("MINIO_IDENTITY_LDAP_STS_EXPIRY"); v != "" {
+		expiry, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid MINIO_IDENTITY_LDAP_STS_EXPIRY value `%s`: %v", v, err)
+		}
+		config.STSExpiry = expiry
+	}
+
+	return config, nil
+}
+
+// connect - opens a connection to the LDAP server.
+func (l *ldapConfig) connect() (*ldap.Conn, error) {
+	if l.ServerInsecure {
+		return ldap.Dial("tcp", l.ServerAddr)
+	}
+	return ldap.DialTLS("tcp", l.ServerAddr, &tls.Config{
+		InsecureSkipVerify: l.TLSSkipVerify,
+		RootCAs:            globalRootCAs,
+	})
+}
+
+// lookupGroups - binds as username with password and returns the names of
+// the groups the user is a member of. errAuthentication is returned when
+// the credentials are rejected by the server.
+func (l *ldapConfig) lookupGroups(username, password string) (userDN string, groups []string, err error) {
+	// Unauthenticated binds always succeed.
+	if username == "" || password == "" {
+		return "", nil, errAuthentication
+	}
+
+	conn, err := l.connect()
+	if err != nil {
+		return "", nil, err
+	}
+	defer conn.Close()
+
+	userDN = fmt.Sprintf(l.UsernameFormat, ldapEscapeDN(username))
+	if err = conn.Bind(userDN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return "", nil, errAuthentication
+		}
+		return "", nil, err
+	}
+
+	if l.GroupSearchFilter == "" {
+		return userDN, nil, nil
+	}
+
+	filter := strings.NewReplacer(
+		"%s", ldap.EscapeFilter(username),
+		"%d", ldap.EscapeFilter(userDN),
+	).Replace(l.GroupSearchFilter)
+	searchRequest := ldap.NewSearchRequest(
+		l.GroupSearchBaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+		filter,
+		[]string{l.GroupNameAttribute},
+		nil,
+	)
+	result, err := conn.Search(searchRequest)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, entry := range result.Entries {
+		groups = append(groups, entry.GetAttributeValues(l.GroupNameAttribute)...)
+	}
+	return userDN, groups, nil
+}
+
+// ldapEscapeDN - escapes the special characters of an attribute value of a
+// DN, as described in RFC 4514.
+func ldapEscapeDN(value string) string {
+	var b strings.Builder
+	for i, c := range value {
+		switch {
+		case strings.ContainsRune(`,+"\<>;=`, c),
+			i == 0 && (c == ' ' || c == '#'),
+			i == len(value)-1 && c == ' ':
+			b.WriteRune('\\')
+			b.WriteRune(c)
+		case c == 0:
+			b.WriteString(`\00`)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
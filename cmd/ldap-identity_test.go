@@ -0,0 +1,345 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/pydio/minio-srv/pkg/iam/policy"
+	ber "gopkg.in/asn1-ber.v1"
+	ldap "gopkg.in/ldap.v3"
+)
+
+// testLDAPEntry - entry of the directory of testLDAPServer.
+type testLDAPEntry struct {
+	dn    string
+	attrs map[string][]string
+}
+
+// testLDAPServer - in-memory LDAP server supporting simple binds and
+// searches with and, or, equality and presence filters.
+type testLDAPServer struct {
+	listener  net.Listener
+	passwords map[string]string
+	entries   []testLDAPEntry
+}
+
+func newTestLDAPServer(t *testing.T, passwords map[string]string, entries []testLDAPEntry) *testLDAPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testLDAPServer{listener: listener, passwords: passwords, entries: entries}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *testLDAPServer) Addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *testLDAPServer) Close() {
+	s.listener.Close()
+}
+
+func (s *testLDAPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		messageID := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			resultCode := int64(ldap.LDAPResultInvalidCredentials)
+			dn, password := op.Children[1].Data.String(), op.Children[2].Data.String()
+			if p, ok := s.passwords[dn]; ok && p == password {
+				resultCode = ldap.LDAPResultSuccess
+			}
+			conn.Write(testLDAPResult(messageID, ldap.ApplicationBindResponse, resultCode).Bytes())
+		case ldap.ApplicationSearchRequest:
+			baseDN, filter := op.Children[0].Data.String(), op.Children[6]
+			for _, entry := range s.entries {
+				if !strings.HasSuffix(entry.dn, baseDN) || !testLDAPMatch(filter, entry) {
+					continue
+				}
+				attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+				for name, values := range entry.attrs {
+					attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+					attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+					set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+					for _, value := range values {
+						set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+					}
+					attr.AppendChild(set)
+					attrs.AppendChild(attr)
+				}
+				conn.Write(testLDAPResponse(messageID, ldap.ApplicationSearchResultEntry,
+					ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, "DN"),
+					attrs).Bytes())
+			}
+			conn.Write(testLDAPResult(messageID, ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess).Bytes())
+		default:
+			return
+		}
+	}
+}
+
+func testLDAPResponse(messageID int64, tag ber.Tag, children ...*ber.Packet) *ber.Packet {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Response")
+	for _, child := range children {
+		op.AppendChild(child)
+	}
+	packet.AppendChild(op)
+	return packet
+}
+
+func testLDAPResult(messageID int64, tag ber.Tag, resultCode int64) *ber.Packet {
+	return testLDAPResponse(messageID, tag,
+		ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, resultCode, "ResultCode"),
+		ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "MatchedDN"),
+		ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic"))
+}
+
+func testLDAPMatch(filter *ber.Packet, entry testLDAPEntry) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !testLDAPMatch(child, entry) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			if testLDAPMatch(child, entry) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterEqualityMatch:
+		name, value := filter.Children[0].Data.String(), filter.Children[1].Data.String()
+		for attr, values := range entry.attrs {
+			if strings.EqualFold(attr, name) {
+				for _, v := range values {
+					if strings.EqualFold(v, value) {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	case ldap.FilterPresent:
+		for attr := range entry.attrs {
+			if strings.EqualFold(attr, filter.Data.String()) {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
+
+func newTestLDAPDirectory(t *testing.T) *testLDAPServer {
+	return newTestLDAPServer(t,
+		map[string]string{
+			"uid=alice,ou=people,dc=example,dc=com": "alicepass",
+			"uid=bob,ou=people,dc=example,dc=com":   "bobpass",
+		},
+		[]testLDAPEntry{
+			{"cn=readwrite,ou=groups,dc=example,dc=com", map[string][]string{
+				"objectclass": {"groupOfNames"},
+				"cn":          {"readwrite"},
+				"member":      {"uid=alice,ou=people,dc=example,dc=com"},
+			}},
+			{"cn=engineers,ou=groups,dc=example,dc=com", map[string][]string{
+				"objectclass": {"groupOfNames"},
+				"cn":          {"engineers"},
+				"member":      {"uid=alice,ou=people,dc=example,dc=com", "uid=bob,ou=people,dc=example,dc=com"},
+			}},
+		})
+}
+
+func newTestLDAPConfig(addr string) *ldapConfig {
+	return &ldapConfig{
+		ServerAddr:         addr,
+		ServerInsecure:     true,
+		UsernameFormat:     "uid=%s,ou=people,dc=example,dc=com",
+		GroupSearchBaseDN:  "ou=groups,dc=example,dc=com",
+		GroupSearchFilter:  "(&(objectclass=groupOfNames)(member=%d))",
+		GroupNameAttribute: "cn",
+		STSExpiry:          defaultLDAPExpiry,
+	}
+}
+
+func TestLDAPLookupGroups(t *testing.T) {
+	server := newTestLDAPDirectory(t)
+	defer server.Close()
+	config := newTestLDAPConfig(server.Addr())
+
+	testCases := []struct {
+		username, password string
+		expectedDN         string
+		expectedGroups     []string
+		expectedErr        error
+	}{
+		{"alice", "alicepass", "uid=alice,ou=people,dc=example,dc=com", []string{"engineers", "readwrite"}, nil},
+		{"bob", "bobpass", "uid=bob,ou=people,dc=example,dc=com", []string{"engineers"}, nil},
+		{"alice", "bobpass", "", nil, errAuthentication},
+		{"alice", "", "", nil, errAuthentication},
+		{"carol", "carolpass", "", nil, errAuthentication},
+	}
+	for i, testCase := range testCases {
+		dn, groups, err := config.lookupGroups(testCase.username, testCase.password)
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+			continue
+		}
+		sort.Strings(groups)
+		if dn != testCase.expectedDN || !reflect.DeepEqual(groups, testCase.expectedGroups) {
+			t.Errorf("Test %d: Expected %s %v, got %s %v", i+1, testCase.expectedDN, testCase.expectedGroups, dn, groups)
+		}
+	}
+}
+
+func TestLDAPEscapeDN(t *testing.T) {
+	testCases := []struct {
+		value, expected string
+	}{
+		{"alice", "alice"},
+		{"smith, john", `smith\, john`},
+		{"a=b+c", `a\=b\+c`},
+		{"#admin ", `\#admin\ `},
+		{" admin", `\ admin`},
+	}
+	for i, testCase := range testCases {
+		if escaped := ldapEscapeDN(testCase.value); escaped != testCase.expected {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.expected, escaped)
+		}
+	}
+}
+
+func TestAssumeRoleWithLDAPIdentity(t *testing.T) {
+	server := newTestLDAPDirectory(t)
+	defer server.Close()
+
+	initNSLock(false)
+	objLayer, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(fsDir)
+	if err = newTestConfig(globalMinioDefaultRegion, objLayer); err != nil {
+		t.Fatal(err)
+	}
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = objLayer
+	globalObjLayerMutex.Unlock()
+	globalIAMSys = NewIAMSys()
+	if err = globalIAMSys.Init(objLayer); err != nil {
+		t.Fatal(err)
+	}
+	globalLDAPConfig = newTestLDAPConfig(server.Addr())
+	defer func() {
+		globalLDAPConfig = nil
+		resetGlobalIAMSys()
+		resetGlobalObjectAPI()
+	}()
+
+	router := mux.NewRouter()
+	registerSTSRouter(router)
+
+	testCases := []struct {
+		username, password string
+		expectedStatus     int
+		expectedPolicies   []string
+	}{
+		{"alice", "alicepass", http.StatusOK, []string{"readwrite"}},
+		// No group of bob is mapped to a canned policy.
+		{"bob", "bobpass", http.StatusForbidden, nil},
+		{"alice", "wrong", http.StatusForbidden, nil},
+		{"alice", "", http.StatusBadRequest, nil},
+	}
+	for i, testCase := range testCases {
+		form := url.Values{
+			"Action":       {"AssumeRoleWithLDAPIdentity"},
+			"Version":      {stsAPIVersion},
+			"LDAPUsername": {testCase.username},
+			"LDAPPassword": {testCase.password},
+		}
+		req := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedStatus {
+			t.Errorf("Test %d: Expected status %d, got %d: %s", i+1, testCase.expectedStatus, rec.Code, rec.Body.String())
+			continue
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+
+		var resp AssumeRoleWithLDAPIdentityResponse
+		if err = xml.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		cred := resp.Result.Credentials
+		if _, ok := globalIAMSys.GetUser(cred.AccessKey); !ok {
+			t.Fatalf("Test %d: Expected temporary credentials to be valid", i+1)
+		}
+
+		// The policies of the groups are enforced on requests
+		// signed with the temporary credentials.
+		claimsReq := httptest.NewRequest("GET", "/bucket/object", nil)
+		claimsReq.Header.Set("X-Amz-Security-Token", cred.SessionToken)
+		claims, s3Err := getClaimsFromToken(claimsReq, cred)
+		if s3Err != ErrNone {
+			t.Fatalf("Test %d: Unexpected error %v", i+1, s3Err)
+		}
+		if !globalIAMSys.IsAllowed(iampolicy.Args{
+			AccountName: cred.AccessKey,
+			Action:      iampolicy.PutObjectAction,
+			BucketName:  "bucket",
+			ObjectName:  "object",
+			Claims:      claims,
+		}) {
+			t.Errorf("Test %d: Expected %v to allow PutObject", i+1, testCase.expectedPolicies)
+		}
+	}
+}
@@ -22,8 +22,11 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"io/ioutil"
+
+	"github.com/pydio/minio-srv/pkg/madmin"
 )
 
 // localAdminClient - represents admin operation to be executed locally.
@@ -62,8 +65,14 @@ func (lc localAdminClient) ServerInfo() (sid ServerInfoData, e error) {
 	}
 	storage := objLayer.StorageInfo(context.Background())
 
+	var healing []madmin.HealingDisk
+	if healer, ok := objLayer.(backgroundHealer); ok {
+		healing = healer.BackgroundHealStatus()
+	}
+
 	return ServerInfoData{
 		StorageInfo: storage,
+		Healing:     healing,
 		ConnStats:   globalConnStats.toServerConnStats(),
 		HTTPStats:   globalHTTPStats.toServerHTTPStats(),
 		Properties: ServerProperties{
@@ -121,3 +130,19 @@ func (lc localAdminClient) DownloadProfilingData() ([]byte, error) {
 
 	return data, nil
 }
+
+// ListLocks - lists the namespace locks of the local server.
+func (lc localAdminClient) ListLocks(bucket, prefix string, olderThan time.Duration) ([]madmin.VolumeLockInfo, error) {
+	if globalNSMutex == nil {
+		return nil, errServerNotInitialized
+	}
+	return globalNSMutex.listLocks(bucket, prefix, olderThan), nil
+}
+
+// ClearLocks - forcefully unlocks the namespace locks of the local server.
+func (lc localAdminClient) ClearLocks(bucket, prefix string, olderThan time.Duration) ([]madmin.VolumeLockInfo, error) {
+	if globalNSMutex == nil {
+		return nil, errServerNotInitialized
+	}
+	return globalNSMutex.clearLocks(bucket, prefix, olderThan), nil
+}
@@ -35,3 +35,7 @@ func TestLocalAdminClientServerInfo(t *testing.T) {
 func TestLocalAdminClientGetConfig(t *testing.T) {
 	testAdminCmdRunnerGetConfig(t, &localAdminClient{})
 }
+
+func TestLocalAdminClientLocks(t *testing.T) {
+	testAdminCmdRunnerLocks(t, &localAdminClient{})
+}
@@ -0,0 +1,178 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pydio/minio-srv/pkg/madmin"
+)
+
+// nsLockOwner - an operation holding or waiting for a namespace lock.
+type nsLockOwner struct {
+	opsID    string
+	source   string
+	readLock bool
+	// Whether the lock is held, or waited for otherwise.
+	held bool
+	// Time the lock was granted if held, requested otherwise.
+	since time.Time
+}
+
+// ownerIndex - returns the index of owner in the owners of the lock,
+// -1 if not found.
+func (nsLk *nsLock) ownerIndex(owner *nsLockOwner) int {
+	for i, o := range nsLk.owners {
+		if o == owner {
+			return i
+		}
+	}
+	return -1
+}
+
+// heldOwnerIndex - returns the index of the first owner of operation
+// opsID holding the lock, -1 if not found.
+func (nsLk *nsLock) heldOwnerIndex(opsID string, readLock bool) int {
+	for i, o := range nsLk.owners {
+		if o.held && o.opsID == opsID && o.readLock == readLock {
+			return i
+		}
+	}
+	return -1
+}
+
+// removeOwner - removes the owner at index i.
+func (nsLk *nsLock) removeOwner(i int) {
+	nsLk.owners = append(nsLk.owners[:i], nsLk.owners[i+1:]...)
+}
+
+// lockState - returns the admin representation of the owner state.
+func (o *nsLockOwner) lockState(now time.Time) madmin.OpsLockState {
+	state := madmin.OpsLockState{
+		OperationID: o.opsID,
+		LockSource:  o.source,
+		LockType:    madmin.WriteLock,
+		Status:      madmin.LockWaiting,
+		Since:       o.since,
+		Duration:    now.Sub(o.since),
+	}
+	if o.readLock {
+		state.LockType = madmin.ReadLock
+	}
+	if o.held {
+		state.Status = madmin.LockHeld
+	}
+	return state
+}
+
+// matches - returns whether the lock of param is on an object under
+// bucket/prefix, an empty bucket matches all the buckets.
+func (param nsParam) matches(bucket, prefix string) bool {
+	return (bucket == "" || param.volume == bucket) && strings.HasPrefix(param.path, prefix)
+}
+
+// oldOwners - returns the state of the owners of the lock holding or
+// waiting for it for longer than olderThan.
+func (nsLk *nsLock) oldOwners(now time.Time, olderThan time.Duration) []madmin.OpsLockState {
+	var details []madmin.OpsLockState
+	for _, owner := range nsLk.owners {
+		if now.Sub(owner.since) < olderThan {
+			continue
+		}
+		details = append(details, owner.lockState(now))
+	}
+	return details
+}
+
+// sortVolumeLocks - sorts volume locks by bucket and object.
+func sortVolumeLocks(volLocks []madmin.VolumeLockInfo) {
+	sort.Slice(volLocks, func(i, j int) bool {
+		if volLocks[i].Bucket != volLocks[j].Bucket {
+			return volLocks[i].Bucket < volLocks[j].Bucket
+		}
+		return volLocks[i].Object < volLocks[j].Object
+	})
+}
+
+// listLocks - lists the locks held or waited for on objects under
+// bucket/prefix for longer than olderThan, an empty bucket matches
+// all the buckets.
+func (n *nsLockMap) listLocks(bucket, prefix string, olderThan time.Duration) []madmin.VolumeLockInfo {
+	n.lockMapMutex.RLock()
+	defer n.lockMapMutex.RUnlock()
+
+	now := UTCNow()
+	volLocks := []madmin.VolumeLockInfo{}
+	for param, nsLk := range n.lockMap {
+		if !param.matches(bucket, prefix) {
+			continue
+		}
+		details := nsLk.oldOwners(now, olderThan)
+		if len(details) == 0 {
+			continue
+		}
+		volLocks = append(volLocks, madmin.VolumeLockInfo{
+			Bucket:      param.volume,
+			Object:      param.path,
+			LockDetails: details,
+		})
+	}
+	sortVolumeLocks(volLocks)
+	return volLocks
+}
+
+// clearLocks - forcefully unlocks the objects under bucket/prefix whose
+// lock is held for longer than olderThan, returns the cleared locks.
+// Force unlocking releases all the holders of a lock, so locks having
+// a holder more recent than olderThan are left alone. The age of the
+// holders is checked under the lock map mutex, such that a lock granted
+// meanwhile is not released.
+func (n *nsLockMap) clearLocks(bucket, prefix string, olderThan time.Duration) []madmin.VolumeLockInfo {
+	n.lockMapMutex.Lock()
+	defer n.lockMapMutex.Unlock()
+
+	now := UTCNow()
+	volLocks := []madmin.VolumeLockInfo{}
+	for param, nsLk := range n.lockMap {
+		if !param.matches(bucket, prefix) {
+			continue
+		}
+		held, recent := false, false
+		for _, owner := range nsLk.owners {
+			if !owner.held {
+				continue
+			}
+			held = true
+			if now.Sub(owner.since) < olderThan {
+				recent = true
+			}
+		}
+		if !held || recent {
+			continue
+		}
+		volLocks = append(volLocks, madmin.VolumeLockInfo{
+			Bucket:      param.volume,
+			Object:      param.path,
+			LockDetails: nsLk.oldOwners(now, olderThan),
+		})
+		n.forceUnlock(param)
+	}
+	sortVolumeLocks(volLocks)
+	return volLocks
+}
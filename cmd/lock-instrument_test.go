@@ -0,0 +1,173 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pydio/minio-srv/pkg/madmin"
+)
+
+// Tests listing the locks held and waited for.
+func TestNSLockMapListLocks(t *testing.T) {
+	nsMutex := newNSLock(false)
+
+	if !nsMutex.Lock("bucket", "dir/object", "ops1", time.Second) {
+		t.Fatal("Failed to acquire write lock")
+	}
+	if !nsMutex.RLock("bucket", "other", "ops2", time.Second) {
+		t.Fatal("Failed to acquire read lock")
+	}
+	if !nsMutex.RLock(minioMetaBucket, "object", "ops3", time.Second) {
+		t.Fatal("Failed to acquire read lock")
+	}
+
+	// Wait for the write lock in the background.
+	lockedCh := make(chan bool)
+	go func() {
+		lockedCh <- nsMutex.Lock("bucket", "dir/object", "ops4", 10*time.Second)
+	}()
+	for {
+		nsMutex.lockMapMutex.RLock()
+		waiting := len(nsMutex.lockMap[nsParam{"bucket", "dir/object"}].owners) == 2
+		nsMutex.lockMapMutex.RUnlock()
+		if waiting {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	testCases := []struct {
+		bucket, prefix  string
+		olderThan       time.Duration
+		expectedObjects []string
+	}{
+		{"", "", 0, []string{minioMetaBucket + "/object", "bucket/dir/object", "bucket/other"}},
+		{"bucket", "", 0, []string{"bucket/dir/object", "bucket/other"}},
+		{"bucket", "dir/", 0, []string{"bucket/dir/object"}},
+		{"bucket", "none", 0, nil},
+		{"none", "", 0, nil},
+		{"", "", time.Hour, nil},
+	}
+	for i, testCase := range testCases {
+		volLocks := nsMutex.listLocks(testCase.bucket, testCase.prefix, testCase.olderThan)
+		if len(volLocks) != len(testCase.expectedObjects) {
+			t.Fatalf("Test %d: expected %d locked objects, got %v", i+1, len(testCase.expectedObjects), volLocks)
+		}
+		for j, volLock := range volLocks {
+			if got := volLock.Bucket + "/" + volLock.Object; got != testCase.expectedObjects[j] {
+				t.Errorf("Test %d: expected %s, got %s", i+1, testCase.expectedObjects[j], got)
+			}
+		}
+	}
+
+	volLocks := nsMutex.listLocks("bucket", "dir/", 0)
+	details := volLocks[0].LockDetails
+	if len(details) != 2 {
+		t.Fatalf("Expected a held and a waiting lock, got %v", details)
+	}
+	if details[0].OperationID != "ops1" || details[0].LockType != madmin.WriteLock ||
+		details[0].Status != madmin.LockHeld || details[0].LockSource == "" {
+		t.Errorf("Unexpected held lock %v", details[0])
+	}
+	if details[1].OperationID != "ops4" || details[1].LockType != madmin.WriteLock ||
+		details[1].Status != madmin.LockWaiting {
+		t.Errorf("Unexpected waiting lock %v", details[1])
+	}
+
+	// Releasing the write lock grants it to the waiting operation.
+	nsMutex.Unlock("bucket", "dir/object", "ops1")
+	if !<-lockedCh {
+		t.Fatal("Failed to acquire write lock")
+	}
+	details = nsMutex.listLocks("bucket", "dir/", 0)[0].LockDetails
+	if len(details) != 1 || details[0].OperationID != "ops4" || details[0].Status != madmin.LockHeld {
+		t.Errorf("Unexpected locks %v", details)
+	}
+
+	nsMutex.Unlock("bucket", "dir/object", "ops4")
+	nsMutex.RUnlock("bucket", "other", "ops2")
+	nsMutex.RUnlock(minioMetaBucket, "object", "ops3")
+	if volLocks = nsMutex.listLocks("", "", 0); len(volLocks) != 0 {
+		t.Errorf("Expected no locks, got %v", volLocks)
+	}
+	if nsMutex.counters.total != 0 || nsMutex.counters.granted != 0 || nsMutex.counters.blocked != 0 {
+		t.Errorf("Unexpected lock counters %+v", *nsMutex.counters)
+	}
+}
+
+// Tests clearing old locks.
+func TestNSLockMapClearLocks(t *testing.T) {
+	nsMutex := newNSLock(false)
+
+	if !nsMutex.Lock("bucket", "object", "ops1", time.Second) {
+		t.Fatal("Failed to acquire write lock")
+	}
+
+	if volLocks := nsMutex.clearLocks("bucket", "", time.Hour); len(volLocks) != 0 {
+		t.Fatalf("Expected no cleared locks, got %v", volLocks)
+	}
+	volLocks := nsMutex.clearLocks("bucket", "", 0)
+	if len(volLocks) != 1 || volLocks[0].Object != "object" {
+		t.Fatalf("Expected the object lock to be cleared, got %v", volLocks)
+	}
+	if volLocks = nsMutex.listLocks("", "", 0); len(volLocks) != 0 {
+		t.Fatalf("Expected no locks, got %v", volLocks)
+	}
+
+	// The resource can be locked again.
+	if !nsMutex.Lock("bucket", "object", "ops2", time.Second) {
+		t.Fatal("Failed to acquire write lock after clearing")
+	}
+	nsMutex.Unlock("bucket", "object", "ops2")
+	if nsMutex.counters.total != 0 {
+		t.Errorf("Unexpected lock counters %+v", *nsMutex.counters)
+	}
+}
+
+// Tests that locks having a recent holder are not cleared.
+func TestNSLockMapClearLocksRecentHolder(t *testing.T) {
+	nsMutex := newNSLock(false)
+
+	if !nsMutex.RLock("bucket", "object", "ops1", time.Second) {
+		t.Fatal("Failed to acquire read lock")
+	}
+	// Make the first holder older than the threshold.
+	nsMutex.lockMapMutex.Lock()
+	nsMutex.lockMap[nsParam{"bucket", "object"}].owners[0].since = UTCNow().Add(-2 * time.Hour)
+	nsMutex.lockMapMutex.Unlock()
+
+	if !nsMutex.RLock("bucket", "object", "ops2", time.Second) {
+		t.Fatal("Failed to acquire read lock")
+	}
+	if volLocks := nsMutex.clearLocks("bucket", "", time.Hour); len(volLocks) != 0 {
+		t.Fatalf("Expected the lock with a recent holder not to be cleared, got %v", volLocks)
+	}
+	if volLocks := nsMutex.listLocks("bucket", "", 0); len(volLocks) != 1 || len(volLocks[0].LockDetails) != 2 {
+		t.Fatalf("Expected both holders to keep the lock, got %v", volLocks)
+	}
+
+	nsMutex.RUnlock("bucket", "object", "ops2")
+	volLocks := nsMutex.clearLocks("bucket", "", time.Hour)
+	if len(volLocks) != 1 || len(volLocks[0].LockDetails) != 1 || volLocks[0].LockDetails[0].OperationID != "ops1" {
+		t.Fatalf("Expected the old holder to be cleared, got %v", volLocks)
+	}
+	if nsMutex.counters.total != 0 {
+		t.Errorf("Unexpected lock counters %+v", *nsMutex.counters)
+	}
+}
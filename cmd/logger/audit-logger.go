@@ -21,6 +21,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/pydio/minio-srv/cmd/logger/message/log"
 )
 
 // Represents the current version of audit log structure.
@@ -31,7 +33,7 @@ type AuditEntry struct {
 	Version      string            `json:"version"`
 	DeploymentID string            `json:"deploymentid,omitempty"`
 	Time         string            `json:"time"`
-	API          *api              `json:"api,omitempty"`
+	API          *log.API          `json:"api,omitempty"`
 	RemoteHost   string            `json:"remotehost,omitempty"`
 	RequestID    string            `json:"requestID,omitempty"`
 	UserAgent    string            `json:"userAgent,omitempty"`
@@ -40,6 +42,15 @@ type AuditEntry struct {
 	RespHeader   map[string]string `json:"responseHeader,omitempty"`
 }
 
+// DecisionEntry - audit entry of an authorization decision taken by an
+// external policy engine.
+type DecisionEntry struct {
+	Version      string      `json:"version"`
+	DeploymentID string      `json:"deploymentid,omitempty"`
+	Time         string      `json:"time"`
+	Decision     interface{} `json:"decision"`
+}
+
 // AuditTargets is the list of enabled audit loggers
 var AuditTargets = []LoggingTarget{}
 
@@ -82,9 +93,9 @@ func AuditLog(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 			RequestID:    req.RequestID,
 			UserAgent:    req.UserAgent,
 			Time:         time.Now().UTC().Format(time.RFC3339Nano),
-			API: &api{
+			API: &log.API{
 				Name: req.API,
-				Args: &args{
+				Args: &log.Args{
 					Bucket: req.BucketName,
 					Object: req.ObjectName,
 				},
@@ -95,3 +106,19 @@ func AuditLog(ctx context.Context, w http.ResponseWriter, r *http.Request) {
 		})
 	}
 }
+
+// AuditDecision - logs an authorization decision to all audit targets.
+func AuditDecision(decision interface{}) {
+	if Disable {
+		return
+	}
+
+	for _, t := range AuditTargets {
+		t.send(DecisionEntry{
+			Version:      auditLogVersion,
+			DeploymentID: deploymentID,
+			Time:         time.Now().UTC().Format(time.RFC3339Nano),
+			Decision:     decision,
+		})
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/pydio/minio-srv/cmd/logger/message/log"
 )
 
 // ConsoleTarget implements loggerTarget to send log
@@ -28,7 +30,7 @@ import (
 type ConsoleTarget struct{}
 
 func (c *ConsoleTarget) send(e interface{}) error {
-	entry, ok := e.(logEntry)
+	entry, ok := e.(log.Entry)
 	if !ok {
 		return fmt.Errorf("Uexpected log entry structure %#v", e)
 	}
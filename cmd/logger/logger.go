@@ -28,6 +28,7 @@ import (
 	"time"
 
 	c "github.com/minio/mc/pkg/console"
+	"github.com/pydio/minio-srv/cmd/logger/message/log"
 )
 
 // Disable disables all logging, false by default. (used for "go test")
@@ -111,35 +112,6 @@ func consoleLog(console Console, msg string, args ...interface{}) {
 	}
 }
 
-type traceEntry struct {
-	Message   string            `json:"message,omitempty"`
-	Source    []string          `json:"source,omitempty"`
-	Variables map[string]string `json:"variables,omitempty"`
-}
-
-type args struct {
-	Bucket   string            `json:"bucket,omitempty"`
-	Object   string            `json:"object,omitempty"`
-	Metadata map[string]string `json:"metadata,omitempty"`
-}
-
-type api struct {
-	Name string `json:"name,omitempty"`
-	Args *args  `json:"args,omitempty"`
-}
-
-type logEntry struct {
-	DeploymentID string      `json:"deploymentid,omitempty"`
-	Level        string      `json:"level"`
-	Time         string      `json:"time"`
-	API          *api        `json:"api,omitempty"`
-	RemoteHost   string      `json:"remotehost,omitempty"`
-	RequestID    string      `json:"requestID,omitempty"`
-	UserAgent    string      `json:"userAgent,omitempty"`
-	Message      string      `json:"message,omitempty"`
-	Trace        *traceEntry `json:"error,omitempty"`
-}
-
 // quiet: Hide startup messages if enabled
 // jsonFlag: Display in JSON format, if enabled
 var (
@@ -326,15 +298,15 @@ func logIf(ctx context.Context, err error) {
 	// Get the cause for the Error
 	message := err.Error()
 
-	entry := logEntry{
+	entry := log.Entry{
 		DeploymentID: deploymentID,
 		Level:        ErrorLvl.String(),
 		RemoteHost:   req.RemoteHost,
 		RequestID:    req.RequestID,
 		UserAgent:    req.UserAgent,
 		Time:         time.Now().UTC().Format(time.RFC3339Nano),
-		API:          &api{Name: API, Args: &args{Bucket: req.BucketName, Object: req.ObjectName}},
-		Trace:        &traceEntry{Message: message, Source: trace, Variables: tags},
+		API:          &log.API{Name: API, Args: &log.Args{Bucket: req.BucketName, Object: req.ObjectName}},
+		Trace:        &log.Trace{Message: message, Source: trace, Variables: tags},
 	}
 
 	// Iterate over all logger targets to send the log entry
@@ -362,6 +334,14 @@ func FatalIf(err error, msg string, data ...interface{}) {
 	}
 	fmt.Println(err, msg)
 	//fatal(err, msg, data...)
+
+	sendToRings(log.Entry{
+		DeploymentID: deploymentID,
+		Level:        FatalLvl.String(),
+		Time:         time.Now().UTC().Format(time.RFC3339Nano),
+		Message:      fmt.Sprintf(msg, data...),
+		Trace:        &log.Trace{Message: err.Error(), Source: []string{getSource(2)}},
+	})
 }
 
 // Fatal prints only fatal error message without no stack trace
@@ -386,10 +366,10 @@ type fatalMsg struct {
 }
 
 func (f fatalMsg) json(msg string, args ...interface{}) {
-	logJSON, err := json.Marshal(&logEntry{
+	logJSON, err := json.Marshal(&log.Entry{
 		Level: FatalLvl.String(),
 		Time:  time.Now().UTC().Format(time.RFC3339Nano),
-		Trace: &traceEntry{Message: fmt.Sprintf(msg, args...), Source: []string{getSource(6)}},
+		Trace: &log.Trace{Message: fmt.Sprintf(msg, args...), Source: []string{getSource(6)}},
 	})
 	if err != nil {
 		panic(err)
@@ -458,7 +438,7 @@ type infoMsg struct{}
 var info infoMsg
 
 func (i infoMsg) json(msg string, args ...interface{}) {
-	logJSON, err := json.Marshal(&logEntry{
+	logJSON, err := json.Marshal(&log.Entry{
 		Level:   InformationLvl.String(),
 		Message: fmt.Sprintf(msg, args...),
 		Time:    time.Now().UTC().Format(time.RFC3339Nano),
@@ -480,6 +460,13 @@ func (i infoMsg) pretty(msg string, args ...interface{}) {
 // Info :
 func Info(msg string, data ...interface{}) {
 	consoleLog(info, msg+"\n", data...)
+
+	sendToRings(log.Entry{
+		DeploymentID: deploymentID,
+		Level:        InformationLvl.String(),
+		Time:         time.Now().UTC().Format(time.RFC3339Nano),
+		Message:      strings.TrimSpace(ansiRE.ReplaceAllLiteralString(fmt.Sprintf(msg, data...), "")),
+	})
 }
 
 var startupMessage startUpMsg
@@ -1,5 +1,7 @@
 package logger
 
+import "github.com/pydio/minio-srv/cmd/logger/message/log"
+
 type PydioLogger interface {
 	Info(entry interface{})
 	Error(entry interface{})
@@ -12,7 +14,7 @@ type PydioTarget struct {
 
 func (c *PydioTarget) send(entry interface{}) error {
 
-	if logE, ok := entry.(logEntry); ok {
+	if logE, ok := entry.(log.Entry); ok {
 		if logE.Level == ErrorLvl.String() {
 			c.logger.Error(entry)
 		} else {
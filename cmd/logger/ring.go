@@ -0,0 +1,104 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logger
+
+import (
+	"container/ring"
+	"fmt"
+	"sync"
+
+	"github.com/pydio/minio-srv/cmd/logger/message/log"
+	"github.com/pydio/minio-srv/pkg/pubsub"
+)
+
+// RingTarget implements loggerTarget and keeps the most recent
+// log entries of the node in memory. New entries are published
+// to the subscribers, to stream them through the admin API.
+type RingTarget struct {
+	sync.RWMutex
+	// Next slot to fill, holding the oldest entry when full.
+	entries *ring.Ring
+	pubsub  *pubsub.PubSub
+}
+
+// NewRing initializes a new logger target which keeps
+// the last size log entries in memory.
+func NewRing(size int) *RingTarget {
+	return &RingTarget{
+		entries: ring.New(size),
+		pubsub:  pubsub.New(),
+	}
+}
+
+func (r *RingTarget) send(e interface{}) error {
+	entry, ok := e.(log.Entry)
+	if !ok {
+		return fmt.Errorf("Uexpected log entry structure %#v", e)
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.entries.Value = entry
+	r.entries = r.entries.Next()
+	r.pubsub.Publish(entry)
+	return nil
+}
+
+// Subscribe - sends the last entries matching filter on subCh, followed
+// by the new matching entries until doneCh is closed. A nil filter
+// matches all the entries, entries are dropped when subCh is full.
+func (r *RingTarget) Subscribe(subCh chan interface{}, doneCh <-chan struct{}, last int, filter func(log.Entry) bool) {
+	// Hold the lock until subscribed, not to miss or repeat entries.
+	r.RLock()
+	defer r.RUnlock()
+
+	var entries []log.Entry
+	r.entries.Do(func(v interface{}) {
+		entry, ok := v.(log.Entry)
+		if ok && (filter == nil || filter(entry)) {
+			entries = append(entries, entry)
+		}
+	})
+	if len(entries) > last {
+		entries = entries[len(entries)-last:]
+	}
+	for _, entry := range entries {
+		select {
+		case subCh <- entry:
+		default:
+		}
+	}
+
+	r.pubsub.Subscribe(subCh, doneCh, func(v interface{}) bool {
+		entry, ok := v.(log.Entry)
+		return ok && (filter == nil || filter(entry))
+	})
+}
+
+// sendToRings - sends an entry to the in-memory targets only, for the
+// messages other targets do not receive.
+func sendToRings(entry log.Entry) {
+	if Disable {
+		return
+	}
+	for _, t := range Targets {
+		if r, ok := t.(*RingTarget); ok {
+			r.send(entry)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logger
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pydio/minio-srv/cmd/logger/message/log"
+)
+
+// newTestRing - returns a ring of 4 entries after sending 6 entries,
+// alternating informational messages and errors.
+func newTestRing(t *testing.T) *RingTarget {
+	r := NewRing(4)
+	for i := 0; i < 6; i++ {
+		level := InformationLvl
+		if i%2 == 1 {
+			level = ErrorLvl
+		}
+		if err := r.send(log.Entry{Level: level.String(), Message: strconv.Itoa(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.send("unexpected"); err == nil {
+		t.Fatal("Expected an error for an unexpected log entry")
+	}
+	return r
+}
+
+func TestRingTarget(t *testing.T) {
+
+	isError := func(entry log.Entry) bool { return entry.Level == ErrorLvl.String() }
+	testCases := []struct {
+		last     int
+		filter   func(log.Entry) bool
+		expected []string
+	}{
+		// Entries 0 and 1 were overwritten.
+		{10, nil, []string{"2", "3", "4", "5"}},
+		{2, nil, []string{"4", "5"}},
+		{0, nil, nil},
+		{10, isError, []string{"3", "5"}},
+		{1, isError, []string{"5"}},
+	}
+
+	for i, testCase := range testCases {
+		r := newTestRing(t)
+		subCh := make(chan interface{}, 10)
+		doneCh := make(chan struct{})
+		r.Subscribe(subCh, doneCh, testCase.last, testCase.filter)
+
+		// New entries are received after the recent ones.
+		r.send(log.Entry{Level: ErrorLvl.String(), Message: "new"})
+		expected := append(testCase.expected, "new")
+		for _, message := range expected {
+			select {
+			case v := <-subCh:
+				if entry := v.(log.Entry); entry.Message != message {
+					t.Errorf("Test %d: expected entry %s, got %s", i+1, message, entry.Message)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("Test %d: expected entry %s", i+1, message)
+			}
+		}
+		close(doneCh)
+	}
+}
@@ -104,6 +104,30 @@ func (c *minioCollector) Collect(ch chan<- prometheus.Metric) {
 			prometheus.GaugeValue,
 			float64(cs.Free),
 		)
+		ch <- prometheus.MustNewConstMetric(
+			prometheus.NewDesc(
+				prometheus.BuildFQName("minio", "cache", "hits_total"),
+				"Total number of requests served from the cache on current Minio server instance",
+				nil, nil),
+			prometheus.CounterValue,
+			float64(globalCacheStats.getHits()),
+		)
+		ch <- prometheus.MustNewConstMetric(
+			prometheus.NewDesc(
+				prometheus.BuildFQName("minio", "cache", "misses_total"),
+				"Total number of requests for cacheable objects served from the backend on current Minio server instance",
+				nil, nil),
+			prometheus.CounterValue,
+			float64(globalCacheStats.getMisses()),
+		)
+		ch <- prometheus.MustNewConstMetric(
+			prometheus.NewDesc(
+				prometheus.BuildFQName("minio", "cache", "evictions_total"),
+				"Total number of entries evicted from the cache on current Minio server instance",
+				nil, nil),
+			prometheus.CounterValue,
+			float64(globalCacheStats.getEvictions()),
+		)
 	}
 
 	// Expose disk stats only if applicable
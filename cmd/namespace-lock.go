@@ -122,6 +122,9 @@ type nsParam struct {
 type nsLock struct {
 	RWLockerSync
 	ref uint
+
+	// Operations holding or waiting for the lock, used for lock debugging.
+	owners []*nsLockOwner
 }
 
 // nsLockMap - namespace lock map, provides primitives to Lock,
@@ -158,6 +161,14 @@ func (n *nsLockMap) lock(volume, path string, lockSource, opsID string, readLock
 		// Update ref count here to avoid multiple races.
 		nsLk.ref++
 	}
+	owner := &nsLockOwner{
+		opsID:    opsID,
+		source:   lockSource,
+		readLock: readLock,
+		since:    UTCNow(),
+	}
+	nsLk.owners = append(nsLk.owners, owner)
+	n.counters.lockWaiting()
 	n.lockMapMutex.Unlock()
 
 	// Locking here will block (until timeout).
@@ -167,17 +178,28 @@ func (n *nsLockMap) lock(volume, path string, lockSource, opsID string, readLock
 		locked = nsLk.GetLock(timeout)
 	}
 
+	n.lockMapMutex.Lock()
+	// The owner is gone if the lock was forcefully unlocked meanwhile.
+	if i := nsLk.ownerIndex(owner); i >= 0 {
+		if locked {
+			owner.held = true
+			owner.since = UTCNow()
+			n.counters.lockGranted()
+		} else {
+			nsLk.removeOwner(i)
+			n.counters.lockTimedOut()
+		}
+	}
 	if !locked { // We failed to get the lock
 
 		// Decrement ref count since we failed to get the lock
-		n.lockMapMutex.Lock()
 		nsLk.ref--
-		if nsLk.ref == 0 {
+		if nsLk.ref == 0 && n.lockMap[param] == nsLk {
 			// Remove from the map if there are no more references.
 			delete(n.lockMap, param)
 		}
-		n.lockMapMutex.Unlock()
 	}
+	n.lockMapMutex.Unlock()
 	return
 }
 
@@ -196,6 +218,10 @@ func (n *nsLockMap) unlock(volume, path, opsID string, readLock bool) {
 		nsLk.Unlock()
 	}
 	n.lockMapMutex.Lock()
+	if i := nsLk.heldOwnerIndex(opsID, readLock); i >= 0 {
+		nsLk.removeOwner(i)
+		n.counters.lockRemoved(true)
+	}
 	if nsLk.ref == 0 {
 		logger.LogIf(context.Background(), errors.New("Namespace reference count cannot be 0"))
 	} else {
@@ -255,12 +281,21 @@ func (n *nsLockMap) ForceUnlock(volume, path string) {
 	//   that participated in granting the lock. Any pending dsync locks that
 	//   are blocking can now proceed as normal and any new locks will also
 	//   participate normally.
+	n.forceUnlock(nsParam{volume, path})
+}
+
+// forceUnlock - forcefully unlocks param, the lock map mutex must be held.
+func (n *nsLockMap) forceUnlock(param nsParam) {
 	if n.isDistXL { // For distributed mode, broadcast ForceUnlock message.
-		dsync.NewDRWMutex(pathJoin(volume, path), globalDsync).ForceUnlock()
+		dsync.NewDRWMutex(pathJoin(param.volume, param.path), globalDsync).ForceUnlock()
 	}
 
-	param := nsParam{volume, path}
-	if _, found := n.lockMap[param]; found {
+	if nsLk, found := n.lockMap[param]; found {
+		for _, owner := range nsLk.owners {
+			n.counters.lockRemoved(owner.held)
+		}
+		nsLk.owners = nil
+
 		// Remove lock from the map.
 		delete(n.lockMap, param)
 	}
@@ -189,6 +189,58 @@ func (sys *NotificationSys) ListenBucketNotification(ctx context.Context, bucket
 	}()
 }
 
+// Trace - streams the HTTP trace records of all peers matching opts to
+// traceCh, until doneCh is closed. Failed streams are retried, as peers
+// may be restarting.
+func (sys *NotificationSys) Trace(traceCh chan interface{}, doneCh <-chan struct{}, opts traceOptions) {
+	for addr, client := range sys.peerRPCClientMap {
+		client := client
+		go sys.retryStream(addr, doneCh, func() error {
+			return client.Trace(traceCh, doneCh, opts)
+		})
+	}
+}
+
+// ConsoleLog - streams the log entries of the peers matching opts to
+// logCh, until doneCh is closed. Failed streams are retried, without
+// sending the recent entries again.
+func (sys *NotificationSys) ConsoleLog(logCh chan interface{}, doneCh <-chan struct{}, opts consoleLogOptions) {
+	for addr, client := range sys.peerRPCClientMap {
+		if !opts.matchesNode(addr.String()) {
+			continue
+		}
+		client, peerOpts := client, opts
+		go sys.retryStream(addr, doneCh, func() error {
+			err := client.ConsoleLog(logCh, doneCh, peerOpts)
+			peerOpts.limit = 0
+			return err
+		})
+	}
+}
+
+// retryStream - runs stream until doneCh is closed, failures are logged
+// once per peer and the stream is restarted every second.
+func (sys *NotificationSys) retryStream(addr xnet.Host, doneCh <-chan struct{}, stream func() error) {
+	for {
+		err := stream()
+		select {
+		case <-doneCh:
+			return
+		default:
+		}
+
+		reqInfo := (&logger.ReqInfo{}).AppendTags("remotePeer", addr.Name)
+		ctx := logger.SetReqInfo(context.Background(), reqInfo)
+		logger.LogOnceIf(ctx, err, addr.String())
+
+		select {
+		case <-doneCh:
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
 // AddRemoteTarget - adds event rules map, HTTP/PeerRPC client target to bucket name.
 func (sys *NotificationSys) AddRemoteTarget(bucketName string, target event.Target, rulesMap event.RulesMap) error {
 	if err := sys.targetList.Add(target); err != nil {
@@ -0,0 +1,146 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pydio/minio-srv/pkg/hash"
+)
+
+const (
+	// Header announcing the trailing headers of a streaming upload.
+	amzTrailer = "X-Amz-Trailer"
+
+	// Header enabling the additional checksum of objects in the response
+	// to GET and HEAD requests.
+	amzChecksumMode = "X-Amz-Checksum-Mode"
+
+	// Header selecting the additional checksum of the parts of a multipart
+	// upload.
+	amzChecksumAlgorithm = "X-Amz-Checksum-Algorithm"
+)
+
+// isRequestChecksumSent - returns whether the request sends an additional
+// checksum, in a x-amz-checksum-* header or as a trailing header, or asks
+// for one with x-amz-checksum-algorithm. Additional checksums are only
+// supported for single part uploads, multipart uploads reject them rather
+// than ignoring them.
+func isRequestChecksumSent(r *http.Request) bool {
+	if r.Header.Get(amzChecksumAlgorithm) != "" {
+		return true
+	}
+	for _, t := range hash.ChecksumTypes {
+		if _, ok := r.Header[t.Key()]; ok {
+			return true
+		}
+	}
+	for _, key := range strings.Split(r.Header.Get(amzTrailer), ",") {
+		if hash.ChecksumTypeFromKey(key).IsValid() {
+			return true
+		}
+	}
+	return false
+}
+
+// newRequestTrailer - declares in r.Trailer the trailing headers announced
+// by x-amz-trailer, their values are set once the last chunk of the content
+// is read. Only additional checksums can be sent as trailing headers.
+func newRequestTrailer(r *http.Request) (http.Header, APIErrorCode) {
+	announced := r.Header.Get(amzTrailer)
+	if announced == "" {
+		return nil, ErrNone
+	}
+
+	trailer := make(http.Header)
+	for _, key := range strings.Split(announced, ",") {
+		if !hash.ChecksumTypeFromKey(key).IsValid() {
+			return nil, ErrUnsupportedTrailer
+		}
+		trailer[http.CanonicalHeaderKey(strings.TrimSpace(key))] = nil
+	}
+	r.Trailer = trailer
+	return trailer, ErrNone
+}
+
+// addRequestChecksum - makes hashReader verify the additional checksum sent
+// by the client, either in a x-amz-checksum-* header or as a trailing header
+// of a streaming upload. Only one additional checksum can be sent.
+func addRequestChecksum(r *http.Request, hashReader *hash.Reader) error {
+	checksum, err := hash.ChecksumFromHeader(r.Header)
+	if err != nil {
+		return err
+	}
+
+	var trailing hash.ChecksumType
+	for key := range r.Trailer {
+		t := hash.ChecksumTypeFromKey(key)
+		if !t.IsValid() {
+			continue
+		}
+		if checksum != nil || trailing != "" {
+			return hash.InvalidChecksum{Type: t}
+		}
+		trailing = t
+	}
+
+	switch {
+	case checksum != nil:
+		return hashReader.AddChecksum(checksum.Type, checksum.Encoded, nil)
+	case trailing != "":
+		return hashReader.AddChecksum(trailing, "", r.Trailer)
+	}
+	return nil
+}
+
+// checksumMetadataKey - returns the internal metadata entry saving the
+// additional checksum of type t of an object.
+func checksumMetadataKey(t hash.ChecksumType) string {
+	return ReservedMetadataPrefix + "checksum-" + strings.ToLower(string(t))
+}
+
+// saveChecksumMetadata - saves in metadata the additional checksum of the
+// content read from data, once it has been verified.
+func saveChecksumMetadata(metadata map[string]string, data *hash.Reader) {
+	if checksum := data.Checksum(); checksum != nil {
+		metadata[checksumMetadataKey(checksum.Type)] = checksum.Encoded
+	}
+}
+
+// getObjectChecksum - returns the additional checksum saved with an
+// object, if any.
+func getObjectChecksum(objInfo ObjectInfo) *hash.Checksum {
+	for _, t := range hash.ChecksumTypes {
+		if encoded, ok := objInfo.UserDefined[checksumMetadataKey(t)]; ok {
+			return &hash.Checksum{Type: t, Encoded: encoded}
+		}
+	}
+	return nil
+}
+
+// setObjectChecksumHeaders - sets the additional checksum of an object in
+// the response to GET and HEAD requests enabling x-amz-checksum-mode. The
+// checksum covers the whole object, it is not sent for range requests.
+func setObjectChecksumHeaders(w http.ResponseWriter, r *http.Request, objInfo ObjectInfo, rs *HTTPRangeSpec) {
+	if rs != nil || !strings.EqualFold(r.Header.Get(amzChecksumMode), "ENABLED") {
+		return
+	}
+	if checksum := getObjectChecksum(objInfo); checksum != nil {
+		w.Header().Set(checksum.Type.Key(), checksum.Encoded)
+	}
+}
@@ -0,0 +1,265 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pydio/minio-srv/pkg/auth"
+	"github.com/pydio/minio-srv/pkg/hash"
+)
+
+// Tests parsing the trailing headers announced by x-amz-trailer.
+func TestNewRequestTrailer(t *testing.T) {
+	testCases := []struct {
+		announced    string
+		expectedKeys []string
+		expectedErr  APIErrorCode
+	}{
+		// Test - 1 no trailer.
+		{"", nil, ErrNone},
+		// Test - 2 checksum trailer.
+		{"x-amz-checksum-crc32", []string{"X-Amz-Checksum-Crc32"}, ErrNone},
+		// Test - 3 several checksum trailers.
+		{"x-amz-checksum-sha1, x-amz-checksum-crc32c", []string{"X-Amz-Checksum-Sha1", "X-Amz-Checksum-Crc32c"}, ErrNone},
+		// Test - 4 unsupported trailer.
+		{"x-amz-meta-key", nil, ErrUnsupportedTrailer},
+		// Test - 5 unsupported checksum algorithm.
+		{"x-amz-checksum-md5", nil, ErrUnsupportedTrailer},
+	}
+	for i, testCase := range testCases {
+		r := httptest.NewRequest("PUT", "/bucket/object", nil)
+		if testCase.announced != "" {
+			r.Header.Set(amzTrailer, testCase.announced)
+		}
+		trailer, errCode := newRequestTrailer(r)
+		if errCode != testCase.expectedErr {
+			t.Errorf("Test %d: Expected %d, got %d", i+1, testCase.expectedErr, errCode)
+			continue
+		}
+		if len(trailer) != len(testCase.expectedKeys) {
+			t.Errorf("Test %d: Expected %d trailing headers, got %d", i+1, len(testCase.expectedKeys), len(trailer))
+		}
+		for _, key := range testCase.expectedKeys {
+			if _, ok := trailer[key]; !ok {
+				t.Errorf("Test %d: Expected trailing header %s", i+1, key)
+			}
+			if _, ok := r.Trailer[key]; !ok {
+				t.Errorf("Test %d: Expected request trailing header %s", i+1, key)
+			}
+		}
+	}
+}
+
+// Tests verifying, saving and returning the additional checksum of
+// an object.
+func TestObjectChecksum(t *testing.T) {
+	testCases := []struct {
+		header      http.Header
+		trailer     http.Header
+		expected    *hash.Checksum
+		expectedErr error
+	}{
+		// Test - 1 no checksum.
+		{http.Header{}, nil, nil, nil},
+		// Test - 2 checksum header.
+		{http.Header{"X-Amz-Checksum-Crc32": []string{"7YLNEQ=="}}, nil, &hash.Checksum{Type: hash.ChecksumCRC32, Encoded: "7YLNEQ=="}, nil},
+		// Test - 3 trailing checksum.
+		{http.Header{}, http.Header{"X-Amz-Checksum-Crc32c": []string{"ksgKMQ=="}}, &hash.Checksum{Type: hash.ChecksumCRC32C, Encoded: "ksgKMQ=="}, nil},
+		// Test - 4 checksum header and trailing checksum.
+		{http.Header{"X-Amz-Checksum-Crc32": []string{"7YLNEQ=="}}, http.Header{"X-Amz-Checksum-Crc32c": []string{"ksgKMQ=="}}, nil, hash.InvalidChecksum{Type: hash.ChecksumCRC32C}},
+		// Test - 5 checksum mismatch.
+		{http.Header{"X-Amz-Checksum-Crc32": []string{"ksgKMQ=="}}, nil, nil, hash.ChecksumMismatch{Type: hash.ChecksumCRC32, Expected: "ksgKMQ==", Calculated: "7YLNEQ=="}},
+	}
+	for i, testCase := range testCases {
+		r := httptest.NewRequest("PUT", "/bucket/object", nil)
+		r.Header = testCase.header
+		r.Trailer = testCase.trailer
+
+		hashReader, err := hash.NewReader(bytes.NewReader([]byte("abcd")), 4, "", "", 4)
+		if err != nil {
+			t.Fatalf("Test %d: Unexpected error %v", i+1, err)
+		}
+		if err = addRequestChecksum(r, hashReader); err == nil {
+			_, err = ioutil.ReadAll(hashReader)
+		}
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expectedErr, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		metadata := make(map[string]string)
+		saveChecksumMetadata(metadata, hashReader)
+		checksum := getObjectChecksum(ObjectInfo{UserDefined: metadata})
+		if (checksum == nil) != (testCase.expected == nil) || (checksum != nil && *checksum != *testCase.expected) {
+			t.Errorf("Test %d: Expected checksum %v, got %v", i+1, testCase.expected, checksum)
+			continue
+		}
+
+		for _, mode := range []string{"", "ENABLED"} {
+			req := httptest.NewRequest("GET", "/bucket/object", nil)
+			req.Header.Set(amzChecksumMode, mode)
+			w := httptest.NewRecorder()
+			setObjectChecksumHeaders(w, req, ObjectInfo{UserDefined: metadata}, nil)
+			returned := checksum != nil && mode == "ENABLED"
+			if checksum != nil && (w.Header().Get(checksum.Type.Key()) != "") != returned {
+				t.Errorf("Test %d: Unexpected checksum header with mode %q: %v", i+1, mode, w.Header())
+			}
+		}
+	}
+}
+
+// Tests uploading an object with an additional checksum and reading it
+// back with HEAD requests.
+func TestAPIPutObjectChecksumHandler(t *testing.T) {
+	defer DetectTestLeak(t)()
+	ExecObjectLayerAPITest(t, testAPIPutObjectChecksumHandler, []string{"PutObject", "HeadObject"})
+}
+
+func testAPIPutObjectChecksumHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+	data := []byte("abcd")
+	testCases := []struct {
+		objectName         string
+		checksum           string
+		expectedRespStatus int
+	}{
+		// Test case - 1 matching checksum.
+		{"checksum-object", "7YLNEQ==", http.StatusOK},
+		// Test case - 2 checksum mismatch.
+		{"checksum-mismatch", "ksgKMQ==", http.StatusBadRequest},
+		// Test case - 3 invalid checksum.
+		{"checksum-invalid", "abcd", http.StatusBadRequest},
+	}
+	for i, testCase := range testCases {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4("PUT", getPutObjectURL("", bucketName, testCase.objectName),
+			int64(len(data)), bytes.NewReader(data), credentials.AccessKey, credentials.SecretKey,
+			map[string]string{"X-Amz-Checksum-Crc32": testCase.checksum})
+		if err != nil {
+			t.Fatalf("Test %d: %s: Failed to create HTTP request for Put Object: <ERROR> %v", i+1, instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedRespStatus {
+			t.Fatalf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`", i+1, instanceType, testCase.expectedRespStatus, rec.Code)
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+		if checksum := rec.Header().Get("X-Amz-Checksum-Crc32"); checksum != testCase.checksum {
+			t.Errorf("Test %d: %s: Expected checksum `%s` in the response, but found `%s`", i+1, instanceType, testCase.checksum, checksum)
+		}
+
+		rec = httptest.NewRecorder()
+		req, err = newTestSignedRequestV4("HEAD", getHeadObjectURL("", bucketName, testCase.objectName),
+			0, nil, credentials.AccessKey, credentials.SecretKey, map[string]string{amzChecksumMode: "ENABLED"})
+		if err != nil {
+			t.Fatalf("Test %d: %s: Failed to create HTTP request for Head Object: <ERROR> %v", i+1, instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if checksum := rec.Header().Get("X-Amz-Checksum-Crc32"); checksum != testCase.checksum {
+			t.Errorf("Test %d: %s: Expected checksum `%s` in Head Object, but found `%s`", i+1, instanceType, testCase.checksum, checksum)
+		}
+	}
+}
+
+// Tests detecting the additional checksums sent by clients.
+func TestIsRequestChecksumSent(t *testing.T) {
+	testCases := []struct {
+		headers  map[string]string
+		expected bool
+	}{
+		{nil, false},
+		{map[string]string{"Content-Md5": "1B2M2Y8AsgTpgAmY7PhCfg=="}, false},
+		{map[string]string{amzChecksumMode: "ENABLED"}, false},
+		{map[string]string{amzTrailer: "x-amz-meta-test"}, false},
+		{map[string]string{"X-Amz-Checksum-Crc32": "7YLNEQ=="}, true},
+		{map[string]string{"X-Amz-Checksum-Sha256": "abcd"}, true},
+		{map[string]string{amzTrailer: "x-amz-checksum-crc32c"}, true},
+		{map[string]string{amzChecksumAlgorithm: "CRC32"}, true},
+	}
+	for i, testCase := range testCases {
+		r := httptest.NewRequest("PUT", "/bucket/object", nil)
+		for key, value := range testCase.headers {
+			r.Header.Set(key, value)
+		}
+		if sent := isRequestChecksumSent(r); sent != testCase.expected {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expected, sent)
+		}
+	}
+}
+
+// Tests rejecting additional checksums sent with multipart uploads.
+func TestAPIMultipartChecksumHandler(t *testing.T) {
+	defer DetectTestLeak(t)()
+	ExecObjectLayerAPITest(t, testAPIMultipartChecksumHandler, []string{"NewMultipart", "PutObjectPart", "CompleteMultipart"})
+}
+
+func testAPIMultipartChecksumHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials auth.Credentials, t *testing.T) {
+	objectName := "multipart-checksum"
+	uploadID, err := obj.NewMultipartUpload(context.Background(), bucketName, objectName, nil, ObjectOptions{})
+	if err != nil {
+		t.Fatalf("%s: Failed to create multipart upload: <ERROR> %v", instanceType, err)
+	}
+
+	data := []byte("abcd")
+	testCases := []struct {
+		method             string
+		url                string
+		body               []byte
+		headers            map[string]string
+		expectedRespStatus int
+	}{
+		// Test case - 1 checksum algorithm of the parts.
+		{"POST", getNewMultipartURL("", bucketName, objectName), nil,
+			map[string]string{amzChecksumAlgorithm: "CRC32"}, http.StatusNotImplemented},
+		// Test case - 2 multipart upload without checksum.
+		{"POST", getNewMultipartURL("", bucketName, objectName), nil, nil, http.StatusOK},
+		// Test case - 3 checksum of a part.
+		{"PUT", getPutObjectPartURL("", bucketName, objectName, uploadID, "1"), data,
+			map[string]string{"X-Amz-Checksum-Crc32": "7YLNEQ=="}, http.StatusNotImplemented},
+		// Test case - 4 part without checksum.
+		{"PUT", getPutObjectPartURL("", bucketName, objectName, uploadID, "1"), data, nil, http.StatusOK},
+		// Test case - 5 checksum of the whole object.
+		{"POST", getCompleteMultipartUploadURL("", bucketName, objectName, uploadID), nil,
+			map[string]string{"X-Amz-Checksum-Crc32": "7YLNEQ=="}, http.StatusNotImplemented},
+	}
+	for i, testCase := range testCases {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4(testCase.method, testCase.url, int64(len(testCase.body)),
+			bytes.NewReader(testCase.body), credentials.AccessKey, credentials.SecretKey, testCase.headers)
+		if err != nil {
+			t.Fatalf("Test %d: %s: Failed to create HTTP request: <ERROR> %v", i+1, instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedRespStatus {
+			t.Errorf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`", i+1, instanceType, testCase.expectedRespStatus, rec.Code)
+		}
+		if checksum := rec.Header().Get("X-Amz-Checksum-Crc32"); checksum != "" {
+			t.Errorf("Test %d: %s: Expected no checksum in the response, but found `%s`", i+1, instanceType, checksum)
+		}
+	}
+}
@@ -30,7 +30,9 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
+	humanize "github.com/dustin/go-humanize"
 	snappy "github.com/golang/snappy"
 	"github.com/gorilla/mux"
 	"github.com/klauspost/readahead"
@@ -46,6 +48,7 @@ import (
 	"github.com/pydio/minio-srv/pkg/ioutil"
 	"github.com/pydio/minio-srv/pkg/policy"
 	"github.com/pydio/minio-srv/pkg/s3select"
+	"github.com/pydio/minio-srv/pkg/s3select/format"
 )
 
 // supportedHeadGetReqParams - supported request parameters for GET and HEAD presigned request.
@@ -71,6 +74,77 @@ func setHeadGetRespHeaders(w http.ResponseWriter, reqParams url.Values) {
 	}
 }
 
+// Size of the reads of objectReaderAt, Parquet pages and column
+// chunks are usually smaller and are served from a single read.
+const objectReaderAtBufferSize = 1 * humanize.MiByte
+
+// objectReaderAt reads ranges of an object, it gives random access to the
+// object to S3 Select input formats such as Parquet. The caller holds the
+// object lock for the lifetime of the reader, reads are buffered to limit
+// the number of range requests sent to the backend.
+type objectReaderAt struct {
+	ctx            context.Context
+	getObjectNInfo func(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, lockType LockType, opts ObjectOptions) (*GetObjectReader, error)
+	bucket         string
+	object         string
+	header         http.Header
+	size           int64
+
+	mu     sync.Mutex
+	buf    []byte
+	bufOff int64
+}
+
+// ReadAt reads len(p) bytes of the object starting at off.
+func (o *objectReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if off < 0 {
+		return 0, errInvalidArgument
+	}
+	if off >= o.size {
+		return 0, io.EOF
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if off < o.bufOff || off+int64(len(p)) > o.bufOff+int64(len(o.buf)) {
+		length := int64(len(p))
+		if length < objectReaderAtBufferSize {
+			length = objectReaderAtBufferSize
+		}
+		if off+length > o.size {
+			length = o.size - off
+		}
+		if int64(cap(o.buf)) < length {
+			o.buf = make([]byte, length)
+		}
+		o.buf = o.buf[:length]
+
+		rs := &HTTPRangeSpec{Start: off, End: off + length - 1}
+		gr, err := o.getObjectNInfo(o.ctx, o.bucket, o.object, rs, o.header, noLock, ObjectOptions{})
+		if err != nil {
+			o.buf = o.buf[:0]
+			return 0, err
+		}
+		_, err = io.ReadFull(gr, o.buf)
+		gr.Close()
+		if err != nil {
+			o.buf = o.buf[:0]
+			return 0, err
+		}
+		o.bufOff = off
+	}
+
+	n := copy(p, o.buf[off-o.bufOff:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
 // SelectObjectContentHandler - GET Object?select
 // ----------
 // This implementation of the GET operation retrieves object content based
@@ -115,7 +189,7 @@ func (api objectAPIHandlers) SelectObjectContentHandler(w http.ResponseWriter, r
 			if globalPolicySys.IsAllowed(policy.Args{
 				Action:          policy.ListBucketAction,
 				BucketName:      bucket,
-				ConditionValues: getConditionValues(r, ""),
+				ConditionValues: getConditionValues(r, "", ""),
 				IsOwner:         false,
 			}) {
 				getObjectInfo := objectAPI.GetObjectInfo
@@ -173,6 +247,17 @@ func (api objectAPIHandlers) SelectObjectContentHandler(w http.ResponseWriter, r
 
 	objInfo := gr.ObjInfo
 
+	if selectReq.InputSerialization.Parquet != nil {
+		// Parquet files are compressed internally, the compression type
+		// only applies to CSV and JSON objects.
+		switch selectReq.InputSerialization.CompressionType {
+		case "", s3select.SelectCompressionNONE:
+			selectReq.InputSerialization.CompressionType = s3select.SelectCompressionNONE
+		default:
+			writeErrorResponse(w, ErrInvalidCompressionFormat, r.URL)
+			return
+		}
+	}
 	if selectReq.InputSerialization.CompressionType == s3select.SelectCompressionGZIP {
 		if !strings.Contains(objInfo.ContentType, "gzip") {
 			writeErrorResponse(w, ErrInvalidDataSource, r.URL)
@@ -200,7 +285,8 @@ func (api objectAPIHandlers) SelectObjectContentHandler(w http.ResponseWriter, r
 		writeErrorResponse(w, ErrExpressionTooLong, r.URL)
 		return
 	}
-	if selectReq.InputSerialization.CSV == nil && selectReq.InputSerialization.JSON == nil {
+	if selectReq.InputSerialization.CSV == nil && selectReq.InputSerialization.JSON == nil &&
+		selectReq.InputSerialization.Parquet == nil {
 		writeErrorResponse(w, ErrInvalidRequestParameter, r.URL)
 		return
 	}
@@ -217,7 +303,8 @@ func (api objectAPIHandlers) SelectObjectContentHandler(w http.ResponseWriter, r
 			writeErrorResponse(w, ErrInvalidFileHeaderInfo, r.URL)
 			return
 		}
-		if selectReq.OutputSerialization.CSV.QuoteFields != s3select.CSVQuoteFieldsAlways &&
+		if selectReq.OutputSerialization.CSV != nil &&
+			selectReq.OutputSerialization.CSV.QuoteFields != s3select.CSVQuoteFieldsAlways &&
 			selectReq.OutputSerialization.CSV.QuoteFields != s3select.CSVQuoteFieldsAsNeeded &&
 			selectReq.OutputSerialization.CSV.QuoteFields != "" {
 			writeErrorResponse(w, ErrInvalidQuoteFields, r.URL)
@@ -229,6 +316,12 @@ func (api objectAPIHandlers) SelectObjectContentHandler(w http.ResponseWriter, r
 		}
 
 	}
+	if selectReq.OutputSerialization.JSON != nil {
+		if len(selectReq.OutputSerialization.JSON.RecordDelimiter) > 2 {
+			writeErrorResponse(w, ErrInvalidRequestParameter, r.URL)
+			return
+		}
+	}
 	if selectReq.InputSerialization.JSON != nil {
 		if selectReq.InputSerialization.JSON.Type != s3select.JSONTypeDocument &&
 			selectReq.InputSerialization.JSON.Type != s3select.JSONLinesType &&
@@ -252,10 +345,39 @@ func (api objectAPIHandlers) SelectObjectContentHandler(w http.ResponseWriter, r
 		}
 	}
 
-	reader := readahead.NewReader(gr)
-	defer reader.Close()
+	var s3s format.Select
+	if selectReq.InputSerialization.Parquet != nil || selectReq.ScanRange != nil {
+		// Parquet files are read from their footer, only the needed
+		// column chunks are then fetched with ranged reads. Scan ranges
+		// are read from the record preceding the range. The reader
+		// opened above keeps the object read locked meanwhile.
+		var size int64
+		switch {
+		case crypto.IsEncrypted(objInfo.UserDefined):
+			if size, err = objInfo.DecryptedSize(); err != nil {
+				writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+				return
+			}
+		case objInfo.IsCompressed():
+			size = objInfo.GetActualSize()
+		default:
+			size = objInfo.Size
+		}
+		objReader := &objectReaderAt{
+			ctx:            ctx,
+			getObjectNInfo: getObjectNInfo,
+			bucket:         bucket,
+			object:         object,
+			header:         r.Header,
+			size:           size,
+		}
+		s3s, err = s3select.New(io.NewSectionReader(objReader, 0, size), size, selectReq)
+	} else {
+		reader := readahead.NewReader(gr)
+		defer reader.Close()
 
-	s3s, err := s3select.New(reader, objInfo.GetActualSize(), selectReq)
+		s3s, err = s3select.New(reader, objInfo.GetActualSize(), selectReq)
+	}
 	if err != nil {
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
@@ -338,7 +460,7 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 			if globalPolicySys.IsAllowed(policy.Args{
 				Action:          policy.ListBucketAction,
 				BucketName:      bucket,
-				ConditionValues: getConditionValues(r, ""),
+				ConditionValues: getConditionValues(r, "", ""),
 				IsOwner:         false,
 			}) {
 				getObjectInfo := objectAPI.GetObjectInfo
@@ -417,6 +539,7 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		writeErrorResponse(w, toAPIErrorCode(hErr), r.URL)
 		return
 	}
+	setObjectChecksumHeaders(w, r, objInfo, rs)
 
 	setHeadGetRespHeaders(w, r.URL.Query())
 
@@ -507,7 +630,7 @@ func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 			if globalPolicySys.IsAllowed(policy.Args{
 				Action:          policy.ListBucketAction,
 				BucketName:      bucket,
-				ConditionValues: getConditionValues(r, ""),
+				ConditionValues: getConditionValues(r, "", ""),
 				IsOwner:         false,
 			}) {
 				_, err := getObjectInfo(ctx, bucket, object, opts)
@@ -579,6 +702,7 @@ func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 		writeErrorResponseHeadersOnly(w, toAPIErrorCode(hErr))
 		return
 	}
+	setObjectChecksumHeaders(w, r, objInfo, rs)
 
 	// Set any additional requested response headers.
 	setHeadGetRespHeaders(w, r.URL.Query())
@@ -1068,7 +1192,7 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 	/// if Content-Length is unknown/missing, deny the request
 	size := r.ContentLength
 	rAuthType := getRequestAuthType(r)
-	if rAuthType == authTypeStreamingSigned {
+	if rAuthType == authTypeStreamingSigned || rAuthType == authTypeStreamingUnsignedTrailer {
 		if sizeStr, ok := r.Header["X-Amz-Decoded-Content-Length"]; ok {
 			if sizeStr[0] == "" {
 				writeErrorResponse(w, ErrMissingContentLength, r.URL)
@@ -1098,7 +1222,7 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if rAuthType == authTypeStreamingSigned {
+	if rAuthType == authTypeStreamingSigned || rAuthType == authTypeStreamingUnsignedTrailer {
 		if contentEncoding, ok := metadata["content-encoding"]; ok {
 			contentEncoding = trimAwsChunkedContentEncoding(contentEncoding)
 			if contentEncoding != "" {
@@ -1141,6 +1265,13 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 			writeErrorResponse(w, s3Err, r.URL)
 			return
 		}
+	case authTypeStreamingUnsignedTrailer:
+		// Initialize unsigned stream reader, parsing the trailer.
+		reader, s3Err = newUnsignedV4ChunkedReader(r)
+		if s3Err != ErrNone {
+			writeErrorResponse(w, s3Err, r.URL)
+			return
+		}
 	case authTypeSignedV2, authTypePresignedV2:
 		s3Err = isReqAuthenticatedV2(r)
 		if s3Err != ErrNone {
@@ -1149,7 +1280,7 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		}
 
 	case authTypePresigned, authTypeSigned:
-		if s3Err = reqSignatureV4Verify(r, globalServerConfig.GetRegion()); s3Err != ErrNone {
+		if s3Err = reqSignatureV4Verify(r, globalServerConfig.GetRegion(), serviceS3); s3Err != ErrNone {
 			writeErrorResponse(w, s3Err, r.URL)
 			return
 		}
@@ -1159,6 +1290,8 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 	}
 
 	actualSize := size
+	// Reader verifying the additional checksum of the plain content.
+	var checksumReader *hash.Reader
 
 	if objectAPI.IsCompressionSupported() && isCompressible(r.Header, object) && size > 0 {
 		// Storing the compression metadata.
@@ -1174,10 +1307,19 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 			return
 		}
+		if err = addRequestChecksum(r, actualReader); err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+		checksumReader = actualReader
 
 		go func() {
 			// Writing to the compressed writer.
 			_, cerr := io.CopyN(snappyWriter, actualReader, actualSize)
+			if cerr == nil {
+				// CopyN stops before EOF, verify the content explicitly.
+				cerr = actualReader.Verify()
+			}
 			snappyWriter.Close()
 			pipeWriter.CloseWithError(cerr)
 		}()
@@ -1194,6 +1336,15 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
+	if checksumReader == nil {
+		if err = addRequestChecksum(r, hashReader); err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+		checksumReader = hashReader
+	} else {
+		hashReader.InheritChecksum(checksumReader)
+	}
 	opts := ObjectOptions{}
 	// Deny if WORM is enabled
 	if globalWORMEnabled {
@@ -1216,6 +1367,7 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 				writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 				return
 			}
+			hashReader.InheritChecksum(checksumReader)
 		}
 	}
 
@@ -1239,6 +1391,9 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 	}
 
 	w.Header().Set("ETag", "\""+objInfo.ETag+"\"")
+	if checksum := getObjectChecksum(objInfo); checksum != nil {
+		w.Header().Set(checksum.Type.Key(), checksum.Encoded)
+	}
 	if objectAPI.IsEncryptionSupported() {
 		if crypto.IsEncrypted(objInfo.UserDefined) {
 			switch {
@@ -1294,6 +1449,10 @@ func (api objectAPIHandlers) NewMultipartUploadHandler(w http.ResponseWriter, r
 		writeErrorResponse(w, ErrNotImplemented, r.URL) // SSE-KMS is not supported
 		return
 	}
+	if isRequestChecksumSent(r) {
+		writeErrorResponse(w, ErrNotImplemented, r.URL) // Additional checksums of multipart uploads are not supported
+		return
+	}
 
 	vars := mux.Vars(r)
 	bucket := vars["bucket"]
@@ -1620,6 +1779,10 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		writeErrorResponse(w, ErrNotImplemented, r.URL) // SSE-KMS is not supported
 		return
 	}
+	if isRequestChecksumSent(r) {
+		writeErrorResponse(w, ErrNotImplemented, r.URL) // Additional checksums of parts are not supported
+		return
+	}
 
 	vars := mux.Vars(r)
 	bucket := vars["bucket"]
@@ -1643,7 +1806,7 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 
 	rAuthType := getRequestAuthType(r)
 	// For auth type streaming signature, we need to gather a different content length.
-	if rAuthType == authTypeStreamingSigned {
+	if rAuthType == authTypeStreamingSigned || rAuthType == authTypeStreamingUnsignedTrailer {
 		if sizeStr, ok := r.Header["X-Amz-Decoded-Content-Length"]; ok {
 			if sizeStr[0] == "" {
 				writeErrorResponse(w, ErrMissingContentLength, r.URL)
@@ -1703,13 +1866,20 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
+	case authTypeStreamingUnsignedTrailer:
+		// Initialize unsigned stream reader, parsing the trailer.
+		reader, s3Error = newUnsignedV4ChunkedReader(r)
+		if s3Error != ErrNone {
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
 	case authTypeSignedV2, authTypePresignedV2:
 		if s3Error = isReqAuthenticatedV2(r); s3Error != ErrNone {
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
 	case authTypePresigned, authTypeSigned:
-		if s3Error = reqSignatureV4Verify(r, globalServerConfig.GetRegion()); s3Error != ErrNone {
+		if s3Error = reqSignatureV4Verify(r, globalServerConfig.GetRegion(), serviceS3); s3Error != ErrNone {
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
@@ -1747,6 +1917,10 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		go func() {
 			// Writing to the compressed writer.
 			_, cerr := io.CopyN(snappyWriter, actualReader, actualSize)
+			if cerr == nil {
+				// CopyN stops before EOF, verify the content explicitly.
+				cerr = actualReader.Verify()
+			}
 			snappyWriter.Close()
 			pipeWriter.CloseWithError(cerr)
 		}()
@@ -1764,7 +1938,6 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
-
 	opts := ObjectOptions{}
 	// Deny if WORM is enabled
 	if globalWORMEnabled {
@@ -1845,7 +2018,6 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 	if partInfo.ETag != "" {
 		w.Header().Set("ETag", "\""+partInfo.ETag+"\"")
 	}
-
 	writeSuccessResponseHeadersOnly(w)
 }
 
@@ -1956,6 +2128,10 @@ func (api objectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
 		return
 	}
+	if isRequestChecksumSent(r) {
+		writeErrorResponse(w, ErrNotImplemented, r.URL) // Additional checksums of multipart uploads are not supported
+		return
+	}
 
 	if s3Error := checkRequestAuthType(ctx, r, policy.PutObjectAction, bucket, object); s3Error != ErrNone {
 		writeErrorResponse(w, s3Error, r.URL)
@@ -3845,3 +3845,52 @@ func testAPIListObjectPartsHandler(obj ObjectLayer, instanceType, bucketName str
 	// `ExecObjectLayerAPINilTest` sets the Object Layer to `nil` and calls the handler.
 	ExecObjectLayerAPINilTest(t, nilBucket, nilObject, instanceType, apiRouter, nilReq)
 }
+
+// Tests the buffered ranged reads of objectReaderAt.
+func TestObjectReaderAt(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), objectReaderAtBufferSize/5)
+	var gets int
+	objReader := &objectReaderAt{
+		ctx: context.Background(),
+		getObjectNInfo: func(ctx context.Context, bucket, object string, rs *HTTPRangeSpec, h http.Header, lockType LockType, opts ObjectOptions) (*GetObjectReader, error) {
+			if lockType != noLock {
+				t.Fatalf("expected ranged reads without lock, got %v", lockType)
+			}
+			gets++
+			return NewGetObjectReaderFromReader(bytes.NewReader(data[rs.Start:rs.End+1]), ObjectInfo{}), nil
+		},
+		size: int64(len(data)),
+	}
+
+	testCases := []struct {
+		off, length int64
+		n           int
+		err         error
+		gets        int
+	}{
+		{0, 10, 10, nil, 1},
+		// Served from the buffer.
+		{10, 100, 100, nil, 1},
+		{objectReaderAtBufferSize - 10, 10, 10, nil, 1},
+		// Crosses the end of the buffer.
+		{objectReaderAtBufferSize - 5, 10, 10, nil, 2},
+		// Larger than the buffer.
+		{0, objectReaderAtBufferSize + 1, objectReaderAtBufferSize + 1, nil, 3},
+		// Crosses the end of the object.
+		{int64(len(data)) - 5, 10, 5, io.EOF, 4},
+		{int64(len(data)), 10, 0, io.EOF, 4},
+	}
+	for i, testCase := range testCases {
+		p := make([]byte, testCase.length)
+		n, err := objReader.ReadAt(p, testCase.off)
+		if n != testCase.n || err != testCase.err {
+			t.Fatalf("Test %d: expected %d, %v, got %d, %v", i+1, testCase.n, testCase.err, n, err)
+		}
+		if !bytes.Equal(p[:n], data[testCase.off:testCase.off+int64(n)]) {
+			t.Fatalf("Test %d: unexpected data", i+1)
+		}
+		if gets != testCase.gets {
+			t.Fatalf("Test %d: expected %d range requests, got %d", i+1, testCase.gets, gets)
+		}
+	}
+}
@@ -19,11 +19,15 @@ package cmd
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
+	"net/url"
 
 	"github.com/pydio/minio-srv/cmd/logger"
+	"github.com/pydio/minio-srv/cmd/logger/message/log"
 	"github.com/pydio/minio-srv/pkg/event"
 	xnet "github.com/pydio/minio-srv/pkg/net"
 	"github.com/pydio/minio-srv/pkg/policy"
+	"github.com/pydio/minio-srv/pkg/trace"
 )
 
 // PeerRPCClient - peer RPC client talks to peer RPC server.
@@ -123,6 +127,60 @@ func (rpcClient *PeerRPCClient) LoadCredentials() error {
 	return rpcClient.Call(peerServiceName+".SetCredentials", &args, &reply)
 }
 
+// Trace - streams the HTTP trace records of the peer matching opts to
+// traceCh, until doneCh is closed or the stream fails.
+func (rpcClient *PeerRPCClient) Trace(traceCh chan interface{}, doneCh <-chan struct{}, opts traceOptions) error {
+	return rpcClient.stream(traceCh, doneCh, peerTraceSubPath, opts.values(), func(dec *json.Decoder) (interface{}, error) {
+		var info trace.Info
+		err := dec.Decode(&info)
+		return info, err
+	})
+}
+
+// ConsoleLog - streams the log entries of the peer matching opts to
+// logCh, until doneCh is closed or the stream fails.
+func (rpcClient *PeerRPCClient) ConsoleLog(logCh chan interface{}, doneCh <-chan struct{}, opts consoleLogOptions) error {
+	return rpcClient.stream(logCh, doneCh, peerConsoleLogSubPath, opts.values(), func(dec *json.Decoder) (interface{}, error) {
+		var entry log.Entry
+		err := dec.Decode(&entry)
+		return entry, err
+	})
+}
+
+// stream - sends the JSON objects streamed by the peer at subPath, as
+// returned by decode, to ch until doneCh is closed or the stream fails.
+func (rpcClient *PeerRPCClient) stream(ch chan interface{}, doneCh <-chan struct{}, subPath string, values url.Values,
+	decode func(*json.Decoder) (interface{}, error)) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-doneCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	body, err := rpcClient.Stream(ctx, subPath, values)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	dec := json.NewDecoder(body)
+	for {
+		v, err := decode(dec)
+		if err != nil {
+			return err
+		}
+		select {
+		case ch <- v:
+		default:
+			// The listener is too slow, drop the entry.
+		}
+	}
+}
+
 // NewPeerRPCClient - returns new peer RPC client.
 func NewPeerRPCClient(host *xnet.Host) (*PeerRPCClient, error) {
 	scheme := "http"
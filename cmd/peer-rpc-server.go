@@ -19,6 +19,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"path"
 
 	"github.com/gorilla/mux"
@@ -32,6 +33,12 @@ import (
 const peerServiceName = "Peer"
 const peerServiceSubPath = "/s3/remote"
 
+// Sub path of the peer service streaming HTTP trace records.
+const peerTraceSubPath = "/trace"
+
+// Sub path of the peer service streaming log entries.
+const peerConsoleLogSubPath = "/log"
+
 var peerServicePath = path.Join(minioReservedBucketPath, peerServiceSubPath)
 
 // peerRPCReceiver - Peer RPC receiver for peer RPC server.
@@ -214,6 +221,50 @@ func (receiver *peerRPCReceiver) LoadCredentials(args *AuthArgs, reply *VoidRepl
 	return globalConfigSys.Load(newObjectLayerFn())
 }
 
+// peerTraceHandler - streams the HTTP trace records of this node matching
+// the options in the query to a peer serving an admin trace request.
+func peerTraceHandler(w http.ResponseWriter, r *http.Request) {
+	if _, owner, err := webRequestAuthenticate(r); err != nil || !owner {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	opts, err := parseTraceOptions(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	traceCh := make(chan interface{}, traceChannelSize)
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	subscribeTrace(traceCh, doneCh, opts)
+
+	writeTraceStream(w, r, traceCh, opts)
+}
+
+// peerConsoleLogHandler - streams the log entries of this node matching
+// the options in the query to a peer serving an admin log request.
+func peerConsoleLogHandler(w http.ResponseWriter, r *http.Request) {
+	if _, owner, err := webRequestAuthenticate(r); err != nil || !owner {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	opts, err := parseConsoleLogOptions(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	logCh := make(chan interface{}, consoleLogChannelSize)
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	subscribeConsoleLog(logCh, doneCh, opts)
+
+	writeConsoleLogStream(w, r, logCh)
+}
+
 // NewPeerRPCServer - returns new peer RPC server.
 func NewPeerRPCServer() (*xrpc.Server, error) {
 	rpcServer := xrpc.NewServer()
@@ -229,4 +280,7 @@ func registerPeerRPCRouter(router *mux.Router) {
 	logger.FatalIf(err, "Unable to initialize peer RPC Server")
 	subrouter := router.PathPrefix(minioReservedBucketPath).Subrouter()
 	subrouter.Path(peerServiceSubPath).HandlerFunc(httpTraceHdrs(rpcServer.ServeHTTP))
+	// Trace and log streams are not traced themselves.
+	subrouter.Path(peerServiceSubPath + peerTraceSubPath).HandlerFunc(peerTraceHandler)
+	subrouter.Path(peerServiceSubPath + peerConsoleLogSubPath).HandlerFunc(peerConsoleLogHandler)
 }
@@ -21,7 +21,9 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -189,7 +191,9 @@ func NewPolicySys() *PolicySys {
 	}
 }
 
-func getConditionValues(request *http.Request, locationConstraint string) map[string][]string {
+// getConditionValues - returns the values of the condition keys of policies
+// for request of the user username, empty for anonymous requests.
+func getConditionValues(request *http.Request, locationConstraint string, username string) map[string][]string {
 	args := make(map[string][]string)
 
 	for key, values := range request.Header {
@@ -208,7 +212,21 @@ func getConditionValues(request *http.Request, locationConstraint string) map[st
 		}
 	}
 
+	// Values set from the request itself override the headers and
+	// query parameters of the same name.
 	args["SourceIp"] = []string{handlers.GetSourceIP(request)}
+	args["CurrentTime"] = []string{UTCNow().Format(time.RFC3339)}
+	args["SecureTransport"] = []string{strconv.FormatBool(request.TLS != nil)}
+	delete(args, "UserAgent")
+	if userAgent := request.UserAgent(); userAgent != "" {
+		args["UserAgent"] = []string{userAgent}
+	}
+	delete(args, "username")
+	delete(args, "userid")
+	if username != "" {
+		args["username"] = []string{username}
+		args["userid"] = []string{username}
+	}
 
 	if locationConstraint != "" {
 		args["LocationConstraint"] = []string{locationConstraint}
@@ -217,6 +235,27 @@ func getConditionValues(request *http.Request, locationConstraint string) map[st
 	return args
 }
 
+// getRequestObjectTags - returns the object tags set by the request in
+// the x-amz-tagging header.
+func getRequestObjectTags(request *http.Request) map[string]string {
+	tagging := request.Header.Get("X-Amz-Tagging")
+	if tagging == "" {
+		return nil
+	}
+
+	values, err := url.ParseQuery(tagging)
+	if err != nil {
+		return nil
+	}
+
+	tags := make(map[string]string, len(values))
+	for key, value := range values {
+		tags[key] = value[0]
+	}
+
+	return tags
+}
+
 // getPolicyConfig - get policy config for given bucket name.
 func getPolicyConfig(objAPI ObjectLayer, bucketName string) (*policy.Policy, error) {
 	// Construct path to policy.json for the given bucket.
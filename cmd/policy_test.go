@@ -17,8 +17,11 @@
 package cmd
 
 import (
+	"crypto/tls"
+	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	miniogopolicy "github.com/pydio/minio-go/pkg/policy"
 	"github.com/pydio/minio-go/pkg/set"
@@ -422,3 +425,73 @@ func TestBucketAccessPolicyToPolicy(t *testing.T) {
 		}
 	}
 }
+
+// Test condition values set from the request, they cannot be overridden
+// by headers or query parameters.
+func TestGetConditionValues(t *testing.T) {
+	testCases := []struct {
+		url       string
+		userAgent string
+		username  string
+		isTLS     bool
+		expected  map[string][]string
+	}{
+		{"http://localhost/bucket?max-keys=10", "MinioClient", "alice", true, map[string][]string{
+			"max-keys":        {"10"},
+			"UserAgent":       {"MinioClient"},
+			"username":        {"alice"},
+			"userid":          {"alice"},
+			"SecureTransport": {"true"},
+		}},
+		{"http://localhost/bucket?username=admin&userid=admin&SecureTransport=true", "", "", false, map[string][]string{
+			"SecureTransport": {"false"},
+		}},
+	}
+
+	for i, testCase := range testCases {
+		req, err := http.NewRequest(http.MethodGet, testCase.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("User-Agent", testCase.userAgent)
+		if testCase.isTLS {
+			req.TLS = &tls.ConnectionState{}
+		}
+
+		values := getConditionValues(req, "", testCase.username)
+		if _, err = time.Parse(time.RFC3339, values["CurrentTime"][0]); err != nil {
+			t.Errorf("Test %d: invalid CurrentTime %v", i+1, values["CurrentTime"])
+		}
+		for _, key := range []string{"max-keys", "UserAgent", "username", "userid", "SecureTransport"} {
+			if !reflect.DeepEqual(values[key], testCase.expected[key]) {
+				t.Errorf("Test %d: %s: expected %v, got %v", i+1, key, testCase.expected[key], values[key])
+			}
+		}
+	}
+}
+
+func TestGetRequestObjectTags(t *testing.T) {
+	testCases := []struct {
+		tagging  string
+		expected map[string]string
+	}{
+		{"", nil},
+		{"project=alpha&team=storage", map[string]string{"project": "alpha", "team": "storage"}},
+		{"name=a%20b&empty=", map[string]string{"name": "a b", "empty": ""}},
+		{"%zz", nil},
+	}
+
+	for i, testCase := range testCases {
+		req, err := http.NewRequest(http.MethodPut, "http://localhost/bucket/object", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if testCase.tagging != "" {
+			req.Header.Set("X-Amz-Tagging", testCase.tagging)
+		}
+
+		if tags := getRequestObjectTags(req); !reflect.DeepEqual(tags, testCase.expected) {
+			t.Errorf("Test %d: expected %v, got %v", i+1, testCase.expected, tags)
+		}
+	}
+}
@@ -17,9 +17,12 @@
 package cmd
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
 	"net/url"
 	"sync"
 	"time"
@@ -240,6 +243,39 @@ func (client *RPCClient) Call(serviceMethod string, args interface {
 	return call()
 }
 
+// Stream - makes a streaming request to subPath of the service URL,
+// authenticated by the same token as RPC calls. The caller must close
+// the returned body.
+func (client *RPCClient) Stream(ctx context.Context, subPath string, values url.Values) (io.ReadCloser, error) {
+	stream := func() (*http.Response, error) {
+		client.RLock()
+		authToken := client.authToken
+		client.RUnlock()
+
+		header := http.Header{}
+		header.Set("Authorization", "Bearer "+authToken)
+		return client.rpcClient.Stream(ctx, subPath, values, header)
+	}
+
+	resp, err := stream()
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		// Retry only once with new authentication token.
+		resp.Body.Close()
+		client.Lock()
+		client.authToken = client.args.NewAuthTokenFunc()
+		client.Unlock()
+		resp, err = stream()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%v stream failed with error code %v", subPath, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
 // Close - closes underneath RPC client.
 func (client *RPCClient) Close() error {
 	client.Lock()
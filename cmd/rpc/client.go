@@ -26,6 +26,8 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
+	"path"
 	"reflect"
 	"time"
 
@@ -111,6 +113,25 @@ func (client *Client) Call(serviceMethod string, args, reply interface{}) error
 	return gobDecode(callResponse.ReplyBytes, reply)
 }
 
+// Stream - makes a streaming request to subPath of the service URL,
+// with the given query and headers. The caller must close the body of
+// the returned response.
+func (client *Client) Stream(ctx context.Context, subPath string, values url.Values, header http.Header) (*http.Response, error) {
+	u := url.URL(*client.serviceURL)
+	u.Path = path.Join(u.Path, subPath)
+	u.RawQuery = values.Encode()
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range header {
+		req.Header[key] = value
+	}
+
+	return client.httpClient.Do(req.WithContext(ctx))
+}
+
 // Close - does nothing and presents for interface compatibility.
 func (client *Client) Close() error {
 	return nil
@@ -75,7 +75,9 @@ func doesPolicySignatureV2Match(formValues http.Header) APIErrorCode {
 	}
 	policy := formValues.Get("Policy")
 	signature := formValues.Get("Signature")
-	if !compareSignatureV2(signature, calculateSignatureV2(policy, cred.SecretKey)) {
+	if _, ok := matchCredSecretKey(cred, func(cred auth.Credentials) bool {
+		return compareSignatureV2(signature, calculateSignatureV2(policy, cred.SecretKey))
+	}); !ok {
 		return ErrSignatureDoesNotMatch
 	}
 	return ErrNone
@@ -168,8 +170,10 @@ func doesPresignV2SignatureMatch(r *http.Request) APIErrorCode {
 		return ErrInvalidRequest
 	}
 
-	expectedSignature := preSignatureV2(cred, r.Method, encodedResource, strings.Join(filteredQueries, "&"), r.Header, expires)
-	if !compareSignatureV2(gotSignature, expectedSignature) {
+	if _, ok := matchCredSecretKey(cred, func(cred auth.Credentials) bool {
+		expectedSignature := preSignatureV2(cred, r.Method, encodedResource, strings.Join(filteredQueries, "&"), r.Header, expires)
+		return compareSignatureV2(gotSignature, expectedSignature)
+	}); !ok {
 		return ErrSignatureDoesNotMatch
 	}
 
@@ -267,8 +271,10 @@ func doesSignV2Match(r *http.Request) APIErrorCode {
 		return ErrSignatureDoesNotMatch
 	}
 	v2Auth = v2Auth[len(prefix):]
-	expectedAuth := signatureV2(cred, r.Method, encodedResource, strings.Join(unescapedQueries, "&"), r.Header)
-	if !compareSignatureV2(v2Auth, expectedAuth) {
+	if _, ok := matchCredSecretKey(cred, func(cred auth.Credentials) bool {
+		expectedAuth := signatureV2(cred, r.Method, encodedResource, strings.Join(unescapedQueries, "&"), r.Header)
+		return compareSignatureV2(v2Auth, expectedAuth)
+	}); !ok {
 		return ErrSignatureDoesNotMatch
 	}
 	return ErrNone
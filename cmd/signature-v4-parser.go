@@ -107,7 +107,9 @@ func parseCredentialHeader(credElement string, region string) (ch credentialHead
 		return ch, ErrAuthorizationHeaderMalformed
 
 	}
-	if credElements[2] != "s3" {
+	switch serviceType(credElements[2]) {
+	case serviceS3, serviceSTS:
+	default:
 		return ch, ErrInvalidService
 	}
 	cred.scope.service = credElements[2]
@@ -142,7 +142,7 @@ func TestParseCredentialHeader(t *testing.T) {
 		},
 		// Test Case - 6.
 		// Test case with invalid service.
-		// "s3" is the valid service string.
+		// "s3" and "sts" are the valid service strings.
 		{
 			inputCredentialStr: generateCredentialStr(
 				"Z7IXGOO6BZ0REAN1Q26I",
@@ -216,6 +216,24 @@ func TestParseCredentialHeader(t *testing.T) {
 				"aws4_request"),
 			expectedErrCode: ErrNone,
 		},
+		// Test Case - 11.
+		// Test case with right inputs for the STS API.
+		{
+			inputCredentialStr: generateCredentialStr(
+				"Z7IXGOO6BZ0REAN1Q26I",
+				sampleTimeStr,
+				"us-west-1",
+				"sts",
+				"aws4_request"),
+			expectedCredentials: generateCredentials(
+				t,
+				"Z7IXGOO6BZ0REAN1Q26I",
+				sampleTimeStr,
+				"us-west-1",
+				"sts",
+				"aws4_request"),
+			expectedErrCode: ErrNone,
+		},
 	}
 
 	for i, testCase := range testCases {
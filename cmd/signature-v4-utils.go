@@ -120,6 +120,24 @@ func checkKeyValid(accessKey string) (auth.Credentials, bool, APIErrorCode) {
 	return cred, owner, ErrNone
 }
 
+// matchCredSecretKey - returns the credential which secret key passes
+// match, trying the secret key of cred and then, during the grace period
+// of a rotation, its previous secret key. A match is recorded as the last
+// use of the access key.
+func matchCredSecretKey(cred auth.Credentials, match func(cred auth.Credentials) bool) (auth.Credentials, bool) {
+	for _, secretKey := range cred.SecretKeys() {
+		candidate := cred
+		candidate.SecretKey = secretKey
+		if match(candidate) {
+			if globalIAMSys != nil {
+				globalIAMSys.UpdateLastUsed(cred.AccessKey)
+			}
+			return candidate, true
+		}
+	}
+	return cred, false
+}
+
 // sumHMAC calculate hmac between two input byte array.
 func sumHMAC(key []byte, data []byte) []byte {
 	hash := hmac.New(sha256.New, key)
@@ -37,6 +37,7 @@ import (
 
 	sha256 "github.com/minio/sha256-simd"
 	"github.com/pydio/minio-go/pkg/s3utils"
+	"github.com/pydio/minio-srv/pkg/auth"
 )
 
 // AWS Signature Version '4' constants.
@@ -46,6 +47,14 @@ const (
 	yyyymmdd        = "20060102"
 )
 
+// serviceType - service of the credential scope of a signature.
+type serviceType string
+
+const (
+	serviceS3  serviceType = "s3"
+	serviceSTS serviceType = "sts"
+)
+
 // getCanonicalHeaders generate a list of request headers with their values
 func getCanonicalHeaders(signedHeaders http.Header) string {
 	var headers []string
@@ -110,7 +119,7 @@ func getScope(t time.Time, region string) string {
 	scope := strings.Join([]string{
 		t.Format(yyyymmdd),
 		region,
-		"s3",
+		string(serviceS3),
 		"aws4_request",
 	}, "/")
 	return scope
@@ -127,9 +136,15 @@ func getStringToSign(canonicalRequest string, t time.Time, scope string) string
 
 // getSigningKey hmac seed to calculate final signature.
 func getSigningKey(secretKey string, t time.Time, region string) []byte {
+	return getServiceSigningKey(secretKey, t, region, serviceS3)
+}
+
+// getServiceSigningKey hmac seed to calculate final signature of the requests
+// of the given service.
+func getServiceSigningKey(secretKey string, t time.Time, region string, stype serviceType) []byte {
 	date := sumHMAC([]byte("AWS4"+secretKey), []byte(t.Format(yyyymmdd)))
 	regionBytes := sumHMAC(date, []byte(region))
-	service := sumHMAC(regionBytes, []byte("s3"))
+	service := sumHMAC(regionBytes, []byte(stype))
 	signingKey := sumHMAC(service, []byte("aws4_request"))
 	return signingKey
 }
@@ -175,14 +190,16 @@ func doesPolicySignatureV4Match(formValues http.Header) APIErrorCode {
 		return s3Err
 	}
 
-	// Get signing key.
-	signingKey := getSigningKey(cred.SecretKey, credHeader.scope.date, credHeader.scope.region)
+	// Verify signature.
+	if _, ok := matchCredSecretKey(cred, func(cred auth.Credentials) bool {
+		// Get signing key.
+		signingKey := getSigningKey(cred.SecretKey, credHeader.scope.date, credHeader.scope.region)
 
-	// Get signature.
-	newSignature := getSignature(signingKey, formValues.Get("Policy"))
+		// Get signature.
+		newSignature := getSignature(signingKey, formValues.Get("Policy"))
 
-	// Verify signature.
-	if !compareSignatureV4(newSignature, formValues.Get("X-Amz-Signature")) {
+		return compareSignatureV4(newSignature, formValues.Get("X-Amz-Signature"))
+	}); !ok {
 		return ErrSignatureDoesNotMatch
 	}
 
@@ -290,14 +307,16 @@ func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, region s
 	// Get string to sign from canonical request.
 	presignedStringToSign := getStringToSign(presignedCanonicalReq, t, pSignValues.Credential.getScope())
 
-	// Get hmac presigned signing key.
-	presignedSigningKey := getSigningKey(cred.SecretKey, pSignValues.Credential.scope.date, pSignValues.Credential.scope.region)
+	// Verify signature.
+	if _, ok := matchCredSecretKey(cred, func(cred auth.Credentials) bool {
+		// Get hmac presigned signing key.
+		presignedSigningKey := getSigningKey(cred.SecretKey, pSignValues.Credential.scope.date, pSignValues.Credential.scope.region)
 
-	// Get new signature.
-	newSignature := getSignature(presignedSigningKey, presignedStringToSign)
+		// Get new signature.
+		newSignature := getSignature(presignedSigningKey, presignedStringToSign)
 
-	// Verify signature.
-	if !compareSignatureV4(req.URL.Query().Get("X-Amz-Signature"), newSignature) {
+		return compareSignatureV4(req.URL.Query().Get("X-Amz-Signature"), newSignature)
+	}); !ok {
 		return ErrSignatureDoesNotMatch
 	}
 	return ErrNone
@@ -305,8 +324,9 @@ func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, region s
 
 // doesSignatureMatch - Verify authorization header with calculated header in accordance with
 //     - http://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html
-// returns ErrNone if signature matches.
-func doesSignatureMatch(hashedPayload string, r *http.Request, region string) APIErrorCode {
+// returns ErrNone if signature matches. The credential scope must be the one
+// of the stype service.
+func doesSignatureMatch(hashedPayload string, r *http.Request, region string, stype serviceType) APIErrorCode {
 	// Copy request.
 	req := *r
 
@@ -318,6 +338,9 @@ func doesSignatureMatch(hashedPayload string, r *http.Request, region string) AP
 	if err != ErrNone {
 		return err
 	}
+	if signV4Values.Credential.scope.service != string(stype) {
+		return ErrInvalidService
+	}
 
 	// Extract all the signed headers along with its values.
 	extractedSignedHeaders, errCode := extractSignedHeaders(signV4Values.SignedHeaders, r)
@@ -353,14 +376,16 @@ func doesSignatureMatch(hashedPayload string, r *http.Request, region string) AP
 	// Get string to sign from canonical request.
 	stringToSign := getStringToSign(canonicalRequest, t, signV4Values.Credential.getScope())
 
-	// Get hmac signing key.
-	signingKey := getSigningKey(cred.SecretKey, signV4Values.Credential.scope.date, signV4Values.Credential.scope.region)
+	// Verify if signature match.
+	if _, ok := matchCredSecretKey(cred, func(cred auth.Credentials) bool {
+		// Get hmac signing key.
+		signingKey := getServiceSigningKey(cred.SecretKey, signV4Values.Credential.scope.date, signV4Values.Credential.scope.region, stype)
 
-	// Calculate signature.
-	newSignature := getSignature(signingKey, stringToSign)
+		// Calculate signature.
+		newSignature := getSignature(signingKey, stringToSign)
 
-	// Verify if signature match.
-	if !compareSignatureV4(newSignature, signV4Values.Signature) {
+		return compareSignatureV4(newSignature, signV4Values.Signature)
+	}); !ok {
 		return ErrSignatureDoesNotMatch
 	}
 
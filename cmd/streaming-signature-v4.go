@@ -26,6 +26,7 @@ import (
 	"hash"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	humanize "github.com/dustin/go-humanize"
@@ -35,10 +36,16 @@ import (
 
 // Streaming AWS Signature Version '4' constants.
 const (
-	emptySHA256              = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
-	streamingContentSHA256   = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
-	signV4ChunkedAlgorithm   = "AWS4-HMAC-SHA256-PAYLOAD"
-	streamingContentEncoding = "aws-chunked"
+	emptySHA256                   = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	streamingContentSHA256        = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	streamingContentSHA256Trailer = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	streamingUnsignedTrailer      = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+	signV4ChunkedAlgorithm        = "AWS4-HMAC-SHA256-PAYLOAD"
+	signV4TrailerAlgorithm        = "AWS4-HMAC-SHA256-TRAILER"
+	streamingContentEncoding      = "aws-chunked"
+
+	// Trailing header carrying the signature of the other trailing headers.
+	trailerSignatureKey = "x-amz-trailer-signature"
 )
 
 // getChunkSignature - get chunk signature.
@@ -60,6 +67,23 @@ func getChunkSignature(cred auth.Credentials, seedSignature string, region strin
 	return newSignature
 }
 
+// getTrailerSignature - get the signature of the trailing headers, in their
+// canonical form "key:value\n".
+func getTrailerSignature(cred auth.Credentials, seedSignature string, region string, date time.Time, trailer []byte) string {
+	// Calculate string to sign.
+	stringToSign := signV4TrailerAlgorithm + "\n" +
+		date.Format(iso8601Format) + "\n" +
+		getScope(date, region) + "\n" +
+		seedSignature + "\n" +
+		getSHA256Hash(trailer)
+
+	// Get hmac signing key.
+	signingKey := getSigningKey(cred.SecretKey, date, region)
+
+	// Calculate signature.
+	return getSignature(signingKey, stringToSign)
+}
+
 // calculateSeedSignature - Calculate seed signature in accordance with
 //     - http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming.html
 // returns signature, error otherwise if the signature mismatches or any other
@@ -78,10 +102,11 @@ func calculateSeedSignature(r *http.Request) (cred auth.Credentials, signature s
 	}
 
 	// Payload streaming.
-	payload := streamingContentSHA256
+	payload := req.Header.Get("X-Amz-Content-Sha256")
 
-	// Payload for STREAMING signature should be 'STREAMING-AWS4-HMAC-SHA256-PAYLOAD'
-	if payload != req.Header.Get("X-Amz-Content-Sha256") {
+	// Payload for STREAMING signature should be 'STREAMING-AWS4-HMAC-SHA256-PAYLOAD',
+	// or 'STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER' with trailing headers.
+	if payload != streamingContentSHA256 && payload != streamingContentSHA256Trailer {
 		return cred, "", "", time.Time{}, ErrContentSHA256Mismatch
 	}
 
@@ -123,14 +148,19 @@ func calculateSeedSignature(r *http.Request) (cred auth.Credentials, signature s
 	// Get string to sign from canonical request.
 	stringToSign := getStringToSign(canonicalRequest, date, signV4Values.Credential.getScope())
 
-	// Get hmac signing key.
-	signingKey := getSigningKey(cred.SecretKey, signV4Values.Credential.scope.date, region)
+	// Verify if signature match, the chunks are signed with the
+	// secret key which matched.
+	var newSignature string
+	var ok bool
+	if cred, ok = matchCredSecretKey(cred, func(cred auth.Credentials) bool {
+		// Get hmac signing key.
+		signingKey := getSigningKey(cred.SecretKey, signV4Values.Credential.scope.date, region)
 
-	// Calculate signature.
-	newSignature := getSignature(signingKey, stringToSign)
+		// Calculate signature.
+		newSignature = getSignature(signingKey, stringToSign)
 
-	// Verify if signature match.
-	if !compareSignatureV4(newSignature, signV4Values.Signature) {
+		return compareSignatureV4(newSignature, signV4Values.Signature)
+	}); !ok {
 		return cred, "", "", time.Time{}, ErrSignatureDoesNotMatch
 	}
 
@@ -158,6 +188,13 @@ func newSignV4ChunkedReader(req *http.Request) (io.ReadCloser, APIErrorCode) {
 		return nil, errCode
 	}
 
+	var trailer http.Header
+	if req.Header.Get("X-Amz-Content-Sha256") == streamingContentSHA256Trailer {
+		if trailer, errCode = newRequestTrailer(req); errCode != ErrNone {
+			return nil, errCode
+		}
+	}
+
 	return &s3ChunkedReader{
 		reader:            bufio.NewReader(req.Body),
 		cred:              cred,
@@ -165,10 +202,33 @@ func newSignV4ChunkedReader(req *http.Request) (io.ReadCloser, APIErrorCode) {
 		seedDate:          seedDate,
 		region:            region,
 		chunkSHA256Writer: sha256.New(),
+		trailer:           trailer,
 		state:             readChunkHeader,
 	}, ErrNone
 }
 
+// newUnsignedV4ChunkedReader returns a new s3ChunkedReader which decodes
+// the unsigned chunks of a 'STREAMING-UNSIGNED-PAYLOAD-TRAILER' upload,
+// once the signature of the request itself is verified. The trailing
+// headers are set in req.Trailer when the last chunk is read.
+func newUnsignedV4ChunkedReader(req *http.Request) (io.ReadCloser, APIErrorCode) {
+	if errCode := doesSignatureMatch(streamingUnsignedTrailer, req, globalServerConfig.GetRegion(), serviceS3); errCode != ErrNone {
+		return nil, errCode
+	}
+
+	trailer, errCode := newRequestTrailer(req)
+	if errCode != ErrNone {
+		return nil, errCode
+	}
+
+	return &s3ChunkedReader{
+		reader:   bufio.NewReader(req.Body),
+		unsigned: true,
+		trailer:  trailer,
+		state:    readChunkHeader,
+	}, ErrNone
+}
+
 // Represents the overall state that is required for decoding a
 // AWS Signature V4 chunked reader.
 type s3ChunkedReader struct {
@@ -180,8 +240,10 @@ type s3ChunkedReader struct {
 	state             chunkState
 	lastChunk         bool
 	chunkSignature    string
-	chunkSHA256Writer hash.Hash // Calculates sha256 of chunk data.
-	n                 uint64    // Unread bytes in chunk
+	chunkSHA256Writer hash.Hash   // Calculates sha256 of chunk data.
+	n                 uint64      // Unread bytes in chunk
+	unsigned          bool        // Chunks are not signed.
+	trailer           http.Header // Trailing headers after the last chunk, if any.
 	err               error
 }
 
@@ -212,6 +274,7 @@ const (
 	readChunkTrailer
 	readChunk
 	verifyChunk
+	readTrailer
 	eofChunk
 )
 
@@ -226,6 +289,8 @@ func (cs chunkState) String() string {
 		stateString = "readChunk"
 	case verifyChunk:
 		stateString = "verifyChunk"
+	case readTrailer:
+		stateString = "readTrailer"
 	case eofChunk:
 		stateString = "eofChunk"
 
@@ -247,6 +312,11 @@ func (cr *s3ChunkedReader) Read(buf []byte) (n int, err error) {
 			// If we're at the end of a chunk.
 			if cr.n == 0 && cr.err == io.EOF {
 				cr.state = readChunkTrailer
+				// Trailing headers follow the last chunk
+				// in place of its CRLF.
+				if cr.trailer != nil {
+					cr.state = verifyChunk
+				}
 				cr.lastChunk = true
 				continue
 			}
@@ -282,7 +352,9 @@ func (cr *s3ChunkedReader) Read(buf []byte) (n int, err error) {
 			}
 
 			// Calculate sha256.
-			cr.chunkSHA256Writer.Write(rbuf[:n0])
+			if !cr.unsigned {
+				cr.chunkSHA256Writer.Write(rbuf[:n0])
+			}
 			// Update the bytes read into request buffer so far.
 			n += n0
 			buf = buf[n0:]
@@ -295,30 +367,92 @@ func (cr *s3ChunkedReader) Read(buf []byte) (n int, err error) {
 				continue
 			}
 		case verifyChunk:
-			// Calculate the hashed chunk.
-			hashedChunk := hex.EncodeToString(cr.chunkSHA256Writer.Sum(nil))
-			// Calculate the chunk signature.
-			newSignature := getChunkSignature(cr.cred, cr.seedSignature, cr.region, cr.seedDate, hashedChunk)
-			if !compareSignatureV4(cr.chunkSignature, newSignature) {
-				// Chunk signature doesn't match we return signature does not match.
-				cr.err = errSignatureMismatch
-				return 0, cr.err
+			if !cr.unsigned {
+				// Calculate the hashed chunk.
+				hashedChunk := hex.EncodeToString(cr.chunkSHA256Writer.Sum(nil))
+				// Calculate the chunk signature.
+				newSignature := getChunkSignature(cr.cred, cr.seedSignature, cr.region, cr.seedDate, hashedChunk)
+				if !compareSignatureV4(cr.chunkSignature, newSignature) {
+					// Chunk signature doesn't match we return signature does not match.
+					cr.err = errSignatureMismatch
+					return 0, cr.err
+				}
+				// Newly calculated signature becomes the seed for the next chunk
+				// this follows the chaining.
+				cr.seedSignature = newSignature
+				cr.chunkSHA256Writer.Reset()
 			}
-			// Newly calculated signature becomes the seed for the next chunk
-			// this follows the chaining.
-			cr.seedSignature = newSignature
-			cr.chunkSHA256Writer.Reset()
-			if cr.lastChunk {
-				cr.state = eofChunk
-			} else {
+			switch {
+			case !cr.lastChunk:
 				cr.state = readChunkHeader
+			case cr.trailer != nil:
+				cr.state = readTrailer
+			default:
+				cr.state = eofChunk
+			}
+		case readTrailer:
+			if cr.err = cr.readTrailer(); cr.err != nil {
+				return 0, cr.err
 			}
+			cr.state = eofChunk
 		case eofChunk:
 			return n, io.EOF
 		}
 	}
 }
 
+// readTrailer - reads the trailing headers sent after the last chunk into
+// cr.trailer, only the announced headers are accepted. With signed chunks
+// the trailing headers are signed, chained to the last chunk signature.
+func (cr *s3ChunkedReader) readTrailer() error {
+	var signature string
+	var canonical bytes.Buffer
+	for lines := 0; ; lines++ {
+		// Announced headers, their signature and the final CRLF.
+		if lines > len(cr.trailer)+1 {
+			return errMalformedEncoding
+		}
+		line, err := cr.reader.ReadSlice('\n')
+		if err == io.EOF && len(line) == 0 {
+			// The final CRLF is optional.
+			break
+		}
+		if err == bufio.ErrBufferFull || len(line) >= maxLineLength {
+			return errLineTooLong
+		}
+		if err != nil {
+			return errMalformedEncoding
+		}
+		line = trimTrailingWhitespace(line)
+		if len(line) == 0 {
+			break
+		}
+		kv := strings.SplitN(string(line), ":", 2)
+		if len(kv) != 2 {
+			return errMalformedEncoding
+		}
+		key, value := strings.ToLower(strings.TrimSpace(kv[0])), strings.TrimSpace(kv[1])
+		if key == trailerSignatureKey {
+			signature = value
+			continue
+		}
+		if _, ok := cr.trailer[http.CanonicalHeaderKey(key)]; !ok {
+			return errMalformedEncoding
+		}
+		cr.trailer.Set(key, value)
+		canonical.WriteString(key + ":" + value + "\n")
+	}
+
+	if cr.unsigned {
+		return nil
+	}
+	newSignature := getTrailerSignature(cr.cred, cr.seedSignature, cr.region, cr.seedDate, canonical.Bytes())
+	if !compareSignatureV4(signature, newSignature) {
+		return errSignatureMismatch
+	}
+	return nil
+}
+
 // readCRLF - check if reader only has '\r\n' CRLF character.
 // returns malformed encoding if it doesn't.
 func readCRLF(reader io.Reader) error {
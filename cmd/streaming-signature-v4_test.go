@@ -19,10 +19,17 @@ package cmd
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/pydio/minio-srv/pkg/auth"
 )
 
 // Test read chunk line.
@@ -194,3 +201,97 @@ func TestParseHexUint(t *testing.T) {
 		}
 	}
 }
+
+// Tests decoding unsigned chunks followed by trailing headers.
+func TestUnsignedChunkedReaderTrailer(t *testing.T) {
+	testCases := []struct {
+		body            string
+		expectedData    string
+		expectedTrailer string
+		expectedErr     error
+	}{
+		// Test - 1 valid trailer with final CRLF.
+		{"5\r\nhello\r\n0\r\nx-amz-checksum-crc32:NhCmhg==\r\n\r\n", "hello", "NhCmhg==", nil},
+		// Test - 2 valid trailer without final CRLF.
+		{"5\r\nhello\r\n0\r\nx-amz-checksum-crc32:NhCmhg==\r\n", "hello", "NhCmhg==", nil},
+		// Test - 3 several chunks.
+		{"3\r\nhel\r\n2\r\nlo\r\n0\r\nx-amz-checksum-crc32:NhCmhg==\r\n\r\n", "hello", "NhCmhg==", nil},
+		// Test - 4 trailing header not announced.
+		{"5\r\nhello\r\n0\r\nx-amz-meta-key:value\r\n\r\n", "", "", errMalformedEncoding},
+		// Test - 5 malformed trailing header.
+		{"5\r\nhello\r\n0\r\nx-amz-checksum-crc32\r\n\r\n", "", "", errMalformedEncoding},
+		// Test - 6 too many trailing headers.
+		{"5\r\nhello\r\n0\r\nx-amz-checksum-crc32:NhCmhg==\r\nx-amz-checksum-crc32:NhCmhg==\r\nx-amz-checksum-crc32:NhCmhg==\r\n\r\n", "", "", errMalformedEncoding},
+	}
+	for i, testCase := range testCases {
+		trailer := http.Header{"X-Amz-Checksum-Crc32": nil}
+		cr := &s3ChunkedReader{
+			reader:   bufio.NewReader(strings.NewReader(testCase.body)),
+			unsigned: true,
+			trailer:  trailer,
+			state:    readChunkHeader,
+		}
+		data, err := ioutil.ReadAll(cr)
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expectedErr, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if string(data) != testCase.expectedData {
+			t.Errorf("Test %d: Expected data %q, got %q", i+1, testCase.expectedData, data)
+		}
+		if value := trailer.Get("X-Amz-Checksum-Crc32"); value != testCase.expectedTrailer {
+			t.Errorf("Test %d: Expected trailer %q, got %q", i+1, testCase.expectedTrailer, value)
+		}
+	}
+}
+
+// Tests verifying the signature of trailing headers after signed chunks.
+func TestSignedChunkedReaderTrailer(t *testing.T) {
+	cred := auth.Credentials{AccessKey: "minio", SecretKey: "minio123"}
+	region := "us-east-1"
+	date := time.Date(2018, time.November, 1, 0, 0, 0, 0, time.UTC)
+	seedSignature := "6a9c5dcb2d6d4a4a0fd9f6ab7f5a4d4ed7ed0ba2d3b8c8ff2bc7b67e0cd49f31"
+
+	chunkSum := sha256.Sum256([]byte("hello"))
+	chunkSignature := getChunkSignature(cred, seedSignature, region, date, hex.EncodeToString(chunkSum[:]))
+	lastSignature := getChunkSignature(cred, chunkSignature, region, date, emptySHA256)
+	trailerSignature := getTrailerSignature(cred, lastSignature, region, date, []byte("x-amz-checksum-crc32:NhCmhg==\n"))
+
+	testCases := []struct {
+		trailerSignature string
+		expectedErr      error
+	}{
+		// Test - 1 valid trailer signature.
+		{trailerSignature, nil},
+		// Test - 2 invalid trailer signature.
+		{lastSignature, errSignatureMismatch},
+	}
+	for i, testCase := range testCases {
+		body := "5;chunk-signature=" + chunkSignature + "\r\nhello\r\n" +
+			"0;chunk-signature=" + lastSignature + "\r\n" +
+			"x-amz-checksum-crc32:NhCmhg==\r\n" +
+			"x-amz-trailer-signature:" + testCase.trailerSignature + "\r\n\r\n"
+		trailer := http.Header{"X-Amz-Checksum-Crc32": nil}
+		cr := &s3ChunkedReader{
+			reader:            bufio.NewReader(strings.NewReader(body)),
+			cred:              cred,
+			seedSignature:     seedSignature,
+			seedDate:          date,
+			region:            region,
+			chunkSHA256Writer: sha256.New(),
+			trailer:           trailer,
+			state:             readChunkHeader,
+		}
+		data, err := ioutil.ReadAll(cr)
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expectedErr, err)
+			continue
+		}
+		if err == nil && (string(data) != "hello" || trailer.Get("X-Amz-Checksum-Crc32") != "NhCmhg==") {
+			t.Errorf("Test %d: Unexpected data %q or trailer %v", i+1, data, trailer)
+		}
+	}
+}
@@ -58,6 +58,8 @@ const (
 	ErrSTSInvalidParameterValue
 	ErrSTSClientGrantsExpiredToken
 	ErrSTSInvalidClientGrantsToken
+	ErrSTSWebIdentityExpiredToken
+	ErrSTSAccessDenied
 	ErrSTSMalformedPolicyDocument
 	ErrSTSNotInitialized
 	ErrSTSInternalError
@@ -86,6 +88,16 @@ var stsErrCodeResponse = map[STSErrorCode]STSError{
 		Description:    "The client grants token that was passed could not be validated by Minio.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrSTSWebIdentityExpiredToken: {
+		Code:           "ExpiredToken",
+		Description:    "The web identity token that was passed is expired or is not valid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSTSAccessDenied: {
+		Code:           "AccessDenied",
+		Description:    "Generating temporary credentials not allowed for this request.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
 	ErrSTSMalformedPolicyDocument: {
 		Code:           "MalformedPolicyDocument",
 		Description:    "The request was rejected because the policy document was malformed.",
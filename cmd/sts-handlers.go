@@ -17,20 +17,58 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/xml"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/pydio/minio-go/pkg/set"
 	"github.com/pydio/minio-srv/cmd/logger"
 	"github.com/pydio/minio-srv/pkg/auth"
+	"github.com/pydio/minio-srv/pkg/iam/policy"
 	"github.com/pydio/minio-srv/pkg/iam/validator"
 )
 
 const (
 	// STS API version.
 	stsAPIVersion = "2011-06-15"
+
+	// Limit of the form of AssumeRole requests.
+	maxSTSRequestSize = 64 * 1024
+
+	// Limit of the inline session policy of AssumeRole requests.
+	maxSTSSessionPolicySize = 2048
+
+	// Default claim of web identity tokens holding the policy of
+	// the temporary credentials.
+	defaultOpenIDClaimName = "policy"
+
+	// Claims of the session token of temporary credentials obtained
+	// through AssumeRole: the long-term user they were issued to, and
+	// the inline session policy narrowing the policy of that user.
+	stsParentClaim        = "parent"
+	stsSessionPolicyClaim = "sessionPolicy"
+
+	// Claims of the session token of temporary credentials obtained
+	// through AssumeRoleWithLDAPIdentity: the DN of the LDAP user and
+	// the canned policies mapped from its groups.
+	stsLDAPUserClaim = "ldapUser"
+	stsPoliciesClaim = "policies"
 )
 
+// removeSTSClaims - removes the claims granting permissions from the claims
+// of a token issued by an identity provider.
+func removeSTSClaims(m map[string]interface{}) {
+	for _, claim := range []string{stsParentClaim, stsSessionPolicyClaim, stsLDAPUserClaim, stsPoliciesClaim} {
+		delete(m, claim)
+	}
+}
+
 // stsAPIHandlers implements and provides http handlers for AWS STS API.
 type stsAPIHandlers struct{}
 
@@ -47,6 +85,44 @@ func registerSTSRouter(router *mux.Router) {
 		Queries("Action", "AssumeRoleWithClientGrants").
 		Queries("Token", "{Token:.*}")
 
+	// AssumeRoleWithWebIdentity
+	stsRouter.Methods("POST").HandlerFunc(httpTraceAll(sts.AssumeRoleWithWebIdentity)).
+		Queries("Action", "AssumeRoleWithWebIdentity").
+		Queries("WebIdentityToken", "{Token:.*}")
+
+	// AssumeRole and AssumeRoleWithLDAPIdentity, parameters are sent
+	// in a form.
+	stsRouter.Methods("POST").MatcherFunc(func(r *http.Request, _ *mux.RouteMatch) bool {
+		return r.URL.Path == "/" && len(r.URL.RawQuery) == 0 &&
+			strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded")
+	}).HandlerFunc(httpTraceHdrs(sts.handleForm))
+}
+
+// handleForm - dispatches the STS requests sent as forms to the handler of
+// their action. The body is kept for the handlers, AssumeRole signatures
+// are computed over it.
+func (sts *stsAPIHandlers) handleForm(w http.ResponseWriter, r *http.Request) {
+	payload, err := ioutil.ReadAll(io.LimitReader(r.Body, maxSTSRequestSize))
+	if err != nil {
+		writeSTSErrorResponse(w, ErrSTSInvalidParameterValue)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(payload))
+
+	form, err := url.ParseQuery(string(payload))
+	if err != nil {
+		writeSTSErrorResponse(w, ErrSTSInvalidParameterValue)
+		return
+	}
+
+	switch form.Get("Action") {
+	case "AssumeRole":
+		sts.AssumeRole(w, r)
+	case "AssumeRoleWithLDAPIdentity":
+		sts.AssumeRoleWithLDAPIdentity(w, r)
+	default:
+		writeSTSErrorResponse(w, ErrSTSInvalidParameterValue)
+	}
 }
 
 // AssumedRoleUser - The identifiers for the temporary security credentials that
@@ -153,6 +229,7 @@ func (sts *stsAPIHandlers) AssumeRoleWithClientGrants(w http.ResponseWriter, r *
 		}
 		return
 	}
+	removeSTSClaims(m)
 
 	secret := globalServerConfig.GetCredential().SecretKey
 	cred, err := auth.GetNewCredentialsWithMetadata(m, secret)
@@ -184,3 +261,376 @@ func (sts *stsAPIHandlers) AssumeRoleWithClientGrants(w http.ResponseWriter, r *
 
 	writeSuccessResponseXML(w, encodedSuccessResponse)
 }
+
+// AssumeRoleWithWebIdentityResponse contains the result of successful AssumeRoleWithWebIdentity request.
+type AssumeRoleWithWebIdentityResponse struct {
+	XMLName          xml.Name          `xml:"https://sts.amazonaws.com/doc/2011-06-15/ AssumeRoleWithWebIdentityResponse" json:"-"`
+	Result           WebIdentityResult `xml:"AssumeRoleWithWebIdentityResult"`
+	ResponseMetadata struct {
+		RequestID string `xml:"RequestId,omitempty"`
+	} `xml:"ResponseMetadata,omitempty"`
+}
+
+// WebIdentityResult - Contains the response to a successful AssumeRoleWithWebIdentity
+// request, including temporary credentials that can be used to make Minio API requests.
+type WebIdentityResult struct {
+	// The identifiers for the temporary security credentials that the operation
+	// returns.
+	AssumedRoleUser AssumedRoleUser `xml:",omitempty"`
+
+	// The intended audience (also known as client ID) of the web identity token.
+	Audience string `xml:",omitempty"`
+
+	// The temporary security credentials, which include an access key ID, a secret
+	// access key, and a security (or session) token.
+	Credentials auth.Credentials `xml:",omitempty"`
+
+	// The issuing authority of the web identity token presented, the value of
+	// the iss field of OpenID Connect ID tokens.
+	Provider string `xml:",omitempty"`
+
+	// The unique user identifier that is returned by the identity provider,
+	// the sub (Subject) claim of OpenID Connect ID tokens.
+	SubjectFromWebIdentityToken string `xml:",omitempty"`
+}
+
+// AssumeRoleResponse contains the result of successful AssumeRole request.
+type AssumeRoleResponse struct {
+	XMLName          xml.Name         `xml:"https://sts.amazonaws.com/doc/2011-06-15/ AssumeRoleResponse" json:"-"`
+	Result           AssumeRoleResult `xml:"AssumeRoleResult"`
+	ResponseMetadata struct {
+		RequestID string `xml:"RequestId,omitempty"`
+	} `xml:"ResponseMetadata,omitempty"`
+}
+
+// AssumeRoleResult - Contains the response to a successful AssumeRole request,
+// including temporary credentials that can be used to make Minio API requests.
+type AssumeRoleResult struct {
+	// The identifiers for the temporary security credentials that the operation
+	// returns.
+	AssumedRoleUser AssumedRoleUser `xml:",omitempty"`
+
+	// The temporary security credentials, which include an access key ID, a secret
+	// access key, and a security (or session) token.
+	Credentials auth.Credentials `xml:",omitempty"`
+
+	// A percentage value that indicates the size of the policy in packed form.
+	PackedPolicySize int `xml:",omitempty"`
+}
+
+// AssumeRoleWithWebIdentity - implementation of AWS STS API supporting
+// OpenID Connect ID tokens. The policy of the temporary credentials is
+// read from the claim configured as openid.claimName.
+//
+// Eg:-
+//    $ curl https://minio:9000/?Action=AssumeRoleWithWebIdentity&WebIdentityToken=<jwt>
+func (sts *stsAPIHandlers) AssumeRoleWithWebIdentity(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "AssumeRoleWithWebIdentity")
+
+	if globalIAMValidators == nil {
+		writeSTSErrorResponse(w, ErrSTSNotInitialized)
+		return
+	}
+
+	// NOTE: this API only accepts JWT tokens.
+	v, err := globalIAMValidators.Get("jwt")
+	if err != nil {
+		writeSTSErrorResponse(w, ErrSTSInvalidParameterValue)
+		return
+	}
+
+	vars := mux.Vars(r)
+	m, err := v.Validate(vars["Token"], r.URL.Query().Get("DurationSeconds"))
+	if err != nil {
+		switch err {
+		case validator.ErrTokenExpired:
+			writeSTSErrorResponse(w, ErrSTSWebIdentityExpiredToken)
+		case validator.ErrInvalidDuration:
+			writeSTSErrorResponse(w, ErrSTSInvalidParameterValue)
+		default:
+			logger.LogIf(ctx, err)
+			writeSTSErrorResponse(w, ErrSTSInvalidParameterValue)
+		}
+		return
+	}
+
+	var policyName string
+	if v, ok := m[globalServerConfig.GetOpenIDClaimName()]; ok {
+		policyName, _ = v.(string)
+	}
+
+	// Claims of the identity provider must not grant the
+	// permissions of a user.
+	removeSTSClaims(m)
+
+	secret := globalServerConfig.GetCredential().SecretKey
+	cred, err := auth.GetNewCredentialsWithMetadata(m, secret)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeSTSErrorResponse(w, ErrSTSInternalError)
+		return
+	}
+
+	// Set the newly generated credentials.
+	if err = globalIAMSys.SetTempUser(cred.AccessKey, cred, policyName); err != nil {
+		logger.LogIf(ctx, err)
+		writeSTSErrorResponse(w, ErrSTSInternalError)
+		return
+	}
+
+	result := WebIdentityResult{Credentials: cred}
+	result.Audience, _ = m["aud"].(string)
+	result.Provider, _ = m["iss"].(string)
+	result.SubjectFromWebIdentityToken, _ = m["sub"].(string)
+
+	encodedSuccessResponse := encodeResponse(&AssumeRoleWithWebIdentityResponse{
+		Result: result,
+	})
+
+	writeSuccessResponseXML(w, encodedSuccessResponse)
+}
+
+// AssumeRole - implementation of AWS STS API exchanging the long-term
+// credentials of a user for temporary credentials. The temporary
+// credentials get the policy of the user, narrowed by the optional
+// inline session policy sent as Policy.
+//
+// Eg:-
+//    $ aws sts assume-role --endpoint-url https://minio:9000 --role-arn arn:xxx:xxx --role-session-name anything
+func (sts *stsAPIHandlers) AssumeRole(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "AssumeRole")
+
+	if getRequestAuthType(r) != authTypeSigned {
+		writeSTSErrorResponse(w, ErrSTSAccessDenied)
+		return
+	}
+
+	region := globalServerConfig.GetRegion()
+	if s3Err := isReqAuthenticated(r, region, serviceSTS); s3Err != ErrNone {
+		writeSTSErrorResponse(w, ErrSTSAccessDenied)
+		return
+	}
+
+	user, owner, s3Err := getReqAccessKeyV4(r, region)
+	if s3Err != ErrNone {
+		writeSTSErrorResponse(w, ErrSTSAccessDenied)
+		return
+	}
+
+	// Temporary credentials are only issued to users, they cannot be
+	// obtained with the admin, temporary or service account credentials.
+	if owner || user.SessionToken != "" || user.IsServiceAccount() {
+		writeSTSErrorResponse(w, ErrSTSAccessDenied)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		logger.LogIf(ctx, err)
+		writeSTSErrorResponse(w, ErrSTSInvalidParameterValue)
+		return
+	}
+
+	if r.Form.Get("Action") != "AssumeRole" {
+		writeSTSErrorResponse(w, ErrSTSInvalidParameterValue)
+		return
+	}
+
+	if version := r.Form.Get("Version"); version != "" && version != stsAPIVersion {
+		writeSTSErrorResponse(w, ErrSTSInvalidParameterValue)
+		return
+	}
+
+	duration, err := validator.GetDefaultExpiration(r.Form.Get("DurationSeconds"))
+	if err != nil {
+		writeSTSErrorResponse(w, ErrSTSInvalidParameterValue)
+		return
+	}
+
+	m := map[string]interface{}{
+		"exp":          float64(time.Now().UTC().Add(duration).Unix()),
+		stsParentClaim: user.AccessKey,
+	}
+
+	packedPolicySize, stsErr := setSessionPolicy(m, r.Form.Get("Policy"))
+	if stsErr != ErrSTSNone {
+		writeSTSErrorResponse(w, stsErr)
+		return
+	}
+
+	secret := globalServerConfig.GetCredential().SecretKey
+	cred, err := auth.GetNewCredentialsWithMetadata(m, secret)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeSTSErrorResponse(w, ErrSTSInternalError)
+		return
+	}
+
+	// Set the newly generated credentials, their policy is the
+	// one of the user at the time of each request.
+	if err = globalIAMSys.SetTempUser(cred.AccessKey, cred, ""); err != nil {
+		logger.LogIf(ctx, err)
+		writeSTSErrorResponse(w, ErrSTSInternalError)
+		return
+	}
+
+	encodedSuccessResponse := encodeResponse(&AssumeRoleResponse{
+		Result: AssumeRoleResult{
+			Credentials:      cred,
+			PackedPolicySize: packedPolicySize,
+		},
+	})
+
+	writeSuccessResponseXML(w, encodedSuccessResponse)
+}
+
+// setSessionPolicy - validates the inline session policy of a request and
+// sets it in the claims m of the temporary credentials. The packed size of
+// the policy is returned.
+func setSessionPolicy(m map[string]interface{}, sessionPolicy string) (int, STSErrorCode) {
+	if sessionPolicy == "" {
+		return 0, ErrSTSNone
+	}
+	if len(sessionPolicy) > maxSTSSessionPolicySize {
+		return 0, ErrSTSMalformedPolicyDocument
+	}
+
+	// Version in policy must not be empty.
+	p, err := iampolicy.ParseConfig(bytes.NewReader([]byte(sessionPolicy)))
+	if err != nil || p.Version == "" {
+		return 0, ErrSTSMalformedPolicyDocument
+	}
+
+	m[stsSessionPolicyClaim] = sessionPolicy
+	return len(sessionPolicy) * 100 / maxSTSSessionPolicySize, ErrSTSNone
+}
+
+// AssumeRoleWithLDAPIdentityResponse contains the result of successful
+// AssumeRoleWithLDAPIdentity request.
+type AssumeRoleWithLDAPIdentityResponse struct {
+	XMLName          xml.Name           `xml:"https://sts.amazonaws.com/doc/2011-06-15/ AssumeRoleWithLDAPIdentityResponse" json:"-"`
+	Result           LDAPIdentityResult `xml:"AssumeRoleWithLDAPIdentityResult"`
+	ResponseMetadata struct {
+		RequestID string `xml:"RequestId,omitempty"`
+	} `xml:"ResponseMetadata,omitempty"`
+}
+
+// LDAPIdentityResult - Contains the response to a successful AssumeRoleWithLDAPIdentity
+// request, including temporary credentials that can be used to make Minio API requests.
+type LDAPIdentityResult struct {
+	// The temporary security credentials, which include an access key ID, a secret
+	// access key, and a security (or session) token.
+	Credentials auth.Credentials `xml:",omitempty"`
+
+	// A percentage value that indicates the size of the policy in packed form.
+	PackedPolicySize int `xml:",omitempty"`
+}
+
+// AssumeRoleWithLDAPIdentity - Minio STS API extension exchanging the
+// credentials of an LDAP user for temporary credentials. The temporary
+// credentials get the canned policies named after the LDAP groups of the
+// user, narrowed by the optional inline session policy sent as Policy.
+//
+// Eg:-
+//    $ curl -X POST https://minio:9000 -d Action=AssumeRoleWithLDAPIdentity -d LDAPUsername=<user> -d LDAPPassword=<password>
+func (sts *stsAPIHandlers) AssumeRoleWithLDAPIdentity(w http.ResponseWriter, r *http.Request) {
+	ctx := newContext(r, w, "AssumeRoleWithLDAPIdentity")
+
+	if globalLDAPConfig == nil {
+		writeSTSErrorResponse(w, ErrSTSNotInitialized)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		logger.LogIf(ctx, err)
+		writeSTSErrorResponse(w, ErrSTSInvalidParameterValue)
+		return
+	}
+
+	if version := r.Form.Get("Version"); version != "" && version != stsAPIVersion {
+		writeSTSErrorResponse(w, ErrSTSInvalidParameterValue)
+		return
+	}
+
+	username, password := r.Form.Get("LDAPUsername"), r.Form.Get("LDAPPassword")
+	if username == "" || password == "" {
+		writeSTSErrorResponse(w, ErrSTSMissingParameter)
+		return
+	}
+
+	duration := globalLDAPConfig.STSExpiry
+	if dsecs := r.Form.Get("DurationSeconds"); dsecs != "" {
+		var err error
+		if duration, err = validator.GetDefaultExpiration(dsecs); err != nil {
+			writeSTSErrorResponse(w, ErrSTSInvalidParameterValue)
+			return
+		}
+	}
+
+	userDN, groups, err := globalLDAPConfig.lookupGroups(username, password)
+	if err != nil {
+		if err == errAuthentication {
+			writeSTSErrorResponse(w, ErrSTSAccessDenied)
+			return
+		}
+		logger.LogIf(ctx, err)
+		writeSTSErrorResponse(w, ErrSTSInternalError)
+		return
+	}
+
+	cannedPolicies, err := globalIAMSys.ListCannedPolicies()
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeSTSErrorResponse(w, ErrSTSInternalError)
+		return
+	}
+	policies := set.NewStringSet()
+	for _, group := range groups {
+		if _, ok := cannedPolicies[group]; ok {
+			policies.Add(group)
+		}
+	}
+
+	// Without OPA, users whose groups map to no policy would
+	// get credentials with no access.
+	if policies.IsEmpty() && globalPolicyOPA == nil {
+		writeSTSErrorResponse(w, ErrSTSAccessDenied)
+		return
+	}
+
+	m := map[string]interface{}{
+		"exp":            float64(time.Now().UTC().Add(duration).Unix()),
+		stsLDAPUserClaim: userDN,
+		stsPoliciesClaim: policies.ToSlice(),
+	}
+
+	packedPolicySize, stsErr := setSessionPolicy(m, r.Form.Get("Policy"))
+	if stsErr != ErrSTSNone {
+		writeSTSErrorResponse(w, stsErr)
+		return
+	}
+
+	secret := globalServerConfig.GetCredential().SecretKey
+	cred, err := auth.GetNewCredentialsWithMetadata(m, secret)
+	if err != nil {
+		logger.LogIf(ctx, err)
+		writeSTSErrorResponse(w, ErrSTSInternalError)
+		return
+	}
+
+	// Set the newly generated credentials, their policies are
+	// carried by the session token.
+	if err = globalIAMSys.SetTempUser(cred.AccessKey, cred, ""); err != nil {
+		logger.LogIf(ctx, err)
+		writeSTSErrorResponse(w, ErrSTSInternalError)
+		return
+	}
+
+	encodedSuccessResponse := encodeResponse(&AssumeRoleWithLDAPIdentityResponse{
+		Result: LDAPIdentityResult{
+			Credentials:      cred,
+			PackedPolicySize: packedPolicySize,
+		},
+	})
+
+	writeSuccessResponseXML(w, encodedSuccessResponse)
+}
@@ -71,6 +71,24 @@ var (
 		"MINIO_CACHE_MAXUSE: Valid cache max-use value between 0-100.",
 	)
 
+	uiErrInvalidCacheWatermark = newUIErrFn(
+		"Invalid cache watermark value",
+		"Please check the passed value",
+		"MINIO_CACHE_WATERMARK_LOW, MINIO_CACHE_WATERMARK_HIGH: Valid cache watermarks are between 0-100, the low watermark should not be above the high watermark.",
+	)
+
+	uiErrInvalidCacheEviction = newUIErrFn(
+		"Invalid cache eviction value",
+		"Please check the passed value",
+		"MINIO_CACHE_EVICTION: Valid cache eviction policies are `lru` and `lfu`.",
+	)
+
+	uiErrInvalidCacheCommit = newUIErrFn(
+		"Invalid cache commit value",
+		"Please check the passed value",
+		"MINIO_CACHE_COMMIT: Valid cache commit modes are `writethrough` and `writeback`.",
+	)
+
 	uiErrInvalidCredentials = newUIErrFn(
 		"Invalid credentials",
 		"Please provide correct credentials",
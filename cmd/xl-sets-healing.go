@@ -0,0 +1,473 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pydio/minio-srv/cmd/logger"
+	"github.com/pydio/minio-srv/pkg/madmin"
+)
+
+const (
+	// Healing tracker file, saved in the meta bucket of the disks
+	// formatted by HealFormat until they are fully healed.
+	healingTrackerFile    = "healing.json"
+	healingTrackerFileTmp = "healing.json.tmp"
+
+	// Interval between two saves of the healing progress.
+	healingTrackerSaveInterval = 10 * time.Second
+
+	// Interval between two looks for unformatted local disks.
+	defaultMonitorNewDiskInterval = time.Minute
+)
+
+// Delay between two objects healed in the background, to limit the
+// load put on the sets by healing.
+var backgroundHealObjectDelay = 10 * time.Millisecond
+
+// healingTracker - progress of the background healing of a newly
+// formatted disk, saved on the disk to resume healing across restarts.
+type healingTracker struct {
+	ID      string    `json:"id"`
+	Started time.Time `json:"started"`
+	Updated time.Time `json:"updated"`
+
+	// Fully healed buckets and prefixes of the meta bucket.
+	HealedBuckets []string `json:"healedBuckets"`
+
+	// Bucket being healed and the last healed object.
+	Bucket string `json:"bucket"`
+	Object string `json:"object"`
+
+	ObjectsHealed uint64 `json:"objectsHealed"`
+	ObjectsFailed uint64 `json:"objectsFailed"`
+}
+
+// isHealed - returns whether bucket was fully healed.
+func (t *healingTracker) isHealed(bucket string) bool {
+	for _, b := range t.HealedBuckets {
+		if b == bucket {
+			return true
+		}
+	}
+	return false
+}
+
+// newHealingTracker - returns a new tracker of the disk with uuid id.
+func newHealingTracker(id string) healingTracker {
+	now := UTCNow()
+	return healingTracker{
+		ID:      id,
+		Started: now,
+		Updated: now,
+	}
+}
+
+// saveHealingTracker - saves the healing tracker on disk.
+func saveHealingTracker(disk StorageAPI, tracker healingTracker) error {
+	trackerBytes, err := json.Marshal(tracker)
+	if err != nil {
+		return err
+	}
+
+	// Purge any existing temporary file, okay to ignore errors here.
+	defer disk.DeleteFile(minioMetaBucket, healingTrackerFileTmp)
+
+	if err = disk.AppendFile(minioMetaBucket, healingTrackerFileTmp, trackerBytes); err != nil {
+		return err
+	}
+	return disk.RenameFile(minioMetaBucket, healingTrackerFileTmp, minioMetaBucket, healingTrackerFile)
+}
+
+// loadHealingTracker - loads the healing tracker of disk, returns
+// errFileNotFound if the disk does not need healing.
+func loadHealingTracker(disk StorageAPI) (tracker healingTracker, err error) {
+	trackerBytes, err := disk.ReadAll(minioMetaBucket, healingTrackerFile)
+	if err != nil {
+		if err == errVolumeNotFound {
+			err = errFileNotFound
+		}
+		return tracker, err
+	}
+	err = json.Unmarshal(trackerBytes, &tracker)
+	return tracker, err
+}
+
+// healingDisk - a local disk healed in the background.
+type healingDisk struct {
+	endpoint            string
+	setIndex, diskIndex int
+	disk                StorageAPI
+
+	// Lock protecting the tracker, read by the status.
+	mu      sync.Mutex
+	tracker healingTracker
+}
+
+// update - updates the tracker with f, then saves it on the disk if
+// save is set.
+func (h *healingDisk) update(save bool, f func(t *healingTracker)) error {
+	h.mu.Lock()
+	f(&h.tracker)
+	h.tracker.Updated = UTCNow()
+	tracker := h.tracker
+	h.mu.Unlock()
+
+	if !save {
+		return nil
+	}
+	return saveHealingTracker(h.disk, tracker)
+}
+
+// status - returns the healing status of the disk.
+func (h *healingDisk) status() madmin.HealingDisk {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return madmin.HealingDisk{
+		Endpoint:      h.endpoint,
+		UUID:          h.tracker.ID,
+		SetIndex:      h.setIndex,
+		DiskIndex:     h.diskIndex,
+		Started:       h.tracker.Started,
+		Updated:       h.tracker.Updated,
+		Bucket:        h.tracker.Bucket,
+		Object:        h.tracker.Object,
+		ObjectsHealed: h.tracker.ObjectsHealed,
+		ObjectsFailed: h.tracker.ObjectsFailed,
+	}
+}
+
+// backgroundHealing - the local disks of the sets healed in the
+// background, one disk is healed at a time.
+type backgroundHealing struct {
+	mu    sync.Mutex
+	disks map[string]*healingDisk
+
+	// Serializes the healing of the disks.
+	healMu sync.Mutex
+}
+
+func newBackgroundHealing() *backgroundHealing {
+	return &backgroundHealing{disks: make(map[string]*healingDisk)}
+}
+
+// backgroundHealer - object layers healing disks in the background.
+type backgroundHealer interface {
+	BackgroundHealStatus() []madmin.HealingDisk
+}
+
+// BackgroundHealStatus - returns the status of the local disks being
+// healed in the background.
+func (s *xlSets) BackgroundHealStatus() []madmin.HealingDisk {
+	s.healing.mu.Lock()
+	defer s.healing.mu.Unlock()
+
+	var status []madmin.HealingDisk
+	for _, h := range s.healing.disks {
+		status = append(status, h.status())
+	}
+	sort.Slice(status, func(i, j int) bool {
+		if status[i].SetIndex != status[j].SetIndex {
+			return status[i].SetIndex < status[j].SetIndex
+		}
+		return status[i].DiskIndex < status[j].DiskIndex
+	})
+	return status
+}
+
+// monitorLocalDisksAndHeal - formats the replaced local disks found at
+// every monitorInterval, and heals the disks being healed in the
+// background, until doneCh is closed.
+func (s *xlSets) monitorLocalDisksAndHeal(monitorInterval time.Duration, doneCh <-chan struct{}) {
+	ticker := time.NewTicker(monitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-doneCh:
+			return
+		case <-ticker.C:
+			s.formatNewDisks()
+			s.healNewDisks()
+		}
+	}
+}
+
+// formatNewDisks - formats the sets when some local disks are
+// unformatted, HealFormat marks the newly formatted disks to be
+// healed in the background.
+func (s *xlSets) formatNewDisks() {
+	var newEndpoints []string
+	for _, endpoint := range s.endpoints {
+		if !endpoint.IsLocal {
+			continue
+		}
+		disk, _, err := connectEndpoint(endpoint)
+		if err == errUnformattedDisk {
+			newEndpoints = append(newEndpoints, endpoint.String())
+			continue
+		}
+		if err == nil {
+			disk.Close()
+		}
+	}
+	if len(newEndpoints) == 0 {
+		return
+	}
+
+	reqInfo := (&logger.ReqInfo{}).AppendTags("disks", strings.Join(newEndpoints, ","))
+	ctx := logger.SetReqInfo(context.Background(), reqInfo)
+
+	logger.Info("Formatting new disks %s...", strings.Join(newEndpoints, ", "))
+	if _, err := s.HealFormat(ctx, false); err != nil && err != errNoHealRequired {
+		logger.LogIf(ctx, err)
+	}
+}
+
+// healNewDisks - looks for a healing tracker on the connected local
+// disks, and starts healing the sets of the disks found in the
+// background. Only local disks are considered such that a disk is
+// healed by a single server in a distributed setup.
+func (s *xlSets) healNewDisks() {
+	localEndpoints := make(map[string]bool)
+	for _, endpoint := range s.endpoints {
+		if endpoint.IsLocal {
+			localEndpoints[endpoint.Path] = true
+		}
+	}
+
+	var newDisks []*healingDisk
+	s.xlDisksMu.RLock()
+	for i := 0; i < s.setCount; i++ {
+		for j := 0; j < s.drivesPerSet; j++ {
+			disk := s.xlDisks[i][j]
+			if disk == nil || !localEndpoints[disk.String()] {
+				continue
+			}
+			newDisks = append(newDisks, &healingDisk{
+				endpoint:  disk.String(),
+				setIndex:  i,
+				diskIndex: j,
+				disk:      disk,
+			})
+		}
+	}
+	s.xlDisksMu.RUnlock()
+
+	for _, h := range newDisks {
+		s.healing.mu.Lock()
+		_, healing := s.healing.disks[h.endpoint]
+		s.healing.mu.Unlock()
+		if healing {
+			continue
+		}
+
+		tracker, err := loadHealingTracker(h.disk)
+		if err != nil {
+			if err != errFileNotFound {
+				reqInfo := (&logger.ReqInfo{}).AppendTags("disk", h.endpoint)
+				ctx := logger.SetReqInfo(context.Background(), reqInfo)
+				logger.LogOnceIf(ctx, err, h.endpoint)
+			}
+			continue
+		}
+		h.tracker = tracker
+
+		s.healing.mu.Lock()
+		s.healing.disks[h.endpoint] = h
+		s.healing.mu.Unlock()
+
+		go s.healDisk(h)
+	}
+}
+
+// healDisk - heals all the buckets and objects of the set of a newly
+// formatted disk, then removes the healing tracker of the disk. The
+// healing is resumed later on errors.
+func (s *xlSets) healDisk(h *healingDisk) {
+	s.healing.healMu.Lock()
+	defer s.healing.healMu.Unlock()
+
+	defer func() {
+		s.healing.mu.Lock()
+		delete(s.healing.disks, h.endpoint)
+		s.healing.mu.Unlock()
+	}()
+
+	reqInfo := (&logger.ReqInfo{}).AppendTags("disk", h.endpoint)
+	ctx := logger.SetReqInfo(context.Background(), reqInfo)
+
+	logger.Info("Healing disk '%s' of set %d in the background...", h.endpoint, h.setIndex+1)
+	if err := s.healDiskSet(ctx, h); err != nil {
+		if err != errHealStopSignalled {
+			logger.LogOnceIf(ctx, err, h.endpoint)
+		}
+		return
+	}
+
+	if err := h.disk.DeleteFile(minioMetaBucket, healingTrackerFile); err != nil {
+		logger.LogIf(ctx, err)
+		return
+	}
+
+	status := h.status()
+	logger.Info("Healing disk '%s' of set %d complete, %d objects healed, %d failed.",
+		h.endpoint, h.setIndex+1, status.ObjectsHealed, status.ObjectsFailed)
+}
+
+// healDiskSet - heals the configs and the buckets of the set of the
+// disk, resuming from the saved progress.
+func (s *xlSets) healDiskSet(ctx context.Context, h *healingDisk) error {
+	buckets, err := s.ListBucketsHeal(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Name < buckets[j].Name
+	})
+
+	type healItem struct {
+		bucket, prefix string
+	}
+	items := []healItem{
+		{minioMetaBucket, minioConfigPrefix + slashSeparator},
+		{minioMetaBucket, bucketConfigPrefix + slashSeparator},
+	}
+	for _, bucket := range buckets {
+		items = append(items, healItem{bucket.Name, ""})
+	}
+
+	for _, item := range items {
+		key := pathJoin(item.bucket, item.prefix)
+
+		h.mu.Lock()
+		healed := h.tracker.isHealed(key)
+		marker := ""
+		if h.tracker.Bucket == key {
+			marker = h.tracker.Object
+		}
+		h.mu.Unlock()
+		if healed {
+			continue
+		}
+
+		if err = h.update(true, func(t *healingTracker) {
+			t.Bucket = key
+			t.Object = marker
+		}); err != nil {
+			return err
+		}
+
+		// The meta bucket is created along with the format.
+		if item.bucket != minioMetaBucket {
+			if _, err = s.HealBucket(ctx, item.bucket, false); err != nil {
+				logger.LogIf(ctx, err)
+			}
+		}
+
+		if err = s.healDiskObjects(ctx, h, item.bucket, item.prefix, marker); err != nil {
+			return err
+		}
+
+		if err = h.update(true, func(t *healingTracker) {
+			t.HealedBuckets = append(t.HealedBuckets, key)
+			t.Bucket = ""
+			t.Object = ""
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// healDiskObjects - heals the objects of the set of the disk under
+// bucket and prefix, starting after marker. Healing is throttled
+// and waits for the requests in progress to be served.
+func (s *xlSets) healDiskObjects(ctx context.Context, h *healingDisk, bucket, prefix, marker string) error {
+	set := s.sets[h.setIndex]
+
+	isLeaf := func(bucket, entry string) bool {
+		entry = strings.TrimSuffix(entry, slashSeparator)
+		return set.isObject(bucket, entry)
+	}
+	listDir := listDirSetsHealFactory(isLeaf, set.getLoadBalancedDisks())
+
+	endWalkCh := make(chan struct{})
+	defer close(endWalkCh)
+	walkResultCh := startTreeWalk(ctx, bucket, prefix, marker, true, listDir, nil, set.isObjectDir, endWalkCh)
+
+	lastSave := UTCNow()
+	for walkResult := range walkResultCh {
+		if walkResult.err != nil {
+			return toObjectErr(walkResult.err, bucket, prefix)
+		}
+
+		select {
+		case <-globalServiceDoneCh:
+			return errHealStopSignalled
+		default:
+		}
+
+		waitForLowHTTPReq(time.Minute)
+		time.Sleep(backgroundHealObjectDelay)
+
+		_, healErr := set.HealObject(ctx, bucket, walkResult.entry, false)
+		if healErr != nil && !isErrObjectNotFound(healErr) {
+			logger.LogIf(ctx, healErr)
+		}
+
+		save := UTCNow().Sub(lastSave) >= healingTrackerSaveInterval
+		if err := h.update(save, func(t *healingTracker) {
+			t.Object = walkResult.entry
+			switch {
+			case healErr == nil:
+				t.ObjectsHealed++
+			case !isErrObjectNotFound(healErr):
+				t.ObjectsFailed++
+			}
+		}); err != nil {
+			return err
+		}
+		if save {
+			lastSave = UTCNow()
+		}
+
+		if walkResult.end {
+			break
+		}
+	}
+	return nil
+}
+
+// waitForLowHTTPReq - waits at most maxWait for the requests in
+// progress to be served, such that healing does not slow them down.
+func waitForLowHTTPReq(maxWait time.Duration) {
+	if globalHTTPServer == nil {
+		return
+	}
+	for globalHTTPServer.GetRequestCount() > 2 && maxWait > 0 {
+		maxWait -= time.Second
+		time.Sleep(time.Second)
+	}
+}
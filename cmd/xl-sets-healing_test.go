@@ -0,0 +1,175 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Tests saving and loading healing trackers.
+func TestHealingTracker(t *testing.T) {
+	disks, err := getRandomDisks(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	disk, err := newPosix(disks[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// No meta bucket, nothing to heal.
+	if _, err = loadHealingTracker(disk); err != errFileNotFound {
+		t.Fatalf("expected %v, got %v", errFileNotFound, err)
+	}
+	if err = disk.MakeVol(minioMetaBucket); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = loadHealingTracker(disk); err != errFileNotFound {
+		t.Fatalf("expected %v, got %v", errFileNotFound, err)
+	}
+
+	tracker := newHealingTracker(mustGetUUID())
+	tracker.HealedBuckets = []string{"bucket1"}
+	tracker.Bucket = "bucket2"
+	tracker.Object = "object"
+	tracker.ObjectsHealed = 10
+	tracker.ObjectsFailed = 1
+	for i := 0; i < 2; i++ {
+		if err = saveHealingTracker(disk, tracker); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := loadHealingTracker(disk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !loaded.Started.Equal(tracker.Started) || !loaded.Updated.Equal(tracker.Updated) {
+			t.Fatalf("expected times %v, %v, got %v, %v", tracker.Started, tracker.Updated, loaded.Started, loaded.Updated)
+		}
+		loaded.Started, loaded.Updated = tracker.Started, tracker.Updated
+		if !reflect.DeepEqual(loaded, tracker) {
+			t.Fatalf("expected %#v, got %#v", tracker, loaded)
+		}
+		tracker.ObjectsHealed++
+	}
+
+	testCases := []struct {
+		bucket string
+		healed bool
+	}{
+		{"bucket1", true},
+		{"bucket2", false},
+		{"bucket", false},
+	}
+	for i, testCase := range testCases {
+		if healed := tracker.isHealed(testCase.bucket); healed != testCase.healed {
+			t.Errorf("Test %d: expected %v, got %v", i+1, testCase.healed, healed)
+		}
+	}
+}
+
+// Tests healing a replaced disk in the background.
+func TestXLSetsBackgroundHeal(t *testing.T) {
+	defer func(delay time.Duration) {
+		backgroundHealObjectDelay = delay
+	}(backgroundHealObjectDelay)
+	backgroundHealObjectDelay = 0
+
+	// Healing buckets takes the global namespace lock.
+	initNSLock(false)
+	defer resetGlobalNSLock()
+
+	objLayer, fsDirs, err := initTestXLObjLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := objLayer.(*xlSets)
+
+	ctx := context.Background()
+	bucket := "bucket"
+	objects := []string{"a", "dir/b", "dir/c", "z"}
+	if err = objLayer.MakeBucketWithLocation(ctx, bucket, ""); err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("a"), 1024)
+	for _, object := range objects {
+		if _, err = objLayer.PutObject(ctx, bucket, object, mustGetHashReader(t, bytes.NewReader(data), int64(len(data)), "", ""), nil, ObjectOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Nothing to heal.
+	xl.healNewDisks()
+	if status := xl.BackgroundHealStatus(); len(status) != 0 {
+		t.Fatalf("expected no disk to heal, got %v", status)
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	go xl.monitorLocalDisksAndHeal(10*time.Millisecond, doneCh)
+
+	// Replace the first disk, it must be formatted and healed
+	// without any admin call.
+	if err = os.RemoveAll(fsDirs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(fsDirs[0], 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	disk, err := newPosix(fsDirs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	isHealed := func() error {
+		if _, err := loadFormatXL(disk); err != nil {
+			return err
+		}
+		for _, object := range objects {
+			if _, err := os.Stat(filepath.Join(fsDirs[0], bucket, object, xlMetaJSONFile)); err != nil {
+				return err
+			}
+		}
+		if _, err := loadHealingTracker(disk); err != errFileNotFound {
+			return fmt.Errorf("healing tracker not removed: %v", err)
+		}
+		if status := xl.BackgroundHealStatus(); len(status) != 0 {
+			return fmt.Errorf("disks still healing: %v", status)
+		}
+		return nil
+	}
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		err = isHealed()
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("healing not complete: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
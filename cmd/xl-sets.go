@@ -79,6 +79,9 @@ type xlSets struct {
 
 	// Pack level listObjects pool management.
 	listPool *treeWalkPool
+
+	// Newly formatted local disks healed in the background.
+	healing *backgroundHealing
 }
 
 // isConnected - checks if the endpoint is connected or not.
@@ -272,6 +275,7 @@ func newXLSets(endpoints EndpointList, format *formatXLV3, setCount int, drivesP
 		disksConnectDoneCh: make(chan struct{}),
 		distributionAlgo:   format.XL.DistributionAlgo,
 		listPool:           newTreeWalkPool(globalLookupTimeout),
+		healing:            newBackgroundHealing(),
 	}
 
 	mutex := newNSLock(globalIsDistXL)
@@ -295,9 +299,15 @@ func newXLSets(endpoints EndpointList, format *formatXLV3, setCount int, drivesP
 	// Connect disks right away, but wait until we have `format.json` quorum.
 	s.connectDisksWithQuorum()
 
+	// Resume healing the disks replaced before a restart.
+	s.healNewDisks()
+
 	// Start the disk monitoring and connect routine.
 	go s.monitorAndConnectEndpoints(defaultMonitorConnectEndpointInterval)
 
+	// Start formatting and healing the replaced local disks.
+	go s.monitorLocalDisksAndHeal(defaultMonitorNewDiskInterval, globalServiceDoneCh)
+
 	return s, nil
 }
 
@@ -1141,6 +1151,17 @@ func (s *xlSets) HealFormat(ctx context.Context, dryRun bool) (res madmin.HealRe
 			return madmin.HealResultItem{}, err
 		}
 
+		// Mark the newly formatted disks to be healed in the background.
+		for index, sErr := range sErrs {
+			if sErr != errUnformattedDisk || tmpNewFormats[index] == nil {
+				continue
+			}
+			tracker := newHealingTracker(tmpNewFormats[index].XL.This)
+			if err = saveHealingTracker(storageDisks[index], tracker); err != nil {
+				return madmin.HealResultItem{}, err
+			}
+		}
+
 		// kill the monitoring loop such that we stop writing
 		// to indicate that we will re-initialize everything
 		// with new format.
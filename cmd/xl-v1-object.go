@@ -842,6 +842,7 @@ func (xl xlObjects) putObject(ctx context.Context, bucket string, object string,
 	// Save additional erasureMetadata.
 	modTime := UTCNow()
 	metadata["etag"] = hex.EncodeToString(data.MD5Current())
+	saveChecksumMetadata(metadata, data)
 
 	// Guess content-type from the extension if possible.
 	if metadata["content-type"] == "" {
@@ -73,6 +73,38 @@ type Credentials struct {
 	Expiration   time.Time `xml:"Expiration" json:"expiration,omitempty"`
 	SessionToken string    `xml:"SessionToken" json:"sessionToken,omitempty"`
 	Status       string    `xml:"-" json:"status,omitempty"`
+	ParentUser   string    `xml:"-" json:"parentUser,omitempty"`
+	// Secret key replaced by the last rotation, still accepted during
+	// its grace period.
+	PrevSecretKey *PrevSecretKey `xml:"-" json:"prevSecretKey,omitempty"`
+}
+
+// PrevSecretKey holds a rotated secret key and the end of its grace period.
+type PrevSecretKey struct {
+	SecretKey  string    `json:"secretKey"`
+	Expiration time.Time `json:"expiration"`
+}
+
+// IsTemp - returns whether credential is a temporary credential issued by STS.
+func (cred Credentials) IsTemp() bool {
+	return cred.SessionToken != ""
+}
+
+// SecretKeys - returns the secret keys accepted for this credential, the
+// current secret key first followed by the previous secret key while its
+// grace period lasts.
+func (cred Credentials) SecretKeys() []string {
+	secretKeys := []string{cred.SecretKey}
+	if prev := cred.PrevSecretKey; prev != nil && prev.SecretKey != "" && time.Now().UTC().Before(prev.Expiration) {
+		secretKeys = append(secretKeys, prev.SecretKey)
+	}
+	return secretKeys
+}
+
+// IsServiceAccount - returns whether credential is a service account,
+// service accounts are long-lived credentials owned by a parent user.
+func (cred Credentials) IsServiceAccount() bool {
+	return cred.ParentUser != "" && !cred.IsTemp()
 }
 
 // IsExpired - returns whether Credential is expired or not.
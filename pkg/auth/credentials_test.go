@@ -16,7 +16,11 @@
 
 package auth
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+	"time"
+)
 
 func TestIsAccessKeyValid(t *testing.T) {
 	testCases := []struct {
@@ -143,3 +147,67 @@ func TestCredentialsEqual(t *testing.T) {
 		}
 	}
 }
+
+func TestCredentialsIsServiceAccount(t *testing.T) {
+	testCases := []struct {
+		cred           Credentials
+		expectedResult bool
+	}{
+		{Credentials{AccessKey: "myuser", SecretKey: "mypassword"}, false},
+		{Credentials{AccessKey: "myservice", SecretKey: "mypassword", ParentUser: "myuser"}, true},
+		// Temporary credentials are not service accounts.
+		{Credentials{AccessKey: "mytemp", SecretKey: "mypassword", ParentUser: "myuser", SessionToken: "token"}, false},
+	}
+
+	for i, testCase := range testCases {
+		result := testCase.cred.IsServiceAccount()
+		if result != testCase.expectedResult {
+			t.Fatalf("test %v: expected: %v, got: %v", i+1, testCase.expectedResult, result)
+		}
+	}
+}
+
+func TestCredentialsSecretKeys(t *testing.T) {
+	now := time.Now().UTC()
+	testCases := []struct {
+		cred           Credentials
+		expectedResult []string
+	}{
+		{Credentials{AccessKey: "myuser", SecretKey: "mypassword"}, []string{"mypassword"}},
+		// Previous secret key in its grace period.
+		{Credentials{AccessKey: "myuser", SecretKey: "mypassword", PrevSecretKey: &PrevSecretKey{
+			SecretKey: "myoldpassword", Expiration: now.Add(time.Hour),
+		}}, []string{"mypassword", "myoldpassword"}},
+		// Grace period is over.
+		{Credentials{AccessKey: "myuser", SecretKey: "mypassword", PrevSecretKey: &PrevSecretKey{
+			SecretKey: "myoldpassword", Expiration: now.Add(-time.Hour),
+		}}, []string{"mypassword"}},
+	}
+
+	for i, testCase := range testCases {
+		result := testCase.cred.SecretKeys()
+		if !reflect.DeepEqual(result, testCase.expectedResult) {
+			t.Fatalf("test %v: expected: %v, got: %v", i+1, testCase.expectedResult, result)
+		}
+	}
+}
+
+func TestCredentialsIsValidExpiration(t *testing.T) {
+	now := time.Now().UTC()
+	testCases := []struct {
+		cred           Credentials
+		expectedResult bool
+	}{
+		{Credentials{AccessKey: "myuser", SecretKey: "mypassword"}, true},
+		{Credentials{AccessKey: "myuser", SecretKey: "mypassword", Expiration: now.Add(time.Hour)}, true},
+		{Credentials{AccessKey: "myuser", SecretKey: "mypassword", Expiration: now.Add(-time.Hour)}, false},
+		{Credentials{AccessKey: "myuser", SecretKey: "mypassword", Expiration: timeSentinel}, true},
+	}
+
+	for i, testCase := range testCases {
+		result := testCase.cred.IsValid()
+		if result != testCase.expectedResult {
+			t.Fatalf("test %v: expected: %v, got: %v", i+1, testCase.expectedResult, result)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hash
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"hash"
+	"hash/crc32"
+	"net/http"
+	"strings"
+
+	sha256 "github.com/minio/sha256-simd"
+)
+
+// ChecksumType - an additional checksum algorithm of S3, sent by
+// clients along with the content in addition to Content-Md5.
+type ChecksumType string
+
+// Supported additional checksum algorithms.
+const (
+	ChecksumCRC32  ChecksumType = "CRC32"
+	ChecksumCRC32C ChecksumType = "CRC32C"
+	ChecksumSHA1   ChecksumType = "SHA1"
+	ChecksumSHA256 ChecksumType = "SHA256"
+)
+
+// ChecksumTypes - all the supported additional checksum algorithms.
+var ChecksumTypes = []ChecksumType{ChecksumCRC32, ChecksumCRC32C, ChecksumSHA1, ChecksumSHA256}
+
+// Prefix of the headers carrying additional checksums.
+const checksumHeaderPrefix = "X-Amz-Checksum-"
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// IsValid - returns whether t is a supported checksum algorithm.
+func (t ChecksumType) IsValid() bool {
+	for _, ct := range ChecksumTypes {
+		if t == ct {
+			return true
+		}
+	}
+	return false
+}
+
+// Key - returns the canonical header carrying checksums of type t,
+// for example "X-Amz-Checksum-Crc32".
+func (t ChecksumType) Key() string {
+	return http.CanonicalHeaderKey(checksumHeaderPrefix + string(t))
+}
+
+// Size - returns the size in bytes of checksums of type t.
+func (t ChecksumType) Size() int {
+	switch t {
+	case ChecksumCRC32, ChecksumCRC32C:
+		return crc32.Size
+	case ChecksumSHA1:
+		return sha1.Size
+	case ChecksumSHA256:
+		return sha256.Size
+	}
+	return 0
+}
+
+// Hasher - returns a new hash.Hash computing checksums of type t.
+func (t ChecksumType) Hasher() hash.Hash {
+	switch t {
+	case ChecksumCRC32:
+		return crc32.NewIEEE()
+	case ChecksumCRC32C:
+		return crc32.New(castagnoliTable)
+	case ChecksumSHA1:
+		return sha1.New()
+	case ChecksumSHA256:
+		return sha256.New()
+	}
+	return nil
+}
+
+// ChecksumTypeFromKey - returns the checksum algorithm of the header
+// key, for example CRC32 for "x-amz-checksum-crc32". The returned type
+// is not valid if key does not carry a supported checksum.
+func ChecksumTypeFromKey(key string) ChecksumType {
+	key = http.CanonicalHeaderKey(strings.TrimSpace(key))
+	if !strings.HasPrefix(key, checksumHeaderPrefix) {
+		return ""
+	}
+	t := ChecksumType(strings.ToUpper(strings.TrimPrefix(key, checksumHeaderPrefix)))
+	if !t.IsValid() {
+		return ""
+	}
+	return t
+}
+
+// Checksum - an additional checksum, its value is base64 encoded as
+// in the S3 headers.
+type Checksum struct {
+	Type    ChecksumType
+	Encoded string
+}
+
+// NewChecksum - returns the checksum of type t with the base64 encoded
+// value, after validating it.
+func NewChecksum(t ChecksumType, encoded string) (*Checksum, error) {
+	if !t.IsValid() {
+		return nil, InvalidChecksum{t}
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(raw) != t.Size() {
+		return nil, InvalidChecksum{t}
+	}
+	return &Checksum{Type: t, Encoded: encoded}, nil
+}
+
+// ChecksumFromHeader - returns the checksum sent in h, if any. Only one
+// additional checksum can be sent.
+func ChecksumFromHeader(h http.Header) (*Checksum, error) {
+	var checksum *Checksum
+	for _, t := range ChecksumTypes {
+		value, ok := h[t.Key()]
+		if !ok {
+			continue
+		}
+		if checksum != nil || len(value) != 1 {
+			return nil, InvalidChecksum{t}
+		}
+		var err error
+		if checksum, err = NewChecksum(t, value[0]); err != nil {
+			return nil, err
+		}
+	}
+	return checksum, nil
+}
@@ -0,0 +1,132 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package hash
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestChecksumTypeFromKey(t *testing.T) {
+	testCases := []struct {
+		key          string
+		expectedType ChecksumType
+	}{
+		{"x-amz-checksum-crc32", ChecksumCRC32},
+		{"X-Amz-Checksum-Crc32c", ChecksumCRC32C},
+		{" x-amz-checksum-sha1", ChecksumSHA1},
+		{"x-amz-checksum-sha256", ChecksumSHA256},
+		{"x-amz-checksum-md5", ""},
+		{"x-amz-meta-checksum", ""},
+	}
+
+	for i, testCase := range testCases {
+		if result := ChecksumTypeFromKey(testCase.key); result != testCase.expectedType {
+			t.Fatalf("Test %d: expected %q, got %q", i+1, testCase.expectedType, result)
+		}
+	}
+}
+
+func TestChecksumFromHeader(t *testing.T) {
+	testCases := []struct {
+		header           http.Header
+		expectedChecksum *Checksum
+		expectedErr      error
+	}{
+		{http.Header{}, nil, nil},
+		{http.Header{"X-Amz-Checksum-Crc32": []string{"7YLNEQ=="}}, &Checksum{ChecksumCRC32, "7YLNEQ=="}, nil},
+		{http.Header{"X-Amz-Checksum-Sha256": []string{"iNQmb9TmM40TuEX88olXnSCciXgjuSF9o+Fhk28DFYk="}},
+			&Checksum{ChecksumSHA256, "iNQmb9TmM40TuEX88olXnSCciXgjuSF9o+Fhk28DFYk="}, nil},
+		// Not base64.
+		{http.Header{"X-Amz-Checksum-Crc32": []string{"7YLNEQ"}}, nil, InvalidChecksum{ChecksumCRC32}},
+		// Wrong size for the algorithm.
+		{http.Header{"X-Amz-Checksum-Sha1": []string{"7YLNEQ=="}}, nil, InvalidChecksum{ChecksumSHA1}},
+		// Several checksums.
+		{http.Header{"X-Amz-Checksum-Crc32": []string{"7YLNEQ=="}, "X-Amz-Checksum-Crc32c": []string{"ksgKMQ=="}},
+			nil, InvalidChecksum{ChecksumCRC32C}},
+	}
+
+	for i, testCase := range testCases {
+		checksum, err := ChecksumFromHeader(testCase.header)
+		if err != testCase.expectedErr {
+			t.Fatalf("Test %d: expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+		if (checksum == nil) != (testCase.expectedChecksum == nil) ||
+			(checksum != nil && *checksum != *testCase.expectedChecksum) {
+			t.Fatalf("Test %d: expected %v, got %v", i+1, testCase.expectedChecksum, checksum)
+		}
+	}
+}
+
+func TestHashReaderChecksum(t *testing.T) {
+	testCases := []struct {
+		checksumType ChecksumType
+		expected     string
+		trailer      http.Header
+		expectedErr  error
+	}{
+		{ChecksumCRC32, "7YLNEQ==", nil, nil},
+		{ChecksumCRC32C, "ksgKMQ==", nil, nil},
+		{ChecksumSHA1, "gf6L/odXbD7LIkJvjleEc4KRes8=", nil, nil},
+		{ChecksumSHA256, "iNQmb9TmM40TuEX88olXnSCciXgjuSF9o+Fhk28DFYk=", nil, nil},
+		{ChecksumCRC32, "AAAAAA==", nil, ChecksumMismatch{ChecksumCRC32, "AAAAAA==", "7YLNEQ=="}},
+		// Trailing checksums.
+		{ChecksumCRC32C, "", http.Header{"X-Amz-Checksum-Crc32c": []string{"ksgKMQ=="}}, nil},
+		{ChecksumCRC32C, "", http.Header{"X-Amz-Checksum-Crc32c": []string{"AAAAAA=="}},
+			ChecksumMismatch{ChecksumCRC32C, "AAAAAA==", "ksgKMQ=="}},
+		{ChecksumCRC32C, "", http.Header{}, InvalidChecksum{ChecksumCRC32C}},
+	}
+
+	for i, testCase := range testCases {
+		r, err := NewReader(bytes.NewReader([]byte("abcd")), 4, "", "", 4)
+		if err != nil {
+			t.Fatalf("Test %d: Initializing reader failed %s", i+1, err)
+		}
+		if err = r.AddChecksum(testCase.checksumType, testCase.expected, testCase.trailer); err != nil {
+			t.Fatalf("Test %d: Adding checksum failed %s", i+1, err)
+		}
+		_, err = ioutil.ReadAll(r)
+		if err != testCase.expectedErr {
+			t.Fatalf("Test %d: expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+		if checksum := r.Checksum(); (err == nil) != (checksum != nil) {
+			t.Fatalf("Test %d: unexpected verified checksum %v", i+1, checksum)
+		}
+	}
+
+	// Checksums verified by an inner reader are reported by the outer one.
+	inner, err := NewReader(bytes.NewReader([]byte("abcd")), 4, "", "", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = inner.AddChecksum(ChecksumCRC32, "7YLNEQ==", nil); err != nil {
+		t.Fatal(err)
+	}
+	outer, err := NewReader(ioutil.NopCloser(inner), -1, "", "", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outer.InheritChecksum(inner)
+	if _, err = ioutil.ReadAll(outer); err != nil {
+		t.Fatal(err)
+	}
+	if checksum := outer.Checksum(); checksum == nil || checksum.Encoded != "7YLNEQ==" {
+		t.Fatalf("Expected inherited checksum, got %v", checksum)
+	}
+}
@@ -35,3 +35,25 @@ type BadDigest struct {
 func (e BadDigest) Error() string {
 	return "Bad digest: Expected " + e.ExpectedMD5 + " is not valid with what we calculated " + e.CalculatedMD5
 }
+
+// ChecksumMismatch - the additional checksum you specified did not match
+// what we received.
+type ChecksumMismatch struct {
+	Type       ChecksumType
+	Expected   string
+	Calculated string
+}
+
+func (e ChecksumMismatch) Error() string {
+	return "Bad " + string(e.Type) + " checksum: Expected " + e.Expected + " is not valid with what we calculated " + e.Calculated
+}
+
+// InvalidChecksum - the additional checksum you specified is missing, is
+// not valid for its algorithm or is not the only one.
+type InvalidChecksum struct {
+	Type ChecksumType
+}
+
+func (e InvalidChecksum) Error() string {
+	return "Invalid " + string(e.Type) + " checksum"
+}
@@ -24,6 +24,7 @@ import (
 	"errors"
 	"hash"
 	"io"
+	"net/http"
 
 	sha256 "github.com/minio/sha256-simd"
 )
@@ -39,6 +40,15 @@ type Reader struct {
 
 	md5sum, sha256sum   []byte // Byte values of md5sum, sha256sum of client sent values.
 	md5Hash, sha256Hash hash.Hash
+
+	// Additional checksum of the content, expected either from the
+	// client sent value or from the trailer.
+	checksumType     ChecksumType
+	checksumHash     hash.Hash
+	expectedChecksum string
+	trailer          http.Header
+	checksum         *Checksum // Set once verified.
+	inner            *Reader   // Reports the checksum, if set.
 }
 
 // NewReader returns a new hash Reader which computes the MD5 sum and
@@ -83,6 +93,9 @@ func (r *Reader) Read(p []byte) (n int, err error) {
 		if r.sha256Hash != nil {
 			r.sha256Hash.Write(p[:n])
 		}
+		if r.checksumHash != nil {
+			r.checksumHash.Write(p[:n])
+		}
 	}
 
 	// At io.EOF verify if the checksums are right.
@@ -137,8 +150,44 @@ func (r *Reader) SHA256HexString() string {
 	return hex.EncodeToString(r.sha256sum)
 }
 
+// AddChecksum - computes the additional checksum of type t of the
+// content, it is verified at EOF against expected. When expected is
+// empty the checksum is looked up in trailer once the content is read,
+// as it is sent after the content by streaming uploads.
+func (r *Reader) AddChecksum(t ChecksumType, expected string, trailer http.Header) error {
+	if expected != "" {
+		if _, err := NewChecksum(t, expected); err != nil {
+			return err
+		}
+	} else if !t.IsValid() {
+		return InvalidChecksum{t}
+	}
+	r.checksumType = t
+	r.checksumHash = t.Hasher()
+	r.expectedChecksum = expected
+	r.trailer = trailer
+	return nil
+}
+
+// InheritChecksum - makes Checksum return the checksum verified by inner,
+// for readers of content transformed from what inner reads, like
+// encrypted or compressed content.
+func (r *Reader) InheritChecksum(inner *Reader) {
+	r.inner = inner
+}
+
+// Checksum - returns the additional checksum of the content once it has
+// been verified, nil otherwise.
+func (r *Reader) Checksum() *Checksum {
+	if r.checksum == nil && r.inner != nil {
+		return r.inner.Checksum()
+	}
+	return r.checksum
+}
+
 // Verify verifies if the computed MD5 sum and SHA256 sum are
-// equal to the ones specified when creating the Reader.
+// equal to the ones specified when creating the Reader, and the
+// additional checksum if any.
 func (r *Reader) Verify() error {
 	if r.sha256Hash != nil && len(r.sha256sum) > 0 {
 		if sum := r.sha256Hash.Sum(nil); !bytes.Equal(r.sha256sum, sum) {
@@ -150,5 +199,19 @@ func (r *Reader) Verify() error {
 			return BadDigest{hex.EncodeToString(r.md5sum), hex.EncodeToString(sum)}
 		}
 	}
+	if r.checksumHash != nil {
+		expected := r.expectedChecksum
+		if expected == "" && r.trailer != nil {
+			expected = r.trailer.Get(r.checksumType.Key())
+		}
+		checksum, err := NewChecksum(r.checksumType, expected)
+		if err != nil {
+			return err
+		}
+		if sum := base64.StdEncoding.EncodeToString(r.checksumHash.Sum(nil)); sum != checksum.Encoded {
+			return ChecksumMismatch{r.checksumType, checksum.Encoded, sum}
+		}
+		r.checksum = checksum
+	}
 	return nil
 }
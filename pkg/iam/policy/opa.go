@@ -18,27 +18,59 @@ package iampolicy
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"os"
 	"time"
 
+	"github.com/patrickmn/go-cache"
 	xnet "github.com/pydio/minio-srv/pkg/net"
 )
 
+// defaultOpaCacheTTL - default duration for which opa decisions are cached.
+const defaultOpaCacheTTL = 5 * time.Second
+
 // OpaArgs opa general purpose policy engine configuration.
 type OpaArgs struct {
 	URL       *xnet.URL `json:"url"`
 	AuthToken string    `json:"authToken"`
+	// FailOpen - allow requests when opa cannot be reached or
+	// returns an invalid response, they are denied otherwise.
+	FailOpen bool `json:"failOpen,omitempty"`
+	// CacheTTL - duration for which decisions are cached, like "10s",
+	// defaults to 5 seconds, "0s" disables the cache.
+	CacheTTL string `json:"cacheTTL,omitempty"`
 }
 
 // Validate - validate opa configuration params.
 func (a *OpaArgs) Validate() error {
+	if _, err := a.cacheTTL(); err != nil {
+		return err
+	}
 	return nil
 }
 
+// cacheTTL - returns the duration for which decisions are cached.
+func (a OpaArgs) cacheTTL() (time.Duration, error) {
+	if a.CacheTTL == "" {
+		return defaultOpaCacheTTL, nil
+	}
+	ttl, err := time.ParseDuration(a.CacheTTL)
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, fmt.Errorf("invalid opa cache TTL '%v'", a.CacheTTL)
+	}
+	return ttl, nil
+}
+
 // UnmarshalJSON - decodes JSON data.
 func (a *OpaArgs) UnmarshalJSON(data []byte) error {
 	// subtype to avoid recursive call to UnmarshalJSON()
@@ -52,6 +84,8 @@ func (a *OpaArgs) UnmarshalJSON(data []byte) error {
 		}
 		so.URL = u
 		so.AuthToken = os.Getenv("MINIO_IAM_OPA_AUTHTOKEN")
+		so.FailOpen = os.Getenv("MINIO_IAM_OPA_FAIL_OPEN") == "on"
+		so.CacheTTL = os.Getenv("MINIO_IAM_OPA_CACHE_TTL")
 	} else {
 		if err := json.Unmarshal(data, &so); err != nil {
 			return err
@@ -72,12 +106,24 @@ func (a *OpaArgs) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// OpaDecision - decision taken by opa for an input, reported to the
+// decision logger.
+type OpaDecision struct {
+	Time   string `json:"time"`
+	Input  Args   `json:"input"`
+	Allow  bool   `json:"allow"`
+	Cached bool   `json:"cached,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
 // Opa - implements opa policy agent calls.
 type Opa struct {
 	args           OpaArgs
 	secureFailed   bool
 	client         *http.Client
 	insecureClient *http.Client
+	cache          *cache.Cache
+	logDecision    func(OpaDecision)
 }
 
 // newCustomHTTPTransport returns a new http configuration
@@ -107,31 +153,128 @@ func NewOpa(args OpaArgs) *Opa {
 	if args.URL == nil && args.AuthToken == "" {
 		return nil
 	}
-	return &Opa{
+	o := &Opa{
 		args:           args,
 		client:         &http.Client{Transport: newCustomHTTPTransport(false)},
 		insecureClient: &http.Client{Transport: newCustomHTTPTransport(true)},
 	}
+	// Invalid TTLs are rejected by Validate(), they disable the cache.
+	if ttl, err := args.cacheTTL(); err == nil && ttl > 0 {
+		o.cache = cache.New(ttl, 2*ttl)
+	}
+	return o
+}
+
+// SetDecisionLogger - sets the function every decision is reported to.
+func (o *Opa) SetDecisionLogger(logDecision func(OpaDecision)) {
+	if o != nil {
+		o.logDecision = logDecision
+	}
+}
+
+// opaVolatileKeys - headers and condition values which change for every
+// request, they are ignored when looking up cached decisions.
+var opaVolatileKeys = []string{
+	"Authorization",
+	"Date",
+	"X-Amz-Date",
+	"X-Amz-Content-Sha256",
+	"X-Amz-Signature",
+	"Signature",
+	"CurrentTime",
+}
+
+// cacheKey - returns the key of the decision for given args in the cache.
+func cacheKey(args Args) (string, error) {
+	args.Headers = withoutKeys(args.Headers, opaVolatileKeys)
+	args.ConditionValues = withoutKeys(args.ConditionValues, opaVolatileKeys)
+
+	data, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// withoutKeys - returns a copy of given values map without given keys.
+func withoutKeys(values map[string][]string, keys []string) map[string][]string {
+	if values == nil {
+		return nil
+	}
+	m := make(map[string][]string, len(values))
+	for k, v := range values {
+		m[k] = v
+	}
+	for _, k := range keys {
+		delete(m, k)
+	}
+	return m
 }
 
 // IsAllowed - checks given policy args is allowed to continue the REST API.
+// Decisions are cached for a short time, when opa cannot be reached the
+// request is allowed only if opa is configured to fail open.
 func (o *Opa) IsAllowed(args Args) bool {
 	if o == nil {
 		return false
 	}
 
+	var key string
+	if o.cache != nil {
+		var err error
+		if key, err = cacheKey(args); err == nil {
+			if allow, ok := o.cache.Get(key); ok {
+				o.log(args, allow.(bool), true, nil)
+				return allow.(bool)
+			}
+		}
+	}
+
+	allow, err := o.query(args)
+	if err != nil {
+		o.log(args, o.args.FailOpen, false, err)
+		return o.args.FailOpen
+	}
+
+	if key != "" {
+		o.cache.SetDefault(key, allow)
+	}
+	o.log(args, allow, false, nil)
+	return allow
+}
+
+// log - reports a decision to the decision logger.
+func (o *Opa) log(args Args, allow, cached bool, err error) {
+	if o.logDecision == nil {
+		return
+	}
+	decision := OpaDecision{
+		Time:   time.Now().UTC().Format(time.RFC3339Nano),
+		Input:  args,
+		Allow:  allow,
+		Cached: cached,
+	}
+	if err != nil {
+		decision.Error = err.Error()
+	}
+	o.logDecision(decision)
+}
+
+// query - asks opa whether given policy args is allowed.
+func (o *Opa) query(args Args) (bool, error) {
 	// OPA input
 	body := make(map[string]interface{})
 	body["input"] = args
 
 	inputBytes, err := json.Marshal(body)
 	if err != nil {
-		return false
+		return false, err
 	}
 
 	req, err := http.NewRequest("POST", o.args.URL.String(), bytes.NewReader(inputBytes))
 	if err != nil {
-		return false
+		return false, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -146,17 +289,19 @@ func (o *Opa) IsAllowed(args Args) bool {
 		resp, err = o.client.Do(req)
 		if err != nil {
 			o.secureFailed = true
+			req.Body = ioutil.NopCloser(bytes.NewReader(inputBytes))
 			resp, err = o.insecureClient.Do(req)
-			if err != nil {
-				return false
-			}
 		}
 	}
 	if err != nil {
-		return false
+		return false, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("opa returned %v", resp.Status)
+	}
+
 	// Handle OPA response
 	type opaResponse struct {
 		Result struct {
@@ -165,8 +310,8 @@ func (o *Opa) IsAllowed(args Args) bool {
 	}
 	var result opaResponse
 	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return false
+		return false, err
 	}
 
-	return result.Result.Allow
+	return result.Result.Allow, nil
 }
@@ -0,0 +1,230 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package iampolicy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	xnet "github.com/pydio/minio-srv/pkg/net"
+)
+
+// opaTestServer - stand-in opa server allowing the requests of user "allowed".
+type opaTestServer struct {
+	sync.Mutex
+	*httptest.Server
+	inputs []Args
+	status int
+}
+
+func newOpaTestServer() *opaTestServer {
+	s := &opaTestServer{status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input Args `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		s.Lock()
+		defer s.Unlock()
+		s.inputs = append(s.inputs, body.Input)
+		if s.status != http.StatusOK {
+			w.WriteHeader(s.status)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": map[string]bool{"allow": body.Input.AccountName == "allowed"},
+		})
+	}))
+	return s
+}
+
+func (s *opaTestServer) calls() int {
+	s.Lock()
+	defer s.Unlock()
+	return len(s.inputs)
+}
+
+func newTestOpa(t *testing.T, s *opaTestServer, failOpen bool, cacheTTL string) *Opa {
+	u, err := xnet.ParseURL(s.URL)
+	if err != nil {
+		t.Fatalf("unexpected error. %v\n", err)
+	}
+	return NewOpa(OpaArgs{URL: u, FailOpen: failOpen, CacheTTL: cacheTTL})
+}
+
+func TestOpaIsAllowed(t *testing.T) {
+	s := newOpaTestServer()
+	defer s.Close()
+
+	o := newTestOpa(t, s, false, "0s")
+	var decisions []OpaDecision
+	o.SetDecisionLogger(func(d OpaDecision) {
+		decisions = append(decisions, d)
+	})
+
+	args := Args{
+		AccountName:     "allowed",
+		Action:          GetObjectAction,
+		BucketName:      "mybucket",
+		ObjectName:      "myobject",
+		ConditionValues: map[string][]string{"SourceIp": {"192.168.1.10"}},
+		Claims:          map[string]interface{}{"sub": "1234"},
+		Headers:         map[string][]string{"X-Amz-Meta-Project": {"alpha"}},
+		ObjectTags:      map[string]string{"project": "alpha"},
+	}
+	if !o.IsAllowed(args) {
+		t.Fatalf("expected allowed")
+	}
+	args.AccountName = "denied"
+	if o.IsAllowed(args) {
+		t.Fatalf("expected denied")
+	}
+
+	// The full request context is sent to opa.
+	if s.calls() != 2 {
+		t.Fatalf("expected 2 opa calls, got %v", s.calls())
+	}
+	input := s.inputs[0]
+	if input.ConditionValues["SourceIp"][0] != "192.168.1.10" || input.Claims["sub"] != "1234" ||
+		input.Headers["X-Amz-Meta-Project"][0] != "alpha" || input.ObjectTags["project"] != "alpha" {
+		t.Fatalf("unexpected opa input %v", input)
+	}
+
+	if len(decisions) != 2 || !decisions[0].Allow || decisions[1].Allow || decisions[0].Cached {
+		t.Fatalf("unexpected decisions %v", decisions)
+	}
+}
+
+func TestOpaIsAllowedCache(t *testing.T) {
+	s := newOpaTestServer()
+	defer s.Close()
+
+	o := newTestOpa(t, s, false, "")
+	var decisions []OpaDecision
+	o.SetDecisionLogger(func(d OpaDecision) {
+		decisions = append(decisions, d)
+	})
+
+	args := Args{
+		AccountName: "allowed",
+		Action:      GetObjectAction,
+		BucketName:  "mybucket",
+		ConditionValues: map[string][]string{
+			"CurrentTime": {"2019-01-01T00:00:00Z"},
+		},
+		Headers: map[string][]string{"X-Amz-Date": {"20190101T000000Z"}},
+	}
+	if !o.IsAllowed(args) {
+		t.Fatalf("expected allowed")
+	}
+
+	// Volatile values are ignored by the cache.
+	args.ConditionValues = map[string][]string{"CurrentTime": {"2019-01-01T00:00:01Z"}}
+	args.Headers = map[string][]string{"X-Amz-Date": {"20190101T000001Z"}}
+	if !o.IsAllowed(args) {
+		t.Fatalf("expected allowed")
+	}
+	if s.calls() != 1 {
+		t.Fatalf("expected 1 opa call, got %v", s.calls())
+	}
+	if len(decisions) != 2 || !decisions[1].Cached {
+		t.Fatalf("unexpected decisions %v", decisions)
+	}
+
+	// Different inputs are not served from the cache.
+	args.ObjectName = "myobject"
+	if !o.IsAllowed(args) {
+		t.Fatalf("expected allowed")
+	}
+	if s.calls() != 2 {
+		t.Fatalf("expected 2 opa calls, got %v", s.calls())
+	}
+}
+
+func TestOpaIsAllowedFailure(t *testing.T) {
+	s := newOpaTestServer()
+	s.status = http.StatusInternalServerError
+	defer s.Close()
+
+	args := Args{AccountName: "allowed", Action: GetObjectAction, BucketName: "mybucket"}
+
+	failClosed := newTestOpa(t, s, false, "")
+	var decisions []OpaDecision
+	failClosed.SetDecisionLogger(func(d OpaDecision) {
+		decisions = append(decisions, d)
+	})
+	if failClosed.IsAllowed(args) {
+		t.Fatalf("expected denied")
+	}
+	if len(decisions) != 1 || decisions[0].Error == "" {
+		t.Fatalf("unexpected decisions %v", decisions)
+	}
+
+	failOpen := newTestOpa(t, s, true, "")
+	args.AccountName = "denied"
+	if !failOpen.IsAllowed(args) {
+		t.Fatalf("expected allowed")
+	}
+
+	// Failures are not cached.
+	s.Lock()
+	s.status = http.StatusOK
+	s.Unlock()
+	if failOpen.IsAllowed(args) {
+		t.Fatalf("expected denied")
+	}
+
+	// Unreachable opa.
+	s.Close()
+	args.ObjectName = "myobject"
+	if failClosed.IsAllowed(args) {
+		t.Fatalf("expected denied")
+	}
+	if !failOpen.IsAllowed(args) {
+		t.Fatalf("expected allowed")
+	}
+}
+
+func TestOpaArgsValidate(t *testing.T) {
+	testCases := []struct {
+		cacheTTL  string
+		expectErr bool
+	}{
+		{"", false},
+		{"0s", false},
+		{"30s", false},
+		{"-1s", true},
+		{"ten seconds", true},
+	}
+
+	for i, testCase := range testCases {
+		args := OpaArgs{CacheTTL: testCase.cacheTTL}
+		err := args.Validate()
+		expectErr := (err != nil)
+
+		if expectErr != testCase.expectErr {
+			t.Fatalf("case %v: error: expected: %v, got: %v", i+1, testCase.expectErr, expectErr)
+		}
+	}
+}
@@ -20,8 +20,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/pydio/minio-srv/pkg/policy"
+	"github.com/pydio/minio-srv/pkg/policy/condition"
 )
 
 // DefaultVersion - default policy version as per AWS S3 specification.
@@ -36,6 +38,9 @@ type Args struct {
 	IsOwner         bool                   `json:"owner"`
 	ObjectName      string                 `json:"object"`
 	Claims          map[string]interface{} `json:"claims"`
+	// Headers and ObjectTags are only used by opa.
+	Headers    map[string][]string `json:"headers,omitempty"`
+	ObjectTags map[string]string   `json:"objectTags,omitempty"`
 }
 
 // Policy - iam bucket iamp.
@@ -45,12 +50,58 @@ type Policy struct {
 	Statements []Statement `json:"Statement"`
 }
 
+// conditionValues - returns condition values of args along with the values
+// of policy variables derived from the account name and the JWT claims.
+func (args Args) conditionValues() map[string][]string {
+	values := make(map[string][]string, len(args.ConditionValues)+len(args.Claims)+2)
+	for key, value := range args.ConditionValues {
+		// Claims are only taken from the token, never from the request.
+		if !strings.HasPrefix(key, condition.JWTPrefix) {
+			values[key] = value
+		}
+	}
+
+	if args.AccountName != "" {
+		for _, key := range []condition.Key{condition.AWSUsername, condition.AWSUserID} {
+			if _, found := values[key.Name()]; !found {
+				values[key.Name()] = []string{args.AccountName}
+			}
+		}
+	}
+
+	for claim, value := range args.Claims {
+		var claimValues []string
+		switch v := value.(type) {
+		case string:
+			claimValues = []string{v}
+		case []string:
+			claimValues = v
+		case []interface{}:
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					claimValues = append(claimValues, s)
+				}
+			}
+		}
+
+		if len(claimValues) > 0 {
+			values[condition.JWTPrefix+claim] = claimValues
+		}
+	}
+
+	return values
+}
+
 // IsAllowed - checks given policy args is allowed to continue the Rest API.
+// Policy variables in resources and condition values are substituted by
+// their value for the request.
 func (iamp Policy) IsAllowed(args Args) bool {
+	conditionValues := args.conditionValues()
+
 	// Check all deny statements. If any one statement denies, return false.
 	for _, statement := range iamp.Statements {
 		if statement.Effect == policy.Deny {
-			if !statement.IsAllowed(args) {
+			if !statement.isAllowed(args, conditionValues) {
 				return false
 			}
 		}
@@ -64,7 +115,7 @@ func (iamp Policy) IsAllowed(args Args) bool {
 	// Check all allow statements. If any one statement allows, return true.
 	for _, statement := range iamp.Statements {
 		if statement.Effect == policy.Allow {
-			if statement.IsAllowed(args) {
+			if statement.isAllowed(args, conditionValues) {
 				return true
 			}
 		}
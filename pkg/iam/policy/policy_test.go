@@ -178,6 +178,79 @@ func TestPolicyIsAllowed(t *testing.T) {
 	}
 }
 
+func TestPolicyIsAllowedPolicyVariables(t *testing.T) {
+	func1, err := condition.NewStringLikeFunc(condition.S3Prefix, "home/${aws:username}/*")
+	if err != nil {
+		t.Fatalf("unexpected error. %v\n", err)
+	}
+
+	homePolicy := Policy{
+		Version: DefaultVersion,
+		Statements: []Statement{
+			NewStatement(
+				policy.Allow,
+				NewActionSet(GetObjectAction, PutObjectAction),
+				NewResourceSet(NewResource("home", "${aws:username}/*")),
+				condition.NewFunctions(),
+			),
+			NewStatement(
+				policy.Allow,
+				NewActionSet(ListBucketAction),
+				NewResourceSet(NewResource("home", "")),
+				condition.NewFunctions(func1),
+			),
+			NewStatement(
+				policy.Deny,
+				NewActionSet(PutObjectAction),
+				NewResourceSet(NewResource("shared", "${jwt:sub}/*")),
+				condition.NewFunctions(),
+			),
+			NewStatement(
+				policy.Allow,
+				NewActionSet(PutObjectAction),
+				NewResourceSet(NewResource("shared", "*")),
+				condition.NewFunctions(),
+			),
+		},
+	}
+
+	claims := map[string]interface{}{"sub": "alice-id"}
+
+	testCases := []struct {
+		args           Args
+		expectedResult bool
+	}{
+		{Args{AccountName: "alice", Action: GetObjectAction, BucketName: "home", ObjectName: "alice/file"}, true},
+		{Args{AccountName: "alice", Action: GetObjectAction, BucketName: "home", ObjectName: "bob/file"}, false},
+		{Args{AccountName: "alice", Action: PutObjectAction, BucketName: "home", ObjectName: "alice/dir/file"}, true},
+		// Condition value username takes precedence over the account name.
+		{Args{AccountName: "AKIAALICE", Action: GetObjectAction, BucketName: "home", ObjectName: "alice/file",
+			ConditionValues: map[string][]string{"username": {"alice"}}}, true},
+		{Args{AccountName: "AKIAALICE", Action: GetObjectAction, BucketName: "home", ObjectName: "AKIAALICE/file",
+			ConditionValues: map[string][]string{"username": {"alice"}}}, false},
+		// Variables in condition values.
+		{Args{AccountName: "alice", Action: ListBucketAction, BucketName: "home",
+			ConditionValues: map[string][]string{"prefix": {"home/alice/photos/"}}}, true},
+		{Args{AccountName: "alice", Action: ListBucketAction, BucketName: "home",
+			ConditionValues: map[string][]string{"prefix": {"home/bob/photos/"}}}, false},
+		// Variables without a value never match.
+		{Args{Action: GetObjectAction, BucketName: "home", ObjectName: "alice/file"}, false},
+		// Claims are taken from the token, not from the request.
+		{Args{AccountName: "alice", Action: PutObjectAction, BucketName: "shared", ObjectName: "alice-id/file", Claims: claims}, false},
+		{Args{AccountName: "alice", Action: PutObjectAction, BucketName: "shared", ObjectName: "bob-id/file", Claims: claims}, true},
+		{Args{AccountName: "alice", Action: PutObjectAction, BucketName: "shared", ObjectName: "bob-id/file", Claims: claims,
+			ConditionValues: map[string][]string{"jwt:sub": {"bob-id"}}}, true},
+	}
+
+	for i, testCase := range testCases {
+		result := homePolicy.IsAllowed(testCase.args)
+
+		if result != testCase.expectedResult {
+			t.Fatalf("case %v: expected: %v, got: %v\n", i+1, testCase.expectedResult, result)
+		}
+	}
+}
+
 func TestPolicyIsEmpty(t *testing.T) {
 	case1Policy := Policy{
 		Version: DefaultVersion,
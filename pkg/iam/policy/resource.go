@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/pydio/minio-srv/pkg/policy/condition"
 	"github.com/pydio/minio-srv/pkg/wildcard"
 )
 
@@ -46,12 +47,14 @@ func (r Resource) IsValid() bool {
 	return r.Pattern != ""
 }
 
-// Match - matches object name with resource pattern.
-func (r Resource) Match(resource string) bool {
-	if strings.HasPrefix(resource, r.Pattern) {
+// Match - matches object name with resource pattern, policy variables in
+// the pattern are substituted by their value in given condition values.
+func (r Resource) Match(resource string, conditionValues map[string][]string) bool {
+	pattern := condition.SubstituteVariables(r.Pattern, conditionValues)
+	if strings.HasPrefix(resource, pattern) {
 		return true
 	}
-	return wildcard.Match(r.Pattern, resource)
+	return wildcard.Match(pattern, resource)
 }
 
 // MarshalJSON - encodes Resource to JSON data.
@@ -99,6 +99,8 @@ func TestResourceIsValid(t *testing.T) {
 }
 
 func TestResourceMatch(t *testing.T) {
+	conditionValues := map[string][]string{"username": {"alice"}}
+
 	testCases := []struct {
 		resource       Resource
 		objectName     string
@@ -121,10 +123,13 @@ func TestResourceMatch(t *testing.T) {
 		{NewResource("mybucket", "*"), "mybucket10/myobject", false},
 		{NewResource("mybucket?0", "/2010/photos/*"), "mybucket0/2010/photos/1.jpg", false},
 		{NewResource("mybucket", ""), "mybucket/myobject", true},
+		{NewResource("home", "${aws:username}/*"), "home/alice/myobject", true},
+		{NewResource("home", "${aws:username}/*"), "home/bob/myobject", false},
+		{NewResource("home", "${aws:userid}/*"), "home/alice/myobject", false},
 	}
 
 	for i, testCase := range testCases {
-		result := testCase.resource.Match(testCase.objectName)
+		result := testCase.resource.Match(testCase.objectName, conditionValues)
 
 		if result != testCase.expectedResult {
 			t.Fatalf("case %v: expected: %v, got: %v", i+1, testCase.expectedResult, result)
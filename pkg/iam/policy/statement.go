@@ -36,6 +36,12 @@ type Statement struct {
 
 // IsAllowed - checks given policy args is allowed to continue the Rest API.
 func (statement Statement) IsAllowed(args Args) bool {
+	return statement.isAllowed(args, args.conditionValues())
+}
+
+// isAllowed - checks given policy args is allowed with given condition
+// values, which also hold the values of policy variables.
+func (statement Statement) isAllowed(args Args, conditionValues map[string][]string) bool {
 	check := func() bool {
 		if !statement.Actions.Match(args.Action) {
 			return false
@@ -52,11 +58,11 @@ func (statement Statement) IsAllowed(args Args) bool {
 			resource += "/"
 		}
 
-		if !statement.Resources.Match(resource) {
+		if !statement.Resources.Match(resource, conditionValues) {
 			return false
 		}
 
-		return statement.Conditions.Evaluate(args.ConditionValues)
+		return statement.Conditions.Evaluate(conditionValues)
 	}
 
 	return statement.Effect.IsAllowed(check())
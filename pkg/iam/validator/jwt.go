@@ -130,7 +130,9 @@ func expToInt64(expI interface{}) (expAt int64, err error) {
 	return expAt, nil
 }
 
-func getDefaultExpiration(dsecs string) (time.Duration, error) {
+// GetDefaultExpiration - returns the duration of the temporary credentials
+// requested by dsecs, the DurationSeconds STS parameter. It defaults to 1hr.
+func GetDefaultExpiration(dsecs string) (time.Duration, error) {
 	defaultExpiryDuration := time.Duration(60) * time.Minute // Defaults to 1hr.
 	if dsecs != "" {
 		expirySecs, err := strconv.ParseInt(dsecs, 10, 64)
@@ -190,6 +192,9 @@ func (p *JWT) Validate(token, dsecs string) (map[string]interface{}, error) {
 		}
 		jwtToken, err = jwtgo.ParseWithClaims(token, &claims, keyFuncCallback)
 		if err != nil {
+			if verr, ok := err.(*jwtgo.ValidationError); ok && verr.Errors&jwtgo.ValidationErrorExpired != 0 {
+				return nil, ErrTokenExpired
+			}
 			return nil, err
 		}
 	}
@@ -203,7 +208,7 @@ func (p *JWT) Validate(token, dsecs string) (map[string]interface{}, error) {
 		return nil, err
 	}
 
-	defaultExpiryDuration, err := getDefaultExpiration(dsecs)
+	defaultExpiryDuration, err := GetDefaultExpiration(dsecs)
 	if err != nil {
 		return nil, err
 	}
@@ -212,10 +217,13 @@ func (p *JWT) Validate(token, dsecs string) (map[string]interface{}, error) {
 		defaultExpiryDuration = time.Unix(expAt, 0).UTC().Sub(time.Now().UTC())
 	}
 
+	// Temporary credentials expire at the end of the requested
+	// duration, or with the token if it expires earlier.
 	expiry := time.Now().UTC().Add(defaultExpiryDuration).Unix()
 	if expAt < expiry {
-		claims["exp"] = strconv.FormatInt(expAt, 64)
+		expiry = expAt
 	}
+	claims["exp"] = float64(expiry)
 
 	return claims, nil
 
@@ -0,0 +1,70 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package madmin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// CachePendingUpload - object written to a cache drive in writeback mode
+// and not uploaded to the backend yet.
+type CachePendingUpload struct {
+	Drive     string    `json:"drive"`
+	Bucket    string    `json:"bucket"`
+	Object    string    `json:"object"`
+	Size      int64     `json:"size"`
+	Created   time.Time `json:"created"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"lastError,omitempty"`
+	NextRetry time.Time `json:"nextRetry"`
+}
+
+// CachePendingUploads - lists the objects of the disk cache waiting to be
+// uploaded to the backend.
+func (adm *AdminClient) CachePendingUploads() ([]CachePendingUpload, error) {
+	reqData := requestData{
+		relPath: "/v1/cache/pending",
+	}
+
+	// Execute GET on /minio/admin/v1/cache/pending
+	resp, err := adm.executeMethod("GET", reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []CachePendingUpload
+	if err = json.Unmarshal(respBytes, &pending); err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
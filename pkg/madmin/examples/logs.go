@@ -0,0 +1,56 @@
+// +build ignore
+
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package main
+
+import (
+	"log"
+
+	"github.com/pydio/minio-srv/pkg/madmin"
+)
+
+func main() {
+	// Note: YOUR-ACCESSKEYID, YOUR-SECRETACCESSKEY are
+	// dummy values, please replace them with original values.
+
+	// API requests are secure (HTTPS) if secure=true and insecure (HTTPS) otherwise.
+	// New returns an Minio Admin client object.
+	madmClnt, err := madmin.New("your-minio.example.com:9000", "YOUR-ACCESSKEYID", "YOUR-SECRETACCESSKEY", true)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	// Tail the errors logged by all nodes, starting with the last 20
+	// errors of each node.
+	opts := madmin.LogOptions{Level: "ERROR", Limit: 20}
+	for logInfo := range madmClnt.GetLogs(opts, doneCh) {
+		if logInfo.Err != nil {
+			log.Fatalln(logInfo.Err)
+		}
+		entry := logInfo.Entry
+		if entry.Trace != nil {
+			log.Println(entry.NodeName, entry.Time, entry.Level, entry.Trace.Message)
+		} else {
+			log.Println(entry.NodeName, entry.Time, entry.Level, entry.Message)
+		}
+	}
+}
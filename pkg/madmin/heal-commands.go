@@ -179,6 +179,22 @@ func (hri *HealResultItem) GetOnlineCounts() (b, a int) {
 	return
 }
 
+// HealingDisk - status of the background healing of a newly
+// formatted disk, which is healed after a drive replacement.
+type HealingDisk struct {
+	Endpoint  string    `json:"endpoint"`
+	UUID      string    `json:"uuid"`
+	SetIndex  int       `json:"setIndex"`
+	DiskIndex int       `json:"diskIndex"`
+	Started   time.Time `json:"started"`
+	Updated   time.Time `json:"updated"`
+	// Bucket being healed and the last healed object.
+	Bucket        string `json:"bucket"`
+	Object        string `json:"object"`
+	ObjectsHealed uint64 `json:"objectsHealed"`
+	ObjectsFailed uint64 `json:"objectsFailed"`
+}
+
 // Heal - API endpoint to start heal and to fetch status
 // forceStart and forceStop are mutually exclusive, you can either
 // set one of them to 'true'. If both are set 'forceStart' will be
@@ -109,6 +109,8 @@ type ServerInfoData struct {
 	ConnStats   ServerConnStats  `json:"network"`
 	HTTPStats   ServerHTTPStats  `json:"http"`
 	Properties  ServerProperties `json:"server"`
+	// Disks of the server being healed in the background.
+	Healing []HealingDisk `json:"healing,omitempty"`
 }
 
 // ServerInfo holds server information result of one node
@@ -0,0 +1,117 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package madmin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// LockType - type of a namespace lock, read or write.
+type LockType string
+
+// Namespace lock types.
+const (
+	ReadLock  LockType = "RLock"
+	WriteLock LockType = "WLock"
+)
+
+// LockStatus - status of a namespace lock, held or waiting.
+type LockStatus string
+
+// Namespace lock statuses.
+const (
+	LockHeld    LockStatus = "Held"
+	LockWaiting LockStatus = "Waiting"
+)
+
+// OpsLockState - state of the lock held or waited for by an operation.
+type OpsLockState struct {
+	OperationID string        `json:"id"`
+	LockSource  string        `json:"source"`
+	LockType    LockType      `json:"type"`
+	Status      LockStatus    `json:"status"`
+	Since       time.Time     `json:"since"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// VolumeLockInfo - locks held or waited for on an object of a node.
+type VolumeLockInfo struct {
+	Node        string         `json:"node"`
+	Bucket      string         `json:"bucket"`
+	Object      string         `json:"object"`
+	LockDetails []OpsLockState `json:"lockDetails"`
+}
+
+// lockQueryValues - returns the query values selecting the locks on
+// objects under bucket/prefix older than olderThan.
+func lockQueryValues(bucket, prefix string, olderThan time.Duration) url.Values {
+	queryVal := make(url.Values)
+	queryVal.Set("bucket", bucket)
+	queryVal.Set("prefix", prefix)
+	queryVal.Set("older-than", olderThan.String())
+	return queryVal
+}
+
+// getLockInfos - executes the lock request and decodes its reply.
+func (adm *AdminClient) getLockInfos(method string, queryVal url.Values) ([]VolumeLockInfo, error) {
+	reqData := requestData{
+		relPath:     "/v1/locks",
+		queryValues: queryVal,
+	}
+
+	resp, err := adm.executeMethod(method, reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var volLocks []VolumeLockInfo
+	if err = json.Unmarshal(respBytes, &volLocks); err != nil {
+		return nil, err
+	}
+
+	return volLocks, nil
+}
+
+// ListLocks - lists the namespace locks held or waited for on objects
+// under bucket/prefix for longer than olderThan, on all the nodes.
+func (adm *AdminClient) ListLocks(bucket, prefix string, olderThan time.Duration) ([]VolumeLockInfo, error) {
+	// Execute GET on /minio/admin/v1/locks
+	return adm.getLockInfos("GET", lockQueryValues(bucket, prefix, olderThan))
+}
+
+// ClearLocks - force-releases the namespace locks of the objects under
+// bucket/prefix held for longer than olderThan by all their holders, on
+// all the nodes, and returns the cleared locks.
+func (adm *AdminClient) ClearLocks(bucket, prefix string, olderThan time.Duration) ([]VolumeLockInfo, error) {
+	// Execute DELETE on /minio/admin/v1/locks
+	return adm.getLockInfos("DELETE", lockQueryValues(bucket, prefix, olderThan))
+}
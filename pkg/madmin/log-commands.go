@@ -0,0 +1,106 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package madmin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pydio/minio-srv/cmd/logger/message/log"
+)
+
+// LogOptions - selects the log entries streamed by GetLogs.
+type LogOptions struct {
+	Level string // Only entries of this level: "INFO", "ERROR" or "FATAL".
+	Node  string // Only entries of this node, for example "host:9000".
+	// Number of recent entries of each node sent first, the server
+	// default is used when 0 and no entries are sent when negative.
+	Limit int
+}
+
+// LogInfo - represents a log entry, additionally
+// also reports errors if any while listening on logs.
+type LogInfo struct {
+	Entry log.Entry `json:"entry"`
+	Err   error     `json:"-"`
+}
+
+// GetLogs - listens to the log entries of all the nodes of the server
+// matching opts, until doneCh is closed. The returned channel is closed
+// when the log stream ends.
+func (adm *AdminClient) GetLogs(opts LogOptions, doneCh <-chan struct{}) <-chan LogInfo {
+	logInfoCh := make(chan LogInfo)
+	go func() {
+		defer close(logInfoCh)
+
+		v := url.Values{}
+		if opts.Level != "" {
+			v.Set("level", opts.Level)
+		}
+		if opts.Node != "" {
+			v.Set("node", opts.Node)
+		}
+		if opts.Limit > 0 {
+			v.Set("limit", strconv.Itoa(opts.Limit))
+		} else if opts.Limit < 0 {
+			v.Set("limit", "0")
+		}
+
+		resp, err := adm.executeMethod("GET", requestData{
+			relPath:     "/v1/log",
+			queryValues: v,
+		})
+		if err != nil {
+			closeResponse(resp)
+			logInfoCh <- LogInfo{Err: err}
+			return
+		}
+		if resp.StatusCode != http.StatusOK {
+			err = httpRespToErrorResponse(resp)
+			closeResponse(resp)
+			logInfoCh <- LogInfo{Err: err}
+			return
+		}
+
+		// Stop reading the stream once the caller is done.
+		go func() {
+			<-doneCh
+			resp.Body.Close()
+		}()
+
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var entry log.Entry
+			if err = dec.Decode(&entry); err != nil {
+				select {
+				case <-doneCh:
+				default:
+					logInfoCh <- LogInfo{Err: err}
+				}
+				return
+			}
+			select {
+			case logInfoCh <- LogInfo{Entry: entry}:
+			case <-doneCh:
+				return
+			}
+		}
+	}()
+	return logInfoCh
+}
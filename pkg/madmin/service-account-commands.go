@@ -0,0 +1,170 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package madmin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+// ServiceAccountInfo - a service account along with the policy
+// restricting it, if any.
+type ServiceAccountInfo struct {
+	AccessKey  string          `json:"accessKey"`
+	ParentUser string          `json:"parentUser"`
+	Status     AccountStatus   `json:"status"`
+	Policy     json.RawMessage `json:"policy,omitempty"`
+}
+
+// ServiceAccountCreds - credentials of a service account, returned when
+// the service account is created and when its secret key is rotated.
+type ServiceAccountCreds struct {
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+}
+
+// AddServiceAccount - creates a service account owned by parentUser. The
+// service account gets the permissions of its parent user, narrowed by
+// the optional policy.
+func (adm *AdminClient) AddServiceAccount(parentUser string, policy []byte) (ServiceAccountCreds, error) {
+	queryValues := url.Values{}
+	queryValues.Set("user", parentUser)
+
+	reqData := requestData{
+		relPath:     "/v1/add-service-account",
+		queryValues: queryValues,
+		content:     policy,
+	}
+
+	// Execute PUT on /minio/admin/v1/add-service-account
+	resp, err := adm.executeMethod("PUT", reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return ServiceAccountCreds{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return ServiceAccountCreds{}, httpRespToErrorResponse(resp)
+	}
+
+	return adm.decryptServiceAccountCreds(resp)
+}
+
+// ListServiceAccounts - lists the service accounts owned by parentUser.
+func (adm *AdminClient) ListServiceAccounts(parentUser string) ([]ServiceAccountInfo, error) {
+	queryValues := url.Values{}
+	queryValues.Set("user", parentUser)
+
+	reqData := requestData{
+		relPath:     "/v1/list-service-accounts",
+		queryValues: queryValues,
+	}
+
+	// Execute GET on /minio/admin/v1/list-service-accounts
+	resp, err := adm.executeMethod("GET", reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceAccounts := []ServiceAccountInfo{}
+	if err = json.Unmarshal(data, &serviceAccounts); err != nil {
+		return nil, err
+	}
+
+	return serviceAccounts, nil
+}
+
+// RotateServiceAccount - replaces the secret key of a service account.
+func (adm *AdminClient) RotateServiceAccount(accessKey string) (ServiceAccountCreds, error) {
+	queryValues := url.Values{}
+	queryValues.Set("accessKey", accessKey)
+
+	reqData := requestData{
+		relPath:     "/v1/rotate-service-account",
+		queryValues: queryValues,
+	}
+
+	// Execute PUT on /minio/admin/v1/rotate-service-account
+	resp, err := adm.executeMethod("PUT", reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return ServiceAccountCreds{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return ServiceAccountCreds{}, httpRespToErrorResponse(resp)
+	}
+
+	return adm.decryptServiceAccountCreds(resp)
+}
+
+// RemoveServiceAccount - revokes a service account.
+func (adm *AdminClient) RemoveServiceAccount(accessKey string) error {
+	queryValues := url.Values{}
+	queryValues.Set("accessKey", accessKey)
+
+	reqData := requestData{
+		relPath:     "/v1/remove-service-account",
+		queryValues: queryValues,
+	}
+
+	// Execute DELETE on /minio/admin/v1/remove-service-account
+	resp, err := adm.executeMethod("DELETE", reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+
+	return nil
+}
+
+// decryptServiceAccountCreds - decodes the encrypted credentials of a
+// service account in resp.
+func (adm *AdminClient) decryptServiceAccountCreds(resp *http.Response) (ServiceAccountCreds, error) {
+	data, err := DecryptData(adm.secretAccessKey, resp.Body)
+	if err != nil {
+		return ServiceAccountCreds{}, err
+	}
+
+	var creds ServiceAccountCreds
+	if err = json.Unmarshal(data, &creds); err != nil {
+		return ServiceAccountCreds{}, err
+	}
+
+	return creds, nil
+}
@@ -0,0 +1,104 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package madmin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pydio/minio-srv/pkg/trace"
+)
+
+// TraceOptions - selects the trace records streamed by Trace.
+type TraceOptions struct {
+	ErrorsOnly bool   // Only failed requests.
+	API        string // Only requests to this API, for example "PutObject".
+	Bucket     string // Only requests to this bucket.
+	Body       bool   // Include the recorded request and response bodies.
+	Internal   bool   // Include the internal requests between nodes.
+}
+
+// TraceInfo - represents a trace record, additionally
+// also reports errors if any while listening on trace.
+type TraceInfo struct {
+	Trace trace.Info `json:"trace"`
+	Err   error      `json:"-"`
+}
+
+// Trace - listens to the HTTP trace records of all the nodes of the
+// server matching opts, until doneCh is closed. The returned channel
+// is closed when the trace stream ends.
+func (adm *AdminClient) Trace(opts TraceOptions, doneCh <-chan struct{}) <-chan TraceInfo {
+	traceInfoCh := make(chan TraceInfo)
+	go func() {
+		defer close(traceInfoCh)
+
+		v := url.Values{}
+		v.Set("errors", strconv.FormatBool(opts.ErrorsOnly))
+		v.Set("body", strconv.FormatBool(opts.Body))
+		v.Set("internal", strconv.FormatBool(opts.Internal))
+		if opts.API != "" {
+			v.Set("api", opts.API)
+		}
+		if opts.Bucket != "" {
+			v.Set("bucket", opts.Bucket)
+		}
+
+		resp, err := adm.executeMethod("GET", requestData{
+			relPath:     "/v1/trace",
+			queryValues: v,
+		})
+		if err != nil {
+			closeResponse(resp)
+			traceInfoCh <- TraceInfo{Err: err}
+			return
+		}
+		if resp.StatusCode != http.StatusOK {
+			err = httpRespToErrorResponse(resp)
+			closeResponse(resp)
+			traceInfoCh <- TraceInfo{Err: err}
+			return
+		}
+
+		// Stop reading the stream once the caller is done.
+		go func() {
+			<-doneCh
+			resp.Body.Close()
+		}()
+
+		dec := json.NewDecoder(resp.Body)
+		for {
+			var info trace.Info
+			if err = dec.Decode(&info); err != nil {
+				select {
+				case <-doneCh:
+				default:
+					traceInfoCh <- TraceInfo{Err: err}
+				}
+				return
+			}
+			select {
+			case traceInfoCh <- TraceInfo{Trace: info}:
+			case <-doneCh:
+				return
+			}
+		}
+	}()
+	return traceInfoCh
+}
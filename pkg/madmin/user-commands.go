@@ -19,8 +19,10 @@ package madmin
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/pydio/minio-srv/pkg/auth"
 )
@@ -39,6 +41,20 @@ type UserInfo struct {
 	SecretKey  string        `json:"secretKey,omitempty"`
 	PolicyName string        `json:"policyName,omitempty"`
 	Status     AccountStatus `json:"status"`
+	// The access key can't be used past its expiration, if set.
+	Expiration *time.Time `json:"expiration,omitempty"`
+	// Last successful authentication with the access key, as tracked
+	// by the server answering ListUsers since it started.
+	LastUsed *time.Time `json:"lastUsed,omitempty"`
+}
+
+// UserSecretRotation - credentials of a user after its secret key was
+// rotated, the previous secret key is still accepted until
+// PrevSecretKeyExpiration.
+type UserSecretRotation struct {
+	AccessKey               string    `json:"accessKey"`
+	SecretKey               string    `json:"secretKey"`
+	PrevSecretKeyExpiration time.Time `json:"prevSecretKeyExpiration"`
 }
 
 // RemoveUser - remove a user.
@@ -99,19 +115,22 @@ func (adm *AdminClient) ListUsers() (map[string]UserInfo, error) {
 
 // SetUser - sets a user info.
 func (adm *AdminClient) SetUser(accessKey, secretKey string, status AccountStatus) error {
+	return adm.setUser(accessKey, UserInfo{
+		SecretKey: secretKey,
+		Status:    status,
+	})
+}
 
+func (adm *AdminClient) setUser(accessKey string, uinfo UserInfo) error {
 	if !auth.IsAccessKeyValid(accessKey) {
 		return auth.ErrInvalidAccessKeyLength
 	}
 
-	if !auth.IsSecretKeyValid(secretKey) {
+	if !auth.IsSecretKeyValid(uinfo.SecretKey) {
 		return auth.ErrInvalidSecretKeyLength
 	}
 
-	data, err := json.Marshal(UserInfo{
-		SecretKey: secretKey,
-		Status:    status,
-	})
+	data, err := json.Marshal(uinfo)
 	if err != nil {
 		return err
 	}
@@ -149,7 +168,56 @@ func (adm *AdminClient) AddUser(accessKey, secretKey string) error {
 	return adm.SetUser(accessKey, secretKey, AccountEnabled)
 }
 
-// SetUserPolicy - adds a policy for a user.
+// AddUserWithExpiration - adds a user whose access key can't be used
+// past expiration.
+func (adm *AdminClient) AddUserWithExpiration(accessKey, secretKey string, expiration time.Time) error {
+	return adm.setUser(accessKey, UserInfo{
+		SecretKey:  secretKey,
+		Status:     AccountEnabled,
+		Expiration: &expiration,
+	})
+}
+
+// RotateUserSecret - replaces the secret key of a user with a new one
+// generated by the server. The previous secret key is still accepted
+// during gracePeriod, so that clients can be updated.
+func (adm *AdminClient) RotateUserSecret(accessKey string, gracePeriod time.Duration) (UserSecretRotation, error) {
+	queryValues := url.Values{}
+	queryValues.Set("accessKey", accessKey)
+	queryValues.Set("gracePeriod", gracePeriod.String())
+
+	reqData := requestData{
+		relPath:     "/v1/rotate-user-secret",
+		queryValues: queryValues,
+	}
+
+	// Execute PUT on /minio/admin/v1/rotate-user-secret
+	resp, err := adm.executeMethod("PUT", reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return UserSecretRotation{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return UserSecretRotation{}, httpRespToErrorResponse(resp)
+	}
+
+	data, err := DecryptData(adm.secretAccessKey, resp.Body)
+	if err != nil {
+		return UserSecretRotation{}, err
+	}
+
+	var rotation UserSecretRotation
+	if err = json.Unmarshal(data, &rotation); err != nil {
+		return UserSecretRotation{}, err
+	}
+
+	return rotation, nil
+}
+
+// SetUserPolicy - sets the policy of a user, replacing the canned policies
+// attached to the user.
 func (adm *AdminClient) SetUserPolicy(accessKey, policyName string) error {
 	queryValues := url.Values{}
 	queryValues.Set("accessKey", accessKey)
@@ -175,6 +243,136 @@ func (adm *AdminClient) SetUserPolicy(accessKey, policyName string) error {
 	return nil
 }
 
+// UserPolicies carries the canned policies attached to a user and its
+// inline policy.
+type UserPolicies struct {
+	Policies     []string        `json:"policies,omitempty"`
+	InlinePolicy json.RawMessage `json:"inlinePolicy,omitempty"`
+}
+
+// GetUserPolicies - fetches the canned policies attached to a user and its
+// inline policy.
+func (adm *AdminClient) GetUserPolicies(accessKey string) (*UserPolicies, error) {
+	queryValues := url.Values{}
+	queryValues.Set("accessKey", accessKey)
+
+	reqData := requestData{
+		relPath:     "/v1/user-policies",
+		queryValues: queryValues,
+	}
+
+	// Execute GET on /minio/admin/v1/user-policies
+	resp, err := adm.executeMethod("GET", reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := UserPolicies{}
+	if err = json.Unmarshal(data, &policies); err != nil {
+		return nil, err
+	}
+
+	return &policies, nil
+}
+
+// AttachUserPolicy - attaches a canned policy to a user, in addition to
+// the policies already attached.
+func (adm *AdminClient) AttachUserPolicy(accessKey, policyName string) error {
+	return adm.updateUserPolicy("/v1/attach-user-policy", accessKey, policyName)
+}
+
+// DetachUserPolicy - detaches a canned policy from a user.
+func (adm *AdminClient) DetachUserPolicy(accessKey, policyName string) error {
+	return adm.updateUserPolicy("/v1/detach-user-policy", accessKey, policyName)
+}
+
+func (adm *AdminClient) updateUserPolicy(relPath, accessKey, policyName string) error {
+	queryValues := url.Values{}
+	queryValues.Set("accessKey", accessKey)
+	queryValues.Set("name", policyName)
+
+	reqData := requestData{
+		relPath:     relPath,
+		queryValues: queryValues,
+	}
+
+	// Execute PUT on /minio/admin/<relPath> to update the policies.
+	resp, err := adm.executeMethod("PUT", reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+
+	return nil
+}
+
+// SetUserInlinePolicy - sets the inline policy of a user.
+func (adm *AdminClient) SetUserInlinePolicy(accessKey, policy string) error {
+	queryValues := url.Values{}
+	queryValues.Set("accessKey", accessKey)
+
+	reqData := requestData{
+		relPath:     "/v1/set-user-inline-policy",
+		queryValues: queryValues,
+		content:     []byte(policy),
+	}
+
+	// Execute PUT on /minio/admin/v1/set-user-inline-policy to set policy.
+	resp, err := adm.executeMethod("PUT", reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+
+	return nil
+}
+
+// RemoveUserInlinePolicy - removes the inline policy of a user.
+func (adm *AdminClient) RemoveUserInlinePolicy(accessKey string) error {
+	queryValues := url.Values{}
+	queryValues.Set("accessKey", accessKey)
+
+	reqData := requestData{
+		relPath:     "/v1/remove-user-inline-policy",
+		queryValues: queryValues,
+	}
+
+	// Execute DELETE on /minio/admin/v1/remove-user-inline-policy to remove policy.
+	resp, err := adm.executeMethod("DELETE", reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+
+	return nil
+}
+
 // SetUserStatus - adds a status for a user.
 func (adm *AdminClient) SetUserStatus(accessKey string, status AccountStatus) error {
 	queryValues := url.Values{}
@@ -0,0 +1,171 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pydio/minio-go/pkg/set"
+	"github.com/pydio/minio-srv/pkg/wildcard"
+)
+
+// Number of the colon separated components of an ARN, i.e.
+// "arn:partition:service:region:account-id:resource".
+const arnComponents = 6
+
+// arnMatch - matches arn with pattern component by component, wildcards of
+// pattern do not span several components.
+func arnMatch(pattern, arn string) bool {
+	patternTokens := strings.SplitN(pattern, ":", arnComponents)
+	arnTokens := strings.SplitN(arn, ":", arnComponents)
+	if len(arnTokens) != arnComponents {
+		return false
+	}
+
+	for i := range patternTokens {
+		if !wildcard.Match(patternTokens[i], arnTokens[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// arnLikeFunc - ARN like function. It checks whether value by Key in given
+// values map is an ARN matching one of condition values.
+// For example,
+//   - if values = ["arn:aws:iam::*:user/admin*"], at evaluate() it returns
+//     whether ARN in value map for Key is the ARN of an admin user.
+type arnLikeFunc struct {
+	k      Key
+	values set.StringSet
+}
+
+// evaluate() - evaluates to check whether value by Key in given values is an
+// ARN matching one of condition values.
+func (f arnLikeFunc) evaluate(values map[string][]string) bool {
+	fvalues := f.values.ApplyFunc(substituteFunc(values))
+	for _, v := range values[f.k.Name()] {
+		if !fvalues.FuncMatch(arnMatch, v).IsEmpty() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// key() - returns condition key which is used by this condition function.
+func (f arnLikeFunc) key() Key {
+	return f.k
+}
+
+// name() - returns "ArnLike" condition name.
+func (f arnLikeFunc) name() name {
+	return arnLike
+}
+
+func (f arnLikeFunc) String() string {
+	return toStringLikeFuncString(arnLike, f.k, f.values)
+}
+
+// toMap - returns map representation of this function.
+func (f arnLikeFunc) toMap() map[Key]ValueSet {
+	if !f.k.IsValid() {
+		return nil
+	}
+
+	values := NewValueSet()
+	for _, value := range f.values.ToSlice() {
+		values.Add(NewStringValue(value))
+	}
+
+	return map[Key]ValueSet{
+		f.k: values,
+	}
+}
+
+// arnNotLikeFunc - ARN not like function. It checks whether value by Key in
+// given values map is NOT an ARN matching one of condition values.
+type arnNotLikeFunc struct {
+	arnLikeFunc
+}
+
+// evaluate() - evaluates to check whether value by Key in given values is NOT
+// an ARN matching one of condition values.
+func (f arnNotLikeFunc) evaluate(values map[string][]string) bool {
+	return !f.arnLikeFunc.evaluate(values)
+}
+
+// name() - returns "ArnNotLike" condition name.
+func (f arnNotLikeFunc) name() name {
+	return arnNotLike
+}
+
+func (f arnNotLikeFunc) String() string {
+	return toStringLikeFuncString(arnNotLike, f.arnLikeFunc.k, f.arnLikeFunc.values)
+}
+
+func validateArnLikeValues(n name, values set.StringSet) error {
+	for _, s := range values.ToSlice() {
+		if !strings.HasPrefix(s, "arn:") || len(strings.SplitN(s, ":", arnComponents)) != arnComponents {
+			return fmt.Errorf("invalid ARN '%v' for %v condition", s, n)
+		}
+	}
+
+	return nil
+}
+
+// newArnLikeFunc - returns new ArnLike function.
+func newArnLikeFunc(key Key, values ValueSet) (Function, error) {
+	valueStrings, err := valuesToStringSlice(arnLike, values)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewArnLikeFunc(key, valueStrings...)
+}
+
+// NewArnLikeFunc - returns new ArnLike function.
+func NewArnLikeFunc(key Key, values ...string) (Function, error) {
+	sset := set.CreateStringSet(values...)
+	if err := validateArnLikeValues(arnLike, sset); err != nil {
+		return nil, err
+	}
+
+	return &arnLikeFunc{key, sset}, nil
+}
+
+// newArnNotLikeFunc - returns new ArnNotLike function.
+func newArnNotLikeFunc(key Key, values ValueSet) (Function, error) {
+	valueStrings, err := valuesToStringSlice(arnNotLike, values)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewArnNotLikeFunc(key, valueStrings...)
+}
+
+// NewArnNotLikeFunc - returns new ArnNotLike function.
+func NewArnNotLikeFunc(key Key, values ...string) (Function, error) {
+	sset := set.CreateStringSet(values...)
+	if err := validateArnLikeValues(arnNotLike, sset); err != nil {
+		return nil, err
+	}
+
+	return &arnNotLikeFunc{arnLikeFunc{key, sset}}, nil
+}
@@ -0,0 +1,78 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import (
+	"testing"
+)
+
+func TestArnLikeFuncEvaluate(t *testing.T) {
+	case1Function, err := newArnLikeFunc(AWSReferer, NewValueSet(NewStringValue("arn:aws:iam::*:user/admin*")))
+	if err != nil {
+		t.Fatalf("unexpected error. %v\n", err)
+	}
+
+	case2Function, err := newArnNotLikeFunc(AWSReferer, NewValueSet(NewStringValue("arn:aws:iam::*:user/admin*")))
+	if err != nil {
+		t.Fatalf("unexpected error. %v\n", err)
+	}
+
+	testCases := []struct {
+		function       Function
+		values         map[string][]string
+		expectedResult bool
+	}{
+		{case1Function, map[string][]string{"Referer": {"arn:aws:iam::123456789012:user/admin"}}, true},
+		{case1Function, map[string][]string{"Referer": {"arn:aws:iam::123456789012:user/admin/root"}}, true},
+		{case1Function, map[string][]string{"Referer": {"arn:aws:iam::123456789012:user/guest"}}, false},
+		// Wildcards do not span components.
+		{case1Function, map[string][]string{"Referer": {"arn:aws:iam::1:2:user/admin"}}, false},
+		{case1Function, map[string][]string{"Referer": {"arn:aws:iam:user/admin"}}, false},
+		{case1Function, map[string][]string{}, false},
+		{case2Function, map[string][]string{"Referer": {"arn:aws:iam::123456789012:user/admin"}}, false},
+		{case2Function, map[string][]string{"Referer": {"arn:aws:iam::123456789012:user/guest"}}, true},
+	}
+
+	for i, testCase := range testCases {
+		result := testCase.function.evaluate(testCase.values)
+
+		if result != testCase.expectedResult {
+			t.Fatalf("case %v: expected: %v, got: %v\n", i+1, testCase.expectedResult, result)
+		}
+	}
+}
+
+func TestNewArnLikeFunc(t *testing.T) {
+	testCases := []struct {
+		values    ValueSet
+		expectErr bool
+	}{
+		{NewValueSet(NewStringValue("arn:aws:s3:::mybucket/*")), false},
+		{NewValueSet(NewStringValue("mybucket/*")), true},
+		{NewValueSet(NewStringValue("arn:aws:s3:mybucket")), true},
+		{NewValueSet(NewIntValue(1)), true},
+	}
+
+	for i, testCase := range testCases {
+		_, err := newArnLikeFunc(AWSReferer, testCase.values)
+		expectErr := (err != nil)
+
+		if expectErr != testCase.expectErr {
+			t.Fatalf("case %v: error: expected: %v, got: %v\n", i+1, testCase.expectErr, expectErr)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import (
+	"testing"
+)
+
+func TestBoolFuncEvaluate(t *testing.T) {
+	case1Function, err := newBooleanFunc(AWSSecureTransport, NewValueSet(NewBoolValue(true)))
+	if err != nil {
+		t.Fatalf("unexpected error. %v\n", err)
+	}
+
+	case2Function, err := newBooleanFunc(AWSSecureTransport, NewValueSet(NewStringValue("false")))
+	if err != nil {
+		t.Fatalf("unexpected error. %v\n", err)
+	}
+
+	testCases := []struct {
+		function       Function
+		values         map[string][]string
+		expectedResult bool
+	}{
+		{case1Function, map[string][]string{"SecureTransport": {"true"}}, true},
+		{case1Function, map[string][]string{"SecureTransport": {"false"}}, false},
+		{case1Function, map[string][]string{}, false},
+		{case2Function, map[string][]string{"SecureTransport": {"false"}}, true},
+		{case2Function, map[string][]string{"SecureTransport": {"true"}}, false},
+		{case2Function, map[string][]string{"SecureTransport": {"yes"}}, false},
+	}
+
+	for i, testCase := range testCases {
+		result := testCase.function.evaluate(testCase.values)
+
+		if result != testCase.expectedResult {
+			t.Fatalf("case %v: expected: %v, got: %v\n", i+1, testCase.expectedResult, result)
+		}
+	}
+}
+
+func TestNewBoolFunc(t *testing.T) {
+	testCases := []struct {
+		values    ValueSet
+		expectErr bool
+	}{
+		{NewValueSet(NewBoolValue(true)), false},
+		{NewValueSet(NewStringValue("true")), false},
+		{NewValueSet(NewStringValue("yes")), true},
+		{NewValueSet(NewIntValue(1)), true},
+		{NewValueSet(NewBoolValue(true), NewBoolValue(false)), true},
+	}
+
+	for i, testCase := range testCases {
+		_, err := newBooleanFunc(AWSSecureTransport, testCase.values)
+		expectErr := (err != nil)
+
+		if expectErr != testCase.expectErr {
+			t.Fatalf("case %v: error: expected: %v, got: %v\n", i+1, testCase.expectErr, expectErr)
+		}
+	}
+}
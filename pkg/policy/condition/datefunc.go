@@ -0,0 +1,188 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Layouts of the dates of Date conditions, dates can also be given as a
+// number of seconds since the epoch.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02",
+}
+
+func parseDate(s string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	seconds, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date '%v'", s)
+	}
+
+	return time.Unix(seconds, 0), nil
+}
+
+// dateFunc - Date comparison function. It checks whether date by Key in
+// given values map compares to one of condition dates as required by the
+// function name.
+// For example,
+//   - if name = DateLessThan, Key = AWSCurrentTime and values =
+//     ["2019-01-01T00:00:00Z"], at evaluate() it returns whether the request
+//     was made before 2019.
+type dateFunc struct {
+	n      name
+	k      Key
+	values []Value
+	dates  []time.Time
+}
+
+// evaluate() - evaluates to check whether date by Key in given values
+// compares to one of condition dates.
+func (f dateFunc) evaluate(values map[string][]string) bool {
+	if f.n == dateNotEquals {
+		return !f.compare(dateEquals, values)
+	}
+
+	return f.compare(f.n, values)
+}
+
+func (f dateFunc) compare(n name, values map[string][]string) bool {
+	for _, s := range values[f.k.Name()] {
+		requestDate, err := parseDate(s)
+		if err != nil {
+			continue
+		}
+
+		for _, date := range f.dates {
+			c := 0
+			if requestDate.Before(date) {
+				c = -1
+			} else if requestDate.After(date) {
+				c = 1
+			}
+
+			if compareOperators[n](c) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// key() - returns condition key which is used by this condition function.
+func (f dateFunc) key() Key {
+	return f.k
+}
+
+// name() - returns "Date*" condition name.
+func (f dateFunc) name() name {
+	return f.n
+}
+
+func (f dateFunc) String() string {
+	return toValuesFuncString(f.n, f.k, f.values)
+}
+
+// toMap - returns map representation of this function.
+func (f dateFunc) toMap() map[Key]ValueSet {
+	if !f.k.IsValid() {
+		return nil
+	}
+
+	return map[Key]ValueSet{
+		f.k: valuesToValueSet(f.values),
+	}
+}
+
+func newDateFunc(n name, key Key, values ValueSet) (Function, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("value must not be empty for %v condition", n)
+	}
+
+	f := &dateFunc{n: n, k: key}
+	for value := range values {
+		var date time.Time
+		switch value.GetType() {
+		case reflect.Int:
+			i, _ := value.GetInt()
+			date = time.Unix(int64(i), 0)
+		case reflect.String:
+			s, _ := value.GetString()
+			var err error
+			if date, err = parseDate(s); err != nil {
+				return nil, fmt.Errorf("value must be a date for %v condition: %v", n, err)
+			}
+		default:
+			return nil, fmt.Errorf("value must be a date for %v condition", n)
+		}
+
+		f.values = append(f.values, value)
+		f.dates = append(f.dates, date)
+	}
+
+	return f, nil
+}
+
+func timesToValueSet(values []time.Time) ValueSet {
+	valueSet := NewValueSet()
+	for _, t := range values {
+		valueSet.Add(NewStringValue(t.UTC().Format(time.RFC3339)))
+	}
+
+	return valueSet
+}
+
+// NewDateEqualsFunc - returns new DateEquals function.
+func NewDateEqualsFunc(key Key, values ...time.Time) (Function, error) {
+	return newDateFunc(dateEquals, key, timesToValueSet(values))
+}
+
+// NewDateNotEqualsFunc - returns new DateNotEquals function.
+func NewDateNotEqualsFunc(key Key, values ...time.Time) (Function, error) {
+	return newDateFunc(dateNotEquals, key, timesToValueSet(values))
+}
+
+// NewDateLessThanFunc - returns new DateLessThan function.
+func NewDateLessThanFunc(key Key, values ...time.Time) (Function, error) {
+	return newDateFunc(dateLessThan, key, timesToValueSet(values))
+}
+
+// NewDateLessThanEqualsFunc - returns new DateLessThanEquals function.
+func NewDateLessThanEqualsFunc(key Key, values ...time.Time) (Function, error) {
+	return newDateFunc(dateLessThanEquals, key, timesToValueSet(values))
+}
+
+// NewDateGreaterThanFunc - returns new DateGreaterThan function.
+func NewDateGreaterThanFunc(key Key, values ...time.Time) (Function, error) {
+	return newDateFunc(dateGreaterThan, key, timesToValueSet(values))
+}
+
+// NewDateGreaterThanEqualsFunc - returns new DateGreaterThanEquals function.
+func NewDateGreaterThanEqualsFunc(key Key, values ...time.Time) (Function, error) {
+	return newDateFunc(dateGreaterThanEquals, key, timesToValueSet(values))
+}
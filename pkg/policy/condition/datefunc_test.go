@@ -0,0 +1,89 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDateFuncEvaluate(t *testing.T) {
+	newFunc := func(n name, values ...Value) Function {
+		f, err := newDateFunc(n, AWSCurrentTime, NewValueSet(values...))
+		if err != nil {
+			t.Fatalf("unexpected error. %v\n", err)
+		}
+		return f
+	}
+	newYear := NewStringValue("2019-01-01T00:00:00Z")
+
+	testCases := []struct {
+		function       Function
+		values         map[string][]string
+		expectedResult bool
+	}{
+		{newFunc(dateEquals, newYear), map[string][]string{"CurrentTime": {"2019-01-01T01:00:00+01:00"}}, true},
+		{newFunc(dateEquals, NewStringValue("2019-01-01")), map[string][]string{"CurrentTime": {"2019-01-01T00:00:00Z"}}, true},
+		{newFunc(dateEquals, NewIntValue(1546300800)), map[string][]string{"CurrentTime": {"2019-01-01T00:00:00Z"}}, true},
+		{newFunc(dateNotEquals, newYear), map[string][]string{"CurrentTime": {"2019-01-01T00:00:00Z"}}, false},
+		{newFunc(dateLessThan, newYear), map[string][]string{"CurrentTime": {"2018-12-31T23:59:59Z"}}, true},
+		{newFunc(dateLessThan, newYear), map[string][]string{"CurrentTime": {"2019-01-01T00:00:00Z"}}, false},
+		{newFunc(dateLessThanEquals, newYear), map[string][]string{"CurrentTime": {"2019-01-01T00:00:00Z"}}, true},
+		{newFunc(dateGreaterThan, newYear), map[string][]string{"CurrentTime": {"2019-01-01T00:00:01Z"}}, true},
+		{newFunc(dateGreaterThanEquals, newYear), map[string][]string{"CurrentTime": {"2018-12-31T23:59:59Z"}}, false},
+		{newFunc(dateGreaterThan, newYear), map[string][]string{"CurrentTime": {"not a date"}}, false},
+		{newFunc(dateGreaterThan, newYear), map[string][]string{}, false},
+	}
+
+	for i, testCase := range testCases {
+		result := testCase.function.evaluate(testCase.values)
+
+		if result != testCase.expectedResult {
+			t.Fatalf("case %v: expected: %v, got: %v\n", i+1, testCase.expectedResult, result)
+		}
+	}
+}
+
+func TestNewDateFunc(t *testing.T) {
+	testCases := []struct {
+		values    ValueSet
+		expectErr bool
+	}{
+		{NewValueSet(NewStringValue("2019-01-01T00:00:00Z")), false},
+		{NewValueSet(NewIntValue(1546300800)), false},
+		{NewValueSet(NewStringValue("tomorrow")), true},
+		{NewValueSet(NewBoolValue(true)), true},
+		{NewValueSet(), true},
+	}
+
+	for i, testCase := range testCases {
+		_, err := newDateFunc(dateLessThan, AWSCurrentTime, testCase.values)
+		expectErr := (err != nil)
+
+		if expectErr != testCase.expectErr {
+			t.Fatalf("case %v: error: expected: %v, got: %v\n", i+1, testCase.expectErr, expectErr)
+		}
+	}
+
+	f, err := NewDateLessThanFunc(AWSCurrentTime, time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("unexpected error. %v\n", err)
+	}
+	if expected := "DateLessThan:aws:CurrentTime:[2019-01-01T00:00:00Z]"; f.String() != expected {
+		t.Fatalf("expected: %v, got: %v\n", expected, f.String())
+	}
+}
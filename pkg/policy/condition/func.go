@@ -71,7 +71,13 @@ func (functions Functions) MarshalJSON() ([]byte, error) {
 	nm := make(map[name]map[Key]ValueSet)
 
 	for _, f := range functions {
-		nm[f.name()] = f.toMap()
+		if _, found := nm[f.name()]; !found {
+			nm[f.name()] = make(map[Key]ValueSet)
+		}
+
+		for key, values := range f.toMap() {
+			nm[f.name()][key] = values
+		}
 	}
 
 	return json.Marshal(nm)
@@ -119,40 +125,46 @@ func (functions *Functions) UnmarshalJSON(data []byte) error {
 				return err
 			}
 
+			_, base, _ := n.split()
+
 			var f Function
-			switch n {
+			switch base {
 			case stringEquals:
-				if f, err = newStringEqualsFunc(key, values); err != nil {
-					return err
-				}
+				f, err = newStringEqualsFunc(key, values)
 			case stringNotEquals:
-				if f, err = newStringNotEqualsFunc(key, values); err != nil {
-					return err
-				}
+				f, err = newStringNotEqualsFunc(key, values)
+			case stringEqualsIgnoreCase:
+				f, err = newStringEqualsIgnoreCaseFunc(key, values)
+			case stringNotEqualsIgnoreCase:
+				f, err = newStringNotEqualsIgnoreCaseFunc(key, values)
 			case stringLike:
-				if f, err = newStringLikeFunc(key, values); err != nil {
-					return err
-				}
+				f, err = newStringLikeFunc(key, values)
 			case stringNotLike:
-				if f, err = newStringNotLikeFunc(key, values); err != nil {
-					return err
-				}
+				f, err = newStringNotLikeFunc(key, values)
+			case numericEquals, numericNotEquals, numericLessThan, numericLessThanEquals, numericGreaterThan, numericGreaterThanEquals:
+				f, err = newNumericFunc(base, key, values)
+			case dateEquals, dateNotEquals, dateLessThan, dateLessThanEquals, dateGreaterThan, dateGreaterThanEquals:
+				f, err = newDateFunc(base, key, values)
+			case boolean:
+				f, err = newBooleanFunc(key, values)
+			case arnLike:
+				f, err = newArnLikeFunc(key, values)
+			case arnNotLike:
+				f, err = newArnNotLikeFunc(key, values)
 			case ipAddress:
-				if f, err = newIPAddressFunc(key, values); err != nil {
-					return err
-				}
+				f, err = newIPAddressFunc(key, values)
 			case notIPAddress:
-				if f, err = newNotIPAddressFunc(key, values); err != nil {
-					return err
-				}
+				f, err = newNotIPAddressFunc(key, values)
 			case null:
-				if f, err = newNullFunc(key, values); err != nil {
-					return err
-				}
+				f, err = newNullFunc(key, values)
 			default:
 				return fmt.Errorf("%v is not handled", n)
 			}
+			if err != nil {
+				return err
+			}
 
+			f = newQualifiedFunc(n, f)
 			funcs = append(funcs, f)
 		}
 	}
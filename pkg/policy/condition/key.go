@@ -68,8 +68,67 @@ const (
 
 	// AWSSourceIP - key representing client's IP address (not intermittent proxies) of any API.
 	AWSSourceIP = "aws:SourceIp"
+
+	// AWSCurrentTime - key representing the date and time of any API request.
+	AWSCurrentTime = "aws:CurrentTime"
+
+	// AWSSecureTransport - key representing whether any API request was sent over TLS.
+	AWSSecureTransport = "aws:SecureTransport"
+
+	// AWSUserAgent - key representing User-Agent header of any API.
+	AWSUserAgent = "aws:UserAgent"
+
+	// AWSUsername - key representing the user name of any authenticated API request.
+	AWSUsername = "aws:username"
+
+	// AWSUserID - key representing the unique identifier of the user of any authenticated API request.
+	AWSUserID = "aws:userid"
+)
+
+// JWTPrefix - prefix of keys representing the claims of the JWT token of any API request.
+const JWTPrefix = "jwt:"
+
+// Keys representing the claims of the JWT token of any API request.
+const (
+	JWTSub               Key = JWTPrefix + "sub"
+	JWTIss                   = JWTPrefix + "iss"
+	JWTAud                   = JWTPrefix + "aud"
+	JWTJti                   = JWTPrefix + "jti"
+	JWTName                  = JWTPrefix + "name"
+	JWTPreferredUsername     = JWTPrefix + "preferred_username"
+	JWTEmail                 = JWTPrefix + "email"
+	JWTGroups                = JWTPrefix + "groups"
+	JWTProfile               = JWTPrefix + "profile"
+	JWTRoles                 = JWTPrefix + "roles"
+	JWTGroupPath             = JWTPrefix + "groupPath"
 )
 
+// JWTKeys - keys representing the claims of the JWT token.
+var JWTKeys = []Key{
+	JWTSub,
+	JWTIss,
+	JWTAud,
+	JWTJti,
+	JWTName,
+	JWTPreferredUsername,
+	JWTEmail,
+	JWTGroups,
+	JWTProfile,
+	JWTRoles,
+	JWTGroupPath,
+}
+
+// CommonKeys - keys which are applicable to all actions.
+var CommonKeys = append([]Key{
+	AWSReferer,
+	AWSSourceIP,
+	AWSCurrentTime,
+	AWSSecureTransport,
+	AWSUserAgent,
+	AWSUsername,
+	AWSUserID,
+}, JWTKeys...)
+
 // IsValid - checks if key is valid or not.
 func (key Key) IsValid() bool {
 	switch key {
@@ -78,6 +137,12 @@ func (key Key) IsValid() bool {
 	case S3XAmzMetadataDirective, S3XAmzStorageClass, S3LocationConstraint, S3Prefix:
 		fallthrough
 	case S3Delimiter, S3MaxKeys, AWSReferer, AWSSourceIP:
+		fallthrough
+	case AWSCurrentTime, AWSSecureTransport, AWSUserAgent, AWSUsername, AWSUserID:
+		fallthrough
+	case JWTSub, JWTIss, JWTAud, JWTJti, JWTName, JWTPreferredUsername, JWTEmail:
+		fallthrough
+	case JWTGroups, JWTProfile, JWTRoles, JWTGroupPath:
 		return true
 	}
 
@@ -93,7 +158,8 @@ func (key Key) MarshalJSON() ([]byte, error) {
 	return json.Marshal(string(key))
 }
 
-// Name - returns key name which is stripped value of prefixes "aws:" and "s3:"
+// Name - returns key name which is stripped value of prefixes "aws:" and "s3:",
+// keys representing JWT claims keep their "jwt:" prefix.
 func (key Key) Name() string {
 	keyString := string(key)
 
@@ -104,6 +170,11 @@ func (key Key) Name() string {
 	return strings.TrimPrefix(keyString, "s3:")
 }
 
+// VarName - returns the policy variable name of the key, e.g. "${aws:username}".
+func (key Key) VarName() string {
+	return "${" + string(key) + "}"
+}
+
 // UnmarshalJSON - decodes JSON data to Key.
 func (key *Key) UnmarshalJSON(data []byte) error {
 	var s string
@@ -38,6 +38,9 @@ func TestKeyIsValid(t *testing.T) {
 		{S3MaxKeys, true},
 		{AWSReferer, true},
 		{AWSSourceIP, true},
+		{AWSUserID, true},
+		{JWTGroupPath, true},
+		{Key("jwt:unknown"), false},
 		{Key("foo"), false},
 	}
 
@@ -83,6 +86,8 @@ func TestKeyName(t *testing.T) {
 	}{
 		{S3XAmzCopySource, "x-amz-copy-source"},
 		{AWSReferer, "Referer"},
+		{AWSUserID, "userid"},
+		{JWTSub, "jwt:sub"},
 	}
 
 	for i, testCase := range testCases {
@@ -19,25 +19,81 @@ package condition
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 type name string
 
 const (
-	stringEquals    name = "StringEquals"
-	stringNotEquals      = "StringNotEquals"
-	stringLike           = "StringLike"
-	stringNotLike        = "StringNotLike"
-	ipAddress            = "IpAddress"
-	notIPAddress         = "NotIpAddress"
-	null                 = "Null"
+	stringEquals              name = "StringEquals"
+	stringNotEquals                = "StringNotEquals"
+	stringEqualsIgnoreCase         = "StringEqualsIgnoreCase"
+	stringNotEqualsIgnoreCase      = "StringNotEqualsIgnoreCase"
+	stringLike                     = "StringLike"
+	stringNotLike                  = "StringNotLike"
+	numericEquals                  = "NumericEquals"
+	numericNotEquals               = "NumericNotEquals"
+	numericLessThan                = "NumericLessThan"
+	numericLessThanEquals          = "NumericLessThanEquals"
+	numericGreaterThan             = "NumericGreaterThan"
+	numericGreaterThanEquals       = "NumericGreaterThanEquals"
+	dateEquals                     = "DateEquals"
+	dateNotEquals                  = "DateNotEquals"
+	dateLessThan                   = "DateLessThan"
+	dateLessThanEquals             = "DateLessThanEquals"
+	dateGreaterThan                = "DateGreaterThan"
+	dateGreaterThanEquals          = "DateGreaterThanEquals"
+	boolean                        = "Bool"
+	arnLike                        = "ArnLike"
+	arnNotLike                     = "ArnNotLike"
+	ipAddress                      = "IpAddress"
+	notIPAddress                   = "NotIpAddress"
+	null                           = "Null"
 )
 
+// Qualifiers of condition names, e.g. "ForAnyValue:StringEqualsIfExists".
+const (
+	forAnyValue  = "ForAnyValue:"
+	forAllValues = "ForAllValues:"
+	ifExists     = "IfExists"
+)
+
+// split - returns the set qualifier ("ForAnyValue:", "ForAllValues:" or
+// empty), the unqualified name and whether the "IfExists" suffix is set.
+func (n name) split() (setQualifier string, base name, isIfExists bool) {
+	s := string(n)
+	for _, q := range []string{forAnyValue, forAllValues} {
+		if strings.HasPrefix(s, q) {
+			setQualifier = q
+			s = strings.TrimPrefix(s, q)
+			break
+		}
+	}
+
+	if strings.HasSuffix(s, ifExists) {
+		isIfExists = true
+		s = strings.TrimSuffix(s, ifExists)
+	}
+
+	return setQualifier, name(s), isIfExists
+}
+
 // IsValid - checks if name is valid or not.
 func (n name) IsValid() bool {
-	switch n {
-	case stringEquals, stringNotEquals, stringLike, stringNotLike, ipAddress, notIPAddress, null:
+	setQualifier, base, isIfExists := n.split()
+
+	switch base {
+	case stringEquals, stringNotEquals, stringEqualsIgnoreCase, stringNotEqualsIgnoreCase, stringLike, stringNotLike:
+		fallthrough
+	case numericEquals, numericNotEquals, numericLessThan, numericLessThanEquals, numericGreaterThan, numericGreaterThanEquals:
+		fallthrough
+	case dateEquals, dateNotEquals, dateLessThan, dateLessThanEquals, dateGreaterThan, dateGreaterThanEquals:
+		fallthrough
+	case boolean, arnLike, arnNotLike, ipAddress, notIPAddress:
 		return true
+	case null:
+		// Null checks the presence of the key, it cannot be qualified.
+		return setQualifier == "" && !isIfExists
 	}
 
 	return false
@@ -34,6 +34,17 @@ func TestNameIsValid(t *testing.T) {
 		{ipAddress, true},
 		{notIPAddress, true},
 		{null, true},
+		{numericLessThan, true},
+		{dateGreaterThanEquals, true},
+		{boolean, true},
+		{stringEqualsIgnoreCase, true},
+		{arnLike, true},
+		{name("ForAnyValue:StringEquals"), true},
+		{name("ForAllValues:StringLikeIfExists"), true},
+		{name("NumericLessThanIfExists"), true},
+		{name("NullIfExists"), false},
+		{name("ForAnyValue:Null"), false},
+		{name("ForAnyValue:"), false},
 		{name("foo"), false},
 	}
 
@@ -0,0 +1,200 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// compareOperators - checks the result of the comparison of a request value
+// with a condition value, c is negative, zero or positive when the request
+// value is respectively less than, equal to or greater than the condition
+// value.
+var compareOperators = map[name]func(c int) bool{
+	numericEquals:            func(c int) bool { return c == 0 },
+	numericLessThan:          func(c int) bool { return c < 0 },
+	numericLessThanEquals:    func(c int) bool { return c <= 0 },
+	numericGreaterThan:       func(c int) bool { return c > 0 },
+	numericGreaterThanEquals: func(c int) bool { return c >= 0 },
+	dateEquals:               func(c int) bool { return c == 0 },
+	dateLessThan:             func(c int) bool { return c < 0 },
+	dateLessThanEquals:       func(c int) bool { return c <= 0 },
+	dateGreaterThan:          func(c int) bool { return c > 0 },
+	dateGreaterThanEquals:    func(c int) bool { return c >= 0 },
+}
+
+func toValuesFuncString(n name, key Key, values []Value) string {
+	valueStrings := []string{}
+	for _, value := range values {
+		valueStrings = append(valueStrings, value.String())
+	}
+	sort.Strings(valueStrings)
+
+	return fmt.Sprintf("%v:%v:%v", n, key, valueStrings)
+}
+
+func valuesToValueSet(values []Value) ValueSet {
+	valueSet := NewValueSet()
+	for _, value := range values {
+		valueSet.Add(value)
+	}
+
+	return valueSet
+}
+
+// numericFunc - Numeric comparison function. It checks whether value by Key
+// in given values map compares to one of condition values as required by
+// the function name.
+// For example,
+//   - if name = NumericLessThan and values = [100], at evaluate() it returns
+//     whether number in value map for Key is less than 100.
+type numericFunc struct {
+	n       name
+	k       Key
+	values  []Value
+	numbers []float64
+}
+
+// evaluate() - evaluates to check whether number by Key in given values
+// compares to one of condition values.
+func (f numericFunc) evaluate(values map[string][]string) bool {
+	if f.n == numericNotEquals {
+		return !f.compare(numericEquals, values)
+	}
+
+	return f.compare(f.n, values)
+}
+
+func (f numericFunc) compare(n name, values map[string][]string) bool {
+	for _, s := range values[f.k.Name()] {
+		requestNumber, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			continue
+		}
+
+		for _, number := range f.numbers {
+			c := 0
+			if requestNumber < number {
+				c = -1
+			} else if requestNumber > number {
+				c = 1
+			}
+
+			if compareOperators[n](c) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// key() - returns condition key which is used by this condition function.
+func (f numericFunc) key() Key {
+	return f.k
+}
+
+// name() - returns "Numeric*" condition name.
+func (f numericFunc) name() name {
+	return f.n
+}
+
+func (f numericFunc) String() string {
+	return toValuesFuncString(f.n, f.k, f.values)
+}
+
+// toMap - returns map representation of this function.
+func (f numericFunc) toMap() map[Key]ValueSet {
+	if !f.k.IsValid() {
+		return nil
+	}
+
+	return map[Key]ValueSet{
+		f.k: valuesToValueSet(f.values),
+	}
+}
+
+func newNumericFunc(n name, key Key, values ValueSet) (Function, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("value must not be empty for %v condition", n)
+	}
+
+	f := &numericFunc{n: n, k: key}
+	for value := range values {
+		var number float64
+		switch value.GetType() {
+		case reflect.Int:
+			i, _ := value.GetInt()
+			number = float64(i)
+		case reflect.String:
+			s, _ := value.GetString()
+			var err error
+			if number, err = strconv.ParseFloat(s, 64); err != nil {
+				return nil, fmt.Errorf("value must be a number for %v condition", n)
+			}
+		default:
+			return nil, fmt.Errorf("value must be a number for %v condition", n)
+		}
+
+		f.values = append(f.values, value)
+		f.numbers = append(f.numbers, number)
+	}
+
+	return f, nil
+}
+
+// NewNumericEqualsFunc - returns new NumericEquals function.
+func NewNumericEqualsFunc(key Key, values ...int) (Function, error) {
+	return newNumericFunc(numericEquals, key, intsToValueSet(values))
+}
+
+// NewNumericNotEqualsFunc - returns new NumericNotEquals function.
+func NewNumericNotEqualsFunc(key Key, values ...int) (Function, error) {
+	return newNumericFunc(numericNotEquals, key, intsToValueSet(values))
+}
+
+// NewNumericLessThanFunc - returns new NumericLessThan function.
+func NewNumericLessThanFunc(key Key, values ...int) (Function, error) {
+	return newNumericFunc(numericLessThan, key, intsToValueSet(values))
+}
+
+// NewNumericLessThanEqualsFunc - returns new NumericLessThanEquals function.
+func NewNumericLessThanEqualsFunc(key Key, values ...int) (Function, error) {
+	return newNumericFunc(numericLessThanEquals, key, intsToValueSet(values))
+}
+
+// NewNumericGreaterThanFunc - returns new NumericGreaterThan function.
+func NewNumericGreaterThanFunc(key Key, values ...int) (Function, error) {
+	return newNumericFunc(numericGreaterThan, key, intsToValueSet(values))
+}
+
+// NewNumericGreaterThanEqualsFunc - returns new NumericGreaterThanEquals function.
+func NewNumericGreaterThanEqualsFunc(key Key, values ...int) (Function, error) {
+	return newNumericFunc(numericGreaterThanEquals, key, intsToValueSet(values))
+}
+
+func intsToValueSet(values []int) ValueSet {
+	valueSet := NewValueSet()
+	for _, i := range values {
+		valueSet.Add(NewIntValue(i))
+	}
+
+	return valueSet
+}
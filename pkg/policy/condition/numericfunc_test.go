@@ -0,0 +1,82 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import (
+	"testing"
+)
+
+func TestNumericFuncEvaluate(t *testing.T) {
+	newFunc := func(n name, values ...Value) Function {
+		f, err := newNumericFunc(n, S3MaxKeys, NewValueSet(values...))
+		if err != nil {
+			t.Fatalf("unexpected error. %v\n", err)
+		}
+		return f
+	}
+
+	testCases := []struct {
+		function       Function
+		values         map[string][]string
+		expectedResult bool
+	}{
+		{newFunc(numericEquals, NewIntValue(100)), map[string][]string{"max-keys": {"100"}}, true},
+		{newFunc(numericEquals, NewStringValue("100")), map[string][]string{"max-keys": {"1000"}}, false},
+		{newFunc(numericEquals, NewIntValue(100), NewIntValue(1000)), map[string][]string{"max-keys": {"1000"}}, true},
+		{newFunc(numericNotEquals, NewIntValue(100)), map[string][]string{"max-keys": {"100"}}, false},
+		{newFunc(numericNotEquals, NewIntValue(100)), map[string][]string{"max-keys": {"10"}}, true},
+		{newFunc(numericLessThan, NewIntValue(100)), map[string][]string{"max-keys": {"10"}}, true},
+		{newFunc(numericLessThan, NewIntValue(100)), map[string][]string{"max-keys": {"100"}}, false},
+		{newFunc(numericLessThanEquals, NewIntValue(100)), map[string][]string{"max-keys": {"100"}}, true},
+		{newFunc(numericGreaterThan, NewStringValue("99.5")), map[string][]string{"max-keys": {"100"}}, true},
+		{newFunc(numericGreaterThan, NewIntValue(100)), map[string][]string{"max-keys": {"100"}}, false},
+		{newFunc(numericGreaterThanEquals, NewIntValue(100)), map[string][]string{"max-keys": {"100"}}, true},
+		// Missing and non numeric values never match.
+		{newFunc(numericLessThan, NewIntValue(100)), map[string][]string{}, false},
+		{newFunc(numericLessThan, NewIntValue(100)), map[string][]string{"max-keys": {"ten"}}, false},
+	}
+
+	for i, testCase := range testCases {
+		result := testCase.function.evaluate(testCase.values)
+
+		if result != testCase.expectedResult {
+			t.Fatalf("case %v: expected: %v, got: %v\n", i+1, testCase.expectedResult, result)
+		}
+	}
+}
+
+func TestNewNumericFunc(t *testing.T) {
+	testCases := []struct {
+		values    ValueSet
+		expectErr bool
+	}{
+		{NewValueSet(NewIntValue(100)), false},
+		{NewValueSet(NewStringValue("100")), false},
+		{NewValueSet(NewStringValue("ten")), true},
+		{NewValueSet(NewBoolValue(true)), true},
+		{NewValueSet(), true},
+	}
+
+	for i, testCase := range testCases {
+		_, err := newNumericFunc(numericLessThan, S3MaxKeys, testCase.values)
+		expectErr := (err != nil)
+
+		if expectErr != testCase.expectErr {
+			t.Fatalf("case %v: error: expected: %v, got: %v\n", i+1, testCase.expectErr, expectErr)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import (
+	"fmt"
+	"strings"
+)
+
+// qualifiedFunc - condition function qualified by "ForAnyValue:",
+// "ForAllValues:" and/or "IfExists".
+//   - with "IfExists", the function evaluates to true when Key is missing
+//     from given values.
+//   - with "ForAnyValue:", the function is evaluated for each value by Key
+//     and evaluates to true when it does for at least one value.
+//   - with "ForAllValues:", the function is evaluated for each value by Key
+//     and evaluates to true when it does for all values, or when there is no
+//     value.
+type qualifiedFunc struct {
+	Function
+	n name
+}
+
+// evaluate() - evaluates the function for the values by Key as required by
+// the qualifiers.
+func (f qualifiedFunc) evaluate(values map[string][]string) bool {
+	setQualifier, _, isIfExists := f.n.split()
+	keyName := f.key().Name()
+	requestValues := values[keyName]
+
+	if isIfExists && len(requestValues) == 0 {
+		return true
+	}
+
+	switch setQualifier {
+	case forAnyValue:
+		for _, v := range requestValues {
+			if f.Function.evaluate(map[string][]string{keyName: {v}}) {
+				return true
+			}
+		}
+		return false
+	case forAllValues:
+		for _, v := range requestValues {
+			if !f.Function.evaluate(map[string][]string{keyName: {v}}) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return f.Function.evaluate(values)
+}
+
+// name() - returns qualified condition name.
+func (f qualifiedFunc) name() name {
+	return f.n
+}
+
+func (f qualifiedFunc) String() string {
+	return fmt.Sprintf("%v%v", f.n, strings.TrimPrefix(f.Function.String(), string(f.Function.name())))
+}
+
+// newQualifiedFunc - returns function f qualified as n, f itself if n has no
+// qualifier.
+func newQualifiedFunc(n name, f Function) Function {
+	if n == f.name() {
+		return f
+	}
+
+	return &qualifiedFunc{f, n}
+}
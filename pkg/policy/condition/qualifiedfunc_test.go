@@ -0,0 +1,109 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestQualifiedFuncEvaluate(t *testing.T) {
+	stringEqualsFunction, err := newStringEqualsFunc(S3Prefix, NewValueSet(NewStringValue("photos/"), NewStringValue("docs/")))
+	if err != nil {
+		t.Fatalf("unexpected error. %v\n", err)
+	}
+
+	numericFunction, err := newNumericFunc(numericLessThan, S3MaxKeys, NewValueSet(NewIntValue(100)))
+	if err != nil {
+		t.Fatalf("unexpected error. %v\n", err)
+	}
+
+	case1Function := newQualifiedFunc(forAnyValue+stringEquals, stringEqualsFunction)
+	case2Function := newQualifiedFunc(forAllValues+stringEquals, stringEqualsFunction)
+	case3Function := newQualifiedFunc(numericLessThan+ifExists, numericFunction)
+	case4Function := newQualifiedFunc(forAllValues+stringEquals+ifExists, stringEqualsFunction)
+
+	testCases := []struct {
+		function       Function
+		values         map[string][]string
+		expectedResult bool
+	}{
+		{case1Function, map[string][]string{"prefix": {"photos/", "private/"}}, true},
+		{case1Function, map[string][]string{"prefix": {"private/"}}, false},
+		{case1Function, map[string][]string{}, false},
+		{case2Function, map[string][]string{"prefix": {"photos/", "docs/"}}, true},
+		{case2Function, map[string][]string{"prefix": {"photos/", "private/"}}, false},
+		{case2Function, map[string][]string{}, true},
+		{case3Function, map[string][]string{"max-keys": {"10"}}, true},
+		{case3Function, map[string][]string{"max-keys": {"1000"}}, false},
+		{case3Function, map[string][]string{}, true},
+		{case4Function, map[string][]string{"prefix": {"private/"}}, false},
+		{case4Function, map[string][]string{}, true},
+	}
+
+	for i, testCase := range testCases {
+		result := testCase.function.evaluate(testCase.values)
+
+		if result != testCase.expectedResult {
+			t.Fatalf("case %v: expected: %v, got: %v\n", i+1, testCase.expectedResult, result)
+		}
+	}
+}
+
+func TestQualifiedFuncJSON(t *testing.T) {
+	testCases := []struct {
+		data      string
+		expectErr bool
+	}{
+		{`{"ForAnyValue:StringEquals":{"s3:prefix":["photos/"]}}`, false},
+		{`{"ForAllValues:StringLikeIfExists":{"s3:prefix":["photos/*"]}}`, false},
+		{`{"NumericLessThanEqualsIfExists":{"s3:max-keys":"100"}}`, false},
+		{`{"DateGreaterThan":{"aws:CurrentTime":"2019-01-01T00:00:00Z"}}`, false},
+		{`{"Bool":{"aws:SecureTransport":"true"}}`, false},
+		{`{"StringEqualsIgnoreCase":{"aws:username":"Alice"}}`, false},
+		{`{"NullIfExists":{"s3:prefix":true}}`, true},
+		{`{"ForSomeValues:StringEquals":{"s3:prefix":["photos/"]}}`, true},
+	}
+
+	for i, testCase := range testCases {
+		var functions Functions
+		err := json.Unmarshal([]byte(testCase.data), &functions)
+		expectErr := (err != nil)
+
+		if expectErr != testCase.expectErr {
+			t.Fatalf("case %v: error: expected: %v, got: %v\n", i+1, testCase.expectErr, expectErr)
+		}
+
+		if err != nil {
+			continue
+		}
+
+		data, err := json.Marshal(functions)
+		if err != nil {
+			t.Fatalf("case %v: unexpected error. %v\n", i+1, err)
+		}
+
+		var result Functions
+		if err = json.Unmarshal(data, &result); err != nil {
+			t.Fatalf("case %v: unexpected error. %v\n", i+1, err)
+		}
+
+		if result.String() != functions.String() {
+			t.Fatalf("case %v: expected: %v, got: %v\n", i+1, functions, result)
+		}
+	}
+}
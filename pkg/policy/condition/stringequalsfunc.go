@@ -42,10 +42,11 @@ type stringEqualsFunc struct {
 }
 
 // evaluate() - evaluates to check whether value by Key in given values is in
-// condition values.
+// condition values. Policy variables in condition values are substituted first.
 func (f stringEqualsFunc) evaluate(values map[string][]string) bool {
 	requestValue := values[f.k.Name()]
-	return !f.values.Intersection(set.CreateStringSet(requestValue...)).IsEmpty()
+	fvalues := f.values.ApplyFunc(substituteFunc(values))
+	return !fvalues.Intersection(set.CreateStringSet(requestValue...)).IsEmpty()
 }
 
 // key() - returns condition key which is used by this condition function.
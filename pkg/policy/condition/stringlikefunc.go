@@ -43,10 +43,12 @@ type stringLikeFunc struct {
 }
 
 // evaluate() - evaluates to check whether value by Key in given values is wildcard
-// matching in condition values.
+// matching in condition values. Policy variables in condition values are
+// substituted first.
 func (f stringLikeFunc) evaluate(values map[string][]string) bool {
+	fvalues := f.values.ApplyFunc(substituteFunc(values))
 	for _, v := range values[f.k.Name()] {
-		if !f.values.FuncMatch(wildcard.Match, v).IsEmpty() {
+		if !fvalues.FuncMatch(wildcard.Match, v).IsEmpty() {
 			return true
 		}
 	}
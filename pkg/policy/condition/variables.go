@@ -0,0 +1,46 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import "strings"
+
+// SubstituteVariables - replaces policy variables like "${aws:username}" in
+// given string by their value in given values map. Only keys applicable to
+// all actions are policy variables, a variable without a value in values
+// map is left as is.
+func SubstituteVariables(s string, values map[string][]string) string {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+
+	for _, key := range CommonKeys {
+		// Empty and missing values are not substituted.
+		if v, ok := values[key.Name()]; ok && len(v) > 0 && v[0] != "" {
+			s = strings.Replace(s, key.VarName(), v[0], -1)
+		}
+	}
+
+	return s
+}
+
+// substituteFunc - returns a function substituting policy variables by
+// their value in given values map.
+func substituteFunc(values map[string][]string) func(string) string {
+	return func(s string) string {
+		return SubstituteVariables(s, values)
+	}
+}
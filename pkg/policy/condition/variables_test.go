@@ -0,0 +1,92 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import "testing"
+
+func TestSubstituteVariables(t *testing.T) {
+	values := map[string][]string{
+		"username":  {"alice"},
+		"userid":    {""},
+		"jwt:sub":   {"1234"},
+		"jwt:email": {"alice@example.com", "a@example.com"},
+		"Referer":   {"http://example.com"},
+	}
+
+	testCases := []struct {
+		s              string
+		expectedResult string
+	}{
+		{"home/${aws:username}/*", "home/alice/*"},
+		{"${aws:username}/${aws:username}", "alice/alice"},
+		{"${jwt:sub}-${jwt:email}", "1234-alice@example.com"},
+		// Empty and missing values are not substituted.
+		{"home/${aws:userid}/*", "home/${aws:userid}/*"},
+		{"home/${jwt:name}/*", "home/${jwt:name}/*"},
+		// Only known keys are policy variables.
+		{"${aws:Username}", "${aws:Username}"},
+		{"${username}", "${username}"},
+		{"home/*", "home/*"},
+	}
+
+	for i, testCase := range testCases {
+		result := SubstituteVariables(testCase.s, values)
+
+		if result != testCase.expectedResult {
+			t.Fatalf("case %v: expected: %v, got: %v\n", i+1, testCase.expectedResult, result)
+		}
+	}
+}
+
+func TestStringFuncsPolicyVariables(t *testing.T) {
+	case1Function, err := newStringEqualsFunc(S3Prefix, NewValueSet(NewStringValue("home/${aws:username}/")))
+	if err != nil {
+		t.Fatalf("unexpected error. %v\n", err)
+	}
+
+	case2Function, err := newStringLikeFunc(S3Prefix, NewValueSet(NewStringValue("home/${aws:username}/*")))
+	if err != nil {
+		t.Fatalf("unexpected error. %v\n", err)
+	}
+
+	case3Function, err := newStringEqualsIgnoreCaseFunc(JWTEmail, NewValueSet(NewStringValue("${aws:username}@example.com")))
+	if err != nil {
+		t.Fatalf("unexpected error. %v\n", err)
+	}
+
+	testCases := []struct {
+		function       Function
+		values         map[string][]string
+		expectedResult bool
+	}{
+		{case1Function, map[string][]string{"prefix": {"home/alice/"}, "username": {"alice"}}, true},
+		{case1Function, map[string][]string{"prefix": {"home/bob/"}, "username": {"alice"}}, false},
+		{case1Function, map[string][]string{"prefix": {"home/${aws:username}/"}}, true},
+		{case2Function, map[string][]string{"prefix": {"home/alice/photos/"}, "username": {"alice"}}, true},
+		{case2Function, map[string][]string{"prefix": {"home/bob/photos/"}, "username": {"alice"}}, false},
+		{case3Function, map[string][]string{"jwt:email": {"Alice@Example.com"}, "username": {"alice"}}, true},
+		{case3Function, map[string][]string{"jwt:email": {"bob@example.com"}, "username": {"alice"}}, false},
+	}
+
+	for i, testCase := range testCases {
+		result := testCase.function.evaluate(testCase.values)
+
+		if result != testCase.expectedResult {
+			t.Fatalf("case %v: expected: %v, got: %v\n", i+1, testCase.expectedResult, result)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pubsub
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	ps := New()
+	ch1 := make(chan interface{}, 1)
+	ch2 := make(chan interface{}, 1)
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	ps.Subscribe(ch1, doneCh, nil)
+	ps.Subscribe(ch2, doneCh, nil)
+	ps.Lock()
+	defer ps.Unlock()
+	if len(ps.subs) != 2 {
+		t.Errorf("expected 2 subscribers")
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	ps := New()
+	ch1 := make(chan interface{}, 1)
+	ch2 := make(chan interface{}, 1)
+	doneCh1 := make(chan struct{})
+	doneCh2 := make(chan struct{})
+	defer close(doneCh2)
+	ps.Subscribe(ch1, doneCh1, nil)
+	ps.Subscribe(ch2, doneCh2, nil)
+
+	close(doneCh1)
+	// Allow for the goroutine to finish
+	time.Sleep(100 * time.Millisecond)
+	if !ps.HasSubscribers() {
+		t.Errorf("expected a remaining subscriber")
+	}
+	ps.Lock()
+	if len(ps.subs) != 1 || ps.subs[0].ch != ch2 {
+		t.Errorf("expected only the second subscriber")
+	}
+	ps.Unlock()
+}
+
+func TestPublish(t *testing.T) {
+	ps := New()
+	ch1 := make(chan interface{}, 1)
+	ch2 := make(chan interface{}, 1)
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	ps.Subscribe(ch1, doneCh, nil)
+	ps.Subscribe(ch2, doneCh, func(entry interface{}) bool {
+		return entry.(string) != "hello"
+	})
+
+	ps.Publish("hello")
+	if msg := <-ch1; msg != "hello" {
+		t.Errorf("expected hello, got %v", msg)
+	}
+	select {
+	case msg := <-ch2:
+		t.Errorf("expected message to be filtered out, got %v", msg)
+	default:
+	}
+
+	// Subscribers with full channels miss messages instead of
+	// blocking the publisher.
+	ps.Publish("world")
+	ps.Publish("again")
+	if msg := <-ch1; msg != "world" {
+		t.Errorf("expected world, got %v", msg)
+	}
+	if msg := <-ch2; msg != "world" {
+		t.Errorf("expected world, got %v", msg)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3select
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/xwb1989/sqlparser"
+)
+
+// supportedAggregate is a function that checks whether the aggregate function
+// is a supported S3 one.
+func supportedAggregate(name string) bool {
+	switch strings.ToUpper(name) {
+	case "COUNT", "SUM", "AVG", "MIN", "MAX":
+		return true
+	}
+	return false
+}
+
+// aggregate accumulates the values of an aggregate function of the SELECT
+// list over the records matching the WHERE clause, NULL and MISSING values
+// are ignored.
+type aggregate struct {
+	name string
+	// expr is the argument of the function, nil for COUNT(*).
+	expr  sqlparser.Expr
+	count int64
+	// sum is exact, onlyInts is set while only integers were summed so
+	// that the sum is returned as an INT.
+	sum      *big.Rat
+	onlyInts bool
+	// extreme is the current minimum or maximum.
+	extreme value
+}
+
+func newAggregate(name string, expr sqlparser.Expr) *aggregate {
+	return &aggregate{
+		name:     strings.ToUpper(name),
+		expr:     expr,
+		sum:      new(big.Rat),
+		onlyInts: true,
+	}
+}
+
+// update accounts for a record matching the WHERE clause.
+func (a *aggregate) update(ev *evaluator, record string) error {
+	if a.expr == nil {
+		a.count++
+		return nil
+	}
+	v, err := ev.eval(a.expr, record)
+	if err != nil {
+		return err
+	}
+	if v.isNull() {
+		return nil
+	}
+	switch a.name {
+	case "SUM", "AVG":
+		if v.kind == kindString && strings.TrimSpace(v.s) == "" {
+			// Empty CSV fields hold no number.
+			return nil
+		}
+		n, isInt, err := exactNumber(v)
+		if err != nil {
+			return err
+		}
+		a.sum.Add(a.sum, n)
+		a.onlyInts = a.onlyInts && isInt
+	case "MIN", "MAX":
+		if n, ok := v.toNumber(); ok {
+			// Numbers found in CSV records are compared as numbers.
+			v = n
+		}
+		if a.count == 0 {
+			a.extreme = v
+			break
+		}
+		cmp, ok := compareValues(v, a.extreme)
+		if !ok {
+			return ErrInvalidDataType
+		}
+		if (a.name == "MIN" && cmp < 0) || (a.name == "MAX" && cmp > 0) {
+			a.extreme = v
+		}
+	}
+	a.count++
+	return nil
+}
+
+// result returns the value of the aggregate, COUNT is 0 and the other
+// functions are NULL when no value was found.
+func (a *aggregate) result() value {
+	if a.name == "COUNT" {
+		return intValue(a.count)
+	}
+	if a.count == 0 {
+		return nullValue()
+	}
+	switch a.name {
+	case "SUM":
+		if a.onlyInts && a.sum.Num().IsInt64() {
+			return intValue(a.sum.Num().Int64())
+		}
+		return decimalValue(new(big.Rat).Set(a.sum))
+	case "AVG":
+		return decimalValue(new(big.Rat).Quo(a.sum, new(big.Rat).SetInt64(a.count)))
+	}
+	return a.extreme
+}
+
+// exactNumber returns the exact value of a number to sum, floats are taken
+// as their shortest decimal representation so that 0.1 + 0.2 is 0.3. isInt
+// is true for integers.
+func exactNumber(v value) (n *big.Rat, isInt bool, err error) {
+	switch v.kind {
+	case kindInt:
+		return new(big.Rat).SetInt64(v.i), true, nil
+	case kindDecimal:
+		return v.d, false, nil
+	case kindFloat:
+		if math.IsInf(v.f, 0) || math.IsNaN(v.f) {
+			return nil, false, ErrInvalidDataType
+		}
+		n, _ := parseDecimal(strconv.FormatFloat(v.f, 'g', -1, 64))
+		return n, false, nil
+	case kindString:
+		if v.raw != "" {
+			break
+		}
+		s := strings.TrimSpace(v.s)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return new(big.Rat).SetInt64(i), true, nil
+		}
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			if n, ok := parseDecimal(s); ok {
+				return n, false, nil
+			}
+		}
+	}
+	return nil, false, ErrInvalidDataType
+}
@@ -36,7 +36,7 @@ const (
 	MaxCharsPerRecord = 1000000
 )
 
-// SelectCompressionType - ONLY GZIP is supported
+// SelectCompressionType - NONE, GZIP, BZIP2, ZSTD or SNAPPY
 type SelectCompressionType string
 
 // JSONType determines json input serialization type.
@@ -47,6 +47,10 @@ const (
 	SelectCompressionNONE SelectCompressionType = "NONE"
 	SelectCompressionGZIP                       = "GZIP"
 	SelectCompressionBZIP                       = "BZIP2"
+	SelectCompressionZSTD                       = "ZSTD"
+	// SelectCompressionSNAPPY is the snappy framing format, which is also
+	// used by the server for internally compressed objects.
+	SelectCompressionSNAPPY = "SNAPPY"
 )
 
 // CSVQuoteFields - Can be either Always or AsNeeded
@@ -107,4 +111,10 @@ type ObjectSelectRequest struct {
 	RequestProgress struct {
 		Enabled bool
 	}
+	// ScanRange restricts the query to the records starting within the
+	// given byte range of the object, both bounds are optional.
+	ScanRange *struct {
+		Start *int64
+		End   *int64
+	}
 }
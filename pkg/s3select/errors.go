@@ -61,7 +61,11 @@ var ErrMissingHeaders = errors.New("Some headers in the query are missing from t
 
 // ErrInvalidCompressionFormat is an error if an unsupported compression type is
 // utilized with the select object query.
-var ErrInvalidCompressionFormat = errors.New("The file is not in a supported compression format. Only GZIP is supported at this time")
+var ErrInvalidCompressionFormat = errors.New("The file is not in a supported compression format. Only GZIP, BZIP2, ZSTD and SNAPPY are supported at this time")
+
+// ErrInvalidScanRange is an error if the scan range is invalid or is used with
+// an input which cannot be split at record boundaries.
+var ErrInvalidScanRange = errors.New("The scan range is invalid. It is only supported for uncompressed CSV and JSON LINES objects")
 
 // ErrInvalidFileHeaderInfo is an error if the argument provided to the
 // FileHeader Argument is incorrect.
@@ -387,6 +391,7 @@ var errorCodeResponse = map[error]string{
 	ErrOverMaxRecordSize:                                      "OverMaxRecordSize",
 	ErrMissingHeaders:                                         "MissingHeaders",
 	ErrInvalidCompressionFormat:                               "InvalidCompressionFormat",
+	ErrInvalidScanRange:                                       "InvalidScanRange",
 	format.ErrTruncatedInput:                                  "TruncatedInput",
 	ErrInvalidFileHeaderInfo:                                  "InvalidFileHeaderInfo",
 	ErrInvalidJSONType:                                        "InvalidJsonType",
@@ -394,6 +399,9 @@ var errorCodeResponse = map[error]string{
 	ErrInvalidRequestParameter:                                "InvalidRequestParameter",
 	format.ErrCSVParsingError:                                 "CSVParsingError",
 	format.ErrJSONParsingError:                                "JSONParsingError",
+	format.ErrParquetParsingError:                             "ParquetParsingError",
+	format.ErrParquetUnsupportedCompressionCodec:              "ParquetUnsupportedCompressionCodec",
+	format.ErrParquetUnsupportedType:                          "UnsupportedParquetType",
 	ErrExternalEvalException:                                  "ExternalEvalException",
 	ErrInvalidDataType:                                        "InvalidDataType",
 	ErrUnrecognizedFormatException:                            "UnrecognizedFormatException",
@@ -17,210 +17,670 @@
 package s3select
 
 import (
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/pydio/minio-srv/pkg/s3select/format"
+	"github.com/tidwall/gjson"
 	"github.com/xwb1989/sqlparser"
 )
 
-// stringOps is a function which handles the case in a clause if there is a need
-// to perform a string function
-func stringOps(myFunc *sqlparser.FuncExpr, record string, myReturnVal string) string {
-	var value string
-	funcName := myFunc.Name.CompliantName()
-	switch tempArg := myFunc.Exprs[0].(type) {
-	case *sqlparser.AliasedExpr:
-		switch col := tempArg.Expr.(type) {
-		case *sqlparser.FuncExpr:
-			// myReturnVal is actually the tail recursive value being used in the eval func.
-			return applyStrFunc(myReturnVal, funcName)
-		case *sqlparser.ColName:
-			value = applyStrFunc(jsonValue(col.Name.CompliantName(), record), funcName)
-		case *sqlparser.SQLVal:
-			value = applyStrFunc(string(col.Val), funcName)
+// functionArity holds the minimum and maximum number of arguments of the
+// supported functions, -1 meaning any number.
+var functionArity = map[string][2]int{
+	"CAST":             {2, 2},
+	"COALESCE":         {1, -1},
+	"NULLIF":           {2, 2},
+	"TRIM":             {1, 1},
+	"LOWER":            {1, 1},
+	"UPPER":            {1, 1},
+	"CHAR_LENGTH":      {1, 1},
+	"CHARACTER_LENGTH": {1, 1},
+	"UTCNOW":           {0, 0},
+	"TO_TIMESTAMP":     {1, 1},
+	"EXTRACT":          {2, 2},
+	"DATE_ADD":         {3, 3},
+	"DATE_DIFF":        {3, 3},
+}
+
+// evaluator evaluates SQL expressions against records, which are given
+// encoded in JSON.
+type evaluator struct {
+	alias string
+	// positional is set when the columns are only known by their position,
+	// the keys of the records then start at _0 while the columns are named
+	// from _1.
+	positional bool
+}
+
+func newEvaluator(alias string, f format.Select) *evaluator {
+	return &evaluator{
+		alias:      alias,
+		positional: f.Type() == format.CSV && !f.HasHeader(),
+	}
+}
+
+// eval returns the value of expr for the record.
+func (e *evaluator) eval(expr sqlparser.Expr, record string) (value, error) {
+	switch expr := expr.(type) {
+	case *sqlparser.ParenExpr:
+		return e.eval(expr.Expr, record)
+	case *sqlparser.SQLVal:
+		return sqlValue(expr)
+	case *sqlparser.NullVal:
+		return nullValue(), nil
+	case sqlparser.BoolVal:
+		return boolValue(bool(expr)), nil
+	case *sqlparser.ColName:
+		return e.column(expr, record), nil
+	case *sqlparser.AndExpr:
+		return e.and(expr, record)
+	case *sqlparser.OrExpr:
+		return e.or(expr, record)
+	case *sqlparser.NotExpr:
+		v, err := e.eval(expr.Expr, record)
+		if err != nil {
+			return value{}, err
+		}
+		return not(v)
+	case *sqlparser.ComparisonExpr:
+		return e.comparison(expr, record)
+	case *sqlparser.RangeCond:
+		return e.between(expr, record)
+	case *sqlparser.IsExpr:
+		return e.is(expr, record)
+	case *sqlparser.BinaryExpr:
+		left, err := e.eval(expr.Left, record)
+		if err != nil {
+			return value{}, err
 		}
+		right, err := e.eval(expr.Right, record)
+		if err != nil {
+			return value{}, err
+		}
+		return arithmetic(expr.Operator, left, right)
+	case *sqlparser.UnaryExpr:
+		v, err := e.eval(expr.Expr, record)
+		if err != nil {
+			return value{}, err
+		}
+		return unary(expr.Operator, v)
+	case *sqlparser.FuncExpr:
+		return e.function(expr, record)
+	case *sqlparser.SubstrExpr:
+		return e.substring(expr, record)
 	}
-	return value
+	return value{}, ErrUnsupportedSQLOperation
 }
 
-// coalOps is a function which decomposes a COALESCE func expr into its struct.
-func coalOps(myFunc *sqlparser.FuncExpr, record string, myReturnVal string) string {
-	myArgs := make([]string, len(myFunc.Exprs))
+// column returns the value found at the path of a column, MISSING if there
+// is none.
+func (e *evaluator) column(col *sqlparser.ColName, record string) value {
+	path := columnPath(col, e.alias)
+	if e.positional && strings.HasPrefix(path, "_") {
+		if i, err := strconv.Atoi(path[1:]); err == nil {
+			path = "_" + strconv.Itoa(i-1)
+		}
+	}
+	return valueFromJSON(gjson.Get(record, path))
+}
 
-	for i := 0; i < len(myFunc.Exprs); i++ {
-		switch tempArg := myFunc.Exprs[i].(type) {
-		case *sqlparser.AliasedExpr:
-			switch col := tempArg.Expr.(type) {
-			case *sqlparser.FuncExpr:
-				// myReturnVal is actually the tail recursive value being used in the eval func.
-				return myReturnVal
-			case *sqlparser.ColName:
-				myArgs[i] = jsonValue(col.Name.CompliantName(), record)
-			case *sqlparser.SQLVal:
-				myArgs[i] = string(col.Val)
+// sqlValue returns the value of a literal, decimal numbers are exact.
+func sqlValue(val *sqlparser.SQLVal) (value, error) {
+	switch val.Type {
+	case sqlparser.StrVal:
+		return stringValue(string(val.Val)), nil
+	case sqlparser.IntVal:
+		i, err := strconv.ParseInt(string(val.Val), 10, 64)
+		if err != nil {
+			return value{}, ErrIntegerOverflow
+		}
+		return intValue(i), nil
+	case sqlparser.FloatVal:
+		if strings.ContainsAny(string(val.Val), "eE") {
+			f, err := strconv.ParseFloat(string(val.Val), 64)
+			if err != nil {
+				return value{}, ErrLexerInvalidLiteral
 			}
+			return floatValue(f), nil
 		}
+		d, ok := parseDecimal(string(val.Val))
+		if !ok {
+			return value{}, ErrLexerInvalidLiteral
+		}
+		return decimalValue(d), nil
 	}
-	return processCoalNoIndex(myArgs)
+	return value{}, ErrLexerInvalidLiteral
 }
 
-// nullOps is a function which decomposes a NullIf func expr into its struct.
-func nullOps(myFunc *sqlparser.FuncExpr, record string, myReturnVal string) string {
-	myArgs := make([]string, 2)
+// logical returns the truth of a value, known is false for NULL and MISSING.
+func logical(v value) (b bool, known bool, err error) {
+	if v.isNull() {
+		return false, false, nil
+	}
+	if b, ok := v.toBool(); ok {
+		return b, true, nil
+	}
+	return false, false, ErrInvalidDataType
+}
 
-	for i := 0; i < len(myFunc.Exprs); i++ {
-		switch tempArg := myFunc.Exprs[i].(type) {
-		case *sqlparser.AliasedExpr:
-			switch col := tempArg.Expr.(type) {
-			case *sqlparser.FuncExpr:
-				return myReturnVal
-			case *sqlparser.ColName:
-				myArgs[i] = jsonValue(col.Name.CompliantName(), record)
-			case *sqlparser.SQLVal:
-				myArgs[i] = string(col.Val)
-			}
+func not(v value) (value, error) {
+	b, known, err := logical(v)
+	if err != nil || !known {
+		return nullValue(), err
+	}
+	return boolValue(!b), nil
+}
+
+// and implements the three-valued AND, FALSE if either side is FALSE, NULL
+// if either side is NULL and TRUE otherwise.
+func (e *evaluator) and(expr *sqlparser.AndExpr, record string) (value, error) {
+	left, err := e.eval(expr.Left, record)
+	if err != nil {
+		return value{}, err
+	}
+	l, knownL, err := logical(left)
+	if err != nil {
+		return value{}, err
+	}
+	if knownL && !l {
+		return boolValue(false), nil
+	}
+	right, err := e.eval(expr.Right, record)
+	if err != nil {
+		return value{}, err
+	}
+	r, knownR, err := logical(right)
+	if err != nil {
+		return value{}, err
+	}
+	switch {
+	case knownR && !r:
+		return boolValue(false), nil
+	case !knownL || !knownR:
+		return nullValue(), nil
+	}
+	return boolValue(true), nil
+}
+
+// or implements the three-valued OR, TRUE if either side is TRUE, NULL if
+// either side is NULL and FALSE otherwise.
+func (e *evaluator) or(expr *sqlparser.OrExpr, record string) (value, error) {
+	left, err := e.eval(expr.Left, record)
+	if err != nil {
+		return value{}, err
+	}
+	l, knownL, err := logical(left)
+	if err != nil {
+		return value{}, err
+	}
+	if knownL && l {
+		return boolValue(true), nil
+	}
+	right, err := e.eval(expr.Right, record)
+	if err != nil {
+		return value{}, err
+	}
+	r, knownR, err := logical(right)
+	if err != nil {
+		return value{}, err
+	}
+	switch {
+	case knownR && r:
+		return boolValue(true), nil
+	case !knownL || !knownR:
+		return nullValue(), nil
+	}
+	return boolValue(false), nil
+}
+
+// compare applies a comparison operator, comparisons with NULL are NULL.
+// Values which cannot be compared are different.
+func compare(op string, a, b value) (value, error) {
+	if a.isNull() || b.isNull() {
+		return nullValue(), nil
+	}
+	cmp, ok := compareValues(a, b)
+	if !ok {
+		return boolValue(op == sqlparser.NotEqualStr), nil
+	}
+	switch op {
+	case sqlparser.EqualStr:
+		return boolValue(cmp == 0), nil
+	case sqlparser.NotEqualStr:
+		return boolValue(cmp != 0), nil
+	case sqlparser.LessThanStr:
+		return boolValue(cmp < 0), nil
+	case sqlparser.LessEqualStr:
+		return boolValue(cmp <= 0), nil
+	case sqlparser.GreaterThanStr:
+		return boolValue(cmp > 0), nil
+	case sqlparser.GreaterEqualStr:
+		return boolValue(cmp >= 0), nil
+	}
+	return value{}, ErrParseUnknownOperator
+}
+
+func (e *evaluator) comparison(expr *sqlparser.ComparisonExpr, record string) (value, error) {
+	left, err := e.eval(expr.Left, record)
+	if err != nil {
+		return value{}, err
+	}
+	switch expr.Operator {
+	case sqlparser.InStr, sqlparser.NotInStr:
+		v, err := e.in(left, expr.Right, record)
+		if err != nil || expr.Operator == sqlparser.InStr {
+			return v, err
+		}
+		return not(v)
+	}
+	right, err := e.eval(expr.Right, record)
+	if err != nil {
+		return value{}, err
+	}
+	switch expr.Operator {
+	case sqlparser.LikeStr, sqlparser.NotLikeStr:
+		if expr.Escape != nil {
+			return value{}, ErrUnsupportedSQLOperation
+		}
+		if left.isNull() || right.isNull() {
+			return nullValue(), nil
 		}
+		matched, err := likeConvert(right.String(), left.String())
+		if err != nil {
+			return value{}, err
+		}
+		return boolValue(matched == (expr.Operator == sqlparser.LikeStr)), nil
 	}
-	return processNullIf(myArgs)
+	return compare(expr.Operator, left, right)
 }
 
-// isValidString is a function that ensures the current index is one with a
-// StrFunc
-func isValidFunc(myList []int, index int) bool {
-	if myList == nil {
-		return false
+// in returns TRUE if v is equal to one of the values of the list, otherwise
+// NULL if v or one of the values is NULL.
+func (e *evaluator) in(v value, list sqlparser.Expr, record string) (value, error) {
+	tuple, ok := list.(sqlparser.ValTuple)
+	if !ok {
+		return value{}, ErrUnsupportedSQLOperation
 	}
-	for i := 0; i < len(myList); i++ {
-		if myList[i] == index {
-			return true
+	result := boolValue(false)
+	for _, expr := range tuple {
+		item, err := e.eval(expr, record)
+		if err != nil {
+			return value{}, err
+		}
+		eq, err := compare(sqlparser.EqualStr, v, item)
+		if err != nil {
+			return value{}, err
+		}
+		if eq.isNull() {
+			result = eq
+		} else if eq.b {
+			return eq, nil
 		}
 	}
-	return false
+	return result, nil
 }
 
-// processNullIf is a function that evaluates a given NULLIF clause.
-func processNullIf(nullStore []string) string {
-	nullValOne := nullStore[0]
-	nullValTwo := nullStore[1]
-	if nullValOne == nullValTwo {
-		return ""
+func (e *evaluator) between(expr *sqlparser.RangeCond, record string) (value, error) {
+	v, err := e.eval(expr.Left, record)
+	if err != nil {
+		return value{}, err
+	}
+	from, err := e.eval(expr.From, record)
+	if err != nil {
+		return value{}, err
+	}
+	to, err := e.eval(expr.To, record)
+	if err != nil {
+		return value{}, err
+	}
+	ge, err := compare(sqlparser.GreaterEqualStr, v, from)
+	if err != nil {
+		return value{}, err
 	}
-	return nullValOne
+	le, err := compare(sqlparser.LessEqualStr, v, to)
+	if err != nil {
+		return value{}, err
+	}
+	var result value
+	switch {
+	case ge.kind == kindBool && !ge.b, le.kind == kindBool && !le.b:
+		result = boolValue(false)
+	case ge.isNull() || le.isNull():
+		result = nullValue()
+	default:
+		result = boolValue(true)
+	}
+	if expr.Operator == sqlparser.NotBetweenStr {
+		return not(result)
+	}
+	return result, nil
 }
 
-// processCoalNoIndex is a function which evaluates a given COALESCE clause.
-func processCoalNoIndex(coalStore []string) string {
-	for i := 0; i < len(coalStore); i++ {
-		if coalStore[i] != "null" && coalStore[i] != "missing" && coalStore[i] != "" {
-			return coalStore[i]
-		}
+// is evaluates IS [NOT] NULL, which is true for MISSING values as well, and
+// IS [NOT] TRUE or FALSE which are never NULL.
+func (e *evaluator) is(expr *sqlparser.IsExpr, record string) (value, error) {
+	v, err := e.eval(expr.Expr, record)
+	if err != nil {
+		return value{}, err
 	}
-	return "null"
+	switch expr.Operator {
+	case sqlparser.IsNullStr:
+		return boolValue(v.isNull()), nil
+	case sqlparser.IsNotNullStr:
+		return boolValue(!v.isNull()), nil
+	}
+	b, known, err := logical(v)
+	if err != nil {
+		return value{}, err
+	}
+	switch expr.Operator {
+	case sqlparser.IsTrueStr:
+		return boolValue(known && b), nil
+	case sqlparser.IsNotTrueStr:
+		return boolValue(!known || !b), nil
+	case sqlparser.IsFalseStr:
+		return boolValue(known && !b), nil
+	case sqlparser.IsNotFalseStr:
+		return boolValue(!known || b), nil
+	}
+	return value{}, ErrUnsupportedSQLOperation
 }
 
-// evaluateFuncExpr is a function that allows for tail recursive evaluation of
-// nested function expressions
-func evaluateFuncExpr(myVal *sqlparser.FuncExpr, myReturnVal string, myRecord string) string {
-	if myVal == nil {
-		return myReturnVal
+func unary(op string, v value) (value, error) {
+	if v.isNull() {
+		return nullValue(), nil
 	}
-	// retrieve all the relevant arguments of the function
-	var mySubFunc []*sqlparser.FuncExpr
-	mySubFunc = make([]*sqlparser.FuncExpr, len(myVal.Exprs))
-	for i := 0; i < len(myVal.Exprs); i++ {
-		switch col := myVal.Exprs[i].(type) {
-		case *sqlparser.AliasedExpr:
-			switch temp := col.Expr.(type) {
-			case *sqlparser.FuncExpr:
-				mySubFunc[i] = temp
-			}
+	n, ok := v.toNumber()
+	if !ok {
+		return value{}, ErrInvalidDataType
+	}
+	switch op {
+	case sqlparser.UPlusStr:
+		return n, nil
+	case sqlparser.UMinusStr:
+		return arithmetic(sqlparser.MinusStr, intValue(0), n)
+	}
+	return value{}, ErrUnsupportedSQLOperation
+}
+
+// function evaluates a call to one of the supported functions, the number of
+// arguments is checked beforehand by funcErrs.
+func (e *evaluator) function(fn *sqlparser.FuncExpr, record string) (value, error) {
+	name := strings.ToUpper(fn.Name.String())
+	args := make([]sqlparser.Expr, len(fn.Exprs))
+	for i, arg := range fn.Exprs {
+		aliased, ok := arg.(*sqlparser.AliasedExpr)
+		if !ok {
+			return value{}, ErrParseUnsupportedCallWithStar
 		}
+		args[i] = aliased.Expr
 	}
-	// Need to do tree recursion so as to explore all possible directions of the
-	// nested function recursion
-	for i := 0; i < len(mySubFunc); i++ {
-		if supportedString(myVal.Name.CompliantName()) {
-			if mySubFunc != nil {
-				return stringOps(myVal, myRecord, evaluateFuncExpr(mySubFunc[i], myReturnVal, myRecord))
-			}
-			return stringOps(myVal, myRecord, myReturnVal)
-		} else if strings.ToUpper(myVal.Name.CompliantName()) == "NULLIF" {
-			if mySubFunc != nil {
-				return nullOps(myVal, myRecord, evaluateFuncExpr(mySubFunc[i], myReturnVal, myRecord))
-			}
-			return nullOps(myVal, myRecord, myReturnVal)
-		} else if strings.ToUpper(myVal.Name.CompliantName()) == "COALESCE" {
-			if mySubFunc != nil {
-				return coalOps(myVal, myRecord, evaluateFuncExpr(mySubFunc[i], myReturnVal, myRecord))
+	if arity, ok := functionArity[name]; !ok || len(args) < arity[0] || (arity[1] >= 0 && len(args) > arity[1]) {
+		return value{}, ErrUnsupportedSQLOperation
+	}
+	switch name {
+	case "COALESCE":
+		for _, arg := range args {
+			v, err := e.eval(arg, record)
+			if err != nil || !v.isNull() {
+				return v, err
 			}
-			return coalOps(myVal, myRecord, myReturnVal)
 		}
+		return nullValue(), nil
+	case "UTCNOW":
+		return timestampValue(utcNow()), nil
+	case "CAST":
+		typ, ok := args[1].(*sqlparser.SQLVal)
+		if !ok || typ.Type != sqlparser.StrVal {
+			return value{}, ErrParseExpectedTypeName
+		}
+		v, err := e.eval(args[0], record)
+		if err != nil {
+			return value{}, err
+		}
+		return castValue(v, string(typ.Val))
+	case "EXTRACT", "DATE_ADD", "DATE_DIFF":
+		return e.dateFunction(name, args, record)
+	}
+
+	values := make([]value, len(args))
+	for i, arg := range args {
+		v, err := e.eval(arg, record)
+		if err != nil {
+			return value{}, err
+		}
+		values[i] = v
+	}
+	if name == "NULLIF" {
+		eq, err := compare(sqlparser.EqualStr, values[0], values[1])
+		if err != nil {
+			return value{}, err
+		}
+		if eq.kind == kindBool && eq.b {
+			return nullValue(), nil
+		}
+		return values[0], nil
+	}
+	v := values[0]
+	if v.isNull() {
+		return nullValue(), nil
+	}
+	switch name {
+	case "TRIM":
+		return stringValue(strings.TrimSpace(v.String())), nil
+	case "LOWER":
+		return stringValue(strings.ToLower(v.String())), nil
+	case "UPPER":
+		return stringValue(strings.ToUpper(v.String())), nil
+	case "CHAR_LENGTH", "CHARACTER_LENGTH":
+		return intValue(int64(utf8.RuneCountInString(v.String()))), nil
+	case "TO_TIMESTAMP":
+		t, ok := v.toTimestamp()
+		if !ok {
+			return value{}, ErrValueParseFailure
+		}
+		return timestampValue(t), nil
 	}
-	return ""
+	return value{}, ErrUnsupportedSQLOperation
 }
 
-// evaluateFuncErr is a function that flags errors in nested functions.
-func evaluateFuncErr(myVal *sqlparser.FuncExpr, reader format.Select) error {
-	if myVal == nil {
-		return nil
+// dateFunction evaluates EXTRACT, DATE_ADD and DATE_DIFF whose first argument
+// is the name of a timestamp field such as year or day.
+func (e *evaluator) dateFunction(name string, args []sqlparser.Expr, record string) (value, error) {
+	part, ok := datePart(args[0])
+	if !ok {
+		return value{}, ErrIllegalSQLFunctionArgument
 	}
-	if !supportedFunc(myVal.Name.CompliantName()) {
-		return ErrUnsupportedSQLOperation
+	values := make([]value, len(args)-1)
+	for i, arg := range args[1:] {
+		v, err := e.eval(arg, record)
+		if err != nil {
+			return value{}, err
+		}
+		if v.isNull() {
+			return nullValue(), nil
+		}
+		values[i] = v
 	}
-	for i := 0; i < len(myVal.Exprs); i++ {
-		switch tempArg := myVal.Exprs[i].(type) {
-		case *sqlparser.StarExpr:
-			return ErrParseUnsupportedCallWithStar
-		case *sqlparser.AliasedExpr:
-			switch col := tempArg.Expr.(type) {
-			case *sqlparser.FuncExpr:
-				if err := evaluateFuncErr(col, reader); err != nil {
-					return err
-				}
-			case *sqlparser.ColName:
-				if err := reader.ColNameErrs([]string{col.Name.CompliantName()}); err != nil {
-					return err
-				}
-			}
+	timestamp := func(v value) (time.Time, error) {
+		t, ok := v.toTimestamp()
+		if !ok {
+			return t, ErrIncorrectSQLFunctionArgumentType
 		}
+		return t, nil
 	}
-	return nil
+	switch name {
+	case "EXTRACT":
+		t, err := timestamp(values[0])
+		if err != nil {
+			return value{}, err
+		}
+		n, err := extractPart(part, t)
+		return intValue(n), err
+	case "DATE_ADD":
+		n, ok := values[0].toNumber()
+		if !ok || n.kind != kindInt {
+			return value{}, ErrIncorrectSQLFunctionArgumentType
+		}
+		t, err := timestamp(values[1])
+		if err != nil {
+			return value{}, err
+		}
+		added, err := dateAdd(part, n.i, t)
+		return timestampValue(added), err
+	}
+	from, err := timestamp(values[0])
+	if err != nil {
+		return value{}, err
+	}
+	to, err := timestamp(values[1])
+	if err != nil {
+		return value{}, err
+	}
+	n, err := dateDiff(part, from, to)
+	return intValue(n), err
 }
 
-// evaluateIsExpr is a function for evaluating expressions of the form "column is ...."
-func evaluateIsExpr(myFunc *sqlparser.IsExpr, row string, alias string) (bool, error) {
-	operator := myFunc.Operator
-	var myVal string
-	switch myIs := myFunc.Expr.(type) {
-	// case for literal val
-	case *sqlparser.SQLVal:
-		myVal = string(myIs.Val)
-	// case for nested func val
-	case *sqlparser.FuncExpr:
-		myVal = evaluateFuncExpr(myIs, "", row)
-	// case for col val
-	case *sqlparser.ColName:
-		myVal = jsonValue(myIs.Name.CompliantName(), row)
+// substring evaluates SUBSTRING(col, start[, length]), start being the
+// position of the first character from 1.
+func (e *evaluator) substring(expr *sqlparser.SubstrExpr, record string) (value, error) {
+	v := e.column(expr.Name, record)
+	start, err := e.eval(expr.From, record)
+	if err != nil {
+		return value{}, err
+	}
+	length := intValue(-1)
+	if expr.To != nil {
+		if length, err = e.eval(expr.To, record); err != nil {
+			return value{}, err
+		}
+	}
+	if v.isNull() || start.isNull() || length.isNull() {
+		return nullValue(), nil
+	}
+	start, okStart := start.toNumber()
+	length, okLength := length.toNumber()
+	if !okStart || !okLength || start.kind != kindInt || length.kind != kindInt {
+		return value{}, ErrIncorrectSQLFunctionArgumentType
 	}
-	// case to evaluate is null
-	if strings.ToLower(operator) == "is null" {
-		return myVal == "", nil
+	runes := []rune(v.String())
+	from, to := start.i-1, int64(len(runes))
+	if length.i >= 0 && from+length.i < to {
+		to = from + length.i
 	}
-	// case to evaluate is not null
-	if strings.ToLower(operator) == "is not null" {
-		return myVal != "", nil
+	if from < 0 {
+		from = 0
 	}
-	return false, ErrUnsupportedSQLOperation
+	if from >= to {
+		return stringValue(""), nil
+	}
+	return stringValue(string(runes[from:to])), nil
 }
 
-// supportedString is a function that checks whether the function is a supported
-// string one
-func supportedString(strFunc string) bool {
-	return format.StringInSlice(strings.ToUpper(strFunc), []string{"TRIM", "SUBSTRING", "CHAR_LENGTH", "CHARACTER_LENGTH", "LOWER", "UPPER"})
+// datePart returns the timestamp field named by the first argument of a date
+// function.
+func datePart(expr sqlparser.Expr) (string, bool) {
+	col, ok := expr.(*sqlparser.ColName)
+	if !ok || !col.Qualifier.IsEmpty() {
+		return "", false
+	}
+	part := col.Name.Lowered()
+	switch part {
+	case "year", "month", "day", "hour", "minute", "second", "timezone_hour", "timezone_minute":
+		return part, true
+	}
+	return "", false
 }
 
-// supportedFunc is a function that checks whether the function is a supported
-// S3 one.
-func supportedFunc(strFunc string) bool {
-	return format.StringInSlice(strings.ToUpper(strFunc), []string{"TRIM", "SUBSTRING", "CHAR_LENGTH", "CHARACTER_LENGTH", "LOWER", "UPPER", "COALESCE", "NULLIF"})
+// exprErrs is a function which returns an error if an expression uses an
+// unsupported operation or function, or a column which does not exist.
+func exprErrs(expr sqlparser.Expr, alias string, f format.Select) error {
+	return sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+		switch node := node.(type) {
+		case *sqlparser.ColName:
+			return false, f.ColNameErrs([]string{cleanCol(columnPath(node, alias), alias)})
+		case *sqlparser.FuncExpr:
+			return false, funcErrs(node, alias, f)
+		case *sqlparser.ComparisonExpr:
+			switch node.Operator {
+			case sqlparser.EqualStr, sqlparser.NotEqualStr, sqlparser.LessThanStr, sqlparser.LessEqualStr,
+				sqlparser.GreaterThanStr, sqlparser.GreaterEqualStr, sqlparser.LikeStr, sqlparser.NotLikeStr:
+			case sqlparser.InStr, sqlparser.NotInStr:
+				if _, ok := node.Right.(sqlparser.ValTuple); !ok {
+					return false, ErrUnsupportedSQLOperation
+				}
+			default:
+				return false, ErrParseUnknownOperator
+			}
+			if node.Escape != nil {
+				return false, ErrUnsupportedSQLOperation
+			}
+		case *sqlparser.BinaryExpr:
+			switch node.Operator {
+			case sqlparser.PlusStr, sqlparser.MinusStr, sqlparser.MultStr, sqlparser.DivStr, sqlparser.ModStr:
+			default:
+				return false, ErrParseUnknownOperator
+			}
+		case *sqlparser.UnaryExpr:
+			if node.Operator != sqlparser.UPlusStr && node.Operator != sqlparser.UMinusStr {
+				return false, ErrParseUnknownOperator
+			}
+		case *sqlparser.SQLVal:
+			if _, err := sqlValue(node); err != nil {
+				return false, err
+			}
+		case *sqlparser.ParenExpr, *sqlparser.AndExpr, *sqlparser.OrExpr, *sqlparser.NotExpr,
+			*sqlparser.RangeCond, *sqlparser.IsExpr, *sqlparser.SubstrExpr, *sqlparser.NullVal,
+			sqlparser.BoolVal, sqlparser.ValTuple, sqlparser.Exprs:
+		default:
+			return false, ErrUnsupportedSQLOperation
+		}
+		return true, nil
+	}, expr)
+}
+
+// funcErrs is a function that flags errors in function calls and their
+// arguments.
+func funcErrs(fn *sqlparser.FuncExpr, alias string, f format.Select) error {
+	name := strings.ToUpper(fn.Name.String())
+	arity, ok := functionArity[name]
+	if !ok || fn.IsAggregate() || fn.Distinct || !fn.Qualifier.IsEmpty() {
+		return ErrUnsupportedSQLOperation
+	}
+	args := make([]sqlparser.Expr, len(fn.Exprs))
+	for i, arg := range fn.Exprs {
+		aliased, ok := arg.(*sqlparser.AliasedExpr)
+		if !ok {
+			return ErrParseUnsupportedCallWithStar
+		}
+		args[i] = aliased.Expr
+	}
+	if len(args) < arity[0] || (arity[1] >= 0 && len(args) > arity[1]) {
+		return ErrEvaluatorInvalidArguments
+	}
+	switch name {
+	case "CAST":
+		typ, ok := args[1].(*sqlparser.SQLVal)
+		if !ok || typ.Type != sqlparser.StrVal {
+			return ErrParseExpectedTypeName
+		}
+		if _, _, ok := parseCastType(string(typ.Val)); !ok {
+			return ErrParseExpectedTypeName
+		}
+		args = args[:1]
+	case "EXTRACT", "DATE_ADD", "DATE_DIFF":
+		if _, ok := datePart(args[0]); !ok {
+			return ErrIllegalSQLFunctionArgument
+		}
+		args = args[1:]
+	}
+	for _, arg := range args {
+		if err := exprErrs(arg, alias, f); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -58,6 +58,12 @@ type Options struct {
 	// What the outputted CSV will be delimited by .
 	OutputFieldDelimiter string
 
+	// What the outputted records will be delimited by.
+	OutputRecordDelimiter string
+
+	// OutputType is the serialization of the outputted records.
+	OutputType format.Type
+
 	// Size of incoming object
 	StreamSize int64
 
@@ -191,6 +197,16 @@ func (reader *cinput) OutputFieldDelimiter() string {
 	return reader.options.OutputFieldDelimiter
 }
 
+// OutputRecordDelimiter - returns the record delimiter specified in input request
+func (reader *cinput) OutputRecordDelimiter() string {
+	return reader.options.OutputRecordDelimiter
+}
+
+// OutputType - returns the output serialization specified in input request
+func (reader *cinput) OutputType() format.Type {
+	return reader.options.OutputType
+}
+
 // HasHeader - returns true or false depending upon the header.
 func (reader *cinput) HasHeader() bool {
 	return reader.options.HasHeader
@@ -304,7 +320,9 @@ func (reader *cinput) ColNameErrs(columnNames []string) error {
 		}
 		if format.IsInt(columnNames[i]) {
 			tempInt, _ := strconv.Atoi(columnNames[i])
-			if tempInt > len(reader.Header()) || tempInt == 0 {
+			// Indices are not checked against empty inputs, such as
+			// scan ranges without records, which have no columns.
+			if (len(reader.Header()) > 0 && tempInt > len(reader.Header())) || tempInt == 0 {
 				return format.ErrInvalidColumnIndex
 			}
 		} else {
@@ -36,3 +36,15 @@ var ErrParseInvalidPathComponent = errors.New("The SQL expression contains an in
 
 // ErrJSONParsingError is an error if while parsing the JSON an error arises.
 var ErrJSONParsingError = errors.New("Encountered an error parsing the JSON file. Check the file and try again")
+
+// ErrParquetParsingError is an error if while parsing the Parquet file an
+// error arises.
+var ErrParquetParsingError = errors.New("Encountered an error parsing the Parquet file. Check the file and try again")
+
+// ErrParquetUnsupportedCompressionCodec is an error if a column of the Parquet
+// file uses a compression codec which is not supported.
+var ErrParquetUnsupportedCompressionCodec = errors.New("The specified Parquet compression codec is not supported")
+
+// ErrParquetUnsupportedType is an error if the Parquet file contains a column
+// type or an encoding which is not supported.
+var ErrParquetUnsupportedType = errors.New("The specified Parquet type is not supported")
@@ -41,8 +41,14 @@ type Options struct {
 	// SQL expression meant to be evaluated.
 	Expression string
 
-	// What the outputted  will be delimited by .
-	RecordDelimiter string
+	// What the outputted CSV will be delimited by .
+	OutputFieldDelimiter string
+
+	// What the outputted records will be delimited by.
+	OutputRecordDelimiter string
+
+	// OutputType is the serialization of the outputted records.
+	OutputType format.Type
 
 	// Size of incoming object
 	StreamSize int64
@@ -54,10 +60,18 @@ type Options struct {
 	Progress bool
 }
 
+// numberConfig decodes numbers as json.Number so that integers which do not
+// fit in a float64 are not rounded.
+var numberConfig = jsoniter.Config{
+	EscapeHTML:             true,
+	SortMapKeys:            true,
+	ValidateJsonRawMessage: true,
+	UseNumber:              true,
+}.Froze()
+
 // jinput represents a record producing input from a  formatted file or pipe.
 type jinput struct {
 	options         *Options
-	reader          *jsoniter.Decoder
 	firstRow        []string
 	header          []string
 	minOutputLength int
@@ -66,6 +80,12 @@ type jinput struct {
 		BytesReturned  int64
 		BytesProcessed int64
 	}
+
+	// iter streams the values of the input, so that the elements of a
+	// large top level array of a DOCUMENT input are read one at a time.
+	iter *jsoniter.Iterator
+	// inArray is true while the elements of a top level array are read.
+	inArray bool
 }
 
 // New sets up a new, the first Json is read when this is run.
@@ -75,8 +95,8 @@ type jinput struct {
 func New(opts *Options) (format.Select, error) {
 	reader := &jinput{
 		options: opts,
-		reader:  jsoniter.NewDecoder(opts.ReadFrom),
 	}
+	reader.iter = jsoniter.Parse(numberConfig, opts.ReadFrom, 64*1024)
 	reader.stats.BytesScanned = opts.StreamSize
 	reader.stats.BytesProcessed = 0
 	reader.stats.BytesReturned = 0
@@ -97,24 +117,84 @@ func (reader *jinput) UpdateBytesProcessed(record map[string]interface{}) {
 
 // Read the file and returns map[string]interface{}
 func (reader *jinput) Read() (map[string]interface{}, error) {
-	dec := reader.reader
-	var record interface{}
+	if reader.options.Type {
+		return reader.readDocument()
+	}
+	// Every line holds an object, whitespace is skipped so that the
+	// input may end with a delimiter.
+	iter := reader.iter
+	switch iter.WhatIsNext() {
+	case jsoniter.ObjectValue:
+	case jsoniter.InvalidValue:
+		if iter.Error == io.EOF || iter.Error == io.ErrClosedPipe {
+			return nil, nil
+		}
+		return nil, format.ErrJSONParsingError
+	default:
+		return nil, format.ErrJSONParsingError
+	}
+	record, ok := iter.Read().(map[string]interface{})
+	if !ok || (iter.Error != nil && iter.Error != io.EOF) {
+		return nil, format.ErrJSONParsingError
+	}
+	return record, nil
+}
+
+// readDocument returns the next record of a DOCUMENT input. The document may
+// span several lines and hold one or more values, the elements of a top level
+// array are returned as separate records.
+func (reader *jinput) readDocument() (map[string]interface{}, error) {
+	iter := reader.iter
 	for {
-		err := dec.Decode(&record)
-		if err == io.EOF || err == io.ErrClosedPipe {
-			break
+		if reader.inArray {
+			if !iter.ReadArray() {
+				if iter.Error != nil {
+					return nil, format.ErrJSONParsingError
+				}
+				reader.inArray = false
+				continue
+			}
+			return reader.documentRecord(iter.Read())
 		}
-		if err != nil {
+		switch iter.WhatIsNext() {
+		case jsoniter.InvalidValue:
+			if iter.Error == nil || iter.Error == io.EOF || iter.Error == io.ErrClosedPipe {
+				return nil, nil
+			}
 			return nil, format.ErrJSONParsingError
+		case jsoniter.ArrayValue:
+			reader.inArray = true
+			continue
 		}
-		return record.(map[string]interface{}), nil
+		return reader.documentRecord(iter.Read())
+	}
+}
+
+// documentRecord turns a value of a DOCUMENT input into a record, values
+// which are not objects are returned under the key _1.
+func (reader *jinput) documentRecord(value interface{}) (map[string]interface{}, error) {
+	if reader.iter.Error != nil && reader.iter.Error != io.EOF {
+		return nil, format.ErrJSONParsingError
+	}
+	if record, ok := value.(map[string]interface{}); ok {
+		return record, nil
 	}
-	return nil, nil
+	return map[string]interface{}{"_1": value}, nil
 }
 
 // OutputFieldDelimiter - returns the delimiter specified in input request
 func (reader *jinput) OutputFieldDelimiter() string {
-	return ","
+	return reader.options.OutputFieldDelimiter
+}
+
+// OutputRecordDelimiter - returns the record delimiter specified in input request
+func (reader *jinput) OutputRecordDelimiter() string {
+	return reader.options.OutputRecordDelimiter
+}
+
+// OutputType - returns the output serialization specified in input request
+func (reader *jinput) OutputType() format.Type {
+	return reader.options.OutputType
 }
 
 // HasHeader - returns true or false depending upon the header.
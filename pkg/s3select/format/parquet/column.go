@@ -0,0 +1,426 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pydio/minio-srv/pkg/s3select/format"
+)
+
+// julianDayOfEpoch is the julian day of 1970-01-01, used by INT96 timestamps.
+const julianDayOfEpoch = 2440588
+
+// column is a leaf of the schema, values of a column are stored in one
+// column chunk per row group.
+type column struct {
+	// path of the column from the schema root.
+	path []string
+	// element is the leaf schema element, describing the value type.
+	element *schemaElement
+	// maxDef and maxRep are the maximum definition and repetition levels.
+	maxDef int
+	maxRep int
+	// index of the column chunk within a row group.
+	index int
+}
+
+// name returns the dotted path of the column.
+func (c *column) name() string {
+	return strings.Join(c.path, ".")
+}
+
+// schemaColumns returns the leaf columns of a schema in the order of the
+// column chunks of the row groups.
+func schemaColumns(schema []schemaElement) ([]*column, error) {
+	if len(schema) == 0 {
+		return nil, format.ErrParquetParsingError
+	}
+	var columns []*column
+	pos := 1
+	var walk func(children int, path []string, def, rep int) error
+	walk = func(children int, path []string, def, rep int) error {
+		for i := 0; i < children; i++ {
+			if pos >= len(schema) {
+				return format.ErrParquetParsingError
+			}
+			e := &schema[pos]
+			pos++
+			d, r := def, rep
+			switch e.RepetitionType {
+			case repetitionOptional:
+				d++
+			case repetitionRepeated:
+				d++
+				r++
+			}
+			p := append(append([]string{}, path...), e.Name)
+			if e.NumChildren > 0 {
+				if err := walk(int(e.NumChildren), p, d, r); err != nil {
+					return err
+				}
+				continue
+			}
+			columns = append(columns, &column{
+				path:    p,
+				element: e,
+				maxDef:  d,
+				maxRep:  r,
+				index:   len(columns),
+			})
+		}
+		return nil
+	}
+	if err := walk(int(schema[0].NumChildren), nil, 0, 0); err != nil {
+		return nil, err
+	}
+	if pos != len(schema) {
+		return nil, format.ErrParquetParsingError
+	}
+	return columns, nil
+}
+
+// readChunk decodes all the values of the column in the given row group, the
+// raw chunk is read from data.
+func (c *column) readChunk(md *columnMetaData, data []byte, numRows int64) ([]interface{}, error) {
+	if c.maxRep > 0 {
+		// Repeated fields would need records to be re-assembled from
+		// repetition levels, only flat and nested records are supported.
+		return nil, format.ErrParquetUnsupportedType
+	}
+	if numRows < 0 || md.NumValues != numRows {
+		return nil, format.ErrParquetParsingError
+	}
+	// The number of rows comes from the file metadata, it is not trusted
+	// to preallocate the values which grow as pages are decoded.
+	capacity := numRows
+	if capacity > int64(len(data)) {
+		capacity = int64(len(data))
+	}
+	r := bytes.NewReader(data)
+	values := make([]interface{}, 0, capacity)
+	var dict []interface{}
+	for int64(len(values)) < md.NumValues {
+		remaining := md.NumValues - int64(len(values))
+		h, err := newThriftReader(r).readPageHeader()
+		if err != nil {
+			return nil, format.ErrParquetParsingError
+		}
+		if h.CompressedPageSize < 0 || int(h.CompressedPageSize) > r.Len() {
+			return nil, format.ErrParquetParsingError
+		}
+		page := make([]byte, h.CompressedPageSize)
+		if _, err = io.ReadFull(r, page); err != nil {
+			return nil, format.ErrParquetParsingError
+		}
+		switch {
+		case h.Type == pageDictionary && h.DictionaryPage != nil:
+			page, err = decompress(md.Codec, page, h.UncompressedPageSize)
+			if err != nil {
+				return nil, err
+			}
+			dict, err = c.decodeValues(encodingPlain, page, int(h.DictionaryPage.NumValues), nil)
+		case h.Type == pageData && h.DataPage != nil:
+			if h.DataPage.NumValues < 0 || int64(h.DataPage.NumValues) > remaining {
+				return nil, format.ErrParquetParsingError
+			}
+			values, err = c.readDataPage(values, h, page, md.Codec, dict)
+		case h.Type == pageDataV2 && h.DataPageV2 != nil:
+			if h.DataPageV2.NumValues < 0 || int64(h.DataPageV2.NumValues) > remaining {
+				return nil, format.ErrParquetParsingError
+			}
+			values, err = c.readDataPageV2(values, h, page, md.Codec, dict)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if int64(len(values)) != numRows {
+		return nil, format.ErrParquetParsingError
+	}
+	return values, nil
+}
+
+// readDataPage decodes a version 1 data page, where levels and values are
+// compressed together.
+func (c *column) readDataPage(values []interface{}, h *pageHeader, page []byte, codec int32, dict []interface{}) ([]interface{}, error) {
+	data, err := decompress(codec, page, h.UncompressedPageSize)
+	if err != nil {
+		return nil, err
+	}
+	n := int(h.DataPage.NumValues)
+	var defs []int32
+	if c.maxDef > 0 {
+		if h.DataPage.DefinitionLevelEncoding != encodingRLE {
+			return nil, format.ErrParquetUnsupportedType
+		}
+		if len(data) < 4 {
+			return nil, format.ErrParquetParsingError
+		}
+		size := int(binary.LittleEndian.Uint32(data))
+		if size < 0 || len(data) < 4+size {
+			return nil, format.ErrParquetParsingError
+		}
+		if defs, err = decodeRLE(data[4:4+size], bitWidth(c.maxDef), n); err != nil {
+			return nil, err
+		}
+		data = data[4+size:]
+	}
+	return c.appendValues(values, h.DataPage.Encoding, data, n, defs, dict)
+}
+
+// readDataPageV2 decodes a version 2 data page, where levels are stored
+// uncompressed before the values.
+func (c *column) readDataPageV2(values []interface{}, h *pageHeader, page []byte, codec int32, dict []interface{}) ([]interface{}, error) {
+	v2 := h.DataPageV2
+	repLen, defLen := int(v2.RepetitionLevelsByteLength), int(v2.DefinitionLevelsByteLength)
+	if repLen < 0 || defLen < 0 || len(page) < repLen+defLen {
+		return nil, format.ErrParquetParsingError
+	}
+	n := int(v2.NumValues)
+	var defs []int32
+	var err error
+	if c.maxDef > 0 {
+		if defs, err = decodeRLE(page[repLen:repLen+defLen], bitWidth(c.maxDef), n); err != nil {
+			return nil, err
+		}
+	}
+	data := page[repLen+defLen:]
+	if v2.IsCompressed {
+		if data, err = decompress(codec, data, h.UncompressedPageSize-int32(repLen+defLen)); err != nil {
+			return nil, err
+		}
+	}
+	return c.appendValues(values, v2.Encoding, data, n, defs, dict)
+}
+
+// appendValues decodes the values of a page and appends them to values, with
+// nil for every undefined value.
+func (c *column) appendValues(values []interface{}, encoding int32, data []byte, n int, defs []int32, dict []interface{}) ([]interface{}, error) {
+	defined := n
+	if defs != nil {
+		defined = 0
+		for _, d := range defs {
+			if int(d) == c.maxDef {
+				defined++
+			}
+		}
+	}
+	decoded, err := c.decodeValues(encoding, data, defined, dict)
+	if err != nil {
+		return nil, err
+	}
+	if defs == nil {
+		return append(values, decoded...), nil
+	}
+	j := 0
+	for _, d := range defs {
+		if int(d) == c.maxDef {
+			values = append(values, decoded[j])
+			j++
+		} else {
+			values = append(values, nil)
+		}
+	}
+	return values, nil
+}
+
+// decodeValues decodes n defined values stored with the given encoding.
+func (c *column) decodeValues(encoding int32, data []byte, n int, dict []interface{}) ([]interface{}, error) {
+	switch encoding {
+	case encodingPlain:
+		values, err := decodePlain(c.element.Type, c.element.TypeLength, data, n)
+		if err != nil {
+			return nil, err
+		}
+		for i := range values {
+			values[i] = c.convert(values[i])
+		}
+		return values, nil
+	case encodingPlainDictionary, encodingRLEDictionary:
+		if n == 0 {
+			return nil, nil
+		}
+		if dict == nil || len(data) == 0 {
+			return nil, format.ErrParquetParsingError
+		}
+		indices, err := decodeRLE(data[1:], int(data[0]), n)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i, idx := range indices {
+			if idx < 0 || int(idx) >= len(dict) {
+				return nil, format.ErrParquetParsingError
+			}
+			values[i] = dict[idx]
+		}
+		return values, nil
+	case encodingRLE:
+		if c.element.Type != typeBoolean || len(data) < 4 {
+			return nil, format.ErrParquetUnsupportedType
+		}
+		bits, err := decodeRLE(data[4:], 1, n)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i, b := range bits {
+			values[i] = b == 1
+		}
+		return values, nil
+	}
+	return nil, format.ErrParquetUnsupportedType
+}
+
+// convert turns a physical value into the value presented in records, using
+// the logical type annotations of the column.
+func (c *column) convert(v interface{}) interface{} {
+	e := c.element
+	isDecimal := e.HasConverted && e.ConvertedType == convertedDecimal
+	switch value := v.(type) {
+	case int32:
+		switch {
+		case isDecimal:
+			return decimalNumber(big.NewInt(int64(value)), e.Scale)
+		case e.HasConverted && e.ConvertedType == convertedDate:
+			return time.Unix(int64(value)*86400, 0).UTC()
+		case e.HasConverted && e.ConvertedType == convertedUint32:
+			return int64(uint32(value))
+		}
+		return int64(value)
+	case int64:
+		switch {
+		case isDecimal:
+			return decimalNumber(big.NewInt(value), e.Scale)
+		case e.IsTimestamp:
+			switch e.TimestampUnit {
+			case unitMillis:
+				return time.Unix(0, value*int64(time.Millisecond)).UTC()
+			case unitMicros:
+				return time.Unix(0, value*int64(time.Microsecond)).UTC()
+			}
+			return time.Unix(0, value).UTC()
+		case e.HasConverted && e.ConvertedType == convertedTimestampMillis:
+			return time.Unix(0, value*int64(time.Millisecond)).UTC()
+		case e.HasConverted && e.ConvertedType == convertedTimestampMicros:
+			return time.Unix(0, value*int64(time.Microsecond)).UTC()
+		case e.HasConverted && e.ConvertedType == convertedUint64:
+			return uint64(value)
+		}
+		return value
+	case int96:
+		nanos := int64(binary.LittleEndian.Uint64(value[:8]))
+		days := int64(binary.LittleEndian.Uint32(value[8:]))
+		return time.Unix((days-julianDayOfEpoch)*86400, nanos).UTC()
+	case float32:
+		// Go through the shortest representation so that 1.1 does not
+		// become 1.100000023841858.
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(value), 'g', -1, 32), 64)
+		return f
+	case []byte:
+		if isDecimal {
+			return decimalNumber(twosComplement(value), e.Scale)
+		}
+		return string(value)
+	}
+	return v
+}
+
+// twosComplement decodes a big-endian two's complement integer.
+func twosComplement(b []byte) *big.Int {
+	n := new(big.Int).SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(b))*8))
+	}
+	return n
+}
+
+// decimalNumber formats an unscaled decimal value, the result is kept as a
+// json.Number so that no precision is lost when records are marshalled.
+func decimalNumber(unscaled *big.Int, scale int32) json.Number {
+	if scale <= 0 {
+		return json.Number(unscaled.String())
+	}
+	digits := new(big.Int).Abs(unscaled).String()
+	if len(digits) <= int(scale) {
+		digits = strings.Repeat("0", int(scale)-len(digits)+1) + digits
+	}
+	point := len(digits) - int(scale)
+	s := digits[:point] + "." + digits[point:]
+	if unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+	return json.Number(s)
+}
+
+// numericValue returns the value of a statistic as an int64 or a float64, ok
+// is false if the column is not a plain numeric column.
+func (c *column) numericValue(b []byte) (interface{}, bool) {
+	e := c.element
+	if e.HasConverted && e.ConvertedType != convertedInt8 && e.ConvertedType != convertedInt16 &&
+		e.ConvertedType != convertedInt32 && e.ConvertedType != convertedInt64 {
+		return nil, false
+	}
+	if e.IsTimestamp {
+		return nil, false
+	}
+	switch e.Type {
+	case typeInt32:
+		if len(b) != 4 {
+			return nil, false
+		}
+		return int64(int32(binary.LittleEndian.Uint32(b))), true
+	case typeInt64:
+		if len(b) != 8 {
+			return nil, false
+		}
+		return int64(binary.LittleEndian.Uint64(b)), true
+	case typeFloat:
+		if len(b) != 4 {
+			return nil, false
+		}
+		f := math.Float32frombits(binary.LittleEndian.Uint32(b))
+		return c.convert(f), !math.IsNaN(float64(f))
+	case typeDouble:
+		if len(b) != 8 {
+			return nil, false
+		}
+		f := math.Float64frombits(binary.LittleEndian.Uint64(b))
+		return f, !math.IsNaN(f)
+	}
+	return nil, false
+}
+
+// isString returns true if the column holds UTF-8 strings, whose statistics
+// are compared byte-wise.
+func (c *column) isString() bool {
+	e := c.element
+	if e.Type != typeByteArray {
+		return false
+	}
+	return e.IsString || (e.HasConverted && (e.ConvertedType == convertedUTF8 ||
+		e.ConvertedType == convertedEnum || e.ConvertedType == convertedJSON))
+}
@@ -0,0 +1,215 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/golang/snappy"
+	"github.com/pydio/minio-srv/pkg/s3select/format"
+)
+
+// int96 is the deprecated 12 bytes timestamp representation, 8 bytes of
+// nanoseconds within the day followed by 4 bytes of julian day.
+type int96 [12]byte
+
+// decompress returns the uncompressed content of a page.
+func decompress(codec int32, data []byte, size int32) ([]byte, error) {
+	switch codec {
+	case codecUncompressed:
+		return data, nil
+	case codecSnappy:
+		if size < 0 {
+			return nil, format.ErrParquetParsingError
+		}
+		out, err := snappy.Decode(make([]byte, size), data)
+		if err != nil {
+			return nil, format.ErrParquetParsingError
+		}
+		return out, nil
+	case codecGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, format.ErrParquetParsingError
+		}
+		defer r.Close()
+		out, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, format.ErrParquetParsingError
+		}
+		return out, nil
+	}
+	return nil, format.ErrParquetUnsupportedCompressionCodec
+}
+
+// decodeRLE decodes n values of the RLE/bit-packing hybrid encoding used for
+// levels, dictionary indices and booleans.
+func decodeRLE(data []byte, bitWidth int, n int) ([]int32, error) {
+	if n < 0 {
+		return nil, format.ErrParquetParsingError
+	}
+	if bitWidth == 0 {
+		// All values are zero, nothing is stored.
+		return make([]int32, n), nil
+	}
+	// n comes from the page headers, runs may decode to more values than
+	// bytes but the values are only preallocated for the stored bits.
+	capacity := n
+	if capacity > len(data)*8 {
+		capacity = len(data) * 8
+	}
+	values := make([]int32, 0, capacity)
+	if bitWidth > 32 {
+		return nil, format.ErrParquetParsingError
+	}
+	r := bytes.NewReader(data)
+	byteWidth := (bitWidth + 7) / 8
+	for len(values) < n {
+		header, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, format.ErrParquetParsingError
+		}
+		if header&1 == 0 {
+			// RLE run, a single value repeated.
+			count := int(header >> 1)
+			var buf [4]byte
+			if _, err = io.ReadFull(r, buf[:byteWidth]); err != nil {
+				return nil, format.ErrParquetParsingError
+			}
+			v := int32(binary.LittleEndian.Uint32(buf[:]))
+			for i := 0; i < count && len(values) < n; i++ {
+				values = append(values, v)
+			}
+			continue
+		}
+		// Bit-packed run of groups of 8 values, least significant bit first.
+		groups := int(header >> 1)
+		packed := make([]byte, groups*bitWidth)
+		if _, err = io.ReadFull(r, packed); err != nil {
+			return nil, format.ErrParquetParsingError
+		}
+		var acc uint64
+		var bitsInAcc uint
+		mask := uint64(1)<<uint(bitWidth) - 1
+		for _, b := range packed {
+			acc |= uint64(b) << bitsInAcc
+			bitsInAcc += 8
+			for bitsInAcc >= uint(bitWidth) {
+				if len(values) < n {
+					values = append(values, int32(acc&mask))
+				}
+				acc >>= uint(bitWidth)
+				bitsInAcc -= uint(bitWidth)
+			}
+		}
+	}
+	return values, nil
+}
+
+// bitWidth returns the number of bits needed to store values up to max.
+func bitWidth(max int) int {
+	w := 0
+	for max > 0 {
+		w++
+		max >>= 1
+	}
+	return w
+}
+
+// decodePlain decodes n values of the given physical type stored with the
+// PLAIN encoding.
+func decodePlain(physical int32, typeLength int32, data []byte, n int) ([]interface{}, error) {
+	// Every value takes at least a bit, booleans are bit-packed.
+	if n < 0 || n > len(data)*8 {
+		return nil, format.ErrParquetParsingError
+	}
+	values := make([]interface{}, n)
+	switch physical {
+	case typeBoolean:
+		if len(data)*8 < n {
+			return nil, format.ErrParquetParsingError
+		}
+		for i := 0; i < n; i++ {
+			values[i] = data[i/8]&(1<<uint(i%8)) != 0
+		}
+	case typeInt32:
+		if len(data) < 4*n {
+			return nil, format.ErrParquetParsingError
+		}
+		for i := 0; i < n; i++ {
+			values[i] = int32(binary.LittleEndian.Uint32(data[4*i:]))
+		}
+	case typeInt64:
+		if len(data) < 8*n {
+			return nil, format.ErrParquetParsingError
+		}
+		for i := 0; i < n; i++ {
+			values[i] = int64(binary.LittleEndian.Uint64(data[8*i:]))
+		}
+	case typeInt96:
+		if len(data) < 12*n {
+			return nil, format.ErrParquetParsingError
+		}
+		for i := 0; i < n; i++ {
+			var v int96
+			copy(v[:], data[12*i:])
+			values[i] = v
+		}
+	case typeFloat:
+		if len(data) < 4*n {
+			return nil, format.ErrParquetParsingError
+		}
+		for i := 0; i < n; i++ {
+			values[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+		}
+	case typeDouble:
+		if len(data) < 8*n {
+			return nil, format.ErrParquetParsingError
+		}
+		for i := 0; i < n; i++ {
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+		}
+	case typeByteArray:
+		for i := 0; i < n; i++ {
+			if len(data) < 4 {
+				return nil, format.ErrParquetParsingError
+			}
+			size := int(binary.LittleEndian.Uint32(data))
+			if size < 0 || len(data) < 4+size {
+				return nil, format.ErrParquetParsingError
+			}
+			values[i] = data[4 : 4+size]
+			data = data[4+size:]
+		}
+	case typeFixedLenByteArray:
+		size := int(typeLength)
+		if size < 0 || len(data) < size*n {
+			return nil, format.ErrParquetParsingError
+		}
+		for i := 0; i < n; i++ {
+			values[i] = data[size*i : size*(i+1)]
+		}
+	default:
+		return nil, format.ErrParquetUnsupportedType
+	}
+	return values, nil
+}
@@ -0,0 +1,257 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parquet
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/xwb1989/sqlparser"
+)
+
+// columnRange holds the min/max statistics of a column chunk.
+type columnRange struct {
+	min, max interface{}
+	// allNull is true if the chunk holds no value at all.
+	allNull bool
+}
+
+// canSkip returns true if no record of the row group can match the WHERE
+// clause according to the column statistics. It is conservative, any
+// expression which is not understood is considered as possibly matching.
+func (reader *pinput) canSkip(rg *rowGroup) bool {
+	if reader.whereClause == nil {
+		return false
+	}
+	return reader.neverMatches(reader.whereClause, rg)
+}
+
+func (reader *pinput) neverMatches(expr sqlparser.Expr, rg *rowGroup) bool {
+	switch e := expr.(type) {
+	case *sqlparser.ParenExpr:
+		return reader.neverMatches(e.Expr, rg)
+	case *sqlparser.AndExpr:
+		return reader.neverMatches(e.Left, rg) || reader.neverMatches(e.Right, rg)
+	case *sqlparser.OrExpr:
+		return reader.neverMatches(e.Left, rg) && reader.neverMatches(e.Right, rg)
+	case *sqlparser.ComparisonExpr:
+		col, val, op, ok := comparisonOperands(e)
+		if !ok {
+			return false
+		}
+		r, ok := reader.columnRange(col, rg)
+		if !ok {
+			return false
+		}
+		if r.allNull {
+			// Comparisons with NULL are never true.
+			return true
+		}
+		return rangeExcludes(r, op, val)
+	case *sqlparser.RangeCond:
+		if e.Operator != sqlparser.BetweenStr {
+			return false
+		}
+		col, ok := e.Left.(*sqlparser.ColName)
+		if !ok {
+			return false
+		}
+		from, okFrom := literalValue(e.From)
+		to, okTo := literalValue(e.To)
+		if !okFrom || !okTo {
+			return false
+		}
+		r, ok := reader.columnRange(col, rg)
+		if !ok {
+			return false
+		}
+		return r.allNull || rangeExcludes(r, sqlparser.GreaterEqualStr, from) || rangeExcludes(r, sqlparser.LessEqualStr, to)
+	}
+	return false
+}
+
+// comparisonOperands returns the column and the literal of a comparison, the
+// operator is reversed when the literal is on the left side.
+func comparisonOperands(e *sqlparser.ComparisonExpr) (*sqlparser.ColName, interface{}, string, bool) {
+	if col, ok := e.Left.(*sqlparser.ColName); ok {
+		val, ok := literalValue(e.Right)
+		return col, val, e.Operator, ok
+	}
+	if col, ok := e.Right.(*sqlparser.ColName); ok {
+		val, ok := literalValue(e.Left)
+		op := e.Operator
+		switch op {
+		case sqlparser.LessThanStr:
+			op = sqlparser.GreaterThanStr
+		case sqlparser.LessEqualStr:
+			op = sqlparser.GreaterEqualStr
+		case sqlparser.GreaterThanStr:
+			op = sqlparser.LessThanStr
+		case sqlparser.GreaterEqualStr:
+			op = sqlparser.LessEqualStr
+		}
+		return col, val, op, ok
+	}
+	return nil, nil, "", false
+}
+
+// literalValue returns the value of a SQL literal as an int64, a float64 or a
+// string.
+func literalValue(expr sqlparser.Expr) (interface{}, bool) {
+	val, ok := expr.(*sqlparser.SQLVal)
+	if !ok {
+		return nil, false
+	}
+	switch val.Type {
+	case sqlparser.IntVal:
+		i, err := strconv.ParseInt(string(val.Val), 10, 64)
+		return i, err == nil
+	case sqlparser.FloatVal:
+		f, err := strconv.ParseFloat(string(val.Val), 64)
+		return f, err == nil
+	case sqlparser.StrVal:
+		return string(val.Val), true
+	}
+	return nil, false
+}
+
+// columnRange returns the statistics of the column referenced by col in the
+// row group, ok is false when they are missing or cannot be compared.
+func (reader *pinput) columnRange(col *sqlparser.ColName, rg *rowGroup) (r columnRange, ok bool) {
+	c := reader.lookupColumn(col)
+	if c == nil || c.index >= len(rg.Columns) {
+		return r, false
+	}
+	md := rg.Columns[c.index].MetaData
+	if md == nil || md.Statistics == nil {
+		return r, false
+	}
+	s := md.Statistics
+	if s.HasNullCount && s.NullCount == rg.NumRows {
+		r.allNull = true
+		return r, true
+	}
+	switch {
+	case c.isString():
+		// Only the newer min_value/max_value are sorted byte-wise, the
+		// deprecated min/max used signed comparison for byte arrays.
+		if s.MinValue == nil || s.MaxValue == nil {
+			return r, false
+		}
+		r.min, r.max = string(s.MinValue), string(s.MaxValue)
+		return r, true
+	default:
+		minStat, maxStat := s.MinValue, s.MaxValue
+		if minStat == nil || maxStat == nil {
+			minStat, maxStat = s.Min, s.Max
+		}
+		var okMin, okMax bool
+		r.min, okMin = c.numericValue(minStat)
+		r.max, okMax = c.numericValue(maxStat)
+		return r, okMin && okMax
+	}
+}
+
+// lookupColumn returns the leaf column referenced in an expression, either
+// by its name or by its full path.
+func (reader *pinput) lookupColumn(col *sqlparser.ColName) *column {
+	name := col.Name.String()
+	for _, c := range reader.columns {
+		if len(c.path) == 1 && c.path[0] == name {
+			return c
+		}
+	}
+	// A nested column such as s.a.b is parsed as the column b of the table
+	// a qualified by s.
+	if q := col.Qualifier.Name.String(); q != "" {
+		for _, c := range reader.columns {
+			if len(c.path) == 2 && c.path[0] == q && c.path[1] == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// rangeExcludes returns true if no value within r can satisfy "value op val".
+func rangeExcludes(r columnRange, op string, val interface{}) bool {
+	cmpMin, ok := compareValues(r.min, val)
+	if !ok {
+		return false
+	}
+	cmpMax, ok := compareValues(r.max, val)
+	if !ok {
+		return false
+	}
+	switch op {
+	case sqlparser.EqualStr:
+		return cmpMin > 0 || cmpMax < 0
+	case sqlparser.NotEqualStr:
+		return cmpMin == 0 && cmpMax == 0
+	case sqlparser.LessThanStr:
+		return cmpMin >= 0
+	case sqlparser.LessEqualStr:
+		return cmpMin > 0
+	case sqlparser.GreaterThanStr:
+		return cmpMax <= 0
+	case sqlparser.GreaterEqualStr:
+		return cmpMax < 0
+	}
+	return false
+}
+
+// compareValues compares a statistic with a literal, ok is false if they are
+// not comparable.
+func compareValues(stat, val interface{}) (int, bool) {
+	switch s := stat.(type) {
+	case int64:
+		switch v := val.(type) {
+		case int64:
+			switch {
+			case s < v:
+				return -1, true
+			case s > v:
+				return 1, true
+			}
+			return 0, true
+		case float64:
+			return compareFloats(float64(s), v), true
+		}
+	case float64:
+		switch v := val.(type) {
+		case int64:
+			return compareFloats(s, float64(v)), true
+		case float64:
+			return compareFloats(s, v), true
+		}
+	case string:
+		if v, ok := val.(string); ok {
+			return bytes.Compare([]byte(s), []byte(v)), true
+		}
+	}
+	return 0, false
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
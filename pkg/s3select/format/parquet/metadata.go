@@ -0,0 +1,468 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parquet
+
+// Only the parts of the parquet format metadata needed to read flat and
+// nested (non repeated) columns are decoded, the complete definition is at
+// https://github.com/apache/parquet-format/blob/master/src/main/thrift/parquet.thrift
+
+// Physical types.
+const (
+	typeBoolean           = 0
+	typeInt32             = 1
+	typeInt64             = 2
+	typeInt96             = 3
+	typeFloat             = 4
+	typeDouble            = 5
+	typeByteArray         = 6
+	typeFixedLenByteArray = 7
+)
+
+// Converted types, the legacy logical type annotations.
+const (
+	convertedUTF8            = 0
+	convertedEnum            = 4
+	convertedDecimal         = 5
+	convertedDate            = 6
+	convertedTimestampMillis = 9
+	convertedTimestampMicros = 10
+	convertedUint32          = 13
+	convertedUint64          = 14
+	convertedInt8            = 15
+	convertedInt16           = 16
+	convertedInt32           = 17
+	convertedInt64           = 18
+	convertedJSON            = 19
+)
+
+// Field repetition types.
+const (
+	repetitionRequired = 0
+	repetitionOptional = 1
+	repetitionRepeated = 2
+)
+
+// Page encodings.
+const (
+	encodingPlain           = 0
+	encodingPlainDictionary = 2
+	encodingRLE             = 3
+	encodingBitPacked       = 4
+	encodingRLEDictionary   = 8
+)
+
+// Compression codecs.
+const (
+	codecUncompressed = 0
+	codecSnappy       = 1
+	codecGzip         = 2
+)
+
+// Page types.
+const (
+	pageData       = 0
+	pageDictionary = 2
+	pageDataV2     = 3
+)
+
+// Time units of the TIMESTAMP logical type.
+const (
+	unitMillis = 1
+	unitMicros = 2
+	unitNanos  = 3
+)
+
+// schemaElement is one node of the flattened schema tree.
+type schemaElement struct {
+	Type           int32
+	HasType        bool
+	TypeLength     int32
+	RepetitionType int32
+	Name           string
+	NumChildren    int32
+	ConvertedType  int32
+	HasConverted   bool
+	Scale          int32
+
+	// From the logicalType union.
+	IsString      bool
+	IsTimestamp   bool
+	TimestampUnit int32
+}
+
+type statistics struct {
+	Max, Min           []byte
+	MaxValue, MinValue []byte
+	NullCount          int64
+	HasNullCount       bool
+}
+
+type columnMetaData struct {
+	Type                  int32
+	Path                  []string
+	Codec                 int32
+	NumValues             int64
+	TotalCompressedSize   int64
+	DataPageOffset        int64
+	DictionaryPageOffset  int64
+	HasDictionaryPage     bool
+	Statistics            *statistics
+	TotalUncompressedSize int64
+}
+
+type columnChunk struct {
+	FilePath string
+	MetaData *columnMetaData
+}
+
+type rowGroup struct {
+	Columns  []columnChunk
+	NumRows  int64
+	ByteSize int64
+}
+
+type fileMetaData struct {
+	Version   int32
+	Schema    []schemaElement
+	NumRows   int64
+	RowGroups []rowGroup
+	CreatedBy string
+}
+
+type dataPageHeader struct {
+	NumValues               int32
+	Encoding                int32
+	DefinitionLevelEncoding int32
+	RepetitionLevelEncoding int32
+}
+
+type dictionaryPageHeader struct {
+	NumValues int32
+	Encoding  int32
+}
+
+type dataPageHeaderV2 struct {
+	NumValues                  int32
+	NumNulls                   int32
+	NumRows                    int32
+	Encoding                   int32
+	DefinitionLevelsByteLength int32
+	RepetitionLevelsByteLength int32
+	IsCompressed               bool
+}
+
+type pageHeader struct {
+	Type                 int32
+	UncompressedPageSize int32
+	CompressedPageSize   int32
+	DataPage             *dataPageHeader
+	DictionaryPage       *dictionaryPageHeader
+	DataPageV2           *dataPageHeaderV2
+}
+
+// readI32Field and friends decode a field of the expected type, skipping it
+// if an unexpected type is found so that readers stay forward compatible.
+func (t *thriftReader) readI32Field(fieldType byte, v *int32) (err error) {
+	if fieldType != compactI32 {
+		return t.skip(fieldType)
+	}
+	*v, err = t.readI32()
+	return err
+}
+
+func (t *thriftReader) readI64Field(fieldType byte, v *int64) (err error) {
+	if fieldType != compactI64 {
+		return t.skip(fieldType)
+	}
+	*v, err = t.readI64()
+	return err
+}
+
+func (t *thriftReader) readBinaryField(fieldType byte, v *[]byte) (err error) {
+	if fieldType != compactBinary {
+		return t.skip(fieldType)
+	}
+	*v, err = t.readBinary()
+	return err
+}
+
+func (t *thriftReader) readStringField(fieldType byte, v *string) (err error) {
+	if fieldType != compactBinary {
+		return t.skip(fieldType)
+	}
+	*v, err = t.readString()
+	return err
+}
+
+func (t *thriftReader) readFileMetaData() (*fileMetaData, error) {
+	m := &fileMetaData{}
+	err := t.readStruct(func(fieldType byte, id int16) error {
+		switch {
+		case id == 1:
+			return t.readI32Field(fieldType, &m.Version)
+		case id == 2 && fieldType == compactList:
+			return t.readList(func(elemType byte) error {
+				if elemType != compactStruct {
+					return errThriftInvalid
+				}
+				s, err := t.readSchemaElement()
+				if err == nil {
+					m.Schema = append(m.Schema, s)
+				}
+				return err
+			})
+		case id == 3:
+			return t.readI64Field(fieldType, &m.NumRows)
+		case id == 4 && fieldType == compactList:
+			return t.readList(func(elemType byte) error {
+				if elemType != compactStruct {
+					return errThriftInvalid
+				}
+				rg, err := t.readRowGroup()
+				if err == nil {
+					m.RowGroups = append(m.RowGroups, rg)
+				}
+				return err
+			})
+		case id == 6:
+			return t.readStringField(fieldType, &m.CreatedBy)
+		}
+		return t.skip(fieldType)
+	})
+	return m, err
+}
+
+func (t *thriftReader) readSchemaElement() (s schemaElement, err error) {
+	err = t.readStruct(func(fieldType byte, id int16) error {
+		switch id {
+		case 1:
+			s.HasType = fieldType == compactI32
+			return t.readI32Field(fieldType, &s.Type)
+		case 2:
+			return t.readI32Field(fieldType, &s.TypeLength)
+		case 3:
+			return t.readI32Field(fieldType, &s.RepetitionType)
+		case 4:
+			return t.readStringField(fieldType, &s.Name)
+		case 5:
+			return t.readI32Field(fieldType, &s.NumChildren)
+		case 6:
+			s.HasConverted = fieldType == compactI32
+			return t.readI32Field(fieldType, &s.ConvertedType)
+		case 7:
+			return t.readI32Field(fieldType, &s.Scale)
+		case 10:
+			if fieldType != compactStruct {
+				return t.skip(fieldType)
+			}
+			return t.readLogicalType(&s)
+		}
+		return t.skip(fieldType)
+	})
+	return s, err
+}
+
+// readLogicalType decodes the LogicalType union, only the string and the
+// timestamp annotations change how values are presented.
+func (t *thriftReader) readLogicalType(s *schemaElement) error {
+	return t.readStruct(func(fieldType byte, id int16) error {
+		switch {
+		case id == 1:
+			s.IsString = true
+		case id == 8 && fieldType == compactStruct:
+			s.IsTimestamp = true
+			return t.readStruct(func(fieldType byte, id int16) error {
+				if id != 2 || fieldType != compactStruct {
+					return t.skip(fieldType)
+				}
+				// TimeUnit union, the field id is the unit.
+				return t.readStruct(func(fieldType byte, id int16) error {
+					s.TimestampUnit = int32(id)
+					return t.skip(fieldType)
+				})
+			})
+		}
+		return t.skip(fieldType)
+	})
+}
+
+func (t *thriftReader) readRowGroup() (rg rowGroup, err error) {
+	err = t.readStruct(func(fieldType byte, id int16) error {
+		switch {
+		case id == 1 && fieldType == compactList:
+			return t.readList(func(elemType byte) error {
+				if elemType != compactStruct {
+					return errThriftInvalid
+				}
+				c, err := t.readColumnChunk()
+				if err == nil {
+					rg.Columns = append(rg.Columns, c)
+				}
+				return err
+			})
+		case id == 2:
+			return t.readI64Field(fieldType, &rg.ByteSize)
+		case id == 3:
+			return t.readI64Field(fieldType, &rg.NumRows)
+		}
+		return t.skip(fieldType)
+	})
+	return rg, err
+}
+
+func (t *thriftReader) readColumnChunk() (c columnChunk, err error) {
+	err = t.readStruct(func(fieldType byte, id int16) error {
+		switch {
+		case id == 1:
+			return t.readStringField(fieldType, &c.FilePath)
+		case id == 3 && fieldType == compactStruct:
+			m, err := t.readColumnMetaData()
+			c.MetaData = m
+			return err
+		}
+		return t.skip(fieldType)
+	})
+	return c, err
+}
+
+func (t *thriftReader) readColumnMetaData() (*columnMetaData, error) {
+	m := &columnMetaData{}
+	err := t.readStruct(func(fieldType byte, id int16) error {
+		switch {
+		case id == 1:
+			return t.readI32Field(fieldType, &m.Type)
+		case id == 3 && fieldType == compactList:
+			return t.readList(func(elemType byte) error {
+				if elemType != compactBinary {
+					return errThriftInvalid
+				}
+				p, err := t.readString()
+				if err == nil {
+					m.Path = append(m.Path, p)
+				}
+				return err
+			})
+		case id == 4:
+			return t.readI32Field(fieldType, &m.Codec)
+		case id == 5:
+			return t.readI64Field(fieldType, &m.NumValues)
+		case id == 6:
+			return t.readI64Field(fieldType, &m.TotalUncompressedSize)
+		case id == 7:
+			return t.readI64Field(fieldType, &m.TotalCompressedSize)
+		case id == 9:
+			return t.readI64Field(fieldType, &m.DataPageOffset)
+		case id == 11:
+			m.HasDictionaryPage = fieldType == compactI64
+			return t.readI64Field(fieldType, &m.DictionaryPageOffset)
+		case id == 12 && fieldType == compactStruct:
+			s, err := t.readStatistics()
+			m.Statistics = s
+			return err
+		}
+		return t.skip(fieldType)
+	})
+	return m, err
+}
+
+func (t *thriftReader) readStatistics() (*statistics, error) {
+	s := &statistics{}
+	err := t.readStruct(func(fieldType byte, id int16) error {
+		switch id {
+		case 1:
+			return t.readBinaryField(fieldType, &s.Max)
+		case 2:
+			return t.readBinaryField(fieldType, &s.Min)
+		case 3:
+			s.HasNullCount = fieldType == compactI64
+			return t.readI64Field(fieldType, &s.NullCount)
+		case 5:
+			return t.readBinaryField(fieldType, &s.MaxValue)
+		case 6:
+			return t.readBinaryField(fieldType, &s.MinValue)
+		}
+		return t.skip(fieldType)
+	})
+	return s, err
+}
+
+func (t *thriftReader) readPageHeader() (*pageHeader, error) {
+	h := &pageHeader{}
+	err := t.readStruct(func(fieldType byte, id int16) error {
+		switch {
+		case id == 1:
+			return t.readI32Field(fieldType, &h.Type)
+		case id == 2:
+			return t.readI32Field(fieldType, &h.UncompressedPageSize)
+		case id == 3:
+			return t.readI32Field(fieldType, &h.CompressedPageSize)
+		case id == 5 && fieldType == compactStruct:
+			h.DataPage = &dataPageHeader{}
+			return t.readStruct(func(fieldType byte, id int16) error {
+				switch id {
+				case 1:
+					return t.readI32Field(fieldType, &h.DataPage.NumValues)
+				case 2:
+					return t.readI32Field(fieldType, &h.DataPage.Encoding)
+				case 3:
+					return t.readI32Field(fieldType, &h.DataPage.DefinitionLevelEncoding)
+				case 4:
+					return t.readI32Field(fieldType, &h.DataPage.RepetitionLevelEncoding)
+				}
+				return t.skip(fieldType)
+			})
+		case id == 7 && fieldType == compactStruct:
+			h.DictionaryPage = &dictionaryPageHeader{}
+			return t.readStruct(func(fieldType byte, id int16) error {
+				switch id {
+				case 1:
+					return t.readI32Field(fieldType, &h.DictionaryPage.NumValues)
+				case 2:
+					return t.readI32Field(fieldType, &h.DictionaryPage.Encoding)
+				}
+				return t.skip(fieldType)
+			})
+		case id == 8 && fieldType == compactStruct:
+			h.DataPageV2 = &dataPageHeaderV2{IsCompressed: true}
+			return t.readStruct(func(fieldType byte, id int16) error {
+				v2 := h.DataPageV2
+				switch id {
+				case 1:
+					return t.readI32Field(fieldType, &v2.NumValues)
+				case 2:
+					return t.readI32Field(fieldType, &v2.NumNulls)
+				case 3:
+					return t.readI32Field(fieldType, &v2.NumRows)
+				case 4:
+					return t.readI32Field(fieldType, &v2.Encoding)
+				case 5:
+					return t.readI32Field(fieldType, &v2.DefinitionLevelsByteLength)
+				case 6:
+					return t.readI32Field(fieldType, &v2.RepetitionLevelsByteLength)
+				case 7:
+					if fieldType == compactBooleanTrue || fieldType == compactBooleanFalse {
+						v2.IsCompressed = t.readBool()
+						return nil
+					}
+				}
+				return t.skip(fieldType)
+			})
+		}
+		return t.skip(fieldType)
+	})
+	return h, err
+}
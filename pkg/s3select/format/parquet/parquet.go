@@ -0,0 +1,378 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/pydio/minio-srv/pkg/s3select/format"
+	"github.com/xwb1989/sqlparser"
+)
+
+// magic is found at the beginning and at the end of every parquet file.
+const magic = "PAR1"
+
+// footerLength is the size of the metadata length followed by the magic.
+const footerLength = 8
+
+// Options options are passed to the underlying parquet reader.
+type Options struct {
+
+	// Name of the table that is used for querying
+	Name string
+
+	// ReadFrom is where the data will be read from, parquet files are read
+	// starting from their footer so random access is required.
+	ReadFrom io.ReaderAt
+
+	// SQL expression meant to be evaluated.
+	Expression string
+
+	// What the outputted CSV will be delimited by .
+	OutputFieldDelimiter string
+
+	// What the outputted records will be delimited by.
+	OutputRecordDelimiter string
+
+	// OutputType is the serialization of the outputted records.
+	OutputType format.Type
+
+	// Size of incoming object
+	StreamSize int64
+
+	// Progress enabled, enable/disable progress messages.
+	Progress bool
+}
+
+// pinput represents a record producing input from a parquet file, values are
+// decoded one row group at a time.
+type pinput struct {
+	options *Options
+	meta    *fileMetaData
+	columns []*column
+	header  []string
+
+	// projected are the columns read from every row group.
+	projected []*column
+	// whereClause is used to skip row groups which cannot match.
+	whereClause sqlparser.Expr
+
+	// rowGroup is the index of the next row group to read.
+	rowGroup int
+	// values holds the decoded values of every projected column of the
+	// current row group.
+	values  [][]interface{}
+	row     int64
+	numRows int64
+
+	stats struct {
+		BytesScanned   int64
+		BytesReturned  int64
+		BytesProcessed int64
+	}
+}
+
+// New sets up a new parquet reader, the file metadata is read from the footer
+// when this is run. If the file is not a valid parquet file the error is
+// returned, otherwise records can be consumed with Read() until it returns
+// nil.
+func New(opts *Options) (format.Select, error) {
+	reader := &pinput{
+		options: opts,
+	}
+	if err := reader.readMetaData(); err != nil {
+		return nil, err
+	}
+	columns, err := schemaColumns(reader.meta.Schema)
+	if err != nil {
+		return nil, err
+	}
+	reader.columns = columns
+	reader.projected = columns
+	seen := make(map[string]bool)
+	for _, c := range columns {
+		if !seen[c.path[0]] {
+			seen[c.path[0]] = true
+			reader.header = append(reader.header, c.path[0])
+		}
+	}
+	return reader, nil
+}
+
+// readMetaData reads and decodes the file metadata found in the footer.
+func (reader *pinput) readMetaData() error {
+	size := reader.options.StreamSize
+	if size < int64(len(magic))+footerLength {
+		return format.ErrParquetParsingError
+	}
+	footer := make([]byte, footerLength)
+	if err := reader.readAt(footer, size-footerLength); err != nil {
+		return err
+	}
+	if string(footer[4:]) != magic {
+		return format.ErrParquetParsingError
+	}
+	metaLength := int64(binary.LittleEndian.Uint32(footer))
+	if metaLength > size-footerLength-int64(len(magic)) {
+		return format.ErrParquetParsingError
+	}
+	buf := make([]byte, metaLength)
+	if err := reader.readAt(buf, size-footerLength-metaLength); err != nil {
+		return err
+	}
+	meta, err := newThriftReader(bytes.NewReader(buf)).readFileMetaData()
+	if err != nil {
+		return format.ErrParquetParsingError
+	}
+	reader.meta = meta
+	reader.stats.BytesScanned += footerLength + metaLength
+	return nil
+}
+
+// readAt fills b from the given offset of the file.
+func (reader *pinput) readAt(b []byte, off int64) error {
+	n, err := reader.options.ReadFrom.ReadAt(b, off)
+	if n == len(b) {
+		return nil
+	}
+	if err == nil || err == io.EOF || err == io.ErrUnexpectedEOF {
+		return format.ErrParquetParsingError
+	}
+	return err
+}
+
+// Project only reads the columns below the given top level fields.
+func (reader *pinput) Project(names []string) {
+	if names == nil {
+		reader.projected = reader.columns
+		return
+	}
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	reader.projected = nil
+	for _, c := range reader.columns {
+		if wanted[c.path[0]] {
+			reader.projected = append(reader.projected, c)
+		}
+	}
+}
+
+// Filter keeps the WHERE clause so that row groups whose statistics show
+// that no row can match are not read.
+func (reader *pinput) Filter(whereClause interface{}) {
+	reader.whereClause, _ = whereClause.(sqlparser.Expr)
+}
+
+// Progress - return true if progress was requested.
+func (reader *pinput) Progress() bool {
+	return reader.options.Progress
+}
+
+// UpdateBytesProcessed - bytes processed are accounted for when column
+// chunks are decoded.
+func (reader *pinput) UpdateBytesProcessed(record map[string]interface{}) {
+}
+
+// Read returns the next record of the file as map[string]interface{}, nested
+// groups are returned as nested maps.
+func (reader *pinput) Read() (map[string]interface{}, error) {
+	for reader.values == nil || reader.row >= reader.numRows {
+		if reader.rowGroup >= len(reader.meta.RowGroups) {
+			return nil, nil
+		}
+		rg := &reader.meta.RowGroups[reader.rowGroup]
+		reader.rowGroup++
+		if reader.canSkip(rg) {
+			continue
+		}
+		if err := reader.readRowGroup(rg); err != nil {
+			return nil, err
+		}
+	}
+	record := make(map[string]interface{}, len(reader.header))
+	for i, c := range reader.projected {
+		parent := record
+		for _, name := range c.path[:len(c.path)-1] {
+			child, ok := parent[name].(map[string]interface{})
+			if !ok {
+				child = make(map[string]interface{})
+				parent[name] = child
+			}
+			parent = child
+		}
+		parent[c.path[len(c.path)-1]] = reader.values[i][reader.row]
+	}
+	reader.row++
+	return record, nil
+}
+
+// readRowGroup decodes the projected columns of a row group.
+func (reader *pinput) readRowGroup(rg *rowGroup) error {
+	if rg.NumRows < 0 {
+		return format.ErrParquetParsingError
+	}
+	values := make([][]interface{}, len(reader.projected))
+	for i, c := range reader.projected {
+		if c.index >= len(rg.Columns) {
+			return format.ErrParquetParsingError
+		}
+		chunk := &rg.Columns[c.index]
+		if chunk.FilePath != "" {
+			// Column chunks stored in another file.
+			return format.ErrParquetUnsupportedType
+		}
+		md := chunk.MetaData
+		if md == nil {
+			return format.ErrParquetParsingError
+		}
+		offset := md.DataPageOffset
+		if md.HasDictionaryPage && md.DictionaryPageOffset > 0 && md.DictionaryPageOffset < offset {
+			offset = md.DictionaryPageOffset
+		}
+		if offset < 0 || md.TotalCompressedSize < 0 || offset+md.TotalCompressedSize > reader.options.StreamSize {
+			return format.ErrParquetParsingError
+		}
+		data := make([]byte, md.TotalCompressedSize)
+		if err := reader.readAt(data, offset); err != nil {
+			return err
+		}
+		v, err := c.readChunk(md, data, rg.NumRows)
+		if err != nil {
+			return err
+		}
+		values[i] = v
+		reader.stats.BytesScanned += md.TotalCompressedSize
+		reader.stats.BytesProcessed += md.TotalUncompressedSize
+	}
+	reader.values = values
+	reader.row = 0
+	reader.numRows = rg.NumRows
+	return nil
+}
+
+// OutputFieldDelimiter - returns the delimiter specified in input request
+func (reader *pinput) OutputFieldDelimiter() string {
+	return reader.options.OutputFieldDelimiter
+}
+
+// OutputRecordDelimiter - returns the record delimiter specified in input request
+func (reader *pinput) OutputRecordDelimiter() string {
+	return reader.options.OutputRecordDelimiter
+}
+
+// OutputType - returns the output serialization specified in input request
+func (reader *pinput) OutputType() format.Type {
+	return reader.options.OutputType
+}
+
+// HasHeader - parquet files always describe their columns.
+func (reader *pinput) HasHeader() bool {
+	return true
+}
+
+// Expression - return the Select Expression for
+func (reader *pinput) Expression() string {
+	return reader.options.Expression
+}
+
+// UpdateBytesReturned - updates the Bytes returned for
+func (reader *pinput) UpdateBytesReturned(size int64) {
+	reader.stats.BytesReturned += size
+}
+
+// Header returns the top level field names of the schema.
+func (reader *pinput) Header() []string {
+	return append([]string{}, reader.header...)
+}
+
+// CreateStatXML is the function which does the marshaling from the stat
+// structs into XML so that the progress and stat message can be sent
+func (reader *pinput) CreateStatXML() (string, error) {
+	out, err := xml.Marshal(&format.Stats{
+		BytesScanned:   reader.stats.BytesScanned,
+		BytesProcessed: reader.stats.BytesProcessed,
+		BytesReturned:  reader.stats.BytesReturned,
+	})
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(out), nil
+}
+
+// CreateProgressXML is the function which does the marshaling from the progress
+// structs into XML so that the progress and stat message can be sent
+func (reader *pinput) CreateProgressXML() (string, error) {
+	out, err := xml.Marshal(&format.Progress{
+		BytesScanned:   reader.stats.BytesScanned,
+		BytesProcessed: reader.stats.BytesProcessed,
+		BytesReturned:  reader.stats.BytesReturned,
+	})
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(out), nil
+}
+
+// Type - return the data format type
+func (reader *pinput) Type() format.Type {
+	return format.Parquet
+}
+
+// ColNameErrs is a function which makes sure that the requested columns are
+// present in the schema otherwise it throws an error.
+func (reader *pinput) ColNameErrs(columnNames []string) error {
+	for _, name := range columnNames {
+		if name == "" {
+			continue
+		}
+		if format.IsInt(name) {
+			return format.ErrInvalidColumnIndex
+		}
+		if !reader.hasField(name) {
+			return format.ErrParseInvalidPathComponent
+		}
+	}
+	return nil
+}
+
+// hasField returns true if the path of a field, such as info.score, is found
+// in the schema.
+func (reader *pinput) hasField(name string) bool {
+	path := strings.Split(name, ".")
+	for _, c := range reader.columns {
+		if len(path) > len(c.path) {
+			continue
+		}
+		found := true
+		for i, p := range path {
+			if c.path[i] != p {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,537 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/pydio/minio-srv/pkg/s3select/format"
+	"github.com/xwb1989/sqlparser"
+)
+
+// thriftWriter encodes the few thrift compact protocol messages needed to
+// build test files.
+type thriftWriter struct {
+	bytes.Buffer
+	lastField []int16
+}
+
+func (w *thriftWriter) uvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	w.Write(b[:binary.PutUvarint(b[:], v)])
+}
+
+func (w *thriftWriter) varint(v int64) {
+	w.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (w *thriftWriter) field(fieldType byte, id int16) {
+	last := &w.lastField[len(w.lastField)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		w.WriteByte(byte(delta)<<4 | fieldType)
+	} else {
+		w.WriteByte(fieldType)
+		w.varint(int64(id))
+	}
+	*last = id
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(compactI32, id)
+	w.varint(int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(compactI64, id)
+	w.varint(v)
+}
+
+func (w *thriftWriter) binary(id int16, b []byte) {
+	w.field(compactBinary, id)
+	w.uvarint(uint64(len(b)))
+	w.Write(b)
+}
+
+func (w *thriftWriter) list(id int16, elemType byte, size int) {
+	w.field(compactList, id)
+	w.WriteByte(byte(size)<<4 | elemType)
+}
+
+// begin starts a struct, either a field when id is positive or a list
+// element otherwise.
+func (w *thriftWriter) begin(id int16) {
+	if id > 0 {
+		w.field(compactStruct, id)
+	}
+	w.lastField = append(w.lastField, 0)
+}
+
+func (w *thriftWriter) end() {
+	w.WriteByte(compactStop)
+	w.lastField = w.lastField[:len(w.lastField)-1]
+}
+
+// testChunk is a column chunk of a test file.
+type testChunk struct {
+	offset, size int64
+	numValues    int64
+	min, max     []byte
+	nulls        int64
+}
+
+// writeColumn writes a PLAIN encoded data page for values, a nil value is
+// undefined when the column has a definition level.
+func writeColumn(file *bytes.Buffer, physical int32, maxDef int, values []interface{}) testChunk {
+	var data bytes.Buffer
+	if maxDef > 0 {
+		// One RLE run of a single level per value.
+		var levels bytes.Buffer
+		for _, v := range values {
+			levels.WriteByte(2)
+			if v == nil {
+				levels.WriteByte(0)
+			} else {
+				levels.WriteByte(byte(maxDef))
+			}
+		}
+		binary.Write(&data, binary.LittleEndian, uint32(levels.Len()))
+		data.Write(levels.Bytes())
+	}
+	chunk := testChunk{numValues: int64(len(values))}
+	for _, v := range values {
+		var b []byte
+		switch value := v.(type) {
+		case nil:
+			chunk.nulls++
+			continue
+		case int64:
+			b = make([]byte, 8)
+			binary.LittleEndian.PutUint64(b, uint64(value))
+			data.Write(b)
+		case float64:
+			b = make([]byte, 8)
+			binary.LittleEndian.PutUint64(b, math.Float64bits(value))
+			data.Write(b)
+		case string:
+			b = []byte(value)
+			binary.Write(&data, binary.LittleEndian, uint32(len(b)))
+			data.Write(b)
+		}
+		if chunk.min == nil || bytes.Compare(b, chunk.min) < 0 {
+			chunk.min = b
+		}
+		if chunk.max == nil || bytes.Compare(b, chunk.max) > 0 {
+			chunk.max = b
+		}
+	}
+	if physical != typeByteArray {
+		// Byte order comparison only holds for small positive values,
+		// which is all the test files use.
+		chunk.min, chunk.max = nil, nil
+		for _, v := range values {
+			if v == nil {
+				continue
+			}
+			b := make([]byte, 8)
+			switch value := v.(type) {
+			case int64:
+				binary.LittleEndian.PutUint64(b, uint64(value))
+				if chunk.min == nil || value < int64(binary.LittleEndian.Uint64(chunk.min)) {
+					chunk.min = b
+				}
+				if chunk.max == nil || value > int64(binary.LittleEndian.Uint64(chunk.max)) {
+					chunk.max = b
+				}
+			case float64:
+				binary.LittleEndian.PutUint64(b, math.Float64bits(value))
+				if chunk.min == nil || value < math.Float64frombits(binary.LittleEndian.Uint64(chunk.min)) {
+					chunk.min = b
+				}
+				if chunk.max == nil || value > math.Float64frombits(binary.LittleEndian.Uint64(chunk.max)) {
+					chunk.max = b
+				}
+			}
+		}
+	}
+
+	var header thriftWriter
+	header.begin(0)
+	header.i32(1, pageData)
+	header.i32(2, int32(data.Len()))
+	header.i32(3, int32(data.Len()))
+	header.begin(5)
+	header.i32(1, int32(len(values)))
+	header.i32(2, encodingPlain)
+	header.i32(3, encodingRLE)
+	header.i32(4, encodingRLE)
+	header.end()
+	header.end()
+
+	chunk.offset = int64(file.Len())
+	file.Write(header.Bytes())
+	file.Write(data.Bytes())
+	chunk.size = int64(file.Len()) - chunk.offset
+	return chunk
+}
+
+// testColumn describes a leaf column of the test schema.
+type testColumn struct {
+	path     []string
+	physical int32
+	optional bool
+}
+
+// buildFile returns a parquet file with one row group for every element of
+// rowGroups, each holding the values of every column.
+func buildFile(columns []testColumn, rowGroups [][][]interface{}) []byte {
+	var file bytes.Buffer
+	file.WriteString(magic)
+	chunks := make([][]testChunk, len(rowGroups))
+	for i, rg := range rowGroups {
+		for j, c := range columns {
+			maxDef := 0
+			if c.optional {
+				maxDef++
+			}
+			if len(c.path) > 1 {
+				// The enclosing group is optional.
+				maxDef++
+			}
+			chunks[i] = append(chunks[i], writeColumn(&file, c.physical, maxDef, rg[j]))
+		}
+	}
+
+	var meta thriftWriter
+	meta.begin(0)
+	meta.i32(1, 1)
+	// The schema of the tests is a root with flat columns and a single
+	// optional group "info".
+	var elements int
+	var flat, nested []testColumn
+	for _, c := range columns {
+		if len(c.path) == 1 {
+			flat = append(flat, c)
+		} else {
+			nested = append(nested, c)
+		}
+	}
+	elements = 1 + len(flat) + len(nested)
+	if len(nested) > 0 {
+		elements++
+	}
+	meta.list(2, compactStruct, elements)
+	meta.begin(0)
+	meta.binary(4, []byte("schema"))
+	children := len(flat)
+	if len(nested) > 0 {
+		children++
+	}
+	meta.i32(5, int32(children))
+	meta.end()
+	leaf := func(c testColumn) {
+		meta.begin(0)
+		meta.i32(1, c.physical)
+		if c.optional {
+			meta.i32(3, repetitionOptional)
+		} else {
+			meta.i32(3, repetitionRequired)
+		}
+		meta.binary(4, []byte(c.path[len(c.path)-1]))
+		if c.physical == typeByteArray {
+			meta.i32(6, convertedUTF8)
+		}
+		meta.end()
+	}
+	for _, c := range flat {
+		leaf(c)
+	}
+	if len(nested) > 0 {
+		meta.begin(0)
+		meta.i32(3, repetitionOptional)
+		meta.binary(4, []byte(nested[0].path[0]))
+		meta.i32(5, int32(len(nested)))
+		meta.end()
+		for _, c := range nested {
+			leaf(c)
+		}
+	}
+	var numRows int64
+	for _, rg := range rowGroups {
+		numRows += int64(len(rg[0]))
+	}
+	meta.i64(3, numRows)
+	meta.list(4, compactStruct, len(rowGroups))
+	ordered := append(flat, nested...)
+	for i, rg := range rowGroups {
+		meta.begin(0)
+		meta.list(1, compactStruct, len(columns))
+		for j, chunk := range chunks[i] {
+			meta.begin(0)
+			meta.i64(2, chunk.offset)
+			meta.begin(3)
+			meta.i32(1, ordered[j].physical)
+			meta.list(2, compactI32, 1)
+			meta.WriteByte(byte(encodingPlain))
+			meta.list(3, compactBinary, len(ordered[j].path))
+			for _, p := range ordered[j].path {
+				meta.uvarint(uint64(len(p)))
+				meta.WriteString(p)
+			}
+			meta.i32(4, codecUncompressed)
+			meta.i64(5, chunk.numValues)
+			meta.i64(6, chunk.size)
+			meta.i64(7, chunk.size)
+			meta.i64(9, chunk.offset)
+			meta.begin(12)
+			meta.i64(3, chunk.nulls)
+			if chunk.max != nil {
+				meta.binary(5, chunk.max)
+				meta.binary(6, chunk.min)
+			}
+			meta.end()
+			meta.end()
+			meta.end()
+		}
+		meta.i64(2, 0)
+		meta.i64(3, int64(len(rg[0])))
+		meta.end()
+	}
+	meta.end()
+
+	file.Write(meta.Bytes())
+	binary.Write(&file, binary.LittleEndian, uint32(meta.Len()))
+	file.WriteString(magic)
+	return file.Bytes()
+}
+
+// testFile returns a file with the columns id, name and info.score split in
+// two row groups of three rows.
+func testFile() []byte {
+	return buildFile([]testColumn{
+		{[]string{"id"}, typeInt64, false},
+		{[]string{"name"}, typeByteArray, true},
+		{[]string{"info", "score"}, typeDouble, true},
+	}, [][][]interface{}{
+		{
+			{int64(1), int64(2), int64(3)},
+			{"alice", nil, "carol"},
+			{1.5, 2.5, nil},
+		},
+		{
+			{int64(4), int64(5), int64(6)},
+			{"dave", "erin", "frank"},
+			{4.5, 5.5, 6.5},
+		},
+	})
+}
+
+func newTestReader(t *testing.T, data []byte) *pinput {
+	s, err := New(&Options{
+		Name:                 "S3Object",
+		ReadFrom:             bytes.NewReader(data),
+		OutputFieldDelimiter: ",",
+		StreamSize:           int64(len(data)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.(*pinput)
+}
+
+func readAll(t *testing.T, reader *pinput) []map[string]interface{} {
+	var records []map[string]interface{}
+	for {
+		record, err := reader.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record == nil {
+			return records
+		}
+		records = append(records, record)
+	}
+}
+
+// TestRead checks that records are assembled from every column chunk.
+func TestRead(t *testing.T) {
+	reader := newTestReader(t, testFile())
+	if !reflect.DeepEqual(reader.Header(), []string{"id", "name", "info"}) {
+		t.Fatalf("unexpected header %v", reader.Header())
+	}
+	records := readAll(t, reader)
+	if len(records) != 6 {
+		t.Fatalf("expected 6 records, got %d", len(records))
+	}
+	expected := []map[string]interface{}{
+		{"id": int64(1), "name": "alice", "info": map[string]interface{}{"score": 1.5}},
+		{"id": int64(2), "name": nil, "info": map[string]interface{}{"score": 2.5}},
+		{"id": int64(3), "name": "carol", "info": map[string]interface{}{"score": nil}},
+	}
+	if !reflect.DeepEqual(records[:3], expected) {
+		t.Errorf("expected %v, got %v", expected, records[:3])
+	}
+}
+
+// TestProject checks that only the requested columns are read.
+func TestProject(t *testing.T) {
+	reader := newTestReader(t, testFile())
+	reader.Project([]string{"name"})
+	records := readAll(t, reader)
+	if len(records) != 6 {
+		t.Fatalf("expected 6 records, got %d", len(records))
+	}
+	if !reflect.DeepEqual(records[5], map[string]interface{}{"name": "frank"}) {
+		t.Errorf("unexpected record %v", records[5])
+	}
+
+	reader = newTestReader(t, testFile())
+	reader.Project([]string{})
+	if records = readAll(t, reader); len(records) != 6 {
+		t.Errorf("expected 6 empty records, got %d", len(records))
+	}
+}
+
+// TestFilter checks that row groups are skipped based on their statistics.
+func TestFilter(t *testing.T) {
+	testCases := []struct {
+		where    string
+		expected int
+	}{
+		{"id > 3", 3},
+		{"id >= 3", 6},
+		{"3 < id", 3},
+		{"id < 1", 0},
+		{"id = 5", 3},
+		{"id between 7 and 9", 0},
+		{"id = 1 or id = 6", 6},
+		{"id = 1 and info.score > 4", 0},
+		{"name = 'bob'", 3},
+		{"name > 'd'", 3},
+		{"name < 'd'", 3},
+		{"unknown = 1", 6},
+	}
+	for i, testCase := range testCases {
+		stmt, err := sqlparser.Parse("select * from S3Object where " + testCase.where)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader := newTestReader(t, testFile())
+		reader.Filter(stmt.(*sqlparser.Select).Where.Expr)
+		if records := readAll(t, reader); len(records) != testCase.expected {
+			t.Errorf("Test %d: expected %d records, got %d", i+1, testCase.expected, len(records))
+		}
+	}
+}
+
+// TestInvalidFile checks that files which are not parquet are rejected.
+func TestInvalidFile(t *testing.T) {
+	testCases := [][]byte{
+		[]byte("id,name\n1,alice\n"),
+		[]byte("PAR1PAR1"),
+		append([]byte("PAR1"), 0xff, 0xff, 0, 0, 'P', 'A', 'R', '1'),
+	}
+	for i, data := range testCases {
+		_, err := New(&Options{
+			ReadFrom:   bytes.NewReader(data),
+			StreamSize: int64(len(data)),
+		})
+		if err != format.ErrParquetParsingError {
+			t.Errorf("Test %d: expected %v, got %v", i+1, format.ErrParquetParsingError, err)
+		}
+	}
+}
+
+// TestColNameErrs checks the validation of the requested columns.
+func TestColNameErrs(t *testing.T) {
+	reader := newTestReader(t, testFile())
+	testCases := []struct {
+		columns []string
+		err     error
+	}{
+		{[]string{"id", "name"}, nil},
+		{[]string{"info.score"}, nil},
+		{[]string{"info.missing"}, format.ErrParseInvalidPathComponent},
+		{[]string{"1"}, format.ErrInvalidColumnIndex},
+		{[]string{"missing"}, format.ErrParseInvalidPathComponent},
+	}
+	for i, testCase := range testCases {
+		if err := reader.ColNameErrs(testCase.columns); err != testCase.err {
+			t.Errorf("Test %d: expected %v, got %v", i+1, testCase.err, err)
+		}
+	}
+}
+
+// TestDecodeRLE checks the RLE/bit-packing hybrid decoder.
+func TestDecodeRLE(t *testing.T) {
+	testCases := []struct {
+		data     []byte
+		bitWidth int
+		n        int
+		expected []int32
+	}{
+		// RLE run of 4 times the value 3.
+		{[]byte{8, 3}, 2, 4, []int32{3, 3, 3, 3}},
+		// Bit-packed group of 8 values 0..7 on 3 bits.
+		{[]byte{3, 0x88, 0xc6, 0xfa}, 3, 8, []int32{0, 1, 2, 3, 4, 5, 6, 7}},
+		// Width 0 means all values are zero.
+		{nil, 0, 3, []int32{0, 0, 0}},
+	}
+	for i, testCase := range testCases {
+		values, err := decodeRLE(testCase.data, testCase.bitWidth, testCase.n)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if !reflect.DeepEqual(values, testCase.expected) {
+			t.Errorf("Test %d: expected %v, got %v", i+1, testCase.expected, values)
+		}
+	}
+}
+
+// TestReadChunkNumRows checks that the number of rows of the metadata is
+// validated and not trusted to allocate the values.
+func TestReadChunkNumRows(t *testing.T) {
+	var file bytes.Buffer
+	writeColumn(&file, typeInt64, 0, []interface{}{int64(1), int64(2)})
+	c := &column{path: []string{"id"}, element: &schemaElement{Type: typeInt64}}
+
+	testCases := []struct {
+		numValues, numRows int64
+		err                error
+	}{
+		{2, 2, nil},
+		{2, 3, format.ErrParquetParsingError},
+		{-1, -1, format.ErrParquetParsingError},
+		// The page holds less values than announced.
+		{1 << 40, 1 << 40, format.ErrParquetParsingError},
+		// The page holds more values than announced.
+		{1, 1, format.ErrParquetParsingError},
+	}
+	for i, testCase := range testCases {
+		md := &columnMetaData{Type: typeInt64, Codec: codecUncompressed, NumValues: testCase.numValues}
+		values, err := c.readChunk(md, file.Bytes(), testCase.numRows)
+		if err != testCase.err {
+			t.Fatalf("Test %d: expected %v, got %v", i+1, testCase.err, err)
+		}
+		if err == nil && !reflect.DeepEqual(values, []interface{}{int64(1), int64(2)}) {
+			t.Fatalf("Test %d: unexpected values %v", i+1, values)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// Thrift compact protocol field types, as used by the parquet file and page
+// headers. Only decoding is implemented, see
+// https://github.com/apache/thrift/blob/master/doc/specs/thrift-compact-protocol.md
+const (
+	compactStop         = 0
+	compactBooleanTrue  = 1
+	compactBooleanFalse = 2
+	compactByte         = 3
+	compactI16          = 4
+	compactI32          = 5
+	compactI64          = 6
+	compactDouble       = 7
+	compactBinary       = 8
+	compactList         = 9
+	compactSet          = 10
+	compactMap          = 11
+	compactStruct       = 12
+)
+
+// maxThriftDepth bounds nesting while skipping unknown fields so that a
+// corrupted header cannot recurse forever.
+const maxThriftDepth = 64
+
+// maxThriftBinary bounds the size of strings and binaries found in headers.
+const maxThriftBinary = 64 << 20
+
+var errThriftInvalid = errors.New("parquet: invalid thrift encoding")
+
+// byteReader is the source a thriftReader decodes from.
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// thriftReader decodes thrift compact protocol messages from a byte reader.
+type thriftReader struct {
+	r byteReader
+
+	// lastField is the id of the previously read field of every open
+	// struct, field ids are delta encoded relative to it.
+	lastField []int16
+	// boolValue holds the value of a boolean field, which the compact
+	// protocol stores in the field header itself.
+	boolValue bool
+}
+
+func newThriftReader(r byteReader) *thriftReader {
+	return &thriftReader{r: r}
+}
+
+func (t *thriftReader) readByte() (byte, error) {
+	return t.r.ReadByte()
+}
+
+func (t *thriftReader) readUvarint() (uint64, error) {
+	v, err := binary.ReadUvarint(t.r)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return v, err
+}
+
+func (t *thriftReader) readVarint() (int64, error) {
+	u, err := t.readUvarint()
+	if err != nil {
+		return 0, err
+	}
+	// Zigzag decoding.
+	return int64(u>>1) ^ -int64(u&1), nil
+}
+
+func (t *thriftReader) readI32() (int32, error) {
+	v, err := t.readVarint()
+	return int32(v), err
+}
+
+func (t *thriftReader) readI64() (int64, error) {
+	return t.readVarint()
+}
+
+func (t *thriftReader) readDouble() (float64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(t.r, b[:]); err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(b[:])), nil
+}
+
+func (t *thriftReader) readBinary() ([]byte, error) {
+	n, err := t.readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if n > maxThriftBinary {
+		return nil, errThriftInvalid
+	}
+	b := make([]byte, n)
+	if _, err = io.ReadFull(t.r, b); err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return b, err
+}
+
+func (t *thriftReader) readString() (string, error) {
+	b, err := t.readBinary()
+	return string(b), err
+}
+
+// readBool reads the value of a boolean field, it must be called right after
+// the field header was read.
+func (t *thriftReader) readBool() bool {
+	return t.boolValue
+}
+
+func (t *thriftReader) structBegin() {
+	t.lastField = append(t.lastField, 0)
+}
+
+func (t *thriftReader) structEnd() {
+	t.lastField = t.lastField[:len(t.lastField)-1]
+}
+
+// readFieldHeader returns the type and id of the next field of the current
+// struct, the type is compactStop once all fields are read.
+func (t *thriftReader) readFieldHeader() (fieldType byte, id int16, err error) {
+	b, err := t.readByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	fieldType = b & 0x0f
+	if fieldType == compactStop {
+		return compactStop, 0, nil
+	}
+	last := &t.lastField[len(t.lastField)-1]
+	if delta := int16(b >> 4); delta != 0 {
+		id = *last + delta
+	} else {
+		v, err := t.readVarint()
+		if err != nil {
+			return 0, 0, err
+		}
+		id = int16(v)
+	}
+	*last = id
+	switch fieldType {
+	case compactBooleanTrue:
+		t.boolValue = true
+	case compactBooleanFalse:
+		t.boolValue = false
+	}
+	return fieldType, id, nil
+}
+
+// readListHeader returns the element type and the size of a list or a set.
+func (t *thriftReader) readListHeader() (elemType byte, size int, err error) {
+	b, err := t.readByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	elemType = b & 0x0f
+	n := uint64(b >> 4)
+	if n == 15 {
+		if n, err = t.readUvarint(); err != nil {
+			return 0, 0, err
+		}
+	}
+	if n > math.MaxInt32 {
+		return 0, 0, errThriftInvalid
+	}
+	return elemType, int(n), nil
+}
+
+// readStruct calls field for every field of the struct at the current
+// position, fields not handled must be skipped by the callback.
+func (t *thriftReader) readStruct(field func(fieldType byte, id int16) error) error {
+	t.structBegin()
+	defer t.structEnd()
+	for {
+		fieldType, id, err := t.readFieldHeader()
+		if err != nil {
+			return err
+		}
+		if fieldType == compactStop {
+			return nil
+		}
+		if err = field(fieldType, id); err != nil {
+			return err
+		}
+	}
+}
+
+// readList calls elem for every element of the list at the current position.
+func (t *thriftReader) readList(elem func(elemType byte) error) error {
+	elemType, size, err := t.readListHeader()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < size; i++ {
+		if err = elem(elemType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skip discards a value of the given type.
+func (t *thriftReader) skip(fieldType byte) error {
+	return t.skipDepth(fieldType, 0)
+}
+
+func (t *thriftReader) skipDepth(fieldType byte, depth int) error {
+	if depth > maxThriftDepth {
+		return errThriftInvalid
+	}
+	var err error
+	switch fieldType {
+	case compactBooleanTrue, compactBooleanFalse:
+		// Value is part of the field header.
+	case compactByte:
+		_, err = t.readByte()
+	case compactI16, compactI32, compactI64:
+		_, err = t.readVarint()
+	case compactDouble:
+		_, err = t.readDouble()
+	case compactBinary:
+		_, err = t.readBinary()
+	case compactList, compactSet:
+		var elemType byte
+		var size int
+		if elemType, size, err = t.readListHeader(); err != nil {
+			return err
+		}
+		for i := 0; i < size && err == nil; i++ {
+			err = t.skipElem(elemType, depth+1)
+		}
+	case compactMap:
+		var size uint64
+		if size, err = t.readUvarint(); err != nil || size == 0 {
+			return err
+		}
+		var kv byte
+		if kv, err = t.readByte(); err != nil {
+			return err
+		}
+		for i := uint64(0); i < size && err == nil; i++ {
+			if err = t.skipElem(kv>>4, depth+1); err == nil {
+				err = t.skipElem(kv&0x0f, depth+1)
+			}
+		}
+	case compactStruct:
+		t.structBegin()
+		defer t.structEnd()
+		for {
+			var ft byte
+			if ft, _, err = t.readFieldHeader(); err != nil || ft == compactStop {
+				return err
+			}
+			if err = t.skipDepth(ft, depth+1); err != nil {
+				return err
+			}
+		}
+	default:
+		err = errThriftInvalid
+	}
+	return err
+}
+
+// skipElem discards a container element, unlike fields booleans inside
+// containers are encoded as one byte.
+func (t *thriftReader) skipElem(elemType byte, depth int) error {
+	if elemType == compactBooleanTrue || elemType == compactBooleanFalse {
+		_, err := t.readByte()
+		return err
+	}
+	return t.skipDepth(elemType, depth)
+}
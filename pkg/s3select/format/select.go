@@ -26,6 +26,8 @@ type Select interface {
 	Header() []string
 	HasHeader() bool
 	OutputFieldDelimiter() string
+	OutputRecordDelimiter() string
+	OutputType() Type
 	UpdateBytesProcessed(record map[string]interface{})
 	Expression() string
 	UpdateBytesReturned(int64)
@@ -35,6 +37,17 @@ type Select interface {
 	Progress() bool
 }
 
+// Pushdown is implemented by formats which can use the parsed query to avoid
+// reading data that cannot be part of the result, such as columnar formats.
+type Pushdown interface {
+	// Project restricts the fields returned in records to the given top
+	// level names, a nil slice selects all of them.
+	Project(names []string)
+	// Filter hints the WHERE clause of the query, records which are read
+	// must still be evaluated against it.
+	Filter(whereClause interface{})
+}
+
 // Progress represents a struct that represents the format for XML of the
 // progress messages
 type Progress struct {
@@ -58,6 +71,7 @@ type Type string
 
 // Different data format types.
 const (
-	JSON Type = "json"
-	CSV  Type = "csv"
+	JSON    Type = "json"
+	CSV     Type = "csv"
+	Parquet Type = "parquet"
 )
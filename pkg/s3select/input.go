@@ -22,12 +22,16 @@ import (
 	"compress/gzip"
 	"io"
 	"net/http"
+	"regexp"
 	"strings"
 	"time"
 
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pydio/minio-srv/pkg/s3select/format"
 	"github.com/pydio/minio-srv/pkg/s3select/format/csv"
 	"github.com/pydio/minio-srv/pkg/s3select/format/json"
+	"github.com/pydio/minio-srv/pkg/s3select/format/parquet"
 
 	humanize "github.com/dustin/go-humanize"
 )
@@ -58,28 +62,240 @@ type Row struct {
 	err    error
 }
 
-// This function replaces "",'' with `` for the select parser
+// cleanExpr rewrites a SELECT expression into one the select parser accepts,
+// double quoted names become backquoted identifiers while single quotes
+// enclose string literals.
 func cleanExpr(expr string) string {
-	r := strings.NewReplacer("\"", "`", "'", "`")
-	return r.Replace(expr)
+	return quoteNames(rewriteSpecialForms(quotePaths(expr)))
+}
+
+// quoteNames replaces the double quotes around names with backquotes.
+func quoteNames(expr string) string {
+	out := []byte(expr)
+	for i := 0; i < len(out); i++ {
+		switch out[i] {
+		case '\'', '`':
+			i = skipQuoted(expr, i) - 1
+		case '"':
+			end := skipQuoted(expr, i)
+			out[i] = '`'
+			if out[end-1] == '"' && end-1 > i {
+				out[end-1] = '`'
+			}
+			i = end - 1
+		}
+	}
+	return string(out)
+}
+
+// skipQuoted returns the position following the quoted text starting at i,
+// a doubled quote character standing for the character itself.
+func skipQuoted(expr string, i int) int {
+	quote := expr[i]
+	for j := i + 1; j < len(expr); j++ {
+		if expr[j] != quote {
+			continue
+		}
+		if j+1 < len(expr) && expr[j+1] == quote {
+			j++
+			continue
+		}
+		return j + 1
+	}
+	return len(expr)
+}
+
+// rewriteSpecialForms rewrites the functions whose arguments are not
+// separated by commas, which the select parser does not accept, into plain
+// function calls. CAST(expr AS type) becomes `cast`(expr, 'type') and
+// EXTRACT(part FROM expr) becomes `extract`(part, expr).
+func rewriteSpecialForms(expr string) string {
+	var out []byte
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		if c == '\'' || c == '"' || c == '`' {
+			end := skipQuoted(expr, i)
+			out = append(out, expr[i:end]...)
+			i = end - 1
+			continue
+		}
+		if !isIdentChar(c) || (i > 0 && (isIdentChar(expr[i-1]) || expr[i-1] == '.')) {
+			out = append(out, c)
+			continue
+		}
+		end := i
+		for end < len(expr) && isIdentChar(expr[end]) {
+			end++
+		}
+		word := strings.ToUpper(expr[i:end])
+		open := end
+		for open < len(expr) && expr[open] == ' ' {
+			open++
+		}
+		if (word == "CAST" || word == "EXTRACT") && open < len(expr) && expr[open] == '(' {
+			if closing := closingParen(expr, open); closing > 0 {
+				args := expr[open+1 : closing]
+				if word == "CAST" {
+					if sep := keywordIndex(args, "AS", true); sep >= 0 {
+						out = append(out, "`cast`("+rewriteSpecialForms(strings.TrimSpace(args[:sep]))+", '"+strings.TrimSpace(args[sep+2:])+"')"...)
+						i = closing
+						continue
+					}
+				} else if sep := keywordIndex(args, "FROM", false); sep >= 0 {
+					out = append(out, "`extract`("+strings.TrimSpace(args[:sep])+", "+rewriteSpecialForms(strings.TrimSpace(args[sep+4:]))+")"...)
+					i = closing
+					continue
+				}
+			}
+		}
+		out = append(out, expr[i:end]...)
+		i = end - 1
+	}
+	return string(out)
+}
+
+// closingParen returns the position of the parenthesis closing the one at
+// open, -1 if there is none.
+func closingParen(expr string, open int) int {
+	depth := 0
+	for i := open; i < len(expr); i++ {
+		switch expr[i] {
+		case '\'', '"', '`':
+			i = skipQuoted(expr, i) - 1
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// keywordIndex returns the position of a keyword found outside of quotes and
+// parentheses, the last one or the first one, -1 if there is none.
+func keywordIndex(expr string, keyword string, last bool) int {
+	found := -1
+	depth := 0
+	for i := 0; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(expr, i) - 1
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && i+len(keyword) <= len(expr) && strings.EqualFold(expr[i:i+len(keyword)], keyword):
+			before := i == 0 || !isIdentChar(expr[i-1])
+			after := i+len(keyword) == len(expr) || !isIdentChar(expr[i+len(keyword)])
+			if before && after {
+				found = i
+				if !last {
+					return found
+				}
+			}
+		}
+	}
+	return found
+}
+
+// pathRegexp matches column paths made of several components such as
+// s.a.b[0].
+var pathRegexp = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*|\[[0-9]+\])+`)
+
+// quotePaths encloses in backquotes the column paths the select parser cannot
+// handle, those with array indices or with more than three components, so that
+// they are parsed as a single column name.
+func quotePaths(expr string) string {
+	var out []byte
+	for len(expr) > 0 {
+		// Quoted names and literals are left as is.
+		i := strings.IndexAny(expr, "\"'`")
+		if i < 0 {
+			i = len(expr)
+		}
+		out = append(out, quoteUnquotedPaths(expr[:i])...)
+		expr = expr[i:]
+		if len(expr) == 0 {
+			break
+		}
+		end := strings.IndexByte(expr[1:], expr[0])
+		if end < 0 {
+			out = append(out, expr...)
+			break
+		}
+		out = append(out, expr[:end+2]...)
+		expr = expr[end+2:]
+	}
+	return string(out)
+}
+
+func quoteUnquotedPaths(s string) string {
+	var out []byte
+	last := 0
+	for _, loc := range pathRegexp.FindAllStringIndex(s, -1) {
+		start, end := loc[0], loc[1]
+		if start > 0 && (isIdentChar(s[start-1]) || s[start-1] == '.') {
+			// Not the beginning of an identifier.
+			continue
+		}
+		path := s[start:end]
+		if !strings.Contains(path, "[") && strings.Count(path, ".") < 3 {
+			continue
+		}
+		out = append(out, s[last:start]...)
+		out = append(out, '`')
+		out = append(out, path...)
+		out = append(out, '`')
+		last = end
+	}
+	return string(append(out, s[last:]...))
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
 }
 
 // New - initialize new select format
 func New(reader io.Reader, size int64, req ObjectSelectRequest) (s3s format.Select, err error) {
+	if req.InputSerialization.Parquet != nil {
+		return newParquet(reader, size, req)
+	}
+	outputType, fieldDelimiter, recordDelimiter := outputOptions(req)
+
+	if req.ScanRange != nil {
+		// Only the records starting within the range are read.
+		if reader, err = scanRange(reader, size, req); err != nil {
+			return nil, err
+		}
+	}
+
 	switch req.InputSerialization.CompressionType {
+	case SelectCompressionNONE, "":
 	case SelectCompressionGZIP:
 		if reader, err = gzip.NewReader(reader); err != nil {
 			return nil, format.ErrTruncatedInput
 		}
 	case SelectCompressionBZIP:
 		reader = bzip2.NewReader(reader)
+	case SelectCompressionZSTD:
+		// A single goroutine decodes the blocks, so that the decoder
+		// does not need to be closed.
+		dec, err := zstd.NewReader(reader, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, format.ErrTruncatedInput
+		}
+		reader = dec
+	case SelectCompressionSNAPPY:
+		reader = snappy.NewReader(reader)
+	default:
+		return nil, ErrInvalidCompressionFormat
 	}
 
 	//  Initializating options for CSV
 	if req.InputSerialization.CSV != nil {
-		if req.OutputSerialization.CSV.FieldDelimiter == "" {
-			req.OutputSerialization.CSV.FieldDelimiter = ","
-		}
 		if req.InputSerialization.CSV.FileHeaderInfo == "" {
 			req.InputSerialization.CSV.FileHeaderInfo = CSVFileHeaderInfoNone
 		}
@@ -87,34 +303,86 @@ func New(reader io.Reader, size int64, req ObjectSelectRequest) (s3s format.Sele
 			req.InputSerialization.CSV.RecordDelimiter = "\n"
 		}
 		s3s, err = csv.New(&csv.Options{
-			HasHeader:            req.InputSerialization.CSV.FileHeaderInfo != CSVFileHeaderInfoNone,
-			RecordDelimiter:      req.InputSerialization.CSV.RecordDelimiter,
-			FieldDelimiter:       req.InputSerialization.CSV.FieldDelimiter,
-			Comments:             req.InputSerialization.CSV.Comments,
-			Name:                 "S3Object", // Default table name for all objects
-			ReadFrom:             reader,
-			Compressed:           string(req.InputSerialization.CompressionType),
-			Expression:           cleanExpr(req.Expression),
-			OutputFieldDelimiter: req.OutputSerialization.CSV.FieldDelimiter,
-			StreamSize:           size,
-			HeaderOpt:            req.InputSerialization.CSV.FileHeaderInfo == CSVFileHeaderInfoUse,
-			Progress:             req.RequestProgress.Enabled,
+			HasHeader:             req.InputSerialization.CSV.FileHeaderInfo != CSVFileHeaderInfoNone,
+			RecordDelimiter:       req.InputSerialization.CSV.RecordDelimiter,
+			FieldDelimiter:        req.InputSerialization.CSV.FieldDelimiter,
+			Comments:              req.InputSerialization.CSV.Comments,
+			Name:                  "S3Object", // Default table name for all objects
+			ReadFrom:              reader,
+			Compressed:            string(req.InputSerialization.CompressionType),
+			Expression:            cleanExpr(req.Expression),
+			OutputFieldDelimiter:  fieldDelimiter,
+			OutputRecordDelimiter: recordDelimiter,
+			OutputType:            outputType,
+			StreamSize:            size,
+			HeaderOpt:             req.InputSerialization.CSV.FileHeaderInfo == CSVFileHeaderInfoUse,
+			Progress:              req.RequestProgress.Enabled,
 		})
 	} else if req.InputSerialization.JSON != nil {
 		//  Initializating options for JSON
 		s3s, err = json.New(&json.Options{
-			Name:       "S3Object", // Default table name for all objects
-			ReadFrom:   reader,
-			Compressed: string(req.InputSerialization.CompressionType),
-			Expression: cleanExpr(req.Expression),
-			StreamSize: size,
-			Type:       req.InputSerialization.JSON.Type == JSONTypeDocument,
-			Progress:   req.RequestProgress.Enabled,
+			Name:                  "S3Object", // Default table name for all objects
+			ReadFrom:              reader,
+			Compressed:            string(req.InputSerialization.CompressionType),
+			Expression:            cleanExpr(req.Expression),
+			OutputFieldDelimiter:  fieldDelimiter,
+			OutputRecordDelimiter: recordDelimiter,
+			OutputType:            outputType,
+			StreamSize:            size,
+			Type:                  req.InputSerialization.JSON.Type == JSONTypeDocument,
+			Progress:              req.RequestProgress.Enabled,
 		})
 	}
 	return s3s, err
 }
 
+// outputOptions returns the serialization of the returned records along with
+// the field and record delimiters, defaulting to comma separated values, one
+// record per line.
+func outputOptions(req ObjectSelectRequest) (outputType format.Type, fieldDelimiter, recordDelimiter string) {
+	outputType, fieldDelimiter, recordDelimiter = format.CSV, ",", "\n"
+	if req.OutputSerialization.JSON != nil {
+		outputType = format.JSON
+		if req.OutputSerialization.JSON.RecordDelimiter != "" {
+			recordDelimiter = req.OutputSerialization.JSON.RecordDelimiter
+		}
+	} else if req.OutputSerialization.CSV != nil {
+		if req.OutputSerialization.CSV.FieldDelimiter != "" {
+			fieldDelimiter = req.OutputSerialization.CSV.FieldDelimiter
+		}
+		if req.OutputSerialization.CSV.RecordDelimiter != "" {
+			recordDelimiter = req.OutputSerialization.CSV.RecordDelimiter
+		}
+	}
+	return outputType, fieldDelimiter, recordDelimiter
+}
+
+// newParquet initializes the parquet format, parquet files are compressed
+// internally and are read from their footer so the reader must also be an
+// io.ReaderAt.
+func newParquet(reader io.Reader, size int64, req ObjectSelectRequest) (format.Select, error) {
+	switch req.InputSerialization.CompressionType {
+	case SelectCompressionNONE, "":
+	default:
+		return nil, ErrInvalidCompressionFormat
+	}
+	readerAt, ok := reader.(io.ReaderAt)
+	if !ok {
+		return nil, ErrInvalidRequestParameter
+	}
+	outputType, fieldDelimiter, recordDelimiter := outputOptions(req)
+	return parquet.New(&parquet.Options{
+		Name:                  "S3Object", // Default table name for all objects
+		ReadFrom:              readerAt,
+		Expression:            cleanExpr(req.Expression),
+		OutputFieldDelimiter:  fieldDelimiter,
+		OutputRecordDelimiter: recordDelimiter,
+		OutputType:            outputType,
+		StreamSize:            size,
+		Progress:              req.RequestProgress.Enabled,
+	})
+}
+
 // Execute is the function where all the blocking occurs, It writes to the HTTP
 // response writer in a streaming fashion so that the client can actively use
 // the results before the query is finally finished executing. The
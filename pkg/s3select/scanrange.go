@@ -0,0 +1,198 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3select
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// scanBufferSize is the size of the reads of the object made for a scan
+// range.
+const scanBufferSize = 1 << 20
+
+// scanBounds returns the first and last offsets of the scan range of the
+// request for an object of the given size, inclusive. Without Start the End
+// bound is the number of bytes to scan at the end of the object. empty is true
+// when the range lies beyond the end of the object.
+func scanBounds(req ObjectSelectRequest, size int64) (start, end int64, empty bool, err error) {
+	start, end = 0, size-1
+	r := req.ScanRange
+	switch {
+	case r.Start != nil && *r.Start < 0, r.End != nil && *r.End < 0:
+		return 0, 0, false, ErrInvalidScanRange
+	case r.Start != nil && r.End != nil && *r.Start > *r.End:
+		return 0, 0, false, ErrInvalidScanRange
+	case r.Start != nil:
+		start = *r.Start
+		if r.End != nil && *r.End < end {
+			end = *r.End
+		}
+	case r.End != nil:
+		if start = size - *r.End; start < 0 {
+			start = 0
+		}
+	}
+	return start, end, start >= size, nil
+}
+
+// scanRange returns the records of the object starting within the scan range
+// of the request. The records of CSV objects with a header are preceded by
+// the first line of the object. The object is split at record delimiters, so
+// quoted CSV fields must not contain the record delimiter.
+func scanRange(reader io.Reader, size int64, req ObjectSelectRequest) (io.Reader, error) {
+	switch req.InputSerialization.CompressionType {
+	case SelectCompressionNONE, "":
+	default:
+		return nil, ErrInvalidScanRange
+	}
+	var delimiter string
+	var hasHeader bool
+	switch {
+	case req.InputSerialization.CSV != nil:
+		delimiter = req.InputSerialization.CSV.RecordDelimiter
+		hasHeader = req.InputSerialization.CSV.FileHeaderInfo == CSVFileHeaderInfoUse ||
+			req.InputSerialization.CSV.FileHeaderInfo == CSVFileHeaderInfoIgnore
+	case req.InputSerialization.JSON != nil && req.InputSerialization.JSON.Type == JSONLinesType:
+	default:
+		return nil, ErrInvalidScanRange
+	}
+	if delimiter == "" {
+		delimiter = "\n"
+	}
+	readerAt, ok := reader.(io.ReaderAt)
+	if !ok {
+		return nil, ErrInvalidRequestParameter
+	}
+	start, end, empty, err := scanBounds(req, size)
+	if err != nil {
+		return nil, err
+	}
+	// Reading starts before the range so that a delimiter ending just
+	// before it, and thus a record starting at its first byte, is found.
+	offset := start - int64(len(delimiter))
+	if offset < 0 {
+		offset = 0
+	}
+	records := &recordReader{
+		r:         bufio.NewReaderSize(io.NewSectionReader(readerAt, offset, size-offset), scanBufferSize),
+		delimiter: []byte(delimiter),
+		pos:       offset,
+		end:       end,
+		done:      empty,
+	}
+	if start > 0 && !empty {
+		if err = records.skipRecord(); err != nil {
+			return nil, err
+		}
+	}
+	if !hasHeader || start == 0 {
+		return records, nil
+	}
+	header := &recordReader{
+		r:         bufio.NewReader(io.NewSectionReader(readerAt, 0, size)),
+		delimiter: []byte(delimiter),
+		end:       0,
+	}
+	return io.MultiReader(header, records), nil
+}
+
+// recordReader returns the bytes of the records starting at or before the
+// end offset, the last one is read up to its delimiter.
+type recordReader struct {
+	r         *bufio.Reader
+	delimiter []byte
+	// pos is the offset in the object of the next byte of r.
+	pos int64
+	end int64
+	// window holds the last bytes read to find delimiters across reads.
+	window []byte
+	done   bool
+}
+
+// next reads a byte and returns true when it completes a delimiter.
+func (rr *recordReader) next() (c byte, delimited bool, err error) {
+	if c, err = rr.r.ReadByte(); err != nil {
+		return 0, false, err
+	}
+	rr.pos++
+	rr.window = append(rr.window, c)
+	if len(rr.window) > len(rr.delimiter) {
+		rr.window = rr.window[1:]
+	}
+	if !bytes.Equal(rr.window, rr.delimiter) {
+		return c, false, nil
+	}
+	rr.window = rr.window[:0]
+	return c, true, nil
+}
+
+// skipRecord skips the bytes up to the first delimiter, the next record is
+// the first one starting within the range.
+func (rr *recordReader) skipRecord() error {
+	for {
+		_, delimited, err := rr.next()
+		if err == io.EOF {
+			rr.done = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if delimited {
+			rr.done = rr.pos > rr.end
+			return nil
+		}
+	}
+}
+
+// Read reads the records, bytes are copied as they are until a delimiter may
+// end past the end offset, they are then read one at a time up to the end of
+// the last record.
+func (rr *recordReader) Read(p []byte) (n int, err error) {
+	if rr.done {
+		return 0, io.EOF
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	// Delimiters ending before the end offset start before tail.
+	tail := rr.end - int64(len(rr.delimiter)) + 1
+	if rr.pos < tail {
+		if int64(len(p)) > tail-rr.pos {
+			p = p[:tail-rr.pos]
+		}
+		rr.window = rr.window[:0]
+		n, err = rr.r.Read(p)
+		rr.pos += int64(n)
+		return n, err
+	}
+	for n < len(p) {
+		c, delimited, err := rr.next()
+		if err != nil {
+			return n, err
+		}
+		p[n] = c
+		n++
+		if delimited && rr.pos-1 >= rr.end {
+			rr.done = true
+			break
+		}
+	}
+	return n, nil
+}
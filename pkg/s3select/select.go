@@ -17,6 +17,7 @@
 package s3select
 
 import (
+	"bytes"
 	"encoding/json"
 	"math"
 	"sort"
@@ -24,14 +25,17 @@ import (
 	"strings"
 
 	"github.com/pydio/minio-srv/pkg/s3select/format"
+	"github.com/tidwall/gjson"
 	"github.com/xwb1989/sqlparser"
 )
 
 // SelectFuncs contains the relevant values from the parser for S3 Select
-// Functions
+// Functions, the expressions of the SELECT list which are neither columns nor
+// aggregations are stored at their position, as well as the arguments of the
+// aggregations.
 type SelectFuncs struct {
-	funcExpr []*sqlparser.FuncExpr
-	index    []int
+	expr      []sqlparser.Expr
+	aggregate []sqlparser.Expr
 }
 
 // RunSqlParser allows us to easily bundle all the functions from above and run
@@ -44,6 +48,10 @@ func runSelectParser(f format.Select, myRow chan Row) {
 		}
 		return
 	}
+	if p, ok := f.(format.Pushdown); ok {
+		p.Project(projectedColumns(f.Expression(), alias))
+		p.Filter(whereClause)
+	}
 	processSelectReq(reqCols, alias, whereClause, myLimit, aggFunctionNames, myRow, myFuncs, f)
 
 }
@@ -69,6 +77,21 @@ func ParseSelect(f format.Select) ([]string, string, int64, interface{}, []strin
 		functionNames := make([]string, len(stmt.SelectExprs))
 		columnNames := make([]string, len(stmt.SelectExprs))
 
+		// This code retrieves the alias and makes sure it is set to the correct
+		// value, if not it sets it to the tablename
+		if (stmt.From) != nil {
+			for i := 0; i < len(stmt.From); i++ {
+				switch smallerexpr := stmt.From[i].(type) {
+				case *sqlparser.JoinTableExpr:
+					return nil, "", 0, nil, nil, sFuncs, ErrParseMalformedJoin
+				case *sqlparser.AliasedTableExpr:
+					alias = smallerexpr.As.CompliantName()
+					if alias == "" {
+						alias = sqlparser.GetTableName(smallerexpr.Expr).CompliantName()
+					}
+				}
+			}
+		}
 		if stmt.Where != nil {
 			switch expr := stmt.Where.Expr.(type) {
 			default:
@@ -86,56 +109,38 @@ func ParseSelect(f format.Select) ([]string, string, int64, interface{}, []strin
 					switch smallerexpr := expr.Expr.(type) {
 					case *sqlparser.FuncExpr:
 						if smallerexpr.IsAggregate() {
-							functionNames[i] = smallerexpr.Name.CompliantName()
-							// Will return function name
-							// Case to deal with if we have functions and not an asterix
+							// The argument of the aggregation is evaluated
+							// for every record matching the where clause.
+							functionNames[i] = smallerexpr.Name.Lowered()
+							if !supportedAggregate(functionNames[i]) || smallerexpr.Distinct {
+								return nil, "", 0, nil, nil, sFuncs, ErrUnsupportedSQLOperation
+							}
+							if len(smallerexpr.Exprs) != 1 {
+								return nil, "", 0, nil, nil, sFuncs, ErrParseNonUnaryAgregateFunctionCall
+							}
 							switch tempagg := smallerexpr.Exprs[0].(type) {
 							case *sqlparser.StarExpr:
-								columnNames[0] = "*"
-								if smallerexpr.Name.CompliantName() != "count" {
+								if functionNames[i] != "count" {
 									return nil, "", 0, nil, nil, sFuncs, ErrParseUnsupportedCallWithStar
 								}
+								sFuncs.addAggregate(i, len(stmt.SelectExprs), nil)
 							case *sqlparser.AliasedExpr:
-								switch col := tempagg.Expr.(type) {
-								case *sqlparser.BinaryExpr:
-									return nil, "", 0, nil, nil, sFuncs, ErrParseNonUnaryAgregateFunctionCall
-								case *sqlparser.ColName:
-									columnNames[i] = col.Name.CompliantName()
-								}
-							}
-							// Case to deal with if COALESCE was used..
-						} else if supportedFunc(smallerexpr.Name.CompliantName()) {
-							if sFuncs.funcExpr == nil {
-								sFuncs.funcExpr = make([]*sqlparser.FuncExpr, len(stmt.SelectExprs))
-								sFuncs.index = make([]int, len(stmt.SelectExprs))
+								sFuncs.addAggregate(i, len(stmt.SelectExprs), tempagg.Expr)
 							}
-							sFuncs.funcExpr[i] = smallerexpr
-							sFuncs.index[i] = i
 						} else {
-							return nil, "", 0, nil, nil, sFuncs, ErrUnsupportedSQLOperation
+							sFuncs.add(i, len(stmt.SelectExprs), smallerexpr)
 						}
 					case *sqlparser.ColName:
-						columnNames[i] = smallerexpr.Name.CompliantName()
+						columnNames[i] = columnPath(smallerexpr, alias)
+					default:
+						// Functions, literals and operations are evaluated for
+						// every record.
+						sFuncs.add(i, len(stmt.SelectExprs), smallerexpr)
 					}
 				}
 			}
 		}
 
-		// This code retrieves the alias and makes sure it is set to the correct
-		// value, if not it sets it to the tablename
-		if (stmt.From) != nil {
-			for i := 0; i < len(stmt.From); i++ {
-				switch smallerexpr := stmt.From[i].(type) {
-				case *sqlparser.JoinTableExpr:
-					return nil, "", 0, nil, nil, sFuncs, ErrParseMalformedJoin
-				case *sqlparser.AliasedTableExpr:
-					alias = smallerexpr.As.CompliantName()
-					if alias == "" {
-						alias = sqlparser.GetTableName(smallerexpr.Expr).CompliantName()
-					}
-				}
-			}
-		}
 		if stmt.Limit != nil {
 			switch expr := stmt.Limit.Rowcount.(type) {
 			case *sqlparser.SQLVal:
@@ -150,6 +155,15 @@ func ParseSelect(f format.Select) ([]string, string, int64, interface{}, []strin
 		if stmt.OrderBy != nil {
 			return nil, "", 0, nil, nil, sFuncs, ErrParseUnsupportedToken
 		}
+		if sFuncs.aggregate != nil {
+			// Aggregations return a single record, so they cannot be
+			// mixed with columns or other expressions.
+			for i := range functionNames {
+				if functionNames[i] == "" {
+					return nil, "", 0, nil, nil, sFuncs, ErrParseUnsupportedSelect
+				}
+			}
+		}
 		if err := parseErrs(columnNames, whereClause, alias, sFuncs, f); err != nil {
 			return nil, "", 0, nil, nil, sFuncs, err
 		}
@@ -158,6 +172,23 @@ func ParseSelect(f format.Select) ([]string, string, int64, interface{}, []strin
 	return nil, "", 0, nil, nil, sFuncs, nil
 }
 
+// add stores the expression found at position i of a SELECT list of size n.
+func (sFuncs *SelectFuncs) add(i, n int, expr sqlparser.Expr) {
+	if sFuncs.expr == nil {
+		sFuncs.expr = make([]sqlparser.Expr, n)
+	}
+	sFuncs.expr[i] = expr
+}
+
+// addAggregate stores the argument of the aggregation found at position i of
+// a SELECT list of size n, nil for COUNT(*).
+func (sFuncs *SelectFuncs) addAggregate(i, n int, expr sqlparser.Expr) {
+	if sFuncs.aggregate == nil {
+		sFuncs.aggregate = make([]sqlparser.Expr, n)
+	}
+	sFuncs.aggregate[i] = expr
+}
+
 // This is the main function, It goes row by row and for records which validate
 // the where clause it currently prints the appropriate row given the requested
 // columns.
@@ -165,9 +196,12 @@ func processSelectReq(reqColNames []string, alias string, whereClause interface{
 	counter := -1
 	var columns []string
 	filtrCount := 0
-	functionFlag := false
-	// My values is used to store our aggregation values if we need to store them.
-	myAggVals := make([]float64, len(reqColNames))
+	// aggregates accumulate the values of the aggregation functions if any,
+	// a single record is then returned at the end.
+	var aggregates []*aggregate
+	for i, expr := range myFunc.aggregate {
+		aggregates = append(aggregates, newAggregate(functionNames[i], expr))
+	}
 	// LowercasecolumnsMap is used in accordance with hasDuplicates so that we can
 	// raise the error "Ambigious" if a case insensitive column is provided and we
 	// have multiple matches.
@@ -178,6 +212,7 @@ func processSelectReq(reqColNames []string, alias string, whereClause interface{
 	if limitOfRecords == 0 {
 		limitOfRecords = math.MaxInt64
 	}
+	ev := newEvaluator(alias, f)
 	for {
 		record, err := f.Read()
 		if err != nil {
@@ -187,9 +222,9 @@ func processSelectReq(reqColNames []string, alias string, whereClause interface{
 			return
 		}
 		if record == nil {
-			if functionFlag {
+			if aggregates != nil {
 				myRow <- Row{
-					record: aggFuncToStr(myAggVals, f) + "\n",
+					record: aggFuncToStr(aggregates, f) + f.OutputRecordDelimiter(),
 				}
 			}
 			close(myRow)
@@ -219,31 +254,44 @@ func processSelectReq(reqColNames []string, alias string, whereClause interface{
 
 		}
 		// Return in case the number of record reaches the LIMIT defined in select query
-		if int64(filtrCount) == limitOfRecords && limitOfRecords != 0 {
+		if int64(filtrCount) == limitOfRecords && aggregates == nil {
 			close(myRow)
 			return
 		}
 
 		// The call to the where function clause,ensures that the rows we print match our where clause.
-		condition, myErr := matchesMyWhereClause(record, alias, whereClause)
+		condition, myErr := matchesMyWhereClause(ev, string(out), whereClause)
 		if myErr != nil {
 			myRow <- Row{
 				err: myErr,
 			}
 			return
 		}
-		if condition {
+		if condition && aggregates != nil {
+			for _, agg := range aggregates {
+				if myErr = agg.update(ev, string(out)); myErr != nil {
+					myRow <- Row{
+						err: myErr,
+					}
+					return
+				}
+			}
+		} else if condition {
 			// if its an asterix we just print everything in the row
 			if reqColNames[0] == "*" && functionNames[0] == "" {
 				var row Row
-				switch f.Type() {
-				case format.CSV:
+				switch {
+				case f.Type() == format.JSON:
 					row = Row{
-						record: strings.Join(convertToSlice(columnsMap, record, string(out)), f.OutputFieldDelimiter()) + "\n",
+						record: string(out) + f.OutputRecordDelimiter(),
 					}
-				case format.JSON:
+				case f.OutputType() == format.JSON:
 					row = Row{
-						record: string(out) + "\n",
+						record: convertToJSON(columnsMap, record, f.HasHeader()) + f.OutputRecordDelimiter(),
+					}
+				default:
+					row = Row{
+						record: strings.Join(convertToSlice(columnsMap, record, string(out)), f.OutputFieldDelimiter()) + f.OutputRecordDelimiter(),
 					}
 				}
 				myRow <- row
@@ -251,46 +299,32 @@ func processSelectReq(reqColNames []string, alias string, whereClause interface{
 				// This is for dealing with the case of if we have to deal with a
 				// request for a column with an index e.g A_1.
 				if format.IsInt(reqColNames[0]) {
-					// This checks whether any aggregation function was called as now we
-					// no longer will go through printing each row, and only print at the end
-					if len(functionNames) > 0 && functionNames[0] != "" {
-						functionFlag = true
-						aggregationFunctions(counter, filtrCount, myAggVals, reqColNames, functionNames, string(out))
-					} else {
-						// The code below finds the appropriate columns of the row given the
-						// indicies provided in the SQL request and utilizes the map to
-						// retrieve the correct part of the row.
-						myQueryRow, myErr := processColNameIndex(string(out), reqColNames, columns, f)
-						if myErr != nil {
-							myRow <- Row{
-								err: myErr,
-							}
-							return
-						}
+					// The code below finds the appropriate columns of the row given the
+					// indicies provided in the SQL request and utilizes the map to
+					// retrieve the correct part of the row.
+					myQueryRow, myErr := processColNameIndex(string(out), reqColNames, columns, f)
+					if myErr != nil {
 						myRow <- Row{
-							record: myQueryRow + "\n",
+							err: myErr,
 						}
+						return
+					}
+					myRow <- Row{
+						record: myQueryRow + f.OutputRecordDelimiter(),
 					}
 				} else {
-					// This code does aggregation if we were provided column names in the
-					// form of acutal names rather an indices.
-					if len(functionNames) > 0 && functionNames[0] != "" {
-						functionFlag = true
-						aggregationFunctions(counter, filtrCount, myAggVals, reqColNames, functionNames, string(out))
-					} else {
-						// This code prints the appropriate part of the row given the filter
-						// and select request, if the select request was based on column
-						// names rather than indices.
-						myQueryRow, myErr := processColNameLiteral(string(out), reqColNames, myFunc, f)
-						if myErr != nil {
-							myRow <- Row{
-								err: myErr,
-							}
-							return
-						}
+					// This code prints the appropriate part of the row given the filter
+					// and select request, if the select request was based on column
+					// names rather than indices.
+					myQueryRow, myErr := processColNameLiteral(string(out), reqColNames, myFunc, ev, f)
+					if myErr != nil {
 						myRow <- Row{
-							record: myQueryRow + "\n",
+							err: myErr,
 						}
+						return
+					}
+					myRow <- Row{
+						record: myQueryRow + f.OutputRecordDelimiter(),
 					}
 				}
 			}
@@ -300,10 +334,42 @@ func processSelectReq(reqColNames []string, alias string, whereClause interface{
 	}
 }
 
+// projectedColumns returns the names of the top level fields referenced in
+// the SELECT expression, nil is returned when all of them are needed.
+func projectedColumns(expression string, alias string) []string {
+	stmt, err := sqlparser.Parse(expression)
+	if err != nil {
+		return nil
+	}
+	sel, ok := stmt.(*sqlparser.Select)
+	if !ok {
+		return nil
+	}
+	names := []string{}
+	visit := func(node sqlparser.SQLNode) (bool, error) {
+		if col, ok := node.(*sqlparser.ColName); ok {
+			// Nested paths such as s.a.b[0] need the whole top level
+			// field.
+			names = append(names, strings.SplitN(columnPath(col, alias), ".", 2)[0], col.Name.String())
+		}
+		return true, nil
+	}
+	for _, expr := range sel.SelectExprs {
+		if _, ok := expr.(*sqlparser.StarExpr); ok {
+			return nil
+		}
+		sqlparser.Walk(visit, expr)
+	}
+	if sel.Where != nil {
+		sqlparser.Walk(visit, sel.Where)
+	}
+	return names
+}
+
 // processColumnNames is a function which allows for cleaning of column names.
 func processColumnNames(reqColNames []string, alias string, f format.Select) error {
 	switch f.Type() {
-	case format.CSV:
+	case format.CSV, format.Parquet:
 		for i := 0; i < len(reqColNames); i++ {
 			// The code below basically cleans the column name of its alias and other
 			// syntax, so that we can extract its pure name.
@@ -320,6 +386,7 @@ func processColumnNames(reqColNames []string, alias string, f format.Select) err
 // query.
 func processColNameIndex(record string, reqColNames []string, columns []string, f format.Select) (string, error) {
 	row := make([]string, len(reqColNames))
+	keys := make([]string, len(reqColNames))
 	for i := 0; i < len(reqColNames); i++ {
 		// COALESCE AND NULLIF do not support index based access.
 		if reqColNames[0] == "0" {
@@ -334,90 +401,90 @@ func processColNameIndex(record string, reqColNames []string, columns []string,
 			return "", ErrMissingHeaders
 		}
 		// Subtract 1 because AWS Indexing is not 0 based, it starts at 1 generating the key like "_1".
+		keys[i] = "_" + strconv.Itoa(mytempindex)
+		if f.OutputType() == format.JSON {
+			row[i] = gjson.Get(record, "_"+strconv.Itoa(mytempindex-1)).Raw
+			continue
+		}
 		row[i] = jsonValue(string("_"+strconv.Itoa(mytempindex-1)), record)
 	}
-	rowStr := strings.Join(row, f.OutputFieldDelimiter())
-	if len(rowStr) > MaxCharsPerRecord {
-		return "", ErrOverMaxRecordSize
-	}
-
-	return rowStr, nil
+	return formatRow(keys, row, f)
 }
 
 // processColNameLiteral is the function which creates the row for an name based
 // query.
-func processColNameLiteral(record string, reqColNames []string, myFunc SelectFuncs, f format.Select) (string, error) {
+func processColNameLiteral(record string, reqColNames []string, myFunc SelectFuncs, ev *evaluator, f format.Select) (string, error) {
 	row := make([]string, len(reqColNames))
+	keys := make([]string, len(reqColNames))
 	for i := 0; i < len(reqColNames); i++ {
-		// this is the case to deal with COALESCE.
-		if reqColNames[i] == "" && isValidFunc(myFunc.index, i) {
-			row[i] = evaluateFuncExpr(myFunc.funcExpr[i], "", record)
+		// this is the case to deal with functions and other expressions.
+		if reqColNames[i] == "" && myFunc.expr != nil && myFunc.expr[i] != nil {
+			keys[i] = "_" + strconv.Itoa(i+1)
+			v, err := ev.eval(myFunc.expr[i], record)
+			if err != nil {
+				return "", err
+			}
+			if f.OutputType() == format.JSON {
+				row[i] = v.JSON()
+			} else {
+				row[i] = v.String()
+			}
+			continue
+		}
+		keys[i] = outputName(reqColNames[i], i)
+		if f.OutputType() == format.JSON {
+			row[i] = gjson.Get(record, reqColNames[i]).Raw
 			continue
 		}
 		row[i] = jsonValue(reqColNames[i], record)
 	}
-	rowStr := strings.Join(row, f.OutputFieldDelimiter())
+	return formatRow(keys, row, f)
+}
+
+// outputName returns the name of a requested column in JSON output records,
+// the last component of its path or its position for array elements.
+func outputName(path string, i int) string {
+	name := path[strings.LastIndex(path, ".")+1:]
+	if format.IsInt(name) {
+		return "_" + strconv.Itoa(i+1)
+	}
+	return name
+}
+
+// formatRow returns the output record made of the given values, either a
+// JSON object of the raw JSON values or the delimited values.
+func formatRow(keys []string, values []string, f format.Select) (string, error) {
+	var rowStr string
+	if f.OutputType() == format.JSON {
+		rowStr = jsonObject(keys, values)
+	} else {
+		rowStr = strings.Join(values, f.OutputFieldDelimiter())
+	}
 	if len(rowStr) > MaxCharsPerRecord {
 		return "", ErrOverMaxRecordSize
 	}
 	return rowStr, nil
 }
 
-// aggregationFunctions is a function which performs the actual aggregation
-// methods on the given row, it uses an array defined in the main parsing
-// function to keep track of values.
-func aggregationFunctions(counter int, filtrCount int, myAggVals []float64, storeReqCols []string, storeFunctions []string, record string) error {
-	for i := 0; i < len(storeFunctions); i++ {
-		if storeFunctions[i] == "" {
-			i++
-		} else if storeFunctions[i] == "count" {
-			myAggVals[i]++
-		} else {
-			// If column names are provided as an index it'll use this if statement instead of the else/
-			var convAggFloat float64
-			if format.IsInt(storeReqCols[i]) {
-				myIndex, _ := strconv.Atoi(storeReqCols[i])
-				convAggFloat, _ = strconv.ParseFloat(jsonValue(string("_"+strconv.Itoa(myIndex)), record), 64)
-
-			} else {
-				// case that the columns are in the form of named columns rather than indices.
-				convAggFloat, _ = strconv.ParseFloat(jsonValue(storeReqCols[i], record), 64)
-			}
-			// This if statement is for calculating the min.
-			if storeFunctions[i] == "min" {
-				if counter == -1 {
-					myAggVals[i] = math.MaxFloat64
-				}
-				if convAggFloat < myAggVals[i] {
-					myAggVals[i] = convAggFloat
-				}
-
-			} else if storeFunctions[i] == "max" {
-				// This if statement is for calculating the max.
-				if counter == -1 {
-					myAggVals[i] = math.SmallestNonzeroFloat64
-				}
-				if convAggFloat > myAggVals[i] {
-					myAggVals[i] = convAggFloat
-				}
-
-			} else if storeFunctions[i] == "sum" {
-				// This if statement is for calculating the sum.
-				myAggVals[i] += convAggFloat
-
-			} else if storeFunctions[i] == "avg" {
-				// This if statement is for calculating the average.
-				if filtrCount == 0 {
-					myAggVals[i] = convAggFloat
-				} else {
-					myAggVals[i] = (convAggFloat + (myAggVals[i] * float64(filtrCount))) / float64((filtrCount + 1))
-				}
-			} else {
-				return ErrParseNonUnaryAgregateFunctionCall
-			}
+// jsonObject returns a JSON object with the given keys and raw JSON values,
+// keys of missing values are left out.
+func jsonObject(keys []string, values []string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if values[i] == "" {
+			continue
 		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		out, _ := json.Marshal(key)
+		buf.Write(out)
+		buf.WriteByte(':')
+		buf.WriteString(values[i])
 	}
-	return nil
+	buf.WriteByte('}')
+	return buf.String()
 }
 
 // convertToSlice takes the map[string]interface{} and convert it to []string
@@ -441,3 +508,28 @@ func convertToSlice(columnsMap map[string]int, record map[string]interface{}, ma
 	}
 	return result
 }
+
+// convertToJSON returns the fields of a record as a JSON object, in the order
+// of the columns. Columns of files without header are named _1, _2...
+func convertToJSON(columnsMap map[string]int, record map[string]interface{}, hasHeader bool) string {
+	keys := make([]string, len(columnsMap))
+	names := make([]string, len(columnsMap))
+	for k, v := range columnsMap {
+		if v < 0 || v >= len(keys) {
+			continue
+		}
+		keys[v] = k
+		names[v] = k
+		if !hasHeader {
+			names[v] = "_" + strconv.Itoa(v+1)
+		}
+	}
+	values := make([]string, len(keys))
+	for i, k := range keys {
+		if value, ok := record[k]; ok {
+			out, _ := json.Marshal(value)
+			values[i] = string(out)
+		}
+	}
+	return jsonObject(names, values)
+}
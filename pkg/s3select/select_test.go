@@ -17,11 +17,17 @@
 package s3select
 
 import (
+	"bytes"
+	"encoding/xml"
 	"fmt"
-	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pydio/minio-srv/pkg/s3select/format"
+	"github.com/xwb1989/sqlparser"
 )
 
 // Unit Test for the checkForDuplicates function.
@@ -78,193 +84,62 @@ func TestMyHelperFunctions(t *testing.T) {
 	}
 }
 
-// TestMyStateMachine is a unit test which ensures that the lowest level of the
-// interpreter is converting properly.
-func TestMyStateMachine(t *testing.T) {
-	tables := []struct {
-		operand  interface{}
-		operator string
-		leftArg  string
-		err      error
-		expected bool
-	}{
-		{"2005", ">", "2012", nil, true},
-		{2005, ">", "2012", nil, true},
-		{2012.0000, ">", "2014.000", nil, true},
-		{"NA", ">", "2014.000", nil, false},
-		{2014, ">", "Random", nil, false},
-		{"test3", ">", "aandom", nil, false},
-	}
-	for _, table := range tables {
-		val, err := evaluateOperator(table.leftArg, table.operator, table.operand)
-		if err != table.err {
-			t.Error()
-		}
-		if val != table.expected {
-			t.Error()
-		}
-	}
-}
-
-// TestMyOperators is a unit test which ensures that the appropriate values are
-// being returned from the operators functions.
-func TestMyOperators(t *testing.T) {
-	tables := []struct {
-		operator string
-		err      error
-	}{
-		{">", nil},
-		{"%", ErrParseUnknownOperator},
-	}
-	for _, table := range tables {
-		err := checkValidOperator(table.operator)
-		if err != table.err {
-			t.Error()
-		}
+// TestAggregate checks the typed results of the aggregations over a set of
+// records.
+func TestAggregate(t *testing.T) {
+	records := []string{
+		`{"n":1,"f":0.1,"s":"b","t":"2018-01-02T","big":9007199254740993,"c":"10"}`,
+		`{"n":2,"f":0.2,"s":"a","t":"2017-05-06T","big":9007199254740993,"c":"9"}`,
+		`{"n":null,"f":0.3,"s":"c","big":9223372036854775807,"c":""}`,
 	}
-}
-
-// TestMyConversion ensures that the conversion of the value from the csv
-// happens correctly.
-func TestMyConversion(t *testing.T) {
 	tables := []struct {
-		myTblVal string
-		expected reflect.Kind
-	}{
-		{"2014", reflect.Int},
-		{"2014.000", reflect.Float64},
-		{"String!!!", reflect.String},
-	}
-	for _, table := range tables {
-		val := reflect.ValueOf(checkStringType(table.myTblVal)).Kind()
-		if val != table.expected {
-			t.Error()
-		}
-	}
-}
-
-// Unit tests for the main function that performs aggreggation.
-func TestMyAggregationFunc(t *testing.T) {
-	columnsMap := make(map[string]int)
-	columnsMap["Col1"] = 0
-	columnsMap["Col2"] = 1
-	tables := []struct {
-		counter        int
-		filtrCount     int
-		myAggVals      []float64
-		columnsMap     map[string]int
-		storeReqCols   []string
-		storeFunctions []string
-		record         string
-		err            error
-		expectedVal    float64
-	}{
-		{10, 5, []float64{10, 11, 12, 13, 14}, columnsMap, []string{"Col1"}, []string{"count"}, "{\"Col1\":\"1\",\"Col2\":\"2\"}", nil, 11},
-		{10, 5, []float64{10}, columnsMap, []string{"Col1"}, []string{"min"}, "{\"Col1\":\"1\",\"Col2\":\"2\"}", nil, 1},
-		{10, 5, []float64{10}, columnsMap, []string{"Col1"}, []string{"max"}, "{\"Col1\":\"1\",\"Col2\":\"2\"}", nil, 10},
-		{10, 5, []float64{10}, columnsMap, []string{"Col1"}, []string{"sum"}, "{\"Col1\":\"1\",\"Col2\":\"2\"}", nil, 11},
-		{1, 1, []float64{10}, columnsMap, []string{"Col1"}, []string{"avg"}, "{\"Col1\":\"1\",\"Col2\":\"2\"}", nil, 5.500},
-		{10, 5, []float64{0.0000}, columnsMap, []string{"Col1"}, []string{"random"}, "{\"Col1\":\"1\",\"Col2\":\"2\"}", ErrParseNonUnaryAgregateFunctionCall, 0},
-		{0, 5, []float64{0}, columnsMap, []string{"0"}, []string{"count"}, "{\"Col1\":\"1\",\"Col2\":\"2\"}", nil, 1},
-		{10, 5, []float64{10}, columnsMap, []string{"1"}, []string{"min"}, "{\"_1\":\"1\",\"_2\":\"2\"}", nil, 1},
-	}
-
-	for _, table := range tables {
-		err := aggregationFunctions(table.counter, table.filtrCount, table.myAggVals, table.storeReqCols, table.storeFunctions, table.record)
-		if table.err != err {
-			t.Error()
-		}
-		if table.myAggVals[0] != table.expectedVal {
-			t.Error()
-		}
-
-	}
-}
-
-// TestMyStringComparator is a unit test which ensures that the appropriate
-// values are being compared for strings.
-func TestMyStringComparator(t *testing.T) {
-	tables := []struct {
-		operand  string
-		operator string
-		myVal    string
-		expected bool
+		name     string
+		arg      string
+		expected string
 		err      error
 	}{
-		{"random", ">", "myName", "random" > "myName", nil},
-		{"12", "!=", "myName", "12" != "myName", nil},
-		{"12", "=", "myName", "12" == "myName", nil},
-		{"12", "<=", "myName", "12" <= "myName", nil},
-		{"12", ">=", "myName", "12" >= "myName", nil},
-		{"12", "<", "myName", "12" < "myName", nil},
-		{"name", "like", "_x%", false, nil},
-		{"12", "randomoperator", "myName", false, ErrUnsupportedSyntax},
+		{"count", "*", "3", nil},
+		{"count", "s.n", "2", nil},
+		{"count", "s.t", "2", nil},
+		{"sum", "s.n", "3", nil},
+		{"sum", "s.f", "0.6", nil},
+		{"sum", "s.c", "19", nil},
+		{"sum", "s.big", "9241386435364257793", nil},
+		{"avg", "s.n", "1.5", nil},
+		{"avg", "s.f", "0.2", nil},
+		{"avg", "s.n * 2", "3", nil},
+		{"min", "s.n", "1", nil},
+		{"max", "s.f", "0.3", nil},
+		{"min", "s.s", "a", nil},
+		{"max", "s.s", "c", nil},
+		{"min", "to_timestamp(s.t)", "2017-05-06T00:00:00Z", nil},
+		{"max", "s.c", "10", nil},
+		{"max", "s.missing", "", nil},
+		{"sum", "s.s", "", ErrInvalidDataType},
 	}
-	for _, table := range tables {
-		myVal, err := stringEval(table.operand, table.operator, table.myVal)
-		if err != table.err {
-			t.Error()
-		}
-		if myVal != table.expected {
-			t.Error()
-		}
-	}
-}
-
-// TestMyFloatComparator is a unit test which ensures that the appropriate
-// values are being compared for floats.
-func TestMyFloatComparator(t *testing.T) {
-	tables := []struct {
-		operand  float64
-		operator string
-		myVal    float64
-		expected bool
-		err      error
-	}{
-		{12.000, ">", 13.000, 12.000 > 13.000, nil},
-		{1000.000, "!=", 1000.000, 1000.000 != 1000.000, nil},
-		{1000.000, "<", 1000.000, 1000.000 < 1000.000, nil},
-		{1000.000, "<=", 1000.000, 1000.000 <= 1000.000, nil},
-		{1000.000, ">=", 1000.000, 1000.000 >= 1000.000, nil},
-		{1000.000, "=", 1000.000, 1000.000 == 1000.000, nil},
-		{17.000, "randomoperator", 0.0, false, ErrUnsupportedSyntax},
-	}
-	for _, table := range tables {
-		myVal, err := floatEval(table.operand, table.operator, table.myVal)
-		if err != table.err {
-			t.Error()
+	for i, table := range tables {
+		var expr sqlparser.Expr
+		if table.arg != "*" {
+			stmt, err := sqlparser.Parse("select " + cleanExpr(table.arg) + " from S3Object s")
+			if err != nil {
+				t.Fatal(err)
+			}
+			expr = stmt.(*sqlparser.Select).SelectExprs[0].(*sqlparser.AliasedExpr).Expr
 		}
-		if myVal != table.expected {
-			t.Error()
+		agg := newAggregate(table.name, expr)
+		ev := &evaluator{alias: "s"}
+		var err error
+		for _, record := range records {
+			if err = agg.update(ev, record); err != nil {
+				break
+			}
 		}
-	}
-}
-
-// TestMyIntComparator is a unit test which ensures that the appropriate values
-// are being compared for ints.
-func TestMyIntComparator(t *testing.T) {
-	tables := []struct {
-		operand  int64
-		operator string
-		myVal    int64
-		expected bool
-		err      error
-	}{
-		{12, ">", 13, 12.000 > 13.000, nil},
-		{1000, "!=", 1000, 1000.000 != 1000.000, nil},
-		{1000, "<", 1000, 1000.000 < 1000.000, nil},
-		{1000, "<=", 1000, 1000.000 <= 1000.000, nil},
-		{1000, ">=", 1000, 1000.000 >= 1000.000, nil},
-		{1000, "=", 1000, 1000.000 >= 1000.000, nil},
-		{17, "randomoperator", 0, false, ErrUnsupportedSyntax},
-	}
-	for _, table := range tables {
-		myVal, err := intEval(table.operand, table.operator, table.myVal)
 		if err != table.err {
-			t.Error()
+			t.Errorf("Test %d: expected error %v, got %v", i+1, table.err, err)
+			continue
 		}
-		if myVal != table.expected {
-			t.Error()
+		if err == nil && agg.result().String() != table.expected {
+			t.Errorf("Test %d: expected %s, got %s", i+1, table.expected, agg.result().String())
 		}
 	}
 }
@@ -471,38 +346,519 @@ func TestMatch(t *testing.T) {
 	}
 }
 
-// TestMyFuncProcessing is a unit test which ensures that the appropriate values are
-// being returned from the Processing... functions.
-func TestMyFuncProcessing(t *testing.T) {
+// TestQuotePaths checks that nested paths are rewritten so that the select
+// parser accepts them.
+func TestQuotePaths(t *testing.T) {
 	tables := []struct {
-		myString    string
-		nullList    []string
-		coalList    []string
-		myValString string
-		myValCoal   string
-		myValNull   string
-		stringFunc  string
+		expr     string
+		expected string
 	}{
-		{"lower", []string{"yo", "yo"}, []string{"random", "hello", "random"}, "LOWER", "random", "", "UPPER"},
-		{"LOWER", []string{"null", "random"}, []string{"missing", "hello", "random"}, "lower", "hello", "null", "LOWER"},
+		{"select s.name from S3Object s", "select s.name from S3Object s"},
+		{"select s.a.b[0] from S3Object s", "select `s.a.b[0]` from S3Object s"},
+		{"select s.a.b.c from S3Object s where s.a.b.c = 'x.y.z[1]'", "select `s.a.b.c` from S3Object s where `s.a.b.c` = 'x.y.z[1]'"},
+		{"select * from S3Object s where s.a[1].b > 1.5", "select * from S3Object s where `s.a[1].b` > 1.5"},
 	}
-	for _, table := range tables {
-		if table.coalList != nil {
-			myVal := processCoalNoIndex(table.coalList)
-			if myVal != table.myValCoal {
-				t.Error()
-			}
+	for i, table := range tables {
+		if out := quotePaths(table.expr); out != table.expected {
+			t.Errorf("Test %d: expected %s, got %s", i+1, table.expected, out)
 		}
-		if table.nullList != nil {
-			myVal := processNullIf(table.nullList)
-			if myVal != table.myValNull {
-				t.Error()
-			}
+	}
+}
+
+// TestColumnPath checks the conversion of column references into record
+// paths.
+func TestColumnPath(t *testing.T) {
+	tables := []struct {
+		expr     string
+		expected string
+	}{
+		{"select name from S3Object", "name"},
+		{"select s.name from S3Object s", "name"},
+		{"select S3Object.name from S3Object", "name"},
+		{"select s.a.b from S3Object s", "a.b"},
+		{"select s.a.b[0] from S3Object s", "a.b.0"},
+		{"select s.a[1].b.c from S3Object s", "a.1.b.c"},
+		{"select s._1 from S3Object s", "_1"},
+		{"select `Last Name` from S3Object s", "Last_Name"},
+	}
+	for i, table := range tables {
+		stmt, err := sqlparser.Parse(cleanExpr(table.expr))
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
 		}
-		myVal := applyStrFunc(table.myString, table.stringFunc)
-		if myVal != table.myValString {
-			t.Error()
+		sel := stmt.(*sqlparser.Select)
+		col := sel.SelectExprs[0].(*sqlparser.AliasedExpr).Expr.(*sqlparser.ColName)
+		alias := sel.From[0].(*sqlparser.AliasedTableExpr).As.String()
+		if alias == "" {
+			alias = "S3Object"
+		}
+		if path := columnPath(col, alias); path != table.expected {
+			t.Errorf("Test %d: expected %s, got %s", i+1, table.expected, path)
 		}
+	}
+}
+
+// runSelect runs a select request against data and returns the records.
+func runSelect(t *testing.T, request string, data string) string {
+	var req ObjectSelectRequest
+	if err := xml.Unmarshal([]byte(request), &req); err != nil {
+		t.Fatal(err)
+	}
+	s3s, err := New(strings.NewReader(data), int64(len(data)), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := make(chan Row, 1000)
+	go runSelectParser(s3s, rows)
+	var out string
+	for row := range rows {
+		if row.err != nil {
+			t.Fatal(row.err)
+		}
+		out += row.record
+	}
+	return out
+}
+
+// TestJSONSerialization checks JSON output records and JSON documents with
+// nested paths.
+func TestJSONSerialization(t *testing.T) {
+	const csvInput = `<InputSerialization><CSV><FileHeaderInfo>USE</FileHeaderInfo></CSV></InputSerialization>`
+	const documentInput = `<InputSerialization><JSON><Type>DOCUMENT</Type></JSON></InputSerialization>`
+	const linesInput = `<InputSerialization><JSON><Type>LINES</Type></JSON></InputSerialization>`
+	const csvOutput = `<OutputSerialization><CSV></CSV></OutputSerialization>`
+	const jsonOutput = `<OutputSerialization><JSON></JSON></OutputSerialization>`
+	const jsonOutputComma = `<OutputSerialization><JSON><RecordDelimiter>,</RecordDelimiter></JSON></OutputSerialization>`
+
+	const document = `{
+  "name": "alice",
+  "a": {"b": [10, 20]}
+}
+{
+  "name": "bob",
+  "a": {"b": [30]}
+}`
+	const documentArray = `[{"name": "alice", "a": {"b": [10, 20]}}, {"name": "bob", "a": {"b": [30]}}]`
+
+	tables := []struct {
+		expression string
+		input      string
+		output     string
+		data       string
+		expected   string
+	}{
+		{"select * from S3Object", csvInput, jsonOutput, "id,name\n1,alice\n2,bob\n", `{"id":"1","name":"alice"}` + "\n" + `{"id":"2","name":"bob"}` + "\n"},
+		{"select name, id from S3Object", csvInput, jsonOutputComma, "id,name\n1,alice\n", `{"name":"alice","id":"1"},`},
+		{"select s.name from S3Object s", linesInput, jsonOutput, `{"name":"alice"}` + "\n" + `{"name":"bob"}`, `{"name":"alice"}` + "\n" + `{"name":"bob"}` + "\n"},
+		{"select s.a.b[0] from S3Object s", documentInput, csvOutput, document, "10\n30\n"},
+		{"select s.name from S3Object s where s.a.b[0] > 15", documentInput, csvOutput, document, "bob\n"},
+		{"select s.name, s.a.b[1] from S3Object s", documentInput, jsonOutput, documentArray, `{"name":"alice","_2":20}` + "\n" + `{"name":"bob"}` + "\n"},
+		{"select s.a from S3Object s where s.a.b[0] = 30", documentInput, jsonOutput, documentArray, `{"a":{"b":[30]}}` + "\n"},
+		{"select count(*) from S3Object", documentInput, jsonOutput, documentArray, `{"_1":2}` + "\n"},
+	}
+	for i, table := range tables {
+		request := `<SelectObjectContentRequest><Expression>` + table.expression +
+			`</Expression><ExpressionType>SQL</ExpressionType>` + table.input + table.output +
+			`</SelectObjectContentRequest>`
+		if out := runSelect(t, request, table.data); out != table.expected {
+			t.Errorf("Test %d: expected %q, got %q", i+1, table.expected, out)
+		}
+	}
+}
+
+// TestCleanExpr checks that names, literals and the special forms of CAST
+// and EXTRACT are rewritten for the select parser.
+func TestCleanExpr(t *testing.T) {
+	tables := []struct {
+		expr     string
+		expected string
+	}{
+		{`select "Last Name" from S3Object where "Last Name" = 'O''Brien "x"'`, "select `Last Name` from S3Object where `Last Name` = 'O''Brien \"x\"'"},
+		{"select CAST(s.a AS INT) from S3Object s", "select `cast`(s.a, 'INT') from S3Object s"},
+		{"select cast(s.a as decimal(10, 2)) from S3Object s", "select `cast`(s.a, 'decimal(10, 2)') from S3Object s"},
+		{"select extract(year from cast(s.d as timestamp)) from S3Object s", "select `extract`(year, `cast`(s.d, 'timestamp')) from S3Object s"},
+		{"select 'cast(x as int)', s.broadcast from S3Object s", "select 'cast(x as int)', s.broadcast from S3Object s"},
+	}
+	for i, table := range tables {
+		if out := cleanExpr(table.expr); out != table.expected {
+			t.Errorf("Test %d: expected %s, got %s", i+1, table.expected, out)
+		}
+	}
+}
+
+// TestEvaluate is the conformance table of the SQL evaluator, the results
+// are given as written in JSON records, MISSING values being empty.
+func TestEvaluate(t *testing.T) {
+	defer func(now func() time.Time) { utcNow = now }(utcNow)
+	utcNow = func() time.Time {
+		return time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	}
+	const record = `{"name":"alice","age":30,"score":"12.5","nothing":null,"born":"1988-03-04T05:06:07Z","tags":["a","b"],"flag":true}`
+
+	tables := []struct {
+		expr     string
+		expected string
+		err      error
+	}{
+		// Literals and columns.
+		{"1", "1", nil},
+		{"1.50", "1.5", nil},
+		{"'x'", `"x"`, nil},
+		{"null", "null", nil},
+		{"true", "true", nil},
+		{"s.name", `"alice"`, nil},
+		{"s.tags", `["a","b"]`, nil},
+		{"s.nothing", "null", nil},
+		{"s.missing", "", nil},
+		// Arithmetic.
+		{"s.age + 1", "31", nil},
+		{"7 / 2", "3", nil},
+		{"7.0 / 2", "3.5", nil},
+		{"0.1 + 0.2", "0.3", nil},
+		{"1.0 / 3", "0.333333333333333333", nil},
+		{"s.score * 2", "25", nil},
+		{"s.age % 7", "2", nil},
+		{"-s.age", "-30", nil},
+		{"1 / 0", "null", nil},
+		{"s.nothing + 1", "null", nil},
+		{"9223372036854775807 + 1", "", ErrIntegerOverflow},
+		{"s.name + 1", "", ErrInvalidDataType},
+		// Comparisons.
+		{"s.name = 'alice'", "true", nil},
+		{"s.name != 'bob'", "true", nil},
+		{"s.age > 25", "true", nil},
+		{"s.score > 12", "true", nil},
+		{"s.score < 9", "false", nil},
+		{"s.born < utcnow()", "true", nil},
+		{"s.name like 'al%'", "true", nil},
+		{"s.name not like 'al%'", "false", nil},
+		{"s.nothing = 1", "null", nil},
+		{"s.missing != 1", "null", nil},
+		{"s.age in (1, 30)", "true", nil},
+		{"s.age in (1, 2)", "false", nil},
+		{"s.age in (1, null)", "null", nil},
+		{"s.age not in (1, 2)", "true", nil},
+		{"s.age not in (1, null)", "null", nil},
+		{"s.age between 20 and 40", "true", nil},
+		{"s.age not between 20 and 40", "false", nil},
+		{"s.nothing between 1 and 2", "null", nil},
+		// IS and three-valued logic.
+		{"s.nothing is null", "true", nil},
+		{"s.missing is null", "true", nil},
+		{"s.name is not null", "true", nil},
+		{"s.flag is true", "true", nil},
+		{"s.nothing is not true", "true", nil},
+		{"s.nothing = 1 and false", "false", nil},
+		{"s.nothing = 1 and true", "null", nil},
+		{"s.nothing = 1 or true", "true", nil},
+		{"s.nothing = 1 or false", "null", nil},
+		{"not (s.nothing = 1)", "null", nil},
+		{"not s.flag", "false", nil},
+		// CAST.
+		{"cast(s.score as float)", "12.5", nil},
+		{"cast(s.score as int)", "12", nil},
+		{"cast('12' as integer) + 1", "13", nil},
+		{"cast(s.score as decimal(10, 0))", "13", nil},
+		{"cast(12.345 as decimal(10, 2))", "12.35", nil},
+		{"cast(s.age as string)", `"30"`, nil},
+		{"cast('TRUE' as bool)", "true", nil},
+		{"cast(0 as boolean)", "false", nil},
+		{"cast(s.born as timestamp)", `"1988-03-04T05:06:07Z"`, nil},
+		{"cast(s.nothing as int)", "null", nil},
+		{"cast('abc' as int)", "", ErrCastFailed},
+		// COALESCE, NULLIF and strings.
+		{"coalesce(s.missing, s.nothing, s.name)", `"alice"`, nil},
+		{"coalesce(s.missing, null)", "null", nil},
+		{"nullif(s.age, 30)", "null", nil},
+		{"nullif(s.age, 1)", "30", nil},
+		{"upper(s.name)", `"ALICE"`, nil},
+		{"lower(s.nothing)", "null", nil},
+		{"char_length(s.name)", "5", nil},
+		{"trim('  x ')", `"x"`, nil},
+		{"substring(s.name, 2, 3)", `"lic"`, nil},
+		{"substring(s.name, 3)", `"ice"`, nil},
+		// Timestamps.
+		{"utcnow()", `"2018-06-01T12:00:00Z"`, nil},
+		{"to_timestamp('2018-01-02T')", `"2018-01-02T00:00:00Z"`, nil},
+		{"to_timestamp('2018-01-02T03:04:05.5+02:00')", `"2018-01-02T03:04:05.5+02:00"`, nil},
+		{"to_timestamp('yesterday')", "", ErrValueParseFailure},
+		{"extract(year from s.born)", "1988", nil},
+		{"extract(timezone_hour from to_timestamp('2018-01-02T03:04-05:30'))", "-5", nil},
+		{"date_add(day, 30, to_timestamp('2018-01-15T'))", `"2018-02-14T00:00:00Z"`, nil},
+		{"date_add(hour, -1, s.born)", `"1988-03-04T04:06:07Z"`, nil},
+		{"date_diff(year, s.born, utcnow())", "30", nil},
+		{"date_diff(year, to_timestamp('2010-12T'), to_timestamp('2011-01T'))", "0", nil},
+		{"date_diff(month, to_timestamp('2010-01-01T'), to_timestamp('2010-05T'))", "4", nil},
+		{"date_diff(day, to_timestamp('2010-01-01T23:00Z'), to_timestamp('2010-01-02T01:00Z'))", "0", nil},
+		{"date_diff(second, utcnow(), s.nothing)", "null", nil},
+	}
+	ev := &evaluator{alias: "s"}
+	for i, table := range tables {
+		stmt, err := sqlparser.Parse(cleanExpr("select " + table.expr + " from S3Object s"))
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		expr := stmt.(*sqlparser.Select).SelectExprs[0].(*sqlparser.AliasedExpr).Expr
+		v, err := ev.eval(expr, record)
+		if err != table.err {
+			t.Errorf("Test %d: %s: expected error %v, got %v", i+1, table.expr, table.err, err)
+			continue
+		}
+		if err == nil && v.JSON() != table.expected {
+			t.Errorf("Test %d: %s: expected %s, got %s", i+1, table.expr, table.expected, v.JSON())
+		}
+	}
+}
+
+// TestExpressionErrs checks the errors returned for invalid expressions
+// before any record is read.
+func TestExpressionErrs(t *testing.T) {
+	tables := []struct {
+		expression string
+		err        error
+	}{
+		{"select upper(s.name), s.age * 2 from S3Object s where s.age in (1, 2)", nil},
+		{"select unknown(s.name) from S3Object s", ErrUnsupportedSQLOperation},
+		{"select * from S3Object s where sum(s.age) > 1", ErrUnsupportedSQLOperation},
+		{"select cast(s.name as blob) from S3Object s", ErrParseExpectedTypeName},
+		{"select date_add(week, 1, s.born) from S3Object s", ErrIllegalSQLFunctionArgument},
+		{"select nullif(s.name) from S3Object s", ErrEvaluatorInvalidArguments},
+		{"select upper(*) from S3Object s", ErrParseUnsupportedCallWithStar},
+		{"select * from S3Object s where s.name regexp 'a'", ErrParseUnknownOperator},
+		{"select * from S3Object s where s.age in (select 1)", ErrUnsupportedSQLOperation},
+		{"select count(*), sum(s.age), min(upper(s.name)) from S3Object s", nil},
+		{"select count(*), s.name from S3Object s", ErrParseUnsupportedSelect},
+		{"select sum(*) from S3Object s", ErrParseUnsupportedCallWithStar},
+		{"select count(distinct s.name) from S3Object s", ErrUnsupportedSQLOperation},
+		{"select group_concat(s.name) from S3Object s", ErrUnsupportedSQLOperation},
+		{"select max(sum(s.age)) from S3Object s", ErrUnsupportedSQLOperation},
+	}
+	for i, table := range tables {
+		var req ObjectSelectRequest
+		req.Expression = table.expression
+		req.InputSerialization.JSON = &struct{ Type JSONType }{JSONLinesType}
+		f, err := New(strings.NewReader(""), 0, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, _, _, _, _, err := ParseSelect(f); err != table.err {
+			t.Errorf("Test %d: expected %v, got %v", i+1, table.err, err)
+		}
+	}
+}
+
+// TestSelectExpressions runs queries using typed expressions in the SELECT
+// list and in the WHERE clause.
+func TestSelectExpressions(t *testing.T) {
+	const csvInput = `<InputSerialization><CSV><FileHeaderInfo>USE</FileHeaderInfo></CSV></InputSerialization>`
+	const csvNoHeader = `<InputSerialization><CSV><FileHeaderInfo>NONE</FileHeaderInfo></CSV></InputSerialization>`
+	const linesInput = `<InputSerialization><JSON><Type>LINES</Type></JSON></InputSerialization>`
+	const csvOutput = `<OutputSerialization><CSV></CSV></OutputSerialization>`
+	const jsonOutput = `<OutputSerialization><JSON></JSON></OutputSerialization>`
+
+	const people = "id,name,born\n1,alice,1988-03-04T\n2,bob,\n3,carol,2001-10-10T\n"
+	const lines = `{"name":"alice","a":{"b":[10,20]}}` + "\n" + `{"name":"bob","a":{"b":[30]}}` + "\n" + `{"name":"carol","a":null}`
+
+	tables := []struct {
+		expression string
+		input      string
+		output     string
+		data       string
+		expected   string
+	}{
+		{"select name from S3Object where cast(id as int) > 1", csvInput, csvOutput, people, "bob\ncarol\n"},
+		{"select name from S3Object where id in ('1', '3')", csvInput, csvOutput, people, "alice\ncarol\n"},
+		{"select name, extract(year from to_timestamp(born)) from S3Object where born != ''", csvInput, csvOutput, people, "alice,1988\ncarol,2001\n"},
+		{"select name from S3Object where name = 'bob' or (id > 2 and name like 'c%')", csvInput, csvOutput, people, "bob\ncarol\n"},
+		{"select _2 from S3Object where _1 = '2'", csvNoHeader, csvOutput, "1,alice\n2,bob\n", "bob\n"},
+		{"select s.a from S3Object s where s.name = 'bob'", linesInput, jsonOutput, lines, `{"a":{"b":[30]}}` + "\n"},
+		{"select s.name from S3Object s where s.a.b[0] is null", linesInput, csvOutput, lines, "carol\n"},
+		{"select s.name from S3Object s where not s.a.b[0] > 15", linesInput, csvOutput, lines, "alice\n"},
+		{"select upper(s.name), s.a.b[0] * 1.5, coalesce(s.a.b[1], 0) from S3Object s", linesInput, jsonOutput, lines,
+			`{"_1":"ALICE","_2":15,"_3":20}` + "\n" + `{"_1":"BOB","_2":45,"_3":0}` + "\n" + `{"_1":"CAROL","_2":null,"_3":0}` + "\n"},
+	}
+	for i, table := range tables {
+		request := `<SelectObjectContentRequest><Expression>` + table.expression +
+			`</Expression><ExpressionType>SQL</ExpressionType>` + table.input + table.output +
+			`</SelectObjectContentRequest>`
+		if out := runSelect(t, request, table.data); out != table.expected {
+			t.Errorf("Test %d: expected %q, got %q", i+1, table.expected, out)
+		}
+	}
+}
+
+// TestSelectAggregates runs queries using aggregations over the records
+// matching the WHERE clause.
+func TestSelectAggregates(t *testing.T) {
+	const csvInput = `<InputSerialization><CSV><FileHeaderInfo>USE</FileHeaderInfo></CSV></InputSerialization>`
+	const csvNoHeader = `<InputSerialization><CSV><FileHeaderInfo>NONE</FileHeaderInfo></CSV></InputSerialization>`
+	const linesInput = `<InputSerialization><JSON><Type>LINES</Type></JSON></InputSerialization>`
+	const csvOutput = `<OutputSerialization><CSV></CSV></OutputSerialization>`
+	const jsonOutput = `<OutputSerialization><JSON></JSON></OutputSerialization>`
+
+	const people = "id,name,born,score\n1,alice,1988-03-04T,0.1\n2,bob,,0.2\n3,carol,2001-10-10T,\n"
+	const lines = `{"name":"alice","n":9007199254740993}` + "\n" + `{"name":"bob","n":9007199254740993}` + "\n" + `{"name":"carol"}`
+
+	tables := []struct {
+		expression string
+		input      string
+		output     string
+		data       string
+		expected   string
+	}{
+		{"select count(*), count(born), sum(score) from S3Object", csvInput, csvOutput, people, "3,3,0.3\n"},
+		{"select count(*) from S3Object where name != 'bob'", csvInput, csvOutput, people, "2\n"},
+		{"select min(name), max(name) from S3Object", csvInput, csvOutput, people, "alice,carol\n"},
+		{"select max(to_timestamp(born)) from S3Object where born != ''", csvInput, csvOutput, people, "2001-10-10T00:00:00Z\n"},
+		{"select avg(cast(id as int)), sum(id) from S3Object where id > 1", csvInput, csvOutput, people, "2.5,5\n"},
+		{"select count(*), sum(id), min(name) from S3Object where id > 5", csvInput, csvOutput, people, "0,,\n"},
+		{"select count(*), sum(id), min(name) from S3Object where id > 5", csvInput, jsonOutput, people, `{"_1":0,"_2":null,"_3":null}` + "\n"},
+		{"select count(*) from S3Object limit 1", csvInput, csvOutput, people, "3\n"},
+		{"select sum(_1) from S3Object", csvNoHeader, csvOutput, "1,alice\n2,bob\n", "3\n"},
+		{"select sum(s.n), count(s.n), max(s.n) from S3Object s", linesInput, jsonOutput, lines, `{"_1":18014398509481986,"_2":2,"_3":9007199254740993}` + "\n"},
+	}
+	for i, table := range tables {
+		request := `<SelectObjectContentRequest><Expression>` + table.expression +
+			`</Expression><ExpressionType>SQL</ExpressionType>` + table.input + table.output +
+			`</SelectObjectContentRequest>`
+		if out := runSelect(t, request, table.data); out != table.expected {
+			t.Errorf("Test %d: expected %q, got %q", i+1, table.expected, out)
+		}
+	}
+}
+
+// TestScanRange checks that the records starting within a scan range are
+// returned, and that consecutive ranges return every record once.
+func TestScanRange(t *testing.T) {
+	const csvInput = `<InputSerialization><CSV><FileHeaderInfo>USE</FileHeaderInfo></CSV></InputSerialization>`
+	const csvCRLF = `<InputSerialization><CSV><FileHeaderInfo>NONE</FileHeaderInfo><RecordDelimiter>` + "\r\n" + `</RecordDelimiter></CSV></InputSerialization>`
+	const linesInput = `<InputSerialization><JSON><Type>LINES</Type></JSON></InputSerialization>`
+	const csvOutput = `<OutputSerialization><CSV></CSV></OutputSerialization>`
+
+	// Records start at offsets 8, 16, 22 and 30.
+	const people = "id,name\n1,alice\n2,bob\n3,carol\n4,dave\n"
+	const lines = `{"n":1}` + "\n" + `{"n":2}` + "\n" + `{"n":3}` + "\n"
+	const crlf = "1,a\r\n2,b\r\n3,c"
+
+	tables := []struct {
+		input    string
+		data     string
+		scan     string
+		expected string
+	}{
+		{csvInput, people, "<Start>0</Start><End>7</End>", ""},
+		{csvInput, people, "<Start>0</Start><End>8</End>", "alice\n"},
+		{csvInput, people, "<Start>8</Start><End>16</End>", "alice\nbob\n"},
+		{csvInput, people, "<Start>9</Start><End>16</End>", "bob\n"},
+		{csvInput, people, "<Start>9</Start><End>15</End>", ""},
+		{csvInput, people, "<Start>17</Start>", "carol\ndave\n"},
+		{csvInput, people, "<End>7</End>", "dave\n"},
+		{csvInput, people, "<Start>100</Start>", ""},
+		{csvInput, people, "<Start>22</Start><End>22</End>", "carol\n"},
+		{linesInput, lines, "<Start>1</Start><End>8</End>", "2\n"},
+		{linesInput, lines, "<Start>8</Start><End>100</End>", "2\n3\n"},
+		{csvCRLF, crlf, "<Start>5</Start><End>5</End>", "b\n"},
+		{csvCRLF, crlf, "<Start>4</Start><End>10</End>", "b\nc\n"},
+		{csvCRLF, crlf, "<Start>1</Start><End>4</End>", ""},
+	}
+	for i, table := range tables {
+		expression := "select name from S3Object"
+		if table.input == linesInput {
+			expression = "select s.n from S3Object s"
+		} else if table.input == csvCRLF {
+			expression = "select _2 from S3Object"
+		}
+		request := `<SelectObjectContentRequest><Expression>` + expression +
+			`</Expression><ExpressionType>SQL</ExpressionType>` + table.input + csvOutput +
+			`<ScanRange>` + table.scan + `</ScanRange></SelectObjectContentRequest>`
+		if out := runSelect(t, request, table.data); out != table.expected {
+			t.Errorf("Test %d: expected %q, got %q", i+1, table.expected, out)
+		}
+	}
+
+	for size := int64(1); size <= int64(len(people)); size++ {
+		var out string
+		for start := int64(0); start < int64(len(people)); start += size {
+			request := `<SelectObjectContentRequest><Expression>select id from S3Object</Expression>` +
+				`<ExpressionType>SQL</ExpressionType>` + csvInput + csvOutput +
+				fmt.Sprintf("<ScanRange><Start>%d</Start><End>%d</End></ScanRange>", start, start+size-1) +
+				`</SelectObjectContentRequest>`
+			out += runSelect(t, request, people)
+		}
+		if out != "1\n2\n3\n4\n" {
+			t.Errorf("Ranges of %d bytes: expected all the records once, got %q", size, out)
+		}
+	}
+}
+
+// TestScanRangeErrs checks the scan ranges which are rejected.
+func TestScanRangeErrs(t *testing.T) {
+	start, end := int64(10), int64(5)
+	negative := int64(-1)
+	tables := []struct {
+		compression SelectCompressionType
+		jsonType    JSONType
+		start, end  *int64
+		err         error
+	}{
+		{SelectCompressionNONE, "", &start, &end, ErrInvalidScanRange},
+		{SelectCompressionNONE, "", &negative, nil, ErrInvalidScanRange},
+		{SelectCompressionGZIP, "", nil, &end, ErrInvalidScanRange},
+		{SelectCompressionNONE, JSONTypeDocument, nil, &end, ErrInvalidScanRange},
+		{SelectCompressionNONE, JSONLinesType, &end, &start, nil},
+	}
+	for i, table := range tables {
+		var req ObjectSelectRequest
+		req.Expression = "select * from S3Object"
+		req.InputSerialization.CompressionType = table.compression
+		req.InputSerialization.JSON = &struct{ Type JSONType }{table.jsonType}
+		req.ScanRange = &struct {
+			Start *int64
+			End   *int64
+		}{table.start, table.end}
+		if _, err := New(strings.NewReader("{}\n"), 3, req); err != table.err {
+			t.Errorf("Test %d: expected %v, got %v", i+1, table.err, err)
+		}
+	}
+}
+
+// TestCompressedInput checks zstd and snappy compressed objects.
+func TestCompressedInput(t *testing.T) {
+	const data = "id,name\n1,alice\n2,bob\n"
+	var zstdData, snappyData bytes.Buffer
+	zw, err := zstd.NewWriter(&zstdData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw.Write([]byte(data))
+	zw.Close()
+	sw := snappy.NewWriter(&snappyData)
+	sw.Write([]byte(data))
+	sw.Close()
+
+	tables := []struct {
+		compression string
+		data        string
+	}{
+		{"ZSTD", zstdData.String()},
+		{"SNAPPY", snappyData.String()},
+		{"NONE", data},
+	}
+	for i, table := range tables {
+		request := `<SelectObjectContentRequest><Expression>select name from S3Object where id = '2'</Expression>` +
+			`<ExpressionType>SQL</ExpressionType><InputSerialization><CompressionType>` + table.compression +
+			`</CompressionType><CSV><FileHeaderInfo>USE</FileHeaderInfo></CSV></InputSerialization>` +
+			`<OutputSerialization><CSV></CSV></OutputSerialization></SelectObjectContentRequest>`
+		if out := runSelect(t, request, table.data); out != "bob\n" {
+			t.Errorf("Test %d: expected %q, got %q", i+1, "bob\n", out)
+		}
+	}
 
+	var req ObjectSelectRequest
+	req.Expression = "select * from S3Object"
+	req.InputSerialization.CompressionType = "LZ4"
+	req.InputSerialization.JSON = &struct{ Type JSONType }{JSONLinesType}
+	if _, err := New(strings.NewReader(""), 0, req); err != ErrInvalidCompressionFormat {
+		t.Errorf("expected %v, got %v", ErrInvalidCompressionFormat, err)
 	}
 }
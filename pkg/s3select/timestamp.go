@@ -0,0 +1,135 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3select
+
+import (
+	"strings"
+	"time"
+)
+
+// timestampLayouts are the accepted forms of timestamps, from a year such as
+// 2018T up to a time with fractional seconds and a time zone offset. Times
+// without offset are in UTC.
+var timestampLayouts = []string{
+	"2006T",
+	"2006-01T",
+	"2006-01-02T",
+	"2006-01-02",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02T15:04:05",
+}
+
+// utcNow returns the current time, it is replaced in tests.
+var utcNow = func() time.Time {
+	return time.Now().UTC()
+}
+
+// parseTimestamp parses a timestamp written in one of timestampLayouts.
+func parseTimestamp(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, ErrValueParseFailure
+}
+
+// formatTimestamp formats a timestamp as returned in output records.
+func formatTimestamp(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// extractPart returns a field of a timestamp for EXTRACT.
+func extractPart(part string, t time.Time) (int64, error) {
+	switch part {
+	case "year":
+		return int64(t.Year()), nil
+	case "month":
+		return int64(t.Month()), nil
+	case "day":
+		return int64(t.Day()), nil
+	case "hour":
+		return int64(t.Hour()), nil
+	case "minute":
+		return int64(t.Minute()), nil
+	case "second":
+		return int64(t.Second()), nil
+	case "timezone_hour":
+		_, offset := t.Zone()
+		return int64(offset / 3600), nil
+	case "timezone_minute":
+		_, offset := t.Zone()
+		return int64(offset % 3600 / 60), nil
+	}
+	return 0, ErrIllegalSQLFunctionArgument
+}
+
+// dateAdd adds n times the given part to a timestamp for DATE_ADD.
+func dateAdd(part string, n int64, t time.Time) (time.Time, error) {
+	switch part {
+	case "year":
+		return t.AddDate(int(n), 0, 0), nil
+	case "month":
+		return t.AddDate(0, int(n), 0), nil
+	case "day":
+		return t.AddDate(0, 0, int(n)), nil
+	case "hour":
+		return t.Add(time.Duration(n) * time.Hour), nil
+	case "minute":
+		return t.Add(time.Duration(n) * time.Minute), nil
+	case "second":
+		return t.Add(time.Duration(n) * time.Second), nil
+	}
+	return time.Time{}, ErrIllegalSQLFunctionArgument
+}
+
+// dateDiff returns the number of whole parts elapsed from a to b for
+// DATE_DIFF, it is negative when b is before a.
+func dateDiff(part string, a, b time.Time) (int64, error) {
+	a, b = a.UTC(), b.UTC()
+	var unit time.Duration
+	switch part {
+	case "year", "month":
+		months := (b.Year()-a.Year())*12 + int(b.Month()) - int(a.Month())
+		// Only months which are entirely elapsed are counted.
+		shifted := a.AddDate(0, months, 0)
+		switch {
+		case months > 0 && shifted.After(b):
+			months--
+		case months < 0 && shifted.Before(b):
+			months++
+		}
+		if part == "year" {
+			return int64(months / 12), nil
+		}
+		return int64(months), nil
+	case "day":
+		unit = 24 * time.Hour
+	case "hour":
+		unit = time.Hour
+	case "minute":
+		unit = time.Minute
+	case "second":
+		unit = time.Second
+	default:
+		return 0, ErrIllegalSQLFunctionArgument
+	}
+	return int64(b.Sub(a) / unit), nil
+}
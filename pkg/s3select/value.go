@@ -0,0 +1,548 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3select
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// maxDecimalScale is the maximum number of fractional digits of decimals
+// which cannot be represented exactly, such as the result of 1.0 / 3.
+const maxDecimalScale = 18
+
+// valueKind is the type of a value computed by the SQL evaluator.
+type valueKind int
+
+// Kinds of values, MISSING is the value of a path which is not found in a
+// record while NULL is an explicit null.
+const (
+	kindMissing valueKind = iota
+	kindNull
+	kindBool
+	kindInt
+	kindFloat
+	kindDecimal
+	kindString
+	kindTimestamp
+)
+
+// value is the result of the evaluation of an expression against a record.
+type value struct {
+	kind valueKind
+	b    bool
+	i    int64
+	f    float64
+	d    *big.Rat
+	s    string
+	t    time.Time
+	// raw holds the JSON of the objects and arrays found in records, they
+	// are otherwise handled as strings.
+	raw string
+}
+
+func nullValue() value                 { return value{kind: kindNull} }
+func boolValue(b bool) value           { return value{kind: kindBool, b: b} }
+func intValue(i int64) value           { return value{kind: kindInt, i: i} }
+func floatValue(f float64) value       { return value{kind: kindFloat, f: f} }
+func decimalValue(d *big.Rat) value    { return value{kind: kindDecimal, d: d} }
+func stringValue(s string) value       { return value{kind: kindString, s: s} }
+func timestampValue(t time.Time) value { return value{kind: kindTimestamp, t: t} }
+
+// isNull returns true for NULL and MISSING values.
+func (v value) isNull() bool {
+	return v.kind == kindNull || v.kind == kindMissing
+}
+
+func (v value) isNumber() bool {
+	return v.kind == kindInt || v.kind == kindFloat || v.kind == kindDecimal
+}
+
+// float returns the value of a number as a float64.
+func (v value) float() float64 {
+	switch v.kind {
+	case kindInt:
+		return float64(v.i)
+	case kindDecimal:
+		f, _ := v.d.Float64()
+		return f
+	}
+	return v.f
+}
+
+// rat returns the exact value of a number, nil is returned for infinite
+// floats.
+func (v value) rat() *big.Rat {
+	switch v.kind {
+	case kindInt:
+		return new(big.Rat).SetInt64(v.i)
+	case kindFloat:
+		return new(big.Rat).SetFloat64(v.f)
+	}
+	return v.d
+}
+
+// valueFromJSON converts a value found in a record.
+func valueFromJSON(r gjson.Result) value {
+	switch r.Type {
+	case gjson.Null:
+		if !r.Exists() {
+			return value{kind: kindMissing}
+		}
+		return nullValue()
+	case gjson.False:
+		return boolValue(false)
+	case gjson.True:
+		return boolValue(true)
+	case gjson.Number:
+		if i, err := strconv.ParseInt(r.Raw, 10, 64); err == nil {
+			return intValue(i)
+		}
+		return floatValue(r.Num)
+	case gjson.String:
+		return stringValue(r.Str)
+	}
+	return value{kind: kindString, s: r.Raw, raw: r.Raw}
+}
+
+// parseNumber returns the number written in s, integers are returned as INT
+// and other numbers as FLOAT.
+func parseNumber(s string) (value, bool) {
+	s = strings.TrimSpace(s)
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return intValue(i), true
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return floatValue(f), true
+	}
+	return value{}, false
+}
+
+// parseDecimal returns the exact value of the decimal number written in s.
+func parseDecimal(s string) (*big.Rat, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.ContainsAny(s, "/") {
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// toNumber returns the value as a number, strings such as the values of CSV
+// records are parsed.
+func (v value) toNumber() (value, bool) {
+	switch {
+	case v.isNumber():
+		return v, true
+	case v.kind == kindString && v.raw == "":
+		return parseNumber(v.s)
+	}
+	return value{}, false
+}
+
+// toTimestamp returns the value as a timestamp, strings are parsed.
+func (v value) toTimestamp() (time.Time, bool) {
+	switch v.kind {
+	case kindTimestamp:
+		return v.t, true
+	case kindString:
+		t, err := parseTimestamp(v.s)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// toBool returns the value as a boolean, the strings true and false are
+// accepted.
+func (v value) toBool() (bool, bool) {
+	switch v.kind {
+	case kindBool:
+		return v.b, true
+	case kindString:
+		switch strings.ToLower(strings.TrimSpace(v.s)) {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+// String returns the value as written in CSV output records, NULL and
+// MISSING values are empty.
+func (v value) String() string {
+	switch v.kind {
+	case kindBool:
+		return strconv.FormatBool(v.b)
+	case kindInt:
+		return strconv.FormatInt(v.i, 10)
+	case kindFloat:
+		return strconv.FormatFloat(v.f, 'f', -1, 64)
+	case kindDecimal:
+		return decimalString(v.d)
+	case kindString:
+		return v.s
+	case kindTimestamp:
+		return formatTimestamp(v.t)
+	}
+	return ""
+}
+
+// JSON returns the value as written in JSON output records, MISSING values
+// are empty so that they are left out.
+func (v value) JSON() string {
+	switch v.kind {
+	case kindMissing:
+		return ""
+	case kindNull:
+		return "null"
+	case kindFloat:
+		if math.IsInf(v.f, 0) || math.IsNaN(v.f) {
+			return "null"
+		}
+	case kindString, kindTimestamp:
+		if v.raw != "" {
+			return v.raw
+		}
+		out, _ := json.Marshal(v.String())
+		return string(out)
+	}
+	return v.String()
+}
+
+// decimalString formats a decimal with the fractional digits needed to
+// represent it exactly, up to maxDecimalScale.
+func decimalString(d *big.Rat) string {
+	if d.IsInt() {
+		return d.Num().String()
+	}
+	ten := big.NewInt(10)
+	pow := big.NewInt(1)
+	rem := new(big.Int)
+	for scale := 1; scale < maxDecimalScale; scale++ {
+		pow.Mul(pow, ten)
+		if rem.Mod(pow, d.Denom()).Sign() == 0 {
+			return d.FloatString(scale)
+		}
+	}
+	return strings.TrimRight(strings.TrimRight(d.FloatString(maxDecimalScale), "0"), ".")
+}
+
+// compareValues returns the order of a and b, ok is false if they cannot be
+// compared. Strings are converted to the type of the other operand, so that
+// the values of CSV records can be compared to numbers or timestamps.
+func compareValues(a, b value) (cmp int, ok bool) {
+	if a.isNull() || b.isNull() {
+		return 0, false
+	}
+	if a.kind == kindString && b.kind != kindString {
+		b, a = a, b
+		defer func() { cmp = -cmp }()
+	}
+	if b.kind == kindString && a.kind != kindString {
+		written := b.s
+		var converted bool
+		switch {
+		case a.isNumber():
+			b, converted = b.toNumber()
+		case a.kind == kindTimestamp:
+			var t time.Time
+			t, converted = b.toTimestamp()
+			b = timestampValue(t)
+		case a.kind == kindBool:
+			var v bool
+			v, converted = b.toBool()
+			b = boolValue(v)
+		}
+		if !converted {
+			// Compared as they are written.
+			return strings.Compare(a.String(), written), true
+		}
+	}
+	switch {
+	case a.isNumber() && b.isNumber():
+		return compareNumbers(a, b), true
+	case a.kind != b.kind:
+		return 0, false
+	case a.kind == kindString:
+		return strings.Compare(a.s, b.s), true
+	case a.kind == kindTimestamp:
+		switch {
+		case a.t.Before(b.t):
+			return -1, true
+		case a.t.After(b.t):
+			return 1, true
+		}
+		return 0, true
+	case a.kind == kindBool:
+		switch {
+		case a.b == b.b:
+			return 0, true
+		case b.b:
+			return -1, true
+		}
+		return 1, true
+	}
+	return 0, false
+}
+
+func compareNumbers(a, b value) int {
+	switch {
+	case a.kind == kindInt && b.kind == kindInt:
+		switch {
+		case a.i < b.i:
+			return -1
+		case a.i > b.i:
+			return 1
+		}
+		return 0
+	case a.kind == kindFloat || b.kind == kindFloat:
+		x, y := a.float(), b.float()
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return a.rat().Cmp(b.rat())
+}
+
+// arithmetic applies a binary arithmetic operator. Operations on INT values
+// stay INT, division truncating the result, and DECIMAL values are computed
+// exactly. Dividing by zero yields NULL.
+func arithmetic(op string, a, b value) (value, error) {
+	if a.isNull() || b.isNull() {
+		return nullValue(), nil
+	}
+	a, okA := a.toNumber()
+	b, okB := b.toNumber()
+	if !okA || !okB {
+		return value{}, ErrInvalidDataType
+	}
+	switch {
+	case a.kind == kindInt && b.kind == kindInt:
+		return intArithmetic(op, a.i, b.i)
+	case a.kind == kindFloat || b.kind == kindFloat:
+		x, y := a.float(), b.float()
+		switch op {
+		case "+":
+			return floatValue(x + y), nil
+		case "-":
+			return floatValue(x - y), nil
+		case "*":
+			return floatValue(x * y), nil
+		case "/":
+			if y == 0 {
+				return nullValue(), nil
+			}
+			return floatValue(x / y), nil
+		case "%":
+			if y == 0 {
+				return nullValue(), nil
+			}
+			return floatValue(math.Mod(x, y)), nil
+		}
+	default:
+		x, y := a.rat(), b.rat()
+		switch op {
+		case "+":
+			return decimalValue(new(big.Rat).Add(x, y)), nil
+		case "-":
+			return decimalValue(new(big.Rat).Sub(x, y)), nil
+		case "*":
+			return decimalValue(new(big.Rat).Mul(x, y)), nil
+		case "/":
+			if y.Sign() == 0 {
+				return nullValue(), nil
+			}
+			return decimalValue(new(big.Rat).Quo(x, y)), nil
+		case "%":
+			if y.Sign() == 0 {
+				return nullValue(), nil
+			}
+			q := new(big.Rat).Quo(x, y)
+			q.SetInt(new(big.Int).Quo(q.Num(), q.Denom()))
+			return decimalValue(q.Sub(x, q.Mul(q, y))), nil
+		}
+	}
+	return value{}, ErrParseUnknownOperator
+}
+
+func intArithmetic(op string, x, y int64) (value, error) {
+	switch op {
+	case "+":
+		r := x + y
+		if (r > x) != (y > 0) {
+			return value{}, ErrIntegerOverflow
+		}
+		return intValue(r), nil
+	case "-":
+		r := x - y
+		if (r < x) != (y > 0) {
+			return value{}, ErrIntegerOverflow
+		}
+		return intValue(r), nil
+	case "*":
+		if x == 0 || y == 0 {
+			return intValue(0), nil
+		}
+		r := x * y
+		if r/y != x || (x == -1 && y == math.MinInt64) || (y == -1 && x == math.MinInt64) {
+			return value{}, ErrIntegerOverflow
+		}
+		return intValue(r), nil
+	case "/", "%":
+		if y == 0 {
+			return nullValue(), nil
+		}
+		if x == math.MinInt64 && y == -1 {
+			if op == "%" {
+				return intValue(0), nil
+			}
+			return value{}, ErrIntegerOverflow
+		}
+		if op == "/" {
+			return intValue(x / y), nil
+		}
+		return intValue(x % y), nil
+	}
+	return value{}, ErrParseUnknownOperator
+}
+
+// castTypes are the types accepted by CAST.
+var castTypes = map[string]valueKind{
+	"BOOL":      kindBool,
+	"BOOLEAN":   kindBool,
+	"INT":       kindInt,
+	"INTEGER":   kindInt,
+	"FLOAT":     kindFloat,
+	"REAL":      kindFloat,
+	"DOUBLE":    kindFloat,
+	"DECIMAL":   kindDecimal,
+	"NUMERIC":   kindDecimal,
+	"STRING":    kindString,
+	"VARCHAR":   kindString,
+	"CHAR":      kindString,
+	"TIMESTAMP": kindTimestamp,
+}
+
+// parseCastType returns the kind of a CAST type, such as INT or
+// DECIMAL(10, 2). The scale of decimals is -1 when it is not given.
+func parseCastType(typ string) (kind valueKind, scale int, ok bool) {
+	typ = strings.ToUpper(strings.TrimSpace(typ))
+	scale = -1
+	if i := strings.IndexByte(typ, '('); i >= 0 && strings.HasSuffix(typ, ")") {
+		params := strings.Split(typ[i+1:len(typ)-1], ",")
+		typ = strings.TrimSpace(typ[:i])
+		if castTypes[typ] != kindDecimal || len(params) > 2 {
+			return 0, 0, false
+		}
+		for _, p := range params {
+			if _, err := strconv.ParseUint(strings.TrimSpace(p), 10, 8); err != nil {
+				return 0, 0, false
+			}
+		}
+		if len(params) == 2 {
+			scale, _ = strconv.Atoi(strings.TrimSpace(params[1]))
+		}
+	}
+	kind, ok = castTypes[typ]
+	return kind, scale, ok
+}
+
+// castValue converts a value to the given CAST type, NULL and MISSING values
+// are cast to NULL.
+func castValue(v value, typ string) (value, error) {
+	kind, scale, ok := parseCastType(typ)
+	if !ok {
+		return value{}, ErrParseExpectedTypeName
+	}
+	if v.isNull() {
+		return nullValue(), nil
+	}
+	switch kind {
+	case kindBool:
+		if v.isNumber() {
+			return boolValue(v.float() != 0), nil
+		}
+		if b, ok := v.toBool(); ok {
+			return boolValue(b), nil
+		}
+	case kindInt:
+		if v.kind == kindBool {
+			if v.b {
+				return intValue(1), nil
+			}
+			return intValue(0), nil
+		}
+		n, ok := v.toNumber()
+		if !ok {
+			break
+		}
+		switch n.kind {
+		case kindInt:
+			return n, nil
+		case kindFloat:
+			if math.IsNaN(n.f) || n.f >= math.MaxInt64 || n.f < math.MinInt64 {
+				return value{}, ErrIntegerOverflow
+			}
+			return intValue(int64(n.f)), nil
+		case kindDecimal:
+			i := new(big.Int).Quo(n.d.Num(), n.d.Denom())
+			if !i.IsInt64() {
+				return value{}, ErrIntegerOverflow
+			}
+			return intValue(i.Int64()), nil
+		}
+	case kindFloat:
+		if n, ok := v.toNumber(); ok {
+			return floatValue(n.float()), nil
+		}
+	case kindDecimal:
+		var d *big.Rat
+		switch {
+		case v.kind == kindInt || v.kind == kindDecimal:
+			d = v.rat()
+		case v.kind == kindFloat:
+			d, _ = parseDecimal(strconv.FormatFloat(v.f, 'f', -1, 64))
+		case v.kind == kindString && v.raw == "":
+			d, _ = parseDecimal(v.s)
+		}
+		if d == nil {
+			break
+		}
+		if scale >= 0 {
+			d, _ = parseDecimal(d.FloatString(scale))
+		}
+		return decimalValue(d), nil
+	case kindString:
+		return stringValue(v.String()), nil
+	case kindTimestamp:
+		if t, ok := v.toTimestamp(); ok {
+			return timestampValue(t), nil
+		}
+	}
+	return value{}, ErrCastFailed
+}
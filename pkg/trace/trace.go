@@ -0,0 +1,57 @@
+/*
+ * Minio Cloud Storage, (C) 2018 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package trace
+
+import (
+	"net/http"
+	"time"
+)
+
+// Info - represents the trace record of an HTTP request served by a node.
+type Info struct {
+	NodeName  string       `json:"nodename"`
+	FuncName  string       `json:"funcname"`
+	ReqInfo   RequestInfo  `json:"request"`
+	RespInfo  ResponseInfo `json:"response"`
+	CallStats CallStats    `json:"stats"`
+}
+
+// RequestInfo - represents the HTTP request of a trace record.
+type RequestInfo struct {
+	Time     time.Time   `json:"time"`
+	Method   string      `json:"method"`
+	Host     string      `json:"host,omitempty"`
+	Path     string      `json:"path,omitempty"`
+	RawQuery string      `json:"rawquery,omitempty"`
+	Headers  http.Header `json:"headers,omitempty"`
+	Body     []byte      `json:"body,omitempty"`
+}
+
+// ResponseInfo - represents the HTTP response of a trace record.
+type ResponseInfo struct {
+	Time       time.Time   `json:"time"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+	StatusCode int         `json:"statuscode,omitempty"`
+}
+
+// CallStats - represents the statistics of the HTTP call of a trace record.
+type CallStats struct {
+	Latency     time.Duration `json:"latency"`
+	InputBytes  int64         `json:"inputbytes"`
+	OutputBytes int64         `json:"outputbytes"`
+}